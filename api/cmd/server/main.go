@@ -67,6 +67,7 @@ func main() {
 	aiService := services.NewAIService()
 	digService := services.NewDigService(aiService)
 	digController := controllers.NewDigController(digService)
+	auditController := controllers.NewAuditController()
 
 	// Initialize middleware
 	authMiddleware := middleware.AuthMiddleware(apiDB, cfg.JWTSecret)
@@ -95,6 +96,7 @@ func main() {
 		exportController,
 		hunterController,
 		digController,
+		auditController,
 		authMiddleware,
 		tenantMiddleware,
 		subscriptionMiddleware,
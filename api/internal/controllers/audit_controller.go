@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ung/api/internal/database"
+	"ung/api/internal/middleware"
+)
+
+// AuditController handles the audit log endpoints
+type AuditController struct{}
+
+// NewAuditController creates a new audit controller
+func NewAuditController() *AuditController {
+	return &AuditController{}
+}
+
+// auditEntities maps accepted entity names to the names audit entries use
+var auditEntities = map[string]string{
+	"invoice": "invoice", "invoices": "invoice",
+	"line_item": "line_item", "line_items": "line_item", "invoice_line_items": "line_item",
+	"session": "session", "sessions": "session", "tracking": "session", "tracking_sessions": "session",
+	"contract": "contract", "contracts": "contract",
+	"expense": "expense", "expenses": "expense",
+}
+
+// List handles GET /api/v1/audit?entity=invoice&id=5&since=2024-01-01&limit=50
+func (c *AuditController) List(w http.ResponseWriter, r *http.Request) {
+	db := middleware.GetTenantDB(r)
+	q := r.URL.Query()
+
+	filter := database.AuditFilter{Since: q.Get("since"), Limit: 50}
+	if entity := strings.ToLower(q.Get("entity")); entity != "" {
+		name, ok := auditEntities[entity]
+		if !ok {
+			RespondError(w, "Unknown entity: "+entity, http.StatusBadRequest)
+			return
+		}
+		filter.Entity = name
+	}
+	if id := q.Get("id"); id != "" {
+		entityID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || filter.Entity == "" {
+			RespondError(w, "id must be a number and needs entity", http.StatusBadRequest)
+			return
+		}
+		filter.EntityID = entityID
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			RespondError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	if _, err := database.SealAuditLog(db); err != nil {
+		RespondError(w, "Failed to seal audit log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entries, err := database.ListAuditEntries(db, filter)
+	if err != nil {
+		RespondError(w, "Failed to fetch audit log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	RespondJSON(w, entries, http.StatusOK)
+}
+
+// Verify handles GET /api/v1/audit/verify
+func (c *AuditController) Verify(w http.ResponseWriter, r *http.Request) {
+	db := middleware.GetTenantDB(r)
+
+	if _, err := database.SealAuditLog(db); err != nil {
+		RespondError(w, "Failed to seal audit log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result, err := database.VerifyAuditLog(db)
+	if err != nil {
+		RespondError(w, "Failed to verify audit log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	RespondJSON(w, result, http.StatusOK)
+}
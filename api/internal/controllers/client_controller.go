@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 	"ung/api/internal/middleware"
 	"ung/api/internal/models"
 )
@@ -138,7 +139,17 @@ func (c *ClientController) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := db.Delete(&client).Error; err != nil {
+	// The billing profile goes with the client
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", client.ID).Delete(&models.ClientContact{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", client.ID).Delete(&models.ClientAddress{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&client).Error
+	})
+	if err != nil {
 		RespondError(w, "Failed to delete client: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
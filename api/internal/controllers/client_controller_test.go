@@ -207,9 +207,11 @@ func TestClientController_Delete(t *testing.T) {
 	db := SetupTestDB(t)
 	controller := NewClientController()
 
-	// Create client
+	// Create client with its billing profile
 	client := models.Client{Name: "To Delete", Email: "delete@example.com"}
 	db.Create(&client)
+	db.Create(&models.ClientContact{ClientID: client.ID, Name: "AP", Email: "ap@example.com", Role: "billing"})
+	db.Create(&models.ClientAddress{ClientID: client.ID, Kind: "billing", Address: "PO Box 1"})
 
 	req := httptest.NewRequest("DELETE", "/clients/1", nil)
 	w := httptest.NewRecorder()
@@ -228,4 +230,8 @@ func TestClientController_Delete(t *testing.T) {
 	var count int64
 	db.Model(&models.Client{}).Count(&count)
 	assert.Equal(t, int64(0), count)
+	db.Model(&models.ClientContact{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	db.Model(&models.ClientAddress{}).Count(&count)
+	assert.Equal(t, int64(0), count)
 }
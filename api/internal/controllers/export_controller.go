@@ -266,7 +266,7 @@ func (c *ExportController) ExportAllJSON(w http.ResponseWriter, r *http.Request)
 	db.Find(&goals)
 	export["goals"] = goals
 
-	var settings models.UserSettings
+	var settings models.RateSettings
 	if err := db.First(&settings).Error; err == nil {
 		export["settings"] = settings
 	}
@@ -453,7 +453,7 @@ func (c *ExportController) ImportAllJSON(w http.ResponseWriter, r *http.Request)
 		Expenses  []models.Expense         `json:"expenses"`
 		Tracking  []models.TrackingSession `json:"tracking"`
 		Goals     []models.IncomeGoal      `json:"goals"`
-		Settings  *models.UserSettings     `json:"settings"`
+		Settings  *models.RateSettings     `json:"settings"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&importData); err != nil {
@@ -2,11 +2,16 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 	"ung/api/internal/middleware"
 	"ung/api/internal/models"
 )
@@ -73,6 +78,7 @@ func (c *InvoiceController) Create(w http.ResponseWriter, r *http.Request) {
 		Status      models.InvoiceStatus        `json:"status"`
 		IssuedDate  string                      `json:"issued_date"`
 		DueDate     string                      `json:"due_date"`
+		PONumber    string                      `json:"po_number"`
 		LineItems   []models.InvoiceLineItem    `json:"line_items"`
 		ClientIDs   []uint                      `json:"client_ids"`
 	}
@@ -92,6 +98,23 @@ func (c *InvoiceController) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The first client's billing profile fills in what isn't given
+	var client models.Client
+	for i, clientID := range req.ClientIDs {
+		var recipient models.Client
+		if err := db.Preload("Addresses").First(&recipient, clientID).Error; err != nil {
+			RespondError(w, fmt.Sprintf("Client %d not found", clientID), http.StatusBadRequest)
+			return
+		}
+		if recipient.PORequired && strings.TrimSpace(req.PONumber) == "" {
+			RespondError(w, fmt.Sprintf("Client %s requires a PO number on invoices", recipient.Name), http.StatusBadRequest)
+			return
+		}
+		if i == 0 {
+			client = recipient
+		}
+	}
+
 	// Parse dates
 	issuedDate := time.Now()
 	if req.IssuedDate != "" {
@@ -100,24 +123,61 @@ func (c *InvoiceController) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	dueDate := issuedDate.AddDate(0, 0, 30) // Default 30 days
+	dueDate := client.DueDate(issuedDate) // The client's payment terms
 	if req.DueDate != "" {
 		if parsed, err := time.Parse("2006-01-02", req.DueDate); err == nil {
 			dueDate = parsed
 		}
 	}
 
+	// Without line items the amount is billed as a single line
+	items := req.LineItems
+	if len(items) == 0 && req.Amount != 0 {
+		items = []models.InvoiceLineItem{{ItemName: req.Description, Quantity: 1, Rate: req.Amount, Amount: req.Amount}}
+	}
+	for i := range items {
+		items[i].ID = 0
+		if items[i].Amount == 0 {
+			items[i].Amount = tax.Round(items[i].Quantity * items[i].Rate)
+		}
+	}
+
+	// The totals are calculated from the line items with the client's tax profile, as the CLI does
+	profile, err := tax.ResolveBuiltin(client.TaxProfile)
+	if err != nil {
+		RespondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loc := locale.Get(client.Language)
+	if loc == nil {
+		loc = locale.Get(locale.Default)
+	}
+	totals, err := profile.Apply(client, items, loc)
+	if err != nil {
+		RespondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	invoice := models.Invoice{
 		InvoiceNum:  req.InvoiceNum,
 		CompanyID:   req.CompanyID,
-		Amount:      req.Amount,
+		Amount:      totals.Gross,
+		NetAmount:   totals.Net,
+		TaxAmount:   totals.Tax,
+		TaxProfile:  totals.Profile,
+		TaxType:     totals.Type,
+		TaxNote:     totals.Note,
 		Currency:    req.Currency,
 		Description: req.Description,
 		Status:      req.Status,
 		IssuedDate:  issuedDate,
 		DueDate:     dueDate,
+		PONumber:    strings.TrimSpace(req.PONumber),
 	}
 
+	if invoice.Currency == "" {
+		invoice.Currency = client.Currency
+	}
 	if invoice.Currency == "" {
 		invoice.Currency = "USD"
 	}
@@ -125,26 +185,29 @@ func (c *InvoiceController) Create(w http.ResponseWriter, r *http.Request) {
 		invoice.Status = models.StatusPending
 	}
 
-	// Create invoice
-	if err := db.Create(&invoice).Error; err != nil {
+	// Create the invoice with its line items and recipients
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&invoice).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			item.InvoiceID = invoice.ID
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+		for _, clientID := range req.ClientIDs {
+			if err := tx.Create(&models.InvoiceRecipient{InvoiceID: invoice.ID, ClientID: clientID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		RespondError(w, "Failed to create invoice: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Create line items
-	for _, item := range req.LineItems {
-		item.InvoiceID = invoice.ID
-		db.Create(&item)
-	}
-
-	// Create recipients
-	for _, clientID := range req.ClientIDs {
-		db.Create(&models.InvoiceRecipient{
-			InvoiceID: invoice.ID,
-			ClientID:  clientID,
-		})
-	}
-
 	RespondJSON(w, invoice, http.StatusCreated)
 }
 
@@ -165,6 +228,7 @@ func (c *InvoiceController) Update(w http.ResponseWriter, r *http.Request) {
 		Description *string               `json:"description"`
 		Status      *models.InvoiceStatus `json:"status"`
 		DueDate     *string               `json:"due_date"`
+		PONumber    *string               `json:"po_number"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -190,6 +254,9 @@ func (c *InvoiceController) Update(w http.ResponseWriter, r *http.Request) {
 			invoice.DueDate = parsed
 		}
 	}
+	if req.PONumber != nil {
+		invoice.PONumber = strings.TrimSpace(*req.PONumber)
+	}
 
 	if err := db.Save(&invoice).Error; err != nil {
 		RespondError(w, "Failed to update invoice: "+err.Error(), http.StatusInternalServerError)
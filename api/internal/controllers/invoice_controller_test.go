@@ -298,6 +298,115 @@ func TestInvoiceController_Create_DefaultValues(t *testing.T) {
 	assert.Equal(t, models.StatusPending, response.Status) // Default status
 }
 
+func TestInvoiceController_Create_UsesClientBillingProfile(t *testing.T) {
+	db := SetupTestDB(t)
+	controller := NewInvoiceController()
+
+	company := models.Company{Name: "Test Company", Email: "company@test.com"}
+	db.Create(&company)
+	client := models.Client{Name: "Initech", Email: "ap@initech.test", Currency: "EUR", PaymentTerms: 14, PORequired: true}
+	db.Create(&client)
+
+	create := func(payload map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/invoices", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(WithTenantDB(req.Context(), db))
+		w := httptest.NewRecorder()
+		controller.Create(w, req)
+		return w
+	}
+
+	payload := map[string]interface{}{
+		"invoice_num": "INV-PO",
+		"company_id":  company.ID,
+		"amount":      1000.00,
+		"issued_date": "2024-01-01",
+		"client_ids":  []uint{client.ID},
+	}
+	w := create(payload)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "PO number")
+
+	payload["po_number"] = " PO-4711 "
+	w = create(payload)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response models.Invoice
+	DecodeStandardResponse(t, w.Body, &response)
+	assert.Equal(t, "EUR", response.Currency)
+	assert.Equal(t, "PO-4711", response.PONumber)
+	assert.Equal(t, "2024-01-15", response.DueDate.Format("2006-01-02"))
+}
+
+func TestInvoiceController_Create_CalculatesTax(t *testing.T) {
+	db := SetupTestDB(t)
+	controller := NewInvoiceController()
+
+	company := models.Company{Name: "Test Company", Email: "company@test.com"}
+	db.Create(&company)
+	local := models.Client{Name: "Local GmbH", Email: "ap@local.test"}
+	db.Create(&local)
+	acme := models.Client{Name: "Acme BV", Email: "ap@acme.test", TaxProfile: "reverse-charge"}
+	db.Create(&acme)
+
+	create := func(payload map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/invoices", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(WithTenantDB(req.Context(), db))
+		w := httptest.NewRecorder()
+		controller.Create(w, req)
+		return w
+	}
+
+	// Discounts and line rates make up the totals, the given amount doesn't
+	w := create(map[string]interface{}{
+		"invoice_num": "INV-TAX",
+		"company_id":  company.ID,
+		"amount":      1.00,
+		"line_items": []map[string]interface{}{
+			{"item_name": "Consulting", "quantity": 10.0, "rate": 100.00, "discount_pct": 10.0, "tax_rate": 0.19},
+			{"item_name": "Books", "quantity": 1.0, "rate": 100.00, "tax_rate": 0.07},
+		},
+		"client_ids": []uint{local.ID},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response models.Invoice
+	DecodeStandardResponse(t, w.Body, &response)
+	assert.Equal(t, 1000.00, response.NetAmount)
+	assert.Equal(t, 178.00, response.TaxAmount)
+	assert.Equal(t, 1178.00, response.Amount)
+
+	var lineItems []models.InvoiceLineItem
+	db.Where("invoice_id = ?", response.ID).Order("id").Find(&lineItems)
+	if assert.Len(t, lineItems, 2) {
+		assert.Equal(t, 1000.00, lineItems[0].Amount)
+		assert.Equal(t, 171.00, lineItems[0].TaxAmount)
+	}
+
+	// The client's profile applies, and reverse charge needs its VAT ID
+	payload := map[string]interface{}{
+		"invoice_num": "INV-RC",
+		"company_id":  company.ID,
+		"amount":      500.00,
+		"client_ids":  []uint{acme.ID},
+	}
+	w = create(payload)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "VAT ID")
+
+	db.Model(&acme).Update("tax_id", "NL123456789B01")
+	w = create(payload)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	response = models.Invoice{}
+	DecodeStandardResponse(t, w.Body, &response)
+	assert.Equal(t, 500.00, response.Amount)
+	assert.Equal(t, "reverse_charge", response.TaxType)
+	assert.NotEmpty(t, response.TaxNote)
+}
+
 func TestInvoiceController_Update(t *testing.T) {
 	db := SetupTestDB(t)
 	controller := NewInvoiceController()
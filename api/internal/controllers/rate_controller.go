@@ -72,9 +72,9 @@ func (c *RateController) Calculate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user settings for defaults
-	var settings models.UserSettings
+	var settings models.RateSettings
 	if err := db.First(&settings).Error; err != nil {
-		settings = models.UserSettings{
+		settings = models.RateSettings{
 			HoursPerWeek:      40,
 			WeeksPerYear:      48,
 			DefaultTaxPercent: 25,
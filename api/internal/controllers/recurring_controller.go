@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 	"ung/api/internal/middleware"
 	"ung/api/internal/models"
 )
@@ -30,7 +31,11 @@ func (c *RecurringController) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	RespondJSON(w, recurring, http.StatusOK)
+	response := make([]recurringResponse, len(recurring))
+	for i, rec := range recurring {
+		response[i] = newRecurringResponse(rec)
+	}
+	RespondJSON(w, response, http.StatusOK)
 }
 
 // Get handles GET /api/v1/recurring/:id
@@ -44,7 +49,7 @@ func (c *RecurringController) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	RespondJSON(w, recurring, http.StatusOK)
+	RespondJSON(w, newRecurringResponse(recurring), http.StatusOK)
 }
 
 // Create handles POST /api/v1/recurring
@@ -80,24 +85,32 @@ func (c *RecurringController) Create(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, "Amount must be greater than 0", http.StatusBadRequest)
 		return
 	}
+	var client models.Client
+	if err := db.First(&client, req.ClientID).Error; err != nil {
+		RespondError(w, "Client not found", http.StatusBadRequest)
+		return
+	}
 
 	// Calculate next run date based on frequency
 	nextRunDate := calculateNextRunDate(req.Frequency, req.DayOfMonth, req.DayOfWeek)
 
 	recurring := models.RecurringInvoice{
-		ClientID:      req.ClientID,
-		CompanyID:     req.CompanyID,
-		Amount:        req.Amount,
-		Currency:      req.Currency,
-		Description:   req.Description,
-		Frequency:     req.Frequency,
-		DayOfMonth:    req.DayOfMonth,
-		DayOfWeek:     req.DayOfWeek,
-		NextRunDate:   nextRunDate,
-		Active:        true,
-		InvoicePrefix: req.InvoicePrefix,
+		ClientID:           req.ClientID,
+		CompanyID:          &req.CompanyID,
+		Amount:             req.Amount,
+		Currency:           req.Currency,
+		Description:        req.Description,
+		Frequency:          req.Frequency,
+		DayOfMonth:         req.DayOfMonth,
+		DayOfWeek:          req.DayOfWeek,
+		NextGenerationDate: nextRunDate,
+		Active:             true,
+		InvoicePrefix:      req.InvoicePrefix,
 	}
 
+	if recurring.Currency == "" {
+		recurring.Currency = client.Currency
+	}
 	if recurring.Currency == "" {
 		recurring.Currency = "USD"
 	}
@@ -113,7 +126,7 @@ func (c *RecurringController) Create(w http.ResponseWriter, r *http.Request) {
 	// Preload relations for response
 	db.Preload("Client").Preload("Company").First(&recurring, recurring.ID)
 
-	RespondJSON(w, recurring, http.StatusCreated)
+	RespondJSON(w, newRecurringResponse(recurring), http.StatusCreated)
 }
 
 // Update handles PUT /api/v1/recurring/:id
@@ -174,7 +187,7 @@ func (c *RecurringController) Update(w http.ResponseWriter, r *http.Request) {
 			recurring.DayOfWeek = dayOfWeek
 		}
 
-		recurring.NextRunDate = calculateNextRunDate(freq, dayOfMonth, dayOfWeek)
+		recurring.NextGenerationDate = calculateNextRunDate(freq, dayOfMonth, dayOfWeek)
 	}
 
 	if err := db.Save(&recurring).Error; err != nil {
@@ -183,7 +196,7 @@ func (c *RecurringController) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	db.Preload("Client").Preload("Company").First(&recurring, recurring.ID)
-	RespondJSON(w, recurring, http.StatusOK)
+	RespondJSON(w, newRecurringResponse(recurring), http.StatusOK)
 }
 
 // Delete handles DELETE /api/v1/recurring/:id
@@ -222,7 +235,7 @@ func (c *RecurringController) Pause(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	RespondJSON(w, recurring, http.StatusOK)
+	RespondJSON(w, newRecurringResponse(recurring), http.StatusOK)
 }
 
 // Resume handles POST /api/v1/recurring/:id/resume
@@ -238,14 +251,14 @@ func (c *RecurringController) Resume(w http.ResponseWriter, r *http.Request) {
 
 	recurring.Active = true
 	// Recalculate next run date when resuming
-	recurring.NextRunDate = calculateNextRunDate(recurring.Frequency, recurring.DayOfMonth, recurring.DayOfWeek)
+	recurring.NextGenerationDate = calculateNextRunDate(recurring.Frequency, recurring.DayOfMonth, recurring.DayOfWeek)
 
 	if err := db.Save(&recurring).Error; err != nil {
 		RespondError(w, "Failed to resume recurring invoice: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	RespondJSON(w, recurring, http.StatusOK)
+	RespondJSON(w, newRecurringResponse(recurring), http.StatusOK)
 }
 
 // Generate handles POST /api/v1/recurring/generate - generates all due recurring invoices
@@ -254,52 +267,32 @@ func (c *RecurringController) Generate(w http.ResponseWriter, r *http.Request) {
 
 	now := time.Now()
 	var dueRecurring []models.RecurringInvoice
-	if err := db.Where("active = ? AND next_run_date <= ?", true, now).Find(&dueRecurring).Error; err != nil {
+	if err := db.Where("active = ? AND next_generation_date <= ?", true, now).Find(&dueRecurring).Error; err != nil {
 		RespondError(w, "Failed to fetch due recurring invoices: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	generated := 0
 	var createdInvoices []models.Invoice
+	needPO := []string{} // Invoices of clients that require a PO number, to be set before sending
 
 	for _, rec := range dueRecurring {
-		// Generate invoice number
-		invoiceNum := fmt.Sprintf("%s-%d-%03d", rec.InvoicePrefix, time.Now().Year(), rec.TotalGenerated+1)
-
-		invoice := models.Invoice{
-			InvoiceNum:  invoiceNum,
-			CompanyID:   rec.CompanyID,
-			Amount:      rec.Amount,
-			Currency:    rec.Currency,
-			Description: rec.Description,
-			Status:      models.StatusPending,
-			IssuedDate:  now,
-			DueDate:     now.AddDate(0, 0, 30),
-		}
-
-		if err := db.Create(&invoice).Error; err != nil {
+		invoice, client, err := generateRecurringInvoice(db, rec, now)
+		if err != nil {
 			continue
 		}
-
-		// Create invoice recipient
-		db.Create(&models.InvoiceRecipient{
-			InvoiceID: invoice.ID,
-			ClientID:  rec.ClientID,
-		})
-
-		// Update recurring invoice
-		rec.LastRunDate = &now
-		rec.NextRunDate = calculateNextRunDate(rec.Frequency, rec.DayOfMonth, rec.DayOfWeek)
-		rec.TotalGenerated++
-		db.Save(&rec)
+		if client.PORequired {
+			needPO = append(needPO, invoice.InvoiceNum)
+		}
 
 		createdInvoices = append(createdInvoices, invoice)
 		generated++
 	}
 
 	response := map[string]interface{}{
-		"generated": generated,
-		"invoices":  createdInvoices,
+		"generated":       generated,
+		"invoices":        createdInvoices,
+		"needs_po_number": needPO,
 	}
 
 	RespondJSON(w, response, http.StatusOK)
@@ -316,25 +309,65 @@ func (c *RecurringController) GenerateSingle(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	now := time.Now()
+	invoice, _, err := generateRecurringInvoice(db, rec, time.Now())
+	if err != nil {
+		RespondError(w, "Failed to create invoice: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	RespondJSON(w, invoice, http.StatusCreated)
+}
+
+// recurringResponse is a recurring invoice as the API returns it. The API
+// named the schedule fields next_run_date, last_run_date and total_generated
+// before it shared pkg/models with the CLI, and clients still use those names.
+type recurringResponse struct {
+	models.RecurringInvoice
+	NextRunDate    time.Time  `json:"next_run_date"`
+	LastRunDate    *time.Time `json:"last_run_date"`
+	TotalGenerated int        `json:"total_generated"`
+
+	// Hide the shared model's names for the same fields
+	NextGenerationDate *struct{} `json:"next_generation_date,omitempty"`
+	LastGeneratedDate  *struct{} `json:"last_generated_date,omitempty"`
+	GeneratedCount     *struct{} `json:"generated_count,omitempty"`
+}
+
+func newRecurringResponse(rec models.RecurringInvoice) recurringResponse {
+	return recurringResponse{
+		RecurringInvoice: rec,
+		NextRunDate:      rec.NextGenerationDate,
+		LastRunDate:      rec.LastGeneratedDate,
+		TotalGenerated:   rec.GeneratedCount,
+	}
+}
+
+// recurringCompanyID returns the company that issues a recurring invoice.
+// Templates created by the CLI don't set one, they use the first company.
+// generateRecurringInvoice creates the next invoice of a recurring invoice,
+// due after the client's payment terms. Clients that require a PO number
+// get the invoice without one, it is set by hand before sending.
+func generateRecurringInvoice(db *gorm.DB, rec models.RecurringInvoice, now time.Time) (models.Invoice, models.Client, error) {
+	var client models.Client
+	if err := db.First(&client, rec.ClientID).Error; err != nil {
+		return models.Invoice{}, client, fmt.Errorf("client %d not found: %w", rec.ClientID, err)
+	}
 
 	// Generate invoice number
-	invoiceNum := fmt.Sprintf("%s-%d-%03d", rec.InvoicePrefix, time.Now().Year(), rec.TotalGenerated+1)
+	invoiceNum := fmt.Sprintf("%s-%d-%03d", rec.InvoicePrefix, now.Year(), rec.GeneratedCount+1)
 
 	invoice := models.Invoice{
 		InvoiceNum:  invoiceNum,
-		CompanyID:   rec.CompanyID,
+		CompanyID:   recurringCompanyID(db, rec),
 		Amount:      rec.Amount,
 		Currency:    rec.Currency,
 		Description: rec.Description,
 		Status:      models.StatusPending,
 		IssuedDate:  now,
-		DueDate:     now.AddDate(0, 0, 30),
+		DueDate:     client.DueDate(now),
 	}
-
 	if err := db.Create(&invoice).Error; err != nil {
-		RespondError(w, "Failed to create invoice: "+err.Error(), http.StatusInternalServerError)
-		return
+		return invoice, client, err
 	}
 
 	// Create invoice recipient
@@ -344,12 +377,22 @@ func (c *RecurringController) GenerateSingle(w http.ResponseWriter, r *http.Requ
 	})
 
 	// Update recurring invoice
-	rec.LastRunDate = &now
-	rec.NextRunDate = calculateNextRunDate(rec.Frequency, rec.DayOfMonth, rec.DayOfWeek)
-	rec.TotalGenerated++
+	rec.LastGeneratedDate = &now
+	rec.LastInvoiceID = &invoice.ID
+	rec.NextGenerationDate = calculateNextRunDate(rec.Frequency, rec.DayOfMonth, rec.DayOfWeek)
+	rec.GeneratedCount++
 	db.Save(&rec)
 
-	RespondJSON(w, invoice, http.StatusCreated)
+	return invoice, client, nil
+}
+
+func recurringCompanyID(db *gorm.DB, rec models.RecurringInvoice) uint {
+	if rec.CompanyID != nil {
+		return *rec.CompanyID
+	}
+	var company models.Company
+	db.Order("id").First(&company)
+	return company.ID
 }
 
 func calculateNextRunDate(frequency models.RecurringFrequency, dayOfMonth, dayOfWeek int) time.Time {
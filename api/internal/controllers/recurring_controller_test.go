@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"ung/api/internal/models"
+)
+
+func TestRecurringController_Get_KeepsAPIFieldNames(t *testing.T) {
+	db := SetupTestDB(t)
+	controller := NewRecurringController()
+
+	client := models.Client{Name: "Test Client", Email: "client@test.com"}
+	db.Create(&client)
+	next := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	recurring := models.RecurringInvoice{
+		ClientID:           client.ID,
+		Amount:             1200,
+		Currency:           "EUR",
+		Frequency:          models.FrequencyMonthly,
+		DayOfMonth:         1,
+		NextGenerationDate: next,
+		Active:             true,
+		GeneratedCount:     4,
+	}
+	db.Create(&recurring)
+
+	req := httptest.NewRequest("GET", "/recurring/1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(WithTenantDB(req.Context(), db), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	controller.Get(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]json.RawMessage
+	DecodeStandardResponse(t, w.Body, &response)
+	assert.JSONEq(t, `"2025-03-01T00:00:00Z"`, string(response["next_run_date"]))
+	assert.JSONEq(t, `4`, string(response["total_generated"]))
+	assert.Contains(t, response, "last_run_date")
+	assert.NotContains(t, response, "next_generation_date")
+	assert.NotContains(t, response, "generated_count")
+	assert.NotContains(t, response, "last_generated_date")
+}
+
+func TestRecurringController_GenerateSingle_UsesClientPaymentTerms(t *testing.T) {
+	db := SetupTestDB(t)
+	controller := NewRecurringController()
+
+	company := models.Company{Name: "Test Company", Email: "company@test.com"}
+	db.Create(&company)
+	client := models.Client{Name: "Initech", Email: "ap@initech.test", PaymentTerms: 10}
+	db.Create(&client)
+	recurring := models.RecurringInvoice{
+		ClientID:      client.ID,
+		Amount:        500,
+		Currency:      "EUR",
+		Frequency:     models.FrequencyMonthly,
+		DayOfMonth:    1,
+		Active:        true,
+		InvoicePrefix: "REC",
+	}
+	db.Create(&recurring)
+
+	req := httptest.NewRequest("POST", "/recurring/1/generate", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(WithTenantDB(req.Context(), db), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	controller.GenerateSingle(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var invoice models.Invoice
+	DecodeStandardResponse(t, w.Body, &invoice)
+	assert.Equal(t, 10, int(invoice.DueDate.Sub(invoice.IssuedDate).Hours()/24))
+	assert.Equal(t, company.ID, invoice.CompanyID)
+}
@@ -20,10 +20,10 @@ func NewSettingsController() *SettingsController {
 func (c *SettingsController) Get(w http.ResponseWriter, r *http.Request) {
 	db := middleware.GetTenantDB(r)
 
-	var settings models.UserSettings
+	var settings models.RateSettings
 	if err := db.First(&settings).Error; err != nil {
 		// Create default settings if not found
-		settings = models.UserSettings{
+		settings = models.RateSettings{
 			HoursPerWeek:      40,
 			WeeksPerYear:      48,
 			DefaultTaxPercent: 25,
@@ -59,9 +59,9 @@ func (c *SettingsController) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get or create settings
-	var settings models.UserSettings
+	var settings models.RateSettings
 	if err := db.First(&settings).Error; err != nil {
-		settings = models.UserSettings{
+		settings = models.RateSettings{
 			HoursPerWeek:      40,
 			WeeksPerYear:      48,
 			DefaultTaxPercent: 25,
@@ -125,10 +125,10 @@ func (c *SettingsController) Update(w http.ResponseWriter, r *http.Request) {
 func (c *SettingsController) GetWorkingHours(w http.ResponseWriter, r *http.Request) {
 	db := middleware.GetTenantDB(r)
 
-	var settings models.UserSettings
+	var settings models.RateSettings
 	if err := db.First(&settings).Error; err != nil {
 		// Use defaults
-		settings = models.UserSettings{
+		settings = models.RateSettings{
 			HoursPerWeek: 40,
 			WeeksPerYear: 48,
 		}
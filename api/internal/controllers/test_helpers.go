@@ -6,6 +6,7 @@ import (
 	"io"
 	"testing"
 
+	"github.com/Andriiklymiuk/ung/pkg/schema"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"ung/api/internal/middleware"
@@ -19,18 +20,12 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
 
-	// Auto-migrate all models
-	err = db.AutoMigrate(
-		&models.Client{},
-		&models.Company{},
-		&models.Contract{},
-		&models.Invoice{},
-		&models.InvoiceLineItem{},
-		&models.InvoiceRecipient{},
-		&models.Expense{},
-		&models.TrackingSession{},
-	)
+	// Create the schema shared with the CLI
+	sqlDB, err := db.DB()
 	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	if err := schema.Migrate(sqlDB); err != nil {
 		t.Fatalf("Failed to migrate schema: %v", err)
 	}
 
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+	"gorm.io/gorm"
+)
+
+// The audit log of a user database is written by the triggers of pkg/audit,
+// which InitUserDatabase installs. Changes are tagged with the source the
+// database was opened for, and new entries are sealed into the hash chain the
+// same way the CLI does.
+
+// Audit sources set by the API
+const (
+	AuditSourceAPI      = audit.SourceAPI
+	AuditSourceTelegram = audit.SourceTelegram
+)
+
+// AuditEntry is one entry of a user's audit log
+type AuditEntry struct {
+	ID         int64  `json:"id"`
+	OccurredAt string `json:"occurred_at"`
+	Entity     string `json:"entity"`
+	EntityID   int64  `json:"entity_id"`
+	Action     string `json:"action"`
+	OldValues  string `json:"old_values,omitempty"`
+	NewValues  string `json:"new_values,omitempty"`
+	Source     string `json:"source"`
+	PrevHash   string `json:"prev_hash"`
+	Hash       string `json:"hash"`
+}
+
+const auditColumns = `id, occurred_at, entity, entity_id, action, COALESCE(old_values, '') AS old_values,
+	COALESCE(new_values, '') AS new_values, source, COALESCE(prev_hash, '') AS prev_hash, COALESCE(hash, '') AS hash`
+
+// HasAuditLog reports whether the database has an audit log
+func HasAuditLog(db *gorm.DB) bool {
+	return db.Migrator().HasTable("audit_log")
+}
+
+// auditHash must match the CLI's chain hash
+func auditHash(prev string, e AuditEntry) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		prev,
+		fmt.Sprint(e.ID),
+		e.OccurredAt,
+		e.Entity,
+		fmt.Sprint(e.EntityID),
+		e.Action,
+		e.OldValues,
+		e.NewValues,
+		e.Source,
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SealAuditLog chains the entries added since the last seal
+func SealAuditLog(db *gorm.DB) (int, error) {
+	if !HasAuditLog(db) {
+		return 0, nil
+	}
+	sealed := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var prev string
+		tx.Raw("SELECT hash FROM audit_log WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1").Scan(&prev)
+
+		var pending []AuditEntry
+		if err := tx.Raw("SELECT " + auditColumns + " FROM audit_log WHERE hash IS NULL ORDER BY id").Scan(&pending).Error; err != nil {
+			return err
+		}
+		for _, e := range pending {
+			hash := auditHash(prev, e)
+			if err := tx.Exec("UPDATE audit_log SET prev_hash = ?, hash = ? WHERE id = ?", prev, hash, e.ID).Error; err != nil {
+				return err
+			}
+			prev = hash
+		}
+		sealed = len(pending)
+		return nil
+	})
+	return sealed, err
+}
+
+// AuditVerification is the result of checking the hash chain
+type AuditVerification struct {
+	Valid   bool   `json:"valid"`
+	Entries int    `json:"entries"`
+	Head    string `json:"head,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// VerifyAuditLog recomputes the hash chain
+func VerifyAuditLog(db *gorm.DB) (*AuditVerification, error) {
+	v := &AuditVerification{Valid: true}
+	if !HasAuditLog(db) {
+		return v, nil
+	}
+	var entries []AuditEntry
+	if err := db.Raw("SELECT " + auditColumns + " FROM audit_log ORDER BY id").Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	prev, pending := "", 0
+	for _, e := range entries {
+		if e.Hash == "" {
+			pending++
+			continue
+		}
+		switch {
+		case pending > 0:
+			v.Error = fmt.Sprintf("entry %d is sealed after unsealed entries: the chain was altered", e.ID)
+		case e.PrevHash != prev:
+			v.Error = fmt.Sprintf("entry %d doesn't follow the previous entry: entries before it were removed or changed", e.ID)
+		case auditHash(prev, e) != e.Hash:
+			v.Error = fmt.Sprintf("entry %d was modified after it was recorded", e.ID)
+		}
+		if v.Error != "" {
+			v.Valid = false
+			return v, nil
+		}
+		prev = e.Hash
+		v.Entries++
+		v.Head = e.Hash
+	}
+	return v, nil
+}
+
+// AuditFilter selects audit entries
+type AuditFilter struct {
+	Entity   string
+	EntityID int64
+	Since    string // UTC timestamp prefix, e.g. 2024-01-01
+	Limit    int
+}
+
+// ListAuditEntries returns matching entries, newest first
+func ListAuditEntries(db *gorm.DB, f AuditFilter) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	if !HasAuditLog(db) {
+		return entries, nil
+	}
+	query := db.Table("audit_log").Select(auditColumns)
+	if f.Entity != "" {
+		query = query.Where("entity = ?", f.Entity)
+	}
+	if f.EntityID != 0 {
+		query = query.Where("entity_id = ?", f.EntityID)
+	}
+	if f.Since != "" {
+		query = query.Where("occurred_at >= ?", f.Since)
+	}
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+	err := query.Order("id DESC").Scan(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestUserDatabaseAuditsChangesBySource(t *testing.T) {
+	dbPath, err := CreateUserDatabase(1, t.TempDir())
+	if err != nil {
+		t.Fatalf("CreateUserDatabase() error: %v", err)
+	}
+
+	// The API and the Telegram bot write to the same database at the same time
+	apiDB, err := InitUserDatabase(dbPath, AuditSourceAPI)
+	if err != nil {
+		t.Fatalf("InitUserDatabase(api) error: %v", err)
+	}
+	telegramDB, err := InitUserDatabase(dbPath, AuditSourceTelegram)
+	if err != nil {
+		t.Fatalf("InitUserDatabase(telegram) error: %v", err)
+	}
+
+	insert := "INSERT INTO expenses (description, amount, category, date) VALUES (?, ?, 'software', CURRENT_TIMESTAMP)"
+	if err := apiDB.Exec(insert, "Hosting", 20).Error; err != nil {
+		t.Fatalf("failed to add expense through the API: %v", err)
+	}
+	if err := telegramDB.Exec(insert, "Taxi", 15).Error; err != nil {
+		t.Fatalf("failed to add expense through Telegram: %v", err)
+	}
+	if err := apiDB.Exec("UPDATE expenses SET amount = 25 WHERE description = 'Hosting'").Error; err != nil {
+		t.Fatalf("failed to update expense: %v", err)
+	}
+
+	if n, err := SealAuditLog(telegramDB); err != nil || n != 3 {
+		t.Fatalf("SealAuditLog() = %d, %v", n, err)
+	}
+	entries, err := ListAuditEntries(apiDB, AuditFilter{Entity: "expense"})
+	if err != nil {
+		t.Fatalf("ListAuditEntries() error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	want := []struct{ action, source string }{
+		{"update", AuditSourceAPI},
+		{"create", AuditSourceTelegram},
+		{"create", AuditSourceAPI},
+	}
+	for i, w := range want {
+		if entries[i].Action != w.action || entries[i].Source != w.source {
+			t.Errorf("entry %d: got %s from %s, want %s from %s", i, entries[i].Action, entries[i].Source, w.action, w.source)
+		}
+	}
+
+	v, err := VerifyAuditLog(apiDB)
+	if err != nil || !v.Valid || v.Entries != 3 {
+		t.Errorf("VerifyAuditLog() = %+v, %v", v, err)
+	}
+}
@@ -1,10 +1,14 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+	"github.com/Andriiklymiuk/ung/pkg/schema"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -39,24 +43,36 @@ func InitAPIDatabase(dbPath string) (*gorm.DB, error) {
 	return db, nil
 }
 
-// InitUserDatabase creates or opens a user's database
-func InitUserDatabase(userDBPath string) (*gorm.DB, error) {
+// migrated records the user databases already brought up to date by this process
+var migrated sync.Map
+
+// InitUserDatabase creates or opens a user's database and brings its schema
+// and audit triggers up to date with the CLI's. Changes made through it are
+// audited as coming from source.
+func InitUserDatabase(userDBPath, source string) (*gorm.DB, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(userDBPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	db, err := gorm.Open(sqlite.Open(userDBPath), &gorm.Config{
+	if _, done := migrated.Load(userDBPath); !done {
+		if err := runUserMigrations(userDBPath); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+		migrated.Store(userDBPath, true)
+	}
+
+	db, err := gorm.Open(sqlite.New(sqlite.Config{
+		DriverName: audit.DriverName,
+		DSN:        audit.DSN(userDBPath, source),
+	}), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open user database: %w", err)
 	}
 
-	// Note: User database schema is managed by CLI migrations
-	// The API just opens and uses it
-
 	return db, nil
 }
 
@@ -69,30 +85,28 @@ func CreateUserDatabase(userID uint, userDataDir string) (string, error) {
 
 	dbPath := filepath.Join(userDir, "ung.db")
 
-	// Initialize database
-	db, err := InitUserDatabase(dbPath)
-	if err != nil {
+	// Initialize database, which runs the migrations
+	if _, err := InitUserDatabase(dbPath, AuditSourceAPI); err != nil {
 		return "", err
 	}
 
-	// Run migrations (reuse CLI migrations)
-	// For now, we'll use AutoMigrate with UNG models
-	// In production, this should run actual migration files
-	if err := runUserMigrations(db); err != nil {
-		return "", fmt.Errorf("failed to run migrations: %w", err)
-	}
-
 	return dbPath, nil
 }
 
-// runUserMigrations runs migrations on user database
-func runUserMigrations(db *gorm.DB) error {
-	// Import UNG CLI models and run migrations
-	// For now, this is a placeholder
-	// In production, you'd exec the actual migration SQL files
-
-	// This would be replaced with proper migration runner
-	// that executes ../migrations/*.up.sql files
+// runUserMigrations applies the schema shared with the CLI (pkg/schema) and
+// installs the audit triggers the CLI installs (pkg/audit). Databases uploaded
+// from an older CLI are upgraded the same way the CLI upgrades them. It uses a
+// connection of its own, since connections opened before the audit log
+// existed don't record the source of changes.
+func runUserMigrations(userDBPath string) error {
+	conn, err := sql.Open("sqlite3", userDBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
 
-	return nil
+	if err := schema.Migrate(conn); err != nil {
+		return err
+	}
+	return audit.Install(conn)
 }
@@ -19,8 +19,14 @@ func TenantMiddleware() func(http.Handler) http.Handler {
 				return
 			}
 
+			// Tag audit log entries with where the change came from
+			source := database.AuditSourceAPI
+			if r.Header.Get("X-UNG-Source") == database.AuditSourceTelegram {
+				source = database.AuditSourceTelegram
+			}
+
 			// Open user's specific database
-			tenantDB, err := database.InitUserDatabase(user.DBPath)
+			tenantDB, err := database.InitUserDatabase(user.DBPath, source)
 			if err != nil {
 				respondError(w, "Database error", http.StatusInternalServerError)
 				return
@@ -29,6 +35,11 @@ func TenantMiddleware() func(http.Handler) http.Handler {
 			// Add tenant DB to context
 			ctx := context.WithValue(r.Context(), TenantDBKey, tenantDB)
 			next.ServeHTTP(w, r.WithContext(ctx))
+
+			// Chain the changes the request made into the audit log
+			if r.Method != http.MethodGet {
+				database.SealAuditLog(tenantDB)
+			}
 		})
 	}
 }
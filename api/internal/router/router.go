@@ -34,6 +34,7 @@ func SetupRouter(
 	exportController *controllers.ExportController,
 	hunterController *controllers.HunterController,
 	digController *controllers.DigController,
+	auditController *controllers.AuditController,
 	authMiddleware func(http.Handler) http.Handler,
 	tenantMiddleware func(http.Handler) http.Handler,
 	subscriptionMiddleware func(http.Handler) http.Handler,
@@ -51,7 +52,7 @@ func SetupRouter(
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-UNG-Source"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -275,6 +276,12 @@ func SetupRouter(
 					r.Post("/{id}/images", digController.GenerateImages)
 					r.Get("/{id}/export", digController.ExportSession)
 				})
+
+				// Audit Log
+				r.Route("/audit", func(r chi.Router) {
+					r.Get("/", auditController.List)
+					r.Get("/verify", auditController.Verify)
+				})
 			})
 
 			// Subscription routes (no tenant DB needed)
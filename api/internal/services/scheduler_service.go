@@ -2,10 +2,15 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +18,7 @@ import (
 type SchedulerService struct {
 	db           *gorm.DB
 	emailService *EmailService
+	templatesDir string // Email templates as 'ung email template' writes them, empty for the built-in ones
 	tasks        map[string]*ScheduledTask
 	mu           sync.RWMutex
 	stopChan     chan struct{}
@@ -27,11 +33,13 @@ type ScheduledTask struct {
 	Enabled  bool
 }
 
-// NewSchedulerService creates a new scheduler service
-func NewSchedulerService(db *gorm.DB, emailService *EmailService) *SchedulerService {
+// NewSchedulerService creates a new scheduler service. Reminders use the
+// email templates in templatesDir, like ung invoice remind.
+func NewSchedulerService(db *gorm.DB, emailService *EmailService, templatesDir string) *SchedulerService {
 	return &SchedulerService{
 		db:           db,
 		emailService: emailService,
+		templatesDir: templatesDir,
 		tasks:        make(map[string]*ScheduledTask),
 		stopChan:     make(chan struct{}),
 	}
@@ -124,39 +132,41 @@ func (s *SchedulerService) registerDefaultTasks() {
 func (s *SchedulerService) sendInvoiceReminders(ctx context.Context) error {
 	log.Println("Running invoice reminders task...")
 
-	// TODO: Query invoices due in next 7 days that are pending
-	// For each invoice, send reminder email to client
-
-	/*
-	Example query:
-	var invoices []Invoice
+	// Pending invoices due in the next 7 days
+	var invoices []models.Invoice
+	now := time.Now()
 	err := s.db.WithContext(ctx).
-		Where("status = ? AND due_date BETWEEN ? AND ?",
-			"pending",
-			time.Now(),
-			time.Now().AddDate(0, 0, 7)).
-		Preload("Client").
+		Preload("Company").
+		Where("status = ? AND due_date BETWEEN ? AND ?", models.StatusPending, now, now.AddDate(0, 0, 7)).
 		Find(&invoices).Error
-
 	if err != nil {
 		return fmt.Errorf("failed to query invoices: %w", err)
 	}
 
 	for _, invoice := range invoices {
-		email := &Email{
-			To:      []string{invoice.Client.Email},
-			Subject: fmt.Sprintf("Payment Reminder: Invoice #%s", invoice.InvoiceNum),
-			HTMLBody: s.generateInvoiceReminderHTML(invoice),
-			Body:    s.generateInvoiceReminderText(invoice),
+		var clients []models.Client
+		err := s.db.WithContext(ctx).
+			Preload("Contacts").
+			Where("id IN (?)", s.db.Model(&models.InvoiceRecipient{}).Select("client_id").Where("invoice_id = ?", invoice.ID)).
+			Find(&clients).Error
+		if err != nil {
+			log.Printf("Failed to load clients of invoice %s: %v", invoice.InvoiceNum, err)
+			continue
 		}
 
-		if err := s.emailService.Send(email); err != nil {
-			log.Printf("Failed to send reminder for invoice %s: %v", invoice.InvoiceNum, err)
-		} else {
-			log.Printf("Sent reminder for invoice %s to %s", invoice.InvoiceNum, invoice.Client.Email)
+		for _, client := range clients {
+			msg, err := s.invoiceReminder(invoice, invoice.Company, client)
+			if err != nil {
+				log.Printf("Failed to render reminder for invoice %s: %v", invoice.InvoiceNum, err)
+				continue
+			}
+			if err := s.emailService.Send(msg); err != nil {
+				log.Printf("Failed to send reminder for invoice %s: %v", invoice.InvoiceNum, err)
+			} else {
+				log.Printf("Sent reminder for invoice %s to %s", invoice.InvoiceNum, strings.Join(msg.To, ", "))
+			}
 		}
 	}
-	*/
 
 	log.Println("Invoice reminders task completed")
 	return nil
@@ -212,61 +222,25 @@ func (s *SchedulerService) sendMonthlyReports(ctx context.Context) error {
 	return nil
 }
 
-// generateInvoiceReminderHTML generates HTML email for invoice reminder
-func (s *SchedulerService) generateInvoiceReminderHTML(invoice interface{}) string {
-	// TODO: Use proper template
-	return `
-	<!DOCTYPE html>
-	<html>
-	<head>
-		<style>
-			body { font-family: Arial, sans-serif; }
-			.container { max-width: 600px; margin: 0 auto; padding: 20px; }
-			.header { background-color: #4CAF50; color: white; padding: 20px; text-align: center; }
-			.content { padding: 20px; background-color: #f9f9f9; }
-			.button { background-color: #4CAF50; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px; display: inline-block; margin-top: 20px; }
-		</style>
-	</head>
-	<body>
-		<div class="container">
-			<div class="header">
-				<h1>Payment Reminder</h1>
-			</div>
-			<div class="content">
-				<p>Dear Client,</p>
-				<p>This is a friendly reminder that your payment is due soon.</p>
-				<p><strong>Invoice Details:</strong></p>
-				<ul>
-					<li>Invoice Number: #INV-001</li>
-					<li>Amount Due: $1,000.00</li>
-					<li>Due Date: 2024-01-15</li>
-				</ul>
-				<p>Please process your payment by the due date to avoid late fees.</p>
-				<a href="#" class="button">View Invoice</a>
-				<p>Thank you for your business!</p>
-			</div>
-		</div>
-	</body>
-	</html>
-	`
-}
-
-// generateInvoiceReminderText generates plain text email for invoice reminder
-func (s *SchedulerService) generateInvoiceReminderText(invoice interface{}) string {
-	return `
-Payment Reminder
-
-Dear Client,
-
-This is a friendly reminder that your payment is due soon.
-
-Invoice Details:
-- Invoice Number: #INV-001
-- Amount Due: $1,000.00
-- Due Date: 2024-01-15
-
-Please process your payment by the due date to avoid late fees.
-
-Thank you for your business!
-	`
+// invoiceReminder renders the reminder email of an invoice in the client's
+// language from the client's templates, like ung invoice remind
+func (s *SchedulerService) invoiceReminder(invoice models.Invoice, company models.Company, client models.Client) (*Email, error) {
+	loc := locale.Get(client.Language)
+	if loc == nil {
+		loc = locale.Get(locale.Default)
+	}
+	msg, err := email.RenderInvoice(s.templatesDir, email.KindReminder, invoice, company, client, loc, "")
+	if err != nil {
+		return nil, err
+	}
+	msg.AddressTo(client, models.ContactBilling)
+
+	return &Email{
+		To:       msg.To,
+		Cc:       msg.Cc,
+		Bcc:      msg.Bcc,
+		Subject:  msg.Subject,
+		Body:     msg.Body,
+		HTMLBody: msg.HTMLBody,
+	}, nil
 }
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/email/smtptest"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSchedulerService_InvoiceReminderUsesClientTemplates(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "acme"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "reminder.yaml"), []byte("subject: Reminder {invoice}\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "acme", "reminder.de.yaml"), []byte("subject: Erinnerung {invoice} für {client}\n"), 0644))
+
+	s := NewSchedulerService(nil, nil, dir)
+	invoice := models.Invoice{InvoiceNum: "INV-7", Amount: 100, Currency: "EUR", DueDate: time.Now().AddDate(0, 0, 3)}
+	company := models.Company{Name: "Me LLC"}
+	client := models.Client{
+		Name:          "Acme",
+		Email:         "info@acme.test",
+		Language:      "de",
+		EmailTemplate: "acme",
+		Contacts:      []models.ClientContact{{Email: "ap@acme.test", Role: models.ContactBilling}},
+	}
+
+	email, err := s.invoiceReminder(invoice, company, client)
+	assert.NoError(t, err)
+	assert.Equal(t, "Erinnerung INV-7 für Acme", email.Subject)
+	assert.Equal(t, []string{"ap@acme.test"}, email.To)
+	assert.Contains(t, email.Body, "INV-7")
+
+	// Other clients get the common template
+	client.EmailTemplate = ""
+	client.Language = "en"
+	email, err = s.invoiceReminder(invoice, company, client)
+	assert.NoError(t, err)
+	assert.Equal(t, "Reminder INV-7", email.Subject)
+}
+
+func TestSchedulerService_SendInvoiceReminders(t *testing.T) {
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ung.db"))
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.NoError(t, schema.Migrate(conn))
+	db, err := gorm.Open(sqlite.Dialector{Conn: conn}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.NoError(t, err)
+
+	soon, later := time.Now().AddDate(0, 0, 3), time.Now().AddDate(0, 1, 0)
+	db.Create(&models.Company{ID: 1, Name: "Me LLC", Email: "me@example.com"})
+	db.Create(&models.Client{ID: 1, Name: "Acme", Email: "info@acme.test"})
+	db.Create(&models.ClientContact{ClientID: 1, Name: "AP", Email: "ap@acme.test", Role: models.ContactBilling})
+	db.Create(&models.Invoice{ID: 1, InvoiceNum: "INV-1", CompanyID: 1, Amount: 100, Currency: "EUR", Status: models.StatusPending, DueDate: soon})
+	db.Create(&models.Invoice{ID: 2, InvoiceNum: "INV-2", CompanyID: 1, Amount: 100, Currency: "EUR", Status: models.StatusPending, DueDate: later})
+	db.Create(&models.Invoice{ID: 3, InvoiceNum: "INV-3", CompanyID: 1, Amount: 100, Currency: "EUR", Status: models.StatusPaid, DueDate: soon})
+	for id := uint(1); id <= 3; id++ {
+		db.Create(&models.InvoiceRecipient{InvoiceID: id, ClientID: 1})
+	}
+
+	sink := smtptest.NewSink()
+	sink.Username, sink.Password = "me", "secret"
+	defer sink.Close()
+	emailService := NewEmailService(&EmailConfig{
+		SMTPHost: sink.Host, SMTPPort: strconv.Itoa(sink.Port),
+		Username: "me", Password: "secret", FromEmail: "me@example.com",
+	})
+
+	s := NewSchedulerService(db, emailService, "")
+	assert.NoError(t, s.sendInvoiceReminders(context.Background()))
+
+	messages := sink.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, []string{"ap@acme.test"}, messages[0].To)
+		assert.Contains(t, string(messages[0].Data), "INV-1")
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the history of changes to financial records",
+	Long: `Show who changed invoices, line items, time sessions, contracts and expenses,
+when, and what the values were before and after.
+
+Every create, update and delete is recorded automatically, whether it came
+from the CLI, the API, the Telegram bot, a sync or a backup restore. The log
+is append-only and hash-chained; 'ung audit verify' detects entries that were
+changed or removed after they were recorded.
+
+Examples:
+  ung audit                              Recent changes
+  ung audit --entity invoice --id 5      History of invoice 5
+  ung audit --entity expense --since 2024-01-01
+  ung audit --json                       Machine-readable output
+  ung audit verify                       Check the hash chain`,
+	RunE: runAudit,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the audit log for tampering",
+	Long: `Recompute the audit log's hash chain. Changing or removing any entry breaks
+the chain, except removing the most recent entries: to detect that, note the
+head hash printed here and pass it to a later check with --head.
+
+Examples:
+  ung audit verify
+  ung audit verify --head 3f2a...`,
+	RunE: runAuditVerify,
+}
+
+var (
+	auditEntity string
+	auditID     int64
+	auditSince  string
+	auditLimit  int
+	auditJSON   bool
+	auditHead   string
+)
+
+func init() {
+	auditCmd.Flags().StringVarP(&auditEntity, "entity", "e", "", "Entity type (invoice, line_item, session, contract, expense)")
+	auditCmd.Flags().Int64Var(&auditID, "id", 0, "Entity ID")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Only changes on or after this date")
+	auditCmd.Flags().IntVarP(&auditLimit, "limit", "n", 50, "Maximum number of entries")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "Output as JSON")
+
+	auditVerifyCmd.Flags().StringVar(&auditHead, "head", "", "Check that a previously noted head hash is still in the log")
+
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	filter := audit.Filter{EntityID: auditID, Limit: auditLimit}
+	if auditEntity != "" {
+		entity, ok := audit.EntityName(auditEntity)
+		if !ok {
+			return fmt.Errorf("unknown entity %q (use invoice, line_item, session, contract or expense)", auditEntity)
+		}
+		filter.Entity = entity
+	} else if auditID != 0 {
+		return fmt.Errorf("--id needs --entity")
+	}
+	if auditSince != "" {
+		since, err := parseDate(auditSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since date: %w", err)
+		}
+		filter.Since = since
+	}
+
+	if _, err := audit.Seal(db.DB); err != nil {
+		return fmt.Errorf("failed to seal audit log: %w", err)
+	}
+	entries, err := audit.Query(db.DB, filter)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	if auditJSON {
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No changes recorded")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSOURCE\tACTION\tRECORD\tCHANGES")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s #%d\t%s\n",
+			e.Time().Local().Format("2006-01-02 15:04:05"),
+			e.Source,
+			e.Action,
+			e.Entity,
+			e.EntityID,
+			formatAuditChanges(e))
+	}
+	w.Flush()
+
+	if len(entries) == auditLimit {
+		fmt.Printf("\nShowing the latest %d entries (use --limit for more)\n", auditLimit)
+	}
+	return nil
+}
+
+// formatAuditChanges summarizes an entry's changes on one line
+func formatAuditChanges(e audit.Entry) string {
+	var parts []string
+	for _, c := range e.Changes() {
+		switch e.Action {
+		case audit.ActionUpdate:
+			if c.Field == "updated_at" {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s: %s → %s", c.Field, formatAuditValue(c.Old), formatAuditValue(c.New)))
+		case audit.ActionCreate:
+			if interestingAuditFields[c.Field] && c.New != nil {
+				parts = append(parts, fmt.Sprintf("%s=%s", c.Field, formatAuditValue(c.New)))
+			}
+		case audit.ActionDelete:
+			if interestingAuditFields[c.Field] && c.Old != nil {
+				parts = append(parts, fmt.Sprintf("%s=%s", c.Field, formatAuditValue(c.Old)))
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// interestingAuditFields are shown for creates and deletes, which change every field
+var interestingAuditFields = map[string]bool{
+	"invoice_num": true, "amount": true, "status": true, "due_date": true, "description": true,
+	"name": true, "hours": true, "duration": true, "rate": true, "total": true, "project_name": true,
+}
+
+func formatAuditValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "∅"
+	case string:
+		if len(val) > 40 {
+			return fmt.Sprintf("%q", val[:37]+"...")
+		}
+		return fmt.Sprintf("%q", val)
+	case float64:
+		return fmt.Sprintf("%g", val)
+	}
+	return fmt.Sprint(v)
+}
+
+func runAuditVerify(cmd *cobra.Command, args []string) error {
+	if _, err := audit.Seal(db.DB); err != nil {
+		return fmt.Errorf("failed to seal audit log: %w", err)
+	}
+	v, err := audit.Verify(db.DB)
+	if err != nil && v == nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if err != nil {
+		fmt.Printf("❌ Audit log failed verification after %d good entries\n", v.Entries)
+		return err
+	}
+
+	if auditHead != "" {
+		found, err := audit.Contains(db.DB, strings.ToLower(strings.TrimSpace(auditHead)))
+		if err != nil {
+			return err
+		}
+		if !found {
+			fmt.Println("❌ The noted head is no longer in the audit log")
+			return fmt.Errorf("entries recorded up to the noted head were removed")
+		}
+	}
+
+	fmt.Printf("✅ Audit log intact: %d entries\n", v.Entries)
+	if v.Head != "" {
+		fmt.Printf("   Head: %s (%s)\n", v.Head, time.Now().Format("2006-01-02 15:04"))
+		if auditHead == "" {
+			fmt.Println("\n💡 Keep the head hash somewhere else and check it later with --head")
+		}
+	}
+	return nil
+}
@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -53,10 +58,18 @@ Examples:
 }
 
 var (
-	clientName    string
-	clientEmail   string
-	clientAddress string
-	clientTaxID   string
+	clientName          string
+	clientEmail         string
+	clientAddress       string
+	clientTaxID         string
+	clientLang          string
+	clientTax           string
+	clientEmailTemplate string
+	clientEmailCc       string
+	clientEmailBcc      string
+	clientCurrency      string
+	clientPaymentTerms  int
+	clientPORequired    bool
 )
 
 func init() {
@@ -71,6 +84,14 @@ func init() {
 	clientAddCmd.Flags().StringVar(&clientEmail, "email", "", "Client email (required)")
 	clientAddCmd.Flags().StringVar(&clientAddress, "address", "", "Client address")
 	clientAddCmd.Flags().StringVar(&clientTaxID, "tax-id", "", "Tax ID")
+	clientAddCmd.Flags().StringVar(&clientLang, "language", "", "Language of invoices and emails for this client, e.g. de (default: config language)")
+	clientAddCmd.Flags().StringVar(&clientTax, "tax-profile", "", "Tax profile of invoices for this client, e.g. reverse-charge (default: tax.default)")
+	clientAddCmd.Flags().StringVar(&clientEmailTemplate, "email-template", "", "Email template set for this client, a directory in the emails config dir")
+	clientAddCmd.Flags().StringVar(&clientEmailCc, "cc", "", "Addresses to copy on emails to this client, comma separated")
+	clientAddCmd.Flags().StringVar(&clientEmailBcc, "bcc", "", "Addresses to blind copy on emails to this client, comma separated")
+	clientAddCmd.Flags().StringVar(&clientCurrency, "currency", "", "Currency of invoices for this client, e.g. EUR (default: USD)")
+	clientAddCmd.Flags().IntVar(&clientPaymentTerms, "payment-terms", 0, "Days invoices are due after issue (default: 30)")
+	clientAddCmd.Flags().BoolVar(&clientPORequired, "po-required", false, "Invoices for this client need a PO number")
 	clientAddCmd.MarkFlagRequired("name")
 	clientAddCmd.MarkFlagRequired("email")
 
@@ -79,26 +100,68 @@ func init() {
 	clientEditCmd.Flags().StringVar(&clientEmail, "email", "", "Client email")
 	clientEditCmd.Flags().StringVar(&clientAddress, "address", "", "Client address")
 	clientEditCmd.Flags().StringVar(&clientTaxID, "tax-id", "", "Tax ID")
+	clientEditCmd.Flags().StringVar(&clientLang, "language", "", "Document language, empty for the config language")
+	clientEditCmd.Flags().StringVar(&clientTax, "tax-profile", "", "Tax profile, empty for tax.default")
+	clientEditCmd.Flags().StringVar(&clientEmailTemplate, "email-template", "", "Email template set, empty for the common templates")
+	clientEditCmd.Flags().StringVar(&clientEmailCc, "cc", "", "Addresses to copy on emails, comma separated")
+	clientEditCmd.Flags().StringVar(&clientEmailBcc, "bcc", "", "Addresses to blind copy on emails, comma separated")
+	clientEditCmd.Flags().StringVar(&clientCurrency, "currency", "", "Invoice currency, empty for USD")
+	clientEditCmd.Flags().IntVar(&clientPaymentTerms, "payment-terms", 0, "Days invoices are due after issue, 0 for 30")
+	clientEditCmd.Flags().BoolVar(&clientPORequired, "po-required", false, "Invoices need a PO number")
 }
 
 func runClientAdd(cmd *cobra.Command, args []string) error {
+	if err := locale.Validate(clientLang); err != nil {
+		return err
+	}
+	cfg, _ := config.Load()
+	if err := tax.Validate(cfg, clientTax); err != nil {
+		return err
+	}
+	if err := email.ValidateSet(clientEmailTemplate); err != nil {
+		return err
+	}
+	cc, err := emailAddressList(clientEmailCc)
+	if err != nil {
+		return err
+	}
+	bcc, err := emailAddressList(clientEmailBcc)
+	if err != nil {
+		return err
+	}
+	if clientPaymentTerms < 0 {
+		return fmt.Errorf("payment terms can't be negative")
+	}
+
 	query := `
-		INSERT INTO clients (name, email, address, tax_id)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO clients (name, email, address, tax_id, language, tax_profile, email_template, email_cc, email_bcc, currency, payment_terms_days, po_required)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := db.DB.Exec(query, clientName, clientEmail, clientAddress, clientTaxID)
+	result, err := db.DB.Exec(query, clientName, clientEmail, clientAddress, clientTaxID, strings.ToLower(clientLang), clientTax, clientEmailTemplate, cc, bcc,
+		strings.ToUpper(clientCurrency), clientPaymentTerms, clientPORequired)
 	if err != nil {
 		return fmt.Errorf("failed to add client: %w", err)
 	}
 
 	id, _ := result.LastInsertId()
 	fmt.Printf("✓ Client added successfully (ID: %d)\n", id)
+	fmt.Printf("💡 Add billing contacts with: ung client contact add %d --name <name> --email <email>\n", id)
 	return nil
 }
 
+// emailAddressList validates a list of addresses from a flag and returns
+// them as stored, comma separated
+func emailAddressList(list string) (string, error) {
+	addresses, err := email.ParseAddresses(list)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(addresses, ", "), nil
+}
+
 func runClientList(cmd *cobra.Command, args []string) error {
-	query := `SELECT id, name, email, address, tax_id, created_at FROM clients ORDER BY id`
+	query := `SELECT id, name, email, address, tax_id, language, tax_profile, created_at FROM clients ORDER BY id`
 
 	rows, err := db.DB.Query(query)
 	if err != nil {
@@ -107,15 +170,15 @@ func runClientList(cmd *cobra.Command, args []string) error {
 	defer rows.Close()
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tADDRESS\tTAX ID\tCREATED")
+	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tADDRESS\tTAX ID\tLANG\tTAX\tCREATED")
 
 	for rows.Next() {
 		var c models.Client
-		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Address, &c.TaxID, &c.CreatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Address, &c.TaxID, &c.Language, &c.TaxProfile, &c.CreatedAt); err != nil {
 			return fmt.Errorf("failed to scan row: %w", err)
 		}
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
-			c.ID, c.Name, c.Email, c.Address, c.TaxID, c.CreatedAt.Format("2006-01-02"))
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.ID, c.Name, c.Email, c.Address, c.TaxID, c.Language, c.TaxProfile, c.CreatedAt.Format("2006-01-02"))
 	}
 
 	w.Flush()
@@ -141,6 +204,51 @@ func runClientEdit(cmd *cobra.Command, args []string) error {
 	if cmd.Flags().Changed("tax-id") {
 		updates["tax_id"] = clientTaxID
 	}
+	if cmd.Flags().Changed("language") {
+		if err := locale.Validate(clientLang); err != nil {
+			return err
+		}
+		updates["language"] = strings.ToLower(clientLang)
+	}
+	if cmd.Flags().Changed("tax-profile") {
+		cfg, _ := config.Load()
+		if err := tax.Validate(cfg, clientTax); err != nil {
+			return err
+		}
+		updates["tax_profile"] = clientTax
+	}
+	if cmd.Flags().Changed("email-template") {
+		if err := email.ValidateSet(clientEmailTemplate); err != nil {
+			return err
+		}
+		updates["email_template"] = clientEmailTemplate
+	}
+	if cmd.Flags().Changed("cc") {
+		cc, err := emailAddressList(clientEmailCc)
+		if err != nil {
+			return err
+		}
+		updates["email_cc"] = cc
+	}
+	if cmd.Flags().Changed("bcc") {
+		bcc, err := emailAddressList(clientEmailBcc)
+		if err != nil {
+			return err
+		}
+		updates["email_bcc"] = bcc
+	}
+	if cmd.Flags().Changed("currency") {
+		updates["currency"] = strings.ToUpper(clientCurrency)
+	}
+	if cmd.Flags().Changed("payment-terms") {
+		if clientPaymentTerms < 0 {
+			return fmt.Errorf("payment terms can't be negative")
+		}
+		updates["payment_terms_days"] = clientPaymentTerms
+	}
+	if cmd.Flags().Changed("po-required") {
+		updates["po_required"] = clientPORequired
+	}
 
 	if len(updates) == 0 {
 		return fmt.Errorf("no fields to update")
@@ -174,6 +282,32 @@ func runClientEdit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// deleteClient deletes a client with its billing profile in one transaction
+func deleteClient(id int) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The billing profile goes with the client
+	if _, err := tx.Exec("DELETE FROM client_contacts WHERE client_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete client contacts: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM client_addresses WHERE client_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete client addresses: %w", err)
+	}
+
+	result, err := tx.Exec("DELETE FROM clients WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete client: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("client with ID %d not found", id)
+	}
+	return tx.Commit()
+}
+
 func runClientDelete(cmd *cobra.Command, args []string) error {
 	id, err := strconv.Atoi(args[0])
 	if err != nil {
@@ -230,15 +364,8 @@ func runClientDelete(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Delete the client
-	result, err := db.DB.Exec("DELETE FROM clients WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("failed to delete client: %w", err)
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("client with ID %d not found", id)
+	if err := deleteClient(id); err != nil {
+		return err
 	}
 
 	fmt.Printf("✓ Client '%s' (ID: %d) deleted successfully\n", clientName, id)
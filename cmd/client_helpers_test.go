@@ -4,7 +4,7 @@ import (
 	"testing"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 func TestSearchClients_SingleMatch(t *testing.T) {
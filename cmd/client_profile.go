@@ -0,0 +1,448 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var clientShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a client's billing profile",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runClientShow,
+}
+
+var clientContactCmd = &cobra.Command{
+	Use:     "contact",
+	Aliases: []string{"contacts"},
+	Short:   "Manage the people at a client",
+	Long: `Manage the people at a client and what they are contacted about.
+
+Invoice and reminder emails go to the billing contacts, contracts to the
+legal contacts, and the client's email when it has none. Contacts added
+with --cc are copied on every invoice and contract email. The first
+billing contact is printed on invoices as "Attn.".
+
+Examples:
+  ung client contact add 3 --name "Anna Schmidt" --email ap@initech.test
+  ung client contact add 3 --name "Tom Lee" --email tom@initech.test --role technical --cc
+  ung client contact ls 3
+  ung client contact edit 7 --role legal
+  ung client contact rm 7`,
+}
+
+var clientContactAddCmd = &cobra.Command{
+	Use:   "add <client-id>",
+	Short: "Add a contact to a client",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runClientContactAdd,
+}
+
+var clientContactListCmd = &cobra.Command{
+	Use:     "ls <client-id>",
+	Aliases: []string{"list"},
+	Short:   "List a client's contacts",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runClientContactList,
+}
+
+var clientContactEditCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Edit a contact",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runClientContactEdit,
+}
+
+var clientContactDeleteCmd = &cobra.Command{
+	Use:     "rm <id>",
+	Aliases: []string{"delete"},
+	Short:   "Remove a contact",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runClientContactDelete,
+}
+
+var clientAddressCmd = &cobra.Command{
+	Use:     "address",
+	Aliases: []string{"addresses"},
+	Short:   "Manage a client's billing, legal and postal addresses",
+	Long: `Manage a client's addresses, one of each kind.
+
+Invoices print the billing address and contracts the legal address, and
+both fall back to the client's address when the kind isn't set. The
+billing address also decides the sales tax of a US client.
+
+Examples:
+  ung client address set 3 billing "Accounts Payable, 1 Main St, Austin, TX 78701"
+  ung client address set 3 legal "Initech GmbH, Hauptstr. 1, 10115 Berlin"
+  ung client address ls 3
+  ung client address rm 3 postal`,
+}
+
+var clientAddressSetCmd = &cobra.Command{
+	Use:   "set <client-id> <billing|legal|postal> <address>",
+	Short: "Set an address of a client",
+	Args:  cobra.ExactArgs(3),
+	RunE:  runClientAddressSet,
+}
+
+var clientAddressListCmd = &cobra.Command{
+	Use:     "ls <client-id>",
+	Aliases: []string{"list"},
+	Short:   "List a client's addresses",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runClientAddressList,
+}
+
+var clientAddressDeleteCmd = &cobra.Command{
+	Use:     "rm <client-id> <billing|legal|postal>",
+	Aliases: []string{"delete"},
+	Short:   "Remove an address of a client",
+	Args:    cobra.ExactArgs(2),
+	RunE:    runClientAddressDelete,
+}
+
+var (
+	contactName  string
+	contactEmail string
+	contactPhone string
+	contactRole  string
+	contactCc    bool
+)
+
+func init() {
+	clientCmd.AddCommand(clientShowCmd)
+	clientCmd.AddCommand(clientContactCmd)
+	clientContactCmd.AddCommand(clientContactAddCmd)
+	clientContactCmd.AddCommand(clientContactListCmd)
+	clientContactCmd.AddCommand(clientContactEditCmd)
+	clientContactCmd.AddCommand(clientContactDeleteCmd)
+	clientCmd.AddCommand(clientAddressCmd)
+	clientAddressCmd.AddCommand(clientAddressSetCmd)
+	clientAddressCmd.AddCommand(clientAddressListCmd)
+	clientAddressCmd.AddCommand(clientAddressDeleteCmd)
+
+	for _, c := range []*cobra.Command{clientContactAddCmd, clientContactEditCmd} {
+		c.Flags().StringVar(&contactName, "name", "", "Contact name")
+		c.Flags().StringVar(&contactEmail, "email", "", "Contact email")
+		c.Flags().StringVar(&contactPhone, "phone", "", "Contact phone")
+		c.Flags().StringVar(&contactRole, "role", string(models.ContactBilling), "Role (billing, technical, legal)")
+		c.Flags().BoolVar(&contactCc, "cc", false, "Copy on invoice and contract emails")
+	}
+	clientContactAddCmd.MarkFlagRequired("email")
+}
+
+// loadClientProfile loads the contacts and addresses of a client
+func loadClientProfile(client *models.Client) error {
+	if err := db.GormDB.Where("client_id = ?", client.ID).Order("id").Find(&client.Contacts).Error; err != nil {
+		return fmt.Errorf("failed to load client contacts: %w", err)
+	}
+	if err := db.GormDB.Where("client_id = ?", client.ID).Order("id").Find(&client.Addresses).Error; err != nil {
+		return fmt.Errorf("failed to load client addresses: %w", err)
+	}
+	return nil
+}
+
+// checkPONumber returns an error when the client needs a PO number on its
+// invoices and there is none
+func checkPONumber(client models.Client, po string) error {
+	if client.PORequired && strings.TrimSpace(po) == "" {
+		return fmt.Errorf("client %s requires a PO number on invoices (use --po)", client.Name)
+	}
+	return nil
+}
+
+// parseContactRole validates a contact role from a flag
+func parseContactRole(role string) (models.ContactRole, error) {
+	for _, r := range models.ContactRoles {
+		if string(r) == strings.ToLower(role) {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown contact role %q (use billing, technical or legal)", role)
+}
+
+// parseAddressKind validates an address kind from an argument
+func parseAddressKind(kind string) (models.AddressKind, error) {
+	for _, k := range models.AddressKinds {
+		if string(k) == strings.ToLower(kind) {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown address kind %q (use billing, legal or postal)", kind)
+}
+
+// contactAddress validates a contact's email and returns it without a name
+func contactAddress(address string) (string, error) {
+	addresses, err := email.ParseAddresses(address)
+	if err != nil {
+		return "", err
+	}
+	if len(addresses) != 1 {
+		return "", fmt.Errorf("expected one email address, got %q", address)
+	}
+	return addresses[0], nil
+}
+
+// findClient returns the client with an ID argument
+func findClient(arg string) (*models.Client, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client ID: %s", arg)
+	}
+	var client models.Client
+	if err := db.GormDB.First(&client, id).Error; err != nil {
+		return nil, fmt.Errorf("client with ID %d not found", id)
+	}
+	return &client, nil
+}
+
+func runClientShow(cmd *cobra.Command, args []string) error {
+	client, err := findClient(args[0])
+	if err != nil {
+		return err
+	}
+	if err := loadClientProfile(client); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (ID: %d)\n", client.Name, client.ID)
+	fmt.Printf("  Email:          %s\n", client.Email)
+	if client.EmailCc != "" {
+		fmt.Printf("  Cc:             %s\n", client.EmailCc)
+	}
+	if client.TaxID != "" {
+		fmt.Printf("  Tax ID:         %s\n", client.TaxID)
+	}
+	currency := client.Currency
+	if currency == "" {
+		currency = "USD (default)"
+	}
+	fmt.Printf("  Currency:       %s\n", currency)
+	terms := fmt.Sprintf("%d days", client.PaymentTerms)
+	if client.PaymentTerms <= 0 {
+		terms = fmt.Sprintf("%d days (default)", models.DefaultPaymentTerms)
+	}
+	fmt.Printf("  Payment terms:  %s\n", terms)
+	if client.PORequired {
+		fmt.Printf("  PO number:      required\n")
+	}
+	if client.Language != "" {
+		fmt.Printf("  Language:       %s\n", client.Language)
+	}
+
+	fmt.Println("\nAddresses:")
+	if client.Address != "" {
+		fmt.Printf("  default  %s\n", client.Address)
+	}
+	for _, a := range client.Addresses {
+		fmt.Printf("  %-8s %s\n", a.Kind, a.Address)
+	}
+
+	fmt.Println("\nContacts:")
+	if len(client.Contacts) == 0 {
+		fmt.Printf("  none, emails go to %s\n", client.Email)
+		fmt.Printf("💡 Add one with: ung client contact add %d --name <name> --email <email>\n", client.ID)
+		return nil
+	}
+	printContacts(client.Contacts)
+	return nil
+}
+
+func printContacts(contacts []models.ClientContact) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ID\tNAME\tEMAIL\tPHONE\tROLE\tCC")
+	for _, c := range contacts {
+		cc := ""
+		if c.CcInvoices {
+			cc = "yes"
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.Name, c.Email, c.Phone, c.Role, cc)
+	}
+	w.Flush()
+}
+
+func runClientContactAdd(cmd *cobra.Command, args []string) error {
+	client, err := findClient(args[0])
+	if err != nil {
+		return err
+	}
+	role, err := parseContactRole(contactRole)
+	if err != nil {
+		return err
+	}
+	address, err := contactAddress(contactEmail)
+	if err != nil {
+		return err
+	}
+
+	contact := models.ClientContact{
+		ClientID:   client.ID,
+		Name:       contactName,
+		Email:      address,
+		Phone:      contactPhone,
+		Role:       role,
+		CcInvoices: contactCc,
+	}
+	if err := db.GormDB.Create(&contact).Error; err != nil {
+		return fmt.Errorf("failed to add contact: %w", err)
+	}
+	fmt.Printf("✓ Contact added to %s (ID: %d)\n", client.Name, contact.ID)
+	return nil
+}
+
+func runClientContactList(cmd *cobra.Command, args []string) error {
+	client, err := findClient(args[0])
+	if err != nil {
+		return err
+	}
+	if err := loadClientProfile(client); err != nil {
+		return err
+	}
+	if len(client.Contacts) == 0 {
+		fmt.Printf("No contacts for %s, emails go to %s.\n", client.Name, client.Email)
+		return nil
+	}
+	printContacts(client.Contacts)
+	return nil
+}
+
+func runClientContactEdit(cmd *cobra.Command, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid contact ID: %s", args[0])
+	}
+
+	updates := make(map[string]interface{})
+	if cmd.Flags().Changed("name") {
+		updates["name"] = contactName
+	}
+	if cmd.Flags().Changed("email") {
+		address, err := contactAddress(contactEmail)
+		if err != nil {
+			return err
+		}
+		updates["email"] = address
+	}
+	if cmd.Flags().Changed("phone") {
+		updates["phone"] = contactPhone
+	}
+	if cmd.Flags().Changed("role") {
+		role, err := parseContactRole(contactRole)
+		if err != nil {
+			return err
+		}
+		updates["role"] = role
+	}
+	if cmd.Flags().Changed("cc") {
+		updates["cc_invoices"] = contactCc
+	}
+	if len(updates) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	result := db.GormDB.Model(&models.ClientContact{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update contact: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("contact with ID %d not found", id)
+	}
+	fmt.Printf("✓ Contact %d updated successfully\n", id)
+	return nil
+}
+
+func runClientContactDelete(cmd *cobra.Command, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid contact ID: %s", args[0])
+	}
+	result := db.GormDB.Delete(&models.ClientContact{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove contact: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("contact with ID %d not found", id)
+	}
+	fmt.Printf("✓ Contact %d removed\n", id)
+	return nil
+}
+
+func runClientAddressSet(cmd *cobra.Command, args []string) error {
+	client, err := findClient(args[0])
+	if err != nil {
+		return err
+	}
+	kind, err := parseAddressKind(args[1])
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSpace(args[2])
+	if text == "" {
+		return fmt.Errorf("address can't be empty, remove it with: ung client address rm %d %s", client.ID, kind)
+	}
+
+	address := models.ClientAddress{ClientID: client.ID, Kind: kind}
+	if err := db.GormDB.Where(address).FirstOrInit(&address).Error; err != nil {
+		return fmt.Errorf("failed to load address: %w", err)
+	}
+	address.Address = text
+	if err := db.GormDB.Save(&address).Error; err != nil {
+		return fmt.Errorf("failed to save address: %w", err)
+	}
+	fmt.Printf("✓ %s address of %s set\n", kind, client.Name)
+	return nil
+}
+
+func runClientAddressList(cmd *cobra.Command, args []string) error {
+	client, err := findClient(args[0])
+	if err != nil {
+		return err
+	}
+	if err := loadClientProfile(client); err != nil {
+		return err
+	}
+
+	set := map[models.AddressKind]string{}
+	for _, a := range client.Addresses {
+		set[a.Kind] = a.Address
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tADDRESS")
+	for _, kind := range models.AddressKinds {
+		address, ok := set[kind]
+		if !ok {
+			address = client.Address + " (client address)"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", kind, address)
+	}
+	w.Flush()
+	return nil
+}
+
+func runClientAddressDelete(cmd *cobra.Command, args []string) error {
+	client, err := findClient(args[0])
+	if err != nil {
+		return err
+	}
+	kind, err := parseAddressKind(args[1])
+	if err != nil {
+		return err
+	}
+	result := db.GormDB.Where("client_id = ? AND kind = ?", client.ID, kind).Delete(&models.ClientAddress{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove address: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s has no %s address", client.Name, kind)
+	}
+	fmt.Printf("✓ %s address of %s removed\n", kind, client.Name)
+	return nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+func TestClientBillingProfile(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+
+	res, err := db.DB.Exec(`
+		INSERT INTO companies (name, email, phone, address, registration_address, tax_id, bank_name, bank_account, bank_swift, logo_path)
+		VALUES ('Profile Co', 'billing@profile.test', '', '', '', '', '', '', '', '')
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	companyID, _ := res.LastInsertId()
+	defer db.DB.Exec("DELETE FROM companies WHERE id = ?", companyID)
+
+	res, err = db.DB.Exec(`
+		INSERT INTO clients (name, email, address, tax_id, email_cc, currency, payment_terms_days, po_required)
+		VALUES ('Initech', 'info@initech.test', 'Initech HQ', '', 'cfo@initech.test', 'EUR', 14, 1)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID, _ := res.LastInsertId()
+
+	for _, c := range []models.ClientContact{
+		{ClientID: uint(clientID), Name: "Anna Schmidt", Email: "ap@initech.test", Role: models.ContactBilling},
+		{ClientID: uint(clientID), Name: "Tom Lee", Email: "tom@initech.test", Role: models.ContactTechnical, CcInvoices: true},
+		{ClientID: uint(clientID), Name: "CFO", Email: "CFO@initech.test", Role: models.ContactLegal, CcInvoices: true},
+	} {
+		if err := db.GormDB.Create(&c).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	address := models.ClientAddress{ClientID: uint(clientID), Kind: models.AddressBilling, Address: "Accounts Payable, PO Box 1"}
+	if err := db.GormDB.Create(&address).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	invoiceClientID, invoiceAmount, invoiceDescription = int(clientID), 100, "Consulting"
+	t.Cleanup(func() {
+		invoiceClientID, invoiceAmount, invoiceDescription, invoicePO, invoiceCompanyID = 0, 0, "", "", 0
+	})
+
+	// The client needs a PO number
+	if err := runInvoiceNew(invoiceNewCmd, nil); err == nil || !strings.Contains(err.Error(), "PO number") {
+		t.Fatalf("expected a missing PO number error, got %v", err)
+	}
+
+	// The currency and due date come from the client
+	invoicePO = "PO-77"
+	if err := runInvoiceNew(invoiceNewCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	var invoiceID int
+	db.DB.QueryRow("SELECT id FROM invoices ORDER BY id DESC LIMIT 1").Scan(&invoiceID)
+
+	inv, _, client, _, err := loadInvoiceForDocument(invoiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Currency != "EUR" || inv.PONumber != "PO-77" {
+		t.Errorf("expected a EUR invoice with PO-77, got %s %q", inv.Currency, inv.PONumber)
+	}
+	if days := inv.DueDate.Sub(inv.IssuedDate).Round(time.Hour).Hours() / 24; days != 14 {
+		t.Errorf("expected the invoice due after 14 days, got %.0f", days)
+	}
+	if client.AddressFor(models.AddressBilling) != "Accounts Payable, PO Box 1" || len(client.Contacts) != 3 {
+		t.Errorf("expected the billing profile to be loaded, got %+v", client)
+	}
+
+	// Invoices go to the billing contact, copying the client's copies and
+	// the contacts that want one
+	msg, err := invoiceEmail(invoiceID, email.KindInvoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(msg.To, ",") != "ap@initech.test" {
+		t.Errorf("expected the billing contact, got %v", msg.To)
+	}
+	if strings.Join(msg.Cc, ",") != "cfo@initech.test,tom@initech.test" {
+		t.Errorf("unexpected copies %v", msg.Cc)
+	}
+
+	// Without contacts of the role the client's email is used
+	msg = &email.Email{}
+	client.Contacts = client.ContactsFor(models.ContactTechnical)
+	msg.AddressTo(client, models.ContactLegal)
+	if strings.Join(msg.To, ",") != "info@initech.test" {
+		t.Errorf("expected the client's email, got %v", msg.To)
+	}
+
+	// Deleting the client deletes its billing profile
+	if err := deleteClient(int(clientID)); err != nil {
+		t.Fatal(err)
+	}
+	var left int
+	db.DB.QueryRow("SELECT (SELECT COUNT(*) FROM client_contacts WHERE client_id = ?) + (SELECT COUNT(*) FROM client_addresses WHERE client_id = ?)", clientID, clientID).Scan(&left)
+	if left != 0 {
+		t.Errorf("expected the billing profile to be deleted, %d rows left", left)
+	}
+	if err := deleteClient(int(clientID)); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
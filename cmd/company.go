@@ -6,8 +6,8 @@ import (
 	"strconv"
 	"text/tabwriter"
 
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/internal/repository"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/spf13/cobra"
 )
 
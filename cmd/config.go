@@ -16,8 +16,10 @@ var configCmd = &cobra.Command{
 	Long: `Manage ung configuration files.
 
 Configuration priority (highest to lowest):
-1. Local workspace: .ung/config.yaml (in current directory)
-2. Global: ~/.ung/config.yaml
+1. Profile given with --profile or UNG_PROFILE
+2. Local workspace: .ung/config.yaml (in current directory)
+3. Active profile: ~/.ung/profiles/<name>/config.yaml (see 'ung profile')
+4. Global: ~/.ung/config.yaml
 
 Use local workspace config for project-specific databases.
 Use global config for your default settings.
@@ -73,8 +75,8 @@ Use 'ung sync' or 'ung database' commands to manage data.`,
 }
 
 var (
-	configInitGlobal   bool
-	configInitICloud   bool
+	configInitGlobal      bool
+	configInitICloud      bool
 	configMigrateToGlobal bool
 	configMigrateToLocal  bool
 )
@@ -304,6 +306,9 @@ func runConfigShow(cmd *cobra.Command, args []string) {
 	if config.IsUsingLocalConfig() {
 		fmt.Println("\n💡 Using local workspace configuration.")
 		fmt.Println("   Use --global flag to use global config instead.")
+	} else if config.GetConfigSource() == config.SourceProfile {
+		fmt.Printf("\n💡 Using profile %s.\n", config.GetCurrentProfile())
+		fmt.Println("   Run 'ung profile ls' to see all profiles.")
 	} else if config.GetConfigSource() == config.SourceGlobal {
 		fmt.Println("\n💡 Using global configuration.")
 		fmt.Println("   Run 'ung config init' to create a local workspace config.")
@@ -319,6 +324,7 @@ func runConfigPath(cmd *cobra.Command, args []string) {
 	fmt.Println()
 
 	// Determine active config
+	config.Load()
 	activeSource := config.GetConfigSource()
 
 	// Check local config (.ung/config.yaml)
@@ -333,10 +339,17 @@ func runConfigPath(cmd *cobra.Command, args []string) {
 		fmt.Printf("⚪ Local:  %s (not found)\n", localAbsPath)
 	}
 
+	// Check profile config
+	if activeSource == config.SourceProfile {
+		fmt.Printf("✅ Profile: %s (active)\n", config.GetActiveConfigPath())
+	}
+
 	// Check global config
 	if _, err := os.Stat(globalConfig); err == nil {
 		if activeSource == config.SourceGlobal {
 			fmt.Printf("✅ Global: %s (active)\n", globalConfig)
+		} else if activeSource == config.SourceProfile {
+			fmt.Printf("⚪ Global: %s (overridden by profile)\n", globalConfig)
 		} else {
 			fmt.Printf("⚪ Global: %s (overridden by local)\n", globalConfig)
 		}
@@ -345,8 +358,10 @@ func runConfigPath(cmd *cobra.Command, args []string) {
 	}
 
 	fmt.Println("\n💡 Priority (highest to lowest):")
-	fmt.Println("   1. Local:  .ung/config.yaml")
-	fmt.Println("   2. Global: ~/.ung/config.yaml")
+	fmt.Println("   1. Profile: --profile or UNG_PROFILE")
+	fmt.Println("   2. Local:   .ung/config.yaml")
+	fmt.Println("   3. Profile: 'ung profile use'")
+	fmt.Println("   4. Global:  ~/.ung/config.yaml")
 
 	if activeSource == config.SourceDefault {
 		fmt.Println("\n⚠️  No config file found, using defaults.")
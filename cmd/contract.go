@@ -10,11 +10,14 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/internal/repository"
 	"github.com/Andriiklymiuk/ung/pkg/contract"
+	"github.com/Andriiklymiuk/ung/pkg/email"
 	"github.com/Andriiklymiuk/ung/pkg/idgen"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -54,7 +57,7 @@ var contractPDFCmd = &cobra.Command{
 
 var contractEmailCmd = &cobra.Command{
 	Use:   "email [contract-id]",
-	Short: "Export contract to email client",
+	Short: "Send contract over SMTP or export it to email client",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runContractEmail,
 }
@@ -75,13 +78,15 @@ Examples:
 var (
 	contractDeleteYes bool
 	contractClientID  int
-	contractName     string
-	contractType     string
-	contractRate     float64
-	contractPrice    float64
-	contractCurrency string
-	contractActive   bool
-	contractNotes    string
+	contractName      string
+	contractType      string
+	contractRate      float64
+	contractPrice     float64
+	contractCurrency  string
+	contractActive    bool
+	contractNotes     string
+	contractTax       string
+	contractEmailApp  string
 )
 
 func init() {
@@ -98,7 +103,8 @@ func init() {
 	contractAddCmd.Flags().StringVar(&contractType, "type", "", "Contract type (hourly, fixed_price, retainer)")
 	contractAddCmd.Flags().Float64Var(&contractRate, "rate", 0, "Hourly rate (for hourly contracts)")
 	contractAddCmd.Flags().Float64Var(&contractPrice, "price", 0, "Fixed price (for fixed_price contracts)")
-	contractAddCmd.Flags().StringVar(&contractCurrency, "currency", "USD", "Currency")
+	contractAddCmd.Flags().StringVar(&contractCurrency, "currency", "", "Currency (default: the client's, otherwise USD)")
+	contractAddCmd.Flags().StringVar(&contractTax, "tax-profile", "", "Tax profile of invoices for this contract (default: the client's)")
 
 	// Edit flags
 	contractEditCmd.Flags().StringVar(&contractName, "name", "", "Contract name")
@@ -107,8 +113,11 @@ func init() {
 	contractEditCmd.Flags().StringVar(&contractCurrency, "currency", "", "Currency")
 	contractEditCmd.Flags().BoolVar(&contractActive, "active", true, "Contract active status")
 	contractEditCmd.Flags().StringVar(&contractNotes, "notes", "", "Contract notes")
+	contractEditCmd.Flags().StringVar(&contractTax, "tax-profile", "", "Tax profile, empty for the client's")
 
 	// Delete flags
+	contractEmailCmd.Flags().StringVar(&contractEmailApp, "email-app", "", "Email client (smtp, apple, outlook, gmail)")
+
 	contractDeleteCmd.Flags().BoolVarP(&contractDeleteYes, "yes", "y", false, "Skip confirmation prompt")
 }
 
@@ -214,14 +223,9 @@ func runContractAdd(cmd *cobra.Command, args []string) error {
 
 				huh.NewInput().
 					Title("Currency").
+					Description("Leave empty for the client's currency").
 					Placeholder("USD").
-					Value(&selectedCurrency).
-					Validate(func(s string) error {
-						if s == "" {
-							return fmt.Errorf("currency is required")
-						}
-						return nil
-					}),
+					Value(&selectedCurrency),
 			).WithHideFunc(func() bool {
 				return selectedContractType == ""
 			}),
@@ -275,11 +279,17 @@ func runContractAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid contract type: %s (use hourly, fixed_price, or retainer)", contractType)
 	}
 
-	// Get client name for contract number generation
-	var clientName string
-	if err := db.DB.QueryRow("SELECT name FROM clients WHERE id = ?", contractClientID).Scan(&clientName); err != nil {
+	// Get client name for contract number generation, and its currency
+	var clientName, defaultCurrency string
+	if err := db.DB.QueryRow("SELECT name, currency FROM clients WHERE id = ?", contractClientID).Scan(&clientName, &defaultCurrency); err != nil {
 		return fmt.Errorf("client not found: %w", err)
 	}
+	if contractCurrency == "" {
+		contractCurrency = defaultCurrency
+	}
+	if contractCurrency == "" {
+		contractCurrency = "USD"
+	}
 
 	// Use current time as start date
 	startDate := time.Now()
@@ -290,10 +300,15 @@ func runContractAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate contract number: %w", err)
 	}
 
+	cfg, _ := config.Load()
+	if err := tax.Validate(cfg, contractTax); err != nil {
+		return err
+	}
+
 	// Insert contract
 	query := `
-		INSERT INTO contracts (contract_num, client_id, name, contract_type, hourly_rate, fixed_price, currency, start_date, active)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1)
+		INSERT INTO contracts (contract_num, client_id, name, contract_type, hourly_rate, fixed_price, currency, start_date, active, tax_profile)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?)
 	`
 
 	var ratePtr *float64
@@ -306,7 +321,7 @@ func runContractAdd(cmd *cobra.Command, args []string) error {
 		pricePtr = &contractPrice
 	}
 
-	result, err := db.DB.Exec(query, contractNum, contractClientID, contractName, ct, ratePtr, pricePtr, contractCurrency, startDate)
+	result, err := db.DB.Exec(query, contractNum, contractClientID, contractName, ct, ratePtr, pricePtr, contractCurrency, startDate, contractTax)
 	if err != nil {
 		return fmt.Errorf("failed to add contract: %w", err)
 	}
@@ -439,7 +454,8 @@ func runContractEdit(cmd *cobra.Command, args []string) error {
 	// Check if any flags were provided (non-interactive mode)
 	hasFlags := cmd.Flags().Changed("name") || cmd.Flags().Changed("rate") ||
 		cmd.Flags().Changed("price") || cmd.Flags().Changed("currency") ||
-		cmd.Flags().Changed("active") || cmd.Flags().Changed("notes")
+		cmd.Flags().Changed("active") || cmd.Flags().Changed("notes") ||
+		cmd.Flags().Changed("tax-profile")
 
 	if hasFlags {
 		// Non-interactive mode - use flags to update
@@ -462,6 +478,13 @@ func runContractEdit(cmd *cobra.Command, args []string) error {
 		if cmd.Flags().Changed("notes") {
 			updates["notes"] = contractNotes
 		}
+		if cmd.Flags().Changed("tax-profile") {
+			cfg, _ := config.Load()
+			if err := tax.Validate(cfg, contractTax); err != nil {
+				return err
+			}
+			updates["tax_profile"] = contractTax
+		}
 
 		if len(updates) == 0 {
 			return fmt.Errorf("no fields to update")
@@ -692,38 +715,27 @@ func runContractEmail(cmd *cobra.Command, args []string) error {
 		pdfPath = contractModel.PDFPath
 	}
 
-	// Prepare email details
-	subject := fmt.Sprintf("Contract: %s - %s", company.Name, contractModel.Name)
-	body := fmt.Sprintf("Hi,\n\nPlease find attached the contract for %s.\n\nBest regards,\n%s",
-		contractModel.Name, company.Name)
-
-	// Prompt for email client selection
-	var emailClient string
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select email client").
-				Options(
-					huh.NewOption("Apple Mail", "apple"),
-					huh.NewOption("Outlook", "outlook"),
-					huh.NewOption("Gmail (Browser)", "gmail"),
-				).
-				Value(&emailClient),
-		),
-	)
+	// Prepare email details from the client's template
+	msg, err := contractEmail(*contractModel, company)
+	if err != nil {
+		return err
+	}
 
-	if err := form.Run(); err != nil {
-		return fmt.Errorf("email export cancelled: %w", err)
+	emailClient, err := selectEmailClient(contractEmailApp)
+	if err != nil {
+		return err
 	}
 
-	// Export to selected email client
+	// Send, or export to selected email client
 	switch emailClient {
+	case "smtp":
+		return sendViaSMTP(msg, email.KindContract, 0, contractModel.ID)
 	case "apple":
-		return exportContractToAppleMail(subject, body, pdfPath)
+		return exportContractToAppleMail(msg.Subject, msg.Body, pdfPath)
 	case "outlook":
-		return exportContractToOutlook(subject, body, pdfPath)
+		return exportContractToOutlook(msg.Subject, msg.Body, pdfPath)
 	case "gmail":
-		return exportContractToGmail(subject, body, pdfPath)
+		return exportContractToGmail(msg.Subject, msg.Body, pdfPath)
 	default:
 		return fmt.Errorf("unknown email client: %s", emailClient)
 	}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/daemon"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the database open for editors and widgets",
+	Long: `Run in the background with the database open and answer JSON-RPC 2.0
+requests, so editor extensions and status bar widgets don't start a new ung
+(and decrypt the database) for every refresh.
+
+Requests go to a Unix socket next to the database (.ung/ung.sock), one JSON
+message per line. With --port the same methods are also served over HTTP on
+localhost: POST /rpc with a JSON body, and GET /events for server-sent events.
+HTTP clients send the token printed at start (or given with --token) as an
+"Authorization: Bearer" header or a ?token= parameter.
+
+Methods:
+  ping                   Version and process ID
+  events.subscribe       Receive timer.changed notifications on this connection
+  tracking.current       Running session, or null
+  tracking.start         {client_id, contract_id, project, notes, billable}
+  tracking.stop          Stop the running session
+  tracking.list          {unbilled, limit}
+  clients.list           All clients
+  invoices.list          {status: open|overdue|all|pending|sent|paid}
+  invoices.mark          {id, status}
+  dashboard.summary      Paid, pending, overdue, unbilled and this month's numbers
+  dashboard.revenue      {months} paid revenue and expenses by month
+  dashboard.unbilled     Unbilled time by client and contract
+
+timer.changed is sent whenever a timer starts or stops, also when that happens
+through 'ung track' in a terminal, as {"timer": {...}} or {"timer": null}.
+
+An encrypted database is only locked while a request is answered, so other
+ung commands keep working while the daemon runs. Their changes are decrypted
+again on the next request.
+
+Examples:
+  ung daemon
+  ung daemon --port 7531 --token s3cret
+  echo '{"jsonrpc":"2.0","id":1,"method":"tracking.current"}' | nc -U .ung/ung.sock`,
+	RunE: runDaemon,
+}
+
+var (
+	daemonSocket string
+	daemonPort   int
+	daemonToken  string
+)
+
+func init() {
+	daemonCmd.Flags().StringVar(&daemonSocket, "socket", "", "Unix socket path (default: ung.sock next to the database)")
+	daemonCmd.Flags().IntVarP(&daemonPort, "port", "p", 0, "Also serve HTTP on this localhost port")
+	daemonCmd.Flags().StringVar(&daemonToken, "token", "", "Token HTTP clients must send (generated by default)")
+
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	socketPath := daemonSocket
+	if socketPath == "" {
+		socketPath = filepath.Join(filepath.Dir(config.GetDatabasePath()), "ung.sock")
+	}
+	listener, err := listenSocket(socketPath)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	if daemonPort > 0 && daemonToken == "" {
+		token, err := generateToken()
+		if err != nil {
+			return err
+		}
+		daemonToken = token
+	}
+
+	server := daemon.New(db.GormDB, daemon.Options{
+		Version: Version,
+		Token:   daemonToken,
+		AfterWrite: func() error {
+			// Chain the audit entry now, the daemon may run for days
+			if _, err := audit.Seal(db.DB); err != nil {
+				return err
+			}
+			return db.Flush()
+		},
+		Acquire: db.Acquire,
+		Release: db.Release,
+	})
+	if err := db.Share(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	go server.Watch(ctx)
+
+	fmt.Printf("✓ Daemon for %s\n", config.GetDatabasePath())
+	fmt.Printf("  Socket: %s\n", socketPath)
+
+	errs := make(chan error, 2)
+	go func() { errs <- server.Serve(listener) }()
+
+	if daemonPort > 0 {
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(daemonPort))
+		httpListener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		httpServer := &http.Server{Handler: server.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := httpServer.Serve(httpListener); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+		fmt.Printf("  HTTP:   http://%s/rpc\n", addr)
+		fmt.Printf("  Token:  %s\n", daemonToken)
+	}
+	fmt.Println("💡 Press Ctrl+C to stop")
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		// Ctrl+C: return so the database is saved and closed on the main goroutine
+		return nil
+	}
+}
+
+// listenSocket listens on a Unix socket that only the current user can
+// open, replacing a socket left behind by a daemon that didn't shut down
+func listenSocket(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already running on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
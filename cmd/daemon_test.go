@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListenSocketReplacesStaleSocket(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, so keep it short
+	dir, err := os.MkdirTemp("", "ung")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ung.sock")
+
+	// A socket file nobody listens on, as left by a killed daemon
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenSocket(path)
+	if err != nil {
+		t.Fatalf("expected the stale socket to be replaced: %v", err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected socket permissions 0600, got %o", perm)
+	}
+
+	if _, err := listenSocket(path); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("expected a second daemon to be refused, got %v", err)
+	}
+}
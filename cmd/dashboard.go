@@ -6,9 +6,9 @@ import (
 	"text/tabwriter"
 	"time"
 
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/internal/repository"
 	"github.com/Andriiklymiuk/ung/pkg/format"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/spf13/cobra"
 )
 
@@ -166,7 +166,14 @@ func runDBSwitch(cmd *cobra.Command, args []string) {
 	dbDir := filepath.Dir(newDBPath)
 	cfg.InvoicesDir = filepath.Join(dbDir, "invoices")
 
-	if err := config.Save(cfg, false); err != nil {
+	// Inside a profile the profile's config is updated instead
+	var err error
+	if config.GetCurrentProfile() != "" {
+		err = config.SaveActive(cfg)
+	} else {
+		err = config.Save(cfg, false)
+	}
+	if err != nil {
 		fmt.Printf("❌ Failed to save config: %v\n", err)
 		return
 	}
@@ -21,11 +21,32 @@ This command will check:
 - Database connection and schema
 - Configuration files
 - Required directories and permissions
-- Data integrity`,
+- Data integrity
+
+With --deep it also checks invoice totals against their line items, line
+items and recipients of missing invoices, sessions without a valid duration,
+several running timers, duplicate invoice numbers, missing PDFs and schema
+drift. --fix previews the repairs, backs up the database and applies them in
+one transaction.
+
+Examples:
+  ung doctor
+  ung doctor --deep
+  ung doctor --deep --fix
+  ung doctor --fix --yes`,
 	Run: runDoctor,
 }
 
+var (
+	doctorDeep bool
+	doctorFix  bool
+	doctorYes  bool
+)
+
 func init() {
+	doctorCmd.Flags().BoolVar(&doctorDeep, "deep", false, "Run consistency checks on all data")
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair problems found by the deep checks (implies --deep)")
+	doctorCmd.Flags().BoolVarP(&doctorYes, "yes", "y", false, "Apply fixes without asking")
 	rootCmd.AddCommand(doctorCmd)
 }
 
@@ -38,13 +59,22 @@ type CheckResult struct {
 func runDoctor(cmd *cobra.Command, args []string) {
 	fmt.Println("🏥 Running ung health checks...")
 
+	// Doctor runs without the usual database setup so it still works when
+	// the database is broken; open it here if it can be opened
+	var initErr error
+	if db.DB == nil && fileExists(db.GetDBPath()) {
+		if initErr = db.Initialize(); initErr == nil {
+			dbInitialized = true
+		}
+	}
+
 	checks := []CheckResult{}
 
 	// Check 1: Database file exists
 	checks = append(checks, checkDatabaseExists())
 
 	// Check 2: Database connection
-	checks = append(checks, checkDatabaseConnection())
+	checks = append(checks, checkDatabaseConnection(initErr))
 
 	// Check 3: Database schema
 	checks = append(checks, checkDatabaseSchema())
@@ -61,6 +91,14 @@ func runDoctor(cmd *cobra.Command, args []string) {
 	// Check 7: Data integrity
 	checks = append(checks, checkDataIntegrity())
 
+	// Deep consistency checks
+	var issues []doctorIssue
+	if (doctorDeep || doctorFix) && db.DB != nil {
+		var deep []CheckResult
+		deep, issues = runDeepChecks()
+		checks = append(checks, deep...)
+	}
+
 	// Print results
 	okCount := 0
 	warningCount := 0
@@ -97,6 +135,17 @@ func runDoctor(cmd *cobra.Command, args []string) {
 	} else {
 		fmt.Println("\n✓ Your installation is functional, but some warnings were found.")
 	}
+
+	if len(issues) == 0 {
+		return
+	}
+	if !doctorFix {
+		fmt.Println("\n💡 Preview and apply repairs with: ung doctor --deep --fix")
+		return
+	}
+	if err := repairIssues(issues); err != nil {
+		fmt.Printf("\n❌ Repair failed: %v\n", err)
+	}
 }
 
 func checkDatabaseExists() CheckResult {
@@ -116,7 +165,14 @@ func checkDatabaseExists() CheckResult {
 	}
 }
 
-func checkDatabaseConnection() CheckResult {
+func checkDatabaseConnection(initErr error) CheckResult {
+	if initErr != nil {
+		return CheckResult{
+			Name:    "Database connection",
+			Status:  "error",
+			Message: fmt.Sprintf("Cannot open: %v", initErr),
+		}
+	}
 	if db.DB == nil {
 		return CheckResult{
 			Name:    "Database connection",
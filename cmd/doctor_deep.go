@@ -0,0 +1,556 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/backup"
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+	"github.com/charmbracelet/huh"
+)
+
+// doctorIssue is a problem found by a deep check, with the change that repairs it
+type doctorIssue struct {
+	Problem string
+	Fix     string              // What the repair does, empty when it has to be fixed by hand
+	apply   func(*sql.Tx) error // Nil when there is no automatic repair
+}
+
+// doctorCheck is a deep check of the data in the database
+type doctorCheck struct {
+	Name string
+	Run  func() ([]doctorIssue, error)
+}
+
+var doctorDeepChecks = []doctorCheck{
+	{"Invoice totals", checkInvoiceTotals},
+	{"Orphaned line items and recipients", checkOrphanedInvoiceRows},
+	{"Time session durations", checkSessionDurations},
+	{"Active timers", checkActiveTimers},
+	{"Invoice numbers", checkDuplicateInvoiceNumbers},
+	{"Invoice PDFs", checkInvoicePDFs},
+	{"Schema drift", checkSchemaDrift},
+}
+
+// maxIssuesShown limits how many issues of one check are listed
+const maxIssuesShown = 10
+
+// runDeepChecks runs the deep checks, returning their results and all issues found
+func runDeepChecks() ([]CheckResult, []doctorIssue) {
+	var results []CheckResult
+	var all []doctorIssue
+	for _, check := range doctorDeepChecks {
+		issues, err := check.Run()
+		if err != nil {
+			results = append(results, CheckResult{Name: check.Name, Status: "error", Message: fmt.Sprintf("Check failed: %v", err)})
+			continue
+		}
+		if len(issues) == 0 {
+			results = append(results, CheckResult{Name: check.Name, Status: "ok"})
+			continue
+		}
+
+		var lines []string
+		for i, issue := range issues {
+			if i == maxIssuesShown {
+				lines = append(lines, fmt.Sprintf("... and %d more", len(issues)-maxIssuesShown))
+				break
+			}
+			lines = append(lines, "• "+issue.Problem)
+		}
+		count := fmt.Sprintf("%d issues", len(issues))
+		if len(issues) == 1 {
+			count = "1 issue"
+		}
+		results = append(results, CheckResult{
+			Name:    fmt.Sprintf("%s (%s)", check.Name, count),
+			Status:  "warning",
+			Message: strings.Join(lines, "\n   "),
+		})
+		all = append(all, issues...)
+	}
+	return results, all
+}
+
+// repairIssues previews the fixes, backs up the database and applies them in one transaction
+func repairIssues(issues []doctorIssue) error {
+	var fixable, manual []doctorIssue
+	for _, issue := range issues {
+		if issue.apply != nil {
+			fixable = append(fixable, issue)
+		} else {
+			manual = append(manual, issue)
+		}
+	}
+
+	if len(manual) > 0 {
+		fmt.Printf("\nNeeds fixing by hand (%d):\n", len(manual))
+		for _, issue := range manual {
+			fmt.Printf("  • %s\n", issue.Problem)
+			if issue.Fix != "" {
+				fmt.Printf("    💡 %s\n", issue.Fix)
+			}
+		}
+	}
+	if len(fixable) == 0 {
+		fmt.Println("\n✓ Nothing to repair automatically")
+		return nil
+	}
+
+	fmt.Printf("\nProposed fixes (%d):\n", len(fixable))
+	for _, issue := range fixable {
+		fmt.Printf("  [Preview] %s\n", issue.Fix)
+	}
+
+	if !doctorYes {
+		var confirm bool
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Apply %d fixes?", len(fixable))).
+					Description("A backup snapshot is taken first.").
+					Affirmative("Yes, repair").
+					Negative("Cancel").
+					Value(&confirm),
+			),
+		)
+		if err := form.Run(); err != nil || !confirm {
+			fmt.Println("\n✅ Nothing was changed")
+			return nil
+		}
+	}
+
+	repo, err := openBackupRepository(true)
+	if err != nil {
+		return err
+	}
+	snap, err := backup.Create(repo, db.DB, backup.Options{SkipFiles: true})
+	if err != nil {
+		return fmt.Errorf("backup failed, nothing was changed: %w", err)
+	}
+	fmt.Printf("✓ Backed up to snapshot %s\n", snap.ID)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, issue := range fixable {
+		if err := issue.apply(tx); err != nil {
+			return fmt.Errorf("failed to %s, nothing was changed: %w", lowerFirst(issue.Fix), err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Applied %d fixes\n", len(fixable))
+	fmt.Printf("💡 Undo with: ung sync restore %s --replace\n", snap.ID)
+	return nil
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// execFix returns a repair that runs one statement
+func execFix(query string, args ...any) func(*sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, args...)
+		return err
+	}
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// checkInvoiceTotals compares line items with quantity × rate and invoices with
+// the sum of their line items after discounts, plus tax when they have any or
+// invoices are configured to add it
+func checkInvoiceTotals() ([]doctorIssue, error) {
+	var issues []doctorIssue
+
+	rows, err := db.DB.Query(`
+		SELECT l.id, i.invoice_num, l.item_name, l.quantity, l.rate, l.amount
+		FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id
+		ORDER BY l.id`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id int64
+		var invoiceNum, name string
+		var quantity, rate, amount float64
+		if err := rows.Scan(&id, &invoiceNum, &name, &quantity, &rate, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		want := roundCents(quantity * rate)
+		if math.Abs(want-amount) < 0.005 {
+			continue
+		}
+		issues = append(issues, doctorIssue{
+			Problem: fmt.Sprintf("%s line %q: amount %.2f, but %g × %.2f = %.2f", invoiceNum, name, amount, quantity, rate, want),
+			Fix:     fmt.Sprintf("Set line item #%d amount to %.2f", id, want),
+			apply:   execFix("UPDATE invoice_line_items SET amount = ? WHERE id = ?", want, id),
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Tax added on top of line items is an accepted total too: the tax
+	// stored on the invoice, or pdf.tax_rate for invoices from before it
+	taxRate := 0.0
+	if cfg, err := config.Load(); err == nil && !cfg.PDF.TaxInclusive {
+		taxRate = cfg.PDF.TaxRate
+	}
+
+	invoices, err := invoicesWithLineItems()
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range invoices {
+		// The totals as the invoice writers calculate them, after discounts
+		totals := tax.Calculate(inv.items)
+		subtotal := totals.Net
+		taxed := taxRate > 0 || inv.taxAmount != 0 || totals.Tax != 0
+		withTax := roundCents(subtotal * (1 + taxRate))
+		switch {
+		case totals.Tax != 0:
+			withTax = totals.Gross
+		case inv.taxAmount != 0:
+			withTax = roundCents(subtotal + inv.taxAmount)
+		}
+		if math.Abs(inv.amount-subtotal) < 0.005 || (taxed && math.Abs(inv.amount-withTax) < 0.005) {
+			continue
+		}
+		problem := fmt.Sprintf("%s: amount %.2f, but its line items add up to %.2f", inv.invoiceNum, inv.amount, subtotal)
+		if taxed {
+			problem += fmt.Sprintf(" (%.2f with tax)", withTax)
+		}
+		if inv.taxAmount == 0 && totals.Tax == 0 && taxRate > 0 {
+			// Without a stored tax there is no telling whether pdf.tax_rate applied to it
+			issues = append(issues, doctorIssue{
+				Problem: problem,
+				Fix:     fmt.Sprintf("Check whether %s includes tax and correct it with 'ung invoice edit %d'", inv.invoiceNum, inv.id),
+			})
+			continue
+		}
+		fixed := subtotal
+		if taxed {
+			fixed = withTax
+		}
+		issues = append(issues, doctorIssue{
+			Problem: problem,
+			Fix:     fmt.Sprintf("Set %s amount to %.2f", inv.invoiceNum, fixed),
+			apply: execFix("UPDATE invoices SET amount = ?, net_amount = ?, tax_amount = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+				fixed, subtotal, roundCents(fixed-subtotal), inv.id),
+		})
+	}
+	return issues, nil
+}
+
+// invoiceLines is an invoice's stored totals with its line items
+type invoiceLines struct {
+	id         int64
+	invoiceNum string
+	amount     float64
+	taxAmount  float64
+	items      []models.InvoiceLineItem
+}
+
+// invoicesWithLineItems loads the invoices that have line items. Line
+// amounts are taken as quantity × rate, which the line check enforces.
+func invoicesWithLineItems() ([]invoiceLines, error) {
+	rows, err := db.DB.Query(`
+		SELECT i.id, i.invoice_num, i.amount, i.tax_amount, l.quantity, l.rate, l.discount, l.discount_pct, l.tax_rate
+		FROM invoices i JOIN invoice_line_items l ON l.invoice_id = i.id
+		ORDER BY i.id, l.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []invoiceLines
+	for rows.Next() {
+		var inv invoiceLines
+		var item models.InvoiceLineItem
+		if err := rows.Scan(&inv.id, &inv.invoiceNum, &inv.amount, &inv.taxAmount,
+			&item.Quantity, &item.Rate, &item.Discount, &item.DiscountPct, &item.TaxRate); err != nil {
+			return nil, err
+		}
+		item.Amount = roundCents(item.Quantity * item.Rate)
+		if n := len(invoices); n == 0 || invoices[n-1].id != inv.id {
+			invoices = append(invoices, inv)
+		}
+		last := &invoices[len(invoices)-1]
+		last.items = append(last.items, item)
+	}
+	return invoices, rows.Err()
+}
+
+// checkOrphanedInvoiceRows finds line items and recipients of invoices or clients that no longer exist
+func checkOrphanedInvoiceRows() ([]doctorIssue, error) {
+	var issues []doctorIssue
+	orphans := []struct {
+		query string
+		label string // The orphaned row
+		ref   string // What it points at
+		table string
+	}{
+		{"SELECT id, invoice_id FROM invoice_line_items WHERE invoice_id NOT IN (SELECT id FROM invoices)",
+			"line item", "invoice", "invoice_line_items"},
+		{"SELECT id, invoice_id FROM invoice_recipients WHERE invoice_id NOT IN (SELECT id FROM invoices)",
+			"recipient", "invoice", "invoice_recipients"},
+		{"SELECT id, client_id FROM invoice_recipients WHERE invoice_id IN (SELECT id FROM invoices) AND client_id NOT IN (SELECT id FROM clients)",
+			"recipient", "client", "invoice_recipients"},
+	}
+	for _, o := range orphans {
+		rows, err := db.DB.Query(o.query)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id, ref int64
+			if err := rows.Scan(&id, &ref); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			issues = append(issues, doctorIssue{
+				Problem: fmt.Sprintf("%s #%d points at missing %s #%d", upperFirst(o.label), id, o.ref, ref),
+				Fix:     fmt.Sprintf("Delete %s #%d", o.label, id),
+				apply:   execFix(fmt.Sprintf("DELETE FROM %s WHERE id = ?", o.table), id),
+			})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+// checkSessionDurations finds finished sessions with a missing or negative duration
+func checkSessionDurations() ([]doctorIssue, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, start_time, end_time, duration, hours
+		FROM tracking_sessions
+		WHERE deleted_at IS NULL AND end_time IS NOT NULL
+		  AND (duration IS NULL OR duration < 0 OR hours < 0)
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []doctorIssue
+	for rows.Next() {
+		var id int64
+		var start, end time.Time
+		var duration sql.NullInt64
+		var hours sql.NullFloat64
+		if err := rows.Scan(&id, &start, &end, &duration, &hours); err != nil {
+			return nil, err
+		}
+
+		problem := fmt.Sprintf("Session #%d has no duration", id)
+		if duration.Valid && duration.Int64 < 0 {
+			problem = fmt.Sprintf("Session #%d has a negative duration (%ds)", id, duration.Int64)
+		} else if duration.Valid {
+			problem = fmt.Sprintf("Session #%d has negative hours (%.2f)", id, hours.Float64)
+		}
+		if end.Before(start) {
+			issues = append(issues, doctorIssue{
+				Problem: problem + ": it ends before it starts",
+				Fix:     fmt.Sprintf("Correct the times with: ung track edit %d", id),
+			})
+			continue
+		}
+
+		seconds := int64(end.Sub(start).Seconds())
+		fix := fmt.Sprintf("Set session #%d duration to %s from its start and end", id, formatDuration(seconds))
+		query := "UPDATE tracking_sessions SET duration = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+		args := []any{seconds, id}
+		if hours.Valid && hours.Float64 < 0 {
+			query = "UPDATE tracking_sessions SET duration = ?, hours = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+			args = []any{seconds, roundCents(float64(seconds) / 3600), id}
+		}
+		issues = append(issues, doctorIssue{Problem: problem, Fix: fix, apply: execFix(query, args...)})
+	}
+	return issues, rows.Err()
+}
+
+func formatDuration(seconds int64) string {
+	return fmt.Sprintf("%dh %02dm", seconds/3600, (seconds%3600)/60)
+}
+
+// checkActiveTimers finds more than one running session; all but the newest
+// are stopped when it started
+func checkActiveTimers() ([]doctorIssue, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, start_time, COALESCE(project_name, '')
+		FROM tracking_sessions
+		WHERE end_time IS NULL AND deleted_at IS NULL
+		ORDER BY start_time DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type timer struct {
+		id      int64
+		start   time.Time
+		project string
+	}
+	var timers []timer
+	for rows.Next() {
+		var t timer
+		if err := rows.Scan(&t.id, &t.start, &t.project); err != nil {
+			return nil, err
+		}
+		timers = append(timers, t)
+	}
+	if err := rows.Err(); err != nil || len(timers) < 2 {
+		return nil, err
+	}
+
+	newest := timers[0]
+	var issues []doctorIssue
+	for _, t := range timers[1:] {
+		seconds := int64(newest.start.Sub(t.start).Seconds())
+		hours := math.Round(float64(seconds)/3600*100) / 100
+		issues = append(issues, doctorIssue{
+			Problem: fmt.Sprintf("Session #%d (%s) has been running since %s alongside #%d",
+				t.id, t.project, t.start.Local().Format("2006-01-02 15:04"), newest.id),
+			Fix: fmt.Sprintf("Stop session #%d at %s, when #%d started (%s)",
+				t.id, newest.start.Local().Format("2006-01-02 15:04"), newest.id, formatDuration(seconds)),
+			apply: execFix("UPDATE tracking_sessions SET end_time = ?, duration = ?, hours = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+				newest.start, seconds, hours, t.id),
+		})
+	}
+	return issues, nil
+}
+
+// checkDuplicateInvoiceNumbers finds invoice numbers that differ only in case or spacing
+func checkDuplicateInvoiceNumbers() ([]doctorIssue, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, invoice_num FROM invoices
+		WHERE LOWER(TRIM(invoice_num)) IN (
+			SELECT LOWER(TRIM(invoice_num)) FROM invoices GROUP BY LOWER(TRIM(invoice_num)) HAVING COUNT(*) > 1
+		)
+		ORDER BY LOWER(TRIM(invoice_num)), id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taken := map[string]bool{}
+	all, err := db.DB.Query("SELECT LOWER(TRIM(invoice_num)) FROM invoices")
+	if err != nil {
+		return nil, err
+	}
+	for all.Next() {
+		var num string
+		all.Scan(&num)
+		taken[num] = true
+	}
+	all.Close()
+
+	var issues []doctorIssue
+	first := map[string]string{}
+	for rows.Next() {
+		var id int64
+		var num string
+		if err := rows.Scan(&id, &num); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(strings.TrimSpace(num))
+		original, seen := first[key]
+		if !seen {
+			first[key] = num
+			continue
+		}
+
+		renamed := strings.TrimSpace(num)
+		for n := 2; taken[strings.ToLower(renamed)]; n++ {
+			renamed = fmt.Sprintf("%s-%d", strings.TrimSpace(num), n)
+		}
+		taken[strings.ToLower(renamed)] = true
+		issues = append(issues, doctorIssue{
+			Problem: fmt.Sprintf("Invoice #%d number %q duplicates %q", id, num, original),
+			Fix:     fmt.Sprintf("Renumber invoice #%d from %q to %q", id, num, renamed),
+			apply:   execFix("UPDATE invoices SET invoice_num = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", renamed, id),
+		})
+	}
+	return issues, rows.Err()
+}
+
+// checkInvoicePDFs finds invoices whose PDF is missing on disk
+func checkInvoicePDFs() ([]doctorIssue, error) {
+	rows, err := db.DB.Query("SELECT id, invoice_num, pdf_path FROM invoices WHERE pdf_path IS NOT NULL AND pdf_path != '' ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []doctorIssue
+	for rows.Next() {
+		var id int64
+		var num, path string
+		if err := rows.Scan(&id, &num, &path); err != nil {
+			return nil, err
+		}
+		resolved := expandPathForUser(path)
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(db.GetInvoicesDir(), resolved)
+		}
+		if _, err := os.Stat(resolved); err == nil {
+			continue
+		}
+		issues = append(issues, doctorIssue{
+			Problem: fmt.Sprintf("%s: PDF %s is missing", num, path),
+			Fix:     fmt.Sprintf("Clear the PDF reference of %s (regenerate with: ung invoice --id %d --pdf)", num, id),
+			apply:   execFix("UPDATE invoices SET pdf_path = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?", id),
+		})
+	}
+	return issues, rows.Err()
+}
+
+// checkSchemaDrift compares the database schema with the one this version of ung expects
+func checkSchemaDrift() ([]doctorIssue, error) {
+	schemaIssues, err := db.CheckSchema()
+	if err != nil {
+		return nil, err
+	}
+	var issues []doctorIssue
+	for _, s := range schemaIssues {
+		issue := doctorIssue{Problem: upperFirst(s.Problem)}
+		if s.Fix != "" {
+			issue.Fix = "Run: " + strings.Join(strings.Fields(s.Fix), " ")
+			issue.apply = execFix(s.Fix)
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+)
+
+// applyFixes applies the automatic repairs of issues in one transaction
+func applyFixes(t *testing.T, issues []doctorIssue) {
+	t.Helper()
+	tx, err := db.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, issue := range issues {
+		if issue.apply == nil {
+			continue
+		}
+		if err := issue.apply(tx); err != nil {
+			t.Fatalf("fix %q failed: %v", issue.Fix, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDoctorDeepChecksAndFixes(t *testing.T) {
+	setupTestDB(t)
+	db.DB.Exec("DELETE FROM invoice_line_items")
+	db.DB.Exec("DELETE FROM invoice_recipients")
+	db.DB.Exec("DELETE FROM invoices")
+	db.DB.Exec("DELETE FROM sqlite_sequence WHERE name IN ('invoice_line_items', 'invoice_recipients')")
+
+	for _, stmt := range []string{
+		"INSERT INTO companies (id, name, email) VALUES (1, 'Me', 'me@test')",
+		"INSERT INTO clients (id, name, email) VALUES (1, 'Acme', 'ap@acme.test')",
+		"INSERT INTO invoices (id, invoice_num, company_id, amount, pdf_path) VALUES (1, 'INV-1', 1, 500, '/nonexistent/inv-1.pdf')",
+		"INSERT INTO invoices (id, invoice_num, company_id, amount) VALUES (2, 'inv-1 ', 1, 10)",
+		"INSERT INTO invoice_line_items (invoice_id, item_name, quantity, rate, amount) VALUES (1, 'Dev', 2, 100, 150), (1, 'QA', 1, 50, 50), (99, 'Ghost', 1, 1, 1)",
+		"INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (1, 1), (1, 42), (77, 1)",
+		`INSERT INTO tracking_sessions (id, project_name, start_time, end_time, duration) VALUES
+			(1, 'negative', '2024-03-01 10:00:00', '2024-03-01 12:00:00', -5),
+			(2, 'backwards', '2024-03-02 10:00:00', '2024-03-02 09:00:00', NULL),
+			(3, 'forgotten', '2024-03-03 09:00:00', NULL, NULL),
+			(4, 'current', '2024-03-03 10:30:00', NULL, NULL)`,
+	} {
+		if _, err := db.DB.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	results, issues := runDeepChecks()
+	for _, r := range results {
+		if r.Status == "error" {
+			t.Fatalf("%s: %s", r.Name, r.Message)
+		}
+	}
+	want := []string{
+		`INV-1 line "Dev": amount 150.00`,
+		"INV-1: amount 500.00",
+		"Line item #3 points at missing invoice #99",
+		"Recipient #3 points at missing invoice #77",
+		"Recipient #2 points at missing client #42",
+		"Session #1 has a negative duration",
+		"Session #2 has no duration: it ends before it starts",
+		"Session #3 (forgotten) has been running",
+		`Invoice #2 number "inv-1 " duplicates "INV-1"`,
+		"INV-1: PDF /nonexistent/inv-1.pdf is missing",
+	}
+	if len(issues) != len(want) {
+		t.Errorf("expected %d issues, got %d: %+v", len(want), len(issues), issues)
+	}
+	for _, w := range want {
+		found := false
+		for _, issue := range issues {
+			if strings.HasPrefix(issue.Problem, w) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing issue %q", w)
+		}
+	}
+
+	applyFixes(t, issues)
+
+	var amount float64
+	var pdfPath *string
+	db.DB.QueryRow("SELECT amount, pdf_path FROM invoices WHERE id = 1").Scan(&amount, &pdfPath)
+	if amount != 250 || pdfPath != nil {
+		t.Errorf("expected INV-1 total 250 without PDF, got %.2f %v", amount, pdfPath)
+	}
+	var num string
+	db.DB.QueryRow("SELECT invoice_num FROM invoices WHERE id = 2").Scan(&num)
+	if num != "inv-1-2" {
+		t.Errorf("expected duplicate to be renumbered, got %q", num)
+	}
+	var duration int
+	db.DB.QueryRow("SELECT duration FROM tracking_sessions WHERE id = 1").Scan(&duration)
+	if duration != 7200 {
+		t.Errorf("expected duration recomputed to 7200, got %d", duration)
+	}
+	var running int
+	db.DB.QueryRow("SELECT COUNT(*) FROM tracking_sessions WHERE end_time IS NULL").Scan(&running)
+	if running != 1 {
+		t.Errorf("expected one running timer, got %d", running)
+	}
+	var hours float64
+	db.DB.QueryRow("SELECT hours FROM tracking_sessions WHERE id = 3").Scan(&hours)
+	if hours != 1.5 {
+		t.Errorf("expected the stopped timer to have 1.5 hours, got %v", hours)
+	}
+
+	// Only the issue that needs a manual fix is left
+	_, issues = runDeepChecks()
+	if len(issues) != 1 || issues[0].apply != nil || !strings.Contains(issues[0].Fix, "ung track edit 2") {
+		t.Errorf("expected only the backwards session to remain, got %+v", issues)
+	}
+}
+
+func TestCheckInvoiceTotalsWithConfiguredTax(t *testing.T) {
+	setupTestDB(t)
+	db.DB.Exec("DELETE FROM invoice_line_items")
+	db.DB.Exec("DELETE FROM invoices")
+
+	configPath := filepath.Join(os.Getenv("HOME"), ".ung", "config.yaml")
+	f, err := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open config: %v", err)
+	}
+	f.WriteString("pdf:\n  tax_rate: 0.2\n")
+	f.Close()
+	config.Reload()
+
+	for _, stmt := range []string{
+		"INSERT INTO companies (id, name, email) VALUES (1, 'Me', 'me@test')",
+		"INSERT INTO invoices (id, invoice_num, company_id, amount) VALUES (1, 'INV-1', 1, 120), (2, 'INV-2', 1, 130)",
+		"INSERT INTO invoice_line_items (invoice_id, item_name, quantity, rate, amount) VALUES (1, 'Dev', 1, 100, 100), (2, 'Dev', 1, 100, 100)",
+	} {
+		if _, err := db.DB.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	// Without a stored tax the amount can't be corrected automatically
+	issues, err := checkInvoiceTotals()
+	if err != nil {
+		t.Fatalf("checkInvoiceTotals() error: %v", err)
+	}
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Problem, "INV-2:") || issues[0].apply != nil {
+		t.Fatalf("expected a manual issue for INV-2 only, got %+v", issues)
+	}
+}
+
+func TestCheckInvoiceTotalsWithDiscounts(t *testing.T) {
+	setupTestDB(t)
+	db.DB.Exec("DELETE FROM invoice_line_items")
+	db.DB.Exec("DELETE FROM invoices")
+
+	for _, stmt := range []string{
+		"INSERT INTO companies (id, name, email) VALUES (1, 'Me', 'me@test')",
+		`INSERT INTO invoices (id, invoice_num, company_id, amount, net_amount, tax_amount) VALUES
+			(1, 'INV-1', 1, 180, 180, 0), (2, 'INV-2', 1, 95.2, 80, 15.2), (3, 'INV-3', 1, 100, 100, 0)`,
+		`INSERT INTO invoice_line_items (invoice_id, item_name, quantity, rate, amount, discount, discount_pct, tax_rate, tax_amount) VALUES
+			(1, 'Dev', 2, 100, 200, 0, 10, 0, 0), (2, 'Dev', 1, 100, 100, 20, 0, 0.19, 15.2), (3, 'Dev', 1, 100, 100, 10, 0, 0, 0)`,
+	} {
+		if _, err := db.DB.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	// Discounted invoices are consistent, only INV-3 lost its discount
+	issues, err := checkInvoiceTotals()
+	if err != nil {
+		t.Fatalf("checkInvoiceTotals() error: %v", err)
+	}
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Problem, "INV-3:") {
+		t.Fatalf("expected an issue for INV-3 only, got %+v", issues)
+	}
+
+	applyFixes(t, issues)
+
+	for id, want := range map[int]float64{1: 180, 2: 95.2, 3: 90} {
+		var amount float64
+		db.DB.QueryRow("SELECT amount FROM invoices WHERE id = ?", id).Scan(&amount)
+		if amount != want {
+			t.Errorf("invoice %d: expected amount %.2f, got %.2f", id, want, amount)
+		}
+	}
+}
+
+func TestCheckSchemaDrift(t *testing.T) {
+	setupTestDB(t)
+	if issues, err := checkSchemaDrift(); err != nil || len(issues) != 0 {
+		t.Fatalf("expected no drift in a fresh database, got %+v, %v", issues, err)
+	}
+
+	db.DB.Exec("ALTER TABLE clients DROP COLUMN tax_id")
+	issues, err := checkSchemaDrift()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Problem != "Column clients.tax_id is missing" {
+		t.Fatalf("expected missing column, got %+v", issues)
+	}
+	applyFixes(t, issues)
+	if issues, _ := checkSchemaDrift(); len(issues) != 0 {
+		t.Errorf("expected drift to be repaired, got %+v", issues)
+	}
+
+	// Migrations are compared with the ones built in, not files next to the working directory
+	db.DB.Exec("DELETE FROM schema_migrations WHERE version = '000001_init_schema'")
+	db.DB.Exec("INSERT INTO schema_migrations (version) VALUES ('999999_from_the_future')")
+	issues, err = checkSchemaDrift()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 || !strings.Contains(issues[0].Problem, "999999") || !strings.Contains(issues[1].Problem, "000001") {
+		t.Errorf("expected an unknown and an unapplied migration, got %+v", issues)
+	}
+}
@@ -17,10 +17,14 @@ var emailCmd = &cobra.Command{
 	Short: "Manage email configuration",
 	Long: `Configure SMTP settings for sending invoices and contracts via email.
 
+With SMTP set up, --email-app smtp sends emails straight from the CLI,
+e.g. on a headless server, and keeps a send log in the outbox.
+
 Examples:
   ung email setup       # Interactive SMTP configuration
   ung email test        # Test email connection
-  ung email show        # Show current email config`,
+  ung email show        # Show current email config
+  ung email outbox      # Show sent and queued emails`,
 }
 
 var emailSetupCmd = &cobra.Command{
@@ -69,19 +73,32 @@ func runEmailSetup(cmd *cobra.Command, args []string) {
 	var (
 		smtpHost    = cfg.Email.SMTPHost
 		smtpPortInt = cfg.Email.SMTPPort
+		security    = cfg.Email.Security
+		auth        = cfg.Email.Auth
 		username    = cfg.Email.Username
 		password    = cfg.Email.Password
+		token       = cfg.Email.OAuth2Token
+		tokenCmd    = cfg.Email.OAuth2TokenCmd
 		fromEmail   = cfg.Email.FromEmail
 		fromName    = cfg.Email.FromName
-		useTLS      = cfg.Email.UseTLS
+		app         = cfg.Email.App
 	)
 
 	// Set defaults if empty
 	if smtpPortInt == 0 {
 		smtpPortInt = 587
 	}
-	if useTLS == false && smtpPortInt == 587 {
-		useTLS = true
+	if security == "" {
+		security = email.SecurityStartTLS
+		if cfg.Email.UseTLS {
+			security = email.SecurityTLS
+		}
+	}
+	if auth == "" {
+		auth = email.AuthPlain
+	}
+	if app == "" {
+		app = "smtp"
 	}
 
 	// Convert port to string for form input
@@ -102,7 +119,7 @@ func runEmailSetup(cmd *cobra.Command, args []string) {
 
 			huh.NewInput().
 				Title("SMTP Port").
-				Description("Common: 587 (TLS), 465 (SSL), 25 (no encryption)").
+				Description("Common: 587 (STARTTLS), 465 (TLS), 25 (no encryption)").
 				Value(&smtpPort).
 				Validate(func(s string) error {
 					if s == "" {
@@ -118,29 +135,55 @@ func runEmailSetup(cmd *cobra.Command, args []string) {
 					return nil
 				}),
 
+			huh.NewSelect[string]().
+				Title("Encryption").
+				Options(
+					huh.NewOption("STARTTLS (port 587)", email.SecurityStartTLS),
+					huh.NewOption("TLS (port 465)", email.SecurityTLS),
+					huh.NewOption("None (local relay)", email.SecurityNone),
+				).
+				Value(&security),
+		),
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Login").
+				Options(
+					huh.NewOption("Password (PLAIN)", email.AuthPlain),
+					huh.NewOption("Password (LOGIN, older Exchange servers)", email.AuthLogin),
+					huh.NewOption("OAuth2 token (XOAUTH2, Gmail and Microsoft 365)", email.AuthXOAuth2),
+				).
+				Value(&auth),
+
 			huh.NewInput().
 				Title("Username").
-				Description("Your email address or SMTP username").
-				Value(&username).
-				Validate(func(s string) error {
-					if s == "" {
-						return fmt.Errorf("username is required")
-					}
-					return nil
-				}),
-
+				Description("Your email address or SMTP username, empty to send without login").
+				Value(&username),
+		),
+		huh.NewGroup(
 			huh.NewInput().
 				Title("Password").
 				Description("Use app-specific password for Gmail/Outlook").
 				Value(&password).
 				Password(true).
 				Validate(func(s string) error {
-					if s == "" {
+					if s == "" && username != "" {
 						return fmt.Errorf("password is required")
 					}
 					return nil
 				}),
-		),
+		).WithHideFunc(func() bool { return auth == email.AuthXOAuth2 }),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Token Command").
+				Description("Command printing a fresh access token, e.g. oama access me@gmail.com").
+				Value(&tokenCmd).
+				Validate(func(s string) error {
+					if s == "" && token == "" {
+						return fmt.Errorf("token command is required")
+					}
+					return nil
+				}),
+		).WithHideFunc(func() bool { return auth != email.AuthXOAuth2 }),
 		huh.NewGroup(
 			huh.NewInput().
 				Title("From Email").
@@ -158,10 +201,16 @@ func runEmailSetup(cmd *cobra.Command, args []string) {
 				Description("Display name for sender").
 				Value(&fromName),
 
-			huh.NewConfirm().
-				Title("Use TLS?").
-				Description("Enable TLS encryption (recommended)").
-				Value(&useTLS),
+			huh.NewSelect[string]().
+				Title("Send Invoices With").
+				Description("Default for --email-app").
+				Options(
+					huh.NewOption("SMTP (send now)", "smtp"),
+					huh.NewOption("Apple Mail", "apple"),
+					huh.NewOption("Outlook", "outlook"),
+					huh.NewOption("Gmail (Browser)", "gmail"),
+				).
+				Value(&app),
 		),
 	)
 
@@ -173,15 +222,40 @@ func runEmailSetup(cmd *cobra.Command, args []string) {
 	// Convert port string to int
 	portInt, _ := strconv.Atoi(smtpPort) // Already validated
 
+	// Only keep the secret of the chosen login
+	if auth == email.AuthXOAuth2 {
+		password = ""
+	} else {
+		token, tokenCmd = "", ""
+	}
+
 	// Update config
 	cfg.Email = config.EmailConfig{
-		SMTPHost:  smtpHost,
-		SMTPPort:  portInt,
-		Username:  username,
-		Password:  password,
-		FromEmail: fromEmail,
-		FromName:  fromName,
-		UseTLS:    useTLS,
+		SMTPHost:       smtpHost,
+		SMTPPort:       portInt,
+		Username:       username,
+		Password:       password,
+		FromEmail:      fromEmail,
+		FromName:       fromName,
+		UseTLS:         security == email.SecurityTLS,
+		Security:       security,
+		Auth:           auth,
+		OAuth2Token:    token,
+		OAuth2TokenCmd: tokenCmd,
+		App:            app,
+	}
+
+	// A profile keeps its own email account
+	if profile := config.GetCurrentProfile(); profile != "" {
+		if err := config.SaveActive(cfg); err != nil {
+			fmt.Printf("❌ Failed to save configuration: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Email configuration saved to profile %s\n", profile)
+		fmt.Printf("   Config: %s\n", config.GetActiveConfigPath())
+		fmt.Println("\n💡 Test your configuration:")
+		fmt.Println("   ung email test")
+		return
 	}
 
 	// Ask if global or local
@@ -232,15 +306,7 @@ func runEmailTest(cmd *cobra.Command, args []string) {
 	}
 
 	// Convert to email config
-	emailCfg := &email.Config{
-		SMTPHost:  cfg.Email.SMTPHost,
-		SMTPPort:  cfg.Email.SMTPPort,
-		Username:  cfg.Email.Username,
-		Password:  cfg.Email.Password,
-		FromEmail: cfg.Email.FromEmail,
-		FromName:  cfg.Email.FromName,
-		UseTLS:    cfg.Email.UseTLS,
-	}
+	emailCfg := smtpConfig(cfg)
 
 	// Validate config
 	if err := email.ValidateConfig(emailCfg); err != nil {
@@ -256,7 +322,8 @@ func runEmailTest(cmd *cobra.Command, args []string) {
 		fmt.Println("\n💡 Common issues:")
 		fmt.Println("   - Wrong password (use app-specific password for Gmail/Outlook)")
 		fmt.Println("   - SMTP host or port incorrect")
-		fmt.Println("   - TLS settings mismatch")
+		fmt.Println("   - Encryption mismatch (STARTTLS on 587, TLS on 465)")
+		fmt.Println("   - Expired OAuth2 token")
 		fmt.Println("   - Account security settings blocking access")
 		return
 	}
@@ -283,11 +350,23 @@ func runEmailShow(cmd *cobra.Command, args []string) {
 	fmt.Println("📧 Email Configuration")
 	fmt.Printf("SMTP Host:  %s\n", cfg.Email.SMTPHost)
 	fmt.Printf("SMTP Port:  %d\n", cfg.Email.SMTPPort)
+	fmt.Printf("Security:   %s\n", emailSecurity(cfg.Email))
 	fmt.Printf("Username:   %s\n", cfg.Email.Username)
-	fmt.Printf("Password:   %s\n", maskPassword(cfg.Email.Password))
+	if strings.EqualFold(cfg.Email.Auth, email.AuthXOAuth2) {
+		fmt.Printf("Auth:       xoauth2\n")
+		if cfg.Email.OAuth2TokenCmd != "" {
+			fmt.Printf("Token Cmd:  %s\n", cfg.Email.OAuth2TokenCmd)
+		} else {
+			fmt.Printf("Token:      %s\n", maskPassword(cfg.Email.OAuth2Token))
+		}
+	} else {
+		fmt.Printf("Password:   %s\n", maskPassword(cfg.Email.Password))
+	}
 	fmt.Printf("From Email: %s\n", cfg.Email.FromEmail)
 	fmt.Printf("From Name:  %s\n", cfg.Email.FromName)
-	fmt.Printf("Use TLS:    %v\n", cfg.Email.UseTLS)
+	if cfg.Email.App != "" {
+		fmt.Printf("Send With:  %s\n", cfg.Email.App)
+	}
 
 	// Show config source
 	configSource := "default"
@@ -320,15 +399,7 @@ func runEmailSendTest(cmd *cobra.Command, args []string) {
 	}
 
 	// Convert to email config
-	emailCfg := &email.Config{
-		SMTPHost:  cfg.Email.SMTPHost,
-		SMTPPort:  cfg.Email.SMTPPort,
-		Username:  cfg.Email.Username,
-		Password:  cfg.Email.Password,
-		FromEmail: cfg.Email.FromEmail,
-		FromName:  cfg.Email.FromName,
-		UseTLS:    cfg.Email.UseTLS,
-	}
+	emailCfg := smtpConfig(cfg)
 
 	// Create test email
 	testEmail := &email.Email{
@@ -358,9 +429,22 @@ UNG CLI`,
 	}
 
 	fmt.Println("✅ Test email sent successfully!")
+	fmt.Printf("   Message-ID: %s\n", testEmail.MessageID)
 	fmt.Printf("   Check %s inbox\n", recipient)
 }
 
+// emailSecurity describes the connection security of the email config
+func emailSecurity(cfg config.EmailConfig) string {
+	switch {
+	case cfg.Security != "":
+		return cfg.Security
+	case cfg.UseTLS:
+		return email.SecurityTLS
+	default:
+		return "starttls when offered"
+	}
+}
+
 func maskPassword(password string) string {
 	if password == "" {
 		return "(not set)"
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var emailOutboxCmd = &cobra.Command{
+	Use:     "outbox",
+	Aliases: []string{"log"},
+	Short:   "Show emails sent over SMTP",
+	Long: `Show the send log of emails sent with --email-app smtp.
+
+An email that can't be sent stays queued and is retried with backoff, after
+1, 4, 16 and 64 minutes, then every few hours, up to 6 attempts. Emails
+the server rejects fail right away. Run 'ung email outbox flush' from cron
+or a systemd timer to retry queued emails on a headless machine.
+
+Examples:
+  ung email outbox                    Recent emails
+  ung email outbox --status queued    Emails waiting for a retry
+  ung email outbox flush              Retry the queued emails that are due
+  ung email outbox retry 12           Send email #12 again now`,
+	RunE: runEmailOutboxList,
+}
+
+var emailOutboxFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Send the queued emails that are due",
+	RunE:  runEmailOutboxFlush,
+}
+
+var emailOutboxRetryCmd = &cobra.Command{
+	Use:   "retry <id>",
+	Short: "Send a queued or failed email again now",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEmailOutboxRetry,
+}
+
+var (
+	emailOutboxStatus string
+	emailOutboxLimit  int
+)
+
+// outboxMaxAttempts is how often an email is tried before it fails
+const outboxMaxAttempts = 6
+
+func init() {
+	emailCmd.AddCommand(emailOutboxCmd)
+	emailOutboxCmd.AddCommand(emailOutboxFlushCmd)
+	emailOutboxCmd.AddCommand(emailOutboxRetryCmd)
+
+	emailOutboxCmd.Flags().StringVar(&emailOutboxStatus, "status", "", "Only emails with this status (queued, sent, failed)")
+	emailOutboxCmd.Flags().IntVarP(&emailOutboxLimit, "limit", "n", 20, "Number of emails to show")
+}
+
+// smtpConfig returns the SMTP settings of the config
+func smtpConfig(cfg *config.Config) *email.Config {
+	return &email.Config{
+		SMTPHost:       cfg.Email.SMTPHost,
+		SMTPPort:       cfg.Email.SMTPPort,
+		Username:       cfg.Email.Username,
+		Password:       cfg.Email.Password,
+		FromEmail:      cfg.Email.FromEmail,
+		FromName:       cfg.Email.FromName,
+		UseTLS:         cfg.Email.UseTLS,
+		Security:       cfg.Email.Security,
+		Auth:           cfg.Email.Auth,
+		OAuth2Token:    cfg.Email.OAuth2Token,
+		OAuth2TokenCmd: cfg.Email.OAuth2TokenCmd,
+	}
+}
+
+// sendViaSMTP queues an email in the outbox and makes the first attempt.
+// An email that can't be sent yet stays queued for a retry, which isn't an
+// error; one the server rejects is.
+func sendViaSMTP(msg *email.Email, kind string, invoiceID, contractID uint) error {
+	cfg, _ := config.Load()
+	if cfg.Email.SMTPHost == "" {
+		return fmt.Errorf("SMTP not configured, run: ung email setup")
+	}
+	if len(msg.To) == 0 {
+		return fmt.Errorf("no recipient, set the client's email address")
+	}
+
+	row, err := queueEmail(msg, kind, invoiceID, contractID)
+	if err != nil {
+		return err
+	}
+	if err := deliverOutboxEmail(row, smtpConfig(cfg), time.Now()); err != nil {
+		if row.Status == models.OutboxFailed {
+			return fmt.Errorf("failed to send email #%d: %w", row.ID, err)
+		}
+		fmt.Printf("⚠️  Email #%d not sent yet, retrying after %s: %v\n", row.ID, row.NextAttemptAt.Format("15:04"), err)
+		fmt.Println("💡 Retry queued emails with: ung email outbox flush")
+		return nil
+	}
+
+	fmt.Printf("✓ Email sent to %s (%s)\n", strings.Join(msg.To, ", "), row.MessageID)
+	return nil
+}
+
+// queueEmail adds an email to the outbox. The Message-ID is chosen now so
+// every attempt sends the same message.
+func queueEmail(msg *email.Email, kind string, invoiceID, contractID uint) (*models.OutboxEmail, error) {
+	if msg.MessageID == "" {
+		cfg, _ := config.Load()
+		msg.MessageID = email.NewMessageID(cfg.Email.FromEmail)
+	}
+
+	row := &models.OutboxEmail{
+		Kind:          kind,
+		MessageID:     msg.MessageID,
+		Recipients:    strings.Join(msg.To, ", "),
+		Cc:            strings.Join(msg.Cc, ", "),
+		Bcc:           strings.Join(msg.Bcc, ", "),
+		Subject:       msg.Subject,
+		Body:          msg.Body,
+		HTMLBody:      msg.HTMLBody,
+		Attachments:   strings.Join(msg.Attachments, "\n"),
+		Status:        models.OutboxQueued,
+		NextAttemptAt: time.Now(),
+	}
+	if invoiceID != 0 {
+		row.InvoiceID = &invoiceID
+	}
+	if contractID != 0 {
+		row.ContractID = &contractID
+	}
+	if err := db.GormDB.Create(row).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue email: %w", err)
+	}
+	return row, nil
+}
+
+// deliverOutboxEmail makes one attempt to send an outbox email and records
+// the result: sent, queued for a retry after a backoff, or failed when the
+// server rejected it or it's out of attempts. A sent invoice email stamps
+// the invoice's sent_at.
+func deliverOutboxEmail(row *models.OutboxEmail, cfg *email.Config, now time.Time) error {
+	msg := &email.Email{
+		To:          email.SplitAddresses(row.Recipients),
+		Cc:          email.SplitAddresses(row.Cc),
+		Bcc:         email.SplitAddresses(row.Bcc),
+		Subject:     row.Subject,
+		Body:        row.Body,
+		HTMLBody:    row.HTMLBody,
+		Attachments: splitLines(row.Attachments),
+		MessageID:   row.MessageID,
+	}
+
+	sendErr := email.Send(cfg, msg)
+	row.Attempts++
+	if sendErr == nil {
+		row.Status = models.OutboxSent
+		row.SentAt = &now
+		row.LastError = ""
+	} else {
+		row.LastError = sendErr.Error()
+		if email.Permanent(sendErr) || row.Attempts >= outboxMaxAttempts {
+			row.Status = models.OutboxFailed
+		} else {
+			row.NextAttemptAt = now.Add(email.RetryDelay(row.Attempts))
+		}
+	}
+	if err := db.GormDB.Save(row).Error; err != nil {
+		return fmt.Errorf("failed to update outbox: %w", err)
+	}
+
+	if sendErr == nil && row.InvoiceID != nil && row.Kind == email.KindInvoice {
+		_, err := db.DB.Exec(`
+			UPDATE invoices SET sent_at = ?, status = CASE WHEN status = ? THEN ? ELSE status END, updated_at = ?
+			WHERE id = ?
+		`, now, models.StatusPending, models.StatusSent, now, *row.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("failed to mark invoice as sent: %w", err)
+		}
+	}
+	return sendErr
+}
+
+// flushOutbox makes an attempt for each queued email that is due and
+// returns how many were sent and how many weren't
+func flushOutbox(cfg *email.Config, now time.Time) (sent, notSent int, err error) {
+	var rows []models.OutboxEmail
+	err = db.GormDB.Where("status = ? AND next_attempt_at <= ?", models.OutboxQueued, now).
+		Order("id").Find(&rows).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch outbox: %w", err)
+	}
+
+	for i := range rows {
+		if err := deliverOutboxEmail(&rows[i], cfg, now); err != nil {
+			fmt.Printf("  ❌ #%d to %s: %v\n", rows[i].ID, rows[i].Recipients, err)
+			notSent++
+			continue
+		}
+		fmt.Printf("  ✓ #%d to %s\n", rows[i].ID, rows[i].Recipients)
+		sent++
+	}
+	return sent, notSent, nil
+}
+
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func runEmailOutboxList(cmd *cobra.Command, args []string) error {
+	query := db.GormDB.Order("id DESC").Limit(emailOutboxLimit)
+	if emailOutboxStatus != "" {
+		query = query.Where("status = ?", emailOutboxStatus)
+	}
+	var rows []models.OutboxEmail
+	if err := query.Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to fetch outbox: %w", err)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No emails sent over SMTP yet.")
+		fmt.Println("💡 Send one with: ung invoice --id 5 --email --email-app smtp")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tKIND\tTO\tSUBJECT\tTRIES\tWHEN\tMESSAGE-ID / ERROR")
+	for _, row := range rows {
+		when, detail := row.NextAttemptAt.Format("2006-01-02 15:04"), row.LastError
+		switch {
+		case row.SentAt != nil:
+			when, detail = row.SentAt.Format("2006-01-02 15:04"), row.MessageID
+		case row.Status == models.OutboxFailed:
+			when = row.UpdatedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			row.ID, row.Status, row.Kind, row.Recipients, truncate(row.Subject, 40), row.Attempts, when, truncate(detail, 60))
+	}
+	w.Flush()
+	return nil
+}
+
+func runEmailOutboxFlush(cmd *cobra.Command, args []string) error {
+	cfg, _ := config.Load()
+	if cfg.Email.SMTPHost == "" {
+		return fmt.Errorf("SMTP not configured, run: ung email setup")
+	}
+
+	sent, notSent, err := flushOutbox(smtpConfig(cfg), time.Now())
+	if err != nil {
+		return err
+	}
+	if sent+notSent == 0 {
+		fmt.Println("No queued emails are due.")
+		return nil
+	}
+	fmt.Printf("✓ Sent %d/%d email(s)\n", sent, sent+notSent)
+	return nil
+}
+
+func runEmailOutboxRetry(cmd *cobra.Command, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid email ID: %s", args[0])
+	}
+	cfg, _ := config.Load()
+	if cfg.Email.SMTPHost == "" {
+		return fmt.Errorf("SMTP not configured, run: ung email setup")
+	}
+
+	var row models.OutboxEmail
+	if err := db.GormDB.First(&row, id).Error; err != nil {
+		return fmt.Errorf("email #%d not found: %w", id, err)
+	}
+	if row.Status == models.OutboxSent {
+		return fmt.Errorf("email #%d was already sent on %s", id, row.SentAt.Format("2006-01-02 15:04"))
+	}
+
+	// A retry by hand gets a fresh set of attempts
+	row.Status, row.Attempts = models.OutboxQueued, 0
+	if err := deliverOutboxEmail(&row, smtpConfig(cfg), time.Now()); err != nil {
+		return fmt.Errorf("failed to send email #%d: %w", id, err)
+	}
+	fmt.Printf("✓ Email #%d sent to %s\n", id, row.Recipients)
+	return nil
+}
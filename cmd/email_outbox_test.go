@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/email/smtptest"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+)
+
+// withEmailConfig points the loaded config at an SMTP sink for a test
+func withEmailConfig(t *testing.T, sink *smtptest.Sink) {
+	cfg, _ := config.Load()
+	old := cfg.Email
+	cfg.Email = config.EmailConfig{
+		SMTPHost:  sink.Host,
+		SMTPPort:  sink.Port,
+		FromEmail: "billing@mail.test",
+		FromName:  "Mail Co",
+	}
+	t.Cleanup(func() { cfg.Email = old })
+}
+
+func TestSendViaSMTP(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+	sink := smtptest.NewSink()
+	defer sink.Close()
+	withEmailConfig(t, sink)
+
+	res, err := db.DB.Exec(`
+		INSERT INTO companies (name, email, phone, address, registration_address, tax_id, bank_name, bank_account, bank_swift, logo_path)
+		VALUES ('Mail Co', 'billing@mail.test', '', '', '', '', '', '', '', '')
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	companyID, _ := res.LastInsertId()
+	res, err = db.DB.Exec(`
+		INSERT INTO clients (name, email, address, tax_id, email_cc, email_bcc)
+		VALUES ('Initech', 'ap@initech.test', '', '', 'cfo@initech.test', 'archive@mail.test')
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID, _ := res.LastInsertId()
+
+	pdf := filepath.Join(t.TempDir(), "SMTP-1.pdf")
+	os.WriteFile(pdf, []byte("%PDF-1.4"), 0644)
+	items := []models.InvoiceLineItem{{ItemName: "Work", Quantity: 1, Rate: 100, Amount: 100}}
+	invoiceID, err := insertInvoice(models.Invoice{
+		InvoiceNum: "SMTP-1", CompanyID: uint(companyID), Currency: "EUR",
+		IssuedDate: time.Now(), DueDate: time.Now().AddDate(0, 0, 30),
+	}, uint(clientID), items, tax.Result{Totals: tax.Totals{Gross: 100, Net: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB.Exec("UPDATE invoices SET pdf_path = ? WHERE id = ?", pdf, invoiceID)
+
+	send := func(kind string) (*models.OutboxEmail, error) {
+		t.Helper()
+		msg, err := invoiceEmail(int(invoiceID), kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendViaSMTP(msg, kind, uint(invoiceID), 0)
+		var row models.OutboxEmail
+		if err := db.GormDB.Where("message_id = ?", msg.MessageID).First(&row).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &row, err
+	}
+
+	// Sent right away to the client and its copies, and stamped on the invoice
+	row, err := send(email.KindInvoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Status != models.OutboxSent || row.Attempts != 1 || row.SentAt == nil || row.Cc != "cfo@initech.test" {
+		t.Errorf("unexpected outbox row %+v", row)
+	}
+	messages := sink.Messages()
+	if len(messages) != 1 || strings.Join(messages[0].To, ",") != "ap@initech.test,cfo@initech.test,archive@mail.test" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+	parsed, _ := messages[0].Parse()
+	if parsed.Header.Get("Message-ID") != row.MessageID {
+		t.Errorf("expected Message-ID %s, got %s", row.MessageID, parsed.Header.Get("Message-ID"))
+	}
+
+	var inv models.Invoice
+	db.GormDB.First(&inv, invoiceID)
+	if inv.SentAt == nil || inv.Status != models.StatusSent {
+		t.Errorf("expected the invoice to be sent, got %s at %v", inv.Status, inv.SentAt)
+	}
+
+	// A temporary failure stays queued until the retry is due
+	sink.Fail(451)
+	row, err = send(email.KindReminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Status != models.OutboxQueued || row.Attempts != 1 || !row.NextAttemptAt.After(time.Now()) || row.LastError == "" {
+		t.Errorf("expected a queued retry, got %+v", row)
+	}
+
+	cfg, _ := config.Load()
+	if sent, notSent, err := flushOutbox(smtpConfig(cfg), time.Now()); err != nil || sent+notSent != 0 {
+		t.Errorf("expected no due emails, got %d/%d %v", sent, notSent, err)
+	}
+	if sent, notSent, err := flushOutbox(smtpConfig(cfg), time.Now().Add(2*time.Minute)); err != nil || sent != 1 || notSent != 0 {
+		t.Errorf("expected the retry to be sent, got %d/%d %v", sent, notSent, err)
+	}
+	db.GormDB.First(row, row.ID)
+	if row.Status != models.OutboxSent || row.Attempts != 2 {
+		t.Errorf("expected the retry to be sent, got %+v", row)
+	}
+	if messages := sink.Messages(); len(messages) != 2 {
+		t.Errorf("expected 2 messages, got %d", len(messages))
+	}
+
+	// A rejected email fails without a retry
+	sink.Fail(550)
+	row, err = send(email.KindReminder)
+	if err == nil || row.Status != models.OutboxFailed {
+		t.Errorf("expected a failed email, got %v %+v", err, row)
+	}
+}
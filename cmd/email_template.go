@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/repository"
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/template"
+	"github.com/spf13/cobra"
+)
+
+var emailTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage email templates",
+	Long: `Manage the subject, text and HTML of invoice, contract and reminder emails.
+
+Templates are YAML files in the emails directory of the config dir, e.g.
+~/.ung/emails/invoice.yaml. A file only needs the fields it changes, and
+placeholders such as {client}, {invoice}, {amount}, {due_date} or
+{payment_link} are filled in when sending. Without html the text is sent
+as HTML too, with a payment button when there is a payment link.
+
+Files override each other, least specific first:
+  invoice.yaml            All invoice emails
+  invoice.de.yaml         Invoice emails in German
+  acme/invoice.yaml       Clients with --email-template acme
+  acme/invoice.de.yaml    The same, in German
+
+Examples:
+  ung email template create invoice                Edit invoice emails
+  ung email template create reminder -l de         Edit German reminders
+  ung email template create invoice --set acme     Override for some clients
+  ung email template ls                            List template files`,
+}
+
+var emailTemplateCreateCmd = &cobra.Command{
+	Use:   "create <invoice|contract|reminder>",
+	Short: "Create an email template file to edit",
+	Long: `Write the email template currently used for a kind of email to a file,
+to edit it. Use --set for a client's template set and --language for
+emails in one language.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEmailTemplateCreate,
+}
+
+var emailTemplateListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List email template files",
+	RunE:    runEmailTemplateList,
+}
+
+var emailPreviewCmd = &cobra.Command{
+	Use:   "preview <invoice|contract|reminder>",
+	Short: "Preview a templated email",
+	Long: `Render an email template with an invoice, a contract or sample data
+and show its subject, text and placeholders. The HTML body is written to
+a file to open in a browser.
+
+Examples:
+  ung email preview invoice                  Sample invoice email
+  ung email preview reminder --invoice 12    Reminder for invoice #12
+  ung email preview contract --contract 3    Email for contract #3
+  ung email preview invoice --set acme -l de Sample with a template set`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEmailPreview,
+}
+
+var (
+	emailTemplateSet      string
+	emailTemplateLanguage string
+	emailTemplateForce    bool
+	emailPreviewInvoice   int
+	emailPreviewContract  int
+	emailPreviewOutput    string
+)
+
+func init() {
+	emailCmd.AddCommand(emailTemplateCmd)
+	emailCmd.AddCommand(emailPreviewCmd)
+	emailTemplateCmd.AddCommand(emailTemplateCreateCmd)
+	emailTemplateCmd.AddCommand(emailTemplateListCmd)
+
+	emailTemplateCreateCmd.Flags().StringVar(&emailTemplateSet, "set", "", "Template set of clients, see ung client edit --email-template")
+	emailTemplateCreateCmd.Flags().StringVarP(&emailTemplateLanguage, "language", "l", "", "Only for emails in this language, e.g. de")
+	emailTemplateCreateCmd.Flags().BoolVarP(&emailTemplateForce, "force", "f", false, "Overwrite an existing file")
+
+	emailPreviewCmd.Flags().IntVar(&emailPreviewInvoice, "invoice", 0, "Invoice ID (default: sample data)")
+	emailPreviewCmd.Flags().IntVar(&emailPreviewContract, "contract", 0, "Contract ID (default: sample data)")
+	emailPreviewCmd.Flags().StringVar(&emailTemplateSet, "set", "", "Template set (default: the client's)")
+	emailPreviewCmd.Flags().StringVarP(&emailTemplateLanguage, "language", "l", "", "Language (default: the client's)")
+	emailPreviewCmd.Flags().StringVarP(&emailPreviewOutput, "output", "o", "", "HTML output path")
+}
+
+// emailTemplatesDir returns the directory of the email templates
+func emailTemplatesDir() string {
+	return filepath.Join(config.GetConfigDir(), "emails")
+}
+
+// invoiceEmail renders an invoice or reminder email for an invoice from
+// its client's template, addressed to the client and its copies with the
+// PDF attached
+func invoiceEmail(invoiceID int, kind string) (*email.Email, error) {
+	inv, company, client, _, err := loadInvoiceForDocument(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := renderInvoiceEmail(kind, inv, company, client)
+	if err != nil {
+		return nil, err
+	}
+	msg.AddressTo(client, models.ContactBilling)
+	if inv.PDFPath != "" {
+		msg.Attachments = []string{inv.PDFPath}
+	}
+	return msg, nil
+}
+
+// renderInvoiceEmail renders an invoice or reminder email in the client's
+// language from its template set
+func renderInvoiceEmail(kind string, inv models.Invoice, company models.Company, client models.Client) (*email.Email, error) {
+	cfg, _ := config.Load()
+	loc, cfg := locale.For(cfg, client.Language)
+	return email.RenderInvoice(emailTemplatesDir(), kind, inv, company, client, loc, cfg.PDF.QRPaymentURL)
+}
+
+// contractEmail renders the email for a contract from its client's
+// template, addressed to the client and its copies with the PDF attached
+func contractEmail(contract models.Contract, company models.Company) (*email.Email, error) {
+	client := contract.Client
+	cfg, _ := config.Load()
+	loc, _ := locale.For(cfg, client.Language)
+	tmpl, err := email.LoadTemplate(emailTemplatesDir(), client.EmailTemplate, email.KindContract, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := tmpl.Render(email.ContractVars(contract, company, client), loc)
+	msg.AddressTo(client, models.ContactLegal)
+	if contract.PDFPath != "" {
+		msg.Attachments = []string{contract.PDFPath}
+	}
+	return msg, nil
+}
+
+func runEmailTemplateCreate(cmd *cobra.Command, args []string) error {
+	kind := args[0]
+	if err := email.ValidateSet(emailTemplateSet); err != nil {
+		return err
+	}
+	if err := locale.Validate(emailTemplateLanguage); err != nil {
+		return err
+	}
+	language := strings.ToLower(emailTemplateLanguage)
+
+	cfg, _ := config.Load()
+	loc, _ := locale.For(cfg, language)
+	tmpl, err := email.LoadTemplate(emailTemplatesDir(), emailTemplateSet, kind, loc)
+	if err != nil {
+		return err
+	}
+
+	files := email.TemplateFiles(emailTemplatesDir(), emailTemplateSet, kind, language)
+	path := files[len(files)-1]
+	if _, err := os.Stat(path); err == nil && !emailTemplateForce {
+		return fmt.Errorf("email template %s already exists (use --force to overwrite)", path)
+	}
+	if err := email.SaveTemplate(tmpl, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Email template created: %s\n", path)
+	preview := "ung email preview " + kind
+	if emailTemplateSet != "" {
+		preview += " --set " + emailTemplateSet
+	}
+	if language != "" {
+		preview += " --language " + language
+	}
+	fmt.Printf("💡 Edit it, then run: %s\n", preview)
+	return nil
+}
+
+func runEmailTemplateList(cmd *cobra.Command, args []string) error {
+	dir := emailTemplatesDir()
+	common, _ := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	sets, _ := filepath.Glob(filepath.Join(dir, "*", "*.yaml"))
+	files := append(common, sets...)
+
+	if len(files) == 0 {
+		fmt.Printf("No email templates in %s, the built-in ones are used.\n", dir)
+		fmt.Println("💡 Create one with: ung email template create invoice")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tLANG\tSET\tPATH")
+	for _, path := range files {
+		set, _ := filepath.Rel(dir, filepath.Dir(path))
+		if set == "." {
+			set = ""
+		}
+		kind, language, _ := strings.Cut(strings.TrimSuffix(filepath.Base(path), ".yaml"), ".")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", kind, language, set, path)
+	}
+	w.Flush()
+	return nil
+}
+
+func runEmailPreview(cmd *cobra.Command, args []string) error {
+	kind := args[0]
+	if err := email.ValidateSet(emailTemplateSet); err != nil {
+		return err
+	}
+	if err := locale.Validate(emailTemplateLanguage); err != nil {
+		return err
+	}
+
+	msg, vars, err := previewEmail(cmd, kind)
+	if err != nil {
+		return err
+	}
+
+	outputPath := emailPreviewOutput
+	if outputPath == "" {
+		outputPath = filepath.Join(os.TempDir(), fmt.Sprintf("ung-email-preview-%s.html", kind))
+	}
+	if err := os.WriteFile(outputPath, []byte(msg.HTMLBody), 0644); err != nil {
+		return fmt.Errorf("failed to write HTML preview: %w", err)
+	}
+
+	if len(msg.To) > 0 {
+		fmt.Printf("To:      %s\n", strings.Join(msg.To, ", "))
+	}
+	if len(msg.Cc) > 0 {
+		fmt.Printf("Cc:      %s\n", strings.Join(msg.Cc, ", "))
+	}
+	if len(msg.Bcc) > 0 {
+		fmt.Printf("Bcc:     %s\n", strings.Join(msg.Bcc, ", "))
+	}
+	fmt.Printf("Subject: %s\n\n", msg.Subject)
+	fmt.Println(msg.Body)
+	fmt.Println()
+
+	names := email.VarNames(vars)
+	for i, name := range names {
+		names[i] = "{" + name + "}"
+	}
+	fmt.Printf("💡 Placeholders: %s\n", strings.Join(names, ", "))
+	fmt.Printf("✓ HTML preview: %s\n", outputPath)
+	return nil
+}
+
+// previewEmail renders an email for the preview from the invoice or
+// contract flags, or from sample data. --set and --language replace the
+// client's template set and language.
+func previewEmail(cmd *cobra.Command, kind string) (*email.Email, map[string]string, error) {
+	cfg, _ := config.Load()
+
+	var client models.Client
+	var inv models.Invoice
+	var contract models.Contract
+	var company models.Company
+	switch {
+	case kind == email.KindContract && emailPreviewContract != 0:
+		c, err := repository.NewContractRepository().GetByID(uint(emailPreviewContract))
+		if err != nil {
+			return nil, nil, fmt.Errorf("contract not found: %w", err)
+		}
+		companies, err := repository.NewCompanyRepository().List()
+		if err != nil || len(companies) == 0 {
+			return nil, nil, fmt.Errorf("no company found: %w", err)
+		}
+		contract, company, client = *c, companies[0], c.Client
+	case kind != email.KindContract && emailPreviewInvoice != 0:
+		var err error
+		inv, company, client, _, err = loadInvoiceForDocument(emailPreviewInvoice)
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		sample := template.GetSampleContractData(cfg)
+		contract = sample.Contract
+		invoice := template.GetSampleInvoiceData(cfg)
+		inv, company, client = invoice.Invoice, invoice.Company, invoice.Client
+	}
+
+	if cmd.Flags().Changed("set") {
+		client.EmailTemplate = emailTemplateSet
+	}
+	if cmd.Flags().Changed("language") {
+		client.Language = strings.ToLower(emailTemplateLanguage)
+	}
+
+	loc, cfg := locale.For(cfg, client.Language)
+	tmpl, err := email.LoadTemplate(emailTemplatesDir(), client.EmailTemplate, kind, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var vars map[string]string
+	if kind == email.KindContract {
+		vars = email.ContractVars(contract, company, client)
+	} else {
+		vars = email.InvoiceVars(inv, company, client, loc, cfg.PDF.QRPaymentURL)
+	}
+
+	msg := tmpl.Render(vars, loc)
+	role := models.ContactBilling
+	if kind == email.KindContract {
+		role = models.ContactLegal
+	}
+	msg.AddressTo(client, role)
+	return msg, vars, nil
+}
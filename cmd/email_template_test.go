@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/email"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+)
+
+func TestInvoiceEmail(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+
+	res, err := db.DB.Exec(`
+		INSERT INTO companies (name, email, phone, address, registration_address, tax_id, bank_name, bank_account, bank_swift, logo_path)
+		VALUES ('Mail Co', 'mail@test.com', '', '', '', '', '', '', '', '')
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	companyID, _ := res.LastInsertId()
+	res, err = db.DB.Exec("INSERT INTO clients (name, email, address, tax_id, language, email_template) VALUES ('Globex', 'billing@globex.com', '', '', 'de', 'globex')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID, _ := res.LastInsertId()
+
+	issued := time.Now().AddDate(0, 0, -40)
+	items := []models.InvoiceLineItem{{ItemName: "Work", Quantity: 1, Rate: 500, Amount: 500}}
+	invoiceID, err := insertInvoice(models.Invoice{
+		InvoiceNum: "MAIL-1", CompanyID: uint(companyID), Currency: "EUR",
+		IssuedDate: issued, DueDate: issued.AddDate(0, 0, 30),
+	}, uint(clientID), items, tax.Result{Totals: tax.Totals{Gross: 500, Net: 500}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB.Exec("UPDATE invoices SET pdf_path = '/tmp/MAIL-1.pdf' WHERE id = ?", invoiceID)
+
+	// The built-in reminder in the client's language
+	msg, err := invoiceEmail(int(invoiceID), email.KindReminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	de := locale.Get("de")
+	if want := locale.Fill(de.Email.ReminderSubject, "{invoice}", "MAIL-1", "{company}", "Mail Co"); msg.Subject != want {
+		t.Errorf("expected subject %q, got %q", want, msg.Subject)
+	}
+	if len(msg.To) != 1 || msg.To[0] != "billing@globex.com" || len(msg.Attachments) != 1 || msg.Attachments[0] != "/tmp/MAIL-1.pdf" {
+		t.Errorf("unexpected recipients or attachments: %v %v", msg.To, msg.Attachments)
+	}
+	if msg.HTMLBody == "" {
+		t.Error("expected an HTML body")
+	}
+
+	// The client's template set replaces it
+	path := filepath.Join(emailTemplatesDir(), "globex", "reminder.yaml")
+	if err := email.SaveTemplate(email.Template{Subject: "Overdue: {invoice} ({days_overdue} days)"}, path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(path)) })
+
+	msg, err = invoiceEmail(int(invoiceID), email.KindReminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(msg.Subject, "Overdue: MAIL-1 (") || msg.Body == "" {
+		t.Errorf("expected the client's subject with the built-in text, got %q / %q", msg.Subject, msg.Body)
+	}
+}
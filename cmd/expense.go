@@ -58,6 +58,7 @@ func init() {
 	expenseAddCmd.Flags().StringP("vendor", "v", "", "Vendor name")
 	expenseAddCmd.Flags().String("date", "", "Date (YYYY-MM-DD, defaults to today)")
 	expenseAddCmd.Flags().StringP("notes", "n", "", "Additional notes")
+	expenseAddCmd.Flags().StringP("receipt", "r", "", "Receipt file to attach (PDF or image)")
 }
 
 func runExpenseAdd(cmd *cobra.Command, args []string) error {
@@ -68,10 +69,11 @@ func runExpenseAdd(cmd *cobra.Command, args []string) error {
 	vendor, _ := cmd.Flags().GetString("vendor")
 	dateStr, _ := cmd.Flags().GetString("date")
 	notes, _ := cmd.Flags().GetString("notes")
+	receipt, _ := cmd.Flags().GetString("receipt")
 
 	// If required flags are provided, use non-interactive mode
 	if description != "" && amountStr != "" && category != "" {
-		return addExpenseNonInteractive(description, amountStr, category, vendor, dateStr, notes)
+		return addExpenseNonInteractive(description, amountStr, category, vendor, dateStr, notes, receipt)
 	}
 
 	// Interactive mode
@@ -132,6 +134,11 @@ func runExpenseAdd(cmd *cobra.Command, args []string) error {
 			huh.NewText().
 				Title("Notes (optional)").
 				Value(&notes),
+
+			huh.NewInput().
+				Title("Receipt file (optional)").
+				Placeholder("e.g., ~/Downloads/receipt.pdf").
+				Value(&receipt),
 		),
 	)
 
@@ -139,10 +146,10 @@ func runExpenseAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("form cancelled: %w", err)
 	}
 
-	return addExpenseNonInteractive(description, amountStr, category, vendor, dateStr, notes)
+	return addExpenseNonInteractive(description, amountStr, category, vendor, dateStr, notes, receipt)
 }
 
-func addExpenseNonInteractive(description, amountStr, category, vendor, dateStr, notes string) error {
+func addExpenseNonInteractive(description, amountStr, category, vendor, dateStr, notes, receipt string) error {
 	// Parse amount
 	amount, err := strconv.ParseFloat(amountStr, 64)
 	if err != nil {
@@ -188,6 +195,16 @@ func addExpenseNonInteractive(description, amountStr, category, vendor, dateStr,
 	fmt.Printf("  Category: %s\n", category)
 	fmt.Printf("  Date: %s\n", expenseDate.Format("2006-01-02"))
 
+	if receipt != "" {
+		path, err := attachReceipt(uint(id), receipt, false)
+		if err != nil {
+			fmt.Printf("⚠️  Receipt not attached: %v\n", err)
+			fmt.Printf("   Retry with: ung expense receipt attach %d <file>\n", id)
+			return nil
+		}
+		fmt.Printf("  Receipt: %s\n", path)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/internal/receipts"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	receiptForce     bool
+	receiptYear      int
+	receiptMinAmount float64
+)
+
+var expenseReceiptCmd = &cobra.Command{
+	Use:   "receipt",
+	Short: "Manage expense receipts",
+	Long: `Attach, view and audit expense receipts.
+
+Receipts are copied into the receipts directory (receipts_dir in config,
+next to the invoices directory by default), organized by year. Set
+security.encrypt_receipts to store them encrypted with the database password.
+
+Examples:
+  ung expense receipt attach 12 ~/Downloads/invoice.pdf   Attach a receipt
+  ung expense receipt open 12                             View a receipt
+  ung expense receipt missing --year 2024                 Expenses without receipts`,
+}
+
+var expenseReceiptAttachCmd = &cobra.Command{
+	Use:   "attach <expense-id> <file>",
+	Short: "Attach a receipt file to an expense",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runExpenseReceiptAttach,
+}
+
+var expenseReceiptOpenCmd = &cobra.Command{
+	Use:   "open <expense-id>",
+	Short: "Open an expense's receipt",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runExpenseReceiptOpen,
+}
+
+var expenseReceiptMissingCmd = &cobra.Command{
+	Use:   "missing",
+	Short: "List expenses without a receipt",
+	RunE:  runExpenseReceiptMissing,
+}
+
+func init() {
+	expenseCmd.AddCommand(expenseReceiptCmd)
+	expenseReceiptCmd.AddCommand(expenseReceiptAttachCmd)
+	expenseReceiptCmd.AddCommand(expenseReceiptOpenCmd)
+	expenseReceiptCmd.AddCommand(expenseReceiptMissingCmd)
+
+	expenseReceiptAttachCmd.Flags().BoolVar(&receiptForce, "force", false, "Attach even if the same receipt is already attached to another expense")
+	expenseReceiptMissingCmd.Flags().IntVar(&receiptYear, "year", 0, "Only expenses from this year")
+	expenseReceiptMissingCmd.Flags().Float64Var(&receiptMinAmount, "min-amount", 0, "Only expenses of at least this amount")
+}
+
+// receiptPassword returns the password used to encrypt new receipts, or "" when encryption is off
+func receiptPassword() (string, error) {
+	cfg, _ := config.Load()
+	if !cfg.Security.EncryptReceipts {
+		return "", nil
+	}
+	return db.GetDatabasePassword()
+}
+
+// loadReceipt reads an expense's stored receipt, asking for the password only when the open database doesn't unlock it
+func loadReceipt(path string) ([]byte, error) {
+	return receipts.Load(path, db.GetDatabasePassword)
+}
+
+// attachReceipt validates, stores and links a receipt file to an expense.
+// A receipt whose contents are already attached to another expense is refused unless force is set.
+func attachReceipt(expenseID uint, srcPath string, force bool) (string, error) {
+	if strings.HasPrefix(srcPath, "~/") {
+		srcPath = strings.Replace(srcPath, "~", os.Getenv("HOME"), 1)
+	}
+
+	var expense models.Expense
+	if err := db.GormDB.First(&expense, expenseID).Error; err != nil {
+		return "", fmt.Errorf("expense with ID %d not found", expenseID)
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read receipt: %w", err)
+	}
+	if err := receipts.Validate(srcPath, data); err != nil {
+		return "", err
+	}
+
+	hash := receipts.Hash(data)
+	if expense.ReceiptHash == hash && expense.ReceiptPath != "" && fileExists(expense.ReceiptPath) {
+		return expense.ReceiptPath, nil
+	}
+	if !force {
+		var duplicate models.Expense
+		if err := db.GormDB.Where("receipt_hash = ? AND id != ?", hash, expenseID).First(&duplicate).Error; err == nil {
+			return "", fmt.Errorf("this receipt is already attached to expense #%d (%s); use --force to attach it anyway", duplicate.ID, duplicate.Description)
+		}
+	}
+
+	password, err := receiptPassword()
+	if err != nil {
+		return "", fmt.Errorf("failed to get password: %w", err)
+	}
+	dir := config.GetReceiptsDir()
+	path, err := receipts.Store(dir, srcPath, data, expense.Date, password)
+	if err != nil {
+		return "", err
+	}
+
+	oldPath := expense.ReceiptPath
+	err = db.GormDB.Model(&models.Expense{}).Where("id = ?", expenseID).Updates(map[string]interface{}{
+		"receipt_path": path,
+		"receipt_hash": hash,
+	}).Error
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to update expense: %w", err)
+	}
+
+	// Remove the replaced receipt if we manage it and nothing else uses it
+	if oldPath != "" && oldPath != path && receipts.IsManaged(dir, oldPath) {
+		var users int64
+		db.GormDB.Model(&models.Expense{}).Where("receipt_path = ?", oldPath).Count(&users)
+		if users == 0 {
+			os.Remove(oldPath)
+		}
+	}
+
+	return path, nil
+}
+
+func runExpenseReceiptAttach(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expense ID: %s", args[0])
+	}
+
+	path, err := attachReceipt(uint(id), args[1], receiptForce)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Receipt attached to expense %d\n", id)
+	fmt.Printf("  Stored at: %s\n", path)
+	return nil
+}
+
+func runExpenseReceiptOpen(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expense ID: %s", args[0])
+	}
+
+	var expense models.Expense
+	if err := db.GormDB.First(&expense, id).Error; err != nil {
+		return fmt.Errorf("expense with ID %d not found", id)
+	}
+	if expense.ReceiptPath == "" {
+		return fmt.Errorf("expense %d has no receipt (attach one with: ung expense receipt attach %d <file>)", id, id)
+	}
+	if !fileExists(expense.ReceiptPath) {
+		return fmt.Errorf("receipt file is missing: %s", expense.ReceiptPath)
+	}
+
+	path := expense.ReceiptPath
+	encrypted := receipts.IsEncrypted(path)
+	if encrypted {
+		data, err := loadReceipt(path)
+		if err != nil {
+			return err
+		}
+		// MkdirTemp creates the directory with 0700, so only the user can read the plaintext
+		dir, err := os.MkdirTemp("", "ung-receipt-")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		path = filepath.Join(dir, fmt.Sprintf("receipt-%d%s", id, receipts.Ext(expense.ReceiptPath)))
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return fmt.Errorf("failed to write decrypted receipt: %w", err)
+		}
+	}
+
+	if err := openURL(path); err != nil {
+		return fmt.Errorf("failed to open receipt: %w", err)
+	}
+	fmt.Printf("✓ Opened receipt for expense %d\n", id)
+
+	if encrypted {
+		// The launcher returns before the viewer has read the file, so give it
+		// a moment before the decrypted copy is removed
+		time.Sleep(receiptViewerDelay)
+	}
+	return nil
+}
+
+// receiptViewerDelay is how long a decrypted receipt is kept for the viewer to load it
+var receiptViewerDelay = 5 * time.Second
+
+func runExpenseReceiptMissing(cmd *cobra.Command, args []string) error {
+	query := db.GormDB.Order("date DESC")
+	if receiptYear > 0 {
+		start := time.Date(receiptYear, 1, 1, 0, 0, 0, 0, time.UTC)
+		query = query.Where("date >= ? AND date < ?", start, start.AddDate(1, 0, 0))
+	}
+	if receiptMinAmount > 0 {
+		query = query.Where("amount >= ?", receiptMinAmount)
+	}
+
+	var expenses []models.Expense
+	if err := query.Find(&expenses).Error; err != nil {
+		return fmt.Errorf("failed to query expenses: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tDESCRIPTION\tVENDOR\tAMOUNT\tPROBLEM")
+
+	missing := 0
+	total := 0.0
+	for _, e := range expenses {
+		problem := ""
+		switch {
+		case e.ReceiptPath == "":
+			problem = "no receipt"
+		case !fileExists(e.ReceiptPath):
+			problem = "file missing"
+		default:
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.2f %s\t%s\n",
+			e.ID, e.Date.Format("2006-01-02"), e.Description, e.Vendor, e.Amount, e.Currency, problem)
+		missing++
+		total += e.Amount
+	}
+
+	if missing == 0 {
+		fmt.Println("✓ All expenses have receipts")
+		return nil
+	}
+	w.Flush()
+	fmt.Printf("\n⚠️  %d expenses without receipts (%.2f total)\n", missing, total)
+	return nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+func createReceiptTestExpense(t *testing.T, description, vendor string) uint {
+	t.Helper()
+	expense := models.Expense{
+		Description: description,
+		Amount:      49.99,
+		Currency:    "USD",
+		Category:    models.ExpenseCategorySoftware,
+		Date:        time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		Vendor:      vendor,
+	}
+	if err := db.GormDB.Create(&expense).Error; err != nil {
+		t.Fatalf("failed to create expense: %v", err)
+	}
+	return expense.ID
+}
+
+func TestAttachReceipt(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+	db.DB.Exec("DELETE FROM expenses")
+
+	src := filepath.Join(t.TempDir(), "receipt.pdf")
+	if err := os.WriteFile(src, []byte("%PDF-1.4\nreceipt\n%%EOF\n"), 0644); err != nil {
+		t.Fatalf("failed to write receipt: %v", err)
+	}
+
+	first := createReceiptTestExpense(t, "Hosting", "DigitalOcean")
+	second := createReceiptTestExpense(t, "Domain", "Namecheap")
+
+	path, err := attachReceipt(first, src, false)
+	if err != nil {
+		t.Fatalf("attachReceipt() error: %v", err)
+	}
+	if !strings.HasPrefix(path, config.GetReceiptsDir()) {
+		t.Errorf("expected receipt inside %s, got %s", config.GetReceiptsDir(), path)
+	}
+	var expense models.Expense
+	db.GormDB.First(&expense, first)
+	if expense.ReceiptPath != path || expense.ReceiptHash == "" {
+		t.Errorf("expected receipt to be linked, got path %q hash %q", expense.ReceiptPath, expense.ReceiptHash)
+	}
+
+	// Attaching the same receipt to another expense is caught as a duplicate
+	if _, err := attachReceipt(second, src, false); err == nil || !strings.Contains(err.Error(), "already attached") {
+		t.Errorf("expected duplicate receipt error, got %v", err)
+	}
+	if _, err := attachReceipt(second, src, true); err != nil {
+		t.Errorf("expected --force to allow duplicate, got %v", err)
+	}
+
+	// Files that aren't receipts are rejected
+	bad := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(bad, []byte("hello"), 0644)
+	if _, err := attachReceipt(first, bad, false); err == nil {
+		t.Error("expected unsupported file to be rejected")
+	}
+}
+
+func TestBundleExpenseReceipts(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+	db.DB.Exec("DELETE FROM expenses")
+
+	src := filepath.Join(t.TempDir(), "receipt.pdf")
+	os.WriteFile(src, []byte("%PDF-1.4\nbundle\n%%EOF\n"), 0644)
+	withReceipt := createReceiptTestExpense(t, "Hosting", "Digital Ocean")
+	createReceiptTestExpense(t, "Coffee", "Cafe")
+	if _, err := attachReceipt(withReceipt, src, false); err != nil {
+		t.Fatalf("attachReceipt() error: %v", err)
+	}
+
+	exportOutput = t.TempDir()
+	exportYear = 0
+	exportReceipts = true
+	defer func() { exportReceipts = false }()
+
+	csvPath, err := exportExpensesData("csv", "test")
+	if err != nil {
+		t.Fatalf("exportExpensesData() error: %v", err)
+	}
+	archive, err := bundleExpenseReceipts(csvPath, "test")
+	if err != nil {
+		t.Fatalf("bundleExpenseReceipts() error: %v", err)
+	}
+
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"expenses_test.csv", fmt.Sprintf("receipts/2024-03-05_%d_Digital_Ocean.pdf", withReceipt)}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("archive contains %v; want %v", names, want)
+	}
+	if _, err := os.Stat(csvPath); !os.IsNotExist(err) {
+		t.Error("expected loose expenses file to be moved into the archive")
+	}
+}
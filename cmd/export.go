@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"archive/zip"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/internal/receipts"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -28,7 +32,8 @@ Examples:
   ung export                       Interactive export wizard
   ung export --format csv          Export all to CSV
   ung export --invoices --year 2024    Export 2024 invoices
-  ung export --expenses --quarter Q4   Export Q4 expenses`,
+  ung export --expenses --quarter Q4   Export Q4 expenses
+  ung export --expenses --receipts     Bundle expenses and receipts into a zip`,
 	RunE: runExport,
 }
 
@@ -40,6 +45,7 @@ var (
 	exportYear     int
 	exportQuarter  string
 	exportOutput   string
+	exportReceipts bool
 )
 
 func init() {
@@ -50,13 +56,14 @@ func init() {
 	exportCmd.Flags().IntVarP(&exportYear, "year", "y", 0, "Filter by year")
 	exportCmd.Flags().StringVarP(&exportQuarter, "quarter", "q", "", "Filter by quarter (Q1, Q2, Q3, Q4)")
 	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output directory")
+	exportCmd.Flags().BoolVar(&exportReceipts, "receipts", false, "Bundle expenses with their receipts into a zip archive")
 
 	rootCmd.AddCommand(exportCmd)
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
 	// Interactive mode if no flags specified
-	if exportFormat == "" && !exportInvoices && !exportExpenses && !exportTime {
+	if exportFormat == "" && !exportInvoices && !exportExpenses && !exportTime && !exportReceipts {
 		return runExportInteractive()
 	}
 
@@ -65,6 +72,11 @@ func runExport(cmd *cobra.Command, args []string) error {
 		exportFormat = "csv"
 	}
 
+	// Receipts are bundled with the expenses export
+	if exportReceipts {
+		exportExpenses = true
+	}
+
 	// Default to all if nothing selected
 	if !exportInvoices && !exportExpenses && !exportTime {
 		exportInvoices = true
@@ -98,6 +110,12 @@ func runExport(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to export expenses: %w", err)
 		}
+		if exportReceipts {
+			path, err = bundleExpenseReceipts(path, timestamp)
+			if err != nil {
+				return fmt.Errorf("failed to bundle receipts: %w", err)
+			}
+		}
 		exported = append(exported, path)
 	}
 
@@ -150,6 +168,7 @@ func runExportInteractive() error {
 				Options(
 					huh.NewOption("Invoices", "invoices"),
 					huh.NewOption("Expenses", "expenses"),
+					huh.NewOption("Expenses with receipts (zip)", "receipts"),
 					huh.NewOption("Time Tracking", "time"),
 				).
 				Value(&dataTypes),
@@ -193,6 +212,8 @@ func runExportInteractive() error {
 			exportInvoices = true
 		case "expenses":
 			exportExpenses = true
+		case "receipts":
+			exportReceipts = true
 		case "time":
 			exportTime = true
 		}
@@ -403,15 +424,7 @@ func exportInvoicesJSON(filename string) (string, error) {
 func exportExpensesData(format, timestamp string) (string, error) {
 	filename := filepath.Join(exportOutput, fmt.Sprintf("expenses_%s.%s", timestamp, format))
 
-	// Get expenses
-	var expenses []models.Expense
-	query := db.GormDB.Order("date DESC")
-	if exportYear > 0 {
-		startOfYear := time.Date(exportYear, 1, 1, 0, 0, 0, 0, time.UTC)
-		endOfYear := time.Date(exportYear+1, 1, 1, 0, 0, 0, 0, time.UTC)
-		query = query.Where("date >= ? AND date < ?", startOfYear, endOfYear)
-	}
-	query.Find(&expenses)
+	expenses := loadExportExpenses()
 
 	switch format {
 	case "csv":
@@ -425,6 +438,19 @@ func exportExpensesData(format, timestamp string) (string, error) {
 	}
 }
 
+// loadExportExpenses returns the expenses selected by the export filters
+func loadExportExpenses() []models.Expense {
+	var expenses []models.Expense
+	query := db.GormDB.Order("date DESC")
+	if exportYear > 0 {
+		startOfYear := time.Date(exportYear, 1, 1, 0, 0, 0, 0, time.UTC)
+		endOfYear := time.Date(exportYear+1, 1, 1, 0, 0, 0, 0, time.UTC)
+		query = query.Where("date >= ? AND date < ?", startOfYear, endOfYear)
+	}
+	query.Find(&expenses)
+	return expenses
+}
+
 func exportExpensesCSV(filename string, expenses []models.Expense) (string, error) {
 	file, err := os.Create(filename)
 	if err != nil {
@@ -435,13 +461,13 @@ func exportExpensesCSV(filename string, expenses []models.Expense) (string, erro
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	writer.Write([]string{"Date", "Description", "Category", "Amount", "Currency", "Vendor", "Notes"})
+	writer.Write([]string{"Date", "Description", "Category", "Amount", "Currency", "Vendor", "Notes", "Receipt"})
 
 	count := 0
 	for _, exp := range expenses {
 		writer.Write([]string{
 			exp.Date.Format("2006-01-02"), exp.Description, string(exp.Category),
-			fmt.Sprintf("%.2f", exp.Amount), exp.Currency, exp.Vendor, exp.Notes,
+			fmt.Sprintf("%.2f", exp.Amount), exp.Currency, exp.Vendor, exp.Notes, exportReceiptName(exp),
 		})
 		count++
 	}
@@ -460,6 +486,7 @@ func exportExpensesJSON(filename string, expenses []models.Expense) (string, err
 		Date        time.Time `json:"date"`
 		Vendor      string    `json:"vendor"`
 		Notes       string    `json:"notes"`
+		Receipt     string    `json:"receipt,omitempty"`
 	}
 
 	var data []jsonExpense
@@ -473,6 +500,7 @@ func exportExpensesJSON(filename string, expenses []models.Expense) (string, err
 			Date:        exp.Date,
 			Vendor:      exp.Vendor,
 			Notes:       exp.Notes,
+			Receipt:     exportReceiptName(exp),
 		})
 	}
 
@@ -608,13 +636,102 @@ func exportTimeJSON(filename string, sessions []models.TrackingSession) (string,
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
 	encoder.Encode(map[string]interface{}{
-		"exported_at":  time.Now(),
-		"type":         "time_tracking",
-		"count":        len(data),
-		"total_hours":  totalHours,
-		"data":         data,
+		"exported_at": time.Now(),
+		"type":        "time_tracking",
+		"count":       len(data),
+		"total_hours": totalHours,
+		"data":        data,
 	})
 
 	fmt.Printf("  ✓ Exported %d time entries (JSON)\n", len(data))
 	return filename, nil
 }
+
+// exportReceiptName returns the path of an expense's receipt inside the export
+// archive, or "" when receipts aren't bundled or the expense has none
+func exportReceiptName(exp models.Expense) string {
+	if !exportReceipts || exp.ReceiptPath == "" {
+		return ""
+	}
+	name := exp.Date.Format("2006-01-02") + "_" + fmt.Sprint(exp.ID)
+	if vendor := sanitizeFilename(exp.Vendor); vendor != "" {
+		name += "_" + vendor
+	}
+	return "receipts/" + name + receipts.Ext(exp.ReceiptPath)
+}
+
+// bundleExpenseReceipts packs the expenses export and all receipts into a zip archive
+func bundleExpenseReceipts(expensesFile, timestamp string) (string, error) {
+	archivePath := filepath.Join(exportOutput, fmt.Sprintf("expenses_%s.zip", timestamp))
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+
+	data, err := os.ReadFile(expensesFile)
+	if err != nil {
+		return "", err
+	}
+	w, err := zw.Create(filepath.Base(expensesFile))
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+
+	added := 0
+	var missing []string
+	for _, exp := range loadExportExpenses() {
+		name := exportReceiptName(exp)
+		if name == "" {
+			continue
+		}
+		receipt, err := loadReceipt(exp.ReceiptPath)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("#%d %s", exp.ID, exp.Description))
+			continue
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := w.Write(receipt); err != nil {
+			return "", err
+		}
+		added++
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	// The expenses file now lives in the archive
+	os.Remove(expensesFile)
+
+	fmt.Printf("  ✓ Bundled %d receipts\n", added)
+	if len(missing) > 0 {
+		fmt.Printf("  ⚠️  Could not read receipts for: %s\n", strings.Join(missing, ", "))
+	}
+	return archivePath, nil
+}
+
+// sanitizeFilename keeps letters, digits, dashes and underscores for use in file names
+func sanitizeFilename(s string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ' || r == '.':
+			b.WriteRune('_')
+		}
+	}
+	name := strings.Trim(b.String(), "_")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return name
+}
@@ -8,7 +8,7 @@ import (
 	"text/tabwriter"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
@@ -8,7 +8,7 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/spf13/cobra"
 )
 
@@ -22,19 +22,6 @@ Commands:
   ls        List all goals
   status    Show progress toward goals
   rm        Remove a goal`,
-	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Run parent's PersistentPreRunE if exists
-		if parent := cmd.Parent(); parent != nil && parent.PersistentPreRunE != nil {
-			if err := parent.PersistentPreRunE(parent, args); err != nil {
-				return err
-			}
-		}
-		// Migrate the schema
-		if db.GormDB != nil {
-			db.GormDB.AutoMigrate(&IncomeGoal{})
-		}
-		return nil
-	},
 }
 
 var goalSetCmd = &cobra.Command{
@@ -85,19 +72,6 @@ func init() {
 	goalCmd.AddCommand(goalRemoveCmd)
 }
 
-type IncomeGoal struct {
-	ID          uint      `gorm:"primaryKey"`
-	Amount      float64   `gorm:"not null"`
-	Period      string    `gorm:"not null"` // monthly, quarterly, yearly
-	Year        int       `gorm:"not null"`
-	Month       int       // for monthly goals
-	Quarter     int       // for quarterly goals
-	Description string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-}
-
-
 func runGoalSet(cmd *cobra.Command, args []string) error {
 	var amount float64
 	_, err := fmt.Sscanf(args[0], "%f", &amount)
@@ -126,7 +100,7 @@ func runGoalSet(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check for existing goal
-	var existing IncomeGoal
+	var existing models.IncomeGoal
 	query := db.GormDB.Where("period = ? AND year = ?", goalPeriod, year)
 	if goalPeriod == "monthly" {
 		query = query.Where("month = ?", month)
@@ -144,7 +118,7 @@ func runGoalSet(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Updated %s goal for %s: $%.2f\n", goalPeriod, formatGoalPeriod(year, month, quarter, goalPeriod), amount)
 	} else {
 		// Create new
-		goal := IncomeGoal{
+		goal := models.IncomeGoal{
 			Amount:      amount,
 			Period:      goalPeriod,
 			Year:        year,
@@ -163,7 +137,7 @@ func runGoalSet(cmd *cobra.Command, args []string) error {
 }
 
 func runGoalList(cmd *cobra.Command, args []string) error {
-	var goals []IncomeGoal
+	var goals []models.IncomeGoal
 	if err := db.GormDB.Order("year DESC, period, month DESC, quarter DESC").Find(&goals).Error; err != nil {
 		return fmt.Errorf("failed to list goals: %w", err)
 	}
@@ -191,7 +165,7 @@ func runGoalList(cmd *cobra.Command, args []string) error {
 }
 
 func runGoalStatus(cmd *cobra.Command, args []string) error {
-	var goals []IncomeGoal
+	var goals []models.IncomeGoal
 	query := db.GormDB.Order("year DESC, period, month DESC, quarter DESC")
 	if goalPeriod != "" {
 		query = query.Where("period = ?", goalPeriod)
@@ -258,7 +232,7 @@ func runGoalRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid ID: %s", args[0])
 	}
 
-	result := db.GormDB.Delete(&IncomeGoal{}, id)
+	result := db.GormDB.Delete(&models.IncomeGoal{}, id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete goal: %w", result.Error)
 	}
@@ -283,7 +257,7 @@ func formatGoalPeriod(year, month, quarter int, period string) string {
 	return ""
 }
 
-func getGoalDateRange(g IncomeGoal) (time.Time, time.Time) {
+func getGoalDateRange(g models.IncomeGoal) (time.Time, time.Time) {
 	loc := time.Now().Location()
 
 	switch g.Period {
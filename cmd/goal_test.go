@@ -3,6 +3,8 @@ package cmd
 import (
 	"testing"
 	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 func TestGoalDefaults(t *testing.T) {
@@ -47,7 +49,7 @@ func TestFormatGoalPeriod(t *testing.T) {
 
 func TestGetGoalDateRange(t *testing.T) {
 	// Test monthly goal
-	monthlyGoal := IncomeGoal{
+	monthlyGoal := models.IncomeGoal{
 		Period: "monthly",
 		Year:   2024,
 		Month:  6,
@@ -62,7 +64,7 @@ func TestGetGoalDateRange(t *testing.T) {
 	}
 
 	// Test quarterly goal
-	quarterlyGoal := IncomeGoal{
+	quarterlyGoal := models.IncomeGoal{
 		Period:  "quarterly",
 		Year:    2024,
 		Quarter: 2,
@@ -77,7 +79,7 @@ func TestGetGoalDateRange(t *testing.T) {
 	}
 
 	// Test yearly goal
-	yearlyGoal := IncomeGoal{
+	yearlyGoal := models.IncomeGoal{
 		Period: "yearly",
 		Year:   2024,
 	}
@@ -108,7 +110,7 @@ func TestGetDaysRemaining(t *testing.T) {
 }
 
 func TestIncomeGoalStruct(t *testing.T) {
-	goal := IncomeGoal{
+	goal := models.IncomeGoal{
 		Amount:      5000,
 		Period:      "monthly",
 		Year:        2024,
@@ -3,8 +3,8 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/internal/repository"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/huh"
 )
 
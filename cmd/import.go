@@ -5,13 +5,12 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -24,6 +23,8 @@ var importCmd = &cobra.Command{
 Supported sources:
   - CSV files (clients, expenses, time)
   - SQLite databases (full database import)
+  - iCalendar files (meetings as tracked time)
+  - Toggl, Clockify and Harvest exports (time entries)
 
 CSV Format Requirements:
   Clients:   name,email,address,tax_id
@@ -34,7 +35,9 @@ Examples:
   ung import                              Interactive import wizard
   ung import --file clients.csv --type clients
   ung import db backup.db                 Import from SQLite
-  ung import db backup.db --password xyz  Import from encrypted SQLite`,
+  ung import db backup.db --password xyz  Import from encrypted SQLite
+  ung import ics calendar.ics             Import calendar meetings
+  ung import toggl report.csv             Import Toggl time entries`,
 	RunE: runImport,
 }
 
@@ -488,7 +491,7 @@ func runImportDB(cmd *cobra.Command, args []string) error {
 
 	// Check if it's a .db.encrypted file (AES-256-GCM encrypted)
 	isAESEncrypted := strings.HasSuffix(dbFile, ".encrypted") || strings.HasSuffix(dbFile, ".db.encrypted")
-	var tempDecryptedPath string
+	var sourceDB *db.SQLiteDB
 
 	if isAESEncrypted {
 		// Need password for AES-encrypted files
@@ -512,27 +515,21 @@ func runImportDB(cmd *cobra.Command, args []string) error {
 
 		fmt.Println("Decrypting AES-256-GCM encrypted database...")
 
-		// Create temp file for decrypted database
-		tempDecryptedPath = filepath.Join(os.TempDir(), fmt.Sprintf("ung_import_%d.db", time.Now().UnixNano()))
-
-		if err := db.DecryptDatabase(dbFile, tempDecryptedPath, importPassword); err != nil {
-			return fmt.Errorf("failed to decrypt database: %w", err)
+		// Decrypt into memory so no plaintext copy touches the disk
+		var err error
+		sourceDB, err = db.OpenEncryptedSQLite(dbFile, importPassword)
+		if err != nil {
+			return err
 		}
-		defer os.Remove(tempDecryptedPath)
-
-		dbFile = tempDecryptedPath
 		fmt.Println("Database decrypted successfully")
-	} else if importPassword != "" {
-		fmt.Println("Using password for SQLCipher encrypted database")
-	}
-
-	// Open source database using raw SQL
-	sourceDB, err := openSourceDB(dbFile, importPassword)
-	if err != nil {
-		// If decryption already happened, don't pass password again
-		if tempDecryptedPath != "" {
-			sourceDB, err = openSourceDB(dbFile, "")
+	} else {
+		if importPassword != "" {
+			fmt.Println("Using password for SQLCipher encrypted database")
 		}
+
+		// Open source database using raw SQL
+		var err error
+		sourceDB, err = openSourceDB(dbFile, importPassword)
 		if err != nil {
 			return fmt.Errorf("failed to open source database: %w", err)
 		}
@@ -0,0 +1,492 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/ical"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+var importICSCmd = &cobra.Command{
+	Use:   "ics <file>",
+	Short: "Import calendar meetings as tracked time",
+	Long: `Import meetings from an iCalendar (.ics) file as time tracking sessions.
+
+Events are matched to clients and contracts by:
+  1. Rules in config.yaml (import.calendar_rules)
+  2. Attendee/organizer email domain vs. client email domain
+  3. Contract or client name in the event title
+  4. Client name in the calendar name
+
+Each event is imported once: re-importing the same file skips events
+that were already imported (matched by the event UID).
+
+Recurring meetings are expanded into one entry per occurrence between
+--since and --until (or now). Rules the importer cannot expand are
+reported as an error instead of being imported as a single meeting.
+
+Example rules in config.yaml:
+  import:
+    calendar_rules:
+      - domain: acme.com
+        contract_id: 3
+      - keyword: standup
+        client_id: 2
+      - calendar: "Globex"
+        client_id: 4
+
+Examples:
+  ung import ics calendar.ics                     Review and import meetings
+  ung import ics calendar.ics --since 2024-01-01  Only meetings after a date
+  ung import ics calendar.ics --dry-run           Preview matches without saving
+  ung import ics calendar.ics --yes               Import matched meetings without review`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportICS,
+}
+
+var (
+	importICSSince     string
+	importICSUntil     string
+	importICSYes       bool
+	importICSUnmatched bool
+)
+
+func init() {
+	importICSCmd.Flags().StringVar(&importICSSince, "since", "", "Only import events starting on or after this date (YYYY-MM-DD)")
+	importICSCmd.Flags().StringVar(&importICSUntil, "until", "", "Only import events starting before this date (YYYY-MM-DD)")
+	importICSCmd.Flags().BoolVarP(&importICSYes, "yes", "y", false, "Import matched events without interactive review")
+	importICSCmd.Flags().BoolVar(&importICSUnmatched, "unmatched", false, "Also propose events that don't match any client")
+	importICSCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Preview import without saving")
+
+	importCmd.AddCommand(importICSCmd)
+}
+
+// icsExternalIDPrefix marks tracking sessions created from calendar events
+const icsExternalIDPrefix = "ics:"
+
+// icsDraft is a calendar event proposed as a tracking session
+type icsDraft struct {
+	event      ical.Event
+	externalID string
+	clientID   *uint
+	contractID *uint
+	clientName string
+	reason     string // why the event matched, shown during review
+}
+
+// hours returns the event duration in hours
+func (d icsDraft) hours() float64 {
+	return d.event.Duration().Hours()
+}
+
+// icsClient is the subset of client data used for matching
+type icsClient struct {
+	id     uint
+	name   string
+	domain string
+}
+
+// icsContract is the subset of contract data used for matching
+type icsContract struct {
+	id       uint
+	clientID uint
+	name     string
+}
+
+// icsMatcher assigns calendar events to clients and contracts
+type icsMatcher struct {
+	rules     []config.CalendarRule
+	clients   []icsClient
+	contracts []icsContract
+}
+
+// freeMailDomains are never used for domain matching since they don't identify a client
+var freeMailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"outlook.com":    true,
+	"hotmail.com":    true,
+	"live.com":       true,
+	"yahoo.com":      true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"proton.me":      true,
+	"protonmail.com": true,
+}
+
+// emailDomain returns the lowercased domain part of an email address
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// loadICSMatcher builds a matcher from the configured rules and the current clients/contracts
+func loadICSMatcher() (*icsMatcher, error) {
+	m := &icsMatcher{}
+	if cfg, err := config.Load(); err == nil {
+		m.rules = cfg.Import.CalendarRules
+	}
+
+	var clients []models.Client
+	if err := db.GormDB.Order("name").Find(&clients).Error; err != nil {
+		return nil, fmt.Errorf("failed to load clients: %w", err)
+	}
+	for _, c := range clients {
+		m.clients = append(m.clients, icsClient{id: c.ID, name: c.Name, domain: emailDomain(c.Email)})
+	}
+
+	var contracts []models.Contract
+	if err := db.GormDB.Where("active = ?", true).Order("id DESC").Find(&contracts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load contracts: %w", err)
+	}
+	for _, c := range contracts {
+		m.contracts = append(m.contracts, icsContract{id: c.ID, clientID: c.ClientID, name: c.Name})
+	}
+
+	return m, nil
+}
+
+// match finds the client and contract for an event
+func (m *icsMatcher) match(event ical.Event, calendarName string) (clientID, contractID *uint, reason string) {
+	title := strings.ToLower(event.Summary)
+	calendar := strings.ToLower(calendarName)
+	domains := event.Domains()
+
+	// 1. Explicit rules from config
+	for _, rule := range m.rules {
+		var why string
+		switch {
+		case rule.Domain != "" && slices.Contains(domains, strings.ToLower(rule.Domain)):
+			why = "rule: domain " + rule.Domain
+		case rule.Keyword != "" && strings.Contains(title, strings.ToLower(rule.Keyword)):
+			why = "rule: keyword " + rule.Keyword
+		case rule.Calendar != "" && strings.EqualFold(rule.Calendar, calendarName):
+			why = "rule: calendar " + rule.Calendar
+		default:
+			continue
+		}
+		if rule.ContractID > 0 {
+			if c := m.contractByID(rule.ContractID); c != nil {
+				return uintPtr(c.clientID), uintPtr(c.id), why
+			}
+		}
+		if rule.ClientID > 0 {
+			return uintPtr(rule.ClientID), m.activeContractFor(rule.ClientID), why
+		}
+	}
+
+	// 2. Attendee domain matches the client's email domain
+	for _, c := range m.clients {
+		if c.domain != "" && !freeMailDomains[c.domain] && slices.Contains(domains, c.domain) {
+			return uintPtr(c.id), m.activeContractFor(c.id), "attendee domain " + c.domain
+		}
+	}
+
+	// 3. Contract name in the event title
+	for _, c := range m.contracts {
+		if c.name != "" && strings.Contains(title, strings.ToLower(c.name)) {
+			return uintPtr(c.clientID), uintPtr(c.id), "title matches contract"
+		}
+	}
+
+	// 4. Client name in the event title or calendar name
+	for _, c := range m.clients {
+		name := strings.ToLower(c.name)
+		if name == "" {
+			continue
+		}
+		if strings.Contains(title, name) {
+			return uintPtr(c.id), m.activeContractFor(c.id), "title matches client"
+		}
+		if calendar != "" && strings.Contains(calendar, name) {
+			return uintPtr(c.id), m.activeContractFor(c.id), "calendar name"
+		}
+	}
+
+	return nil, nil, ""
+}
+
+// contractByID returns an active contract by ID
+func (m *icsMatcher) contractByID(id uint) *icsContract {
+	for i := range m.contracts {
+		if m.contracts[i].id == id {
+			return &m.contracts[i]
+		}
+	}
+	return nil
+}
+
+// activeContractFor returns the most recent active contract for a client, if any
+func (m *icsMatcher) activeContractFor(clientID uint) *uint {
+	for _, c := range m.contracts {
+		if c.clientID == clientID {
+			return uintPtr(c.id)
+		}
+	}
+	return nil
+}
+
+// clientName returns a client's name for display
+func (m *icsMatcher) clientName(id uint) string {
+	for _, c := range m.clients {
+		if c.id == id {
+			return c.name
+		}
+	}
+	return fmt.Sprintf("client #%d", id)
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+// buildICSDrafts filters events and proposes tracking sessions for them.
+// Events already imported (by external ID) are counted in skipped.
+func buildICSDrafts(cal *ical.Calendar, matcher *icsMatcher, imported map[string]bool, since, until time.Time, includeUnmatched bool) (drafts []icsDraft, skipped int) {
+	for _, event := range cal.Events {
+		if event.UID == "" || event.AllDay || event.Status == "CANCELLED" || event.Duration() <= 0 {
+			skipped++
+			continue
+		}
+		if !since.IsZero() && event.Start.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !event.Start.Before(until) {
+			continue
+		}
+
+		externalID := icsExternalIDPrefix + event.Key()
+		if imported[externalID] {
+			skipped++
+			continue
+		}
+
+		draft := icsDraft{event: event, externalID: externalID}
+		draft.clientID, draft.contractID, draft.reason = matcher.match(event, cal.Name)
+		if draft.clientID == nil && !includeUnmatched {
+			skipped++
+			continue
+		}
+		if draft.clientID != nil {
+			draft.clientName = matcher.clientName(*draft.clientID)
+		}
+		drafts = append(drafts, draft)
+	}
+
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].event.Start.Before(drafts[j].event.Start)
+	})
+	return drafts, skipped
+}
+
+// loadImportedExternalIDs returns external IDs with the given prefix, including soft-deleted
+// sessions so that meetings the user removed are not imported again
+func loadImportedExternalIDs(prefix string) (map[string]bool, error) {
+	rows, err := db.DB.Query("SELECT external_id FROM tracking_sessions WHERE external_id LIKE ?", prefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load imported sessions: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+func runImportICS(cmd *cobra.Command, args []string) error {
+	filePath := args[0]
+	if strings.HasPrefix(filePath, "~/") {
+		filePath = strings.Replace(filePath, "~", os.Getenv("HOME"), 1)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	cal, err := ical.Parse(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse calendar: %w", err)
+	}
+
+	var since, until time.Time
+	if importICSSince != "" {
+		if since, err = parseDate(importICSSince); err != nil {
+			return err
+		}
+	}
+	if importICSUntil != "" {
+		if until, err = parseDate(importICSUntil); err != nil {
+			return err
+		}
+	}
+
+	// Recurring meetings are expanded up to --until, or up to now since
+	// future occurrences haven't taken place yet
+	expandUntil := until
+	if expandUntil.IsZero() {
+		expandUntil = time.Now()
+	}
+	if cal.Events, err = ical.Expand(cal.Events, since, expandUntil); err != nil {
+		return fmt.Errorf("failed to expand recurring events: %w", err)
+	}
+
+	matcher, err := loadICSMatcher()
+	if err != nil {
+		return err
+	}
+	imported, err := loadImportedExternalIDs(icsExternalIDPrefix)
+	if err != nil {
+		return err
+	}
+
+	drafts, skipped := buildICSDrafts(cal, matcher, imported, since, until, importICSUnmatched)
+
+	fmt.Printf("\nImporting meetings from %s", filePath)
+	if cal.Name != "" {
+		fmt.Printf(" (%s)", cal.Name)
+	}
+	fmt.Printf("\nFound %d events, %d to review, %d skipped (already imported, all-day, cancelled or unmatched)\n\n",
+		len(cal.Events), len(drafts), skipped)
+
+	if len(drafts) == 0 {
+		fmt.Println("Nothing to import.")
+		return nil
+	}
+
+	if importDryRun {
+		for _, d := range drafts {
+			fmt.Printf("  [Preview] %s\n", icsDraftLabel(d))
+		}
+		fmt.Println()
+		fmt.Println("Dry run completed - no data was saved")
+		return nil
+	}
+
+	selected := drafts
+	if !importICSYes {
+		selected, err = reviewICSDrafts(drafts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("No meetings selected - nothing was imported.")
+		return nil
+	}
+
+	created := 0
+	errors := 0
+	var totalHours float64
+	for _, d := range selected {
+		session := icsDraftSession(d)
+		if err := db.GormDB.Create(&session).Error; err != nil {
+			fmt.Printf("  [Error] %s: %v\n", d.event.Summary, err)
+			errors++
+			continue
+		}
+		fmt.Printf("  [OK] %s\n", icsDraftLabel(d))
+		totalHours += d.hours()
+		created++
+	}
+
+	fmt.Println()
+	fmt.Printf("✓ Imported: %d (%.2fh), Skipped: %d, Errors: %d\n", created, totalHours, skipped+len(drafts)-len(selected), errors)
+	return nil
+}
+
+// reviewICSDrafts lets the user pick which proposed meetings to import
+func reviewICSDrafts(drafts []icsDraft) ([]icsDraft, error) {
+	options := make([]huh.Option[int], len(drafts))
+	var chosen []int
+	for i, d := range drafts {
+		options[i] = huh.NewOption(icsDraftLabel(d), i).Selected(d.clientID != nil)
+	}
+
+	var confirm bool
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Review meetings to import").
+				Description("Space to toggle, enter to continue. Unmatched meetings are imported without a client.").
+				Options(options...).
+				Value(&chosen),
+		),
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Create tracking sessions for the selected meetings?").
+				Affirmative("Import").
+				Negative("Cancel").
+				Value(&confirm),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("cancelled: %w", err)
+	}
+	if !confirm {
+		return nil, nil
+	}
+
+	selected := make([]icsDraft, 0, len(chosen))
+	for _, i := range chosen {
+		selected = append(selected, drafts[i])
+	}
+	return selected, nil
+}
+
+// icsDraftLabel formats a draft for previews and the review list
+func icsDraftLabel(d icsDraft) string {
+	target := "(no client)"
+	if d.clientID != nil {
+		target = fmt.Sprintf("%s [%s]", d.clientName, d.reason)
+	}
+	return fmt.Sprintf("%s  %5.2fh  %s → %s",
+		d.event.Start.Local().Format("2006-01-02 15:04"), d.hours(), d.event.Summary, target)
+}
+
+// icsDraftSession converts a reviewed draft into a tracking session
+func icsDraftSession(d icsDraft) models.TrackingSession {
+	start := d.event.Start
+	end := d.event.End
+	hours := d.hours()
+	duration := int(d.event.Duration().Seconds())
+
+	notes := "Meeting imported from calendar"
+	if desc := strings.TrimSpace(d.event.Description); desc != "" {
+		notes += ": " + desc
+	}
+
+	return models.TrackingSession{
+		ClientID:    d.clientID,
+		ContractID:  d.contractID,
+		ProjectName: d.event.Summary,
+		StartTime:   start,
+		EndTime:     &end,
+		Duration:    &duration,
+		Hours:       &hours,
+		Billable:    d.clientID != nil,
+		Notes:       notes,
+		ExternalID:  d.externalID,
+	}
+}
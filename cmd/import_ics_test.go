@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/ical"
+)
+
+func testICSMatcher() *icsMatcher {
+	return &icsMatcher{
+		rules: []config.CalendarRule{
+			{Keyword: "standup", ClientID: 2},
+		},
+		clients: []icsClient{
+			{id: 1, name: "Acme", domain: "acme.com"},
+			{id: 2, name: "Globex", domain: "globex.io"},
+			{id: 3, name: "Freelancer", domain: "gmail.com"},
+		},
+		contracts: []icsContract{
+			{id: 10, clientID: 1, name: "Website Redesign"},
+			{id: 20, clientID: 2, name: "Retainer"},
+		},
+	}
+}
+
+func TestICSMatcherMatch(t *testing.T) {
+	m := testICSMatcher()
+
+	tests := []struct {
+		name         string
+		event        ical.Event
+		calendar     string
+		wantClient   uint
+		wantContract uint
+	}{
+		{
+			name:         "rule keyword wins",
+			event:        ical.Event{Summary: "Daily Standup", Attendees: []string{"bob@acme.com"}},
+			wantClient:   2,
+			wantContract: 20,
+		},
+		{
+			name:         "attendee domain",
+			event:        ical.Event{Summary: "Sync", Attendees: []string{"bob@acme.com"}},
+			wantClient:   1,
+			wantContract: 10,
+		},
+		{
+			name:         "free mail domain ignored",
+			event:        ical.Event{Summary: "Coffee", Attendees: []string{"someone@gmail.com"}},
+			wantClient:   0,
+			wantContract: 0,
+		},
+		{
+			name:         "contract name in title",
+			event:        ical.Event{Summary: "Website redesign review"},
+			wantClient:   1,
+			wantContract: 10,
+		},
+		{
+			name:         "client name in calendar",
+			event:        ical.Event{Summary: "Planning"},
+			calendar:     "Globex Work",
+			wantClient:   2,
+			wantContract: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientID, contractID, _ := m.match(tt.event, tt.calendar)
+
+			var gotClient, gotContract uint
+			if clientID != nil {
+				gotClient = *clientID
+			}
+			if contractID != nil {
+				gotContract = *contractID
+			}
+			if gotClient != tt.wantClient || gotContract != tt.wantContract {
+				t.Errorf("match() = client %d, contract %d; want client %d, contract %d",
+					gotClient, gotContract, tt.wantClient, tt.wantContract)
+			}
+		})
+	}
+}
+
+func TestBuildICSDrafts(t *testing.T) {
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	cal := &ical.Calendar{
+		Events: []ical.Event{
+			{UID: "b", Summary: "Sync", Attendees: []string{"x@acme.com"}, Start: start.Add(24 * time.Hour), End: start.Add(25 * time.Hour)},
+			{UID: "a", Summary: "Sync", Attendees: []string{"x@acme.com"}, Start: start, End: start.Add(time.Hour)},
+			{UID: "done", Summary: "Sync", Attendees: []string{"x@acme.com"}, Start: start, End: start.Add(time.Hour)},
+			{UID: "allday", Summary: "Offsite", AllDay: true, Start: start, End: start.Add(24 * time.Hour)},
+			{UID: "cancel", Summary: "Sync", Status: "CANCELLED", Start: start, End: start.Add(time.Hour)},
+			{UID: "nobody", Summary: "Dentist", Start: start, End: start.Add(time.Hour)},
+		},
+	}
+	imported := map[string]bool{icsExternalIDPrefix + "done": true}
+
+	drafts, skipped := buildICSDrafts(cal, testICSMatcher(), imported, time.Time{}, time.Time{}, false)
+	if len(drafts) != 2 {
+		t.Fatalf("expected 2 drafts, got %d", len(drafts))
+	}
+	if skipped != 4 {
+		t.Errorf("expected 4 skipped, got %d", skipped)
+	}
+	if drafts[0].event.UID != "a" {
+		t.Errorf("expected drafts sorted by start time, first is %s", drafts[0].event.UID)
+	}
+	if drafts[0].clientName != "Acme" {
+		t.Errorf("expected client name Acme, got %s", drafts[0].clientName)
+	}
+
+	drafts, _ = buildICSDrafts(cal, testICSMatcher(), imported, time.Time{}, time.Time{}, true)
+	if len(drafts) != 3 {
+		t.Errorf("expected unmatched event to be proposed, got %d drafts", len(drafts))
+	}
+
+	drafts, _ = buildICSDrafts(cal, testICSMatcher(), imported, start.Add(time.Hour), time.Time{}, false)
+	if len(drafts) != 1 || drafts[0].event.UID != "b" {
+		t.Errorf("expected --since to filter earlier events, got %d drafts", len(drafts))
+	}
+}
+
+func TestICSImportDedupe(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	draft := icsDraft{
+		event:      ical.Event{UID: "abc", Summary: "Planning", Start: start, End: start.Add(90 * time.Minute)},
+		externalID: icsExternalIDPrefix + "abc",
+	}
+
+	session := icsDraftSession(draft)
+	if session.Hours == nil || *session.Hours != 1.5 {
+		t.Fatalf("expected 1.5 hours, got %v", session.Hours)
+	}
+	if session.Billable {
+		t.Error("expected unmatched meeting to be non-billable")
+	}
+	if err := db.GormDB.Create(&session).Error; err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	// Soft-deleted sessions still count as imported
+	if _, err := db.DB.Exec("UPDATE tracking_sessions SET deleted_at = ? WHERE id = ?", time.Now(), session.ID); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+
+	imported, err := loadImportedExternalIDs(icsExternalIDPrefix)
+	if err != nil {
+		t.Fatalf("loadImportedExternalIDs() error: %v", err)
+	}
+	if !imported[icsExternalIDPrefix+"abc"] {
+		t.Error("expected imported event to be found by external ID")
+	}
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/timeimport"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	importCreateClients bool
+	importFormat        string
+)
+
+// newTrackerImportCmd builds the import subcommand for a time tracker export
+func newTrackerImportCmd(source timeimport.Source, product, exports string) *cobra.Command {
+	name := string(source)
+	cmd := &cobra.Command{
+		Use:   name + " <file>",
+		Short: fmt.Sprintf("Import time entries from a %s export", product),
+		Long: fmt.Sprintf(`Import time entries from a %s export (%s).
+
+Clients are matched by name, projects are matched to gigs (by name) and to the
+client's contracts, and tags are matched to gigs when the project isn't.
+Entries keep their %s ID, so importing the same export again skips entries
+that were already imported.
+
+Examples:
+  ung import %s export.csv --dry-run           Preview without saving
+  ung import %s export.csv                     Import entries
+  ung import %s export.json --create-clients   Create clients that don't exist yet`,
+			product, exports, product, name, name, name),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrackerImport(source, args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&importCreateClients, "create-clients", false, "Create clients that don't exist in UNG")
+	cmd.Flags().StringVar(&importFormat, "format", "", "File format: csv or json (default: from file extension)")
+	cmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Preview import without saving")
+	return cmd
+}
+
+func init() {
+	importCmd.AddCommand(newTrackerImportCmd(timeimport.SourceToggl, "Toggl Track", "Detailed report CSV or time entries JSON"))
+	importCmd.AddCommand(newTrackerImportCmd(timeimport.SourceClockify, "Clockify", "Detailed report CSV or time entries JSON"))
+	importCmd.AddCommand(newTrackerImportCmd(timeimport.SourceHarvest, "Harvest", "Detailed time report CSV or API time_entries JSON"))
+}
+
+// trackerMapper maps tracker clients, projects and tags to UNG clients, contracts and gigs
+type trackerMapper struct {
+	clients       []models.Client
+	contracts     []models.Contract // active contracts, most recent first
+	gigs          []models.Gig
+	createClients bool
+	dryRun        bool
+}
+
+// trackerTarget is the result of mapping an entry
+type trackerTarget struct {
+	clientID   *uint
+	contractID *uint
+	gigID      *uint
+	newClient  string // name of a client created (or to be created) for this entry
+}
+
+// loadTrackerMapper loads clients, contracts and gigs for mapping
+func loadTrackerMapper(createClients, dryRun bool) (*trackerMapper, error) {
+	m := &trackerMapper{createClients: createClients, dryRun: dryRun}
+	if err := db.GormDB.Find(&m.clients).Error; err != nil {
+		return nil, fmt.Errorf("failed to load clients: %w", err)
+	}
+	if err := db.GormDB.Where("active = ?", true).Order("id DESC").Find(&m.contracts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load contracts: %w", err)
+	}
+	if err := db.GormDB.Find(&m.gigs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load gigs: %w", err)
+	}
+	return m, nil
+}
+
+// findClient looks up a client by case-insensitive name
+func (m *trackerMapper) findClient(name string) *models.Client {
+	for i := range m.clients {
+		if strings.EqualFold(strings.TrimSpace(m.clients[i].Name), strings.TrimSpace(name)) {
+			return &m.clients[i]
+		}
+	}
+	return nil
+}
+
+// findGig looks up an open gig by name or project, ignoring case and separators
+func (m *trackerMapper) findGig(name string) *models.Gig {
+	normalized := normalizeRepoName(name)
+	if normalized == "" {
+		return nil
+	}
+	for i := range m.gigs {
+		g := &m.gigs[i]
+		if g.Status == models.GigStatusDone || g.Status == models.GigStatusCancelled {
+			continue
+		}
+		if normalizeRepoName(g.Name) == normalized || (g.Project != "" && normalizeRepoName(g.Project) == normalized) {
+			return g
+		}
+	}
+	return nil
+}
+
+// contractFor picks the client's contract matching the project, or its most recent active contract
+func (m *trackerMapper) contractFor(clientID uint, project string) *uint {
+	normalized := normalizeRepoName(project)
+	var fallback *uint
+	for _, c := range m.contracts {
+		if c.ClientID != clientID {
+			continue
+		}
+		if normalized != "" && strings.Contains(normalizeRepoName(c.Name), normalized) {
+			return uintPtr(c.ID)
+		}
+		if fallback == nil {
+			fallback = uintPtr(c.ID)
+		}
+	}
+	return fallback
+}
+
+// resolve maps an entry, creating its client when allowed
+func (m *trackerMapper) resolve(e timeimport.Entry) (trackerTarget, error) {
+	var t trackerTarget
+
+	gig := m.findGig(e.Project)
+	for _, tag := range e.Tags {
+		if gig != nil {
+			break
+		}
+		gig = m.findGig(tag)
+	}
+	if gig != nil {
+		t.gigID = uintPtr(gig.ID)
+		t.clientID = gig.ClientID
+		t.contractID = gig.ContractID
+	}
+
+	if e.Client != "" {
+		if client := m.findClient(e.Client); client != nil {
+			t.clientID = uintPtr(client.ID)
+		} else if m.createClients {
+			t.newClient = e.Client
+			if m.dryRun {
+				return t, nil
+			}
+			client := models.Client{Name: e.Client}
+			if err := db.GormDB.Create(&client).Error; err != nil {
+				return t, fmt.Errorf("failed to create client %s: %w", e.Client, err)
+			}
+			m.clients = append(m.clients, client)
+			t.clientID = uintPtr(client.ID)
+		}
+	}
+
+	if t.contractID == nil && t.clientID != nil {
+		t.contractID = m.contractFor(*t.clientID, e.Project)
+	}
+	return t, nil
+}
+
+// trackerEntrySession converts an imported entry into a tracking session
+func trackerEntrySession(e timeimport.Entry, t trackerTarget) models.TrackingSession {
+	end := e.End
+	hours := e.Hours
+	duration := int(e.Hours * 3600)
+
+	project := e.Project
+	if project == "" {
+		project = e.Task
+	}
+	notes := e.Description
+	if e.Task != "" && e.Task != project {
+		notes = strings.TrimSpace(e.Task + ": " + notes)
+	}
+
+	return models.TrackingSession{
+		ClientID:    t.clientID,
+		ContractID:  t.contractID,
+		ProjectName: project,
+		StartTime:   e.Start,
+		EndTime:     &end,
+		Duration:    &duration,
+		Hours:       &hours,
+		Billable:    e.Billable,
+		Notes:       notes,
+		ExternalID:  e.ExternalID,
+	}
+}
+
+// addGigHours adds tracked hours to a gig's aggregates
+func addGigHours(tx *gorm.DB, gigID uint, hours float64, at time.Time) error {
+	return tx.Model(&models.Gig{}).Where("id = ?", gigID).Updates(map[string]interface{}{
+		"total_hours_tracked": gorm.Expr("total_hours_tracked + ?", hours),
+		"last_tracked_at":     at,
+	}).Error
+}
+
+func runTrackerImport(source timeimport.Source, filePath string) error {
+	if strings.HasPrefix(filePath, "~/") {
+		filePath = strings.Replace(filePath, "~", os.Getenv("HOME"), 1)
+	}
+
+	format := timeimport.DetectFormat(filePath)
+	if importFormat != "" {
+		format = timeimport.Format(strings.ToLower(importFormat))
+		if format != timeimport.FormatCSV && format != timeimport.FormatJSON {
+			return fmt.Errorf("unknown format: %s (use csv or json)", importFormat)
+		}
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	entries, err := timeimport.Parse(source, format, file)
+	if err != nil {
+		return err
+	}
+
+	mapper, err := loadTrackerMapper(importCreateClients, importDryRun)
+	if err != nil {
+		return err
+	}
+	imported, err := loadImportedExternalIDs(string(source) + ":")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nImporting %d %s time entries from %s...\n\n", len(entries), source, filePath)
+
+	created := 0
+	skipped := 0
+	errors := 0
+	unmatched := make(map[string]bool)
+
+	for _, e := range entries {
+		label := fmt.Sprintf("%s: %.2fh %s", e.Start.Format("2006-01-02"), e.Hours, cmp.Or(e.Project, e.Description, "(no project)"))
+
+		if imported[e.ExternalID] {
+			fmt.Printf("  [Skip] %s (already imported)\n", label)
+			skipped++
+			continue
+		}
+		if e.Hours <= 0 {
+			fmt.Printf("  [Skip] %s (no duration)\n", label)
+			skipped++
+			continue
+		}
+
+		target, err := mapper.resolve(e)
+		if err != nil {
+			fmt.Printf("  [Error] %s: %v\n", label, err)
+			errors++
+			continue
+		}
+		if target.clientID == nil && target.newClient == "" && e.Client != "" {
+			unmatched[e.Client] = true
+		}
+
+		if importDryRun {
+			client := e.Client
+			if target.newClient != "" {
+				client += " (new)"
+			} else if target.clientID == nil && client != "" {
+				client += " (not found)"
+			}
+			fmt.Printf("  [Preview] %s → %s\n", label, cmp.Or(client, "(no client)"))
+			imported[e.ExternalID] = true
+			created++
+			continue
+		}
+
+		session := trackerEntrySession(e, target)
+		err = db.GormDB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&session).Error; err != nil {
+				return err
+			}
+			if target.gigID != nil {
+				return addGigHours(tx, *target.gigID, e.Hours, e.End)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("  [Error] %s: %v\n", label, err)
+			errors++
+			continue
+		}
+
+		imported[e.ExternalID] = true
+		fmt.Printf("  [OK] %s\n", label)
+		created++
+	}
+
+	fmt.Println()
+	if len(unmatched) > 0 {
+		names := make([]string, 0, len(unmatched))
+		for name := range unmatched {
+			names = append(names, name)
+		}
+		fmt.Printf("⚠️  Clients not found in UNG (entries imported without client): %s\n", strings.Join(names, ", "))
+		fmt.Println("   Use --create-clients to create them.")
+	}
+	if importDryRun {
+		fmt.Println("Dry run completed - no data was saved")
+	}
+	fmt.Printf("✓ Imported: %d, Skipped: %d, Errors: %d\n", created, skipped, errors)
+	return nil
+}
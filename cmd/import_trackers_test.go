@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/timeimport"
+)
+
+func TestTrackerMapperResolve(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+
+	client := models.Client{Name: "Acme", Email: "billing@acme.com"}
+	if err := db.GormDB.Create(&client).Error; err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	rate := 100.0
+	contracts := []models.Contract{
+		{ContractNum: "c.acme.1", ClientID: client.ID, Name: "Support", ContractType: models.ContractTypeHourly, HourlyRate: &rate, Active: true, StartDate: time.Now()},
+		{ContractNum: "c.acme.2", ClientID: client.ID, Name: "Website Build", ContractType: models.ContractTypeHourly, HourlyRate: &rate, Active: true, StartDate: time.Now()},
+	}
+	for i := range contracts {
+		if err := db.GormDB.Create(&contracts[i]).Error; err != nil {
+			t.Fatalf("failed to create contract: %v", err)
+		}
+	}
+
+	mapper, err := loadTrackerMapper(false, false)
+	if err != nil {
+		t.Fatalf("loadTrackerMapper() error: %v", err)
+	}
+
+	target, err := mapper.resolve(timeimport.Entry{Client: "acme", Project: "website"})
+	if err != nil {
+		t.Fatalf("resolve() error: %v", err)
+	}
+	if target.clientID == nil || *target.clientID != client.ID {
+		t.Fatalf("expected client %d, got %v", client.ID, target.clientID)
+	}
+	if target.contractID == nil || *target.contractID != contracts[1].ID {
+		t.Errorf("expected project to select contract %d, got %v", contracts[1].ID, target.contractID)
+	}
+
+	target, _ = mapper.resolve(timeimport.Entry{Client: "Acme", Project: "Something else"})
+	if target.contractID == nil || *target.contractID != contracts[1].ID {
+		t.Errorf("expected most recent active contract as fallback, got %v", target.contractID)
+	}
+
+	target, _ = mapper.resolve(timeimport.Entry{Client: "Globex"})
+	if target.clientID != nil {
+		t.Errorf("expected unknown client to stay unmatched without --create-clients")
+	}
+
+	mapper.createClients = true
+	target, err = mapper.resolve(timeimport.Entry{Client: "Globex"})
+	if err != nil {
+		t.Fatalf("resolve() error: %v", err)
+	}
+	if target.clientID == nil || target.newClient != "Globex" {
+		t.Fatalf("expected Globex to be created, got %+v", target)
+	}
+	var count int64
+	db.GormDB.Model(&models.Client{}).Where("name = ?", "Globex").Count(&count)
+	if count != 1 {
+		t.Errorf("expected 1 Globex client, got %d", count)
+	}
+
+	// A second entry for the same client reuses the created client
+	again, _ := mapper.resolve(timeimport.Entry{Client: "globex"})
+	if again.clientID == nil || *again.clientID != *target.clientID || again.newClient != "" {
+		t.Errorf("expected created client to be reused, got %+v", again)
+	}
+}
+
+func TestTrackerEntrySession(t *testing.T) {
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	entry := timeimport.Entry{
+		ExternalID:  "toggl:101",
+		Project:     "Website",
+		Task:        "Design",
+		Description: "Wireframes",
+		Billable:    true,
+		Start:       start,
+		End:         start.Add(90 * time.Minute),
+		Hours:       1.5,
+	}
+
+	session := trackerEntrySession(entry, trackerTarget{})
+	if session.ExternalID != "toggl:101" {
+		t.Errorf("unexpected external ID: %s", session.ExternalID)
+	}
+	if session.ProjectName != "Website" || session.Notes != "Design: Wireframes" {
+		t.Errorf("unexpected project/notes: %s / %s", session.ProjectName, session.Notes)
+	}
+	if session.Duration == nil || *session.Duration != 5400 {
+		t.Errorf("expected 5400s duration, got %v", session.Duration)
+	}
+}
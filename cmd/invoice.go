@@ -11,10 +11,12 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/email"
 	"github.com/Andriiklymiuk/ung/pkg/idgen"
 	"github.com/Andriiklymiuk/ung/pkg/invoice"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -79,6 +81,23 @@ Examples:
 	RunE: runInvoiceSendAll,
 }
 
+var invoiceRemindCmd = &cobra.Command{
+	Use:   "remind [id]",
+	Short: "Send payment reminders for overdue invoices",
+	Long: `Send payment reminders, rendered from the reminder email template, for an
+invoice or for all overdue invoices.
+
+An invoice is overdue when it's marked overdue, or pending or sent and past
+its due date. Edit the reminder with: ung email template create reminder
+
+Examples:
+  ung invoice remind                      Remind all overdue invoices
+  ung invoice remind 5                    Remind invoice #5
+  ung invoice remind --email-app gmail    Use Gmail`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInvoiceRemind,
+}
+
 var invoiceMarkCmd = &cobra.Command{
 	Use:   "mark <id>",
 	Short: "Mark an invoice with a new status",
@@ -105,7 +124,8 @@ var invoiceEditCmd = &cobra.Command{
 
 Examples:
   ung invoice edit 5                          Interactive edit
-  ung invoice edit 5 --amount 1500            Update amount
+  ung invoice edit 5 --amount 1500            Update amount (before tax)
+  ung invoice edit 5 --tax reverse-charge     Recalculate tax with a tax profile
   ung invoice edit 5 --due 2025-01-15         Update due date
   ung invoice edit 5 --description "Updated"  Update description`,
 	Args: cobra.ExactArgs(1),
@@ -129,9 +149,10 @@ var invoiceMarkStatus string
 var invoiceEditAmount float64
 var invoiceEditDueDate string
 var invoiceEditDescription string
+var invoiceEditTax string
+var invoiceEditPO string
 var invoiceDeleteYes bool
 
-
 var (
 	// Flags for invoice new command
 	invoiceCompanyID   int
@@ -141,6 +162,8 @@ var (
 	invoiceCurrency    string
 	invoiceDescription string
 	invoiceDueDate     string
+	invoiceTaxProfile  string
+	invoicePO          string
 
 	// Flags for main invoice command
 	invoiceFlagClient   string // --client, -c
@@ -149,6 +172,7 @@ var (
 	invoiceFlagEmail    bool   // --email
 	invoiceFlagEmailApp string // --email-app
 	invoiceFlagBatch    bool   // --batch
+	invoiceFlagPO       string // --po
 )
 
 func init() {
@@ -157,6 +181,7 @@ func init() {
 	invoiceCmd.AddCommand(invoiceListCmd)
 	invoiceCmd.AddCommand(invoiceGenerateAllCmd)
 	invoiceCmd.AddCommand(invoiceSendAllCmd)
+	invoiceCmd.AddCommand(invoiceRemindCmd)
 	invoiceCmd.AddCommand(invoiceMarkCmd)
 	invoiceCmd.AddCommand(invoiceEditCmd)
 	invoiceCmd.AddCommand(invoiceDeleteCmd)
@@ -166,9 +191,11 @@ func init() {
 	invoiceMarkCmd.MarkFlagRequired("status")
 
 	// Edit command flags
-	invoiceEditCmd.Flags().Float64Var(&invoiceEditAmount, "amount", 0, "New invoice amount")
+	invoiceEditCmd.Flags().Float64Var(&invoiceEditAmount, "amount", 0, "New invoice amount (before tax)")
 	invoiceEditCmd.Flags().StringVar(&invoiceEditDueDate, "due", "", "New due date (YYYY-MM-DD)")
 	invoiceEditCmd.Flags().StringVar(&invoiceEditDescription, "description", "", "New description")
+	invoiceEditCmd.Flags().StringVar(&invoiceEditTax, "tax", "", "Recalculate tax with a tax profile")
+	invoiceEditCmd.Flags().StringVar(&invoiceEditPO, "po", "", "Client's purchase order number")
 
 	// Delete command flags
 	invoiceDeleteCmd.Flags().BoolVarP(&invoiceDeleteYes, "yes", "y", false, "Skip confirmation prompt")
@@ -178,25 +205,31 @@ func init() {
 	invoiceCmd.Flags().IntVar(&invoiceFlagID, "id", 0, "Existing invoice ID")
 	invoiceCmd.Flags().BoolVar(&invoiceFlagPDF, "pdf", false, "Generate PDF")
 	invoiceCmd.Flags().BoolVar(&invoiceFlagEmail, "email", false, "Send email (auto-generates PDF)")
-	invoiceCmd.Flags().StringVar(&invoiceFlagEmailApp, "email-app", "", "Email client (apple, outlook, gmail)")
+	invoiceCmd.Flags().StringVar(&invoiceFlagEmailApp, "email-app", "", "Email client (smtp, apple, outlook, gmail)")
 	invoiceCmd.Flags().BoolVar(&invoiceFlagBatch, "batch", false, "Batch operation for multiple invoices")
+	invoiceCmd.Flags().StringVar(&invoiceFlagPO, "po", "", "Client's purchase order number for the invoice from tracked time")
 
 	// Generate-all command flags
 	invoiceGenerateAllCmd.Flags().BoolVar(&invoiceFlagPDF, "pdf", false, "Generate PDF for each invoice")
 	invoiceGenerateAllCmd.Flags().BoolVar(&invoiceFlagEmail, "email", false, "Send email for each invoice (auto-generates PDF)")
-	invoiceGenerateAllCmd.Flags().StringVar(&invoiceFlagEmailApp, "email-app", "", "Email client (apple, outlook, gmail)")
+	invoiceGenerateAllCmd.Flags().StringVar(&invoiceFlagEmailApp, "email-app", "", "Email client (smtp, apple, outlook, gmail)")
 
 	// Send-all command flags
-	invoiceSendAllCmd.Flags().StringVar(&invoiceFlagEmailApp, "email-app", "", "Email client (apple, outlook, gmail)")
+	invoiceSendAllCmd.Flags().StringVar(&invoiceFlagEmailApp, "email-app", "", "Email client (smtp, apple, outlook, gmail)")
+
+	// Remind command flags
+	invoiceRemindCmd.Flags().StringVar(&invoiceFlagEmailApp, "email-app", "", "Email client (smtp, apple, outlook, gmail)")
 
 	// New invoice flags
 	invoiceNewCmd.Flags().IntVar(&invoiceCompanyID, "company", 0, "Company ID")
 	invoiceNewCmd.Flags().IntVar(&invoiceClientID, "client-id", 0, "Client ID")
 	invoiceNewCmd.Flags().StringVar(&invoiceClientName, "client-name", "", "Client name (partial match, min 3 chars)")
 	invoiceNewCmd.Flags().Float64Var(&invoiceAmount, "price", 0, "Invoice amount (required)")
-	invoiceNewCmd.Flags().StringVar(&invoiceCurrency, "currency", "USD", "Currency code")
+	invoiceNewCmd.Flags().StringVar(&invoiceCurrency, "currency", "", "Currency code (default: the client's, otherwise USD)")
 	invoiceNewCmd.Flags().StringVar(&invoiceDescription, "description", "", "Invoice description")
-	invoiceNewCmd.Flags().StringVar(&invoiceDueDate, "due", "", "Due date (YYYY-MM-DD)")
+	invoiceNewCmd.Flags().StringVar(&invoiceDueDate, "due", "", "Due date (YYYY-MM-DD, default: the client's payment terms)")
+	invoiceNewCmd.Flags().StringVar(&invoicePO, "po", "", "Client's purchase order number")
+	invoiceNewCmd.Flags().StringVar(&invoiceTaxProfile, "tax", "", "Tax profile (default: the client's, see 'ung config' tax section)")
 	invoiceNewCmd.MarkFlagRequired("price")
 }
 
@@ -256,6 +289,22 @@ func runInvoiceNew(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// The client's billing profile fills in what isn't given
+	var client models.Client
+	if err := db.GormDB.First(&client, resolvedClientID).Error; err != nil {
+		return fmt.Errorf("client not found: %w", err)
+	}
+	if err := checkPONumber(client, invoicePO); err != nil {
+		return err
+	}
+	currency := invoiceCurrency
+	if currency == "" {
+		currency = client.Currency
+	}
+	if currency == "" {
+		currency = "USD"
+	}
+
 	// Get company ID - use provided or default to first company
 	if invoiceCompanyID == 0 {
 		err := db.DB.QueryRow("SELECT id FROM companies LIMIT 1").Scan(&invoiceCompanyID)
@@ -282,45 +331,38 @@ func runInvoiceNew(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("invalid due date format (use YYYY-MM-DD): %w", err)
 		}
 	} else {
-		// Default: 30 days from invoice date
-		dueDate = issuedDate.AddDate(0, 0, 30)
+		dueDate = client.DueDate(issuedDate)
 	}
 
-	// Insert invoice
-	query := `
-		INSERT INTO invoices (invoice_num, company_id, amount, currency, description, status, issued_date, due_date)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
-	result, err := db.DB.Exec(query,
-		invoiceNum,
-		invoiceCompanyID,
-		invoiceAmount,
-		invoiceCurrency,
-		invoiceDescription,
-		models.StatusPending,
-		issuedDate,
-		dueDate,
-	)
+	// The price is before tax, stored as a single line item so its tax is kept
+	items := []models.InvoiceLineItem{{
+		ItemName: invoiceDescription,
+		Quantity: 1,
+		Rate:     invoiceAmount,
+		Amount:   invoiceAmount,
+	}}
+	t, err := invoiceTax(invoiceTaxProfile, uint(resolvedClientID), 0, items)
 	if err != nil {
-		return fmt.Errorf("failed to create invoice: %w", err)
-	}
-
-	invoiceID, _ := result.LastInsertId()
-
-	// Link invoice to client
-	_, err = db.DB.Exec(
-		"INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (?, ?)",
-		invoiceID, resolvedClientID,
-	)
+		return err
+	}
+
+	invoiceID, err := insertInvoice(models.Invoice{
+		InvoiceNum:  invoiceNum,
+		CompanyID:   uint(invoiceCompanyID),
+		Currency:    currency,
+		Description: invoiceDescription,
+		IssuedDate:  issuedDate,
+		DueDate:     dueDate,
+		PONumber:    strings.TrimSpace(invoicePO),
+	}, uint(resolvedClientID), items, t)
 	if err != nil {
-		return fmt.Errorf("failed to link invoice to client: %w", err)
+		return err
 	}
 
 	fmt.Printf("✓ Invoice created successfully\n")
 	fmt.Printf("  Invoice Number: %s\n", invoiceNum)
 	fmt.Printf("  Invoice ID: %d\n", invoiceID)
-	fmt.Printf("  Amount: %.2f %s\n", invoiceAmount, invoiceCurrency)
+	printInvoiceTax(t, currency)
 	fmt.Printf("  Due Date: %s\n", dueDate.Format("2006-01-02"))
 	return nil
 }
@@ -362,7 +404,6 @@ func runInvoiceList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-
 // exportToAppleMail opens Apple Mail with prefilled email and attachment
 func exportToAppleMail(subject, body, attachmentPath string) error {
 	if runtime.GOOS != "darwin" {
@@ -448,7 +489,6 @@ func escapeAppleScript(s string) string {
 	return s
 }
 
-
 // runInvoiceMain handles the main invoice command with flags
 func runInvoiceMain(cmd *cobra.Command, args []string) error {
 	// If --batch flag is set, handle batch operations
@@ -458,7 +498,7 @@ func runInvoiceMain(cmd *cobra.Command, args []string) error {
 
 	// If --client is provided, generate invoice from tracked time
 	if invoiceFlagClient != "" {
-		invoiceID, err := generateInvoiceFromTime(invoiceFlagClient)
+		invoiceID, err := generateInvoiceFromTime(invoiceFlagClient, invoiceFlagPO)
 		if err != nil {
 			return err
 		}
@@ -489,12 +529,20 @@ func runInvoiceMain(cmd *cobra.Command, args []string) error {
 }
 
 // generateInvoiceFromTime creates an invoice from tracked time for a client
-func generateInvoiceFromTime(clientName string) (int64, error) {
+// with the client's purchase order number po
+func generateInvoiceFromTime(clientName, po string) (int64, error) {
 	// Find client (handles multiple matches with interactive selection)
 	clientID, fullClientName, err := FindClientByName(clientName)
 	if err != nil {
 		return 0, fmt.Errorf("%w. Create client first with: ung client create", err)
 	}
+	var client models.Client
+	if err := db.GormDB.First(&client, clientID).Error; err != nil {
+		return 0, fmt.Errorf("client not found: %w", err)
+	}
+	if err := checkPONumber(client, po); err != nil {
+		return 0, err
+	}
 
 	fmt.Printf("📊 Generating invoice from tracked time for %s...\n\n", fullClientName)
 
@@ -561,43 +609,20 @@ func generateInvoiceFromTime(clientName string) (int64, error) {
 	// Create invoice - use end of current month for issued date
 	now := time.Now()
 	issuedDate := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())
-	dueDate := issuedDate.AddDate(0, 0, 30) // 30 days from issued date
-
-	result, err := db.DB.Exec(`
-		INSERT INTO invoices (invoice_num, company_id, amount, currency, description, status, issued_date, due_date)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, invoiceNum, companyID, amount, currency, "Time-based services", models.StatusPending, issuedDate, dueDate)
-
-	if err != nil {
-		return 0, fmt.Errorf("failed to create invoice: %w", err)
-	}
-
-	invoiceID, _ := result.LastInsertId()
-
-	// Link to client
-	db.DB.Exec("INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (?, ?)", invoiceID, clientID)
+	dueDate := client.DueDate(issuedDate)
 
 	// Create line items based on contract type
+	var items []models.InvoiceLineItem
 	if selectedGroup.ContractType == "fixed_price" {
 		// For fixed price contracts, create a single line item
 		monthName := time.Now().Format("January 2006")
-		itemName := fmt.Sprintf("Software services in %s", monthName)
-		itemDescription := fmt.Sprintf("Fixed price contract work (%.2f hours tracked)", selectedGroup.TotalHours)
-
-		db.DB.Exec(`
-			INSERT INTO invoice_line_items (invoice_id, item_name, description, quantity, rate, amount)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`, invoiceID, itemName, itemDescription, 1, amount, amount)
-
-		// Mark all sessions as invoiced
-		for _, session := range selectedGroup.Sessions {
-			newNotes := session.Notes
-			if newNotes != "" {
-				newNotes += " "
-			}
-			newNotes += fmt.Sprintf("[Invoiced: %s]", invoiceNum)
-			db.DB.Exec("UPDATE tracking_sessions SET notes = ? WHERE id = ?", newNotes, session.ID)
-		}
+		items = append(items, models.InvoiceLineItem{
+			ItemName:    fmt.Sprintf("Software services in %s", monthName),
+			Description: fmt.Sprintf("Fixed price contract work (%.2f hours tracked)", selectedGroup.TotalHours),
+			Quantity:    1,
+			Rate:        amount,
+			Amount:      amount,
+		})
 	} else {
 		// For hourly contracts, create line items for each session
 		for _, session := range selectedGroup.Sessions {
@@ -611,30 +636,54 @@ func generateInvoiceFromTime(clientName string) (int64, error) {
 				rate = *selectedGroup.HourlyRate
 			}
 
-			itemAmount := hours * rate
 			itemName := session.ProjectName
 			if itemName == "" {
 				itemName = "Development work"
 			}
-			itemName = fmt.Sprintf("%s - %s", session.StartTime.Format("Jan 2"), itemName)
+			items = append(items, models.InvoiceLineItem{
+				ItemName:    fmt.Sprintf("%s - %s", session.StartTime.Format("Jan 2"), itemName),
+				Description: session.Notes,
+				Quantity:    hours,
+				Rate:        rate,
+				Amount:      hours * rate,
+			})
+		}
+	}
 
-			db.DB.Exec(`
-				INSERT INTO invoice_line_items (invoice_id, item_name, description, quantity, rate, amount)
-				VALUES (?, ?, ?, ?, ?, ?)
-			`, invoiceID, itemName, session.Notes, hours, rate, itemAmount)
+	var contractID uint
+	if selectedGroup.ContractID != nil {
+		contractID = *selectedGroup.ContractID
+	}
+	t, err := invoiceTax("", clientID, contractID, items)
+	if err != nil {
+		return 0, err
+	}
+
+	invoiceID, err := insertInvoice(models.Invoice{
+		InvoiceNum:  invoiceNum,
+		CompanyID:   companyID,
+		Currency:    currency,
+		Description: "Time-based services",
+		IssuedDate:  issuedDate,
+		DueDate:     dueDate,
+		PONumber:    strings.TrimSpace(po),
+	}, clientID, items, t)
+	if err != nil {
+		return 0, err
+	}
 
-			// Mark as invoiced
-			newNotes := session.Notes
-			if newNotes != "" {
-				newNotes += " "
-			}
-			newNotes += fmt.Sprintf("[Invoiced: %s]", invoiceNum)
-			db.DB.Exec("UPDATE tracking_sessions SET notes = ? WHERE id = ?", newNotes, session.ID)
+	// Mark all sessions as invoiced
+	for _, session := range selectedGroup.Sessions {
+		newNotes := session.Notes
+		if newNotes != "" {
+			newNotes += " "
 		}
+		newNotes += fmt.Sprintf("[Invoiced: %s]", invoiceNum)
+		db.DB.Exec("UPDATE tracking_sessions SET notes = ? WHERE id = ?", newNotes, session.ID)
 	}
 
 	fmt.Printf("✓ Invoice created: %s\n", invoiceNum)
-	fmt.Printf("  Amount: %.2f %s\n", amount, currency)
+	printInvoiceTax(t, currency)
 	fmt.Printf("  Due: %s\n\n", dueDate.Format("2006-01-02"))
 
 	return invoiceID, nil
@@ -642,21 +691,46 @@ func generateInvoiceFromTime(clientName string) (int64, error) {
 
 // generateInvoicePDFByID generates PDF for an invoice by ID
 func generateInvoicePDFByID(invoiceID int) error {
-	// Fetch invoice details
+	inv, company, client, lineItems, err := loadInvoiceForDocument(invoiceID)
+	if err != nil {
+		return err
+	}
+
+	// Generate PDF
+	pdfPath, err := invoice.GeneratePDF(inv, company, client, lineItems)
+	if err != nil {
+		return fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	// Update invoice with PDF path
+	_, err = db.DB.Exec("UPDATE invoices SET pdf_path = ? WHERE id = ?", pdfPath, invoiceID)
+	if err != nil {
+		return fmt.Errorf("failed to update invoice: %w", err)
+	}
+
+	fmt.Printf("✓ PDF generated successfully: %s\n", pdfPath)
+	return nil
+}
+
+// loadInvoiceForDocument fetches an invoice with its company, client and
+// line items, for PDFs, e-invoices and emails
+func loadInvoiceForDocument(invoiceID int) (models.Invoice, models.Company, models.Client, []models.InvoiceLineItem, error) {
 	var inv models.Invoice
 	var company models.Company
 	var client models.Client
 
 	// Get invoice
 	err := db.DB.QueryRow(`
-		SELECT id, invoice_num, company_id, amount, currency, description, status, issued_date, due_date
+		SELECT id, invoice_num, company_id, amount, net_amount, tax_amount, tax_profile, tax_type, tax_note,
+		       currency, description, status, issued_date, due_date, COALESCE(pdf_path, ''), po_number
 		FROM invoices WHERE id = ?
 	`, invoiceID).Scan(
-		&inv.ID, &inv.InvoiceNum, &inv.CompanyID, &inv.Amount, &inv.Currency,
-		&inv.Description, &inv.Status, &inv.IssuedDate, &inv.DueDate,
+		&inv.ID, &inv.InvoiceNum, &inv.CompanyID, &inv.Amount, &inv.NetAmount, &inv.TaxAmount,
+		&inv.TaxProfile, &inv.TaxType, &inv.TaxNote, &inv.Currency,
+		&inv.Description, &inv.Status, &inv.IssuedDate, &inv.DueDate, &inv.PDFPath, &inv.PONumber,
 	)
 	if err != nil {
-		return fmt.Errorf("invoice not found: %w", err)
+		return inv, company, client, nil, fmt.Errorf("invoice not found: %w", err)
 	}
 
 	// Get company with all fields
@@ -668,38 +742,45 @@ func generateInvoicePDFByID(invoiceID int) error {
 		&company.Address, &company.RegistrationAddress, &company.TaxID,
 		&company.BankName, &company.BankAccount, &company.BankSWIFT, &company.LogoPath)
 	if err != nil {
-		return fmt.Errorf("company not found: %w", err)
+		return inv, company, client, nil, fmt.Errorf("company not found: %w", err)
 	}
 
 	// Get client
 	err = db.DB.QueryRow(`
-		SELECT c.id, c.name, c.email, c.address, c.tax_id
+		SELECT c.id, c.name, c.email, c.address, c.tax_id, c.language, c.tax_profile, c.email_template,
+		       c.email_cc, c.email_bcc, c.currency, c.payment_terms_days, c.po_required
 		FROM clients c
 		JOIN invoice_recipients ir ON c.id = ir.client_id
 		WHERE ir.invoice_id = ?
-	`, invoiceID).Scan(&client.ID, &client.Name, &client.Email, &client.Address, &client.TaxID)
+	`, invoiceID).Scan(&client.ID, &client.Name, &client.Email, &client.Address, &client.TaxID, &client.Language, &client.TaxProfile, &client.EmailTemplate,
+		&client.EmailCc, &client.EmailBcc, &client.Currency, &client.PaymentTerms, &client.PORequired)
 	if err != nil {
-		return fmt.Errorf("client not found: %w", err)
+		return inv, company, client, nil, fmt.Errorf("client not found: %w", err)
+	}
+	if err := loadClientProfile(&client); err != nil {
+		return inv, company, client, nil, err
 	}
 
 	// Get line items
 	var lineItems []models.InvoiceLineItem
 	rows, err := db.DB.Query(`
-		SELECT id, invoice_id, item_name, description, quantity, rate, amount, created_at
+		SELECT id, invoice_id, item_name, description, quantity, rate, amount,
+		       COALESCE(discount, 0), COALESCE(discount_pct, 0), COALESCE(tax_rate, 0), COALESCE(tax_amount, 0), created_at
 		FROM invoice_line_items
 		WHERE invoice_id = ?
 		ORDER BY id
 	`, invoiceID)
 	if err != nil {
-		return fmt.Errorf("failed to fetch line items: %w", err)
+		return inv, company, client, nil, fmt.Errorf("failed to fetch line items: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var item models.InvoiceLineItem
 		if err := rows.Scan(&item.ID, &item.InvoiceID, &item.ItemName, &item.Description,
-			&item.Quantity, &item.Rate, &item.Amount, &item.CreatedAt); err != nil {
-			return fmt.Errorf("failed to scan line item: %w", err)
+			&item.Quantity, &item.Rate, &item.Amount, &item.Discount, &item.DiscountPct,
+			&item.TaxRate, &item.TaxAmount, &item.CreatedAt); err != nil {
+			return inv, company, client, nil, fmt.Errorf("failed to scan line item: %w", err)
 		}
 		lineItems = append(lineItems, item)
 	}
@@ -711,108 +792,93 @@ func generateInvoicePDFByID(invoiceID int) error {
 				ItemName:    inv.Description,
 				Description: "",
 				Quantity:    1.0,
-				Rate:        inv.Amount,
-				Amount:      inv.Amount,
+				Rate:        inv.Net(),
+				Amount:      inv.Net(),
 			},
 		}
 	}
 
-	// Generate PDF
-	pdfPath, err := invoice.GeneratePDF(inv, company, client, lineItems)
-	if err != nil {
-		return fmt.Errorf("failed to generate PDF: %w", err)
-	}
-
-	// Update invoice with PDF path
-	_, err = db.DB.Exec("UPDATE invoices SET pdf_path = ? WHERE id = ?", pdfPath, invoiceID)
-	if err != nil {
-		return fmt.Errorf("failed to update invoice: %w", err)
-	}
-
-	fmt.Printf("✓ PDF generated successfully: %s\n", pdfPath)
-	return nil
+	return inv, company, client, lineItems, nil
 }
 
 // emailInvoiceByID sends an invoice email by ID
 func emailInvoiceByID(invoiceID int, emailApp string) error {
-	// Fetch invoice details
-	var inv models.Invoice
-	var company models.Company
-
-	// Get invoice
-	err := db.DB.QueryRow(`
-		SELECT id, invoice_num, company_id, amount, currency, description, status, issued_date, due_date, pdf_path
-		FROM invoices WHERE id = ?
-	`, invoiceID).Scan(
-		&inv.ID, &inv.InvoiceNum, &inv.CompanyID, &inv.Amount, &inv.Currency,
-		&inv.Description, &inv.Status, &inv.IssuedDate, &inv.DueDate, &inv.PDFPath,
-	)
-	if err != nil {
-		return fmt.Errorf("invoice not found: %w", err)
-	}
+	return sendInvoiceEmail(invoiceID, email.KindInvoice, emailApp)
+}
 
-	// Get company info
-	err = db.DB.QueryRow(`
-		SELECT id, name FROM companies WHERE id = ?
-	`, inv.CompanyID).Scan(&company.ID, &company.Name)
+// sendInvoiceEmail sends an invoice or reminder email, rendered from the
+// client's email template, over SMTP or opens it in the email client, with
+// the PDF attached
+func sendInvoiceEmail(invoiceID int, kind, emailApp string) error {
+	msg, err := invoiceEmail(invoiceID, kind)
 	if err != nil {
-		return fmt.Errorf("company not found: %w", err)
+		return err
 	}
 
 	// Ensure PDF exists
-	pdfPath := inv.PDFPath
-	if pdfPath == "" {
+	if len(msg.Attachments) == 0 {
 		return fmt.Errorf("PDF not generated. Run with --pdf flag first")
 	}
 
-	// Extract month and year from issued date
-	month := inv.IssuedDate.Format("01")
-	year := inv.IssuedDate.Format("2006")
+	emailClient, err := selectEmailClient(emailApp)
+	if err != nil {
+		return err
+	}
 
-	// Prepare email details
-	subject := fmt.Sprintf("%s.%s %s", month, year, company.Name)
-	body := fmt.Sprintf("Hi,\n\nHere is the invoice for %s %s.\n\nBest regards,\n%s",
-		inv.IssuedDate.Format("January"), year, company.Name)
+	// Send, or export to selected email client
+	switch emailClient {
+	case "smtp":
+		return sendViaSMTP(msg, kind, uint(invoiceID), 0)
+	case "apple":
+		return exportToAppleMail(msg.Subject, msg.Body, msg.Attachments[0])
+	case "outlook":
+		return exportToOutlook(msg.Subject, msg.Body, msg.Attachments[0])
+	case "gmail":
+		return exportToGmail(msg.Subject, msg.Body, msg.Attachments[0])
+	default:
+		return fmt.Errorf("unknown email client: %s", emailClient)
+	}
+}
 
-	// Determine email client
+// selectEmailClient validates the --email-app flag, or uses the default
+// email client of the config, or prompts for one when neither is set
+func selectEmailClient(emailApp string) (string, error) {
 	emailClient := emailApp
+	cfg, _ := config.Load()
+	if emailClient == "" {
+		emailClient = cfg.Email.App
+	}
 
 	if emailClient == "" {
 		// Interactive mode - prompt for selection
+		var options []huh.Option[string]
+		if cfg.Email.SMTPHost != "" {
+			options = append(options, huh.NewOption("SMTP (send now)", "smtp"))
+		}
+		options = append(options,
+			huh.NewOption("Apple Mail", "apple"),
+			huh.NewOption("Outlook", "outlook"),
+			huh.NewOption("Gmail (Browser)", "gmail"),
+		)
 		form := huh.NewForm(
 			huh.NewGroup(
 				huh.NewSelect[string]().
 					Title("Select email client").
-					Options(
-						huh.NewOption("Apple Mail", "apple"),
-						huh.NewOption("Outlook", "outlook"),
-						huh.NewOption("Gmail (Browser)", "gmail"),
-					).
+					Options(options...).
 					Value(&emailClient),
 			),
 		)
 
 		if err := form.Run(); err != nil {
-			return fmt.Errorf("email export cancelled: %w", err)
+			return "", fmt.Errorf("email export cancelled: %w", err)
 		}
 	} else {
 		// Validate provided email client
-		if emailClient != "apple" && emailClient != "outlook" && emailClient != "gmail" {
-			return fmt.Errorf("invalid email client: %s (valid: apple, outlook, gmail)", emailClient)
+		if emailClient != "smtp" && emailClient != "apple" && emailClient != "outlook" && emailClient != "gmail" {
+			return "", fmt.Errorf("invalid email client: %s (valid: smtp, apple, outlook, gmail)", emailClient)
 		}
 	}
-
-	// Export to selected email client
-	switch emailClient {
-	case "apple":
-		return exportToAppleMail(subject, body, pdfPath)
-	case "outlook":
-		return exportToOutlook(subject, body, pdfPath)
-	case "gmail":
-		return exportToGmail(subject, body, pdfPath)
-	default:
-		return fmt.Errorf("unknown email client: %s", emailClient)
-	}
+	return emailClient, nil
 }
 
 // runBatchInvoice handles batch invoice operations
@@ -1052,7 +1118,7 @@ func runInvoiceGenerateAll(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\n[%d/%d] Generating invoice for %s...\n", i+1, len(groups), group.ClientName)
 
 		// Use the existing generateInvoiceFromTime function
-		invoiceID, err := generateInvoiceFromTime(group.ClientName)
+		invoiceID, err := generateInvoiceFromTime(group.ClientName, "")
 		if err != nil {
 			fmt.Printf("  ❌ Failed: %v\n", err)
 			continue
@@ -1190,6 +1256,75 @@ func runInvoiceSendAll(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runInvoiceRemind sends reminders for an invoice or all overdue invoices
+func runInvoiceRemind(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		invoiceID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid invoice ID: %s", args[0])
+		}
+		if err := generateInvoicePDFByID(invoiceID); err != nil {
+			return err
+		}
+		return sendInvoiceEmail(invoiceID, email.KindReminder, invoiceFlagEmailApp)
+	}
+
+	var invoices []models.Invoice
+	err := db.GormDB.Where("status = ? OR (status IN ? AND due_date < ?)",
+		models.StatusOverdue,
+		[]models.InvoiceStatus{models.StatusPending, models.StatusSent},
+		time.Now()).Order("due_date").Find(&invoices).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch invoices: %w", err)
+	}
+
+	if len(invoices) == 0 {
+		fmt.Println("No overdue invoices found.")
+		return nil
+	}
+
+	fmt.Printf("📧 Found %d overdue invoice(s):\n\n", len(invoices))
+	for i, inv := range invoices {
+		fmt.Printf("  %d. %s - %.2f %s - due %s\n", i+1, inv.InvoiceNum, inv.Amount, inv.Currency, inv.DueDate.Format("2006-01-02"))
+	}
+	fmt.Println()
+
+	// Confirm
+	var shouldProceed bool
+	confirmForm := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Send reminders for all overdue invoices?").
+				Value(&shouldProceed),
+		),
+	)
+
+	if err := confirmForm.Run(); err != nil || !shouldProceed {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	successCount := 0
+	for i, inv := range invoices {
+		fmt.Printf("\n[%d/%d] Processing %s...\n", i+1, len(invoices), inv.InvoiceNum)
+
+		if err := generateInvoicePDFByID(int(inv.ID)); err != nil {
+			fmt.Printf("  ❌ Failed to generate PDF: %v\n", err)
+			continue
+		}
+
+		if err := sendInvoiceEmail(int(inv.ID), email.KindReminder, invoiceFlagEmailApp); err != nil {
+			fmt.Printf("  ❌ Failed to email: %v\n", err)
+			continue
+		}
+		fmt.Printf("  ✓ Reminder prepared\n")
+		successCount++
+	}
+
+	fmt.Printf("\n✓ Sent %d/%d reminder(s)!\n", successCount, len(invoices))
+	return nil
+}
+
 // runInvoiceMark updates the status of an invoice
 func runInvoiceMark(cmd *cobra.Command, args []string) error {
 	// Parse invoice ID from args
@@ -1220,7 +1355,11 @@ func runInvoiceMark(cmd *cobra.Command, args []string) error {
 	}
 
 	// Update status
-	_, err = db.DB.Exec("UPDATE invoices SET status = ? WHERE id = ?", newStatus, invoiceID)
+	if newStatus == models.StatusPaid {
+		_, err = db.DB.Exec("UPDATE invoices SET status = ?, paid_date = ? WHERE id = ?", newStatus, time.Now(), invoiceID)
+	} else {
+		_, err = db.DB.Exec("UPDATE invoices SET status = ?, paid_date = NULL WHERE id = ?", newStatus, invoiceID)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update invoice: %w", err)
 	}
@@ -1239,9 +1378,9 @@ func runInvoiceEdit(cmd *cobra.Command, args []string) error {
 	// Get current invoice details
 	var inv models.Invoice
 	err = db.DB.QueryRow(`
-		SELECT id, invoice_num, amount, currency, description, due_date
+		SELECT id, invoice_num, amount, net_amount, tax_amount, currency, description, due_date, po_number
 		FROM invoices WHERE id = ?
-	`, invoiceID).Scan(&inv.ID, &inv.InvoiceNum, &inv.Amount, &inv.Currency, &inv.Description, &inv.DueDate)
+	`, invoiceID).Scan(&inv.ID, &inv.InvoiceNum, &inv.Amount, &inv.NetAmount, &inv.TaxAmount, &inv.Currency, &inv.Description, &inv.DueDate, &inv.PONumber)
 	if err != nil {
 		return fmt.Errorf("invoice not found: %w", err)
 	}
@@ -1250,19 +1389,32 @@ func runInvoiceEdit(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Current values:\n")
 	fmt.Printf("  Amount:      %.2f %s\n", inv.Amount, inv.Currency)
 	fmt.Printf("  Description: %s\n", inv.Description)
-	fmt.Printf("  Due Date:    %s\n\n", inv.DueDate.Format("2006-01-02"))
+	fmt.Printf("  Due Date:    %s\n", inv.DueDate.Format("2006-01-02"))
+	if inv.PONumber != "" {
+		fmt.Printf("  PO Number:   %s\n", inv.PONumber)
+	}
+	fmt.Println()
 
 	// Check if any flags were provided
-	hasFlags := invoiceEditAmount > 0 || invoiceEditDueDate != "" || invoiceEditDescription != ""
+	hasFlags := invoiceEditAmount > 0 || invoiceEditDueDate != "" || invoiceEditDescription != "" || invoiceEditTax != "" || cmd.Flags().Changed("po")
 
 	if hasFlags {
 		// Non-interactive mode - use provided flags
 		if invoiceEditAmount > 0 {
-			_, err = db.DB.Exec("UPDATE invoices SET amount = ? WHERE id = ?", invoiceEditAmount, invoiceID)
+			updated, err := setInvoiceAmount(invoiceID, invoiceEditAmount)
 			if err != nil {
-				return fmt.Errorf("failed to update amount: %w", err)
+				return err
 			}
-			fmt.Printf("✓ Amount updated: %.2f → %.2f\n", inv.Amount, invoiceEditAmount)
+			fmt.Printf("✓ Amount updated: %.2f → %.2f\n", inv.Amount, updated.Amount)
+		}
+
+		if invoiceEditTax != "" {
+			t, err := recalculateInvoiceTax(invoiceID, invoiceEditTax)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Tax recalculated\n")
+			printInvoiceTax(t, inv.Currency)
 		}
 
 		if invoiceEditDueDate != "" {
@@ -1284,18 +1436,27 @@ func runInvoiceEdit(cmd *cobra.Command, args []string) error {
 			}
 			fmt.Printf("✓ Description updated\n")
 		}
+
+		if cmd.Flags().Changed("po") {
+			_, err = db.DB.Exec("UPDATE invoices SET po_number = ? WHERE id = ?", strings.TrimSpace(invoiceEditPO), invoiceID)
+			if err != nil {
+				return fmt.Errorf("failed to update PO number: %w", err)
+			}
+			fmt.Printf("✓ PO number updated\n")
+		}
 	} else {
 		// Interactive mode
 		var newAmountStr string
 		var newDueDateStr string
 		var newDescription string
+		var newPO string
 
 		form := huh.NewForm(
 			huh.NewGroup(
 				huh.NewInput().
-					Title("Amount").
-					Description(fmt.Sprintf("Current: %.2f %s", inv.Amount, inv.Currency)).
-					Placeholder(fmt.Sprintf("%.2f", inv.Amount)).
+					Title("Amount (before tax)").
+					Description(fmt.Sprintf("Current: %.2f %s", inv.Net(), inv.Currency)).
+					Placeholder(fmt.Sprintf("%.2f", inv.Net())).
 					Value(&newAmountStr),
 				huh.NewInput().
 					Title("Due Date (YYYY-MM-DD)").
@@ -1307,6 +1468,11 @@ func runInvoiceEdit(cmd *cobra.Command, args []string) error {
 					Description(fmt.Sprintf("Current: %s", inv.Description)).
 					Placeholder(inv.Description).
 					Value(&newDescription),
+				huh.NewInput().
+					Title("PO Number").
+					Description(fmt.Sprintf("Current: %s", inv.PONumber)).
+					Placeholder(inv.PONumber).
+					Value(&newPO),
 			),
 		)
 
@@ -1320,11 +1486,11 @@ func runInvoiceEdit(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return fmt.Errorf("invalid amount: %w", err)
 			}
-			_, err = db.DB.Exec("UPDATE invoices SET amount = ? WHERE id = ?", newAmount, invoiceID)
+			updated, err := setInvoiceAmount(invoiceID, newAmount)
 			if err != nil {
-				return fmt.Errorf("failed to update amount: %w", err)
+				return err
 			}
-			fmt.Printf("✓ Amount updated: %.2f → %.2f\n", inv.Amount, newAmount)
+			fmt.Printf("✓ Amount updated: %.2f → %.2f\n", inv.Amount, updated.Amount)
 		}
 
 		if newDueDateStr != "" {
@@ -1346,6 +1512,14 @@ func runInvoiceEdit(cmd *cobra.Command, args []string) error {
 			}
 			fmt.Printf("✓ Description updated\n")
 		}
+
+		if newPO = strings.TrimSpace(newPO); newPO != "" {
+			_, err = db.DB.Exec("UPDATE invoices SET po_number = ? WHERE id = ?", newPO, invoiceID)
+			if err != nil {
+				return fmt.Errorf("failed to update PO number: %w", err)
+			}
+			fmt.Printf("✓ PO number updated\n")
+		}
 	}
 
 	return nil
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/pkg/einvoice"
+	"github.com/Andriiklymiuk/ung/pkg/invoice"
+	"github.com/spf13/cobra"
+)
+
+var (
+	invoiceExportFormat string
+	invoiceExportOutput string
+)
+
+var invoiceExportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "Export an invoice as a structured e-invoice",
+	Long: `Export an invoice as a machine-readable e-invoice (EN 16931).
+
+Formats:
+  ubl       UBL 2.1 XML following Peppol BIS Billing 3.0
+  cii       UN/CEFACT Cross Industry Invoice XML (EN 16931 profile)
+  facturx   Factur-X / ZUGFeRD: the invoice PDF as PDF/A-3 with the CII XML inside
+
+The invoice is checked against the EN 16931 business rules (and the Peppol
+rules for UBL) before it's written; broken rules are listed with their IDs.
+The checks are built into ung and need no network access, but cover only the
+rules an invoice from ung can break. To check the written file against the
+official XSD and Schematron files, or a Factur-X PDF for PDF/A-3, set a
+validator command in invoice.einvoice_validator (or UNG_EINVOICE_VALIDATOR).
+It's given the file as its last argument, and a file it rejects is removed:
+
+  einvoice_validator: java -jar ~/kosit/validationtool.jar -s ~/kosit/scenarios.xml
+  einvoice_validator: verapdf --flavour 3b
+
+Countries come from the last line of the company and client addresses
+("..., 10115 Berlin, Germany") or from the prefix of their VAT IDs.
+Line items without a tax rate are reverse charge between VAT registered
+parties in different countries, exempt for a VAT registered company, and
+not subject to VAT otherwise.
+
+Examples:
+  ung invoice export 12                         UBL in the invoices directory
+  ung invoice export 12 --format facturx        Factur-X PDF
+  ung invoice export 12 --format cii -o out.xml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInvoiceExport,
+}
+
+func init() {
+	invoiceCmd.AddCommand(invoiceExportCmd)
+	invoiceExportCmd.Flags().StringVarP(&invoiceExportFormat, "format", "f", einvoice.FormatUBL, "Format: "+strings.Join(einvoice.Formats, ", "))
+	invoiceExportCmd.Flags().StringVarP(&invoiceExportOutput, "output", "o", "", "Output file (default: invoices directory)")
+}
+
+func runInvoiceExport(cmd *cobra.Command, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid invoice ID: %s", args[0])
+	}
+
+	path, err := exportEInvoice(id, invoiceExportFormat, invoiceExportOutput)
+	if verr, ok := err.(einvoice.ValidationError); ok {
+		for _, v := range verr {
+			fmt.Printf("❌ %s\n", v)
+		}
+		fmt.Println("💡 Fix the company or client with 'ung company edit' and 'ung client edit', then export again")
+	}
+	var xerr *einvoice.ExternalError
+	if errors.As(err, &xerr) && xerr.Output != "" {
+		fmt.Println(xerr.Output)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ %s e-invoice exported: %s\n", strings.ToUpper(invoiceExportFormat), path)
+	return nil
+}
+
+// exportEInvoice writes an invoice in format and returns the file's path.
+// A document that breaks a rule is not written and einvoice.ValidationError
+// is returned.
+func exportEInvoice(invoiceID int, format, output string) (string, error) {
+	format = strings.ToLower(format)
+	var ext string
+	switch format {
+	case einvoice.FormatUBL:
+		ext = ".ubl.xml"
+	case einvoice.FormatCII:
+		ext = ".cii.xml"
+	case einvoice.FormatFacturX:
+		ext = ".facturx.pdf"
+	default:
+		return "", fmt.Errorf("unknown format %q (valid: %s)", format, strings.Join(einvoice.Formats, ", "))
+	}
+
+	inv, company, client, lineItems, err := loadInvoiceForDocument(invoiceID)
+	if err != nil {
+		return "", err
+	}
+	doc := einvoice.New(inv, company, client, lineItems)
+	if err := einvoice.Validate(doc, format); err != nil {
+		return "", err
+	}
+
+	var data []byte
+	switch format {
+	case einvoice.FormatUBL:
+		data, err = einvoice.UBL(doc)
+	case einvoice.FormatCII:
+		data, err = einvoice.CII(doc)
+	case einvoice.FormatFacturX:
+		data, err = einvoice.CII(doc)
+		if err != nil {
+			break
+		}
+		var pdf bytes.Buffer
+		if err = invoice.WriteEmbeddedPDF(&pdf, inv, company, client, lineItems); err != nil {
+			return "", fmt.Errorf("failed to generate PDF: %w", err)
+		}
+		data, err = einvoice.FacturX(pdf.Bytes(), data, einvoice.PDFInfo{
+			Title:   "Invoice " + inv.InvoiceNum,
+			Author:  company.Name,
+			Created: inv.IssuedDate,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if output == "" {
+		dir := config.GetInvoicesDir()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create invoices directory: %w", err)
+		}
+		output = filepath.Join(dir, inv.InvoiceNum+ext)
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	if validator := eInvoiceValidator(); validator != "" {
+		if err := einvoice.ValidateWith(validator, output); err != nil {
+			os.Remove(output)
+			return "", err
+		}
+	}
+	return output, nil
+}
+
+// eInvoiceValidator returns the external validator command, if one is configured
+func eInvoiceValidator() string {
+	if command := os.Getenv("UNG_EINVOICE_VALIDATOR"); command != "" {
+		return command
+	}
+	if cfg, _ := config.Load(); cfg != nil {
+		return cfg.Invoice.EInvoiceValidator
+	}
+	return ""
+}
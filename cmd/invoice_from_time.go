@@ -5,7 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 // timeSessionGroup represents a group of time sessions for invoicing
@@ -5,7 +5,7 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 func TestTimeSessionGroup_HourlyContract(t *testing.T) {
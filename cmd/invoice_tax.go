@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+)
+
+// invoiceTax calculates the tax of a new invoice's line items with the tax
+// profile given on the command line, otherwise the one of the contract (0
+// for none), otherwise the client's, otherwise tax.default
+func invoiceTax(override string, clientID, contractID uint, items []models.InvoiceLineItem) (tax.Result, error) {
+	var client models.Client
+	if err := db.GormDB.Preload("Addresses").First(&client, clientID).Error; err != nil {
+		return tax.Result{}, fmt.Errorf("client not found: %w", err)
+	}
+	var contractProfile string
+	if contractID > 0 {
+		db.DB.QueryRow("SELECT tax_profile FROM contracts WHERE id = ?", contractID).Scan(&contractProfile)
+	}
+
+	cfg, _ := config.Load()
+	profile, err := tax.Resolve(cfg, override, contractProfile, client.TaxProfile)
+	if err != nil {
+		return tax.Result{}, err
+	}
+	loc, _ := locale.For(cfg, client.Language)
+	return profile.Apply(client, items, loc)
+}
+
+// insertInvoice stores a new invoice for a client with its line items and
+// the tax calculated for them, and returns its ID. The invoice amount is the
+// gross total.
+func insertInvoice(inv models.Invoice, clientID uint, items []models.InvoiceLineItem, t tax.Result) (int64, error) {
+	result, err := db.DB.Exec(`
+		INSERT INTO invoices (invoice_num, company_id, amount, net_amount, tax_amount, tax_profile, tax_type, tax_note,
+		                      currency, description, status, issued_date, due_date, po_number)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, inv.InvoiceNum, inv.CompanyID, t.Gross, t.Net, t.Tax, t.Profile, t.Type, t.Note,
+		inv.Currency, inv.Description, models.StatusPending, inv.IssuedDate, inv.DueDate, inv.PONumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create invoice: %w", err)
+	}
+	invoiceID, _ := result.LastInsertId()
+
+	if _, err := db.DB.Exec("INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (?, ?)", invoiceID, clientID); err != nil {
+		return invoiceID, fmt.Errorf("failed to link invoice to client: %w", err)
+	}
+
+	for _, item := range items {
+		if _, err := db.DB.Exec(`
+			INSERT INTO invoice_line_items (invoice_id, item_name, description, quantity, rate, amount, tax_rate, tax_amount)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, invoiceID, item.ItemName, item.Description, item.Quantity, item.Rate, item.Amount, item.TaxRate, item.TaxAmount); err != nil {
+			return invoiceID, fmt.Errorf("failed to add line item: %w", err)
+		}
+	}
+	return invoiceID, nil
+}
+
+// printInvoiceTax prints the amount of a new or edited invoice, with the net
+// and tax when it has any
+func printInvoiceTax(t tax.Result, currency string) {
+	if t.Tax == 0 && t.Note == "" {
+		fmt.Printf("  Amount: %.2f %s\n", t.Gross, currency)
+		return
+	}
+	fmt.Printf("  Net: %.2f %s\n", t.Net, currency)
+	fmt.Printf("  Tax: %.2f %s (%s)\n", t.Tax, currency, t.Profile)
+	fmt.Printf("  Total: %.2f %s\n", t.Gross, currency)
+}
+
+// saveInvoiceTax stores recalculated line items and totals of an invoice.
+// A line without an ID, the stand-in for an invoice without line items, is
+// added so the invoice keeps its tax.
+func saveInvoiceTax(invoiceID int, items []models.InvoiceLineItem, t tax.Result) error {
+	for _, item := range items {
+		var err error
+		if item.ID == 0 {
+			_, err = db.DB.Exec(`
+				INSERT INTO invoice_line_items (invoice_id, item_name, description, quantity, rate, amount, tax_rate, tax_amount)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			`, invoiceID, item.ItemName, item.Description, item.Quantity, item.Rate, item.Amount, item.TaxRate, item.TaxAmount)
+		} else {
+			_, err = db.DB.Exec(`
+				UPDATE invoice_line_items SET quantity = ?, rate = ?, amount = ?, discount = ?, discount_pct = ?, tax_rate = ?, tax_amount = ?
+				WHERE id = ?
+			`, item.Quantity, item.Rate, item.Amount, item.Discount, item.DiscountPct, item.TaxRate, item.TaxAmount, item.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update line item: %w", err)
+		}
+	}
+
+	_, err := db.DB.Exec(`
+		UPDATE invoices SET amount = ?, net_amount = ?, tax_amount = ?, tax_profile = ?, tax_type = ?, tax_note = ?
+		WHERE id = ?
+	`, t.Gross, t.Net, t.Tax, t.Profile, t.Type, t.Note, invoiceID)
+	if err != nil {
+		return fmt.Errorf("failed to update invoice: %w", err)
+	}
+	return nil
+}
+
+// recalculateInvoiceTax calculates the tax of an existing invoice with a
+// tax profile, or with the client's when profile is empty. The rates of its
+// line items are replaced by the profile's.
+func recalculateInvoiceTax(invoiceID int, profile string) (tax.Result, error) {
+	_, _, client, items, err := loadInvoiceForDocument(invoiceID)
+	if err != nil {
+		return tax.Result{}, err
+	}
+	for i := range items {
+		items[i].TaxRate = 0
+	}
+	t, err := invoiceTax(profile, client.ID, 0, items)
+	if err != nil {
+		return t, err
+	}
+	return t, saveInvoiceTax(invoiceID, items, t)
+}
+
+// setInvoiceAmount changes the amount of an invoice. The line item of an
+// invoice with a single one is repriced to amount before tax, keeping its
+// quantity, and the tax is recalculated at its rate; otherwise amount is the
+// new total and the stored tax is kept.
+func setInvoiceAmount(invoiceID int, amount float64) (models.Invoice, error) {
+	inv, _, _, items, err := loadInvoiceForDocument(invoiceID)
+	if err != nil {
+		return inv, err
+	}
+
+	if len(items) != 1 || items[0].ID == 0 {
+		inv.Amount = amount
+		inv.NetAmount = tax.Round(amount - inv.TaxAmount)
+		_, err := db.DB.Exec("UPDATE invoices SET amount = ?, net_amount = ? WHERE id = ?", inv.Amount, inv.NetAmount, invoiceID)
+		if err != nil {
+			return inv, fmt.Errorf("failed to update amount: %w", err)
+		}
+		return inv, nil
+	}
+
+	item := &items[0]
+	if item.Quantity <= 0 {
+		item.Quantity = 1
+	}
+	item.Rate, item.Amount, item.Discount, item.DiscountPct = amount/item.Quantity, amount, 0, 0
+	item.TaxAmount = tax.Round(amount * item.TaxRate)
+	t := tax.Result{Totals: tax.Calculate(items), Profile: inv.TaxProfile, Type: inv.TaxType, Note: inv.TaxNote}
+	if err := saveInvoiceTax(invoiceID, items, t); err != nil {
+		return inv, err
+	}
+	inv.Amount, inv.NetAmount, inv.TaxAmount = t.Gross, t.Net, t.Tax
+	return inv, nil
+}
@@ -5,8 +5,8 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/internal/repository"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
@@ -160,7 +160,7 @@ func runNext(cmd *cobra.Command, args []string) error {
 	}
 
 	// Try to get goal from database using GORM directly
-	var goal IncomeGoal
+	var goal models.IncomeGoal
 	year := time.Now().Year()
 	month := int(time.Now().Month())
 
@@ -30,13 +30,13 @@ Examples:
 }
 
 var (
-	pomodoroWorkMinutes    int
-	pomodoroBreakMinutes   int
-	pomodoroLongBreak      int
-	pomodoroSessions       int
-	pomodoroClient         string
-	pomodoroProject        string
-	pomodoroAutoTrack      bool
+	pomodoroWorkMinutes  int
+	pomodoroBreakMinutes int
+	pomodoroLongBreak    int
+	pomodoroSessions     int
+	pomodoroClient       string
+	pomodoroProject      string
+	pomodoroAutoTrack    bool
 )
 
 func init() {
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Switch between databases with their own configuration",
+	Long: `Keep separate books, e.g. for personal freelance work and an agency, each
+with its own database, invoices, templates, email account and keychain entry.
+
+Profiles live in ~/.ung/profiles/<name>/. 'ung profile use' makes one the
+default for later runs; --profile or UNG_PROFILE picks one for a single
+command. A local .ung/ workspace still wins over the active profile, but not
+over --profile. The "default" profile is the plain ~/.ung/ configuration.
+
+Examples:
+  ung profile create agency
+  ung profile use agency
+  ung --profile personal invoice list
+  ung profile use default`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List profiles",
+	RunE:    runProfileList,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the default for later commands",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileUse,
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile with its own database and documents",
+	Long: `Create ~/.ung/profiles/<name>/ with a config.yaml, invoices/, contracts/
+and templates/. The database is created on first use.
+
+With --copy-settings the language, invoice labels and PDF styling are copied
+from the current configuration. Email and security settings are never copied:
+set them up inside the profile with 'ung email config' and 'ung security'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileCreate,
+}
+
+var (
+	profileCreateCopy bool
+	profileCreateUse  bool
+)
+
+func init() {
+	profileCreateCmd.Flags().BoolVar(&profileCreateCopy, "copy-settings", false, "Copy labels and PDF styling from the current configuration")
+	profileCreateCmd.Flags().BoolVar(&profileCreateUse, "use", false, "Make the new profile active")
+
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	names, err := config.ListProfiles()
+	if err != nil {
+		return err
+	}
+
+	active := config.GetActiveProfile()
+	current := config.DefaultProfile
+	if config.CheckProfile() == nil {
+		if name := config.GetCurrentProfile(); name != "" {
+			current = name
+		}
+	}
+
+	for _, name := range append([]string{config.DefaultProfile}, names...) {
+		marker := "  "
+		if name == active {
+			marker = "* "
+		}
+		path := filepath.Join(config.GetProfileDir(name), "config.yaml")
+		if name == config.DefaultProfile {
+			path = filepath.Join(config.GetGlobalUngDir(), "config.yaml")
+		}
+		note := ""
+		if name == current && name != active {
+			note = " (this command)"
+		}
+		fmt.Printf("%s%-16s %s%s\n", marker, name, path, note)
+	}
+	if active != config.DefaultProfile && !config.ProfileExists(active) {
+		fmt.Printf("\n⚠️  The active profile %q no longer exists, run 'ung profile use default'\n", active)
+	}
+	if len(names) == 0 {
+		fmt.Println("\n💡 Create one with 'ung profile create <name>'")
+	}
+	return nil
+}
+
+func runProfileUse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := config.UseProfile(name); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Using profile %s\n", name)
+	if _, err := os.Stat(config.LocalUngDir); err == nil {
+		fmt.Println("⚠️  The local .ung/ workspace in this directory still takes precedence here")
+	}
+	return nil
+}
+
+func runProfileCreate(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	var base *config.Config
+	if profileCreateCopy {
+		var err error
+		if base, err = config.Load(); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.CreateProfile(name, base)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Created profile %s\n", name)
+	fmt.Printf("  Database: %s\n", cfg.DatabasePath)
+	fmt.Printf("  Invoices: %s\n", cfg.InvoicesDir)
+
+	if profileCreateUse {
+		if err := config.UseProfile(name); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Using profile %s\n", name)
+	} else {
+		fmt.Printf("💡 Switch with 'ung profile use %s', or try it with 'ung --profile %s company add'\n", name, name)
+	}
+	return nil
+}
@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
@@ -60,14 +60,14 @@ var (
 )
 
 type profitModel struct {
-	data         *dashboardData
-	selectedTab  int
-	selectedRow  int
-	tabs         []string
-	width        int
-	height       int
-	loading      bool
-	err          error
+	data        *dashboardData
+	selectedTab int
+	selectedRow int
+	tabs        []string
+	width       int
+	height      int
+	loading     bool
+	err         error
 }
 
 type dashboardData struct {
@@ -92,8 +92,8 @@ type dashboardData struct {
 	monthlyTrend   []monthData
 
 	// Goals
-	monthlyGoal    float64
-	goalProgress   float64
+	monthlyGoal  float64
+	goalProgress float64
 }
 
 type clientRevenue struct {
@@ -249,7 +249,7 @@ func loadDashboardData() tea.Msg {
 	}
 
 	// Get monthly goal if set
-	var goal IncomeGoal
+	var goal models.IncomeGoal
 	if err := db.GormDB.Where("period = ? AND year = ? AND month = ?",
 		"monthly", now.Year(), int(now.Month())).First(&goal).Error; err == nil {
 		data.monthlyGoal = goal.Amount
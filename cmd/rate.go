@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/spf13/cobra"
 )
 
@@ -41,12 +41,12 @@ var rateCompareCmd = &cobra.Command{
 }
 
 var (
-	rateAnnual      float64
-	rateMonthly     float64
-	rateHoursWeek   float64
-	rateWeeksYear   int
-	rateExpenses    float64
-	rateTaxPercent  float64
+	rateAnnual       float64
+	rateMonthly      float64
+	rateHoursWeek    float64
+	rateWeeksYear    int
+	rateExpenses     float64
+	rateTaxPercent   float64
 	rateProfitMargin float64
 )
 
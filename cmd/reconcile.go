@@ -0,0 +1,492 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/bankstmt"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	reconcileFormat        string
+	reconcileCurrency      string
+	reconcileYes           bool
+	reconcileDryRun        bool
+	reconcileExpenses      bool
+	reconcileMinConfidence int
+)
+
+// reconcileCandidateThreshold is the lowest score shown as a possible match
+const reconcileCandidateThreshold = 30
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile <statement>",
+	Short: "Match bank statement payments to open invoices",
+	Long: `Import a bank statement and mark invoices paid from the payments it contains.
+
+Supported formats:
+  - CSV exports (comma or semicolon separated, signed amount or credit/debit columns)
+  - OFX and QFX files
+  - CAMT.053 XML statements (ISO 20022)
+
+Each incoming credit is scored against unpaid invoices by amount, currency,
+invoice number in the payment reference, and client name. Confirmed matches
+mark the invoice paid with the date the payment was booked.
+
+With --expenses, outgoing debits can be saved as expenses. Debits are only
+imported once, so the same statement can be reconciled again safely.
+
+Examples:
+  ung reconcile statement.csv                   Review matches interactively
+  ung reconcile statement.ofx --dry-run         Show matches without saving
+  ung reconcile camt053.xml --yes               Accept matches scoring 80% or more
+  ung reconcile statement.csv --expenses        Also record outgoing payments as expenses`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReconcile,
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+
+	reconcileCmd.Flags().StringVar(&reconcileFormat, "format", "", "Statement format: csv, ofx or camt (default: detected)")
+	reconcileCmd.Flags().StringVar(&reconcileCurrency, "currency", "", "Currency for statements that don't include one")
+	reconcileCmd.Flags().BoolVarP(&reconcileYes, "yes", "y", false, "Accept confident matches without prompting")
+	reconcileCmd.Flags().BoolVar(&reconcileDryRun, "dry-run", false, "Show matches without saving")
+	reconcileCmd.Flags().BoolVar(&reconcileExpenses, "expenses", false, "Record outgoing debits as expenses")
+	reconcileCmd.Flags().IntVar(&reconcileMinConfidence, "min-confidence", 80, "Minimum confidence (0-100) accepted with --yes")
+}
+
+// openInvoice is an unpaid invoice considered for matching
+type openInvoice struct {
+	ID         uint
+	Num        string
+	Amount     float64
+	Currency   string
+	ClientName string
+	IssuedDate time.Time
+}
+
+// invoiceCandidate is an invoice scored against a transaction
+type invoiceCandidate struct {
+	invoice openInvoice
+	score   int
+	reasons []string
+}
+
+// reconcileMatch holds the candidate invoices for an incoming payment
+type reconcileMatch struct {
+	tx         bankstmt.Transaction
+	candidates []invoiceCandidate // best first
+}
+
+// loadOpenInvoices loads all invoices that are not paid yet
+func loadOpenInvoices() ([]openInvoice, error) {
+	rows, err := db.DB.Query(`
+		SELECT i.id, i.invoice_num, i.amount, COALESCE(i.currency, ''), COALESCE(c.name, ''), i.issued_date
+		FROM invoices i
+		LEFT JOIN invoice_recipients ir ON ir.invoice_id = i.id
+		LEFT JOIN clients c ON c.id = ir.client_id
+		WHERE i.status != ?
+		ORDER BY i.issued_date
+	`, models.StatusPaid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []openInvoice
+	seen := make(map[uint]bool)
+	for rows.Next() {
+		var inv openInvoice
+		if err := rows.Scan(&inv.ID, &inv.Num, &inv.Amount, &inv.Currency, &inv.ClientName, &inv.IssuedDate); err != nil {
+			return nil, fmt.Errorf("failed to read invoice: %w", err)
+		}
+		// Invoices with several recipients are matched on the first one
+		if seen[inv.ID] {
+			continue
+		}
+		seen[inv.ID] = true
+		invoices = append(invoices, inv)
+	}
+	return invoices, rows.Err()
+}
+
+// normalizeMatchText lowercases s and drops everything but letters and digits
+func normalizeMatchText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// scoreInvoiceMatch rates how likely a payment settles an invoice, from 0 to 100
+func scoreInvoiceMatch(tx bankstmt.Transaction, inv openInvoice) invoiceCandidate {
+	c := invoiceCandidate{invoice: inv}
+
+	if tx.Currency != "" && inv.Currency != "" {
+		if !strings.EqualFold(tx.Currency, inv.Currency) {
+			return c
+		}
+		c.score += 10
+		c.reasons = append(c.reasons, "currency")
+	}
+
+	diff := math.Abs(tx.Amount - inv.Amount)
+	switch {
+	case diff < 0.01:
+		c.score += 40
+		c.reasons = append(c.reasons, "amount")
+	case tx.Amount < inv.Amount && diff <= inv.Amount*0.03:
+		// Small shortfalls are usually bank or transfer fees
+		c.score += 20
+		c.reasons = append(c.reasons, "amount (minus fees)")
+	}
+
+	text := normalizeMatchText(tx.Text())
+	if num := normalizeMatchText(inv.Num); len(num) >= 4 && strings.Contains(text, num) {
+		c.score += 40
+		c.reasons = append(c.reasons, "invoice number")
+	}
+
+	if inv.ClientName != "" {
+		if name := normalizeMatchText(inv.ClientName); name != "" && strings.Contains(text, name) {
+			c.score += 20
+			c.reasons = append(c.reasons, "client name")
+		} else {
+			for _, word := range strings.Fields(inv.ClientName) {
+				if w := normalizeMatchText(word); len(w) >= 4 && strings.Contains(text, w) {
+					c.score += 10
+					c.reasons = append(c.reasons, "client name (partial)")
+					break
+				}
+			}
+		}
+	}
+
+	// Payments are rarely booked before the invoice was issued
+	if !inv.IssuedDate.IsZero() && tx.Date.Before(inv.IssuedDate.AddDate(0, 0, -1)) {
+		c.score -= 20
+	}
+
+	if c.score > 100 {
+		c.score = 100
+	}
+	if c.score < 0 {
+		c.score = 0
+	}
+	return c
+}
+
+// matchPayments scores every incoming payment against the open invoices
+func matchPayments(txs []bankstmt.Transaction, invoices []openInvoice) []reconcileMatch {
+	var matches []reconcileMatch
+	for _, tx := range txs {
+		if !tx.IsCredit() {
+			continue
+		}
+		m := reconcileMatch{tx: tx}
+		for _, inv := range invoices {
+			if c := scoreInvoiceMatch(tx, inv); c.score >= reconcileCandidateThreshold {
+				m.candidates = append(m.candidates, c)
+			}
+		}
+		sort.SliceStable(m.candidates, func(i, j int) bool {
+			return m.candidates[i].score > m.candidates[j].score
+		})
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+func reconcileTxLabel(tx bankstmt.Transaction) string {
+	label := fmt.Sprintf("%s %+.2f %s", tx.Date.Format("2006-01-02"), tx.Amount, tx.Currency)
+	if text := tx.Text(); text != "" {
+		if len(text) > 60 {
+			text = text[:57] + "..."
+		}
+		label += " " + text
+	}
+	return strings.TrimSpace(label)
+}
+
+func invoiceCandidateLabel(c invoiceCandidate) string {
+	label := fmt.Sprintf("%s (%.2f %s", c.invoice.Num, c.invoice.Amount, c.invoice.Currency)
+	if c.invoice.ClientName != "" {
+		label += ", " + c.invoice.ClientName
+	}
+	return fmt.Sprintf("%s) %d%%: %s", label, c.score, strings.Join(c.reasons, ", "))
+}
+
+func runReconcile(cmd *cobra.Command, args []string) error {
+	filePath := args[0]
+	if strings.HasPrefix(filePath, "~/") {
+		filePath = strings.Replace(filePath, "~", os.Getenv("HOME"), 1)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read statement: %w", err)
+	}
+
+	format := bankstmt.DetectFormat(filePath, data)
+	if reconcileFormat != "" {
+		format = bankstmt.Format(strings.ToLower(reconcileFormat))
+	}
+	txs, err := bankstmt.Parse(format, strings.NewReader(string(data)))
+	if err != nil {
+		return err
+	}
+	if reconcileCurrency != "" {
+		for i := range txs {
+			if txs[i].Currency == "" {
+				txs[i].Currency = strings.ToUpper(reconcileCurrency)
+			}
+		}
+	}
+
+	invoices, err := loadOpenInvoices()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nReconciling %d transactions from %s against %d open invoices...\n\n", len(txs), filePath, len(invoices))
+
+	matches := matchPayments(txs, invoices)
+	paid, err := reviewPaymentMatches(matches)
+	if err != nil {
+		return err
+	}
+
+	imported := 0
+	if reconcileExpenses {
+		imported, err = importDebitExpenses(txs)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	if reconcileDryRun {
+		fmt.Println("Dry run completed - no data was saved")
+	}
+	fmt.Printf("✓ Invoices marked paid: %d, Payments without match: %d", paid, countUnmatched(matches))
+	if reconcileExpenses {
+		fmt.Printf(", Expenses recorded: %d", imported)
+	}
+	fmt.Println()
+	return nil
+}
+
+// countUnmatched counts incoming payments with no candidate invoice
+func countUnmatched(matches []reconcileMatch) int {
+	n := 0
+	for _, m := range matches {
+		if len(m.candidates) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// reviewPaymentMatches confirms matches and marks the chosen invoices paid.
+// An invoice can only be settled by one payment.
+func reviewPaymentMatches(matches []reconcileMatch) (int, error) {
+	used := make(map[uint]bool)
+	paid := 0
+
+	for _, m := range matches {
+		var candidates []invoiceCandidate
+		for _, c := range m.candidates {
+			if !used[c.invoice.ID] {
+				candidates = append(candidates, c)
+			}
+		}
+
+		if len(candidates) == 0 {
+			fmt.Printf("  [No match] %s\n", reconcileTxLabel(m.tx))
+			continue
+		}
+
+		var chosen *invoiceCandidate
+		switch {
+		case reconcileDryRun:
+			fmt.Printf("  [Preview] %s\n", reconcileTxLabel(m.tx))
+			for _, c := range candidates {
+				fmt.Printf("      → %s\n", invoiceCandidateLabel(c))
+			}
+			continue
+		case reconcileYes:
+			if candidates[0].score < reconcileMinConfidence {
+				fmt.Printf("  [Skip] %s (best match %s is %d%%)\n", reconcileTxLabel(m.tx), candidates[0].invoice.Num, candidates[0].score)
+				continue
+			}
+			chosen = &candidates[0]
+		default:
+			var err error
+			chosen, err = promptPaymentMatch(m.tx, candidates)
+			if err != nil {
+				return paid, err
+			}
+			if chosen == nil {
+				fmt.Printf("  [Skip] %s\n", reconcileTxLabel(m.tx))
+				continue
+			}
+		}
+
+		if err := markInvoicePaid(chosen.invoice.ID, m.tx.Date); err != nil {
+			fmt.Printf("  [Error] %s: %v\n", chosen.invoice.Num, err)
+			continue
+		}
+		used[chosen.invoice.ID] = true
+		paid++
+		fmt.Printf("  [OK] %s → %s paid on %s\n", reconcileTxLabel(m.tx), chosen.invoice.Num, m.tx.Date.Format("2006-01-02"))
+	}
+	return paid, nil
+}
+
+// promptPaymentMatch asks which invoice a payment settles; nil means skip
+func promptPaymentMatch(tx bankstmt.Transaction, candidates []invoiceCandidate) (*invoiceCandidate, error) {
+	options := make([]huh.Option[int], 0, len(candidates)+1)
+	for i, c := range candidates {
+		options = append(options, huh.NewOption(invoiceCandidateLabel(c), i))
+	}
+	options = append(options, huh.NewOption("Skip this payment", -1))
+
+	selected := 0
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title(reconcileTxLabel(tx)).
+				Description("Which invoice does this payment settle?").
+				Options(options...).
+				Value(&selected),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("cancelled: %w", err)
+	}
+	if selected < 0 {
+		return nil, nil
+	}
+	return &candidates[selected], nil
+}
+
+// markInvoicePaid sets an invoice to paid with the given payment date
+func markInvoicePaid(invoiceID uint, paidDate time.Time) error {
+	return db.GormDB.Model(&models.Invoice{}).Where("id = ?", invoiceID).Updates(map[string]interface{}{
+		"status":    models.StatusPaid,
+		"paid_date": paidDate,
+	}).Error
+}
+
+// bankExpenseID is the external ID stored on expenses created from debits
+func bankExpenseID(tx bankstmt.Transaction) string {
+	return "bank:" + tx.ID
+}
+
+// debitExpense converts an outgoing debit into an expense
+func debitExpense(tx bankstmt.Transaction) models.Expense {
+	description := cmp.Or(tx.Reference, tx.Counterparty, "Bank payment")
+	currency := tx.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	return models.Expense{
+		Description: description,
+		Amount:      -tx.Amount,
+		Currency:    currency,
+		Category:    parseCategory(tx.Text()),
+		Date:        tx.Date,
+		Vendor:      tx.Counterparty,
+		Notes:       "Imported from bank statement",
+		ExternalID:  bankExpenseID(tx),
+	}
+}
+
+// importDebitExpenses records outgoing debits that weren't imported before as expenses
+func importDebitExpenses(txs []bankstmt.Transaction) (int, error) {
+	var existing []string
+	if err := db.GormDB.Model(&models.Expense{}).Where("external_id LIKE ?", "bank:%").Pluck("external_id", &existing).Error; err != nil {
+		return 0, fmt.Errorf("failed to load imported expenses: %w", err)
+	}
+	imported := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		imported[id] = true
+	}
+
+	var debits []bankstmt.Transaction
+	for _, tx := range txs {
+		if !tx.IsCredit() && !imported[bankExpenseID(tx)] {
+			debits = append(debits, tx)
+		}
+	}
+	if len(debits) == 0 {
+		fmt.Println("\nNo new outgoing payments to record as expenses.")
+		return 0, nil
+	}
+
+	fmt.Printf("\nOutgoing payments (%d):\n", len(debits))
+	if reconcileDryRun {
+		for _, tx := range debits {
+			e := debitExpense(tx)
+			fmt.Printf("  [Preview] %s → %s\n", reconcileTxLabel(tx), e.Category)
+		}
+		return 0, nil
+	}
+
+	selected := debits
+	if !reconcileYes {
+		options := make([]huh.Option[int], len(debits))
+		for i, tx := range debits {
+			options[i] = huh.NewOption(reconcileTxLabel(tx), i).Selected(true)
+		}
+		var chosen []int
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewMultiSelect[int]().
+					Title("Record these payments as expenses?").
+					Description("Deselect personal or internal transfers").
+					Options(options...).
+					Value(&chosen),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return 0, fmt.Errorf("cancelled: %w", err)
+		}
+		selected = selected[:0:0]
+		for _, i := range chosen {
+			selected = append(selected, debits[i])
+		}
+	}
+
+	created := 0
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		for _, debit := range selected {
+			expense := debitExpense(debit)
+			if err := tx.Create(&expense).Error; err != nil {
+				return fmt.Errorf("failed to save expense %s: %w", expense.Description, err)
+			}
+			fmt.Printf("  [OK] %s → %s\n", reconcileTxLabel(debit), expense.Category)
+			created++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
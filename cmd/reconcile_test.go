@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/bankstmt"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+func TestScoreInvoiceMatch(t *testing.T) {
+	issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	inv := openInvoice{ID: 1, Num: "inv.acme.2024-01-15", Amount: 1500, Currency: "USD", ClientName: "Acme Corp", IssuedDate: issued}
+	paidOn := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		tx   bankstmt.Transaction
+		min  int
+		max  int
+	}{
+		{
+			name: "full match",
+			tx:   bankstmt.Transaction{Date: paidOn, Amount: 1500, Currency: "USD", Counterparty: "ACME CORP", Reference: "INV ACME 2024-01-15"},
+			min:  100, max: 100,
+		},
+		{
+			name: "amount and client only",
+			tx:   bankstmt.Transaction{Date: paidOn, Amount: 1500, Currency: "USD", Counterparty: "Acme Corporation Ltd"},
+			min:  70, max: 70,
+		},
+		{
+			name: "amount minus fees",
+			tx:   bankstmt.Transaction{Date: paidOn, Amount: 1485, Currency: "USD"},
+			min:  30, max: 30,
+		},
+		{
+			name: "currency mismatch",
+			tx:   bankstmt.Transaction{Date: paidOn, Amount: 1500, Currency: "EUR", Reference: "inv.acme.2024-01-15"},
+			min:  0, max: 0,
+		},
+		{
+			name: "paid before issue",
+			tx:   bankstmt.Transaction{Date: issued.AddDate(0, 0, -10), Amount: 1500, Currency: "USD"},
+			min:  30, max: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := scoreInvoiceMatch(tt.tx, inv)
+			if c.score < tt.min || c.score > tt.max {
+				t.Errorf("score = %d (%v); want %d-%d", c.score, c.reasons, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestReconcilePayments(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+	db.DB.Exec("DELETE FROM invoice_recipients")
+	db.DB.Exec("DELETE FROM invoices")
+	db.DB.Exec("DELETE FROM expenses")
+
+	companyResult, err := db.DB.Exec("INSERT INTO companies (name, email) VALUES ('Reconcile Co', 'reconcile@test.com')")
+	if err != nil {
+		t.Fatalf("failed to create company: %v", err)
+	}
+	companyID, _ := companyResult.LastInsertId()
+	client := models.Client{Name: "Acme Corp", Email: "billing@acme.com"}
+	if err := db.GormDB.Create(&client).Error; err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	invoices := []models.Invoice{
+		{InvoiceNum: "inv.acme.2024-01-15", CompanyID: uint(companyID), Amount: 1500, Currency: "USD", Status: models.StatusSent, IssuedDate: issued, DueDate: issued.AddDate(0, 0, 30)},
+		{InvoiceNum: "inv.acme.2024-01-20", CompanyID: uint(companyID), Amount: 1500, Currency: "USD", Status: models.StatusSent, IssuedDate: issued.AddDate(0, 0, 5), DueDate: issued.AddDate(0, 0, 35)},
+	}
+	for i := range invoices {
+		if err := db.GormDB.Create(&invoices[i]).Error; err != nil {
+			t.Fatalf("failed to create invoice: %v", err)
+		}
+		db.GormDB.Create(&models.InvoiceRecipient{InvoiceID: invoices[i].ID, ClientID: client.ID})
+	}
+
+	open, err := loadOpenInvoices()
+	if err != nil {
+		t.Fatalf("loadOpenInvoices() error: %v", err)
+	}
+	if len(open) != 2 || open[0].ClientName != "Acme Corp" {
+		t.Fatalf("unexpected open invoices: %+v", open)
+	}
+
+	paidOn := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	txs := []bankstmt.Transaction{
+		{ID: "1", Date: paidOn, Amount: 1500, Currency: "USD", Counterparty: "Acme Corp", Reference: "inv.acme.2024-01-20"},
+		{ID: "2", Date: paidOn, Amount: -21, Currency: "USD", Counterparty: "GitHub", Reference: "Software subscription"},
+		{ID: "3", Date: paidOn, Amount: 42, Currency: "USD", Counterparty: "Someone"},
+	}
+
+	matches := matchPayments(txs, open)
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 incoming payments, got %d", len(matches))
+	}
+	if len(matches[0].candidates) != 2 || matches[0].candidates[0].invoice.ID != invoices[1].ID {
+		t.Fatalf("expected referenced invoice to rank first, got %+v", matches[0].candidates)
+	}
+	if countUnmatched(matches) != 1 {
+		t.Errorf("expected 1 unmatched payment, got %d", countUnmatched(matches))
+	}
+
+	reconcileYes, reconcileDryRun, reconcileMinConfidence = true, false, 80
+	defer func() { reconcileYes, reconcileMinConfidence = false, 80 }()
+
+	paid, err := reviewPaymentMatches(matches)
+	if err != nil {
+		t.Fatalf("reviewPaymentMatches() error: %v", err)
+	}
+	if paid != 1 {
+		t.Fatalf("expected 1 invoice marked paid, got %d", paid)
+	}
+	var inv models.Invoice
+	db.GormDB.First(&inv, invoices[1].ID)
+	if inv.Status != models.StatusPaid || inv.PaidDate == nil || !inv.PaidDate.Equal(paidOn) {
+		t.Errorf("expected invoice paid on %v, got status %s date %v", paidOn, inv.Status, inv.PaidDate)
+	}
+
+	created, err := importDebitExpenses(txs)
+	if err != nil {
+		t.Fatalf("importDebitExpenses() error: %v", err)
+	}
+	if created != 1 {
+		t.Fatalf("expected 1 expense, got %d", created)
+	}
+	var expense models.Expense
+	db.GormDB.Where("external_id = ?", "bank:2").First(&expense)
+	if expense.Amount != 21 || expense.Vendor != "GitHub" || expense.Category != models.ExpenseCategorySoftware {
+		t.Errorf("unexpected expense: %+v", expense)
+	}
+
+	// Reconciling the same statement again doesn't duplicate expenses
+	created, _ = importDebitExpenses(txs)
+	if created != 0 {
+		t.Errorf("expected no new expenses on re-import, got %d", created)
+	}
+}
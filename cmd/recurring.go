@@ -7,9 +7,10 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/pkg/idgen"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -394,32 +395,47 @@ func runRecurringGenerate(cmd *cobra.Command, args []string) error {
 
 		// Calculate dates
 		issuedDate := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()) // End of month
-		dueDate := issuedDate.AddDate(0, 0, 30)                                            // 30 days from issued
-
-		// Create invoice
-		result, err := db.DB.Exec(`
-			INSERT INTO invoices (invoice_num, company_id, amount, currency, description, status, issued_date, due_date)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		`, invoiceNum, company.ID, inv.Amount, inv.Currency, inv.Description, models.StatusPending, issuedDate, dueDate)
-
+		dueDate := inv.Client.DueDate(issuedDate)                                         // The client's payment terms
+
+		// Create invoice with its line item and tax
+		items := []models.InvoiceLineItem{{
+			ItemName:    inv.Description,
+			Description: fmt.Sprintf("Recurring invoice - %s", inv.Frequency),
+			Quantity:    1,
+			Rate:        inv.Amount,
+			Amount:      inv.Amount,
+		}}
+		var contractID uint
+		if inv.ContractID != nil {
+			contractID = *inv.ContractID
+		}
+		t, err := invoiceTax("", inv.ClientID, contractID, items)
 		if err != nil {
-			fmt.Printf("  ❌ Failed to create invoice: %v\n", err)
+			fmt.Printf("  ❌ %v\n", err)
 			continue
 		}
 
-		invoiceID, _ := result.LastInsertId()
-
-		// Link to client
-		db.DB.Exec("INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (?, ?)", invoiceID, inv.ClientID)
-
-		// Create line item
-		db.DB.Exec(`
-			INSERT INTO invoice_line_items (invoice_id, item_name, description, quantity, rate, amount)
-			VALUES (?, ?, ?, 1, ?, ?)
-		`, invoiceID, inv.Description, fmt.Sprintf("Recurring invoice - %s", inv.Frequency), inv.Amount, inv.Amount)
+		invoiceID, err := insertInvoice(models.Invoice{
+			InvoiceNum:  invoiceNum,
+			CompanyID:   company.ID,
+			Currency:    inv.Currency,
+			Description: inv.Description,
+			IssuedDate:  issuedDate,
+			DueDate:     dueDate,
+		}, inv.ClientID, items, t)
+		if err != nil {
+			fmt.Printf("  ❌ %v\n", err)
+			continue
+		}
 
 		fmt.Printf("  ✓ Created %s\n", invoiceNum)
 
+		// A client that needs a PO number gets it by hand before the invoice is sent
+		poMissing := inv.Client.PORequired
+		if poMissing {
+			fmt.Printf("  ⚠ %s requires a PO number, set it with: ung invoice edit %d --po <number>\n", inv.Client.Name, invoiceID)
+		}
+
 		// Generate PDF if auto_pdf
 		if inv.AutoPDF {
 			if err := generateInvoicePDFByID(int(invoiceID)); err != nil {
@@ -430,8 +446,12 @@ func runRecurringGenerate(cmd *cobra.Command, args []string) error {
 		}
 
 		// Send email if auto_send
-		if inv.AutoSend {
+		if inv.AutoSend && !poMissing {
 			emailApp := inv.EmailApp
+			if emailApp == "" {
+				cfg, _ := config.Load()
+				emailApp = cfg.Email.App
+			}
 			if emailApp == "" {
 				emailApp = "gmail"
 			}
@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 func TestCalculateNextGenerationDate(t *testing.T) {
@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +22,8 @@ Commands:
   revenue    Revenue breakdown
   clients    Client summary
   overdue    Overdue invoices
-  unpaid     All unpaid invoices`,
+  unpaid     All unpaid invoices
+  tax        VAT return figures for a quarter, month or year`,
 }
 
 var reportWeeklyCmd = &cobra.Command{
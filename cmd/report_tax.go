@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+	"github.com/spf13/cobra"
+)
+
+var reportTaxCmd = &cobra.Command{
+	Use:   "tax",
+	Short: "Show VAT return figures for a period",
+	Long: `Show the figures of a VAT return: net sales and output tax by rate,
+reverse charge sales by client VAT ID for the EC sales list, exempt and
+untaxed sales. Invoices count by issue date, or by payment date with --cash.
+
+The period is a quarter, month or year. Without one, the last complete
+quarter is shown.
+
+Examples:
+  ung report tax                      Last complete quarter
+  ung report tax --period Q1          First quarter of this year
+  ung report tax --period Q4 --year 2025
+  ung report tax --period 2026-03     March 2026
+  ung report tax --period 2025 --cash Paid in 2025`,
+	RunE: runReportTax,
+}
+
+var (
+	reportTaxPeriod string
+	reportTaxCash   bool
+)
+
+func init() {
+	reportTaxCmd.Flags().StringVar(&reportTaxPeriod, "period", "", "Period: Q1-Q4, YYYY-Qn, YYYY-MM or YYYY (default: last complete quarter)")
+	reportTaxCmd.Flags().IntVar(&reportYear, "year", 0, "Year of a quarter or month period (default: this year)")
+	reportTaxCmd.Flags().BoolVar(&reportTaxCash, "cash", false, "Count paid invoices by payment date (cash accounting)")
+	reportCmd.AddCommand(reportTaxCmd)
+}
+
+var (
+	quarterPeriod = regexp.MustCompile(`^(?:(\d{4})-)?[Qq]([1-4])$`)
+	monthPeriod   = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+	yearPeriod    = regexp.MustCompile(`^\d{4}$`)
+)
+
+// parseTaxPeriod returns the start and end (exclusive) of a report period
+// and its name. A quarter without a year is in year, or in now's year when
+// it's 0; an empty period is the last complete quarter before now.
+func parseTaxPeriod(period string, year int, now time.Time) (time.Time, time.Time, string, error) {
+	if year == 0 {
+		year = now.Year()
+	}
+	quarter := func(y, q int) (time.Time, time.Time, string, error) {
+		from := time.Date(y, time.Month(3*q-2), 1, 0, 0, 0, 0, now.Location())
+		return from, from.AddDate(0, 3, 0), fmt.Sprintf("Q%d %d", q, y), nil
+	}
+
+	period = strings.TrimSpace(period)
+	switch {
+	case period == "":
+		q := (int(now.Month())-1)/3 + 1
+		if q == 1 {
+			return quarter(now.Year()-1, 4)
+		}
+		return quarter(now.Year(), q-1)
+	case quarterPeriod.MatchString(period):
+		m := quarterPeriod.FindStringSubmatch(period)
+		if m[1] != "" {
+			year, _ = strconv.Atoi(m[1])
+		}
+		q, _ := strconv.Atoi(m[2])
+		return quarter(year, q)
+	case monthPeriod.MatchString(period):
+		from, err := time.ParseInLocation("2006-01", period, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("invalid month %q: %w", period, err)
+		}
+		return from, from.AddDate(0, 1, 0), from.Format("January 2006"), nil
+	case yearPeriod.MatchString(period):
+		y, _ := strconv.Atoi(period)
+		from := time.Date(y, 1, 1, 0, 0, 0, 0, now.Location())
+		return from, from.AddDate(1, 0, 0), period, nil
+	}
+	return time.Time{}, time.Time{}, "", fmt.Errorf("invalid period %q (use Q1-Q4, YYYY-Qn, YYYY-MM or YYYY)", period)
+}
+
+// taxReturns totals the invoices of a period by currency
+func taxReturns(from, to time.Time, cash bool) ([]*tax.Return, error) {
+	var invoices []models.Invoice
+	query := db.GormDB.Order("issued_date")
+	if cash {
+		query = query.Where("status = ? AND COALESCE(paid_date, updated_at) >= ? AND COALESCE(paid_date, updated_at) < ?", models.StatusPaid, from, to)
+	} else {
+		query = query.Where("issued_date >= ? AND issued_date < ?", from, to)
+	}
+	if err := query.Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("failed to load invoices: %w", err)
+	}
+
+	byCurrency := map[string]*tax.Return{}
+	for _, inv := range invoices {
+		var client models.Client
+		err := db.DB.QueryRow(`
+			SELECT c.id, c.name, c.tax_id FROM clients c
+			JOIN invoice_recipients ir ON c.id = ir.client_id
+			WHERE ir.invoice_id = ?
+		`, inv.ID).Scan(&client.ID, &client.Name, &client.TaxID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client of %s: %w", inv.InvoiceNum, err)
+		}
+
+		var items []models.InvoiceLineItem
+		if err := db.GormDB.Where("invoice_id = ?", inv.ID).Find(&items).Error; err != nil {
+			return nil, fmt.Errorf("failed to load line items of %s: %w", inv.InvoiceNum, err)
+		}
+
+		r, ok := byCurrency[inv.Currency]
+		if !ok {
+			r = &tax.Return{Currency: inv.Currency}
+			byCurrency[inv.Currency] = r
+		}
+		r.Add(inv, client, items)
+	}
+
+	returns := make([]*tax.Return, 0, len(byCurrency))
+	for _, r := range byCurrency {
+		returns = append(returns, r)
+	}
+	sort.Slice(returns, func(i, j int) bool { return returns[i].Currency < returns[j].Currency })
+	return returns, nil
+}
+
+func runReportTax(cmd *cobra.Command, args []string) error {
+	from, to, name, err := parseTaxPeriod(reportTaxPeriod, reportYear, time.Now())
+	if err != nil {
+		return err
+	}
+	returns, err := taxReturns(from, to, reportTaxCash)
+	if err != nil {
+		return err
+	}
+
+	basis := "by issue date"
+	if reportTaxCash {
+		basis = "paid, by payment date"
+	}
+	fmt.Printf("🧾 Tax Report %s (%s – %s, %s)\n\n", name, from.Format("2 Jan 2006"), to.AddDate(0, 0, -1).Format("2 Jan 2006"), basis)
+
+	if len(returns) == 0 {
+		fmt.Println("No invoices in this period.")
+		return nil
+	}
+
+	for _, r := range returns {
+		fmt.Printf("%s — %d invoice(s)\n", r.Currency, r.Invoices)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if len(r.Rates) > 0 {
+			fmt.Fprintln(w, "  KIND\tRATE\tNET\tTAX")
+			for _, rate := range r.Rates {
+				kind := "VAT"
+				if rate.Type == tax.TypeSalesTax {
+					kind = "Sales tax"
+				}
+				fmt.Fprintf(w, "  %s\t%s\t%.2f\t%.2f\n", kind, taxPercent(rate.Rate.Rate), rate.Net, rate.Tax)
+			}
+			w.Flush()
+			fmt.Println()
+		}
+
+		if len(r.ReverseCharge) > 0 {
+			fmt.Println("  Reverse charge (EC sales list):")
+			fmt.Fprintln(w, "  CLIENT\tVAT ID\tNET")
+			for _, s := range r.ReverseCharge {
+				fmt.Fprintf(w, "  %s\t%s\t%.2f\n", s.Client, s.TaxID, s.Net)
+			}
+			w.Flush()
+			fmt.Println()
+		}
+
+		fmt.Fprintf(w, "  Reverse charge:\t%.2f\n", r.ReverseChargeNet())
+		fmt.Fprintf(w, "  Exempt:\t%.2f\n", r.Exempt)
+		fmt.Fprintf(w, "  Not taxed:\t%.2f\n", r.NotTaxed)
+		fmt.Fprintf(w, "  Total net:\t%.2f\n", r.Net)
+		fmt.Fprintf(w, "  Output tax:\t%.2f\n", r.Tax)
+		if r.SalesTax != 0 {
+			fmt.Fprintf(w, "  Sales tax:\t%.2f\n", r.SalesTax)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	fmt.Println("💡 Input VAT on expenses isn't tracked, deduct it from the output tax yourself.")
+	return nil
+}
+
+// taxPercent formats a tax rate as a percentage, e.g. "7.25%"
+func taxPercent(rate float64) string {
+	s := strconv.FormatFloat(rate*100, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	return s + "%"
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+)
+
+// withTaxConfig sets the tax section of the loaded config for a test
+func withTaxConfig(t *testing.T, rate float64, tc config.TaxConfig) {
+	cfg, _ := config.Load()
+	oldRate, oldTax := cfg.PDF.TaxRate, cfg.Tax
+	cfg.PDF.TaxRate, cfg.Tax = rate, tc
+	t.Cleanup(func() { cfg.PDF.TaxRate, cfg.Tax = oldRate, oldTax })
+}
+
+func TestInvoiceTaxProfiles(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+	withTaxConfig(t, 0.19, config.TaxConfig{Default: tax.ProfileVAT})
+
+	db.DB.Exec("DELETE FROM invoices")
+	res, err := db.DB.Exec(`
+		INSERT INTO companies (name, email, phone, address, registration_address, tax_id, bank_name, bank_account, bank_swift, logo_path)
+		VALUES ('Tax Co', 'tax@test.com', '', '', '', 'DE123', '', '', '', '')
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	companyID := uint(id)
+
+	res, _ = db.DB.Exec("INSERT INTO clients (name, email, address, tax_id, tax_profile) VALUES ('Acme BV', 'acme@test.com', 'Amsterdam', 'NL123456789B01', 'reverse-charge')")
+	acmeID, _ := res.LastInsertId()
+	res, _ = db.DB.Exec("INSERT INTO clients (name, email, address, tax_id) VALUES ('Local GmbH', 'local@test.com', 'Berlin', '')")
+	localID, _ := res.LastInsertId()
+
+	issued := time.Date(2026, 2, 28, 0, 0, 0, 0, time.Local)
+	create := func(num string, clientID int64, override string, amounts ...float64) int64 {
+		t.Helper()
+		var items []models.InvoiceLineItem
+		for _, a := range amounts {
+			items = append(items, models.InvoiceLineItem{ItemName: "Work", Quantity: 1, Rate: a, Amount: a})
+		}
+		tr, err := invoiceTax(override, uint(clientID), 0, items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := insertInvoice(models.Invoice{
+			InvoiceNum: num, CompanyID: companyID, Currency: "EUR",
+			IssuedDate: issued, DueDate: issued.AddDate(0, 0, 30),
+		}, uint(clientID), items, tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// The default profile applies to a client without one
+	localInv := create("TAX-1", localID, "", 1000)
+	var inv models.Invoice
+	db.GormDB.First(&inv, localInv)
+	if inv.Amount != 1190 || inv.NetAmount != 1000 || inv.TaxAmount != 190 || inv.TaxType != tax.TypeVAT {
+		t.Errorf("unexpected VAT invoice %+v", inv)
+	}
+
+	// The client's profile wins over the default
+	acmeInv := create("TAX-2", acmeID, "", 500)
+	inv = models.Invoice{}
+	db.GormDB.First(&inv, acmeInv)
+	if inv.Amount != 500 || inv.TaxAmount != 0 || inv.TaxProfile != tax.ProfileReverseCharge || inv.TaxNote == "" {
+		t.Errorf("unexpected reverse charge invoice %+v", inv)
+	}
+
+	// And the command line over both
+	exemptInv := create("TAX-3", localID, tax.ProfileExempt, 80)
+
+	// Repricing a single line recalculates its tax
+	updated, err := setInvoiceAmount(int(localInv), 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Amount != 2380 || updated.TaxAmount != 380 {
+		t.Errorf("expected 2000 + 380 tax, got %+v", updated)
+	}
+
+	// Recalculating with another profile replaces the line rates
+	tr, err := recalculateInvoiceTax(int(exemptInv), tax.ProfileVAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Gross != 95.2 {
+		t.Errorf("expected 80 + 19%%, got %+v", tr)
+	}
+	if _, err := recalculateInvoiceTax(int(exemptInv), tax.ProfileExempt); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to, _, err := parseTaxPeriod("Q1", 2026, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	returns, err := taxReturns(from, to, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(returns) != 1 {
+		t.Fatalf("expected one currency, got %d", len(returns))
+	}
+	r := returns[0]
+	if r.Invoices != 3 || r.Net != 2580 || r.Tax != 380 || r.Exempt != 80 || r.ReverseChargeNet() != 500 {
+		t.Errorf("unexpected return %+v", r)
+	}
+	if len(r.ReverseCharge) != 1 || r.ReverseCharge[0].TaxID != "NL123456789B01" {
+		t.Errorf("expected Acme in the EC sales list, got %+v", r.ReverseCharge)
+	}
+
+	// Nothing was paid, so nothing counts with cash accounting
+	if returns, _ := taxReturns(from, to, true); len(returns) != 0 {
+		t.Errorf("expected no paid invoices, got %+v", returns)
+	}
+}
+
+func TestParseTaxPeriod(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period   string
+		year     int
+		from, to string
+		name     string
+	}{
+		{"", 0, "2026-01-01", "2026-04-01", "Q1 2026"},
+		{"Q1", 0, "2026-01-01", "2026-04-01", "Q1 2026"},
+		{"q4", 2025, "2025-10-01", "2026-01-01", "Q4 2025"},
+		{"2024-Q3", 0, "2024-07-01", "2024-10-01", "Q3 2024"},
+		{"2026-03", 0, "2026-03-01", "2026-04-01", "March 2026"},
+		{"2025", 0, "2025-01-01", "2026-01-01", "2025"},
+	}
+	for _, tt := range tests {
+		from, to, name, err := parseTaxPeriod(tt.period, tt.year, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.period, err)
+			continue
+		}
+		if from.Format("2006-01-02") != tt.from || to.Format("2006-01-02") != tt.to || name != tt.name {
+			t.Errorf("%q: got %s – %s %q, want %s – %s %q", tt.period, from.Format("2006-01-02"), to.Format("2006-01-02"), name, tt.from, tt.to, tt.name)
+		}
+	}
+
+	// In the first quarter the last complete one is Q4 of last year
+	if _, _, name, _ := parseTaxPeriod("", 0, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)); name != "Q4 2025" {
+		t.Errorf("expected Q4 2025, got %q", name)
+	}
+	for _, bad := range []string{"Q5", "2026-13", "last"} {
+		if _, _, _, err := parseTaxPeriod(bad, 0, now); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
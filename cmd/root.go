@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
@@ -13,18 +16,23 @@ import (
 // globalFlag indicates whether to use global config
 var globalFlag bool
 
+// profileFlag selects a profile for this run
+var profileFlag string
+
 // dbInitialized tracks if database was successfully initialized
 var dbInitialized bool
 
 // commandsWithoutDB is a list of commands that don't require database initialization
 var commandsWithoutDB = map[string]bool{
-	"config":   true,
-	"version":  true,
-	"help":     true,
-	"doctor":   true,
-	"upgrade":  true,
-	"update":   true,
-	"docs":     true,
+	"config":     true,
+	"version":    true,
+	"help":       true,
+	"doctor":     true,
+	"upgrade":    true,
+	"update":     true,
+	"docs":       true,
+	"security":   true, // rewrites the database files itself
+	"profile":    true,
 	"__complete": true,
 	"completion": true,
 }
@@ -38,13 +46,22 @@ clients, invoices, and time tracking.
 Data storage (similar to VS Code settings):
 - Local:  .ung/ folder in current directory (project-specific)
 - Global: ~/.ung/ folder (default when no local config exists)
+- Profiles: ~/.ung/profiles/<name>/, e.g. personal and agency
 
 Use 'ung config init' to create a local workspace configuration.
-Use --global flag to explicitly use global configuration.`,
+Use --global flag to explicitly use global configuration.
+Use 'ung profile use <name>' or --profile to switch between profiles.`,
 	Version: Version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Set global flag in config package
 		config.SetForceGlobal(globalFlag)
+		profile := profileFlag
+		if profile == "" {
+			profile = os.Getenv("UNG_PROFILE")
+		}
+		if err := config.SetProfile(profile); err != nil {
+			return err
+		}
 
 		// Check if this command requires database
 		cmdName := cmd.Name()
@@ -57,6 +74,13 @@ Use --global flag to explicitly use global configuration.`,
 			cmdName = parent.Name()
 		}
 
+		// 'ung profile' must still work when the active profile was removed
+		if cmdName != "profile" {
+			if err := config.CheckProfile(); err != nil {
+				return err
+			}
+		}
+
 		// Skip DB initialization for commands that don't need it
 		if commandsWithoutDB[cmdName] {
 			return nil
@@ -74,10 +98,7 @@ Use --global flag to explicitly use global configuration.`,
 		return nil
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		// Close database if it was initialized
-		if dbInitialized {
-			db.Close()
-		}
+		closeDatabase()
 	},
 }
 
@@ -103,15 +124,52 @@ func showOnboarding() {
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// On Ctrl+C the command's context is cancelled and the database is closed
+	// here, on the main goroutine, once the command has returned. Closing it from
+	// the signal handler would race with a command still using db.DB. A second
+	// Ctrl+C exits right away for commands that don't watch their context.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+		<-signals
+		fmt.Fprintln(os.Stderr, "\nInterrupted, unsaved changes are lost")
+		os.Exit(130)
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if ctx.Err() != nil {
+		// PersistentPostRun is skipped when the command was interrupted with an error
+		closeDatabase()
+		os.Exit(130)
+	}
+	if err != nil {
+		// PersistentPostRun is skipped on errors, but changes made before the error still need saving
+		closeDatabase()
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+// closeDatabase closes the database if it was initialized, reporting a failed write-back
+func closeDatabase() {
+	if !dbInitialized {
+		return
+	}
+	if err := db.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to save database: %v\n", err)
+		return
+	}
+	dbInitialized = false
+}
+
 func init() {
 	// Add global flag to root command (applies to all subcommands)
 	rootCmd.PersistentFlags().BoolVarP(&globalFlag, "global", "G", false, "Use global ~/.ung/ configuration instead of local")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Use this profile's database and configuration (or set UNG_PROFILE)")
 
 	// Register subcommands
 	rootCmd.AddCommand(companyCmd)
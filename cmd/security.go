@@ -20,6 +20,10 @@ Examples:
   ung security enable              # Enable database encryption
   ung security disable             # Disable database encryption
   ung security change-password     # Change encryption password
+  ung security upgrade             # Upgrade to the latest file format
+  ung security recovery-key        # Create a recovery key
+  ung security recover             # Set a new password with the recovery key
+  ung security key-file create     # Unlock with a key file (headless use)
   ung security save-password       # Save password to OS keychain
   ung security forget-password     # Remove password from OS keychain`,
 }
@@ -35,7 +39,8 @@ var securityEnableCmd = &cobra.Command{
 	Use:   "enable",
 	Short: "Enable database encryption",
 	Long: `Enable encryption for the database. This will encrypt the database file at rest
-using AES-256-GCM encryption with PBKDF2 key derivation.
+using AES-256-GCM with a random data key, wrapped by a key derived from your
+password with PBKDF2 (or Argon2id with security.kdf: argon2id in config).
 
 The database will be encrypted with a password that you provide. Save your password
 to the OS keychain with 'ung security save-password' for seamless access.
@@ -67,7 +72,12 @@ var securityChangePasswordCmd = &cobra.Command{
 	Short: "Change database encryption password",
 	Long: `Change the password used to encrypt the database.
 
-You'll need to provide both the current password and the new password.`,
+You'll need to provide both the current password and the new password.
+Only the wrapped data key is rewritten; version 1 files are upgraded.
+
+Encrypted receipts, the backup repository key and the keys of encrypted
+sync folders used from this device move to the new password as well.
+Other devices syncing through those folders need the new password too.`,
 	Run: runSecurityChangePassword,
 }
 
@@ -121,7 +131,7 @@ func runSecurityStatus(cmd *cobra.Command, args []string) {
 	if encryptedExists {
 		fmt.Println("Status:    ✅ Encrypted")
 		fmt.Printf("File:      %s\n", encryptedPath)
-		fmt.Println("Algorithm: AES-256-GCM with PBKDF2")
+		securityFormatStatus(encryptedPath)
 	} else if plainExists {
 		fmt.Println("Status:    ⚠️  Not Encrypted (Plain Text)")
 		fmt.Printf("File:      %s\n", dbPath)
@@ -173,7 +183,7 @@ func runSecurityEnable(cmd *cobra.Command, args []string) {
 	if !fileExists(dbPath) {
 		// No database yet, just enable in config
 		cfg.Security.EncryptDatabase = true
-		if err := config.Save(cfg, false); err != nil {
+		if err := config.SaveActive(cfg); err != nil {
 			fmt.Printf("❌ Failed to save config: %v\n", err)
 			return
 		}
@@ -202,6 +212,13 @@ func runSecurityEnable(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	unlock, err := db.LockDatabase()
+	if err != nil {
+		fmt.Printf("\n❌ %v\n", err)
+		return
+	}
+	defer unlock()
+
 	// Encrypt the database
 	fmt.Println("\n🔄 Encrypting database...")
 	if err := db.EncryptDatabase(dbPath, encryptedPath, password); err != nil {
@@ -211,21 +228,22 @@ func runSecurityEnable(cmd *cobra.Command, args []string) {
 
 	// Update config
 	cfg.Security.EncryptDatabase = true
-	if err := config.Save(cfg, false); err != nil {
+	if err := config.SaveActive(cfg); err != nil {
 		fmt.Printf("❌ Failed to save config: %v\n", err)
 		// Remove encrypted file since config update failed
 		os.Remove(encryptedPath)
 		return
 	}
 
-	// Remove plain text database
-	if err := os.Remove(dbPath); err != nil {
+	// Overwrite and remove the plain text database
+	if err := db.SecureRemove(dbPath); err != nil {
 		fmt.Printf("⚠️  Failed to remove plain text database: %v\n", err)
 		fmt.Println("   Please remove it manually for security")
 	}
 
 	fmt.Println("✅ Database encrypted successfully")
 	fmt.Printf("   Encrypted: %s\n", encryptedPath)
+	fmt.Println("\n💡 Run 'ung security recovery-key' so a forgotten password isn't fatal")
 
 	// Offer to save password to keychain
 	if db.KeychainAvailable() {
@@ -284,6 +302,13 @@ func runSecurityDisable(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	unlock, err := db.LockDatabase()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer unlock()
+
 	// Decrypt the database
 	fmt.Println("\n🔄 Decrypting database...")
 	if err := db.DecryptDatabase(encryptedPath, dbPath, password); err != nil {
@@ -293,7 +318,7 @@ func runSecurityDisable(cmd *cobra.Command, args []string) {
 
 	// Update config
 	cfg.Security.EncryptDatabase = false
-	if err := config.Save(cfg, false); err != nil {
+	if err := config.SaveActive(cfg); err != nil {
 		fmt.Printf("❌ Failed to save config: %v\n", err)
 		return
 	}
@@ -337,10 +362,9 @@ func runSecurityChangePassword(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	// Decrypt with current password
+	// Verify the current password before asking for a new one
 	fmt.Println("\n🔄 Verifying current password...")
-	tempPath := dbPath + ".temp"
-	if err := db.DecryptDatabase(encryptedPath, tempPath, currentPassword); err != nil {
+	if err := db.VerifyDatabasePassword(encryptedPath, currentPassword); err != nil {
 		fmt.Printf("❌ Failed to decrypt: %v\n", err)
 		fmt.Println("   (Wrong password?)")
 		return
@@ -351,7 +375,6 @@ func runSecurityChangePassword(cmd *cobra.Command, args []string) {
 	newPassword, err := readPassword()
 	if err != nil {
 		fmt.Printf("\n❌ Failed to read password: %v\n", err)
-		os.Remove(tempPath)
 		return
 	}
 
@@ -359,42 +382,28 @@ func runSecurityChangePassword(cmd *cobra.Command, args []string) {
 	confirmPassword, err := readPassword()
 	if err != nil {
 		fmt.Printf("\n❌ Failed to read password: %v\n", err)
-		os.Remove(tempPath)
 		return
 	}
 
 	if newPassword != confirmPassword {
 		fmt.Println("\n❌ Passwords don't match")
-		os.Remove(tempPath)
-		return
-	}
-
-	// Encrypt with new password
-	fmt.Println("\n🔄 Re-encrypting with new password...")
-	newEncryptedPath := encryptedPath + ".new"
-	if err := db.EncryptDatabase(tempPath, newEncryptedPath, newPassword); err != nil {
-		fmt.Printf("❌ Failed to encrypt: %v\n", err)
-		os.Remove(tempPath)
 		return
 	}
 
-	// Replace old encrypted file
-	if err := os.Remove(encryptedPath); err != nil {
-		fmt.Printf("❌ Failed to remove old encrypted file: %v\n", err)
-		os.Remove(tempPath)
-		os.Remove(newEncryptedPath)
+	unlock, err := db.LockDatabase()
+	if err != nil {
+		fmt.Printf("\n❌ %v\n", err)
 		return
 	}
+	defer unlock()
 
-	if err := os.Rename(newEncryptedPath, encryptedPath); err != nil {
-		fmt.Printf("❌ Failed to replace encrypted file: %v\n", err)
-		os.Remove(tempPath)
+	// Re-encrypt in memory and atomically replace the encrypted file
+	fmt.Println("\n🔄 Re-encrypting with new password...")
+	if err := changeDatabasePassword(encryptedPath, currentPassword, newPassword); err != nil {
+		fmt.Printf("❌ Failed to re-encrypt: %v\n", err)
 		return
 	}
 
-	// Clean up
-	os.Remove(tempPath)
-
 	fmt.Println("✅ Password changed successfully")
 
 	// Update keychain if password was stored there
@@ -419,6 +428,18 @@ func runSecurityChangePassword(cmd *cobra.Command, args []string) {
 	}
 }
 
+// changeDatabasePassword re-encrypts the database and the stores sealed with
+// its password (receipts, backup repository and sync folder keys)
+func changeDatabasePassword(encryptedPath, currentPassword, newPassword string) error {
+	keyring, err := db.ReencryptDatabase(encryptedPath, currentPassword, newPassword)
+	if err != nil {
+		return err
+	}
+	rewrapLinkedStores(keyring, currentPassword, newPassword)
+	db.ClearPasswordCache()
+	return nil
+}
+
 func readPassword() (string, error) {
 	password, err := db.GetDatabasePassword()
 	if err != nil {
@@ -475,12 +496,10 @@ func runSecuritySavePassword(cmd *cobra.Command, args []string) {
 
 	// Verify the password by trying to decrypt
 	fmt.Println("\n🔄 Verifying password...")
-	tempPath := dbPath + ".verify"
-	if err := db.DecryptDatabase(encryptedPath, tempPath, password); err != nil {
+	if err := db.VerifyDatabasePassword(encryptedPath, password); err != nil {
 		fmt.Printf("❌ Invalid password: %v\n", err)
 		return
 	}
-	os.Remove(tempPath)
 
 	// Save to keychain
 	if err := db.SavePasswordToKeychain(password); err != nil {
@@ -0,0 +1,507 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Andriiklymiuk/ung/internal/backup"
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/internal/receipts"
+	"github.com/Andriiklymiuk/ung/internal/replica"
+	"github.com/spf13/cobra"
+)
+
+var upgradeKDF string
+
+var securityUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the encrypted database to the latest format",
+	Long: `Upgrade an encrypted database in place to the current file format.
+
+Version 2 files encrypt the database with a random data key that is wrapped
+by your password, so passwords can be changed and a recovery key or key file
+added without re-encrypting the data. Use --kdf to switch the password's key
+derivation between pbkdf2 and argon2id.
+
+Examples:
+  ung security upgrade                 # Convert a version 1 file
+  ung security upgrade --kdf argon2id  # Derive the password key with Argon2id`,
+	Run: runSecurityUpgrade,
+}
+
+var securityRecoveryKeyCmd = &cobra.Command{
+	Use:   "recovery-key",
+	Short: "Generate a recovery key for a forgotten password",
+	Long: `Generate a printable recovery key that unlocks the database if you forget
+your password. It is shown once: print it or store it somewhere safe, away
+from the computer. Generating a new key invalidates the previous one.
+
+Use it with 'ung security recover' to set a new password.`,
+	Run: runSecurityRecoveryKey,
+}
+
+var securityRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Set a new password using the recovery key",
+	Long: `Unlock the database with its recovery key and set a new password.
+
+Encrypted receipts, the backup repository key and sync folder keys created
+with this version of ung move to the new password too. Older ones can't be
+unlocked without the old password and are listed.`,
+	Run: runSecurityRecover,
+}
+
+var securityKeyFileCmd = &cobra.Command{
+	Use:   "key-file",
+	Short: "Manage key file unlock for headless use",
+	Long: `A key file unlocks the database without a password, for servers, cron jobs
+and CI. Its path is saved as security.key_file in config and can be
+overridden with UNG_KEY_FILE. Anyone who can read the key file can read
+your database: keep it on a protected volume.
+
+Examples:
+  ung security key-file create ~/.ung/ung.key
+  ung security key-file remove`,
+}
+
+var securityKeyFileCreateCmd = &cobra.Command{
+	Use:   "create <path>",
+	Short: "Create a key file that unlocks the database",
+	Args:  cobra.ExactArgs(1),
+	Run:   runSecurityKeyFileCreate,
+}
+
+var securityKeyFileRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Stop the key file from unlocking the database",
+	Run:   runSecurityKeyFileRemove,
+}
+
+func init() {
+	securityCmd.AddCommand(securityUpgradeCmd)
+	securityCmd.AddCommand(securityRecoveryKeyCmd)
+	securityCmd.AddCommand(securityRecoverCmd)
+	securityCmd.AddCommand(securityKeyFileCmd)
+	securityKeyFileCmd.AddCommand(securityKeyFileCreateCmd)
+	securityKeyFileCmd.AddCommand(securityKeyFileRemoveCmd)
+
+	securityUpgradeCmd.Flags().StringVar(&upgradeKDF, "kdf", "", "Key derivation for the password: pbkdf2 or argon2id")
+}
+
+// readEncryptedDatabase locks the database and reads the encrypted file. The
+// returned function releases the lock.
+func readEncryptedDatabase() (string, []byte, func(), error) {
+	encryptedPath := db.GetDBPath() + ".encrypted"
+	if !fileExists(encryptedPath) {
+		return "", nil, nil, fmt.Errorf("database is not encrypted (run 'ung security enable' first)")
+	}
+	unlock, err := db.LockDatabase()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	data, err := os.ReadFile(encryptedPath)
+	if err != nil {
+		unlock()
+		return "", nil, nil, fmt.Errorf("failed to read encrypted database: %w", err)
+	}
+	return encryptedPath, data, unlock, nil
+}
+
+// openKeyringWithPassword unlocks a version 2 file's keyring with the database password
+func openKeyringWithPassword(data []byte) (*db.Keyring, error) {
+	if !db.IsVersioned(data) {
+		return nil, fmt.Errorf("the database uses the version 1 format; run 'ung security upgrade' first")
+	}
+	password, err := db.GetDatabasePassword()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password: %w", err)
+	}
+	keyring, _, err := db.OpenKeyring(data, db.SlotPassword, []byte(password))
+	if err != nil {
+		db.ClearPasswordCache()
+		return nil, err
+	}
+	return keyring, nil
+}
+
+func runSecurityUpgrade(cmd *cobra.Command, args []string) {
+	fmt.Println("🔒 Upgrade Encrypted Database")
+
+	if upgradeKDF != "" && upgradeKDF != db.KDFPBKDF2 && upgradeKDF != db.KDFArgon2id {
+		fmt.Printf("❌ Unknown --kdf %q (use %s or %s)\n", upgradeKDF, db.KDFPBKDF2, db.KDFArgon2id)
+		return
+	}
+
+	encryptedPath, data, unlock, err := readEncryptedDatabase()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer unlock()
+
+	password, err := db.GetDatabasePassword()
+	if err != nil {
+		fmt.Printf("❌ Failed to get password: %v\n", err)
+		return
+	}
+	kdf := upgradeKDF
+	if kdf == "" {
+		kdf = db.DefaultKDF()
+	}
+
+	var output []byte
+	if db.IsVersioned(data) {
+		keyring, _, err := db.OpenKeyring(data, db.SlotPassword, []byte(password))
+		if err != nil {
+			fmt.Printf("❌ Failed to unlock: %v\n", err)
+			return
+		}
+		if slot, _ := keyring.Slot(db.SlotPassword); upgradeKDF == "" || slot.KDF == upgradeKDF {
+			fmt.Printf("✅ Database already uses version %d (%s)\n", db.FileVersion(data), slot.Describe())
+			return
+		}
+		fmt.Printf("\n🔄 Switching password key derivation to %s...\n", kdf)
+		if err := keyring.SetPassword(password, kdf); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		output, err = keyring.Rewrap(data)
+		if err != nil {
+			fmt.Printf("❌ Failed to rewrap key: %v\n", err)
+			return
+		}
+	} else {
+		_, plaintext, err := db.UnlockData(data, password)
+		if err != nil {
+			fmt.Printf("❌ Failed to decrypt: %v\n", err)
+			return
+		}
+		fmt.Printf("\n🔄 Converting to version 2 (%s)...\n", kdf)
+		keyring, err := db.NewKeyring()
+		if err == nil {
+			err = keyring.SetPassword(password, kdf)
+		}
+		if err == nil {
+			output, err = keyring.Seal(plaintext)
+		}
+		if err != nil {
+			fmt.Printf("❌ Failed to encrypt: %v\n", err)
+			return
+		}
+	}
+
+	if err := db.WriteFileAtomic(encryptedPath, output, 0600); err != nil {
+		fmt.Printf("❌ Failed to write encrypted database: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Database upgraded")
+	fmt.Println("\n💡 Run 'ung security recovery-key' to create a recovery key")
+}
+
+func runSecurityRecoveryKey(cmd *cobra.Command, args []string) {
+	fmt.Println("🔑 Generate Recovery Key")
+
+	encryptedPath, data, unlock, err := readEncryptedDatabase()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer unlock()
+
+	keyring, err := openKeyringWithPassword(data)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	_, replaced := keyring.Slot(db.SlotRecovery)
+
+	recoveryKey, err := keyring.SetRecoveryKey()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	output, err := keyring.Rewrap(data)
+	if err != nil {
+		fmt.Printf("❌ Failed to rewrap key: %v\n", err)
+		return
+	}
+	if err := db.WriteFileAtomic(encryptedPath, output, 0600); err != nil {
+		fmt.Printf("❌ Failed to write encrypted database: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n✅ Recovery key created")
+	fmt.Printf("\n    %s\n\n", recoveryKey)
+	fmt.Println("⚠️  This key is shown only once. Write it down or print it and keep it")
+	fmt.Println("   somewhere safe: anyone with it can read your database.")
+	if replaced {
+		fmt.Println("   The previous recovery key no longer works.")
+	}
+	fmt.Println("\n💡 If you forget your password, run 'ung security recover'")
+}
+
+func runSecurityRecover(cmd *cobra.Command, args []string) {
+	fmt.Println("🔑 Recover Database Access")
+
+	encryptedPath, data, unlock, err := readEncryptedDatabase()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer unlock()
+
+	if !db.IsVersioned(data) {
+		fmt.Println("❌ Version 1 databases have no recovery key")
+		return
+	}
+
+	input, err := db.PromptSecret("Enter recovery key: ")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	secret, ok := db.ParseRecoveryKey(input)
+	if !ok {
+		fmt.Println("❌ That doesn't look like a recovery key (expected groups like ABCD-EFGH-...)")
+		return
+	}
+	keyring, _, err := db.OpenKeyring(data, db.SlotRecovery, secret)
+	if err != nil {
+		fmt.Printf("❌ Failed to unlock: %v\n", err)
+		return
+	}
+
+	newPassword, err := db.PromptSecret("Enter new password: ")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	confirmPassword, err := db.PromptSecret("Confirm new password: ")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if newPassword != confirmPassword {
+		fmt.Println("❌ Passwords don't match")
+		return
+	}
+
+	kdf := db.DefaultKDF()
+	if slot, ok := keyring.Slot(db.SlotPassword); ok {
+		kdf = slot.KDF
+	}
+	if err := keyring.SetPassword(newPassword, kdf); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	output, err := keyring.Rewrap(data)
+	if err != nil {
+		fmt.Printf("❌ Failed to rewrap key: %v\n", err)
+		return
+	}
+	if err := db.WriteFileAtomic(encryptedPath, output, 0600); err != nil {
+		fmt.Printf("❌ Failed to write encrypted database: %v\n", err)
+		return
+	}
+	db.ClearPasswordCache()
+
+	fmt.Println("✅ New password set")
+	fmt.Println("   Your recovery key still works; run 'ung security recovery-key' to replace it")
+	rewrapLinkedStores(keyring, "", newPassword)
+
+	if db.KeychainAvailable() && db.HasPasswordInKeychain() {
+		if err := db.SavePasswordToKeychain(newPassword); err != nil {
+			fmt.Printf("⚠️  Failed to update keychain: %v\n", err)
+		} else {
+			fmt.Printf("✅ Updated password in %s\n", db.GetKeychainPlatformName())
+		}
+	}
+}
+
+func runSecurityKeyFileCreate(cmd *cobra.Command, args []string) {
+	fmt.Println("🔑 Create Key File")
+
+	path := expandPathForUser(args[0])
+	if fileExists(path) {
+		fmt.Printf("❌ %s already exists\n", path)
+		return
+	}
+
+	encryptedPath, data, unlock, err := readEncryptedDatabase()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer unlock()
+
+	keyring, err := openKeyringWithPassword(data)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	secret, err := keyring.SetKeyFile()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	output, err := keyring.Rewrap(data)
+	if err != nil {
+		fmt.Printf("❌ Failed to rewrap key: %v\n", err)
+		return
+	}
+
+	// Write the key file before the database so the new slot is never unusable
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		fmt.Printf("❌ Failed to create directory: %v\n", err)
+		return
+	}
+	if err := db.WriteFileAtomic(path, []byte(hex.EncodeToString(secret)+"\n"), 0600); err != nil {
+		fmt.Printf("❌ Failed to write key file: %v\n", err)
+		return
+	}
+	if err := db.WriteFileAtomic(encryptedPath, output, 0600); err != nil {
+		os.Remove(path)
+		fmt.Printf("❌ Failed to write encrypted database: %v\n", err)
+		return
+	}
+
+	cfg, _ := config.Load()
+	cfg.Security.KeyFile = path
+	if err := config.SaveActive(cfg); err != nil {
+		fmt.Printf("⚠️  Failed to save config: %v\n", err)
+		fmt.Printf("   Set UNG_KEY_FILE=%s to use the key file\n", path)
+	}
+
+	fmt.Println("✅ Key file created")
+	fmt.Printf("   Key file: %s\n", path)
+	fmt.Println("\n⚠️  Anyone who can read this file can read your database.")
+	fmt.Println("   Your password keeps working as before.")
+}
+
+func runSecurityKeyFileRemove(cmd *cobra.Command, args []string) {
+	fmt.Println("🔑 Remove Key File")
+
+	encryptedPath, data, unlock, err := readEncryptedDatabase()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer unlock()
+
+	keyring, err := openKeyringWithPassword(data)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if !keyring.RemoveSlot(db.SlotKeyFile) {
+		fmt.Println("✅ No key file is set up")
+		return
+	}
+	output, err := keyring.Rewrap(data)
+	if err != nil {
+		fmt.Printf("❌ Failed to rewrap key: %v\n", err)
+		return
+	}
+	if err := db.WriteFileAtomic(encryptedPath, output, 0600); err != nil {
+		fmt.Printf("❌ Failed to write encrypted database: %v\n", err)
+		return
+	}
+
+	cfg, _ := config.Load()
+	keyFile := cfg.Security.KeyFile
+	if keyFile != "" {
+		cfg.Security.KeyFile = ""
+		if err := config.SaveActive(cfg); err != nil {
+			fmt.Printf("⚠️  Failed to save config: %v\n", err)
+		}
+	}
+
+	fmt.Println("✅ Key file no longer unlocks the database")
+	if keyFile != "" {
+		fmt.Printf("   You can delete %s\n", keyFile)
+	}
+}
+
+// securityFormatStatus prints the encrypted file's format and key slots
+func securityFormatStatus(encryptedPath string) {
+	data, err := os.ReadFile(encryptedPath)
+	if err != nil {
+		return
+	}
+	version := db.FileVersion(data)
+	fmt.Printf("Format:    version %d\n", version)
+	if version == 1 {
+		fmt.Println("Algorithm: AES-256-GCM with PBKDF2")
+		fmt.Println("\n💡 Run 'ung security upgrade' to enable recovery keys and key files")
+		return
+	}
+
+	fmt.Println("Algorithm: AES-256-GCM with a random data key")
+	slots, err := db.ReadKeySlots(data)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return
+	}
+	hasRecovery := false
+	for _, slot := range slots {
+		name := map[string]string{
+			db.SlotPassword: "Password",
+			db.SlotRecovery: "Recovery key",
+			db.SlotKeyFile:  "Key file",
+		}[slot.Type]
+		if name == "" {
+			name = slot.Type
+		}
+		fmt.Printf("   • %-13s %s\n", name, slot.Describe())
+		hasRecovery = hasRecovery || slot.Type == db.SlotRecovery
+	}
+	if !hasRecovery {
+		fmt.Println("\n💡 Run 'ung security recovery-key' so a forgotten password isn't fatal")
+	}
+}
+
+// rewrapLinkedStores moves encrypted receipts, the backup repository key and
+// the keys of known sync folders to newPassword. Stores linked to the database
+// key are rewrapped with it; older ones need oldPassword, which is empty after
+// a recovery, and are reported when they can't be unlocked.
+func rewrapLinkedStores(database *db.Keyring, oldPassword, newPassword string) (failed []string) {
+	rewrap := func(sealed []byte) ([]byte, error) {
+		return db.RewrapLinked(sealed, database, oldPassword, newPassword)
+	}
+
+	n, locked, err := receipts.Rewrap(config.GetReceiptsDir(), rewrap)
+	if err != nil {
+		failed = append(failed, fmt.Sprintf("receipts: %v", err))
+	}
+	failed = append(failed, locked...)
+	if n > 0 {
+		fmt.Printf("✅ Re-encrypted %d receipts\n", n)
+	}
+
+	if dir := backupDir(); backup.Exists(dir) {
+		if err := backup.RewrapKey(dir, rewrap); err != nil {
+			failed = append(failed, fmt.Sprintf("backup repository %s: %v", dir, err))
+		}
+	}
+	for _, dir := range knownSyncFolders() {
+		if !replica.FolderExists(dir) {
+			continue
+		}
+		if err := replica.RewrapFolderKey(dir, rewrap); err != nil {
+			failed = append(failed, fmt.Sprintf("sync folder %s: %v", dir, err))
+			continue
+		}
+		fmt.Printf("✅ Sync folder %s now uses the new password (other devices need it too)\n", dir)
+	}
+
+	if len(failed) > 0 {
+		fmt.Println("⚠️  These are still locked to the old password:")
+		for _, f := range failed {
+			fmt.Printf("   %s\n", f)
+		}
+	}
+	return failed
+}
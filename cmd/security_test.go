@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Andriiklymiuk/ung/internal/backup"
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/internal/receipts"
+)
+
+func TestChangePasswordRewrapsReceiptsAndBackups(t *testing.T) {
+	setupTestDB(t)
+	db.Close()
+
+	// Switch the test database to encryption with encrypted receipts
+	configPath := filepath.Join(os.Getenv("HOME"), ".ung", "config.yaml")
+	f, err := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open config: %v", err)
+	}
+	f.WriteString("security:\n  encrypt_database: true\n  encrypt_receipts: true\n")
+	f.Close()
+	config.Reload()
+
+	db.SetDatabasePassword("old-password")
+	defer db.ClearPasswordCache()
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("failed to open encrypted database: %v", err)
+	}
+
+	src := filepath.Join(t.TempDir(), "receipt.pdf")
+	if err := os.WriteFile(src, []byte("%PDF-1.4\nreceipt\n%%EOF\n"), 0644); err != nil {
+		t.Fatalf("failed to write receipt: %v", err)
+	}
+	receiptPath, err := attachReceipt(createReceiptTestExpense(t, "Hosting", "DigitalOcean"), src, false)
+	if err != nil {
+		t.Fatalf("attachReceipt() error: %v", err)
+	}
+	if !receipts.IsEncrypted(receiptPath) {
+		t.Fatalf("expected an encrypted receipt, got %s", receiptPath)
+	}
+
+	repo, err := openBackupRepository(true)
+	if err != nil {
+		t.Fatalf("openBackupRepository() error: %v", err)
+	}
+	snap, err := backup.Create(repo, db.DB, backup.Options{SkipFiles: true})
+	if err != nil {
+		t.Fatalf("backup.Create() error: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+	db.ClearPasswordCache()
+
+	if err := changeDatabasePassword(db.GetDBPath()+".encrypted", "old-password", "new-password"); err != nil {
+		t.Fatalf("changeDatabasePassword() error: %v", err)
+	}
+
+	// With no database open, only the new password unlocks the receipt and the backups
+	if _, err := receipts.Load(receiptPath, staticPassword("old-password")); err == nil {
+		t.Error("expected the old password to no longer open the receipt")
+	}
+	if _, err := receipts.Load(receiptPath, staticPassword("new-password")); err != nil {
+		t.Errorf("expected the new password to open the receipt, got %v", err)
+	}
+	if _, err := backup.Open(repo.Dir(), staticPassword("old-password")); err == nil {
+		t.Error("expected the old password to no longer open the backup repository")
+	}
+	reopened, err := backup.Open(repo.Dir(), staticPassword("new-password"))
+	if err != nil {
+		t.Fatalf("expected the new password to open the backup repository, got %v", err)
+	}
+	if _, err := reopened.LoadSnapshot(snap.ID); err != nil {
+		t.Errorf("failed to read snapshot after password change: %v", err)
+	}
+
+	// With the database open, its key unlocks both without asking for a password
+	db.SetDatabasePassword("new-password")
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if _, err := receipts.Load(receiptPath, failPassword(t)); err != nil {
+		t.Errorf("expected the database key to open the receipt, got %v", err)
+	}
+	if _, err := backup.Open(repo.Dir(), failPassword(t)); err != nil {
+		t.Errorf("expected the database key to open the backup repository, got %v", err)
+	}
+}
+
+// staticPassword returns a password callback that always answers pw
+func staticPassword(pw string) func() (string, error) {
+	return func() (string, error) { return pw, nil }
+}
+
+// failPassword returns a password callback that fails the test when called
+func failPassword(t *testing.T) func() (string, error) {
+	return func() (string, error) {
+		t.Error("password was asked for")
+		return "", os.ErrPermission
+	}
+}
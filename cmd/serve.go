@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/internal/web"
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Open a web dashboard of your local data",
+	Long: `Serve a small web dashboard of the local database: revenue and expenses by
+month, unbilled time, open invoices and their PDFs.
+
+It listens on localhost only, so nothing leaves your machine, and doesn't need
+the API server or an account. The printed URL holds a token that is generated
+each time unless you pass --token; the browser keeps it in a cookie. Use
+--read-only to turn off marking invoices as paid from the browser.
+
+Other web pages can't use the dashboard: it only answers requests for
+localhost, and changes must come from the dashboard itself. With --host
+0.0.0.0 it answers any host name, so anyone on your network with the token
+can open it.
+
+The database stays open while the server runs. An encrypted database is only
+locked while a request is answered, so other ung commands keep working, and
+their changes show up on the next refresh.
+
+Examples:
+  ung serve                           Dashboard at http://localhost:8080
+  ung serve --port 9000 --read-only   Read-only on another port
+  ung serve --token s3cret            Use http://localhost:8080/?token=s3cret`,
+	RunE: runServe,
+}
+
+var (
+	serveHost     string
+	servePort     int
+	serveToken    string
+	serveReadOnly bool
+)
+
+func init() {
+	serveCmd.Flags().StringVar(&serveHost, "host", "127.0.0.1", "Address to listen on")
+	serveCmd.Flags().IntVarP(&servePort, "port", "p", 8080, "Port to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Token to open the dashboard with (generated by default)")
+	serveCmd.Flags().BoolVar(&serveReadOnly, "read-only", false, "Don't allow any changes from the browser")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if serveToken == "" {
+		token, err := generateToken()
+		if err != nil {
+			return err
+		}
+		serveToken = token
+	}
+
+	server := web.New(db.GormDB, web.Options{
+		Token:    serveToken,
+		ReadOnly: serveReadOnly,
+		AnyHost:  !isLoopback(serveHost),
+		AfterWrite: func() error {
+			// Chain the audit entry now, the server may run for days
+			if _, err := audit.Seal(db.DB); err != nil {
+				return err
+			}
+			return db.Flush()
+		},
+		Acquire: db.Acquire,
+		Release: db.Release,
+	})
+	if err := db.Share(); err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(serveHost, strconv.Itoa(servePort))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	link := url.URL{Scheme: "http", Host: addr, Path: "/"}
+	if isLoopback(serveHost) {
+		link.Host = net.JoinHostPort("localhost", strconv.Itoa(servePort))
+	}
+	link.RawQuery = url.Values{"token": {serveToken}}.Encode()
+
+	fmt.Printf("✓ Dashboard for %s\n", config.GetDatabasePath())
+	fmt.Printf("  %s\n", link.String())
+	if serveReadOnly {
+		fmt.Println("  Read-only: invoices can't be changed from the browser")
+	}
+	if !isLoopback(serveHost) {
+		fmt.Printf("⚠️  Listening on %s, anyone on your network with the token can open it\n", serveHost)
+	}
+	fmt.Println("💡 Press Ctrl+C to stop")
+
+	httpServer := &http.Server{
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		// Ctrl+C: stop serving so the database is saved and closed on the main goroutine
+		<-cmd.Context().Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// generateToken returns a random token for HTTP clients
+func generateToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// isLoopback reports whether host only accepts connections from this machine
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
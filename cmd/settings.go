@@ -8,7 +8,7 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/spf13/cobra"
 )
 
@@ -23,19 +23,6 @@ Commands:
   get       Get a setting value
   set       Set a setting value
   ls        List all settings`,
-	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Run parent's PersistentPreRunE if exists
-		if parent := cmd.Parent(); parent != nil && parent.PersistentPreRunE != nil {
-			if err := parent.PersistentPreRunE(parent, args); err != nil {
-				return err
-			}
-		}
-		// Migrate the schema
-		if db.GormDB != nil {
-			db.GormDB.AutoMigrate(&models.UserSettings{})
-		}
-		return nil
-	},
 }
 
 var settingsTargetCmd = &cobra.Command{
@@ -6,8 +6,8 @@ import (
 
 	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/internal/repository"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
@@ -280,7 +280,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 
 	if !setupSkipGoal {
 		// Check for existing goal
-		var existingGoal IncomeGoal
+		var existingGoal models.IncomeGoal
 		year := time.Now().Year()
 		month := int(time.Now().Month())
 
@@ -332,7 +332,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 				var amount float64
 				fmt.Sscanf(goalAmount, "%f", &amount)
 
-				goal := IncomeGoal{
+				goal := models.IncomeGoal{
 					Amount: amount,
 					Period: "monthly",
 					Year:   year,
@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/Andriiklymiuk/ung/internal/backup"
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -18,16 +24,30 @@ var syncCmd = &cobra.Command{
 	Short: "Backup and sync your data",
 	Long: `Backup and synchronize your UNG data.
 
+Backups are incremental snapshots of every table and of the files the data
+references (invoice and contract PDFs, receipts, logos). Unchanged tables and
+files are stored once across snapshots. Repositories are encrypted when the
+database is encrypted (or with --encrypt / backup.encrypt in config).
+
 Commands:
-  backup     Create a backup of all data
-  restore    Restore data from a backup
-  ls         List available backups
+  backup     Create a snapshot of all data
+  restore    Restore data from a snapshot
+  ls         List snapshots
+  verify     Check snapshots for missing or corrupted data
+  prune      Remove snapshots outside the retention policy
+  push       Send local changes to a shared folder or another database
+  pull       Merge changes from a shared folder or another database
+  conflicts  Review fields edited on two devices
 
 Examples:
-  ung sync backup                Create backup
-  ung sync backup --output ~/    Save backup to custom location
-  ung sync restore               Restore from latest backup
-  ung sync ls                    List backups`,
+  ung sync backup                          Create snapshot
+  ung sync backup --output ~/Dropbox/ung   Back up to another repository
+  ung sync backup --keep-daily 7 --keep-monthly 12
+  ung sync restore                         Pick a snapshot to restore
+  ung sync restore --at 2024-03-01 --only invoices
+  ung sync ls                              List snapshots
+  ung sync pull ~/Dropbox/ung-sync         Merge changes from other devices
+  ung sync push ~/Dropbox/ung-sync         Share this device's changes`,
 	RunE: runSyncInteractive,
 }
 
@@ -38,9 +58,22 @@ var syncBackupCmd = &cobra.Command{
 }
 
 var syncRestoreCmd = &cobra.Command{
-	Use:   "restore [file]",
+	Use:   "restore [snapshot|file.json]",
 	Short: "Restore data from a backup",
-	RunE:  runSyncRestore,
+	Long: `Restore data from a snapshot (or a legacy ung_backup_*.json file).
+
+The snapshot is verified before anything is written, and the restore runs in
+one transaction that is checked before it commits. By default rows are merged
+by ID into the existing data; --replace makes the restored tables match the
+snapshot exactly.
+
+Examples:
+  ung sync restore 20240301T090000Z-ab12         Restore a snapshot
+  ung sync restore --at 2024-03-01               Latest snapshot on or before a date
+  ung sync restore --only invoices,clients       Restore some tables only
+  ung sync restore --only expenses --files       Also restore missing receipts`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSyncRestore,
 }
 
 var syncListCmd = &cobra.Command{
@@ -50,23 +83,70 @@ var syncListCmd = &cobra.Command{
 	RunE:    runSyncList,
 }
 
+var syncVerifyCmd = &cobra.Command{
+	Use:   "verify [snapshot]",
+	Short: "Check backups for missing or corrupted data",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runSyncVerify,
+}
+
+var syncPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove snapshots outside the retention policy",
+	Long: `Remove snapshots that the retention policy doesn't keep, then delete data
+no remaining snapshot uses. The policy comes from the flags or from
+backup.keep_* in config.
+
+Example:
+  ung sync prune --keep-last 5 --keep-daily 7 --keep-weekly 4 --keep-monthly 12`,
+	RunE: runSyncPrune,
+}
+
 var (
-	syncOutputPath string
-	syncForce      bool
+	syncOutputPath  string
+	syncForce       bool
+	syncEncrypt     bool
+	syncNoFiles     bool
+	syncOnly        []string
+	syncAt          string
+	syncReplace     bool
+	syncFiles       bool
+	syncVerifyAll   bool
+	syncDryRun      bool
+	syncKeepLast    int
+	syncKeepDaily   int
+	syncKeepWeekly  int
+	syncKeepMonthly int
 )
 
 func init() {
-	syncBackupCmd.Flags().StringVarP(&syncOutputPath, "output", "o", "", "Output directory for backup")
+	syncCmd.PersistentFlags().StringVarP(&syncOutputPath, "output", "o", "", "Backup repository directory")
+	syncBackupCmd.Flags().BoolVar(&syncEncrypt, "encrypt", false, "Encrypt a new repository with the database password")
+	syncBackupCmd.Flags().BoolVar(&syncNoFiles, "no-files", false, "Don't back up referenced files (PDFs, receipts)")
 	syncRestoreCmd.Flags().BoolVarP(&syncForce, "force", "f", false, "Force restore without confirmation")
+	syncRestoreCmd.Flags().StringSliceVar(&syncOnly, "only", nil, "Only restore these tables (e.g. invoices,clients)")
+	syncRestoreCmd.Flags().StringVar(&syncAt, "at", "", "Restore the latest snapshot on or before this date")
+	syncRestoreCmd.Flags().BoolVar(&syncReplace, "replace", false, "Replace table contents instead of merging")
+	syncRestoreCmd.Flags().BoolVar(&syncFiles, "files", false, "Restore referenced files missing on disk")
+	syncVerifyCmd.Flags().BoolVar(&syncVerifyAll, "all", false, "Verify every snapshot")
+	syncPruneCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Show what would be removed")
+	for _, c := range []*cobra.Command{syncBackupCmd, syncPruneCmd} {
+		c.Flags().IntVar(&syncKeepLast, "keep-last", 0, "Keep the N most recent snapshots")
+		c.Flags().IntVar(&syncKeepDaily, "keep-daily", 0, "Keep one snapshot per day for N days")
+		c.Flags().IntVar(&syncKeepWeekly, "keep-weekly", 0, "Keep one snapshot per week for N weeks")
+		c.Flags().IntVar(&syncKeepMonthly, "keep-monthly", 0, "Keep one snapshot per month for N months")
+	}
 
 	syncCmd.AddCommand(syncBackupCmd)
 	syncCmd.AddCommand(syncRestoreCmd)
 	syncCmd.AddCommand(syncListCmd)
+	syncCmd.AddCommand(syncVerifyCmd)
+	syncCmd.AddCommand(syncPruneCmd)
 
 	rootCmd.AddCommand(syncCmd)
 }
 
-// BackupData represents all data in the system
+// BackupData is the legacy single-file JSON backup format, still accepted by restore
 type BackupData struct {
 	Version           string                    `json:"version"`
 	CreatedAt         time.Time                 `json:"created_at"`
@@ -81,6 +161,19 @@ type BackupData struct {
 	RecurringInvoices []models.RecurringInvoice `json:"recurring_invoices"`
 }
 
+// tableAliases maps friendly names accepted by --only to table names
+var tableAliases = map[string]string{
+	"time":       "tracking_sessions",
+	"sessions":   "tracking_sessions",
+	"recipients": "invoice_recipients",
+	"line_items": "invoice_line_items",
+	"items":      "invoice_line_items",
+	"recurring":  "recurring_invoices",
+	"goals":      "income_goals",
+	"settings":   "user_settings",
+	"tasks":      "gig_tasks",
+}
+
 func runSyncInteractive(cmd *cobra.Command, args []string) error {
 	var action string
 
@@ -92,6 +185,7 @@ func runSyncInteractive(cmd *cobra.Command, args []string) error {
 					huh.NewOption("Create Backup", "backup"),
 					huh.NewOption("Restore from Backup", "restore"),
 					huh.NewOption("List Backups", "list"),
+					huh.NewOption("Verify Backups", "verify"),
 				).
 				Value(&action),
 		),
@@ -108,145 +202,347 @@ func runSyncInteractive(cmd *cobra.Command, args []string) error {
 		return runSyncRestore(cmd, args)
 	case "list":
 		return runSyncList(cmd, args)
+	case "verify":
+		return runSyncVerify(cmd, args)
 	}
 
 	return nil
 }
 
-func runSyncBackup(cmd *cobra.Command, args []string) error {
-	// Determine output path
-	outputDir := syncOutputPath
-	if outputDir == "" {
-		outputDir = filepath.Join(os.Getenv("HOME"), ".ung", "backups")
+// backupDir returns the backup repository directory
+func backupDir() string {
+	if syncOutputPath != "" {
+		return expandPathForUser(syncOutputPath)
 	}
-
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+	if cfg, _ := config.Load(); cfg != nil && cfg.Backup.Dir != "" {
+		return expandPathForUser(cfg.Backup.Dir)
 	}
+	return filepath.Join(os.Getenv("HOME"), ".ung", "backups")
+}
 
-	// Collect all data
-	backup := BackupData{
-		Version:   "1.0",
-		CreatedAt: time.Now(),
+// openBackupRepository opens the backup repository, creating it when create is set
+func openBackupRepository(create bool) (*backup.Repository, error) {
+	dir := backupDir()
+	if backup.Exists(dir) {
+		return backup.Open(dir, db.GetDatabasePassword)
 	}
-
-	// Load companies
-	if err := db.GormDB.Find(&backup.Companies).Error; err != nil {
-		return fmt.Errorf("failed to load companies: %w", err)
+	if !create {
+		return nil, fmt.Errorf("no backups in %s. Create one with: ung sync backup", dir)
 	}
 
-	// Load clients
-	if err := db.GormDB.Find(&backup.Clients).Error; err != nil {
-		return fmt.Errorf("failed to load clients: %w", err)
+	cfg, _ := config.Load()
+	password := ""
+	if syncEncrypt || cfg.Backup.Encrypt || cfg.Security.EncryptDatabase || db.IsEncryptedInMemory() {
+		var err error
+		if password, err = db.GetDatabasePassword(); err != nil {
+			return nil, fmt.Errorf("failed to get password: %w", err)
+		}
 	}
-
-	// Load contracts
-	if err := db.GormDB.Find(&backup.Contracts).Error; err != nil {
-		return fmt.Errorf("failed to load contracts: %w", err)
+	repo, err := backup.Init(dir, password)
+	if err != nil {
+		return nil, err
 	}
+	if repo.Encrypted() {
+		fmt.Printf("✓ Created encrypted backup repository in %s\n", dir)
+	} else {
+		fmt.Printf("✓ Created backup repository in %s\n", dir)
+	}
+	return repo, nil
+}
 
-	// Load invoices
-	if err := db.GormDB.Find(&backup.Invoices).Error; err != nil {
-		return fmt.Errorf("failed to load invoices: %w", err)
+// retentionPolicy returns the policy from flags, falling back to config
+func retentionPolicy() backup.Policy {
+	policy := backup.Policy{KeepLast: syncKeepLast, KeepDaily: syncKeepDaily, KeepWeekly: syncKeepWeekly, KeepMonthly: syncKeepMonthly}
+	if policy.IsZero() {
+		if cfg, _ := config.Load(); cfg != nil {
+			b := cfg.Backup
+			policy = backup.Policy{KeepLast: b.KeepLast, KeepDaily: b.KeepDaily, KeepWeekly: b.KeepWeekly, KeepMonthly: b.KeepMonthly}
+		}
 	}
+	return policy
+}
 
-	// Load invoice recipients
-	if err := db.GormDB.Find(&backup.InvoiceRecipients).Error; err != nil {
-		return fmt.Errorf("failed to load invoice recipients: %w", err)
+func runSyncBackup(cmd *cobra.Command, args []string) error {
+	repo, err := openBackupRepository(true)
+	if err != nil {
+		return err
 	}
 
-	// Load invoice line items
-	if err := db.GormDB.Find(&backup.InvoiceLineItems).Error; err != nil {
-		return fmt.Errorf("failed to load invoice line items: %w", err)
+	snap, err := backup.Create(repo, db.DB, backup.Options{SkipFiles: syncNoFiles})
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
 	}
 
-	// Load tracking sessions
-	if err := db.GormDB.Unscoped().Find(&backup.TrackingSessions).Error; err != nil {
-		return fmt.Errorf("failed to load tracking sessions: %w", err)
+	// Summary
+	fmt.Println("\n✓ Backup created successfully!")
+	fmt.Printf("  Snapshot: %s\n", snap.ID)
+	fmt.Printf("  Location: %s\n", repo.Dir())
+	fmt.Printf("  Data:     %d rows in %d tables, %d files\n", snap.Rows(), len(snap.Tables), len(snap.Files))
+	fmt.Printf("  Added:    %s (unchanged data is shared with earlier snapshots)\n", formatBytes(snap.AddedBytes))
+
+	policy := retentionPolicy()
+	if !policy.IsZero() {
+		return pruneSnapshots(repo, policy, false)
 	}
+	return nil
+}
 
-	// Load expenses
-	if err := db.GormDB.Find(&backup.Expenses).Error; err != nil {
-		return fmt.Errorf("failed to load expenses: %w", err)
+// pruneSnapshots forgets snapshots outside the policy and removes unused data
+func pruneSnapshots(repo *backup.Repository, policy backup.Policy, dryRun bool) error {
+	snapshots, err := repo.Snapshots()
+	if err != nil {
+		return err
+	}
+	keep, forget := policy.Apply(snapshots)
+	if len(forget) == 0 {
+		fmt.Printf("\n✓ Retention: keeping all %d snapshots\n", len(keep))
+		return nil
 	}
 
-	// Load recurring invoices
-	if err := db.GormDB.Find(&backup.RecurringInvoices).Error; err != nil {
-		return fmt.Errorf("failed to load recurring invoices: %w", err)
+	fmt.Printf("\nRetention: keeping %d snapshots, removing %d\n", len(keep), len(forget))
+	for _, s := range forget {
+		prefix := "[Remove]"
+		if dryRun {
+			prefix = "[Preview]"
+		}
+		fmt.Printf("  %s %s  %s\n", prefix, s.ID, s.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	if dryRun {
+		fmt.Println("\nDry run - nothing was removed")
+		return nil
 	}
 
-	// Generate filename with timestamp
-	filename := filepath.Join(outputDir, fmt.Sprintf("ung_backup_%s.json", time.Now().Format("2006-01-02_150405")))
+	if err := repo.Forget(forget); err != nil {
+		return err
+	}
+	objects, freed, err := repo.Prune()
+	if err != nil {
+		return fmt.Errorf("failed to remove unused data: %w", err)
+	}
+	fmt.Printf("✓ Removed %d snapshots, freed %s (%d objects)\n", len(forget), formatBytes(freed), objects)
+	return nil
+}
 
-	// Write backup file
-	file, err := os.Create(filename)
+func runSyncPrune(cmd *cobra.Command, args []string) error {
+	policy := retentionPolicy()
+	if policy.IsZero() {
+		return fmt.Errorf("no retention policy: use --keep-last/--keep-daily/--keep-weekly/--keep-monthly or backup.keep_* in config")
+	}
+	repo, err := openBackupRepository(false)
 	if err != nil {
-		return fmt.Errorf("failed to create backup file: %w", err)
+		return err
 	}
-	defer file.Close()
+	return pruneSnapshots(repo, policy, syncDryRun)
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(backup); err != nil {
-		return fmt.Errorf("failed to write backup: %w", err)
+// resolveTables maps --only names to the snapshot's table names
+func resolveTables(snap *backup.Snapshot, names []string) ([]string, error) {
+	var tables []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		candidates := []string{name, name + "s", tableAliases[name]}
+		found := ""
+		for _, c := range candidates {
+			if _, ok := snap.Table(c); ok && c != "" {
+				found = c
+				break
+			}
+		}
+		if found == "" {
+			var available []string
+			for _, t := range snap.Tables {
+				available = append(available, t.Name)
+			}
+			return nil, fmt.Errorf("unknown table %q; snapshot has: %s", name, strings.Join(available, ", "))
+		}
+		if !slices.Contains(tables, found) {
+			tables = append(tables, found)
+		}
 	}
+	return tables, nil
+}
 
-	// Summary
-	fmt.Println("\n✓ Backup created successfully!")
-	fmt.Printf("  Location: %s\n", filename)
-	fmt.Println("\n  Data backed up:")
-	fmt.Printf("    • %d companies\n", len(backup.Companies))
-	fmt.Printf("    • %d clients\n", len(backup.Clients))
-	fmt.Printf("    • %d contracts\n", len(backup.Contracts))
-	fmt.Printf("    • %d invoices\n", len(backup.Invoices))
-	fmt.Printf("    • %d time entries\n", len(backup.TrackingSessions))
-	fmt.Printf("    • %d expenses\n", len(backup.Expenses))
-	fmt.Printf("    • %d recurring invoices\n", len(backup.RecurringInvoices))
+// snapshotAt returns the newest snapshot taken on or before the given date
+func snapshotAt(snapshots []*backup.Snapshot, at string) (*backup.Snapshot, error) {
+	cutoff, err := time.ParseInLocation("2006-01-02 15:04", at, time.Local)
+	if err != nil {
+		day, derr := parseDate(at)
+		if derr != nil {
+			return nil, fmt.Errorf("invalid --at %q (use YYYY-MM-DD or \"YYYY-MM-DD HH:MM\")", at)
+		}
+		cutoff = time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, time.Local)
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if !snapshots[i].CreatedAt.After(cutoff) {
+			return snapshots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no snapshot on or before %s", at)
+}
 
-	return nil
+// legacyBackupFiles lists ung_backup_*.json files in the backup directory, newest first
+func legacyBackupFiles(dir string) []os.DirEntry {
+	entries, _ := os.ReadDir(dir)
+	var files []os.DirEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].IsDir() && filepath.Ext(entries[i].Name()) == ".json" && entries[i].Name() != "repo.json" {
+			files = append(files, entries[i])
+		}
+	}
+	return files
 }
 
 func runSyncRestore(cmd *cobra.Command, args []string) error {
-	var backupFile string
+	if len(args) > 0 && strings.HasSuffix(args[0], ".json") {
+		return restoreLegacyBackup(expandPathForUser(args[0]))
+	}
 
-	if len(args) > 0 {
-		backupFile = args[0]
-	} else {
-		// List available backups
-		backupDir := filepath.Join(os.Getenv("HOME"), ".ung", "backups")
-		entries, err := os.ReadDir(backupDir)
-		if err != nil || len(entries) == 0 {
-			return fmt.Errorf("no backups found. Create one with: ung sync backup")
+	dir := backupDir()
+	var repo *backup.Repository
+	var snapshots []*backup.Snapshot
+	if backup.Exists(dir) {
+		var err error
+		if repo, err = backup.Open(dir, db.GetDatabasePassword); err != nil {
+			return err
+		}
+		if snapshots, err = repo.Snapshots(); err != nil {
+			return err
 		}
+	}
 
+	var snap *backup.Snapshot
+	switch {
+	case len(args) > 0:
+		if repo == nil {
+			return fmt.Errorf("no backups in %s", dir)
+		}
+		var err error
+		if snap, err = repo.LoadSnapshot(args[0]); err != nil {
+			return err
+		}
+	case syncAt != "":
+		var err error
+		if snap, err = snapshotAt(snapshots, syncAt); err != nil {
+			return err
+		}
+	default:
 		var options []huh.Option[string]
-		for i := len(entries) - 1; i >= 0; i-- {
-			entry := entries[i]
-			if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
-				info, _ := entry.Info()
-				label := fmt.Sprintf("%s (%s)", entry.Name(), info.ModTime().Format("Jan 2, 2006 3:04 PM"))
-				options = append(options, huh.NewOption(label, filepath.Join(backupDir, entry.Name())))
-			}
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			s := snapshots[i]
+			label := fmt.Sprintf("%s  %d rows, %d files", s.CreatedAt.Local().Format("Jan 2, 2006 3:04 PM"), s.Rows(), len(s.Files))
+			options = append(options, huh.NewOption(label, s.ID))
+		}
+		for _, entry := range legacyBackupFiles(dir) {
+			info, _ := entry.Info()
+			label := fmt.Sprintf("%s (%s, legacy)", entry.Name(), info.ModTime().Format("Jan 2, 2006 3:04 PM"))
+			options = append(options, huh.NewOption(label, filepath.Join(dir, entry.Name())))
 		}
-
 		if len(options) == 0 {
-			return fmt.Errorf("no backup files found")
+			return fmt.Errorf("no backups found. Create one with: ung sync backup")
 		}
 
+		var choice string
 		form := huh.NewForm(
 			huh.NewGroup(
 				huh.NewSelect[string]().
 					Title("Select Backup to Restore").
 					Options(options...).
-					Value(&backupFile),
+					Value(&choice),
 			),
 		)
-
 		if err := form.Run(); err != nil {
 			return fmt.Errorf("cancelled: %w", err)
 		}
+		if strings.HasSuffix(choice, ".json") {
+			return restoreLegacyBackup(choice)
+		}
+		var err error
+		if snap, err = repo.LoadSnapshot(choice); err != nil {
+			return err
+		}
+	}
+
+	tables, err := resolveTables(snap, syncOnly)
+	if err != nil {
+		return err
+	}
+
+	// Show what will be restored
+	fmt.Printf("\nSnapshot %s (%s):\n", snap.ID, snap.CreatedAt.Local().Format("Jan 2, 2006 3:04 PM"))
+	for _, t := range snap.Tables {
+		if len(tables) == 0 || slices.Contains(tables, t.Name) {
+			fmt.Printf("  • %d %s\n", t.Rows, t.Name)
+		}
+	}
+	if syncFiles {
+		fmt.Printf("  • up to %d files\n", len(snap.Files))
+	}
+
+	fmt.Println("\nVerifying snapshot...")
+	if err := backup.Verify(repo, snap); err != nil {
+		return fmt.Errorf("snapshot failed verification, nothing was restored: %w", err)
+	}
+	fmt.Println("✓ Snapshot verified")
+
+	// Confirm restore
+	if !syncForce {
+		description := "This will merge data with your existing database"
+		if syncReplace {
+			description = "This will REPLACE the contents of these tables"
+		}
+		var confirm bool
+		confirmForm := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title("Restore this backup?").
+					Description(description).
+					Affirmative("Restore").
+					Negative("Cancel").
+					Value(&confirm),
+			),
+		)
+
+		if err := confirmForm.Run(); err != nil || !confirm {
+			fmt.Println("Restore cancelled")
+			return nil
+		}
+	}
+
+	defer audit.Relabel(audit.SourceCLI, audit.SourceRestore)()
+	result, err := backup.Restore(repo, db.DB, snap, backup.RestoreOptions{Tables: tables, Replace: syncReplace, Files: syncFiles})
+	if err != nil {
+		return err
 	}
 
+	fmt.Println("\n✓ Restore completed!")
+	fmt.Println("  Data restored:")
+	names := make([]string, 0, len(result.Tables))
+	for name := range result.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("    • %d %s\n", result.Tables[name], name)
+	}
+	if syncFiles {
+		fmt.Printf("    • %d files\n", result.Files)
+	}
+	for _, name := range result.CreatedTables {
+		fmt.Printf("  ⚠️  Created missing table %s from the snapshot\n", name)
+	}
+	for name, columns := range result.MissingColumns {
+		fmt.Printf("  ⚠️  %s: skipped columns no longer in the schema: %s\n", name, strings.Join(columns, ", "))
+	}
+	return nil
+}
+
+// restoreLegacyBackup restores a single-file JSON backup made by older versions
+func restoreLegacyBackup(backupFile string) error {
+	defer audit.Relabel(audit.SourceCLI, audit.SourceRestore)()
+
 	// Read backup file
 	file, err := os.Open(backupFile)
 	if err != nil {
@@ -364,37 +660,102 @@ func runSyncRestore(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runSyncList(cmd *cobra.Command, args []string) error {
-	backupDir := filepath.Join(os.Getenv("HOME"), ".ung", "backups")
-
-	entries, err := os.ReadDir(backupDir)
+func runSyncVerify(cmd *cobra.Command, args []string) error {
+	repo, err := openBackupRepository(false)
+	if err != nil {
+		return err
+	}
+	snapshots, err := repo.Snapshots()
 	if err != nil {
-		fmt.Println("No backups found. Create one with: ung sync backup")
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots to verify")
 		return nil
 	}
 
+	switch {
+	case len(args) > 0:
+		snap, err := repo.LoadSnapshot(args[0])
+		if err != nil {
+			return err
+		}
+		snapshots = []*backup.Snapshot{snap}
+	case !syncVerifyAll:
+		snapshots = snapshots[len(snapshots)-1:]
+	}
+
+	failed := 0
+	for _, snap := range snapshots {
+		if err := backup.Verify(repo, snap); err != nil {
+			fmt.Printf("[Error] %s: %v\n", snap.ID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("[OK] %s  %d rows, %d files\n", snap.ID, snap.Rows(), len(snap.Files))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d snapshots failed verification", failed, len(snapshots))
+	}
+	fmt.Printf("\n✓ Verified %d snapshots\n", len(snapshots))
+	return nil
+}
+
+func runSyncList(cmd *cobra.Command, args []string) error {
+	dir := backupDir()
+
 	fmt.Println("\nAvailable backups:")
 	fmt.Println("==================")
 
 	count := 0
-	for i := len(entries) - 1; i >= 0; i-- {
-		entry := entries[i]
-		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
-			info, _ := entry.Info()
-			size := float64(info.Size()) / 1024 // KB
-			fmt.Printf("  %s  %.1f KB  %s\n",
-				info.ModTime().Format("2006-01-02 15:04"),
-				size,
-				entry.Name())
+	if backup.Exists(dir) {
+		repo, err := backup.Open(dir, db.GetDatabasePassword)
+		if err != nil {
+			return err
+		}
+		snapshots, err := repo.Snapshots()
+		if err != nil {
+			return err
+		}
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			s := snapshots[i]
+			fmt.Printf("  %s  %-22s  %6d rows  %4d files  +%s\n",
+				s.CreatedAt.Local().Format("2006-01-02 15:04"),
+				s.ID,
+				s.Rows(),
+				len(s.Files),
+				formatBytes(s.AddedBytes))
 			count++
 		}
 	}
 
+	for _, entry := range legacyBackupFiles(dir) {
+		info, _ := entry.Info()
+		size := float64(info.Size()) / 1024 // KB
+		fmt.Printf("  %s  %.1f KB  %s (legacy)\n",
+			info.ModTime().Format("2006-01-02 15:04"),
+			size,
+			entry.Name())
+		count++
+	}
+
 	if count == 0 {
-		fmt.Println("  No backups found")
+		fmt.Println("  No backups found. Create one with: ung sync backup")
 	} else {
-		fmt.Printf("\nTotal: %d backups in %s\n", count, backupDir)
+		fmt.Printf("\nTotal: %d backups in %s\n", count, dir)
 	}
 
 	return nil
 }
+
+// formatBytes renders a byte count for humans
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
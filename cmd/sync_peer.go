@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/internal/replica"
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+var syncPushCmd = &cobra.Command{
+	Use:   "push <folder|file.db>",
+	Short: "Send local changes to a shared folder or another database",
+	Long: `Send the changes made on this device since the last sync to a shared folder
+(Dropbox, iCloud Drive, a network share) or directly into another UNG
+database file.
+
+Every row has a global ID, so records created separately on two devices never
+collide. A new folder is encrypted when your database is encrypted (or with
+--encrypt); every device syncing through it must use the same password.
+
+Examples:
+  ung sync push ~/Dropbox/ung-sync
+  ung sync push /Volumes/desktop/.ung/ung.db`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSyncPush,
+}
+
+var syncPullCmd = &cobra.Command{
+	Use:   "pull <folder|file.db>",
+	Short: "Merge changes from a shared folder or another database",
+	Long: `Merge the changes other devices made into this database.
+
+Edits to different fields of the same record merge cleanly. When the same
+field was changed on both devices, the later edit wins and the conflict is
+recorded for review with 'ung sync conflicts'. A record deleted on one device
+stays deleted even if it was edited on the other.
+
+The first sync of two copies of a database that were edited separately has
+no common starting point, so every field that differs is reported.
+
+Examples:
+  ung sync pull ~/Dropbox/ung-sync
+  ung sync pull ~/Dropbox/ung-sync && ung sync push ~/Dropbox/ung-sync`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSyncPull,
+}
+
+var syncConflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "Review fields edited on two devices",
+	Long: `List conflicts found while pulling: fields edited on two devices since they
+last synced, and records edited on one device but deleted on the other.
+
+Examples:
+  ung sync conflicts                    # Open conflicts
+  ung sync conflicts --all              # Include reviewed ones
+  ung sync conflicts resolve 4 local    # Keep this device's value instead
+  ung sync conflicts clear              # Accept all automatic resolutions`,
+	RunE: runSyncConflicts,
+}
+
+var syncConflictsResolveCmd = &cobra.Command{
+	Use:   "resolve <id> <local|remote>",
+	Short: "Choose which value of a conflict to keep",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runSyncConflictsResolve,
+}
+
+var syncConflictsClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Mark all conflicts as reviewed",
+	RunE:  runSyncConflictsClear,
+}
+
+var syncConflictsAll bool
+
+func init() {
+	syncPushCmd.Flags().BoolVar(&syncEncrypt, "encrypt", false, "Encrypt a new sync folder with the database password")
+	syncConflictsCmd.Flags().BoolVar(&syncConflictsAll, "all", false, "Include reviewed conflicts")
+
+	syncConflictsCmd.AddCommand(syncConflictsResolveCmd)
+	syncConflictsCmd.AddCommand(syncConflictsClearCmd)
+	syncCmd.AddCommand(syncPushCmd)
+	syncCmd.AddCommand(syncPullCmd)
+	syncCmd.AddCommand(syncConflictsCmd)
+}
+
+// replicaIdentity names where a database lives, so a copied file is told apart from the original
+func replicaIdentity(path string) string {
+	host, _ := os.Hostname()
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return host + ":" + path
+}
+
+// localReplica returns the current database as a sync replica
+func localReplica() *replica.Replica {
+	return replica.New(db.DB, replicaIdentity(db.GetDBPath()))
+}
+
+// isDatabaseFile reports whether a sync target is a database file rather than a folder
+func isDatabaseFile(path string) bool {
+	if info, err := os.Stat(path); err == nil {
+		return !info.IsDir()
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".db" || ext == ".sqlite" || ext == ".sqlite3"
+}
+
+// openPeerDatabase opens another UNG database file to sync with
+func openPeerDatabase(path string) (*db.SQLiteDB, *replica.Replica, error) {
+	if !fileExists(path) {
+		return nil, nil, fmt.Errorf("database not found: %s", path)
+	}
+	header := make([]byte, 16)
+	if f, err := os.Open(path); err == nil {
+		f.Read(header)
+		f.Close()
+	}
+	if string(header) != "SQLite format 3\x00" {
+		return nil, nil, fmt.Errorf("%s is not a plain SQLite database; sync with an encrypted database through a shared folder instead", path)
+	}
+	// Changes merged into the other database are audited as sync
+	conn, err := sql.Open(audit.DriverName, audit.DSN(path, audit.SourceSync))
+	if err == nil {
+		err = conn.Ping()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	peer := &db.SQLiteDB{DB: conn}
+	return peer, replica.New(peer.DB, replicaIdentity(path)), nil
+}
+
+// captureLocalChanges records local edits in the change log before syncing
+func captureLocalChanges() (*replica.Replica, error) {
+	local := localReplica()
+	n, err := local.Capture()
+	if err != nil {
+		return nil, fmt.Errorf("failed to record local changes: %w", err)
+	}
+	if n > 0 {
+		fmt.Printf("✓ Recorded %d local changes\n", n)
+	}
+	return local, nil
+}
+
+func runSyncPush(cmd *cobra.Command, args []string) error {
+	target := expandPathForUser(args[0])
+	local, err := captureLocalChanges()
+	if err != nil {
+		return err
+	}
+
+	if isDatabaseFile(target) {
+		peer, remote, err := openPeerDatabase(target)
+		if err != nil {
+			return err
+		}
+		defer peer.Close()
+		if _, err := remote.Capture(); err != nil {
+			return fmt.Errorf("failed to record changes in %s: %w", target, err)
+		}
+		report, err := replica.Exchange(local, remote)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Pushed %d changes to %s\n", report.Applied, target)
+		printSyncConflicts(report.Conflicts, "Conflicts in "+target+" (review there with 'ung sync conflicts')")
+		return nil
+	}
+
+	folder, err := openSyncFolder(target, true)
+	if err != nil {
+		return err
+	}
+	n, err := folder.Push(local)
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	if n == 0 {
+		fmt.Printf("✓ %s is up to date\n", target)
+		return nil
+	}
+	fmt.Printf("✓ Pushed %d changes to %s\n", n, target)
+	return nil
+}
+
+func runSyncPull(cmd *cobra.Command, args []string) error {
+	source := expandPathForUser(args[0])
+	local, err := captureLocalChanges()
+	if err != nil {
+		return err
+	}
+	// Changes merged from other devices are audited as sync
+	defer audit.Relabel(audit.SourceCLI, audit.SourceSync)()
+
+	var report *replica.Report
+	if isDatabaseFile(source) {
+		peer, remote, err := openPeerDatabase(source)
+		if err != nil {
+			return err
+		}
+		defer peer.Close()
+		if _, err := remote.Capture(); err != nil {
+			return fmt.Errorf("failed to record changes in %s: %w", source, err)
+		}
+		if report, err = replica.Exchange(remote, local); err != nil {
+			return err
+		}
+	} else {
+		folder, err := openSyncFolder(source, false)
+		if err != nil {
+			return err
+		}
+		if report, err = folder.Pull(local); err != nil {
+			return fmt.Errorf("pull failed: %w", err)
+		}
+	}
+
+	if report.Applied == 0 {
+		fmt.Println("✓ Already up to date")
+		return nil
+	}
+	fmt.Printf("✓ Applied %d changes from %d devices\n", report.Applied, len(report.Devices))
+	if report.Skipped > 0 {
+		fmt.Printf("⚠️  Skipped %d changes to tables this version of ung doesn't have\n", report.Skipped)
+	}
+	if printSyncConflicts(report.Conflicts, "Conflicts resolved automatically") {
+		fmt.Println("\n💡 Review with: ung sync conflicts")
+	}
+	return nil
+}
+
+// openSyncFolder opens a shared sync folder, creating it when create is set
+func openSyncFolder(dir string, create bool) (*replica.Folder, error) {
+	if replica.FolderExists(dir) {
+		folder, err := replica.OpenFolder(dir, db.GetDatabasePassword)
+		if err == nil && folder.Encrypted() {
+			rememberSyncFolder(dir)
+		}
+		return folder, err
+	}
+	if !create {
+		return nil, fmt.Errorf("no sync folder in %s. Push from another device first: ung sync push %s", dir, dir)
+	}
+
+	cfg, _ := config.Load()
+	password := ""
+	if syncEncrypt || cfg.Security.EncryptDatabase || db.IsEncryptedInMemory() {
+		var err error
+		if password, err = db.GetDatabasePassword(); err != nil {
+			return nil, fmt.Errorf("failed to get password: %w", err)
+		}
+	}
+	folder, err := replica.InitFolder(dir, password)
+	if err != nil {
+		return nil, err
+	}
+	if folder.Encrypted() {
+		rememberSyncFolder(dir)
+		fmt.Printf("✓ Created encrypted sync folder in %s\n", dir)
+	} else {
+		fmt.Printf("✓ Created sync folder in %s\n", dir)
+	}
+	return folder, nil
+}
+
+// syncFoldersPath lists the encrypted sync folders used with this database,
+// so 'ung security change-password' can move their keys to the new password
+func syncFoldersPath() string {
+	return db.GetDBPath() + ".sync-folders"
+}
+
+// knownSyncFolders returns the encrypted sync folders used with this database
+func knownSyncFolders() []string {
+	data, err := os.ReadFile(syncFoldersPath())
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	json.Unmarshal(data, &dirs)
+	return dirs
+}
+
+// rememberSyncFolder records an encrypted sync folder; failures only cost a later rewrap
+func rememberSyncFolder(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	dirs := knownSyncFolders()
+	if slices.Contains(dirs, dir) {
+		return
+	}
+	data, _ := json.MarshalIndent(append(dirs, dir), "", "  ")
+	db.WriteFileAtomic(syncFoldersPath(), data, 0600)
+}
+
+// printSyncConflicts prints a conflict report and returns whether there was anything to print
+func printSyncConflicts(conflicts []replica.Conflict, title string) bool {
+	if len(conflicts) == 0 {
+		return false
+	}
+	fmt.Printf("\n⚠️  %s (%d):\n", title, len(conflicts))
+	for _, c := range conflicts {
+		printSyncConflict(c)
+	}
+	return true
+}
+
+func printSyncConflict(c replica.Conflict) {
+	record := c.Table
+	if c.RowID != 0 {
+		record = fmt.Sprintf("%s #%d", c.Table, c.RowID)
+	}
+	if c.Field == replica.RowField {
+		fmt.Printf("  [%d] %s: %s\n", c.ID, record, c.Note)
+		return
+	}
+
+	kept, other := c.Local, c.Remote
+	if c.Winner == replica.WinnerRemote {
+		kept, other = c.Remote, c.Local
+	}
+	fmt.Printf("  [%d] %s %s: kept %s value %q (other: %q)\n", c.ID, record, c.Field, c.Winner,
+		replica.FormatValue(kept), replica.FormatValue(other))
+	if c.Note != "" {
+		fmt.Printf("       %s\n", c.Note)
+	}
+}
+
+func runSyncConflicts(cmd *cobra.Command, args []string) error {
+	conflicts, err := localReplica().Conflicts(syncConflictsAll)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		fmt.Println("✓ No conflicts to review")
+		return nil
+	}
+
+	fmt.Println("\nSync conflicts:")
+	fmt.Println("===============")
+	for _, c := range conflicts {
+		printSyncConflict(c)
+	}
+	fmt.Println("\n💡 Keep the other value with: ung sync conflicts resolve <id> <local|remote>")
+	fmt.Println("   Accept all with:           ung sync conflicts clear")
+	return nil
+}
+
+func runSyncConflictsResolve(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid conflict ID: %s", args[0])
+	}
+	take := strings.ToLower(args[1])
+	if err := localReplica().Resolve(id, take); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Conflict %d resolved with the %s value\n", id, take)
+	fmt.Println("💡 Run 'ung sync push' to send it to your other devices")
+	return nil
+}
+
+func runSyncConflictsClear(cmd *cobra.Command, args []string) error {
+	n, err := localReplica().MarkReviewed()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Marked %d conflicts as reviewed\n", n)
+	return nil
+}
@@ -39,3 +39,33 @@ func TestBackupDataStructure(t *testing.T) {
 		t.Errorf("Expected version 1.0, got %s", backup.Version)
 	}
 }
+
+func TestIsDatabaseFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ung.db")
+	os.WriteFile(file, []byte("SQLite format 3\x00"), 0600)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{file, true},
+		{dir, false},
+		{filepath.Join(dir, "missing.db"), true},
+		{filepath.Join(dir, "new-folder"), false},
+	}
+	for _, tt := range tests {
+		if got := isDatabaseFile(tt.path); got != tt.want {
+			t.Errorf("isDatabaseFile(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if _, _, err := openPeerDatabase(filepath.Join(dir, "missing.db")); err == nil {
+		t.Error("expected error for a missing database")
+	}
+	encrypted := filepath.Join(dir, "ung.db.encrypted")
+	os.WriteFile(encrypted, []byte("UNGENC\x02 encrypted payload"), 0600)
+	if _, _, err := openPeerDatabase(encrypted); err == nil {
+		t.Error("expected error for an encrypted database")
+	}
+}
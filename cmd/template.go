@@ -19,12 +19,17 @@ var templateCmd = &cobra.Command{
 Templates define the layout, colors, and blocks used when generating PDFs.
 You can create custom templates or use the built-in default template.
 
+Blocks can be shown conditionally with show_if, e.g. "status==paid" or
+"currency!=EUR && total>1000", and text blocks fill in {placeholders}
+such as {invoice.number}, {client.name} or {total_money}.
+
 Examples:
-  ung template list                    List available templates
-  ung template show default            Show template configuration
-  ung template create my-template      Create a new template from default
-  ung template preview my-template     Generate a preview PDF
-  ung template use my-template         Set as active template`,
+  ung template list                              List available templates
+  ung template show default                      Show template configuration
+  ung template create my-template                Create a new template from default
+  ung template create my-contract -t contract    Create a contract template
+  ung template preview my-template               Generate a preview PDF
+  ung template use my-template                   Set as active template`,
 }
 
 var templateListCmd = &cobra.Command{
@@ -84,8 +89,10 @@ func init() {
 	templateCmd.AddCommand(templatePreviewCmd)
 	templateCmd.AddCommand(templateUseCmd)
 
-	// Flags for create
-	templateCreateCmd.Flags().StringVarP(&templateType, "type", "t", "invoice", "Template type (invoice or contract)")
+	// Flags for create, and for the built-in default of the other commands
+	for _, c := range []*cobra.Command{templateCreateCmd, templateShowCmd, templatePreviewCmd, templateUseCmd} {
+		c.Flags().StringVarP(&templateType, "type", "t", "invoice", "Template type (invoice or contract)")
+	}
 
 	// Flags for preview
 	templatePreviewCmd.Flags().StringVarP(&templateOutput, "output", "o", "", "Output file path")
@@ -128,25 +135,14 @@ func runTemplateList(cmd *cobra.Command, args []string) error {
 }
 
 func runTemplateShow(cmd *cobra.Command, args []string) error {
-	name := args[0]
-
-	var tmpl *template.TemplateDefinition
-
-	if name == "default" {
-		tmpl = template.GetDefaultInvoiceTemplate()
-	} else {
-		cfg, err := config.Load()
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
-		}
-
-		templatesDir := getTemplatesDir(cfg)
-		templatePath := filepath.Join(templatesDir, name+".json")
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
 
-		tmpl, err = template.LoadTemplate(templatePath)
-		if err != nil {
-			return fmt.Errorf("template '%s' not found: %w", name, err)
-		}
+	tmpl, err := loadNamedTemplate(cfg, args[0])
+	if err != nil {
+		return err
 	}
 
 	data, err := json.MarshalIndent(tmpl, "", "  ")
@@ -179,7 +175,10 @@ func runTemplateCreate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create from default
-	tmpl := template.GetDefaultInvoiceTemplate()
+	tmpl, err := defaultTemplate(templateType)
+	if err != nil {
+		return err
+	}
 	tmpl.Name = name
 	tmpl.Description = fmt.Sprintf("Custom %s template", templateType)
 
@@ -207,18 +206,9 @@ func runTemplatePreview(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	var tmpl *template.TemplateDefinition
-
-	if name == "default" {
-		tmpl = template.GetDefaultInvoiceTemplate()
-	} else {
-		templatesDir := getTemplatesDir(cfg)
-		templatePath := filepath.Join(templatesDir, name+".json")
-
-		tmpl, err = template.LoadTemplate(templatePath)
-		if err != nil {
-			return fmt.Errorf("template '%s' not found: %w", name, err)
-		}
+	tmpl, err := loadNamedTemplate(cfg, name)
+	if err != nil {
+		return err
 	}
 
 	// Generate preview
@@ -228,9 +218,12 @@ func runTemplatePreview(cmd *cobra.Command, args []string) error {
 	}
 
 	renderer := template.NewRenderer(tmpl)
-	sampleData := template.GetSampleInvoiceData(cfg)
-
-	if err := renderer.RenderInvoice(sampleData, outputPath); err != nil {
+	if tmpl.Type == "contract" {
+		err = renderer.RenderContract(template.GetSampleContractData(cfg), outputPath)
+	} else {
+		err = renderer.RenderInvoice(template.GetSampleInvoiceData(cfg), outputPath)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to generate preview: %w", err)
 	}
 
@@ -247,39 +240,79 @@ func runTemplateUse(cmd *cobra.Command, args []string) error {
 	}
 
 	if name == "default" {
+		if _, err := defaultTemplate(templateType); err != nil {
+			return err
+		}
 		// Clear custom template path
-		cfg.Templates.InvoiceHTML = ""
-		fmt.Println("✓ Using default built-in template")
+		if templateType == "contract" {
+			cfg.Templates.ContractHTML = ""
+		} else {
+			cfg.Templates.InvoiceHTML = ""
+		}
+		fmt.Printf("✓ Using default built-in %s template\n", templateType)
 	} else {
 		templatesDir := getTemplatesDir(cfg)
 		templatePath := filepath.Join(templatesDir, name+".json")
 
 		// Verify template exists
-		if _, err := os.Stat(templatePath); os.IsNotExist(err) {
+		tmpl, err := template.LoadTemplate(templatePath)
+		if err != nil {
 			return fmt.Errorf("template '%s' not found at %s", name, templatePath)
 		}
 
-		cfg.Templates.InvoiceHTML = templatePath
-		fmt.Printf("✓ Active template set to '%s'\n", name)
+		if tmpl.Type == "contract" {
+			cfg.Templates.ContractHTML = templatePath
+		} else {
+			cfg.Templates.InvoiceHTML = templatePath
+		}
+		fmt.Printf("✓ Active %s template set to '%s'\n", templateTypeName(tmpl), name)
 	}
 
 	// Save config
-	isGlobal := config.GetConfigSource() == config.SourceGlobal
-	if err := config.Save(cfg, isGlobal); err != nil {
+	if err := config.SaveActive(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
 	return nil
 }
 
-func getTemplatesDir(cfg *config.Config) string {
-	// Use templates directory alongside config
-	switch config.GetConfigSource() {
-	case config.SourceLocal:
-		return filepath.Join(config.GetLocalUngDir(), "templates")
-	case config.SourceGlobal:
-		return filepath.Join(config.GetGlobalUngDir(), "templates")
+// loadNamedTemplate returns the built-in default of --type for "default",
+// otherwise the custom template of that name
+func loadNamedTemplate(cfg *config.Config, name string) (*template.TemplateDefinition, error) {
+	if name == "default" {
+		return defaultTemplate(templateType)
+	}
+
+	templatePath := filepath.Join(getTemplatesDir(cfg), name+".json")
+	tmpl, err := template.LoadTemplate(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("template '%s' not found: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// defaultTemplate returns the built-in template of a type
+func defaultTemplate(templateType string) (*template.TemplateDefinition, error) {
+	switch templateType {
+	case "invoice":
+		return template.GetDefaultInvoiceTemplate(), nil
+	case "contract":
+		return template.GetDefaultContractTemplate(), nil
 	default:
-		return filepath.Join(config.GetLocalUngDir(), "templates")
+		return nil, fmt.Errorf("unknown template type '%s' (use invoice or contract)", templateType)
 	}
 }
+
+// templateTypeName returns the document type of a template, templates
+// without one are invoice templates
+func templateTypeName(tmpl *template.TemplateDefinition) string {
+	if tmpl.Type == "" {
+		return "invoice"
+	}
+	return tmpl.Type
+}
+
+func getTemplatesDir(cfg *config.Config) string {
+	// Use templates directory alongside config
+	return filepath.Join(config.GetConfigDir(), "templates")
+}
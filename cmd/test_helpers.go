@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
 )
 
@@ -33,6 +34,7 @@ invoices_dir: ` + filepath.Join(ungDir, "invoices") + `
 	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
 		t.Fatalf("Failed to create config file: %v", err)
 	}
+	config.Reload()
 
 	// Initialize test database
 	err := db.Initialize()
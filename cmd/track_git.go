@@ -0,0 +1,476 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/gitlog"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var trackFromGitCmd = &cobra.Command{
+	Use:   "from-git",
+	Short: "Propose time entries from git commit history",
+	Long: `Read commits from local git repositories, cluster them into work blocks
+and propose tracking sessions with the commit subjects as notes.
+
+Commits closer together than --gap minutes form one block. Each block starts
+--lead minutes before its first commit to account for the work behind it.
+Every proposed entry is reviewed (accept, edit or drop) before anything is saved.
+
+Repositories are mapped to contracts or gigs by:
+  1. Rules in config.yaml (import.git_repos)
+  2. A gig whose name or project matches the repository name
+  3. An active contract whose name contains the repository name
+
+Example rules in config.yaml:
+  import:
+    git_gap_minutes: 120
+    git_lead_minutes: 30
+    git_repos:
+      - repo: ~/code/acme-site
+        contract_id: 3
+      - repo: globex-api
+        gig_id: 7
+
+Only local repositories are read; nothing is fetched from remotes.
+Commits that were already turned into time entries are skipped.
+
+Examples:
+  ung track from-git --since 2024-01-01
+  ung track from-git --repo ~/code/acme --repo ~/code/globex --since 2024-01-01
+  ung track from-git --since 2024-01-01 --author jane@example.com --gap 90
+  ung track from-git --since 2024-01-01 --dry-run`,
+	RunE: runTrackFromGit,
+}
+
+var (
+	trackGitRepos  []string
+	trackGitAuthor string
+	trackGitSince  string
+	trackGitUntil  string
+	trackGitGap    int
+	trackGitLead   int
+	trackGitDryRun bool
+)
+
+// gitExternalIDPrefix marks tracking sessions created from git commits
+const gitExternalIDPrefix = "git:"
+
+func init() {
+	trackCmd.AddCommand(trackFromGitCmd)
+
+	trackFromGitCmd.Flags().StringSliceVar(&trackGitRepos, "repo", []string{"."}, "Repository path (repeatable)")
+	trackFromGitCmd.Flags().StringVar(&trackGitAuthor, "author", "", "Author name or email (default: user.email of each repository)")
+	trackFromGitCmd.Flags().StringVar(&trackGitSince, "since", "", "Only include commits on or after this date (YYYY-MM-DD)")
+	trackFromGitCmd.Flags().StringVar(&trackGitUntil, "until", "", "Only include commits before this date (YYYY-MM-DD)")
+	trackFromGitCmd.Flags().IntVar(&trackGitGap, "gap", 0, "Max minutes between commits in one block (default from config or 120)")
+	trackFromGitCmd.Flags().IntVar(&trackGitLead, "lead", 0, "Minutes of work before a block's first commit (default from config or 30)")
+	trackFromGitCmd.Flags().BoolVar(&trackGitDryRun, "dry-run", false, "Show proposed entries without saving")
+	trackFromGitCmd.MarkFlagRequired("since")
+}
+
+// gitTarget is the client/contract/gig a repository's work is booked to
+type gitTarget struct {
+	clientID   *uint
+	contractID *uint
+	gigID      *uint
+	label      string
+}
+
+// gitDraft is a proposed tracking session built from a block of commits
+type gitDraft struct {
+	repo   string
+	block  gitlog.Block
+	target gitTarget
+	hours  float64
+	notes  string
+}
+
+// normalizeRepoName lowercases a name and strips separators so "acme-site" matches "Acme Site"
+func normalizeRepoName(name string) string {
+	replacer := strings.NewReplacer("-", "", "_", "", " ", "", ".", "")
+	return replacer.Replace(strings.ToLower(name))
+}
+
+// resolveGitTarget maps a repository to a contract or gig using config rules, then names
+func resolveGitTarget(repoPath string, rules []config.GitRepoRule, gigs []models.Gig, contracts []models.Contract) gitTarget {
+	repoName := filepath.Base(repoPath)
+	normalized := normalizeRepoName(repoName)
+
+	gigTarget := func(g models.Gig) gitTarget {
+		t := gitTarget{clientID: g.ClientID, contractID: g.ContractID, gigID: uintPtr(g.ID), label: "gig: " + g.Name}
+		if t.clientID == nil && t.contractID != nil {
+			for _, c := range contracts {
+				if c.ID == *t.contractID {
+					t.clientID = uintPtr(c.ClientID)
+				}
+			}
+		}
+		return t
+	}
+	contractTarget := func(c models.Contract) gitTarget {
+		return gitTarget{clientID: uintPtr(c.ClientID), contractID: uintPtr(c.ID), label: "contract: " + c.Name}
+	}
+
+	for _, rule := range rules {
+		rulePath := rule.Repo
+		if strings.HasPrefix(rulePath, "~/") {
+			rulePath = filepath.Join(os.Getenv("HOME"), rulePath[2:])
+		}
+		if filepath.Clean(rulePath) != repoPath && !strings.EqualFold(rule.Repo, repoName) {
+			continue
+		}
+		if rule.GigID > 0 {
+			for _, g := range gigs {
+				if g.ID == rule.GigID {
+					return gigTarget(g)
+				}
+			}
+		}
+		if rule.ContractID > 0 {
+			for _, c := range contracts {
+				if c.ID == rule.ContractID {
+					return contractTarget(c)
+				}
+			}
+		}
+	}
+
+	for _, g := range gigs {
+		if g.Status == models.GigStatusDone || g.Status == models.GigStatusCancelled {
+			continue
+		}
+		if normalizeRepoName(g.Name) == normalized || (g.Project != "" && normalizeRepoName(g.Project) == normalized) {
+			return gigTarget(g)
+		}
+	}
+
+	for _, c := range contracts {
+		if normalized != "" && strings.Contains(normalizeRepoName(c.Name), normalized) {
+			return contractTarget(c)
+		}
+	}
+
+	return gitTarget{}
+}
+
+// buildGitDrafts turns work blocks into proposed tracking sessions
+func buildGitDrafts(repo string, blocks []gitlog.Block, target gitTarget) []gitDraft {
+	drafts := make([]gitDraft, 0, len(blocks))
+	for _, b := range blocks {
+		drafts = append(drafts, gitDraft{
+			repo:   repo,
+			block:  b,
+			target: target,
+			hours:  b.Duration().Hours(),
+			notes:  strings.Join(b.Subjects(), "; "),
+		})
+	}
+	return drafts
+}
+
+// gitDraftExternalID records all commits of a block so they are never proposed twice
+func gitDraftExternalID(d gitDraft) string {
+	hashes := make([]string, len(d.block.Commits))
+	for i, c := range d.block.Commits {
+		hashes[i] = c.ShortHash()
+	}
+	return gitExternalIDPrefix + strings.Join(hashes, ",")
+}
+
+// importedCommitHashes returns the short hashes of commits already booked as time
+func importedCommitHashes() (map[string]bool, error) {
+	ids, err := loadImportedExternalIDs(gitExternalIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]bool)
+	for id := range ids {
+		for _, h := range strings.Split(strings.TrimPrefix(id, gitExternalIDPrefix), ",") {
+			hashes[h] = true
+		}
+	}
+	return hashes, nil
+}
+
+func runTrackFromGit(cmd *cobra.Command, args []string) error {
+	since, err := parseDate(trackGitSince)
+	if err != nil {
+		return err
+	}
+	var until time.Time
+	if trackGitUntil != "" {
+		if until, err = parseDate(trackGitUntil); err != nil {
+			return err
+		}
+	}
+
+	cfg, _ := config.Load()
+	gap, lead := 120, 30
+	var rules []config.GitRepoRule
+	if cfg != nil {
+		rules = cfg.Import.GitRepos
+		if cfg.Import.GitGapMinutes > 0 {
+			gap = cfg.Import.GitGapMinutes
+		}
+		if cfg.Import.GitLeadMinutes > 0 {
+			lead = cfg.Import.GitLeadMinutes
+		}
+	}
+	if trackGitGap > 0 {
+		gap = trackGitGap
+	}
+	if trackGitLead > 0 {
+		lead = trackGitLead
+	}
+
+	var contracts []models.Contract
+	if err := db.GormDB.Where("active = ?", true).Order("id DESC").Find(&contracts).Error; err != nil {
+		return fmt.Errorf("failed to load contracts: %w", err)
+	}
+	var gigs []models.Gig
+	if err := db.GormDB.Find(&gigs).Error; err != nil {
+		return fmt.Errorf("failed to load gigs: %w", err)
+	}
+
+	imported, err := importedCommitHashes()
+	if err != nil {
+		return err
+	}
+
+	var drafts []gitDraft
+	for _, repoArg := range trackGitRepos {
+		if strings.HasPrefix(repoArg, "~/") {
+			repoArg = filepath.Join(os.Getenv("HOME"), repoArg[2:])
+		}
+		root, err := gitlog.RepoRoot(repoArg)
+		if err != nil {
+			return err
+		}
+
+		author := trackGitAuthor
+		if author == "" {
+			author = gitlog.ConfiguredEmail(root)
+		}
+
+		commits, err := gitlog.ReadCommits(root, gitlog.Options{Author: author, Since: since, Until: until})
+		if err != nil {
+			return err
+		}
+
+		var fresh []gitlog.Commit
+		for _, c := range commits {
+			if !imported[c.ShortHash()] {
+				fresh = append(fresh, c)
+			}
+		}
+
+		blocks := gitlog.Cluster(fresh, time.Duration(gap)*time.Minute, time.Duration(lead)*time.Minute)
+		target := resolveGitTarget(root, rules, gigs, contracts)
+		drafts = append(drafts, buildGitDrafts(gitlog.RepoName(root), blocks, target)...)
+
+		fmt.Printf("📂 %s: %d new commits in %d blocks", gitlog.RepoName(root), len(fresh), len(blocks))
+		if target.label != "" {
+			fmt.Printf(" → %s", target.label)
+		}
+		fmt.Println()
+	}
+
+	if len(drafts) == 0 {
+		fmt.Println("\nNo new commits to turn into time entries.")
+		return nil
+	}
+
+	if trackGitDryRun {
+		fmt.Println()
+		var total float64
+		for _, d := range drafts {
+			fmt.Printf("  [Preview] %s\n", gitDraftLabel(d))
+			total += d.hours
+		}
+		fmt.Printf("\n%d entries, %.2fh total\n", len(drafts), total)
+		fmt.Println("Dry run completed - no data was saved")
+		return nil
+	}
+
+	accepted, err := reviewGitDrafts(drafts, contracts)
+	if err != nil {
+		return err
+	}
+	if len(accepted) == 0 {
+		fmt.Println("\nNo entries accepted - nothing was saved.")
+		return nil
+	}
+
+	created := 0
+	var totalHours float64
+	err = db.GormDB.Transaction(func(tx *gorm.DB) error {
+		for _, d := range accepted {
+			session := gitDraftSession(d)
+			if err := tx.Create(&session).Error; err != nil {
+				return fmt.Errorf("failed to save entry for %s: %w", d.repo, err)
+			}
+			if d.target.gigID != nil {
+				if err := addGigHours(tx, *d.target.gigID, d.hours, d.block.End); err != nil {
+					return fmt.Errorf("failed to update gig: %w", err)
+				}
+			}
+			created++
+			totalHours += d.hours
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Created %d time entries (%.2fh), dropped %d\n", created, totalHours, len(drafts)-len(accepted))
+	return nil
+}
+
+// reviewGitDrafts asks the user to accept, edit or drop each proposed entry
+func reviewGitDrafts(drafts []gitDraft, contracts []models.Contract) ([]gitDraft, error) {
+	var accepted []gitDraft
+	for i, d := range drafts {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(drafts), gitDraftLabel(d))
+		for _, c := range d.block.Commits {
+			fmt.Printf("    %s %s %s\n", c.ShortHash()[:7], c.Time.Local().Format("15:04"), c.Subject)
+		}
+
+		var action string
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title("What should happen with this entry?").
+					Options(
+						huh.NewOption("Accept", "accept"),
+						huh.NewOption("Edit", "edit"),
+						huh.NewOption("Drop", "drop"),
+					).
+					Value(&action),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return nil, fmt.Errorf("cancelled: %w", err)
+		}
+
+		switch action {
+		case "accept":
+			accepted = append(accepted, d)
+		case "edit":
+			edited, err := editGitDraft(d, contracts)
+			if err != nil {
+				return nil, err
+			}
+			accepted = append(accepted, edited)
+		}
+	}
+	return accepted, nil
+}
+
+// editGitDraft lets the user change the hours, notes and contract of a draft
+func editGitDraft(d gitDraft, contracts []models.Contract) (gitDraft, error) {
+	hoursStr := fmt.Sprintf("%.2f", d.hours)
+	notes := d.notes
+
+	var contractID uint
+	if d.target.contractID != nil {
+		contractID = *d.target.contractID
+	}
+	options := []huh.Option[uint]{huh.NewOption("No contract", uint(0))}
+	for _, c := range contracts {
+		options = append(options, huh.NewOption(c.Name, c.ID))
+	}
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Hours").
+				Value(&hoursStr).
+				Validate(func(s string) error {
+					h, err := strconv.ParseFloat(s, 64)
+					if err != nil || h <= 0 {
+						return fmt.Errorf("hours must be a positive number")
+					}
+					return nil
+				}),
+			huh.NewSelect[uint]().
+				Title("Contract").
+				Options(options...).
+				Value(&contractID),
+			huh.NewText().
+				Title("Notes").
+				Value(&notes),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return d, fmt.Errorf("cancelled: %w", err)
+	}
+
+	d.hours, _ = strconv.ParseFloat(hoursStr, 64)
+	d.notes = notes
+
+	return setGitDraftContract(d, contractID, contracts), nil
+}
+
+// setGitDraftContract points a draft at a contract, or at no client at all when contractID is 0
+func setGitDraftContract(d gitDraft, contractID uint, contracts []models.Contract) gitDraft {
+	if contractID == 0 {
+		d.target = gitTarget{gigID: d.target.gigID}
+		return d
+	}
+	if d.target.contractID != nil && *d.target.contractID == contractID {
+		return d
+	}
+	for _, c := range contracts {
+		if c.ID == contractID {
+			d.target = gitTarget{clientID: uintPtr(c.ClientID), contractID: uintPtr(c.ID), gigID: d.target.gigID, label: "contract: " + c.Name}
+		}
+	}
+	return d
+}
+
+// gitDraftLabel formats a draft for display
+func gitDraftLabel(d gitDraft) string {
+	target := "(no contract)"
+	if d.target.label != "" {
+		target = d.target.label
+	}
+	return fmt.Sprintf("%s %s–%s  %5.2fh  %s (%d commits) → %s",
+		d.block.Start.Local().Format("2006-01-02"),
+		d.block.Start.Local().Format("15:04"),
+		d.block.End.Local().Format("15:04"),
+		d.hours, d.repo, len(d.block.Commits), target)
+}
+
+// gitDraftSession converts an accepted draft into a tracking session
+func gitDraftSession(d gitDraft) models.TrackingSession {
+	start := d.block.Start
+	end := start.Add(time.Duration(d.hours * float64(time.Hour)))
+	hours := d.hours
+	duration := int(d.hours * 3600)
+
+	return models.TrackingSession{
+		ClientID:    d.target.clientID,
+		ContractID:  d.target.contractID,
+		ProjectName: d.repo,
+		StartTime:   start,
+		EndTime:     &end,
+		Duration:    &duration,
+		Hours:       &hours,
+		Billable:    d.target.clientID != nil,
+		Notes:       d.notes,
+		ExternalID:  gitDraftExternalID(d),
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/gitlog"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+func TestNormalizeRepoName(t *testing.T) {
+	tests := map[string]string{
+		"acme-site":  "acmesite",
+		"Acme Site":  "acmesite",
+		"globex_api": "globexapi",
+		"ung.docs":   "ungdocs",
+	}
+	for input, expected := range tests {
+		if got := normalizeRepoName(input); got != expected {
+			t.Errorf("normalizeRepoName(%q) = %q; want %q", input, got, expected)
+		}
+	}
+}
+
+func TestResolveGitTarget(t *testing.T) {
+	clientID := uint(5)
+	contractID := uint(30)
+	contracts := []models.Contract{
+		{ID: 10, ClientID: 1, Name: "Acme Site Rebuild"},
+		{ID: 30, ClientID: 5, Name: "Globex Retainer"},
+	}
+	gigs := []models.Gig{
+		{ID: 7, Name: "globex-api", ContractID: &contractID, Status: models.GigStatusInProgress},
+		{ID: 8, Name: "old-project", ClientID: &clientID, Status: models.GigStatusDone},
+	}
+	rules := []config.GitRepoRule{
+		{Repo: "mapped-repo", ContractID: 30},
+	}
+
+	tests := []struct {
+		repo         string
+		wantContract uint
+		wantClient   uint
+		wantGig      uint
+	}{
+		{"/code/mapped-repo", 30, 5, 0},
+		{"/code/globex-api", 30, 5, 7},
+		{"/code/acme-site", 10, 1, 0},
+		{"/code/old-project", 0, 0, 0},
+		{"/code/unknown", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		target := resolveGitTarget(tt.repo, rules, gigs, contracts)
+
+		var gotContract, gotClient, gotGig uint
+		if target.contractID != nil {
+			gotContract = *target.contractID
+		}
+		if target.clientID != nil {
+			gotClient = *target.clientID
+		}
+		if target.gigID != nil {
+			gotGig = *target.gigID
+		}
+		if gotContract != tt.wantContract || gotClient != tt.wantClient || gotGig != tt.wantGig {
+			t.Errorf("%s: got contract %d, client %d, gig %d; want %d, %d, %d",
+				tt.repo, gotContract, gotClient, gotGig, tt.wantContract, tt.wantClient, tt.wantGig)
+		}
+	}
+}
+
+func TestGitDraftSession(t *testing.T) {
+	setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	blocks := gitlog.Cluster([]gitlog.Commit{
+		{Hash: "aaaaaaaaaaaaaaaa", Time: base, Subject: "Add form"},
+		{Hash: "bbbbbbbbbbbbbbbb", Time: base.Add(time.Hour), Subject: "Fix form"},
+	}, 2*time.Hour, 30*time.Minute)
+
+	drafts := buildGitDrafts("acme-site", blocks, gitTarget{})
+	if len(drafts) != 1 {
+		t.Fatalf("expected 1 draft, got %d", len(drafts))
+	}
+	if drafts[0].hours != 1.5 {
+		t.Errorf("expected 1.5 hours, got %.2f", drafts[0].hours)
+	}
+	if drafts[0].notes != "Add form; Fix form" {
+		t.Errorf("unexpected notes: %s", drafts[0].notes)
+	}
+
+	session := gitDraftSession(drafts[0])
+	if session.ExternalID != "git:aaaaaaaaaaaa,bbbbbbbbbbbb" {
+		t.Errorf("unexpected external ID: %s", session.ExternalID)
+	}
+	if err := db.GormDB.Create(&session).Error; err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	hashes, err := importedCommitHashes()
+	if err != nil {
+		t.Fatalf("importedCommitHashes() error: %v", err)
+	}
+	if !hashes["aaaaaaaaaaaa"] || !hashes["bbbbbbbbbbbb"] {
+		t.Errorf("expected both commits to be marked as imported, got %v", hashes)
+	}
+}
+
+func TestSetGitDraftContract(t *testing.T) {
+	contracts := []models.Contract{{ID: 7, ClientID: 3, Name: "Website"}}
+	d := gitDraft{target: gitTarget{clientID: uintPtr(2), contractID: uintPtr(5), gigID: uintPtr(9), label: "contract: Old"}}
+
+	d = setGitDraftContract(d, 7, contracts)
+	if d.target.clientID == nil || *d.target.clientID != 3 || d.target.label != "contract: Website" {
+		t.Errorf("expected draft to move to contract 7, got %+v", d.target)
+	}
+
+	d = setGitDraftContract(d, 0, contracts)
+	if d.target.clientID != nil || d.target.contractID != nil || d.target.label != "" {
+		t.Errorf("expected no contract to clear client and label, got %+v", d.target)
+	}
+	if d.target.gigID == nil || *d.target.gigID != 9 {
+		t.Error("expected gig to be kept")
+	}
+	if gitDraftSession(d).Billable {
+		t.Error("expected draft without a client not to be billable")
+	}
+}
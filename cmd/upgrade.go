@@ -160,7 +160,7 @@ func detectInstallMethod() string {
 
 	// Check for Homebrew installation
 	if strings.Contains(exePath, "/homebrew/") || strings.Contains(exePath, "/Homebrew/") ||
-	   strings.Contains(exePath, "/Cellar/") || strings.Contains(exePath, "/opt/homebrew/") {
+		strings.Contains(exePath, "/Cellar/") || strings.Contains(exePath, "/opt/homebrew/") {
 		return "homebrew"
 	}
 
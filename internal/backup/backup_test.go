@@ -0,0 +1,256 @@
+package backup
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ung.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_, err = conn.Exec(`
+		CREATE TABLE clients (id INTEGER PRIMARY KEY, name TEXT, logo BLOB, rate REAL);
+		CREATE TABLE expenses (id INTEGER PRIMARY KEY, amount REAL, receipt_path TEXT);
+		INSERT INTO clients (name, logo, rate) VALUES ('Acme', x'00ff10', 95.5), ('Globex', NULL, 120);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	return conn
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	conn := openTestDB(t)
+	receipt := filepath.Join(t.TempDir(), "receipt.pdf")
+	os.WriteFile(receipt, []byte("%PDF receipt"), 0600)
+	conn.Exec("INSERT INTO expenses (amount, receipt_path) VALUES (12.5, ?)", receipt)
+
+	repo, err := Init(filepath.Join(t.TempDir(), "repo"), "")
+	if err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	first, err := Create(repo, conn, Options{})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if first.Rows() != 3 || len(first.Files) != 1 {
+		t.Errorf("expected 3 rows and 1 file, got %d rows and %d files", first.Rows(), len(first.Files))
+	}
+
+	// Nothing changed, so the second snapshot adds no data
+	second, err := Create(repo, conn, Options{})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if second.AddedBytes != 0 {
+		t.Errorf("expected unchanged data to be deduplicated, added %d bytes", second.AddedBytes)
+	}
+
+	// Lose data, then restore only the clients table
+	conn.Exec("DELETE FROM clients WHERE name = 'Acme'")
+	conn.Exec("UPDATE clients SET name = 'Changed'")
+	conn.Exec("DELETE FROM expenses")
+	os.Remove(receipt)
+
+	result, err := Restore(repo, conn, first, RestoreOptions{Tables: []string{"clients"}, Files: true})
+	if err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if result.Tables["clients"] != 2 || result.Files != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	var name string
+	var logo []byte
+	var rate float64
+	conn.QueryRow("SELECT name, logo, rate FROM clients WHERE id = 1").Scan(&name, &logo, &rate)
+	if name != "Acme" || string(logo) != "\x00\xff\x10" || rate != 95.5 {
+		t.Errorf("got %q %x %v", name, logo, rate)
+	}
+	var expenses int
+	conn.QueryRow("SELECT count(*) FROM expenses").Scan(&expenses)
+	if expenses != 0 {
+		t.Error("expected expenses to be left alone")
+	}
+
+	// Restoring expenses brings the receipt back
+	result, err = Restore(repo, conn, first, RestoreOptions{Tables: []string{"expenses"}, Files: true})
+	if err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if data, err := os.ReadFile(receipt); err != nil || string(data) != "%PDF receipt" || result.Files != 1 {
+		t.Errorf("expected receipt to be restored, got %q, %v", data, err)
+	}
+}
+
+func TestRestoreReplaceAndSchemaChanges(t *testing.T) {
+	conn := openTestDB(t)
+	repo, _ := Init(filepath.Join(t.TempDir(), "repo"), "")
+	snap, err := Create(repo, conn, Options{})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	conn.Exec("INSERT INTO clients (name) VALUES ('Added later')")
+	conn.Exec("ALTER TABLE clients DROP COLUMN rate")
+	conn.Exec("DROP TABLE expenses")
+
+	result, err := Restore(repo, conn, snap, RestoreOptions{Replace: true})
+	if err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	var count int
+	conn.QueryRow("SELECT count(*) FROM clients").Scan(&count)
+	if count != 2 {
+		t.Errorf("expected replace to leave 2 clients, got %d", count)
+	}
+	if got := result.MissingColumns["clients"]; len(got) != 1 || got[0] != "rate" {
+		t.Errorf("expected rate to be reported missing, got %v", got)
+	}
+	if len(result.CreatedTables) != 1 || result.CreatedTables[0] != "expenses" {
+		t.Errorf("expected expenses to be recreated, got %v", result.CreatedTables)
+	}
+}
+
+func TestEncryptedRepository(t *testing.T) {
+	conn := openTestDB(t)
+	dir := filepath.Join(t.TempDir(), "repo")
+	repo, err := Init(dir, "secret")
+	if err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	snap, err := Create(repo, conn, Options{})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	// Nothing readable is left on disk
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if data, _ := os.ReadFile(path); !d.IsDir() && strings.Contains(string(data), "Acme") {
+			t.Errorf("%s contains plaintext", path)
+		}
+		return nil
+	})
+
+	if _, err := Open(dir, func() (string, error) { return "wrong", nil }); err == nil {
+		t.Error("expected wrong password to fail")
+	}
+	reopened, err := Open(dir, func() (string, error) { return "secret", nil })
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	loaded, err := reopened.LoadSnapshot(snap.ID)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error: %v", err)
+	}
+	if err := Verify(reopened, loaded); err != nil {
+		t.Errorf("Verify() error: %v", err)
+	}
+}
+
+func TestVerifyDetectsCorruption(t *testing.T) {
+	conn := openTestDB(t)
+	repo, _ := Init(filepath.Join(t.TempDir(), "repo"), "")
+	snap, _ := Create(repo, conn, Options{})
+	entry, _ := snap.Table("clients")
+
+	stored, _ := repo.pack([]byte(`{"columns":["id"],"rows":[[1]]}`), nil)
+	os.WriteFile(repo.objectPath(entry.Object), stored, 0600)
+	if err := Verify(repo, snap); err == nil {
+		t.Error("expected corrupted object to fail verification")
+	}
+	if _, err := Restore(repo, conn, snap, RestoreOptions{}); err == nil {
+		t.Error("expected restore of a corrupted snapshot to fail")
+	}
+
+	os.Remove(repo.objectPath(entry.Object))
+	if err := Verify(repo, snap); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected missing object error, got %v", err)
+	}
+}
+
+func TestRetentionAndPrune(t *testing.T) {
+	base := time.Date(2024, 3, 31, 12, 0, 0, 0, time.Local)
+	var snaps []*Snapshot
+	for i := 89; i >= 0; i-- {
+		created := base.AddDate(0, 0, -i)
+		snaps = append(snaps, &Snapshot{ID: created.Format("20060102"), CreatedAt: created})
+	}
+
+	keep, forget := Policy{KeepLast: 2, KeepDaily: 5, KeepMonthly: 3}.Apply(snaps)
+	// 5 daily (includes the last 2) plus the end of February and January
+	if len(keep) != 7 || len(keep)+len(forget) != len(snaps) {
+		t.Errorf("expected 7 kept, got %d kept and %d forgotten", len(keep), len(forget))
+	}
+	if keep[0].ID != "20240131" || keep[1].ID != "20240229" {
+		t.Errorf("expected monthly snapshots to be the last of each month, got %s and %s", keep[0].ID, keep[1].ID)
+	}
+	if keep, _ := (Policy{}).Apply(snaps); len(keep) != len(snaps) {
+		t.Error("expected an empty policy to keep everything")
+	}
+
+	// Forgetting a snapshot frees only data no other snapshot uses
+	conn := openTestDB(t)
+	repo, _ := Init(filepath.Join(t.TempDir(), "repo"), "")
+	old, _ := Create(repo, conn, Options{})
+	conn.Exec("INSERT INTO clients (name) VALUES ('Initech')")
+	latest, _ := Create(repo, conn, Options{})
+
+	if err := repo.Forget([]*Snapshot{old}); err != nil {
+		t.Fatalf("Forget() error: %v", err)
+	}
+	removed, freed, err := repo.Prune()
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if removed != 1 || freed == 0 {
+		t.Errorf("expected the old clients dump to be removed, got %d objects, %d bytes", removed, freed)
+	}
+	if err := Verify(repo, latest); err != nil {
+		t.Errorf("expected latest snapshot to survive prune: %v", err)
+	}
+}
+
+func TestSnapshotSchemaVersion(t *testing.T) {
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ung.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+	if err := schema.Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error: %v", err)
+	}
+
+	repo, err := Init(filepath.Join(t.TempDir(), "repo"), "")
+	if err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	snap, err := Create(repo, conn, Options{SkipFiles: true})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if snap.SchemaVersion != schema.Latest() {
+		t.Errorf("expected schema version %s, got %q", schema.Latest(), snap.SchemaVersion)
+	}
+	loaded, err := repo.LoadSnapshot(snap.ID)
+	if err != nil || loaded.SchemaVersion != snap.SchemaVersion {
+		t.Errorf("expected schema version to be saved, got %q, %v", loaded.SchemaVersion, err)
+	}
+
+	// A snapshot from a newer ung is refused instead of half-restored
+	loaded.SchemaVersion = "999999_from_the_future"
+	if _, err := Restore(repo, conn, loaded, RestoreOptions{}); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected restore of a newer schema to fail, got %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+// Package backup stores versioned, deduplicated snapshots of the database and
+// the files it references.
+//
+// A repository is a directory:
+//
+//	repo.json            format version and whether the repository is encrypted
+//	key                  repository key, encrypted with the database password and key (encrypted repositories only)
+//	objects/ab/abcd...   content-addressed table dumps and files (gzip, then AES-256-GCM when encrypted)
+//	snapshots/<id>.json  snapshot manifests listing the objects of each table and file
+//
+// Objects are named by the SHA-256 of their contents (an HMAC keyed with the
+// repository key when encrypted), so tables and files that didn't change
+// between snapshots are stored once.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+)
+
+const repoVersion = 1
+
+// ErrNoRepository is returned when a directory doesn't contain a backup repository
+var ErrNoRepository = errors.New("no backup repository")
+
+type repoConfig struct {
+	Version   int  `json:"version"`
+	Encrypted bool `json:"encrypted"`
+}
+
+// Repository is an opened backup repository
+type Repository struct {
+	dir string
+	key []byte // nil for unencrypted repositories
+}
+
+// Exists reports whether dir contains a backup repository
+func Exists(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "repo.json"))
+	return err == nil
+}
+
+// Init creates a repository in dir. An empty password creates an unencrypted repository.
+func Init(dir, password string) (*Repository, error) {
+	if Exists(dir) {
+		return nil, fmt.Errorf("backup repository already exists in %s", dir)
+	}
+	for _, sub := range []string{"objects", "snapshots"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create backup repository: %w", err)
+		}
+	}
+
+	repo := &Repository{dir: dir}
+	if password != "" {
+		repo.key = make([]byte, 32)
+		if _, err := rand.Read(repo.key); err != nil {
+			return nil, fmt.Errorf("failed to generate repository key: %w", err)
+		}
+		sealed, err := db.SealLinked(repo.key, password)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.WriteFileAtomic(filepath.Join(dir, "key"), sealed, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write repository key: %w", err)
+		}
+	}
+
+	cfg, _ := json.MarshalIndent(repoConfig{Version: repoVersion, Encrypted: password != ""}, "", "  ")
+	if err := db.WriteFileAtomic(filepath.Join(dir, "repo.json"), cfg, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write repository config: %w", err)
+	}
+	return repo, nil
+}
+
+// Open opens the repository in dir, asking for the password only if it is encrypted
+func Open(dir string, password func() (string, error)) (*Repository, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "repo.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w in %s", ErrNoRepository, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository config: %w", err)
+	}
+	var cfg repoConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid repository config: %w", err)
+	}
+	if cfg.Version > repoVersion {
+		return nil, fmt.Errorf("backup repository version %d is newer than this ung supports (upgrade ung)", cfg.Version)
+	}
+
+	repo := &Repository{dir: dir}
+	if cfg.Encrypted {
+		sealed, err := os.ReadFile(filepath.Join(dir, "key"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read repository key: %w", err)
+		}
+		if repo.key, err = db.OpenLinked(sealed, password); err != nil {
+			return nil, fmt.Errorf("failed to unlock backup repository: %w", err)
+		}
+	}
+	return repo, nil
+}
+
+// RewrapKey replaces the sealed repository key in dir with the result of rewrap,
+// used when the database password changes. Unencrypted repositories are left alone.
+func RewrapKey(dir string, rewrap func([]byte) ([]byte, error)) error {
+	path := filepath.Join(dir, "key")
+	sealed, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read repository key: %w", err)
+	}
+	output, err := rewrap(sealed)
+	if err != nil {
+		return err
+	}
+	return db.WriteFileAtomic(path, output, 0600)
+}
+
+// Dir returns the repository directory
+func (r *Repository) Dir() string {
+	return r.dir
+}
+
+// Encrypted reports whether the repository encrypts its contents
+func (r *Repository) Encrypted() bool {
+	return r.key != nil
+}
+
+// objectID names an object by its contents
+func (r *Repository) objectID(data []byte) string {
+	if r.key != nil {
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write(data)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *Repository) objectPath(id string) string {
+	return filepath.Join(r.dir, "objects", id[:2], id)
+}
+
+// putObject stores data unless an identical object exists and returns its ID
+// and the number of bytes written
+func (r *Repository) putObject(data []byte) (string, int64, error) {
+	id := r.objectID(data)
+	path := r.objectPath(id)
+	if _, err := os.Stat(path); err == nil {
+		return id, 0, nil
+	}
+
+	stored, err := r.pack(data, []byte(id))
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", 0, err
+	}
+	if err := db.WriteFileAtomic(path, stored, 0600); err != nil {
+		return "", 0, fmt.Errorf("failed to write object: %w", err)
+	}
+	return id, int64(len(stored)), nil
+}
+
+// getObject reads an object and checks that its contents match its ID
+func (r *Repository) getObject(id string) ([]byte, error) {
+	if len(id) < 3 || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("invalid object ID %q", id)
+	}
+	stored, err := os.ReadFile(r.objectPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("object %s is missing: %w", id[:12], err)
+	}
+	data, err := r.unpack(stored, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("object %s is corrupted: %w", id[:12], err)
+	}
+	if r.objectID(data) != id {
+		return nil, fmt.Errorf("object %s is corrupted: checksum mismatch", id[:12])
+	}
+	return data, nil
+}
+
+// pack compresses and, for encrypted repositories, encrypts data bound to aad
+func (r *Repository) pack(data, aad []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if r.key == nil {
+		return buf.Bytes(), nil
+	}
+
+	gcm, err := r.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, buf.Bytes(), aad), nil
+}
+
+// unpack reverses pack
+func (r *Repository) unpack(stored, aad []byte) ([]byte, error) {
+	if r.key != nil {
+		gcm, err := r.gcm()
+		if err != nil {
+			return nil, err
+		}
+		if len(stored) < gcm.NonceSize() {
+			return nil, fmt.Errorf("ciphertext too short")
+		}
+		if stored, err = gcm.Open(nil, stored[:gcm.NonceSize()], stored[gcm.NonceSize():], aad); err != nil {
+			return nil, fmt.Errorf("decryption failed: %w", err)
+		}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+func (r *Repository) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(r.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (r *Repository) snapshotPath(id string) string {
+	return filepath.Join(r.dir, "snapshots", id+".json")
+}
+
+// saveSnapshot writes a snapshot manifest
+func (r *Repository) saveSnapshot(s *Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if r.key != nil {
+		if data, err = r.pack(data, []byte("snapshot:"+s.ID)); err != nil {
+			return err
+		}
+	}
+	return db.WriteFileAtomic(r.snapshotPath(s.ID), data, 0600)
+}
+
+// LoadSnapshot reads a snapshot manifest by ID
+func (r *Repository) LoadSnapshot(id string) (*Snapshot, error) {
+	if strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid snapshot ID %q", id)
+	}
+	data, err := os.ReadFile(r.snapshotPath(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot %s not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.key != nil {
+		if data, err = r.unpack(data, []byte("snapshot:"+id)); err != nil {
+			return nil, fmt.Errorf("snapshot %s is corrupted: %w", id, err)
+		}
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("snapshot %s is corrupted: %w", id, err)
+	}
+	return &s, nil
+}
+
+// Snapshots returns all snapshots, oldest first
+func (r *Repository) Snapshots() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(r.dir, "snapshots"))
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*Snapshot
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		s, err := r.LoadSnapshot(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Forget deletes snapshot manifests. Their objects are removed by Prune.
+func (r *Repository) Forget(snapshots []*Snapshot) error {
+	for _, s := range snapshots {
+		if err := os.Remove(r.snapshotPath(s.ID)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove snapshot %s: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// Prune removes objects no snapshot references and returns how many objects and bytes were freed
+func (r *Repository) Prune() (int, int64, error) {
+	snapshots, err := r.Snapshots()
+	if err != nil {
+		return 0, 0, err
+	}
+	used := map[string]bool{}
+	for _, s := range snapshots {
+		for _, id := range s.objects() {
+			used[id] = true
+		}
+	}
+
+	removed := 0
+	var freed int64
+	err = filepath.WalkDir(filepath.Join(r.dir, "objects"), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || used[d.Name()] {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	return removed, freed, err
+}
@@ -0,0 +1,247 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+)
+
+// RestoreOptions controls what a restore touches
+type RestoreOptions struct {
+	Tables  []string // Tables to restore (all when empty)
+	Replace bool     // Replace table contents instead of merging rows by primary key
+	Files   bool     // Restore referenced files that are missing on disk
+}
+
+// RestoreResult summarizes a restore
+type RestoreResult struct {
+	Tables         map[string]int      // Rows written per table
+	Files          int                 // Files written
+	MissingColumns map[string][]string // Backed up columns the current schema no longer has
+	CreatedTables  []string            // Tables that didn't exist and were created from the snapshot
+}
+
+// appendOnly tables are never cleared by a restore; only missing rows are added
+var appendOnly = map[string]bool{"audit_log": true}
+
+// Verify checks that every object of a snapshot is present and intact and
+// that each table dump has the recorded number of rows
+func Verify(repo *Repository, snap *Snapshot) error {
+	if _, err := loadTables(repo, snap, nil); err != nil {
+		return err
+	}
+	for _, f := range snap.Files {
+		data, err := repo.getObject(f.Object)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Path, err)
+		}
+		if int64(len(data)) != f.Size {
+			return fmt.Errorf("%s: size mismatch", f.Path)
+		}
+	}
+	return nil
+}
+
+// loadTables reads and checks the dumps of the selected tables
+func loadTables(repo *Repository, snap *Snapshot, tables []string) (map[string]*tableDump, error) {
+	dumps := map[string]*tableDump{}
+	for _, t := range snap.Tables {
+		if len(tables) > 0 && !containsName(tables, t.Name) {
+			continue
+		}
+		data, err := repo.getObject(t.Object)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", t.Name, err)
+		}
+		dump, err := decodeTable(data)
+		if err != nil {
+			return nil, fmt.Errorf("table %s is corrupted: %w", t.Name, err)
+		}
+		if len(dump.Rows) != t.Rows {
+			return nil, fmt.Errorf("table %s has %d rows, snapshot recorded %d", t.Name, len(dump.Rows), t.Rows)
+		}
+		dumps[t.Name] = dump
+	}
+	for _, name := range tables {
+		if _, ok := dumps[name]; !ok {
+			return nil, fmt.Errorf("snapshot %s has no table %q", snap.ID, name)
+		}
+	}
+	return dumps, nil
+}
+
+// CheckCompatible returns an error when a snapshot was taken from a database
+// migrated further than this build knows. Snapshots of older schemas restore
+// fine: columns they lack keep their defaults. Snapshots of unmigrated
+// databases, and those made before the version was recorded, are accepted.
+func CheckCompatible(snap *Snapshot) error {
+	if snap.SchemaVersion == "" || schema.Known(snap.SchemaVersion) {
+		return nil
+	}
+	return fmt.Errorf("snapshot %s was taken at schema version %s, newer than this ung supports (%s); upgrade ung to restore it",
+		snap.ID, snap.SchemaVersion, schema.Latest())
+}
+
+// Restore writes a verified snapshot back into the database in one transaction
+func Restore(repo *Repository, conn *sql.DB, snap *Snapshot, opts RestoreOptions) (*RestoreResult, error) {
+	if err := CheckCompatible(snap); err != nil {
+		return nil, err
+	}
+	dumps, err := loadTables(repo, snap, opts.Tables)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot failed verification: %w", err)
+	}
+
+	result := &RestoreResult{Tables: map[string]int{}, MissingColumns: map[string][]string{}}
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range snap.Tables {
+		dump, ok := dumps[entry.Name]
+		if !ok {
+			continue
+		}
+		columns, err := tableColumns(tx, entry.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			if _, err := tx.Exec(entry.Schema); err != nil {
+				return nil, fmt.Errorf("failed to create table %s: %w", entry.Name, err)
+			}
+			result.CreatedTables = append(result.CreatedTables, entry.Name)
+			if columns, err = tableColumns(tx, entry.Name); err != nil {
+				return nil, err
+			}
+		}
+
+		replace := opts.Replace && !appendOnly[entry.Name]
+		n, missing, err := restoreTable(tx, entry.Name, dump, columns, replace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", entry.Name, err)
+		}
+		if len(missing) > 0 {
+			result.MissingColumns[entry.Name] = missing
+		}
+
+		// Check the restored table before committing
+		var count int
+		if err := tx.QueryRow("SELECT count(*) FROM " + quoteIdent(entry.Name)).Scan(&count); err != nil {
+			return nil, err
+		}
+		if (replace && count != entry.Rows) || count < entry.Rows {
+			return nil, fmt.Errorf("verification of %s failed: %d rows after restore, expected %d", entry.Name, count, entry.Rows)
+		}
+		result.Tables[entry.Name] = n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if opts.Files {
+		for _, f := range snap.Files {
+			if _, ok := dumps[f.Table]; !ok {
+				continue
+			}
+			if _, err := os.Stat(f.Path); err == nil {
+				continue
+			}
+			data, err := repo.getObject(f.Object)
+			if err != nil {
+				return result, fmt.Errorf("%s: %w", f.Path, err)
+			}
+			if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+				return result, err
+			}
+			if err := db.WriteFileAtomic(f.Path, data, 0600); err != nil {
+				return result, fmt.Errorf("failed to restore %s: %w", f.Path, err)
+			}
+			os.Chtimes(f.Path, f.ModTime, f.ModTime)
+			result.Files++
+		}
+	}
+	return result, nil
+}
+
+// restoreTable writes a dump's rows, limited to the columns the table still has
+func restoreTable(tx *sql.Tx, table string, dump *tableDump, columns map[string]bool, replace bool) (int, []string, error) {
+	var names, missing []string
+	var indexes []int
+	for i, column := range dump.Columns {
+		if columns[column] {
+			names = append(names, quoteIdent(column))
+			indexes = append(indexes, i)
+		} else {
+			missing = append(missing, column)
+		}
+	}
+
+	if replace {
+		if _, err := tx.Exec("DELETE FROM " + quoteIdent(table)); err != nil {
+			return 0, nil, err
+		}
+	}
+	if len(names) == 0 || len(dump.Rows) == 0 {
+		return 0, missing, nil
+	}
+
+	conflict := "REPLACE"
+	if appendOnly[table] {
+		conflict = "IGNORE"
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT OR %s INTO %s (%s) VALUES (%s)",
+		conflict, quoteIdent(table), strings.Join(names, ", "), placeholders))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer stmt.Close()
+
+	args := make([]any, len(indexes))
+	for _, row := range dump.Rows {
+		for j, i := range indexes {
+			args[j] = row[i]
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			return 0, nil, err
+		}
+	}
+	return len(dump.Rows), missing, nil
+}
+
+// tableColumns returns the columns of a table (empty if the table doesn't exist)
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Policy decides which snapshots to keep. A snapshot is kept if any rule keeps it.
+type Policy struct {
+	KeepLast    int // Most recent snapshots
+	KeepDaily   int // Newest snapshot of each of the last N days with snapshots
+	KeepWeekly  int // Newest snapshot of each of the last N weeks with snapshots
+	KeepMonthly int // Newest snapshot of each of the last N months with snapshots
+}
+
+// IsZero reports whether the policy has no rules (and so keeps everything)
+func (p Policy) IsZero() bool {
+	return p.KeepLast <= 0 && p.KeepDaily <= 0 && p.KeepWeekly <= 0 && p.KeepMonthly <= 0
+}
+
+// Apply splits snapshots into those to keep and those to forget
+func (p Policy) Apply(snapshots []*Snapshot) (keep, forget []*Snapshot) {
+	if p.IsZero() {
+		return snapshots, nil
+	}
+
+	newest := append([]*Snapshot(nil), snapshots...)
+	sort.Slice(newest, func(i, j int) bool {
+		return newest[i].CreatedAt.After(newest[j].CreatedAt)
+	})
+
+	kept := map[string]bool{}
+	for i, s := range newest {
+		if i < p.KeepLast {
+			kept[s.ID] = true
+		}
+	}
+	keepBuckets(newest, p.KeepDaily, kept, func(s *Snapshot) string {
+		return s.CreatedAt.Local().Format("2006-01-02")
+	})
+	keepBuckets(newest, p.KeepWeekly, kept, func(s *Snapshot) string {
+		year, week := s.CreatedAt.Local().ISOWeek()
+		return fmt.Sprintf("%d-%02d", year, week)
+	})
+	keepBuckets(newest, p.KeepMonthly, kept, func(s *Snapshot) string {
+		return s.CreatedAt.Local().Format("2006-01")
+	})
+
+	for _, s := range snapshots {
+		if kept[s.ID] {
+			keep = append(keep, s)
+		} else {
+			forget = append(forget, s)
+		}
+	}
+	return keep, forget
+}
+
+// keepBuckets marks the newest snapshot of each of the first n buckets
+func keepBuckets(newest []*Snapshot, n int, kept map[string]bool, bucket func(*Snapshot) string) {
+	seen := map[string]bool{}
+	for _, s := range newest {
+		if len(seen) >= n {
+			return
+		}
+		key := bucket(s)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept[s.ID] = true
+	}
+}
@@ -0,0 +1,296 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+)
+
+// Snapshot is a point-in-time copy of every table and referenced file
+type Snapshot struct {
+	ID            string       `json:"id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Host          string       `json:"host,omitempty"`
+	SchemaVersion string       `json:"schema_migration,omitempty"` // Latest applied migration, see schema.Version
+	Tables        []TableEntry `json:"tables"`
+	Files         []FileEntry  `json:"files"`
+	AddedBytes    int64        `json:"added_bytes"` // Bytes this snapshot added to the repository
+}
+
+// TableEntry is one table's dump in a snapshot
+type TableEntry struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"` // CREATE TABLE statement
+	Rows   int    `json:"rows"`
+	Object string `json:"object"`
+}
+
+// FileEntry is a file referenced by a *_path column
+type FileEntry struct {
+	Path    string    `json:"path"`
+	Table   string    `json:"table"`
+	Column  string    `json:"column"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Object  string    `json:"object"`
+}
+
+// Rows returns the total number of rows in the snapshot
+func (s *Snapshot) Rows() int {
+	total := 0
+	for _, t := range s.Tables {
+		total += t.Rows
+	}
+	return total
+}
+
+// Table returns the entry for a table
+func (s *Snapshot) Table(name string) (TableEntry, bool) {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return TableEntry{}, false
+}
+
+// objects lists every object the snapshot references
+func (s *Snapshot) objects() []string {
+	var ids []string
+	for _, t := range s.Tables {
+		ids = append(ids, t.Object)
+	}
+	for _, f := range s.Files {
+		ids = append(ids, f.Object)
+	}
+	return ids
+}
+
+// tableDump is the serialized form of a table's rows
+type tableDump struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// sqliteTimeFormat is the format go-sqlite3 stores time values in
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// Options controls what a snapshot includes
+type Options struct {
+	SkipFiles   bool  // Don't copy files referenced by *_path columns
+	MaxFileSize int64 // Skip referenced files larger than this (0 = no limit)
+}
+
+// Create takes a snapshot of every table in the database and the files it references
+func Create(repo *Repository, conn *sql.DB, opts Options) (*Snapshot, error) {
+	now := time.Now().UTC()
+	snap := &Snapshot{ID: newSnapshotID(now), CreatedAt: now}
+	snap.Host, _ = os.Hostname()
+	var err error
+	if snap.SchemaVersion, err = schema.Version(conn); err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	tables, err := listTables(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	seenFiles := map[string]bool{}
+	for _, table := range tables {
+		dump, err := dumpTable(conn, table.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump %s: %w", table.Name, err)
+		}
+		data, err := json.Marshal(dump)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", table.Name, err)
+		}
+		id, added, err := repo.putObject(data)
+		if err != nil {
+			return nil, err
+		}
+		snap.AddedBytes += added
+		table.Rows = len(dump.Rows)
+		table.Object = id
+		snap.Tables = append(snap.Tables, table)
+
+		if opts.SkipFiles {
+			continue
+		}
+		for _, ref := range dump.fileRefs() {
+			if seenFiles[ref.path] {
+				continue
+			}
+			seenFiles[ref.path] = true
+			info, err := os.Stat(ref.path)
+			if err != nil || !info.Mode().IsRegular() || (opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize) {
+				continue
+			}
+			content, err := os.ReadFile(ref.path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", ref.path, err)
+			}
+			id, added, err := repo.putObject(content)
+			if err != nil {
+				return nil, err
+			}
+			snap.AddedBytes += added
+			snap.Files = append(snap.Files, FileEntry{
+				Path: ref.path, Table: table.Name, Column: ref.column,
+				Size: info.Size(), ModTime: info.ModTime().UTC(), Object: id,
+			})
+		}
+	}
+
+	if err := repo.saveSnapshot(snap); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// newSnapshotID returns a sortable, unique snapshot ID
+func newSnapshotID(t time.Time) string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return t.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// listTables returns the user tables of the database with their schema
+func listTables(conn *sql.DB) ([]TableEntry, error) {
+	rows, err := conn.Query(`SELECT name, sql FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableEntry
+	for rows.Next() {
+		var t TableEntry
+		if err := rows.Scan(&t.Name, &t.Schema); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// dumpTable reads all rows of a table in rowid order, so unchanged tables serialize identically
+func dumpTable(conn *sql.DB, table string) (*tableDump, error) {
+	rows, err := conn.Query(fmt.Sprintf("SELECT * FROM %s ORDER BY rowid", quoteIdent(table)))
+	if err != nil {
+		// WITHOUT ROWID tables
+		if rows, err = conn.Query(fmt.Sprintf("SELECT * FROM %s", quoteIdent(table))); err != nil {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	dump := &tableDump{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			values[i] = encodeValue(v)
+		}
+		dump.Rows = append(dump.Rows, values)
+	}
+	return dump, rows.Err()
+}
+
+type fileRef struct {
+	column string
+	path   string
+}
+
+// fileRefs returns the absolute paths stored in *_path columns
+func (d *tableDump) fileRefs() []fileRef {
+	var refs []fileRef
+	for i, column := range d.Columns {
+		if !strings.HasSuffix(column, "_path") {
+			continue
+		}
+		for _, row := range d.Rows {
+			if path, ok := row[i].(string); ok && filepath.IsAbs(path) {
+				refs = append(refs, fileRef{column: column, path: path})
+			}
+		}
+	}
+	return refs
+}
+
+// encodeValue converts a scanned value into its JSON form. Blobs are wrapped so
+// they can be told apart from text.
+func encodeValue(v any) any {
+	switch val := v.(type) {
+	case []byte:
+		return map[string]string{"base64": base64.StdEncoding.EncodeToString(val)}
+	case time.Time:
+		return val.Format(sqliteTimeFormat)
+	}
+	return v
+}
+
+// decodeValue reverses encodeValue on a value decoded with UseNumber
+func decodeValue(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		if s, ok := val["base64"].(string); ok {
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
+
+// decodeTable parses a table dump
+func decodeTable(data []byte) (*tableDump, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var dump tableDump
+	if err := dec.Decode(&dump); err != nil {
+		return nil, err
+	}
+	for _, row := range dump.Rows {
+		if len(row) != len(dump.Columns) {
+			return nil, fmt.Errorf("row has %d values for %d columns", len(row), len(dump.Columns))
+		}
+		for i, v := range row {
+			row[i] = decodeValue(v)
+		}
+	}
+	return &dump, nil
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
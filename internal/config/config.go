@@ -14,12 +14,16 @@ type Config struct {
 	DatabasePath string         `yaml:"database_path"`
 	InvoicesDir  string         `yaml:"invoices_dir"`
 	ContractsDir string         `yaml:"contracts_dir,omitempty"` // Path to contracts directory
+	ReceiptsDir  string         `yaml:"receipts_dir,omitempty"`  // Path to managed expense receipts
 	Language     string         `yaml:"language"`                // e.g., "en", "uk", "de"
 	Invoice      InvoiceConfig  `yaml:"invoice"`
 	PDF          PDFConfig      `yaml:"pdf"`
 	Templates    TemplateConfig `yaml:"templates"`
 	Email        EmailConfig    `yaml:"email"`
+	Tax          TaxConfig      `yaml:"tax,omitempty"`
 	Security     SecurityConfig `yaml:"security"`
+	Import       ImportConfig   `yaml:"import,omitempty"`
+	Backup       BackupConfig   `yaml:"backup,omitempty"`
 }
 
 // ConfigSource indicates where the config was loaded from
@@ -29,6 +33,7 @@ const (
 	SourceDefault ConfigSource = iota
 	SourceLocal                // .ung/config.yaml in current directory
 	SourceGlobal               // ~/.ung/config.yaml
+	SourceProfile              // ~/.ung/profiles/<name>/config.yaml
 )
 
 // LocalUngDir is the name of the local ung directory
@@ -54,8 +59,21 @@ type PDFConfig struct {
 	ShowPageNumber   bool `yaml:"show_page_number"`   // Show page numbers on multi-page documents
 	ShowTaxBreakdown bool `yaml:"show_tax_breakdown"` // Show VAT/tax breakdown
 
+	// Font, empty for the bundled DejaVu Sans which covers Latin, Cyrillic and Greek.
+	// Core fonts (Arial, Helvetica, Times, Courier) are only used for plain ASCII documents.
+	FontFamily     string `yaml:"font_family,omitempty"`      // Core font, or the name for the TTF files below
+	FontRegular    string `yaml:"font_regular,omitempty"`     // Path to a TTF file, e.g. ~/fonts/NotoSans-Regular.ttf
+	FontBold       string `yaml:"font_bold,omitempty"`        // Optional, defaults to the regular file
+	FontItalic     string `yaml:"font_italic,omitempty"`      // Optional, defaults to the regular file
+	FontBoldItalic string `yaml:"font_bold_italic,omitempty"` // Optional, defaults to the bold file
+
+	// Payment QR code, drawn when ShowQRCode is set and the invoice isn't paid
+	QRFormat     string `yaml:"qr_format,omitempty"`      // "epc" (SEPA, default), "swiss" (QR-bill) or "url"
+	QRPaymentURL string `yaml:"qr_payment_url,omitempty"` // Link for "url", e.g. https://pay.example.com/{invoice}?amount={amount}
+	QRLabel      string `yaml:"qr_label,omitempty"`       // Caption above the code
+
 	// Tax settings
-	TaxRate      float64 `yaml:"tax_rate"`      // Tax/VAT rate (e.g., 0.20 for 20%)
+	TaxRate      float64 `yaml:"tax_rate"`      // Rate of the built-in vat tax profile (e.g., 0.20 for 20%)
 	TaxLabel     string  `yaml:"tax_label"`     // e.g., "VAT", "GST", "Tax"
 	TaxInclusive bool    `yaml:"tax_inclusive"` // Whether prices include tax
 
@@ -97,22 +115,84 @@ type InvoiceConfig struct {
 	QuantityLabel    string `yaml:"quantity_label"`    // "Quantity" column header
 	RateLabel        string `yaml:"rate_label"`        // "Rate" column header
 	AmountLabel      string `yaml:"amount_label"`      // "Amount" column header
+
+	EInvoiceValidator string `yaml:"einvoice_validator,omitempty"` // Command that validates exported e-invoices, given the file as its last argument
+}
+
+// TaxConfig holds the tax profiles that clients and contracts are assigned to
+type TaxConfig struct {
+	Default  string                `yaml:"default,omitempty"`  // Profile of clients and contracts without one, empty for no tax
+	Profiles map[string]TaxProfile `yaml:"profiles,omitempty"` // Custom profiles by name, see pkg/tax for the built-in ones
+}
+
+// TaxProfile describes how invoices of a client or contract are taxed
+type TaxProfile struct {
+	Type  string             `yaml:"type"`            // vat, reverse_charge, exempt or sales_tax
+	Rate  float64            `yaml:"rate,omitempty"`  // vat: rate of lines without their own, e.g. 0.19; sales_tax: rate when the state has none below
+	Rates map[string]float64 `yaml:"rates,omitempty"` // sales_tax: rates by state, e.g. CA: 0.0725, over the built-in state rates
+	State string             `yaml:"state,omitempty"` // sales_tax: state used when the client address has none
+	Label string             `yaml:"label,omitempty"` // Tax row label on invoices, default pdf.tax_label
+	Note  string             `yaml:"note,omitempty"`  // Note printed on invoices, default for reverse_charge and exempt
 }
 
 // EmailConfig represents email/SMTP configuration
 type EmailConfig struct {
-	SMTPHost  string `yaml:"smtp_host"`  // SMTP server host
-	SMTPPort  int    `yaml:"smtp_port"`  // SMTP server port
-	Username  string `yaml:"username"`   // SMTP username
-	Password  string `yaml:"password"`   // SMTP password (or app password)
-	FromEmail string `yaml:"from_email"` // Sender email address
-	FromName  string `yaml:"from_name"`  // Sender name
-	UseTLS    bool   `yaml:"use_tls"`    // Use TLS encryption
+	SMTPHost       string `yaml:"smtp_host"`                  // SMTP server host
+	SMTPPort       int    `yaml:"smtp_port"`                  // SMTP server port
+	Username       string `yaml:"username"`                   // SMTP username, empty for relays without login
+	Password       string `yaml:"password"`                   // SMTP password (or app password)
+	FromEmail      string `yaml:"from_email"`                 // Sender email address
+	FromName       string `yaml:"from_name"`                  // Sender name
+	UseTLS         bool   `yaml:"use_tls"`                    // Use implicit TLS when security is empty
+	Security       string `yaml:"security,omitempty"`         // tls (port 465), starttls (port 587) or none
+	Auth           string `yaml:"auth,omitempty"`             // plain (default), login or xoauth2
+	OAuth2Token    string `yaml:"oauth2_token,omitempty"`     // Access token for xoauth2
+	OAuth2TokenCmd string `yaml:"oauth2_token_cmd,omitempty"` // Command printing a fresh access token for xoauth2
+	App            string `yaml:"app,omitempty"`              // Default email client: smtp, apple, outlook or gmail
 }
 
 // SecurityConfig represents database security configuration
 type SecurityConfig struct {
-	EncryptDatabase bool `yaml:"encrypt_database"` // Whether to encrypt database at rest
+	EncryptDatabase bool   `yaml:"encrypt_database"`           // Whether to encrypt database at rest
+	EncryptReceipts bool   `yaml:"encrypt_receipts,omitempty"` // Whether to encrypt stored expense receipts
+	KDF             string `yaml:"kdf,omitempty"`              // Key derivation for new passwords: pbkdf2 (default) or argon2id
+	KeyFile         string `yaml:"key_file,omitempty"`         // Key file that unlocks the database without a password
+	KeychainAccount string `yaml:"keychain_account,omitempty"` // Keychain account holding the password (default database-password)
+}
+
+// BackupConfig represents settings for 'ung sync backup'
+type BackupConfig struct {
+	Dir         string `yaml:"dir,omitempty"`          // Backup repository (default ~/.ung/backups)
+	Encrypt     bool   `yaml:"encrypt,omitempty"`      // Encrypt new repositories (always on when the database is encrypted)
+	KeepLast    int    `yaml:"keep_last,omitempty"`    // Retention: most recent snapshots to keep
+	KeepDaily   int    `yaml:"keep_daily,omitempty"`   // Retention: days to keep one snapshot for
+	KeepWeekly  int    `yaml:"keep_weekly,omitempty"`  // Retention: weeks to keep one snapshot for
+	KeepMonthly int    `yaml:"keep_monthly,omitempty"` // Retention: months to keep one snapshot for
+}
+
+// ImportConfig represents settings for importing data from external sources
+type ImportConfig struct {
+	CalendarRules  []CalendarRule `yaml:"calendar_rules,omitempty"`   // Map calendar events to clients/contracts
+	GitRepos       []GitRepoRule  `yaml:"git_repos,omitempty"`        // Map git repositories to contracts/gigs
+	GitGapMinutes  int            `yaml:"git_gap_minutes,omitempty"`  // Max minutes between commits in one work block (default 120)
+	GitLeadMinutes int            `yaml:"git_lead_minutes,omitempty"` // Minutes of work assumed before a block's first commit (default 30)
+}
+
+// GitRepoRule maps a local git repository to a contract or gig
+type GitRepoRule struct {
+	Repo       string `yaml:"repo"`                  // Repository path or directory name
+	ContractID uint   `yaml:"contract_id,omitempty"` // Contract to assign
+	GigID      uint   `yaml:"gig_id,omitempty"`      // Gig to assign (its client/contract are used)
+}
+
+// CalendarRule maps calendar events to a client or contract.
+// A rule matches when any of its non-empty conditions match; the first matching rule wins.
+type CalendarRule struct {
+	Domain     string `yaml:"domain,omitempty"`      // Attendee/organizer email domain, e.g. "acme.com"
+	Keyword    string `yaml:"keyword,omitempty"`     // Case-insensitive substring of the event title
+	Calendar   string `yaml:"calendar,omitempty"`    // Calendar name (X-WR-CALNAME)
+	ClientID   uint   `yaml:"client_id,omitempty"`   // Client to assign
+	ContractID uint   `yaml:"contract_id,omitempty"` // Contract to assign (implies its client)
 }
 
 var currentConfig *Config
@@ -153,6 +233,8 @@ func GetConfigSourceString() string {
 		return "local (.ung/config.yaml)"
 	case SourceGlobal:
 		return "global (~/.ung/config.yaml)"
+	case SourceProfile:
+		return fmt.Sprintf("profile %s (~/.ung/profiles/%s/config.yaml)", loadedProfile, loadedProfile)
 	default:
 		return "default"
 	}
@@ -165,18 +247,29 @@ func Reload() (*Config, error) {
 }
 
 // Load loads configuration with priority:
-// 1. Local .ung/config.yaml (if not --global and local .ung/ exists)
-// 2. Global ~/.ung/config.yaml
-// 3. Default config (uses global ~/.ung/ paths unless local .ung/ already exists)
+// 1. Profile given with --profile or UNG_PROFILE
+// 2. Local .ung/config.yaml (if not --global and local .ung/ exists)
+// 3. Active profile set with 'ung profile use'
+// 4. Global ~/.ung/config.yaml
+// 5. Default config (uses global ~/.ung/ paths unless local .ung/ already exists)
 func Load() (*Config, error) {
 	if currentConfig != nil {
 		return currentConfig, nil
 	}
 
+	_, err := os.Stat(LocalUngDir)
+	localExists := err == nil
+	loadedProfile = ""
+	if name := requestedProfile(localExists); name != "" && name != DefaultProfile {
+		currentConfig = loadProfile(name)
+		configSource = SourceProfile
+		loadedProfile = name
+		return currentConfig, nil
+	}
+
 	// If not forcing global, try local config first (only if .ung directory exists)
-	if !forceGlobal {
-		// Check if local .ung directory exists
-		if _, err := os.Stat(LocalUngDir); err == nil {
+	if !forceGlobal && profileName == "" {
+		if localExists {
 			localConfigPath := filepath.Join(LocalUngDir, "config.yaml")
 			if _, err := os.Stat(localConfigPath); err == nil {
 				cfg, err := loadFromFile(localConfigPath)
@@ -256,6 +349,11 @@ func getDefaultConfig(useGlobal bool) *Config {
 		basePath = LocalUngDir
 	}
 
+	return defaultConfigIn(basePath)
+}
+
+// defaultConfigIn returns the default configuration with all paths inside basePath
+func defaultConfigIn(basePath string) *Config {
 	return &Config{
 		DatabasePath: filepath.Join(basePath, "ung.db"),
 		InvoicesDir:  filepath.Join(basePath, "invoices"),
@@ -282,7 +380,7 @@ func getDefaultConfig(useGlobal bool) *Config {
 			TextColor:        ColorRGB{R: 60, G: 60, B: 60},   // Dark gray
 			ShowWatermark:    true,
 			ShowLogo:         true,
-			ShowQRCode:       false, // Disabled by default until library is added
+			ShowQRCode:       false,
 			ShowPageNumber:   true,
 			ShowTaxBreakdown: false,
 			TaxRate:          0.0,
@@ -295,6 +393,7 @@ func getDefaultConfig(useGlobal bool) *Config {
 			PaidLabel:        "PAID",
 			DraftLabel:       "DRAFT",
 			OverdueLabel:     "OVERDUE",
+			QRLabel:          "Scan to pay",
 		},
 	}
 }
@@ -320,6 +419,7 @@ func GetDefaultPDFConfig() PDFConfig {
 		PaidLabel:        "PAID",
 		DraftLabel:       "DRAFT",
 		OverdueLabel:     "OVERDUE",
+		QRLabel:          "Scan to pay",
 	}
 }
 
@@ -366,6 +466,24 @@ func Save(cfg *Config, global bool) error {
 		path = filepath.Join(LocalUngDir, "config.yaml")
 	}
 
+	return saveTo(cfg, path)
+}
+
+// SaveActive saves the configuration back to the file it was loaded from:
+// the profile, local or global config
+func SaveActive(cfg *Config) error {
+	switch configSource {
+	case SourceProfile:
+		return saveTo(cfg, filepath.Join(GetProfileDir(loadedProfile), "config.yaml"))
+	case SourceLocal:
+		return Save(cfg, false)
+	default:
+		return Save(cfg, true)
+	}
+}
+
+// saveTo writes the configuration to path
+func saveTo(cfg *Config, path string) error {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return err
@@ -526,6 +644,16 @@ func GetContractsDir() string {
 	return filepath.Join(filepath.Dir(cfg.InvoicesDir), "contracts")
 }
 
+// GetReceiptsDir returns the configured receipts directory
+func GetReceiptsDir() string {
+	cfg, _ := Load()
+	if cfg.ReceiptsDir != "" {
+		return expandPath(cfg.ReceiptsDir)
+	}
+	// Fallback: derive from invoices dir
+	return filepath.Join(filepath.Dir(cfg.InvoicesDir), "receipts")
+}
+
 // IsUsingLocalConfig returns true if the current config is from local .ung directory
 func IsUsingLocalConfig() bool {
 	return configSource == SourceLocal
@@ -539,11 +667,27 @@ func GetActiveConfigPath() string {
 		return absPath
 	case SourceGlobal:
 		return filepath.Join(GetGlobalUngDir(), "config.yaml")
+	case SourceProfile:
+		return filepath.Join(GetProfileDir(loadedProfile), "config.yaml")
 	default:
 		return "(using defaults)"
 	}
 }
 
+// GetConfigDir returns the directory of the active configuration, where
+// templates and other per-workspace files live
+func GetConfigDir() string {
+	Load()
+	switch configSource {
+	case SourceLocal:
+		return LocalUngDir
+	case SourceProfile:
+		return GetProfileDir(loadedProfile)
+	default:
+		return GetGlobalUngDir()
+	}
+}
+
 // GetDatabasePath returns the configured database path
 func GetDatabasePath() string {
 	cfg, _ := Load()
@@ -558,6 +702,11 @@ func GetInvoicesDir() string {
 
 // IsInitialized checks if UNG has been initialized (either local or global .ung directory exists with content)
 func IsInitialized() bool {
+	// A selected profile is initialized once it has been created
+	if GetCurrentProfile() != "" {
+		return isDirectoryInitialized(GetProfileDir(loadedProfile))
+	}
+
 	// Check local .ung directory first
 	if isDirectoryInitialized(LocalUngDir) {
 		return true
@@ -604,6 +753,10 @@ func isDirectoryInitialized(dir string) bool {
 
 // GetInitializedDir returns the path to the initialized .ung directory, or empty string if not initialized
 func GetInitializedDir() string {
+	if GetCurrentProfile() != "" && isDirectoryInitialized(GetProfileDir(loadedProfile)) {
+		return GetProfileDir(loadedProfile)
+	}
+
 	// Check local first
 	if isDirectoryInitialized(LocalUngDir) {
 		absPath, _ := filepath.Abs(LocalUngDir)
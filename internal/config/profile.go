@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile names the plain global ~/.ung/ configuration
+const DefaultProfile = "default"
+
+// activeProfileFile stores the profile chosen with 'ung profile use'
+const activeProfileFile = "active_profile"
+
+// profileName is the profile requested with --profile or UNG_PROFILE
+var profileName string
+
+// loadedProfile is the profile the current config was loaded from
+var loadedProfile string
+
+var validProfileName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+// SetProfile selects a profile for this run, overriding the local workspace
+// and the active profile. An empty name keeps the usual lookup. Names that
+// aren't valid profile names are refused, since they become paths.
+func SetProfile(name string) error {
+	name = strings.TrimSpace(name)
+	if name != "" && name != DefaultProfile {
+		if err := ValidateProfileName(name); err != nil {
+			return err
+		}
+	}
+	profileName = name
+	// Reset cached config so it will be reloaded
+	currentConfig = nil
+	return nil
+}
+
+// ValidateProfileName checks that name can be used as a profile directory
+func ValidateProfileName(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("%q is reserved for the global configuration", DefaultProfile)
+	}
+	if !validProfileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// GetProfilesDir returns the directory holding all profiles
+func GetProfilesDir() string {
+	return filepath.Join(GetGlobalUngDir(), "profiles")
+}
+
+// GetProfileDir returns the directory of a profile
+func GetProfileDir(name string) string {
+	return filepath.Join(GetProfilesDir(), name)
+}
+
+// ProfileExists reports whether a profile has been created
+func ProfileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	info, err := os.Stat(GetProfileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// ListProfiles returns the names of all created profiles, sorted
+func ListProfiles() ([]string, error) {
+	entries, err := os.ReadDir(GetProfilesDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && validProfileName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetActiveProfile returns the profile chosen with 'ung profile use',
+// or DefaultProfile when none was chosen
+func GetActiveProfile() string {
+	data, err := os.ReadFile(filepath.Join(GetGlobalUngDir(), activeProfileFile))
+	if err != nil {
+		return DefaultProfile
+	}
+	name := strings.TrimSpace(string(data))
+	if name == "" || !validProfileName.MatchString(name) {
+		return DefaultProfile
+	}
+	return name
+}
+
+// UseProfile makes name the active profile for later runs
+func UseProfile(name string) error {
+	path := filepath.Join(GetGlobalUngDir(), activeProfileFile)
+	if name == DefaultProfile {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to reset active profile: %w", err)
+		}
+		currentConfig = nil
+		return nil
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist, create it with 'ung profile create %s'", name, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to save active profile: %w", err)
+	}
+	currentConfig = nil
+	return nil
+}
+
+// GetCurrentProfile returns the profile the current config was loaded
+// from, or an empty string for a local or the global configuration
+func GetCurrentProfile() string {
+	if _, err := Load(); err != nil {
+		return ""
+	}
+	return loadedProfile
+}
+
+// CheckProfile returns an error when the requested or active profile
+// doesn't exist, e.g. after its directory was removed
+func CheckProfile() error {
+	_, err := os.Stat(LocalUngDir)
+	name := requestedProfile(err == nil)
+	if name != "" && !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist (see 'ung profile ls')", name)
+	}
+	return nil
+}
+
+// requestedProfile returns the profile Load should use, if any. An explicit
+// profile wins over a local workspace; the active profile doesn't, and
+// --global skips it.
+func requestedProfile(localExists bool) string {
+	if profileName != "" {
+		return profileName
+	}
+	if localExists || forceGlobal {
+		return ""
+	}
+	if name := GetActiveProfile(); name != DefaultProfile {
+		return name
+	}
+	return ""
+}
+
+// loadProfile loads a profile's config, falling back to defaults inside
+// the profile directory
+func loadProfile(name string) *Config {
+	dir := GetProfileDir(name)
+	if cfg, err := loadFromFile(filepath.Join(dir, "config.yaml")); err == nil {
+		return cfg
+	}
+	return getProfileDefaultConfig(name)
+}
+
+// getProfileDefaultConfig returns the default configuration for a profile:
+// its own database, documents, backups and keychain entry
+func getProfileDefaultConfig(name string) *Config {
+	dir := GetProfileDir(name)
+	cfg := defaultConfigIn(dir)
+	cfg.Backup.Dir = filepath.Join(dir, "backups")
+	cfg.Security.KeychainAccount = "database-password:" + name
+	return cfg
+}
+
+// CreateProfile creates a profile directory with a default config. Labels,
+// PDF styling and language are copied from base when it's not nil; paths,
+// email and security settings never are.
+func CreateProfile(name string, base *Config) (*Config, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	if ProfileExists(name) {
+		return nil, fmt.Errorf("profile %q already exists", name)
+	}
+
+	dir := GetProfileDir(name)
+	for _, sub := range []string{"invoices", "contracts", "templates"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create profile directory: %w", err)
+		}
+	}
+
+	cfg := getProfileDefaultConfig(name)
+	if base != nil {
+		cfg.Language = base.Language
+		cfg.Invoice = base.Invoice
+		cfg.PDF = base.PDF
+	}
+	if err := saveTo(cfg, filepath.Join(dir, "config.yaml")); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
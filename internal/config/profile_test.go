@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTempHome points the global ~/.ung/ and the working directory at empty
+// temporary directories
+func useTempHome(t *testing.T) (home, work string) {
+	t.Helper()
+	home, work = t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	oldWd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.Chdir(work)
+
+	currentConfig = nil
+	forceGlobal = false
+	profileName = ""
+	t.Cleanup(func() { SetProfile("") })
+	return home, work
+}
+
+func TestProfiles(t *testing.T) {
+	home, work := useTempHome(t)
+
+	if _, err := CreateProfile("agency", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateProfile("agency", nil); err == nil {
+		t.Error("expected creating a profile twice to fail")
+	}
+	for _, name := range []string{DefaultProfile, "Agency", "../x", ""} {
+		if _, err := CreateProfile(name, nil); err == nil {
+			t.Errorf("expected %q to be refused", name)
+		}
+	}
+
+	// Without an active profile the global config is used
+	cfg, _ := Reload()
+	if configSource != SourceGlobal || GetCurrentProfile() != "" {
+		t.Errorf("expected the global config, got source %d", configSource)
+	}
+	if cfg.DatabasePath != filepath.Join(home, ".ung", "ung.db") {
+		t.Errorf("unexpected database %s", cfg.DatabasePath)
+	}
+
+	if err := UseProfile("personal"); err == nil {
+		t.Error("expected using a missing profile to fail")
+	}
+	if err := UseProfile("agency"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ = Load()
+	agencyDir := filepath.Join(home, ".ung", "profiles", "agency")
+	if GetCurrentProfile() != "agency" || cfg.DatabasePath != filepath.Join(agencyDir, "ung.db") {
+		t.Errorf("expected the agency database, got %s", cfg.DatabasePath)
+	}
+	if cfg.Security.KeychainAccount != "database-password:agency" {
+		t.Errorf("expected a keychain account per profile, got %q", cfg.Security.KeychainAccount)
+	}
+	if !IsInitialized() {
+		t.Error("expected a created profile to count as initialized")
+	}
+
+	// Changes are saved into the profile
+	cfg.Email.FromEmail = "billing@agency.test"
+	if err := SaveActive(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ = Reload()
+	if cfg.Email.FromEmail != "billing@agency.test" {
+		t.Error("expected the email account to be saved in the profile")
+	}
+	if _, err := os.Stat(filepath.Join(home, ".ung", "config.yaml")); err == nil {
+		t.Error("expected the global config to be left alone")
+	}
+
+	// --global skips the active profile
+	SetForceGlobal(true)
+	if Load(); GetCurrentProfile() != "" {
+		t.Error("expected --global to skip the active profile")
+	}
+	SetForceGlobal(false)
+
+	// A local workspace wins over the active profile, --profile wins over both
+	os.Mkdir(filepath.Join(work, LocalUngDir), 0755)
+	if Reload(); configSource != SourceLocal {
+		t.Errorf("expected the local workspace, got source %d", configSource)
+	}
+	SetProfile("agency")
+	if Load(); GetCurrentProfile() != "agency" {
+		t.Error("expected --profile to win over the local workspace")
+	}
+	SetProfile(DefaultProfile)
+	if Load(); configSource != SourceGlobal {
+		t.Errorf("expected --profile default to use the global config, got source %d", configSource)
+	}
+
+	SetProfile("personal")
+	if err := CheckProfile(); err == nil {
+		t.Error("expected a missing profile to be reported")
+	}
+	for _, name := range []string{"../x", "Agency", "a/b"} {
+		if err := SetProfile(name); err == nil {
+			t.Errorf("expected --profile %q to be refused", name)
+		}
+	}
+	if GetCurrentProfile() != "personal" {
+		t.Errorf("expected a refused name to leave the profile alone, got %q", GetCurrentProfile())
+	}
+
+	names, _ := ListProfiles()
+	if len(names) != 1 || names[0] != "agency" {
+		t.Errorf("expected [agency], got %v", names)
+	}
+	if err := UseProfile(DefaultProfile); err != nil || GetActiveProfile() != DefaultProfile {
+		t.Errorf("expected to switch back to the default profile, got %v", err)
+	}
+	os.WriteFile(filepath.Join(GetGlobalUngDir(), activeProfileFile), []byte("../x\n"), 0644)
+	if name := GetActiveProfile(); name != DefaultProfile {
+		t.Errorf("expected an invalid active profile to be ignored, got %q", name)
+	}
+}
+
+func TestCreateProfileCopiesSettings(t *testing.T) {
+	useTempHome(t)
+
+	base := getDefaultConfig(true)
+	base.Language = "de"
+	base.Invoice.InvoiceLabel = "RECHNUNG"
+	base.Email.Password = "secret"
+	base.Security.EncryptDatabase = true
+
+	cfg, err := CreateProfile("personal", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Language != "de" || cfg.Invoice.InvoiceLabel != "RECHNUNG" {
+		t.Error("expected labels to be copied")
+	}
+	if cfg.Email.Password != "" || cfg.Security.EncryptDatabase {
+		t.Error("expected email and security settings not to be copied")
+	}
+	for _, sub := range []string{"invoices", "contracts", "templates", "config.yaml"} {
+		if _, err := os.Stat(filepath.Join(GetProfileDir("personal"), sub)); err != nil {
+			t.Errorf("expected %s in the profile: %v", sub, err)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+// Package daemon keeps a database open and answers JSON-RPC 2.0 requests
+// from editors, status bar widgets and scripts, so they don't start (and
+// possibly decrypt) a new ung process for every refresh.
+//
+// Two transports share the same methods:
+//
+//   - A stream socket (a Unix socket by default) carrying one JSON value
+//     per line in each direction. Connections that call events.subscribe
+//     also receive notifications.
+//   - Optionally HTTP on localhost: POST /rpc takes a single request and
+//     GET /events streams the same notifications as server-sent events.
+//
+// The only notification is timer.changed, sent whenever a tracking session
+// starts or stops, including from another ung process, with the running
+// timer (or null) as {"timer": ...}.
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/dashboard"
+	"github.com/Andriiklymiuk/ung/internal/web"
+	"gorm.io/gorm"
+)
+
+// EventTimerChanged is sent when the running tracking session changes
+const EventTimerChanged = "timer.changed"
+
+// Standard JSON-RPC error codes, and CodeFailed for requests the method
+// refused, e.g. starting a second timer
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternal       = -32603
+	CodeFailed         = -32000
+)
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func invalidParams(format string, args ...any) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+func failed(format string, args ...any) *Error {
+	return &Error{Code: CodeFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is a message the daemon sends without being asked
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Options configures a Server
+type Options struct {
+	// Version is reported by ping
+	Version string
+	// Token, when set, is required from HTTP clients in an
+	// "Authorization: Bearer" header or a ?token= query parameter.
+	// Socket clients are trusted: only the owner can open the socket.
+	Token string
+	// PollInterval is how often the database is checked for timers started
+	// or stopped by other processes, one second by default
+	PollInterval time.Duration
+	// AfterWrite runs after a change was made, e.g. to flush an encrypted
+	// database to disk
+	AfterWrite func() error
+	// Acquire and Release, when set, bracket every use of the database, e.g.
+	// to hold the lock of an encrypted database only while a request is
+	// answered so other ung commands can use it in between
+	Acquire func() error
+	Release func() error
+}
+
+// Server answers requests against one database
+type Server struct {
+	db      *gorm.DB
+	opts    Options
+	methods map[string]method
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]bool
+	timer       *dashboard.Timer
+	timerKnown  bool
+}
+
+// method handles one call. conn is nil for HTTP requests.
+type method func(conn *streamConn, params json.RawMessage) (any, error)
+
+// subscriber receives notifications until it's removed
+type subscriber struct {
+	send func(Notification) error
+}
+
+// New returns a server for db
+func New(db *gorm.DB, opts Options) *Server {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	s := &Server{db: db, opts: opts, subscribers: map[*subscriber]bool{}}
+	s.methods = s.registerMethods()
+	return s
+}
+
+// Watch polls the database for timer changes until ctx is done
+func (s *Server) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	s.checkTimer()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkTimer()
+		}
+	}
+}
+
+// checkTimer notifies subscribers when the running timer differs from the
+// one last seen
+func (s *Server) checkTimer() {
+	var timer *dashboard.Timer
+	if err := s.use(func() (err error) {
+		timer, err = dashboard.ActiveTimer(s.db)
+		return err
+	}); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	changed := !s.timerKnown || !sameTimer(s.timer, timer)
+	s.timer, s.timerKnown = timer, true
+	s.mu.Unlock()
+
+	if changed {
+		s.broadcast(timerChanged(timer))
+	}
+}
+
+// currentTimer reads the running timer, nil when none runs or it can't be read
+func (s *Server) currentTimer() *dashboard.Timer {
+	var timer *dashboard.Timer
+	s.use(func() (err error) {
+		timer, err = dashboard.ActiveTimer(s.db)
+		return err
+	})
+	return timer
+}
+
+// use runs fn between the Acquire and Release hooks
+func (s *Server) use(fn func() error) error {
+	if s.opts.Acquire != nil {
+		if err := s.opts.Acquire(); err != nil {
+			return err
+		}
+	}
+	err := fn()
+	if s.opts.Release != nil {
+		if releaseErr := s.opts.Release(); err == nil {
+			err = releaseErr
+		}
+	}
+	return err
+}
+
+func sameTimer(a, b *dashboard.Timer) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID && a.Started.Equal(b.Started)
+}
+
+func timerChanged(timer *dashboard.Timer) Notification {
+	return Notification{JSONRPC: "2.0", Method: EventTimerChanged, Params: map[string]any{"timer": timer}}
+}
+
+func (s *Server) subscribe(sub *subscriber) {
+	s.mu.Lock()
+	s.subscribers[sub] = true
+	s.mu.Unlock()
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	delete(s.subscribers, sub)
+	s.mu.Unlock()
+}
+
+func (s *Server) broadcast(n Notification) {
+	s.mu.Lock()
+	subs := make([]*subscriber, 0, len(s.subscribers))
+	for sub := range s.subscribers {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		if err := sub.send(n); err != nil {
+			s.unsubscribe(sub)
+		}
+	}
+}
+
+// call runs one request and returns its response, or nil for a
+// notification (a request without an ID)
+func (s *Server) call(conn *streamConn, raw []byte) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}}
+	}
+	id := req.ID
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &response{JSONRPC: "2.0", ID: id, Error: &Error{Code: CodeInvalidRequest, Message: `expected "jsonrpc": "2.0" and a method`}}
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: id}
+	if m, ok := s.methods[req.Method]; !ok {
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown method %s", req.Method)}
+	} else if result, err := s.invoke(m, conn, req.Params); err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternal, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Result, resp.Error = nil, &Error{Code: CodeInternal, Message: err.Error()}
+	}
+
+	if len(req.ID) == 0 {
+		return nil
+	}
+	return resp
+}
+
+// invoke runs a method with the database in use
+func (s *Server) invoke(m method, conn *streamConn, params json.RawMessage) (any, error) {
+	var result any
+	err := s.use(func() (err error) {
+		result, err = m(conn, params)
+		return err
+	})
+	return result, err
+}
+
+// Serve accepts stream connections until the listener is closed
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(c)
+	}
+}
+
+// streamConn is a socket connection; writes come from its own requests and
+// from notifications, so they're serialized
+type streamConn struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	sub   *subscriber
+	greet bool // send the current timer after the subscribe response
+}
+
+func (c *streamConn) write(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enc.Encode(v)
+}
+
+func (s *Server) serveConn(nc net.Conn) {
+	defer nc.Close()
+	conn := &streamConn{enc: json.NewEncoder(nc)}
+	defer func() {
+		if conn.sub != nil {
+			s.unsubscribe(conn.sub)
+		}
+	}()
+
+	dec := json.NewDecoder(nc)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				conn.write(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			}
+			return
+		}
+		resp := s.call(conn, raw)
+		if resp != nil {
+			if err := conn.write(resp); err != nil {
+				return
+			}
+		}
+		// The current timer follows the subscribe response, so a status bar
+		// can draw itself right away
+		if conn.greet {
+			conn.greet = false
+			if err := conn.sub.send(timerChanged(s.currentTimer())); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Handler returns the HTTP transport: POST /rpc and GET /events
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /rpc", s.handleRPC)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A page whose DNS name was rebound to 127.0.0.1 counts as same-origin
+		// in the browser, but still sends its own name as Host
+		if !web.IsLoopbackHost(r.Host) {
+			http.Error(w, "the daemon is only served on localhost", http.StatusMisdirectedRequest)
+			return
+		}
+		if s.opts.Token != "" && !s.authorized(r) {
+			http.Error(w, "a valid token is required", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		// EventSource can't set headers
+		token = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	// Browsers can't send JSON cross-origin without a preflight, which is
+	// never answered, so web pages can't make calls
+	if mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); mediaType != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := s.call(nil, body)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	events := make(chan Notification, 16)
+	sub := &subscriber{send: func(n Notification) error {
+		select {
+		case events <- n:
+			return nil
+		default:
+			return errors.New("event stream is not keeping up")
+		}
+	}}
+	s.subscribe(sub)
+	defer s.unsubscribe(sub)
+
+	events <- timerChanged(s.currentTimer())
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case n := <-events:
+			data, _ := json.Marshal(n.Params)
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", n.Method, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
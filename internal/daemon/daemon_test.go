@@ -0,0 +1,377 @@
+package daemon
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) (*sql.DB, *gorm.DB) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ung.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := schema.Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`
+		INSERT INTO companies (id, name, email) VALUES (1, 'Me LLC', 'me@example.com');
+		INSERT INTO clients (id, name, email) VALUES (1, 'Acme', 'ap@acme.test');
+		INSERT INTO invoices (id, invoice_num, company_id, amount, status) VALUES (1, 'INV-1', 1, 500, 'pending');
+		INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (1, 1);
+	`); err != nil {
+		t.Fatal(err)
+	}
+	gdb, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, gdb
+}
+
+// message is anything the daemon writes: a response or a notification
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+type client struct {
+	t      *testing.T
+	conn   net.Conn
+	dec    *json.Decoder
+	nextID int
+	events []message
+}
+
+// dial starts s on a loopback socket and connects to it
+func dial(t *testing.T, s *Server) *client {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go s.Serve(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, dec: json.NewDecoder(conn)}
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var m message
+	if err := c.dec.Decode(&m); err != nil {
+		c.t.Fatalf("reading from the daemon: %v", err)
+	}
+	return m
+}
+
+// call sends a request and returns its response, keeping notifications
+// that arrive in between
+func (c *client) call(method string, params any) message {
+	c.t.Helper()
+	c.nextID++
+	req := map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		m := c.read()
+		if m.ID == nil {
+			c.events = append(c.events, m)
+			continue
+		}
+		if *m.ID != c.nextID {
+			c.t.Fatalf("expected response %d, got %d", c.nextID, *m.ID)
+		}
+		return m
+	}
+}
+
+// event returns the next notification
+func (c *client) event() message {
+	c.t.Helper()
+	if len(c.events) > 0 {
+		m := c.events[0]
+		c.events = c.events[1:]
+		return m
+	}
+	m := c.read()
+	if m.ID != nil {
+		c.t.Fatalf("expected a notification, got response %d", *m.ID)
+	}
+	return m
+}
+
+// timerOf decodes the timer of a timer.changed notification
+func timerOf(t *testing.T, m message) map[string]any {
+	t.Helper()
+	if m.Method != EventTimerChanged {
+		t.Fatalf("expected %s, got %q", EventTimerChanged, m.Method)
+	}
+	var p struct {
+		Timer map[string]any `json:"timer"`
+	}
+	if err := json.Unmarshal(m.Params, &p); err != nil {
+		t.Fatal(err)
+	}
+	return p.Timer
+}
+
+func TestTrackingOverSocket(t *testing.T) {
+	_, gdb := openTestDB(t)
+	s := New(gdb, Options{})
+	c := dial(t, s)
+
+	if m := c.call("events.subscribe", nil); m.Error != nil {
+		t.Fatal(m.Error)
+	}
+	if timer := timerOf(t, c.event()); timer != nil {
+		t.Errorf("expected no timer when subscribing, got %v", timer)
+	}
+
+	m := c.call("tracking.start", map[string]any{"client_id": 1, "project": "Website"})
+	if m.Error != nil {
+		t.Fatal(m.Error)
+	}
+	var started Session
+	json.Unmarshal(m.Result, &started)
+	if started.Client != "Acme" || started.Project != "Website" || !started.Billable || started.End != nil {
+		t.Errorf("unexpected session %+v", started)
+	}
+	if timer := timerOf(t, c.event()); timer == nil || timer["project"] != "Website" || timer["client"] != "Acme" {
+		t.Errorf("expected the Website timer, got %v", timer)
+	}
+
+	if m := c.call("tracking.start", nil); m.Error == nil || m.Error.Code != CodeFailed {
+		t.Errorf("expected starting a second timer to fail, got %+v", m)
+	}
+
+	m = c.call("tracking.current", nil)
+	var current Session
+	json.Unmarshal(m.Result, &current)
+	if current.ID != started.ID {
+		t.Errorf("expected session %d to be current, got %s", started.ID, m.Result)
+	}
+
+	m = c.call("tracking.stop", nil)
+	var stopped Session
+	json.Unmarshal(m.Result, &stopped)
+	if m.Error != nil || stopped.End == nil {
+		t.Errorf("expected the session to stop, got %+v", m)
+	}
+	if timer := timerOf(t, c.event()); timer != nil {
+		t.Errorf("expected no timer after stopping, got %v", timer)
+	}
+
+	if m := c.call("tracking.current", nil); string(m.Result) != "null" {
+		t.Errorf("expected no current session, got %s", m.Result)
+	}
+	if m := c.call("tracking.stop", nil); m.Error == nil || m.Error.Code != CodeFailed {
+		t.Errorf("expected stopping without a timer to fail, got %+v", m)
+	}
+
+	m = c.call("tracking.list", map[string]any{"limit": 10})
+	var sessions []Session
+	json.Unmarshal(m.Result, &sessions)
+	if len(sessions) != 1 || sessions[0].ID != started.ID {
+		t.Errorf("expected the stopped session in the list, got %s", m.Result)
+	}
+}
+
+// Timers started by another process are noticed by polling
+func TestWatchNotifiesExternalChanges(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	s := New(gdb, Options{PollInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx)
+
+	c := dial(t, s)
+	c.call("events.subscribe", nil)
+	timerOf(t, c.event())
+
+	if _, err := conn.Exec("INSERT INTO tracking_sessions (client_id, project_name, start_time) VALUES (1, 'From the CLI', ?)", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if timer := timerOf(t, c.event()); timer == nil || timer["project"] != "From the CLI" {
+		t.Errorf("expected the timer started elsewhere, got %v", timer)
+	}
+
+	if _, err := conn.Exec("UPDATE tracking_sessions SET end_time = ?, duration = 60", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if timer := timerOf(t, c.event()); timer != nil {
+		t.Errorf("expected the timer to stop, got %v", timer)
+	}
+}
+
+func TestInvoicesAndDashboard(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	writes := 0
+	s := New(gdb, Options{AfterWrite: func() error { writes++; return nil }})
+	c := dial(t, s)
+
+	m := c.call("invoices.list", map[string]any{"status": "open"})
+	if !strings.Contains(string(m.Result), `"invoice_num":"INV-1"`) || !strings.Contains(string(m.Result), `"client":"Acme"`) {
+		t.Errorf("expected INV-1 for Acme, got %s", m.Result)
+	}
+	if m := c.call("invoices.list", map[string]any{"status": "bogus"}); m.Error == nil || m.Error.Code != CodeInvalidParams {
+		t.Errorf("expected invalid params for an unknown filter, got %+v", m)
+	}
+
+	if m := c.call("invoices.mark", map[string]any{"id": 1, "status": "paid"}); m.Error != nil {
+		t.Fatal(m.Error)
+	}
+	var paid sql.NullTime
+	conn.QueryRow("SELECT paid_date FROM invoices WHERE id = 1").Scan(&paid)
+	if !paid.Valid {
+		t.Error("expected a payment date")
+	}
+	if writes != 1 {
+		t.Errorf("expected AfterWrite to run once, ran %d times", writes)
+	}
+	if m := c.call("invoices.mark", map[string]any{"id": 1, "status": "lost"}); m.Error == nil || m.Error.Code != CodeInvalidParams {
+		t.Errorf("expected invalid params for an unknown status, got %+v", m)
+	}
+
+	m = c.call("dashboard.summary", nil)
+	var sum struct {
+		Paid float64 `json:"paid"`
+	}
+	json.Unmarshal(m.Result, &sum)
+	if sum.Paid != 500 {
+		t.Errorf("expected 500 paid, got %s", m.Result)
+	}
+
+	if m := c.call("clients.list", nil); !strings.Contains(string(m.Result), `"name":"Acme"`) {
+		t.Errorf("expected Acme, got %s", m.Result)
+	}
+	if m := c.call("no.such.method", nil); m.Error == nil || m.Error.Code != CodeMethodNotFound {
+		t.Errorf("expected method not found, got %+v", m)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	_, gdb := openTestDB(t)
+	h := New(gdb, Options{Token: "s3cret", Version: "1.2.3"}).Handler()
+
+	post := func(body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:7531/rpc", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"jsonrpc":"2.0","id":1,"method":"ping"}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", rec.Code)
+	}
+	rec := post(`{"jsonrpc":"2.0","id":1,"method":"ping"}`, "s3cret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"version":"1.2.3"`) {
+		t.Errorf("expected a pong, got %d %s", rec.Code, rec.Body)
+	}
+	req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:7531/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	req.Header.Set("Content-Type", "text/plain")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected form posts to be refused, got %d", rec.Code)
+	}
+	if rec := post(`{"jsonrpc":"2.0","method":"ping"}`, "s3cret"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected no response to a notification, got %d", rec.Code)
+	}
+	if rec := post(`{not json`, "s3cret"); !strings.Contains(rec.Body.String(), `"code":-32700`) {
+		t.Errorf("expected a parse error, got %s", rec.Body)
+	}
+	if rec := post(`{"id":1,"method":"ping"}`, "s3cret"); !strings.Contains(rec.Body.String(), `"code":-32600`) {
+		t.Errorf("expected an invalid request error, got %s", rec.Body)
+	}
+	if rec := post(`{"jsonrpc":"2.0","id":1,"method":"events.subscribe"}`, "s3cret"); !strings.Contains(rec.Body.String(), "GET /events") {
+		t.Errorf("expected subscribing over HTTP to point at /events, got %s", rec.Body)
+	}
+
+	// A page rebound to 127.0.0.1 sends its own name as Host
+	req = httptest.NewRequest(http.MethodPost, "http://rebind.example:7531/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"clients.list"}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMisdirectedRequest {
+		t.Errorf("expected other hosts to be refused, got %d", rec.Code)
+	}
+}
+
+func TestDatabaseIsOnlyUsedBetweenAcquireAndRelease(t *testing.T) {
+	_, gdb := openTestDB(t)
+	var mu sync.Mutex
+	held, acquired := 0, 0
+	s := New(gdb, Options{
+		Acquire: func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			held++
+			acquired++
+			return nil
+		},
+		Release: func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			held--
+			return nil
+		},
+	})
+	c := dial(t, s)
+
+	c.call("tracking.start", map[string]any{"client_id": 1})
+	c.call("invoices.list", nil)
+	s.checkTimer()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if held != 0 || acquired < 3 {
+		t.Errorf("expected every use to be released, %d of %d still held", held, acquired)
+	}
+}
+
+func TestLockedDatabaseIsReported(t *testing.T) {
+	_, gdb := openTestDB(t)
+	s := New(gdb, Options{Acquire: func() error { return errors.New("database is in use by another ung process") }})
+	c := dial(t, s)
+
+	if m := c.call("tracking.current", nil); m.Error == nil || m.Error.Code != CodeInternal || !strings.Contains(m.Error.Message, "in use") {
+		t.Errorf("expected the lock error, got %+v", m)
+	}
+}
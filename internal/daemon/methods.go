@@ -0,0 +1,316 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/dashboard"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Session is a tracking session as the daemon returns it
+type Session struct {
+	ID         uint       `json:"id"`
+	ClientID   *uint      `json:"client_id"`
+	Client     string     `json:"client"`
+	ContractID *uint      `json:"contract_id"`
+	Contract   string     `json:"contract"`
+	Project    string     `json:"project"`
+	Start      time.Time  `json:"start"`
+	End        *time.Time `json:"end"`
+	Duration   int        `json:"duration"` // seconds, so far for a running session
+	Billable   bool       `json:"billable"`
+	Notes      string     `json:"notes"`
+	Invoiced   bool       `json:"invoiced"`
+}
+
+func newSession(ts models.TrackingSession, now time.Time) *Session {
+	s := &Session{
+		ID:         ts.ID,
+		ClientID:   ts.ClientID,
+		ContractID: ts.ContractID,
+		Project:    ts.ProjectName,
+		Start:      ts.StartTime,
+		End:        ts.EndTime,
+		Billable:   ts.Billable,
+		Notes:      ts.Notes,
+		Invoiced:   strings.Contains(ts.Notes, "[Invoiced:"),
+	}
+	if ts.Client != nil {
+		s.Client = ts.Client.Name
+	}
+	if ts.Contract != nil {
+		s.Contract = ts.Contract.Name
+	}
+	switch {
+	case ts.Duration != nil:
+		s.Duration = *ts.Duration
+	case ts.EndTime == nil:
+		s.Duration = int(now.Sub(ts.StartTime).Seconds())
+	}
+	return s
+}
+
+// params decodes the parameters of a call into v; calls may omit them
+func params(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return invalidParams("invalid params: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) registerMethods() map[string]method {
+	return map[string]method{
+		"ping":              s.ping,
+		"events.subscribe":  s.eventsSubscribe,
+		"tracking.current":  s.trackingCurrent,
+		"tracking.start":    s.trackingStart,
+		"tracking.stop":     s.trackingStop,
+		"tracking.list":     s.trackingList,
+		"clients.list":      s.clientsList,
+		"invoices.list":     s.invoicesList,
+		"invoices.mark":     s.invoicesMark,
+		"dashboard.summary": s.dashboardSummary,
+		"dashboard.revenue": s.dashboardRevenue,
+		"dashboard.unbilled": func(*streamConn, json.RawMessage) (any, error) {
+			return dashboard.UnbilledTime(s.db)
+		},
+	}
+}
+
+// written runs AfterWrite and reports the timer, which may have changed
+func (s *Server) written() error {
+	s.checkTimer()
+	if s.opts.AfterWrite != nil {
+		return s.opts.AfterWrite()
+	}
+	return nil
+}
+
+func (s *Server) ping(*streamConn, json.RawMessage) (any, error) {
+	return map[string]any{"version": s.opts.Version, "pid": os.Getpid()}, nil
+}
+
+func (s *Server) eventsSubscribe(conn *streamConn, _ json.RawMessage) (any, error) {
+	if conn == nil {
+		return nil, failed("subscribe over the socket, or open GET /events over HTTP")
+	}
+	if conn.sub == nil {
+		conn.sub = &subscriber{send: func(n Notification) error { return conn.write(n) }}
+		conn.greet = true
+		s.subscribe(conn.sub)
+	}
+	return map[string]any{"events": []string{EventTimerChanged}}, nil
+}
+
+// session loads a tracking session with its client and contract
+func (s *Server) session(id uint) (*Session, error) {
+	var ts models.TrackingSession
+	if err := s.db.Preload("Client").Preload("Contract").First(&ts, id).Error; err != nil {
+		return nil, err
+	}
+	return newSession(ts, time.Now()), nil
+}
+
+func (s *Server) trackingCurrent(*streamConn, json.RawMessage) (any, error) {
+	var ts models.TrackingSession
+	err := s.db.Preload("Client").Preload("Contract").
+		Where("end_time IS NULL").Order("start_time DESC").First(&ts).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newSession(ts, time.Now()), nil
+}
+
+func (s *Server) trackingStart(_ *streamConn, raw json.RawMessage) (any, error) {
+	var p struct {
+		ClientID   uint   `json:"client_id"`
+		ContractID uint   `json:"contract_id"`
+		Project    string `json:"project"`
+		Notes      string `json:"notes"`
+		Billable   *bool  `json:"billable"`
+	}
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+
+	var active int64
+	if err := s.db.Model(&models.TrackingSession{}).Where("end_time IS NULL").Count(&active).Error; err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, failed("there is already an active tracking session, stop it first")
+	}
+
+	ts := models.TrackingSession{
+		ProjectName: p.Project,
+		StartTime:   time.Now(),
+		Billable:    p.Billable == nil || *p.Billable,
+		Notes:       p.Notes,
+	}
+	if p.ContractID != 0 {
+		var contract models.Contract
+		if err := s.db.First(&contract, p.ContractID).Error; err != nil {
+			return nil, invalidParams("contract %d not found", p.ContractID)
+		}
+		ts.ContractID = &contract.ID
+		if p.ClientID == 0 {
+			p.ClientID = contract.ClientID
+		}
+	}
+	if p.ClientID != 0 {
+		var client models.Client
+		if err := s.db.First(&client, p.ClientID).Error; err != nil {
+			return nil, invalidParams("client %d not found", p.ClientID)
+		}
+		ts.ClientID = &client.ID
+	}
+
+	if err := s.db.Create(&ts).Error; err != nil {
+		return nil, err
+	}
+	// GORM leaves false to the column default, which is billable
+	if !ts.Billable {
+		if err := s.db.Model(&ts).Update("billable", false).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := s.written(); err != nil {
+		return nil, err
+	}
+	return s.session(ts.ID)
+}
+
+func (s *Server) trackingStop(*streamConn, json.RawMessage) (any, error) {
+	var ts models.TrackingSession
+	err := s.db.Where("end_time IS NULL").Order("start_time DESC").First(&ts).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, failed("no active tracking session found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	duration := int(end.Sub(ts.StartTime).Seconds())
+	err = s.db.Model(&ts).Updates(map[string]any{"end_time": end, "duration": duration}).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.written(); err != nil {
+		return nil, err
+	}
+	return s.session(ts.ID)
+}
+
+func (s *Server) trackingList(_ *streamConn, raw json.RawMessage) (any, error) {
+	p := struct {
+		Unbilled bool `json:"unbilled"`
+		Limit    int  `json:"limit"`
+	}{Limit: 50}
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	if p.Limit < 1 || p.Limit > 1000 {
+		return nil, invalidParams("limit must be between 1 and 1000")
+	}
+
+	query := s.db.Preload("Client").Preload("Contract").Order("start_time DESC").Limit(p.Limit)
+	if p.Unbilled {
+		query = query.Where("billable = 1 AND (notes NOT LIKE '%[Invoiced:%' OR notes IS NULL)")
+	}
+	var sessions []models.TrackingSession
+	if err := query.Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]*Session, 0, len(sessions))
+	for _, ts := range sessions {
+		result = append(result, newSession(ts, now))
+	}
+	return result, nil
+}
+
+func (s *Server) clientsList(*streamConn, json.RawMessage) (any, error) {
+	clients := []models.Client{}
+	err := s.db.Order("name").Find(&clients).Error
+	return clients, err
+}
+
+func (s *Server) invoicesList(_ *streamConn, raw json.RawMessage) (any, error) {
+	var p struct {
+		Status string `json:"status"`
+	}
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	invoices, err := dashboard.Invoices(s.db, p.Status, time.Now())
+	if errors.Is(err, dashboard.ErrUnknownFilter) {
+		return nil, invalidParams("%v", err)
+	}
+	return invoices, err
+}
+
+func (s *Server) invoicesMark(_ *streamConn, raw json.RawMessage) (any, error) {
+	var p struct {
+		ID     uint                 `json:"id"`
+		Status models.InvoiceStatus `json:"status"`
+	}
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	switch p.Status {
+	case models.StatusPending, models.StatusSent, models.StatusPaid, models.StatusOverdue:
+	default:
+		return nil, invalidParams("invalid status %q (valid: pending, sent, paid, overdue)", p.Status)
+	}
+
+	var inv models.Invoice
+	if err := s.db.First(&inv, p.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalidParams("invoice %d not found", p.ID)
+		}
+		return nil, err
+	}
+
+	// Same as 'ung invoice mark': only paid invoices have a payment date
+	updates := map[string]any{"status": p.Status, "paid_date": nil}
+	if p.Status == models.StatusPaid {
+		updates["paid_date"] = time.Now()
+	}
+	if err := s.db.Model(&inv).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	if err := s.written(); err != nil {
+		return nil, err
+	}
+	return map[string]any{"id": inv.ID, "invoice_num": inv.InvoiceNum, "status": p.Status}, nil
+}
+
+func (s *Server) dashboardSummary(*streamConn, json.RawMessage) (any, error) {
+	return dashboard.Summarize(s.db, time.Now())
+}
+
+func (s *Server) dashboardRevenue(_ *streamConn, raw json.RawMessage) (any, error) {
+	p := struct {
+		Months int `json:"months"`
+	}{Months: 12}
+	if err := params(raw, &p); err != nil {
+		return nil, err
+	}
+	if p.Months < 1 || p.Months > 120 {
+		return nil, invalidParams("months must be between 1 and 120")
+	}
+	return dashboard.Revenue(s.db, time.Now(), p.Months)
+}
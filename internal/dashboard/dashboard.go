@@ -0,0 +1,324 @@
+// Package dashboard computes the numbers shown by the dashboards outside the
+// terminal: the web page of 'ung serve' and the editor integrations talking
+// to 'ung daemon'. Both read the same figures, so they always agree.
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"gorm.io/gorm"
+)
+
+// ErrUnknownFilter is returned for an invoice filter Invoices doesn't know
+var ErrUnknownFilter = errors.New("unknown invoice filter")
+
+// unbilledFilter selects billable sessions that no invoice has claimed yet;
+// invoicing from time appends "[Invoiced: ...]" to a session's notes
+const unbilledFilter = `ts.billable = 1
+	AND ts.deleted_at IS NULL
+	AND ts.end_time IS NOT NULL
+	AND (ts.notes NOT LIKE '%[Invoiced:%' OR ts.notes IS NULL)`
+
+// sessionHours falls back to the duration for sessions without hours
+const sessionHours = "COALESCE(ts.hours, ts.duration / 3600.0, 0)"
+
+// Summary is the headline numbers of the dashboard
+type Summary struct {
+	Paid              float64 `json:"paid"`
+	Pending           float64 `json:"pending"`
+	Overdue           float64 `json:"overdue"`
+	OpenInvoices      int     `json:"open_invoices"`
+	OverdueInvoices   int     `json:"overdue_invoices"`
+	UnbilledHours     float64 `json:"unbilled_hours"`
+	UnbilledAmount    float64 `json:"unbilled_amount"`
+	RevenueThisMonth  float64 `json:"revenue_this_month"`
+	ExpensesThisMonth float64 `json:"expenses_this_month"`
+	HoursThisMonth    float64 `json:"hours_this_month"`
+	ActiveTimer       *Timer  `json:"active_timer"`
+}
+
+// Timer is a tracking session that is still running
+type Timer struct {
+	ID      uint      `json:"id"`
+	Client  string    `json:"client"`
+	Project string    `json:"project"`
+	Started time.Time `json:"started"`
+}
+
+// Month is the paid revenue and expenses of one calendar month
+type Month struct {
+	Month    string  `json:"month"` // 2006-01
+	Revenue  float64 `json:"revenue"`
+	Expenses float64 `json:"expenses"`
+}
+
+// Unbilled is the uninvoiced time of one client and contract
+type Unbilled struct {
+	Client   string   `json:"client"`
+	Contract string   `json:"contract"`
+	Sessions int      `json:"sessions"`
+	Hours    float64  `json:"hours"`
+	Rate     *float64 `json:"rate"`
+	Amount   float64  `json:"amount"` // hours × rate, 0 without an hourly rate
+	Currency string   `json:"currency"`
+}
+
+// Invoice is an invoice as the dashboards list it
+type Invoice struct {
+	ID         uint                 `json:"id"`
+	InvoiceNum string               `json:"invoice_num"`
+	Client     string               `json:"client"`
+	Amount     float64              `json:"amount"`
+	Currency   string               `json:"currency"`
+	Status     models.InvoiceStatus `json:"status"`
+	IssuedDate time.Time            `json:"issued_date"`
+	DueDate    time.Time            `json:"due_date"`
+	PaidDate   *time.Time           `json:"paid_date"`
+	Overdue    bool                 `json:"overdue"`
+	HasPDF     bool                 `json:"has_pdf"`
+}
+
+// IsOverdue matches 'ung report overdue': marked overdue, or past due and not paid
+func IsOverdue(inv models.Invoice, now time.Time) bool {
+	if inv.Status == models.StatusOverdue {
+		return true
+	}
+	return (inv.Status == models.StatusPending || inv.Status == models.StatusSent) &&
+		!inv.DueDate.IsZero() && inv.DueDate.Before(now)
+}
+
+// paidOn is when an invoice's revenue counts, the issue date for invoices
+// marked paid before payment dates were recorded
+func paidOn(inv models.Invoice) time.Time {
+	if inv.PaidDate != nil {
+		return *inv.PaidDate
+	}
+	return inv.IssuedDate
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Summarize computes the headline numbers as of now
+func Summarize(db *gorm.DB, now time.Time) (*Summary, error) {
+	start := monthStart(now)
+	sum := &Summary{}
+
+	var invoices []models.Invoice
+	if err := db.Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	for _, inv := range invoices {
+		switch {
+		case inv.Status == models.StatusPaid:
+			sum.Paid += inv.Amount
+			if !paidOn(inv).Before(start) {
+				sum.RevenueThisMonth += inv.Amount
+			}
+		case IsOverdue(inv, now):
+			sum.Overdue += inv.Amount
+			sum.OverdueInvoices++
+			sum.OpenInvoices++
+		default:
+			sum.Pending += inv.Amount
+			sum.OpenInvoices++
+		}
+	}
+
+	unbilled, err := UnbilledTime(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range unbilled {
+		sum.UnbilledHours += u.Hours
+		sum.UnbilledAmount += u.Amount
+	}
+
+	err = db.Raw("SELECT COALESCE(SUM(amount), 0) FROM expenses WHERE date >= ?", start).
+		Scan(&sum.ExpensesThisMonth).Error
+	if err != nil {
+		return nil, err
+	}
+	err = db.Raw("SELECT COALESCE(SUM("+sessionHours+"), 0) FROM tracking_sessions ts WHERE ts.deleted_at IS NULL AND ts.start_time >= ?", start).
+		Scan(&sum.HoursThisMonth).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if sum.ActiveTimer, err = ActiveTimer(db); err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// ActiveTimer returns the running tracking session, or nil when no timer runs
+func ActiveTimer(db *gorm.DB) (*Timer, error) {
+	var active models.TrackingSession
+	err := db.Preload("Client").Where("end_time IS NULL").Order("start_time DESC").First(&active).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	timer := &Timer{ID: active.ID, Project: active.ProjectName, Started: active.StartTime}
+	if active.Client != nil {
+		timer.Client = active.Client.Name
+	}
+	return timer, nil
+}
+
+// Revenue returns paid revenue and expenses for the last months calendar
+// months, oldest first and ending with the current one
+func Revenue(db *gorm.DB, now time.Time, months int) ([]Month, error) {
+	start := monthStart(now).AddDate(0, -(months - 1), 0)
+	result := make([]Month, months)
+	index := map[string]int{}
+	for i := range result {
+		key := start.AddDate(0, i, 0).Format("2006-01")
+		result[i].Month = key
+		index[key] = i
+	}
+
+	var paid []models.Invoice
+	if err := db.Where("status = ?", models.StatusPaid).Find(&paid).Error; err != nil {
+		return nil, err
+	}
+	for _, inv := range paid {
+		if i, ok := index[paidOn(inv).In(now.Location()).Format("2006-01")]; ok {
+			result[i].Revenue += inv.Amount
+		}
+	}
+
+	var expenses []models.Expense
+	if err := db.Where("date >= ?", start).Find(&expenses).Error; err != nil {
+		return nil, err
+	}
+	for _, e := range expenses {
+		if i, ok := index[e.Date.In(now.Location()).Format("2006-01")]; ok {
+			result[i].Expenses += e.Amount
+		}
+	}
+	return result, nil
+}
+
+// UnbilledTime groups uninvoiced billable time by client and contract, the
+// way 'ung invoice' would bill it
+func UnbilledTime(db *gorm.DB) ([]Unbilled, error) {
+	rows, err := db.Raw(`
+		SELECT c.name, COALESCE(ct.name, ''), ct.hourly_rate, COALESCE(ct.currency, 'USD'),
+			COUNT(*), SUM(` + sessionHours + `)
+		FROM tracking_sessions ts
+		JOIN clients c ON ts.client_id = c.id
+		LEFT JOIN contracts ct ON ts.contract_id = ct.id
+		WHERE ` + unbilledFilter + `
+		GROUP BY c.id, ct.id
+		ORDER BY c.name, ct.name
+	`).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Unbilled{}
+	for rows.Next() {
+		var u Unbilled
+		if err := rows.Scan(&u.Client, &u.Contract, &u.Rate, &u.Currency, &u.Sessions, &u.Hours); err != nil {
+			return nil, err
+		}
+		if u.Hours <= 0 {
+			continue
+		}
+		if u.Rate != nil {
+			u.Amount = u.Hours * *u.Rate
+		}
+		result = append(result, u)
+	}
+	return result, rows.Err()
+}
+
+// Invoices lists invoices, newest first. The filter is "open" (the default,
+// anything not paid), "overdue" (see IsOverdue), "all" or a single status.
+func Invoices(db *gorm.DB, filter string, now time.Time) ([]Invoice, error) {
+	query := db.Order("issued_date DESC, id DESC")
+	switch filter {
+	case "", "open", "overdue":
+		query = query.Where("status != ?", models.StatusPaid)
+	case "all":
+	case string(models.StatusPending), string(models.StatusSent), string(models.StatusPaid):
+		query = query.Where("status = ?", filter)
+	default:
+		return nil, fmt.Errorf("%w %q (open, all, pending, sent, paid, overdue)", ErrUnknownFilter, filter)
+	}
+
+	var invoices []models.Invoice
+	if err := query.Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	clients, err := recipientNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Invoice, 0, len(invoices))
+	for _, inv := range invoices {
+		if filter == "overdue" && !IsOverdue(inv, now) {
+			continue
+		}
+		result = append(result, Invoice{
+			ID:         inv.ID,
+			InvoiceNum: inv.InvoiceNum,
+			Client:     clients[inv.ID],
+			Amount:     inv.Amount,
+			Currency:   inv.Currency,
+			Status:     inv.Status,
+			IssuedDate: inv.IssuedDate,
+			DueDate:    inv.DueDate,
+			PaidDate:   inv.PaidDate,
+			Overdue:    IsOverdue(inv, now),
+			HasPDF:     FileExists(inv.PDFPath),
+		})
+	}
+	return result, nil
+}
+
+// recipientNames maps invoice IDs to the name of the client billed
+func recipientNames(db *gorm.DB) (map[uint]string, error) {
+	rows, err := db.Raw(`
+		SELECT ir.invoice_id, c.name
+		FROM invoice_recipients ir
+		JOIN clients c ON ir.client_id = c.id
+		ORDER BY ir.id
+	`).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[uint]string{}
+	for rows.Next() {
+		var id uint
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		if _, ok := names[id]; !ok {
+			names[id] = name
+		}
+	}
+	return names, rows.Err()
+}
+
+// FileExists reports whether path names a regular file, e.g. an invoice PDF
+func FileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
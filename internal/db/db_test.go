@@ -1,9 +1,11 @@
 package db
 
 import (
-	"database/sql"
+	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/Andriiklymiuk/ung/pkg/schema"
 )
 
 func TestGetInvoicesDir(t *testing.T) {
@@ -30,31 +32,37 @@ func TestGetDBPath(t *testing.T) {
 	}
 }
 
-func TestInlineSchema(t *testing.T) {
-	// Create a temporary database
-	tmpDB, err := sql.Open("sqlite3", ":memory:")
-	if err != nil {
-		t.Fatalf("failed to open test database: %v", err)
+func TestInitializeRunsMigrations(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	ungDir := filepath.Join(home, ".ung")
+	if err := os.MkdirAll(ungDir, 0755); err != nil {
+		t.Fatal(err)
 	}
-	defer tmpDB.Close()
-
-	// Save and restore global DB
-	oldDB := DB
-	DB = tmpDB
-	defer func() { DB = oldDB }()
+	cfg := "database_path: " + filepath.Join(ungDir, "ung.db") + "\ninvoices_dir: " + filepath.Join(ungDir, "invoices") + "\n"
+	if err := os.WriteFile(filepath.Join(ungDir, "config.yaml"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Initialize(); err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	defer Close()
 
-	// Run inline schema
-	if err := runInlineSchema(); err != nil {
-		t.Fatalf("failed to run inline schema: %v", err)
+	if version, err := schema.Version(DB); err != nil || version != schema.Latest() {
+		t.Errorf("expected schema version %s, got %q (%v)", schema.Latest(), version, err)
 	}
 
-	// Verify core tables exist (inline schema may not have all tables from migrations)
+	// Core tables and those that used to come from GORM auto-migration or only from the API
 	tables := []string{
 		"companies",
 		"clients",
 		"invoices",
 		"invoice_recipients",
 		"tracking_sessions",
+		"recurring_invoices",
+		"user_settings",
+		"income_goals",
+		"pomodoro_sessions",
 	}
 
 	for _, table := range tables {
@@ -1,13 +1,12 @@
 package db
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
 	"os"
 
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -15,68 +14,177 @@ const (
 	// Encryption parameters
 	keySize    = 32 // AES-256
 	saltSize   = 32
-	nonceSize  = 12 // GCM standard nonce size
-	pbkdf2Iter = 100000
+	nonceSize  = 12     // GCM standard nonce size
+	pbkdf2Iter = 100000 // version 1 files
 )
 
 // EncryptDatabase encrypts a database file using AES-256-GCM with password-derived key
 func EncryptDatabase(inputPath, outputPath string, password string) error {
-	// Generate random salt
-	salt := make([]byte, saltSize)
-	if _, err := rand.Read(salt); err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
-	}
-
-	// Derive key from password using PBKDF2
-	key := pbkdf2.Key([]byte(password), salt, pbkdf2Iter, keySize, sha256.New)
-
 	// Read input file
 	plaintext, err := os.ReadFile(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to read input file: %w", err)
 	}
 
-	// Create AES cipher
-	block, err := aes.NewCipher(key)
+	output, err := EncryptData(plaintext, password)
 	if err != nil {
-		return fmt.Errorf("failed to create cipher: %w", err)
+		return err
 	}
 
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return fmt.Errorf("failed to create GCM: %w", err)
+	if err := WriteFileAtomic(outputPath, output, 0600); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
 	}
 
-	// Generate nonce
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := rand.Read(nonce); err != nil {
-		return fmt.Errorf("failed to generate nonce: %w", err)
+	return nil
+}
+
+// DecryptDatabase decrypts a database file encrypted with EncryptDatabase
+func DecryptDatabase(inputPath, outputPath string, password string) error {
+	// Read encrypted file
+	encrypted, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read encrypted file: %w", err)
 	}
 
-	// Encrypt data
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	plaintext, err := DecryptData(encrypted, password)
+	if err != nil {
+		return err
+	}
 
-	// Write encrypted file: [salt][ciphertext]
-	output := append(salt, ciphertext...)
-	if err := os.WriteFile(outputPath, output, 0600); err != nil {
+	// Write decrypted file
+	if err := WriteFileAtomic(outputPath, plaintext, 0600); err != nil {
 		return fmt.Errorf("failed to write output file: %w", err)
 	}
 
 	return nil
 }
 
-// DecryptDatabase decrypts a database file encrypted with EncryptDatabase
-func DecryptDatabase(inputPath, outputPath string, password string) error {
-	// Read encrypted file
-	encrypted, err := os.ReadFile(inputPath)
+// VerifyDatabasePassword checks that password decrypts the encrypted database
+func VerifyDatabasePassword(path, password string) error {
+	encrypted, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read encrypted file: %w", err)
 	}
+	_, err = DecryptData(encrypted, password)
+	return err
+}
+
+// ReencryptDatabase changes the password of an encrypted database and returns
+// its keyring. Version 2 files only get their password slot rewrapped; version 1
+// files are upgraded.
+func ReencryptDatabase(path, oldPassword, newPassword string) (*Keyring, error) {
+	encrypted, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encrypted file: %w", err)
+	}
+	keyring, plaintext, err := UnlockData(encrypted, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	kdf := DefaultKDF()
+	if keyring == nil {
+		if keyring, err = NewKeyring(); err != nil {
+			return nil, err
+		}
+	} else if slot, ok := keyring.Slot(SlotPassword); ok {
+		kdf = slot.KDF
+	}
+	if err := keyring.SetPassword(newPassword, kdf); err != nil {
+		return nil, err
+	}
+
+	var output []byte
+	if IsVersioned(encrypted) {
+		output, err = keyring.Rewrap(encrypted)
+	} else {
+		output, err = keyring.Seal(plaintext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteFileAtomic(path, output, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write encrypted file: %w", err)
+	}
+	return keyring, nil
+}
+
+// DefaultKDF returns the key derivation function for new password slots (security.kdf in config)
+func DefaultKDF() string {
+	cfg, _ := config.Load()
+	if cfg != nil && cfg.Security.KDF == KDFArgon2id {
+		return KDFArgon2id
+	}
+	return KDFPBKDF2
+}
+
+// EncryptData encrypts data into a version 2 file whose data key is wrapped by password
+func EncryptData(plaintext []byte, password string) ([]byte, error) {
+	return encryptWithKDF(plaintext, password, DefaultKDF())
+}
+
+func encryptWithKDF(plaintext []byte, password, kdf string) ([]byte, error) {
+	keyring, err := NewKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.SetPassword(password, kdf); err != nil {
+		return nil, err
+	}
+	return keyring.Seal(plaintext)
+}
+
+// DecryptData decrypts data encrypted with EncryptData (either file version).
+// A recovery key is accepted in place of the password.
+func DecryptData(encrypted []byte, password string) ([]byte, error) {
+	_, plaintext, err := UnlockData(encrypted, password)
+	return plaintext, err
+}
+
+// UnlockData decrypts an encrypted file with a password and returns its
+// keyring (nil for version 1 files) along with the plaintext
+func UnlockData(encrypted []byte, password string) (*Keyring, []byte, error) {
+	if IsVersioned(encrypted) {
+		return openWithPassword(encrypted, password)
+	}
+	plaintext, err := decryptLegacy(encrypted, password)
+	return nil, plaintext, err
+}
+
+// encryptLegacy encrypts data in the version 1 layout [salt][nonce][ciphertext]
+// with a key derived directly from the password
+func encryptLegacy(plaintext []byte, password string) ([]byte, error) {
+	// Generate random salt
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	// Derive key from password using PBKDF2
+	key := pbkdf2.Key([]byte(password), salt, pbkdf2Iter, keySize, sha256.New)
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate nonce
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// Encrypt data
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
 
+	return append(salt, ciphertext...), nil
+}
+
+// decryptLegacy decrypts a version 1 file
+func decryptLegacy(encrypted []byte, password string) ([]byte, error) {
 	// Extract salt
 	if len(encrypted) < saltSize {
-		return fmt.Errorf("encrypted file too short")
+		return nil, fmt.Errorf("encrypted file too short")
 	}
 	salt := encrypted[:saltSize]
 	ciphertext := encrypted[saltSize:]
@@ -84,22 +192,15 @@ func DecryptDatabase(inputPath, outputPath string, password string) error {
 	// Derive key from password
 	key := pbkdf2.Key([]byte(password), salt, pbkdf2Iter, keySize, sha256.New)
 
-	// Create AES cipher
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(key)
 	if err != nil {
-		return fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
 	}
 
 	// Extract nonce
 	nonceSize := gcm.NonceSize()
 	if len(ciphertext) < nonceSize {
-		return fmt.Errorf("ciphertext too short")
+		return nil, fmt.Errorf("ciphertext too short")
 	}
 	nonce := ciphertext[:nonceSize]
 	ciphertext = ciphertext[nonceSize:]
@@ -107,15 +208,10 @@ func DecryptDatabase(inputPath, outputPath string, password string) error {
 	// Decrypt
 	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return fmt.Errorf("decryption failed (wrong password?): %w", err)
+		return nil, fmt.Errorf("decryption failed (wrong password?): %w", err)
 	}
 
-	// Write decrypted file
-	if err := os.WriteFile(outputPath, plaintext, 0600); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
-	}
-
-	return nil
+	return plaintext, nil
 }
 
 // IsEncrypted checks if a file appears to be encrypted
@@ -136,6 +232,9 @@ func IsEncrypted(path string) bool {
 		return false
 	}
 
+	if IsVersioned(data) {
+		return true
+	}
 	if len(data) < saltSize {
 		return false
 	}
@@ -4,16 +4,26 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/zalando/go-keyring"
 )
 
 const (
 	// KeychainService is the service name used in the OS keychain
 	KeychainService = "ung-database"
-	// KeychainUser is the account name used in the OS keychain
+	// KeychainUser is the default account name used in the OS keychain
 	KeychainUser = "database-password"
 )
 
+// keychainUser returns the keychain account for the active configuration,
+// so each profile keeps its own password
+func keychainUser() string {
+	if cfg, err := config.Load(); err == nil && cfg.Security.KeychainAccount != "" {
+		return cfg.Security.KeychainAccount
+	}
+	return KeychainUser
+}
+
 // KeychainAvailable checks if keychain functionality is available on the current platform
 func KeychainAvailable() bool {
 	// go-keyring supports macOS, Windows, and Linux (with Secret Service)
@@ -45,7 +55,7 @@ func SavePasswordToKeychain(password string) error {
 		return fmt.Errorf("keychain not available on %s", runtime.GOOS)
 	}
 
-	err := keyring.Set(KeychainService, KeychainUser, password)
+	err := keyring.Set(KeychainService, keychainUser(), password)
 	if err != nil {
 		return fmt.Errorf("failed to save password to %s: %w", GetKeychainPlatformName(), err)
 	}
@@ -59,7 +69,7 @@ func GetPasswordFromKeychain() (string, error) {
 		return "", fmt.Errorf("keychain not available on %s", runtime.GOOS)
 	}
 
-	password, err := keyring.Get(KeychainService, KeychainUser)
+	password, err := keyring.Get(KeychainService, keychainUser())
 	if err != nil {
 		if err == keyring.ErrNotFound {
 			return "", nil // Password not stored, not an error
@@ -76,7 +86,7 @@ func DeletePasswordFromKeychain() error {
 		return fmt.Errorf("keychain not available on %s", runtime.GOOS)
 	}
 
-	err := keyring.Delete(KeychainService, KeychainUser)
+	err := keyring.Delete(KeychainService, keychainUser())
 	if err != nil {
 		if err == keyring.ErrNotFound {
 			return nil // Already deleted or never saved, not an error
@@ -93,6 +103,6 @@ func HasPasswordInKeychain() bool {
 		return false
 	}
 
-	_, err := keyring.Get(KeychainService, KeychainUser)
+	_, err := keyring.Get(KeychainService, keychainUser())
 	return err == nil
 }
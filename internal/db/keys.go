@@ -0,0 +1,469 @@
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Version 2 encrypted files encrypt the payload with a random data key. A
+// header of key slots wraps that data key once per credential (password,
+// recovery key, key file), so credentials can be added or changed by
+// rewriting the header alone.
+//
+// Layout: "UNGENC" | version (1 byte) | header length (uint32, big endian) | header JSON | nonce | ciphertext
+//
+// Files without the magic are version 1: [salt][nonce][ciphertext] with a
+// PBKDF2 key derived directly from the password.
+
+var fileMagic = []byte("UNGENC")
+
+const fileVersion = 2
+
+// Key derivation functions
+const (
+	KDFPBKDF2   = "pbkdf2"
+	KDFArgon2id = "argon2id"
+	kdfHKDF     = "hkdf" // for high-entropy secrets (recovery key, key file)
+)
+
+// Key slot types
+const (
+	SlotPassword = "password"
+	SlotRecovery = "recovery"
+	SlotKeyFile  = "keyfile"
+)
+
+const (
+	pbkdf2V2Iter  = 600000
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024 // KiB
+	argon2Threads = 4
+	recoveryBytes = 20
+	keyFileBytes  = 32
+)
+
+// ErrWrongCredential is returned when no key slot opens with the given credential
+var ErrWrongCredential = errors.New("decryption failed (wrong password?)")
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// KeySlot wraps the data key with a key derived from one credential
+type KeySlot struct {
+	Type       string `json:"type"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Iterations uint32 `json:"iterations,omitempty"` // PBKDF2 iterations or Argon2 passes
+	Memory     uint32 `json:"memory,omitempty"`     // Argon2 memory in KiB
+	Threads    uint8  `json:"threads,omitempty"`    // Argon2 parallelism
+	WrappedKey []byte `json:"wrapped_key"`          // nonce + sealed data key
+}
+
+type fileHeader struct {
+	Slots []KeySlot `json:"slots"`
+}
+
+// Keyring is the unlocked data key of a version 2 file together with its key slots
+type Keyring struct {
+	dataKey []byte
+	slots   []KeySlot
+}
+
+// NewKeyring creates a keyring with a fresh random data key and no slots
+func NewKeyring() (*Keyring, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	return &Keyring{dataKey: key}, nil
+}
+
+// IsVersioned reports whether data is a version 2 (or later) encrypted file
+func IsVersioned(data []byte) bool {
+	return len(data) > len(fileMagic) && bytes.Equal(data[:len(fileMagic)], fileMagic)
+}
+
+// FileVersion returns the format version of an encrypted file
+func FileVersion(data []byte) int {
+	if !IsVersioned(data) {
+		return 1
+	}
+	return int(data[len(fileMagic)])
+}
+
+// splitFile parses a version 2 file into its header and payload
+func splitFile(data []byte) (fileHeader, []byte, error) {
+	var header fileHeader
+	prefix := len(fileMagic) + 1 + 4
+	if !IsVersioned(data) || len(data) < prefix {
+		return header, nil, fmt.Errorf("not a versioned encrypted file")
+	}
+	if v := FileVersion(data); v != fileVersion {
+		return header, nil, fmt.Errorf("unsupported encrypted file version %d (upgrade ung)", v)
+	}
+	size := binary.BigEndian.Uint32(data[len(fileMagic)+1 : prefix])
+	if uint64(len(data)-prefix) < uint64(size) {
+		return header, nil, fmt.Errorf("encrypted file header is truncated")
+	}
+	if err := json.Unmarshal(data[prefix:prefix+int(size)], &header); err != nil {
+		return header, nil, fmt.Errorf("invalid encrypted file header: %w", err)
+	}
+	return header, data[prefix+int(size):], nil
+}
+
+// ReadKeySlots returns the key slots of an encrypted file without unlocking it (nil for version 1)
+func ReadKeySlots(data []byte) ([]KeySlot, error) {
+	if !IsVersioned(data) {
+		return nil, nil
+	}
+	header, _, err := splitFile(data)
+	return header.Slots, err
+}
+
+// OpenKeyring unlocks a version 2 file with a credential of the given slot
+// type and returns the keyring and decrypted payload
+func OpenKeyring(data []byte, slotType string, secret []byte) (*Keyring, []byte, error) {
+	header, payload, err := splitFile(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := false
+	for _, slot := range header.Slots {
+		if slot.Type != slotType {
+			continue
+		}
+		found = true
+		dataKey, err := slot.unwrap(secret)
+		if err != nil {
+			continue
+		}
+		plaintext, err := openPayload(dataKey, payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &Keyring{dataKey: dataKey, slots: header.Slots}, plaintext, nil
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("no %s key slot in encrypted file", slotType)
+	}
+	return nil, nil, ErrWrongCredential
+}
+
+// reopen decrypts a version 2 file with the keyring's data key and returns a
+// keyring with the file's key slots, which another process may have changed
+func (k *Keyring) reopen(data []byte) (*Keyring, []byte, error) {
+	header, payload, err := splitFile(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := openPayload(k.dataKey, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Keyring{dataKey: k.dataKey, slots: header.Slots}, plaintext, nil
+}
+
+// openWithPassword unlocks a version 2 file with a password, or with a recovery key typed in its place
+func openWithPassword(data []byte, password string) (*Keyring, []byte, error) {
+	keyring, plaintext, err := OpenKeyring(data, SlotPassword, []byte(password))
+	if err == nil {
+		return keyring, plaintext, nil
+	}
+	if secret, ok := ParseRecoveryKey(password); ok {
+		if keyring, plaintext, rerr := OpenKeyring(data, SlotRecovery, secret); rerr == nil {
+			return keyring, plaintext, nil
+		}
+	}
+	return nil, nil, err
+}
+
+// Slots returns a copy of the keyring's key slots
+func (k *Keyring) Slots() []KeySlot {
+	return append([]KeySlot(nil), k.slots...)
+}
+
+// Slot returns the first slot of the given type
+func (k *Keyring) Slot(slotType string) (KeySlot, bool) {
+	for _, slot := range k.slots {
+		if slot.Type == slotType {
+			return slot, true
+		}
+	}
+	return KeySlot{}, false
+}
+
+// SetPassword replaces the password slot, deriving its key with kdf
+func (k *Keyring) SetPassword(password, kdf string) error {
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+	slot, err := newSlot(SlotPassword, kdf)
+	if err != nil {
+		return err
+	}
+	return k.setSlot(slot, []byte(password))
+}
+
+// SetRecoveryKey generates a new recovery key, replacing any previous one,
+// and returns it in its printable form
+func (k *Keyring) SetRecoveryKey() (string, error) {
+	secret := make([]byte, recoveryBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate recovery key: %w", err)
+	}
+	slot, err := newSlot(SlotRecovery, kdfHKDF)
+	if err != nil {
+		return "", err
+	}
+	if err := k.setSlot(slot, secret); err != nil {
+		return "", err
+	}
+	return FormatRecoveryKey(secret), nil
+}
+
+// SetKeyFile generates new key file contents, replacing any previous key file slot
+func (k *Keyring) SetKeyFile() ([]byte, error) {
+	secret := make([]byte, keyFileBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate key file: %w", err)
+	}
+	slot, err := newSlot(SlotKeyFile, kdfHKDF)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.setSlot(slot, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// RemoveSlot removes all slots of a type. The password slot can't be removed.
+func (k *Keyring) RemoveSlot(slotType string) bool {
+	if slotType == SlotPassword {
+		return false
+	}
+	kept := k.slots[:0]
+	removed := false
+	for _, slot := range k.slots {
+		if slot.Type == slotType {
+			removed = true
+			continue
+		}
+		kept = append(kept, slot)
+	}
+	k.slots = kept
+	return removed
+}
+
+// Seal encrypts plaintext with the data key and returns a complete version 2 file
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(k.dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	payload := gcm.Seal(nonce, nonce, plaintext, payloadAAD())
+	return k.withHeader(payload)
+}
+
+// Rewrap replaces the header of a version 2 file with the keyring's slots,
+// keeping the encrypted payload as is
+func (k *Keyring) Rewrap(data []byte) ([]byte, error) {
+	_, payload, err := splitFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := openPayload(k.dataKey, payload); err != nil {
+		return nil, fmt.Errorf("keyring does not match file: %w", err)
+	}
+	return k.withHeader(payload)
+}
+
+// withHeader prefixes an encrypted payload with the file header
+func (k *Keyring) withHeader(payload []byte) ([]byte, error) {
+	if _, ok := k.Slot(SlotPassword); !ok {
+		return nil, fmt.Errorf("keyring has no password slot")
+	}
+	header, err := json.Marshal(fileHeader{Slots: k.slots})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode header: %w", err)
+	}
+
+	out := make([]byte, 0, len(fileMagic)+5+len(header)+len(payload))
+	out = append(out, fileMagic...)
+	out = append(out, fileVersion)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(header)))
+	out = append(out, header...)
+	return append(out, payload...), nil
+}
+
+// setSlot wraps the data key into slot with secret and replaces the slots of the same type
+func (k *Keyring) setSlot(slot KeySlot, secret []byte) error {
+	kek, err := slot.deriveKey(secret)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	// The slot type is authenticated so a slot can't be relabeled
+	slot.WrappedKey = gcm.Seal(nonce, nonce, k.dataKey, []byte(slot.Type))
+
+	var kept []KeySlot
+	for _, s := range k.slots {
+		if s.Type != slot.Type {
+			kept = append(kept, s)
+		}
+	}
+	// The password slot goes first since it's tried most often
+	if slot.Type == SlotPassword {
+		k.slots = append([]KeySlot{slot}, kept...)
+	} else {
+		k.slots = append(kept, slot)
+	}
+	return nil
+}
+
+// newSlot creates an empty slot with fresh salt and the default parameters for kdf
+func newSlot(slotType, kdf string) (KeySlot, error) {
+	slot := KeySlot{Type: slotType, KDF: kdf, Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(slot.Salt); err != nil {
+		return slot, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	switch kdf {
+	case KDFPBKDF2:
+		slot.Iterations = pbkdf2V2Iter
+	case KDFArgon2id:
+		slot.Iterations = argon2Time
+		slot.Memory = argon2Memory
+		slot.Threads = argon2Threads
+	case kdfHKDF:
+	default:
+		return slot, fmt.Errorf("unknown key derivation function %q (use %s or %s)", kdf, KDFPBKDF2, KDFArgon2id)
+	}
+	return slot, nil
+}
+
+// deriveKey derives the key-encryption key for this slot from a secret
+func (s KeySlot) deriveKey(secret []byte) ([]byte, error) {
+	switch s.KDF {
+	case KDFPBKDF2:
+		return pbkdf2.Key(secret, s.Salt, int(s.Iterations), keySize, sha256.New), nil
+	case KDFArgon2id:
+		return argon2.IDKey(secret, s.Salt, s.Iterations, s.Memory, s.Threads, keySize), nil
+	case kdfHKDF:
+		key := make([]byte, keySize)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, secret, s.Salt, []byte("ung "+s.Type)), key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key derivation function %q", s.KDF)
+}
+
+// unwrap recovers the data key from this slot
+func (s KeySlot) unwrap(secret []byte) ([]byte, error) {
+	kek, err := s.deriveKey(secret)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.WrappedKey) < gcm.NonceSize() {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+	nonce, sealed := s.WrappedKey[:gcm.NonceSize()], s.WrappedKey[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, []byte(s.Type))
+}
+
+// Describe returns a short human readable description of the slot's key derivation
+func (s KeySlot) Describe() string {
+	switch s.KDF {
+	case KDFPBKDF2:
+		return fmt.Sprintf("PBKDF2-SHA256, %d iterations", s.Iterations)
+	case KDFArgon2id:
+		return fmt.Sprintf("Argon2id, %d passes, %d MiB", s.Iterations, s.Memory/1024)
+	case kdfHKDF:
+		return "HKDF-SHA256"
+	}
+	return s.KDF
+}
+
+// openPayload decrypts a version 2 payload with the data key
+func openPayload(dataKey, payload []byte) ([]byte, error) {
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, sealed := payload[:gcm.NonceSize()], payload[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, payloadAAD())
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed (file corrupted?): %w", err)
+	}
+	return plaintext, nil
+}
+
+// payloadAAD binds the payload to the file format version
+func payloadAAD() []byte {
+	return append(append([]byte{}, fileMagic...), fileVersion)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// FormatRecoveryKey renders a recovery key as dash-separated groups of four characters
+func FormatRecoveryKey(secret []byte) string {
+	encoded := recoveryEncoding.EncodeToString(secret)
+	var groups []string
+	for len(encoded) > 4 {
+		groups = append(groups, encoded[:4])
+		encoded = encoded[4:]
+	}
+	return strings.Join(append(groups, encoded), "-")
+}
+
+// ParseRecoveryKey decodes a recovery key, ignoring case, dashes and spaces
+func ParseRecoveryKey(s string) ([]byte, bool) {
+	s = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s)))
+	secret, err := recoveryEncoding.DecodeString(s)
+	if err != nil || len(secret) != recoveryBytes {
+		return nil, false
+	}
+	return secret, true
+}
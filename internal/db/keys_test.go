@@ -0,0 +1,127 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyringSlots(t *testing.T) {
+	plaintext := []byte("SQLite format 3\x00 invoices")
+
+	keyring, err := NewKeyring()
+	if err != nil {
+		t.Fatalf("NewKeyring() error: %v", err)
+	}
+	if err := keyring.SetPassword("first", KDFArgon2id); err != nil {
+		t.Fatalf("SetPassword() error: %v", err)
+	}
+	recoveryKey, err := keyring.SetRecoveryKey()
+	if err != nil {
+		t.Fatalf("SetRecoveryKey() error: %v", err)
+	}
+	keyFile, err := keyring.SetKeyFile()
+	if err != nil {
+		t.Fatalf("SetKeyFile() error: %v", err)
+	}
+	sealed, err := keyring.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error: %v", err)
+	}
+	if FileVersion(sealed) != 2 || !IsEncrypted(writeTemp(t, sealed)) {
+		t.Errorf("expected a version 2 encrypted file, got version %d", FileVersion(sealed))
+	}
+
+	// Every credential opens the file
+	if got, err := DecryptData(sealed, "first"); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("password: got %q, %v", got, err)
+	}
+	if got, err := DecryptData(sealed, recoveryKey); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("recovery key: got %q, %v", got, err)
+	}
+	if _, got, err := OpenKeyring(sealed, SlotKeyFile, keyFile); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("key file: got %q, %v", got, err)
+	}
+	if _, err := DecryptData(sealed, "wrong"); !errors.Is(err, ErrWrongCredential) {
+		t.Errorf("expected ErrWrongCredential, got %v", err)
+	}
+
+	// Changing the password rewrites the header only
+	opened, _, err := OpenKeyring(sealed, SlotPassword, []byte("first"))
+	if err != nil {
+		t.Fatalf("OpenKeyring() error: %v", err)
+	}
+	opened.SetPassword("second", KDFPBKDF2)
+	opened.RemoveSlot(SlotKeyFile)
+	rewrapped, err := opened.Rewrap(sealed)
+	if err != nil {
+		t.Fatalf("Rewrap() error: %v", err)
+	}
+	if !bytes.HasSuffix(rewrapped, sealed[len(sealed)-len(plaintext)-16:]) {
+		t.Error("expected the encrypted payload to be kept")
+	}
+	if _, err := DecryptData(rewrapped, "first"); err == nil {
+		t.Error("expected the old password to stop working")
+	}
+	if got, err := DecryptData(rewrapped, "second"); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("new password: got %q, %v", got, err)
+	}
+	if _, _, err := OpenKeyring(rewrapped, SlotKeyFile, keyFile); err == nil {
+		t.Error("expected removed key file to stop working")
+	}
+	slots, _ := ReadKeySlots(rewrapped)
+	if len(slots) != 2 || slots[0].Type != SlotPassword || slots[0].KDF != KDFPBKDF2 {
+		t.Errorf("unexpected slots: %+v", slots)
+	}
+}
+
+func TestRecoveryKeyFormat(t *testing.T) {
+	secret := bytes.Repeat([]byte{0xAB}, recoveryBytes)
+	formatted := FormatRecoveryKey(secret)
+	if len(formatted) != 39 {
+		t.Errorf("expected 8 groups of 4, got %q", formatted)
+	}
+	for _, input := range []string{formatted, " " + formatted + "\n", strings.ToLower(formatted), strings.ReplaceAll(formatted, "-", " ")} {
+		if got, ok := ParseRecoveryKey(input); !ok || !bytes.Equal(got, secret) {
+			t.Errorf("ParseRecoveryKey(%q) = %x, %v", input, got, ok)
+		}
+	}
+	if _, ok := ParseRecoveryKey("my password"); ok {
+		t.Error("expected a password not to parse as a recovery key")
+	}
+}
+
+func TestReencryptDatabaseUpgradesLegacy(t *testing.T) {
+	plaintext := []byte("legacy database")
+	legacy, err := encryptLegacy(plaintext, "old")
+	if err != nil {
+		t.Fatalf("encryptLegacy() error: %v", err)
+	}
+	if got, err := DecryptData(legacy, "old"); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("expected version 1 files to still decrypt, got %q, %v", got, err)
+	}
+
+	path := writeTemp(t, legacy)
+	if _, err := ReencryptDatabase(path, "old", "new"); err != nil {
+		t.Fatalf("ReencryptDatabase() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if FileVersion(data) != 2 {
+		t.Errorf("expected version 2 after changing password, got %d", FileVersion(data))
+	}
+	if got, err := DecryptData(data, "new"); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ung.db.encrypted")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"fmt"
+)
+
+// Receipts, backup repository keys and sync folder keys are sealed with the
+// database password as well. SealLinked adds a second slot to them that wraps
+// their data key with the data key of the open database, so whatever unlocks
+// the database (password, key file or recovery key) unlocks them too, and
+// RewrapLinked can move them to a new password without knowing the old one.
+
+// SlotDatabase wraps a data key with the data key of the encrypted database
+const SlotDatabase = "database"
+
+// openKeyring returns the keyring of the open encrypted database, if any
+func openKeyring() *Keyring {
+	if store == nil {
+		return nil
+	}
+	return store.keyring
+}
+
+// linkTo adds a slot that opens the keyring with database's data key
+func (k *Keyring) linkTo(database *Keyring) error {
+	slot, err := newSlot(SlotDatabase, kdfHKDF)
+	if err != nil {
+		return err
+	}
+	return k.setSlot(slot, database.dataKey)
+}
+
+// SealLinked encrypts data with password and, when an encrypted database is
+// open, links it to the database's data key
+func SealLinked(plaintext []byte, password string) ([]byte, error) {
+	keyring, err := NewKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.SetPassword(password, DefaultKDF()); err != nil {
+		return nil, err
+	}
+	if database := openKeyring(); database != nil {
+		if err := keyring.linkTo(database); err != nil {
+			return nil, err
+		}
+	}
+	return keyring.Seal(plaintext)
+}
+
+// OpenLinked decrypts data sealed with SealLinked. The open database's data key
+// is tried first, so password is only called when that doesn't unlock it.
+func OpenLinked(encrypted []byte, password func() (string, error)) ([]byte, error) {
+	if database := openKeyring(); database != nil && IsVersioned(encrypted) {
+		if _, plaintext, err := OpenKeyring(encrypted, SlotDatabase, database.dataKey); err == nil {
+			return plaintext, nil
+		}
+	}
+	pw, err := password()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password: %w", err)
+	}
+	return DecryptData(encrypted, pw)
+}
+
+// RewrapLinked moves data sealed with the database password to newPassword and
+// links it to database. It unlocks the data with database's data key, falling
+// back to oldPassword for data sealed before it was linked; oldPassword may be
+// empty when it isn't known, as after a recovery.
+func RewrapLinked(encrypted []byte, database *Keyring, oldPassword, newPassword string) ([]byte, error) {
+	var keyring *Keyring
+	var err error
+	if database != nil && IsVersioned(encrypted) {
+		keyring, _, err = OpenKeyring(encrypted, SlotDatabase, database.dataKey)
+	}
+	if keyring == nil && oldPassword != "" {
+		keyring, _, err = UnlockData(encrypted, oldPassword)
+		if err == nil && keyring == nil {
+			return nil, fmt.Errorf("version 1 encrypted data can't be rewrapped")
+		}
+	}
+	if keyring == nil {
+		if err == nil {
+			err = ErrWrongCredential
+		}
+		return nil, err
+	}
+
+	kdf := DefaultKDF()
+	if slot, ok := keyring.Slot(SlotPassword); ok {
+		kdf = slot.KDF
+	}
+	if err := keyring.SetPassword(newPassword, kdf); err != nil {
+		return nil, err
+	}
+	if database != nil {
+		if err := keyring.linkTo(database); err != nil {
+			return nil, err
+		}
+	}
+	return keyring.Rewrap(encrypted)
+}
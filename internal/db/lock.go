@@ -0,0 +1,106 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDatabaseLocked is returned when another ung process holds the database lock
+var ErrDatabaseLocked = errors.New("database is in use by another ung process")
+
+// lockWait is how long Initialize waits for another process to release the lock
+var lockWait = 5 * time.Second
+
+// fileLock is an exclusive lock file next to the database. It records the
+// owner's PID and host so a lock left behind by a crashed process can be
+// detected and taken over.
+type fileLock struct {
+	path string
+}
+
+// acquireLock creates the lock file, waiting up to wait for a live owner to release it
+func acquireLock(path string, wait time.Duration) (*fileLock, error) {
+	hostname, _ := os.Hostname()
+	content := fmt.Sprintf("%d\n%s\n", os.Getpid(), hostname)
+	deadline := time.Now().Add(wait)
+
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, werr := f.WriteString(content)
+			cerr := f.Close()
+			if werr != nil || cerr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file: %w", errors.Join(werr, cerr))
+			}
+			return &fileLock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		pid, host, readErr := readLock(path)
+		switch {
+		case readErr != nil:
+			// The owner may be between creating and writing the file
+		case host == hostname && pid == os.Getpid():
+			// Already held by this process (Initialize called again without Close)
+			return &fileLock{path: path}, nil
+		case host == hostname && !processAlive(pid):
+			// Stale lock from a crashed process
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			if readErr == nil {
+				return nil, fmt.Errorf("%w (pid %d on %s); if no other ung is running, remove %s", ErrDatabaseLocked, pid, host, path)
+			}
+			return nil, fmt.Errorf("%w; if no other ung is running, remove %s", ErrDatabaseLocked, path)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// readLock returns the PID and host recorded in a lock file
+func readLock(path string) (int, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", err
+	}
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid lock file: %w", err)
+	}
+	host := ""
+	if len(lines) > 1 {
+		host = strings.TrimSpace(lines[1])
+	}
+	return pid, host, nil
+}
+
+// release removes the lock file if this process still owns it
+func (l *fileLock) release() {
+	if l == nil {
+		return
+	}
+	if pid, _, err := readLock(l.path); err == nil && pid == os.Getpid() {
+		os.Remove(l.path)
+	}
+}
+
+// LockDatabase takes the database lock for commands that rewrite the database
+// files directly (such as changing the encryption password). The returned
+// function releases it.
+func LockDatabase() (func(), error) {
+	lock, err := acquireLock(GetDBPath()+".lock", lockWait)
+	if err != nil {
+		return nil, err
+	}
+	return lock.release, nil
+}
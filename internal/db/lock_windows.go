@@ -0,0 +1,19 @@
+//go:build windows
+
+package db
+
+import "os"
+
+// processAlive reports whether a process with the given PID is running.
+// On Windows FindProcess fails when no such process exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -39,22 +39,31 @@ func GetDatabasePassword() (string, error) {
 	}
 
 	// Prompt user
-	fmt.Print("Enter database password: ")
-	passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
+	password, err := PromptSecret("Enter database password: ")
+	if err != nil {
+		return "", err
+	}
+
+	// Cache for this session
+	passwordCache = password
+	return password, nil
+}
+
+// PromptSecret reads a password or key from the terminal without echoing it
+func PromptSecret(prompt string) (string, error) {
+	fmt.Print(prompt)
+	secretBytes, err := term.ReadPassword(int(syscall.Stdin))
 	fmt.Println() // Print newline after password input
 
 	if err != nil {
 		return "", fmt.Errorf("failed to read password: %w", err)
 	}
 
-	password := string(passwordBytes)
-	if password == "" {
+	secret := string(secretBytes)
+	if secret == "" {
 		return "", fmt.Errorf("password cannot be empty")
 	}
-
-	// Cache for this session
-	passwordCache = password
-	return password, nil
+	return secret, nil
 }
 
 // SetDatabasePassword sets the password cache (useful for testing or automation)
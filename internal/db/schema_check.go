@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+)
+
+// SchemaIssue is a difference between the open database and the schema this
+// version of ung expects
+type SchemaIssue struct {
+	Problem string
+	Fix     string // SQL that repairs it, empty when it has to be fixed by hand
+}
+
+// CheckSchema compares the open database with the latest schema and the
+// migrations recorded in schema_migrations
+func CheckSchema() ([]SchemaIssue, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	ref, err := schema.Reference()
+	if err != nil {
+		return nil, err
+	}
+	defer ref.Close()
+	expected, err := schema.Tables(ref)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := schema.Tables(DB)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []SchemaIssue
+	for _, table := range sortedKeys(expected) {
+		want := expected[table]
+		have, ok := actual[table]
+		if !ok {
+			issues = append(issues, SchemaIssue{
+				Problem: fmt.Sprintf("table %s is missing", table),
+				Fix:     want.SQL,
+			})
+			continue
+		}
+		for _, col := range want.Columns {
+			if have.Has(col.Name) {
+				continue
+			}
+			issue := SchemaIssue{Problem: fmt.Sprintf("column %s.%s is missing", table, col.Name)}
+			if col.Addable() {
+				issue.Fix = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, col.Definition())
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	migrationIssues, err := checkMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, migrationIssues...), nil
+}
+
+// checkMigrations finds migrations recorded in the database that this
+// version of ung doesn't know, and known ones that were never recorded
+func checkMigrations() ([]SchemaIssue, error) {
+	applied, err := schema.Applied(DB)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := map[string]bool{}
+	var issues []SchemaIssue
+	for _, version := range applied {
+		recorded[version] = true
+		if !schema.Known(version) {
+			issues = append(issues, SchemaIssue{
+				Problem: fmt.Sprintf("migration %s was applied by a newer or different version of ung", version),
+			})
+		}
+	}
+	for _, m := range schema.Migrations() {
+		if !recorded[m.Version] {
+			issues = append(issues, SchemaIssue{
+				Problem: fmt.Sprintf("migration %s was never applied", m.Version),
+			})
+		}
+	}
+	return issues, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,417 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Encrypted databases are never decrypted to disk. The ciphertext is decrypted
+// into a shared in-memory SQLite database (the memdb VFS), every connection in
+// the process works on that copy, and it is serialized, encrypted and written
+// back atomically on Flush and Close. A crash therefore loses the unsaved
+// changes of that run but never leaves plaintext behind.
+
+// legacyPlaintextSuffixes are plaintext copies older versions (and interrupted
+// security commands) could leave next to the database
+var legacyPlaintextSuffixes = []string{".decrypted", ".temp", ".verify"}
+
+// memoryStore is the in-memory working copy of an encrypted database
+type memoryStore struct {
+	dsn           string
+	pool          *sql.DB
+	keeper        *sql.Conn // keeps the shared in-memory database alive
+	encryptedPath string
+	plainPath     string    // plaintext database being migrated to encryption, removed after the first write-back
+	keyring       *Keyring  // nil for version 1 files, which keep their format until upgraded
+	stamp         fileStamp // encrypted file as last loaded or written
+	version       int64     // data_version as last loaded or written
+}
+
+// openMemoryStore decrypts the database into memory and returns the working copy
+func openMemoryStore(dbPath, encryptedPath string) (*memoryStore, error) {
+	var data []byte
+	var keyring *Keyring
+	plainPath := ""
+
+	if fileExists(encryptedPath) {
+		encrypted, err := os.ReadFile(encryptedPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encrypted database: %w", err)
+		}
+		if keyring, data, err = unlockDatabase(encrypted); err != nil {
+			return nil, fmt.Errorf("failed to decrypt database: %w", err)
+		}
+	} else {
+		if fileExists(dbPath) {
+			// Encryption was just enabled: load the plaintext database, it is
+			// replaced by the encrypted file on the first write-back
+			var err error
+			if data, err = os.ReadFile(dbPath); err != nil {
+				return nil, fmt.Errorf("failed to read database: %w", err)
+			}
+			plainPath = dbPath
+		}
+		var err error
+		if keyring, err = newPasswordKeyring(); err != nil {
+			return nil, err
+		}
+	}
+
+	store, err := newMemoryStore(data)
+	if err != nil {
+		return nil, err
+	}
+	store.encryptedPath = encryptedPath
+	store.plainPath = plainPath
+	store.keyring = keyring
+	store.stamp, _ = statFile(encryptedPath)
+	return store, nil
+}
+
+// unlockDatabase decrypts a database file with the configured key file, falling back to the password
+func unlockDatabase(encrypted []byte) (*Keyring, []byte, error) {
+	if path := KeyFilePath(); path != "" && IsVersioned(encrypted) {
+		secret, err := ReadKeyFile(path)
+		if err == nil {
+			var keyring *Keyring
+			var data []byte
+			if keyring, data, err = OpenKeyring(encrypted, SlotKeyFile, secret); err == nil {
+				return keyring, data, nil
+			}
+		}
+		fmt.Fprintf(os.Stderr, "⚠️  Key file %s did not unlock the database: %v\n", path, err)
+	}
+
+	password, err := GetDatabasePassword()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get password: %w", err)
+	}
+	return UnlockData(encrypted, password)
+}
+
+// newPasswordKeyring creates the keyring for a newly encrypted database
+func newPasswordKeyring() (*Keyring, error) {
+	password, err := GetDatabasePassword()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password: %w", err)
+	}
+	keyring, err := NewKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.SetPassword(password, DefaultKDF()); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// KeyFilePath returns the key file to unlock the database with (UNG_KEY_FILE or security.key_file), if any
+func KeyFilePath() string {
+	path := os.Getenv("UNG_KEY_FILE")
+	if path == "" {
+		if cfg, _ := config.Load(); cfg != nil {
+			path = cfg.Security.KeyFile
+		}
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// ReadKeyFile reads a key file written by 'ung security key-file create'
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(secret) != keyFileBytes {
+		return nil, fmt.Errorf("invalid key file")
+	}
+	return secret, nil
+}
+
+// newMemoryStore creates a shared in-memory database holding a serialized database (or empty when data is nil)
+func newMemoryStore(data []byte) (*memoryStore, error) {
+	name := make([]byte, 8)
+	if _, err := rand.Read(name); err != nil {
+		return nil, fmt.Errorf("failed to name in-memory database: %w", err)
+	}
+	dsn := fmt.Sprintf("file:/ung-%s.db?vfs=memdb&_busy_timeout=5000", hex.EncodeToString(name))
+
+	pool, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open in-memory database: %w", err)
+	}
+	keeper, err := pool.Conn(context.Background())
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to open in-memory database: %w", err)
+	}
+	store := &memoryStore{dsn: dsn, pool: pool, keeper: keeper}
+
+	if len(data) > 0 {
+		if err := store.restore(data); err != nil {
+			store.close()
+			return nil, fmt.Errorf("failed to load database into memory: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// restore copies a serialized database into the shared in-memory database
+func (s *memoryStore) restore(data []byte) error {
+	// Deserialize into a private connection, then copy its pages into the shared database
+	tmp, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+	conn, err := tmp.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(src any) error {
+		srcConn := src.(*sqlite3.SQLiteConn)
+		if err := srcConn.Deserialize(data, "main"); err != nil {
+			return err
+		}
+		return s.keeper.Raw(func(dest any) error {
+			backup, err := dest.(*sqlite3.SQLiteConn).Backup("main", srcConn, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// serialize returns a consistent snapshot of the in-memory database
+func (s *memoryStore) serialize() ([]byte, error) {
+	ctx := context.Background()
+	// A read transaction keeps writers from committing halfway through the copy
+	if _, err := s.keeper.ExecContext(ctx, "BEGIN"); err != nil {
+		return nil, err
+	}
+	defer s.keeper.ExecContext(ctx, "COMMIT")
+	var n int
+	if err := s.keeper.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master").Scan(&n); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err := s.keeper.Raw(func(dc any) error {
+		var err error
+		data, err = dc.(*sqlite3.SQLiteConn).Serialize("main")
+		return err
+	})
+	return data, err
+}
+
+// flush encrypts the in-memory database and atomically replaces the encrypted file
+func (s *memoryStore) flush() error {
+	// Read before the snapshot, so commits made during the write-back count as changes
+	version, err := s.dataVersion()
+	if err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	data, err := s.serialize()
+	if err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	var encrypted []byte
+	if s.keyring != nil {
+		encrypted, err = s.keyring.Seal(data)
+	} else {
+		var password string
+		if password, err = GetDatabasePassword(); err != nil {
+			return fmt.Errorf("failed to get password for encryption: %w", err)
+		}
+		encrypted, err = encryptLegacy(data, password)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encrypt database: %w", err)
+	}
+	if err := WriteFileAtomic(s.encryptedPath, encrypted, 0600); err != nil {
+		return fmt.Errorf("failed to write encrypted database: %w", err)
+	}
+	s.version = version
+	s.stamp, _ = statFile(s.encryptedPath)
+
+	if s.plainPath != "" {
+		if err := SecureRemove(s.plainPath); err != nil {
+			return fmt.Errorf("database encrypted, but failed to remove plaintext copy %s: %w", s.plainPath, err)
+		}
+		s.plainPath = ""
+	}
+	return nil
+}
+
+// close drops the in-memory database
+func (s *memoryStore) close() {
+	s.keeper.Close()
+	s.pool.Close()
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory, syncs
+// it and renames it over path, so readers see either the old or the new file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Persist the rename; not supported on every platform, so errors are ignored
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// SecureRemove overwrites a file with zeros before deleting it. This is best
+// effort: copy-on-write filesystems and SSDs may keep the old blocks.
+func SecureRemove(path string) error {
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			zeros := make([]byte, 64*1024)
+			for remaining := info.Size(); remaining > 0; {
+				n := int64(len(zeros))
+				if remaining < n {
+					n = remaining
+				}
+				if _, err := f.Write(zeros[:n]); err != nil {
+					break
+				}
+				remaining -= n
+			}
+			f.Sync()
+			f.Close()
+		}
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// recoverPlaintextCopies cleans up plaintext and temporary files left by a
+// crash. A ".decrypted" file newer than the encrypted database holds changes
+// that were never written back, so it is encrypted first. Must be called with
+// the database lock held.
+func recoverPlaintextCopies(dbPath, encryptedPath string) error {
+	// Interrupted atomic writes only leave ciphertext behind
+	if stale, err := filepath.Glob(encryptedPath + ".tmp-*"); err == nil {
+		for _, p := range stale {
+			os.Remove(p)
+		}
+	}
+
+	for _, suffix := range legacyPlaintextSuffixes {
+		path := dbPath + suffix
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if suffix == ".decrypted" && isNewerThan(info.ModTime(), encryptedPath) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			encrypted, err := encryptLike(encryptedPath, data)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt recovered database: %w", err)
+			}
+			if err := WriteFileAtomic(encryptedPath, encrypted, 0600); err != nil {
+				return fmt.Errorf("failed to write recovered database: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "⚠️  Recovered unsaved changes from %s\n", path)
+		} else {
+			fmt.Fprintf(os.Stderr, "⚠️  Removed leftover plaintext database copy %s\n", path)
+		}
+
+		if err := SecureRemove(path); err != nil {
+			return fmt.Errorf("failed to remove plaintext copy %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// encryptLike encrypts data in the format and with the keys of the existing
+// encrypted file, checking the credentials against it first
+func encryptLike(encryptedPath string, data []byte) ([]byte, error) {
+	existing, err := os.ReadFile(encryptedPath)
+	if err != nil {
+		keyring, err := newPasswordKeyring()
+		if err != nil {
+			return nil, err
+		}
+		return keyring.Seal(data)
+	}
+	keyring, _, err := unlockDatabase(existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt database: %w", err)
+	}
+	if keyring != nil {
+		return keyring.Seal(data)
+	}
+	password, err := GetDatabasePassword()
+	if err != nil {
+		return nil, err
+	}
+	return encryptLegacy(data, password)
+}
+
+// isNewerThan reports whether t is after the modification time of path (or path doesn't exist)
+func isNewerThan(t time.Time, path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	return t.After(info.ModTime())
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ung.db.lock")
+	hostname, _ := os.Hostname()
+
+	lock, err := acquireLock(path, 0)
+	if err != nil {
+		t.Fatalf("acquireLock() error: %v", err)
+	}
+	// The same process may take its own lock again
+	if _, err := acquireLock(path, 0); err != nil {
+		t.Errorf("expected re-entrant lock, got %v", err)
+	}
+	lock.release()
+	if fileExists(path) {
+		t.Error("expected lock file to be removed on release")
+	}
+
+	// A live process on this host holds the lock
+	os.WriteFile(path, []byte(fmt.Sprintf("%d\n%s\n", os.Getppid(), hostname)), 0600)
+	if _, err := acquireLock(path, 200*time.Millisecond); !errors.Is(err, ErrDatabaseLocked) {
+		t.Errorf("expected ErrDatabaseLocked, got %v", err)
+	}
+
+	// A lock left behind by a dead process is taken over
+	os.WriteFile(path, []byte(fmt.Sprintf("%d\n%s\n", 999999999, hostname)), 0600)
+	lock, err = acquireLock(path, 0)
+	if err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v", err)
+	}
+	lock.release()
+}
+
+func TestMemoryStoreFlush(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "ung.db")
+	encryptedPath := dbPath + ".encrypted"
+	SetDatabasePassword("TestPassword123!")
+	defer ClearPasswordCache()
+
+	store, err := openMemoryStore(dbPath, encryptedPath)
+	if err != nil {
+		t.Fatalf("openMemoryStore() error: %v", err)
+	}
+	if _, err := store.pool.Exec("CREATE TABLE notes (body TEXT); INSERT INTO notes VALUES ('secret note')"); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := store.flush(); err != nil {
+		t.Fatalf("flush() error: %v", err)
+	}
+	store.close()
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "ung.db.encrypted" {
+		t.Errorf("expected only the encrypted database on disk, got %v", entries)
+	}
+
+	// A fresh store decrypts what was written back
+	store, err = openMemoryStore(dbPath, encryptedPath)
+	if err != nil {
+		t.Fatalf("openMemoryStore() reopen error: %v", err)
+	}
+	defer store.close()
+	var body string
+	if err := store.pool.QueryRow("SELECT body FROM notes").Scan(&body); err != nil || body != "secret note" {
+		t.Errorf("expected note to survive write-back, got %q, %v", body, err)
+	}
+}
+
+func TestSharedDatabase(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	ungDir := filepath.Join(home, ".ung")
+	dbPath := filepath.Join(ungDir, "ung.db")
+	os.MkdirAll(ungDir, 0755)
+	cfg := "database_path: " + dbPath + "\ninvoices_dir: " + filepath.Join(ungDir, "invoices") + "\nsecurity:\n  encrypt_database: true\n"
+	os.WriteFile(filepath.Join(ungDir, "config.yaml"), []byte(cfg), 0644)
+	config.Reload()
+	SetDatabasePassword("TestPassword123!")
+	if err := Initialize(); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	defer Close()
+
+	if err := Share(); err != nil {
+		t.Fatalf("Share() error: %v", err)
+	}
+	if fileExists(dbPath + ".lock") {
+		t.Fatal("expected the lock to be released while shared")
+	}
+
+	// Another ung command writes in the meantime
+	other, err := acquireLock(dbPath+".lock", 0)
+	if err != nil {
+		t.Fatalf("expected the lock to be free, got %v", err)
+	}
+	otherStore, err := openMemoryStore(dbPath, dbPath+".encrypted")
+	if err != nil {
+		t.Fatalf("openMemoryStore() error: %v", err)
+	}
+	otherStore.pool.Exec("INSERT INTO clients (name, email) VALUES ('Acme', 'billing@acme.test')")
+	if err := otherStore.flush(); err != nil {
+		t.Fatalf("flush() error: %v", err)
+	}
+	otherStore.close()
+	other.release()
+
+	if err := Acquire(); err != nil {
+		t.Fatalf("Acquire() error: %v", err)
+	}
+	if !fileExists(dbPath + ".lock") {
+		t.Error("expected the lock to be held between Acquire and Release")
+	}
+	var name string
+	if err := DB.QueryRow("SELECT name FROM clients").Scan(&name); err != nil || name != "Acme" {
+		t.Errorf("expected the other command's client, got %q, %v", name, err)
+	}
+	DB.Exec("UPDATE clients SET name = 'Acme Ltd'")
+	if err := Release(); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if fileExists(dbPath + ".lock") {
+		t.Error("expected the lock to be released")
+	}
+
+	// The change was written back for the next command
+	otherStore, err = openMemoryStore(dbPath, dbPath+".encrypted")
+	if err != nil {
+		t.Fatalf("openMemoryStore() error: %v", err)
+	}
+	defer otherStore.close()
+	if err := otherStore.pool.QueryRow("SELECT name FROM clients").Scan(&name); err != nil || name != "Acme Ltd" {
+		t.Errorf("expected the change to be written back, got %q, %v", name, err)
+	}
+}
+
+func TestRecoverPlaintextCopies(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "ung.db")
+	encryptedPath := dbPath + ".encrypted"
+	password := "TestPassword123!"
+	SetDatabasePassword(password)
+	defer ClearPasswordCache()
+
+	old, _ := EncryptData([]byte("old"), password)
+	os.WriteFile(encryptedPath, old, 0600)
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(encryptedPath, past, past)
+
+	// A newer .decrypted copy holds unsaved changes; the others are just removed
+	os.WriteFile(dbPath+".decrypted", []byte("new"), 0600)
+	os.WriteFile(dbPath+".verify", []byte("old"), 0600)
+	os.WriteFile(encryptedPath+".tmp-123", []byte("partial"), 0600)
+
+	if err := recoverPlaintextCopies(dbPath, encryptedPath); err != nil {
+		t.Fatalf("recoverPlaintextCopies() error: %v", err)
+	}
+
+	for _, p := range []string{dbPath + ".decrypted", dbPath + ".verify", encryptedPath + ".tmp-123"} {
+		if fileExists(p) {
+			t.Errorf("expected %s to be removed", filepath.Base(p))
+		}
+	}
+	encrypted, _ := os.ReadFile(encryptedPath)
+	data, err := DecryptData(encrypted, password)
+	if err != nil || string(data) != "new" {
+		t.Errorf("expected recovered changes to be encrypted, got %q, %v", data, err)
+	}
+}
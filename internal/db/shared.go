@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Long-running commands (the daemon, the dashboard) share an encrypted
+// database with the other ung commands instead of holding its lock for their
+// whole life: after Share, the lock is only taken between Acquire and
+// Release. Acquire reloads the encrypted file when another process wrote it
+// in the meantime, and Release writes changes back before giving up the lock.
+
+var (
+	sharedMu sync.Mutex
+	shared   bool
+	leases   int
+)
+
+// fileStamp identifies a version of the encrypted file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Share writes back an encrypted database and releases its lock until the
+// next Acquire. It does nothing for unencrypted databases, which SQLite
+// already shares between processes.
+func Share() error {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if store == nil || shared {
+		return nil
+	}
+	if err := store.flush(); err != nil {
+		return err
+	}
+	shared = true
+	releaseLock()
+	return nil
+}
+
+// Acquire takes the lock of a shared encrypted database, loading the changes
+// other processes made since the last Release. Every Acquire must be paired
+// with a Release; calls may overlap.
+func Acquire() error {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if !shared {
+		return nil
+	}
+	if leases == 0 {
+		if err := lockAndReload(); err != nil {
+			return err
+		}
+	}
+	leases++
+	return nil
+}
+
+// Release writes back the changes made since Acquire and releases the lock
+// once no other caller holds it. If the write-back fails the lock is kept so
+// the next Release can retry it.
+func Release() error {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if !shared || leases == 0 {
+		return nil
+	}
+	if leases--; leases > 0 {
+		return nil
+	}
+	if store.changed() {
+		if err := store.flush(); err != nil {
+			return err
+		}
+	}
+	releaseLock()
+	return nil
+}
+
+// unshare takes the lock back for Close
+func unshare() error {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if !shared {
+		return nil
+	}
+	if leases == 0 {
+		if err := lockAndReload(); err != nil {
+			return err
+		}
+	}
+	shared = false
+	leases = 0
+	return nil
+}
+
+// lockAndReload takes the database lock and reloads the encrypted file if it changed
+func lockAndReload() error {
+	var err error
+	if lock, err = acquireLock(GetDBPath()+".lock", lockWait); err != nil {
+		return err
+	}
+	if err := store.reload(); err != nil {
+		releaseLock()
+		return err
+	}
+	return nil
+}
+
+// reload replaces the in-memory copy with the encrypted file if another
+// process wrote it since it was loaded or flushed
+func (s *memoryStore) reload() error {
+	stamp, err := statFile(s.encryptedPath)
+	if err != nil {
+		return fmt.Errorf("failed to read encrypted database: %w", err)
+	}
+	if stamp == s.stamp {
+		return nil
+	}
+	encrypted, err := os.ReadFile(s.encryptedPath)
+	if err != nil {
+		return fmt.Errorf("failed to read encrypted database: %w", err)
+	}
+
+	// The data key stays the same when another process changes the password
+	var keyring *Keyring
+	var data []byte
+	if s.keyring != nil && IsVersioned(encrypted) {
+		keyring, data, err = s.keyring.reopen(encrypted)
+	}
+	if keyring == nil {
+		keyring, data, err = unlockDatabase(encrypted)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decrypt database: %w", err)
+	}
+	if err := s.restore(data); err != nil {
+		return fmt.Errorf("failed to load database into memory: %w", err)
+	}
+	s.keyring = keyring
+	s.stamp = stamp
+	s.version, err = s.dataVersion()
+	return err
+}
+
+// changed reports whether the in-memory copy changed since it was loaded or flushed
+func (s *memoryStore) changed() bool {
+	version, err := s.dataVersion()
+	return err != nil || version != s.version
+}
+
+// dataVersion returns SQLite's counter of commits made through other connections than the keeper
+func (s *memoryStore) dataVersion() (int64, error) {
+	var version int64
+	err := s.keeper.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version)
+	return version, err
+}
+
+// statFile returns the stamp of the file at path
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
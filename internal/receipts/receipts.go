@@ -0,0 +1,150 @@
+package receipts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+)
+
+// MaxSize is the largest receipt file accepted (20 MB)
+const MaxSize = 20 << 20
+
+// EncryptedExt is appended to receipts stored encrypted
+const EncryptedExt = ".encrypted"
+
+// allowedTypes maps accepted receipt extensions to the content types they must sniff as.
+// HEIC is not recognised by http.DetectContentType, so it is accepted by extension only.
+var allowedTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".heic": "",
+}
+
+// Validate checks that data is a supported receipt file
+func Validate(name string, data []byte) error {
+	ext := strings.ToLower(filepath.Ext(name))
+	want, ok := allowedTypes[ext]
+	if !ok {
+		return fmt.Errorf("unsupported receipt type %q (use PDF, PNG, JPEG, GIF, WebP or HEIC)", ext)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("receipt file is empty")
+	}
+	if len(data) > MaxSize {
+		return fmt.Errorf("receipt file is too large (%d MB, max %d MB)", len(data)>>20, MaxSize>>20)
+	}
+	if want != "" {
+		if got := http.DetectContentType(data); !strings.HasPrefix(got, want) {
+			return fmt.Errorf("file content (%s) doesn't match its %s extension", got, ext)
+		}
+	}
+	return nil
+}
+
+// Hash returns the SHA-256 of the receipt contents, used to detect duplicates
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store writes a receipt into dir, organized by year and named by date and hash.
+// When password is set the receipt is encrypted, linked to the open database so it
+// follows password changes. It returns the stored path.
+func Store(dir, name string, data []byte, date time.Time, password string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".jpeg" {
+		ext = ".jpg"
+	}
+
+	yearDir := filepath.Join(dir, date.Format("2006"))
+	if err := os.MkdirAll(yearDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create receipts directory: %w", err)
+	}
+
+	path := filepath.Join(yearDir, fmt.Sprintf("%s_%s%s", date.Format("2006-01-02"), Hash(data)[:12], ext))
+	if password != "" {
+		encrypted, err := db.SealLinked(data, password)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt receipt: %w", err)
+		}
+		data = encrypted
+		path += EncryptedExt
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write receipt: %w", err)
+	}
+	return path, nil
+}
+
+// IsEncrypted reports whether a stored receipt is encrypted
+func IsEncrypted(path string) bool {
+	return strings.HasSuffix(path, EncryptedExt)
+}
+
+// Ext returns the original file extension of a stored receipt
+func Ext(path string) string {
+	return filepath.Ext(strings.TrimSuffix(path, EncryptedExt))
+}
+
+// Load reads a stored receipt, decrypting it when needed. The password is only
+// asked for when the open database's key doesn't unlock the receipt.
+func Load(path string, password func() (string, error)) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+	if !IsEncrypted(path) {
+		return data, nil
+	}
+	return db.OpenLinked(data, password)
+}
+
+// Rewrap re-encrypts the key of every encrypted receipt in dir with rewrap, which
+// is called with the stored file and returns its replacement. Receipts that can't
+// be rewrapped are returned in failed; the others are counted in rewrapped.
+func Rewrap(dir string, rewrap func([]byte) ([]byte, error)) (rewrapped int, failed []string, err error) {
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !IsEncrypted(path) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read receipt: %w", err)
+		}
+		output, err := rewrap(data)
+		if err != nil {
+			failed = append(failed, path)
+			return nil
+		}
+		if err := db.WriteFileAtomic(path, output, 0600); err != nil {
+			return fmt.Errorf("failed to write receipt: %w", err)
+		}
+		rewrapped++
+		return nil
+	})
+	return rewrapped, failed, err
+}
+
+// IsManaged reports whether path lies inside the receipts directory
+func IsManaged(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
@@ -0,0 +1,75 @@
+package receipts
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// password returns a password callback for Load
+func password(pw string) func() (string, error) {
+	return func() (string, error) { return pw, nil }
+}
+
+var pdfData = []byte("%PDF-1.4\n1 0 obj << >> endobj\ntrailer << >>\n%%EOF\n")
+
+func TestValidate(t *testing.T) {
+	if err := Validate("receipt.pdf", pdfData); err != nil {
+		t.Errorf("expected PDF to be valid, got %v", err)
+	}
+	if err := Validate("receipt.PDF", pdfData); err != nil {
+		t.Errorf("expected extension check to ignore case, got %v", err)
+	}
+	if err := Validate("receipt.png", pdfData); err == nil {
+		t.Error("expected mismatched content to be rejected")
+	}
+	if err := Validate("receipt.exe", pdfData); err == nil {
+		t.Error("expected unsupported extension to be rejected")
+	}
+	if err := Validate("receipt.pdf", nil); err == nil {
+		t.Error("expected empty file to be rejected")
+	}
+}
+
+func TestStoreAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	path, err := Store(dir, "Scan.PDF", pdfData, date, "")
+	if err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	wantPrefix := filepath.Join(dir, "2024", "2024-03-05_"+Hash(pdfData)[:12])
+	if path != wantPrefix+".pdf" {
+		t.Errorf("unexpected stored path: %s", path)
+	}
+	if !IsManaged(dir, path) || IsManaged(dir, "/tmp/other.pdf") {
+		t.Error("IsManaged() gave unexpected result")
+	}
+	data, err := Load(path, password(""))
+	if err != nil || !bytes.Equal(data, pdfData) {
+		t.Errorf("Load() = %q, %v", data, err)
+	}
+
+	encPath, err := Store(dir, "scan.pdf", pdfData, date, "secret")
+	if err != nil {
+		t.Fatalf("Store() encrypted error: %v", err)
+	}
+	if !IsEncrypted(encPath) || Ext(encPath) != ".pdf" {
+		t.Errorf("unexpected encrypted path: %s", encPath)
+	}
+	raw, _ := os.ReadFile(encPath)
+	if bytes.Contains(raw, []byte("%PDF")) {
+		t.Error("expected receipt to be encrypted on disk")
+	}
+	data, err = Load(encPath, password("secret"))
+	if err != nil || !bytes.Equal(data, pdfData) {
+		t.Errorf("Load() encrypted = %q, %v", data, err)
+	}
+	if _, err := Load(encPath, password("wrong")); err == nil || !strings.Contains(err.Error(), "decryption failed") {
+		t.Errorf("expected decryption error with wrong password, got %v", err)
+	}
+}
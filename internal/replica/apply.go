@@ -0,0 +1,443 @@
+package replica
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Winners of a conflict
+const (
+	WinnerLocal  = "local"
+	WinnerRemote = "remote"
+)
+
+// Conflict is a field edited on two devices since they last synced
+type Conflict struct {
+	ID         int64
+	Table      string
+	GID        string
+	RowID      int64
+	Field      string // RowField for delete/edit conflicts
+	Local      any
+	Remote     any
+	Winner     string
+	Origin     string // Device the remote change came from
+	Note       string
+	Reviewed   bool
+	DetectedAt time.Time
+}
+
+// Report summarizes applying changes
+type Report struct {
+	Applied   int            // Changes applied
+	Skipped   int            // Changes for tables this database doesn't have
+	Devices   map[string]int // Changes applied per origin device
+	Conflicts []Conflict
+}
+
+// bookkeeping columns change on every edit; their conflicts are resolved silently
+var bookkeeping = map[string]bool{"created_at": true, "updated_at": true}
+
+// Apply merges changes from other devices. Changes already applied, and this
+// device's own changes, are ignored. Local edits must be captured first so
+// they are detected as conflicts instead of being overwritten.
+func (r *Replica) Apply(changes []Change) (*Report, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	me, err := r.device(tx)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := syncedTables(tx)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := vector(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &applier{tx: tx, tables: map[string]*tableInfo{}, report: &Report{Devices: map[string]int{}}}
+	for _, t := range tables {
+		a.tables[t.name] = t
+	}
+	for _, c := range changes {
+		if c.Origin == me || c.Seq <= seen[c.Origin] {
+			continue
+		}
+		if err := a.apply(c); err != nil {
+			return nil, fmt.Errorf("failed to apply change %s to %s: %w", c.version(), c.Table, err)
+		}
+		if err := appendLog(tx, c); err != nil {
+			return nil, err
+		}
+		seen[c.Origin] = c.Seq
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return a.report, nil
+}
+
+type applier struct {
+	tx     *sql.Tx
+	tables map[string]*tableInfo
+	report *Report
+}
+
+func (a *applier) apply(c Change) error {
+	t, ok := a.tables[c.Table]
+	if !ok {
+		a.report.Skipped++
+		return nil
+	}
+	s, err := loadState(a.tx, c.GID)
+	if err != nil {
+		return err
+	}
+	a.report.Applied++
+	a.report.Devices[c.Origin]++
+
+	if c.Op == OpDelete {
+		return a.applyDelete(t, c, s)
+	}
+	if s != nil && s.Deleted {
+		// Deletes win, so both devices end up without the row
+		return a.conflict(Conflict{Table: t.name, GID: c.GID, Field: RowField, Remote: changedFields(c),
+			Winner: WinnerLocal, Origin: c.Origin, Note: "edited on the other device after it was deleted here"})
+	}
+	if s == nil {
+		return a.insert(t, c)
+	}
+	return a.update(t, c, s)
+}
+
+func (a *applier) applyDelete(t *tableInfo, c Change, s *rowState) error {
+	if s == nil {
+		// Never seen here: keep a tombstone so later edits are ignored
+		return saveState(a.tx, &rowState{GID: c.GID, Table: t.name, Deleted: true, Fields: map[string]fieldState{}})
+	}
+	if s.Deleted {
+		return nil
+	}
+
+	var edited []string
+	for column, f := range s.Fields {
+		if f.Version != c.Fields[column].Prev && !bookkeeping[column] {
+			edited = append(edited, column)
+		}
+	}
+	if len(edited) > 0 {
+		sort.Strings(edited)
+		local := map[string]any{}
+		for _, column := range edited {
+			local[column] = s.Fields[column].Value
+		}
+		err := a.conflict(Conflict{Table: t.name, GID: c.GID, RowID: s.RowID, Field: RowField, Local: local,
+			Winner: WinnerRemote, Origin: c.Origin, Note: "deleted on the other device; local edits to " + strings.Join(edited, ", ") + " were discarded"})
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := a.tx.Exec("DELETE FROM "+quoteIdent(t.name)+" WHERE id = ?", s.RowID); err != nil {
+		return err
+	}
+	s.Deleted, s.RowID = true, 0
+	return saveState(a.tx, s)
+}
+
+func (a *applier) insert(t *tableInfo, c Change) error {
+	var columns, placeholders []string
+	var args []any
+	for _, column := range sortedColumns(c.Fields) {
+		if !t.columns[column] || column == "id" {
+			continue
+		}
+		columns = append(columns, quoteIdent(column))
+		placeholders = append(placeholders, "?")
+		args = append(args, a.decode(t, c, column, c.Fields[column].Value))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", quoteIdent(t.name))
+	if len(columns) > 0 {
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(t.name), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	}
+	res, err := a.tx.Exec(query, args...)
+	if err != nil {
+		// A unique value (such as an invoice number) already taken here
+		return a.conflict(Conflict{Table: t.name, GID: c.GID, Field: RowField, Remote: changedFields(c),
+			Winner: WinnerLocal, Origin: c.Origin, Note: "not added: " + err.Error()})
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	s := &rowState{GID: c.GID, Table: t.name, RowID: id, Fields: map[string]fieldState{}}
+	for column, f := range c.Fields {
+		s.Fields[column] = fieldState{Value: f.Value, Version: c.version(), Time: c.Time}
+	}
+	return a.refresh(t, s)
+}
+
+func (a *applier) update(t *tableInfo, c Change, s *rowState) error {
+	var sets []string
+	var args []any
+	for _, column := range sortedColumns(c.Fields) {
+		incoming := c.Fields[column]
+		local := s.Fields[column]
+		theirs := fieldState{Value: incoming.Value, Version: c.version(), Time: c.Time}
+
+		switch {
+		case local.Version == incoming.Prev:
+			// Nothing changed here since the other device saw the field
+		case sameValue(local.Value, incoming.Value):
+			// Both made the same edit: agree on one version
+			if newer(local, theirs) {
+				theirs = local
+			}
+		case newer(local, theirs):
+			if !bookkeeping[column] {
+				err := a.conflict(Conflict{Table: t.name, GID: c.GID, RowID: s.RowID, Field: column,
+					Local: local.Value, Remote: incoming.Value, Winner: WinnerLocal, Origin: c.Origin})
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		default:
+			if !bookkeeping[column] {
+				err := a.conflict(Conflict{Table: t.name, GID: c.GID, RowID: s.RowID, Field: column,
+					Local: local.Value, Remote: incoming.Value, Winner: WinnerRemote, Origin: c.Origin})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		s.Fields[column] = theirs
+		if t.columns[column] && column != "id" && !sameValue(local.Value, theirs.Value) {
+			sets = append(sets, quoteIdent(column)+" = ?")
+			args = append(args, a.decode(t, c, column, theirs.Value))
+		}
+	}
+
+	if len(sets) > 0 {
+		args = append(args, s.RowID)
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", quoteIdent(t.name), strings.Join(sets, ", "))
+		if _, err := a.tx.Exec(query, args...); err != nil {
+			return a.conflict(Conflict{Table: t.name, GID: c.GID, RowID: s.RowID, Field: RowField, Remote: changedFields(c),
+				Winner: WinnerLocal, Origin: c.Origin, Note: "not updated: " + err.Error()})
+		}
+	}
+	return a.refresh(t, s)
+}
+
+// refresh stores the row as the database now holds it, so the next capture
+// doesn't mistake formatting differences for local edits
+func (a *applier) refresh(t *tableInfo, s *rowState) error {
+	gids := map[string]map[int64]string{}
+	for _, target := range t.refs {
+		if gids[target] != nil {
+			continue
+		}
+		rows, err := a.tx.Query("SELECT row_id, gid FROM sync_rows WHERE table_name = ? AND deleted = 0", target)
+		if err != nil {
+			return err
+		}
+		gids[target] = map[int64]string{}
+		for rows.Next() {
+			var id int64
+			var gid string
+			if err := rows.Scan(&id, &gid); err != nil {
+				rows.Close()
+				return err
+			}
+			gids[target][id] = gid
+		}
+		rows.Close()
+	}
+
+	current, err := readRows(a.tx, t, gids, s.RowID)
+	if err != nil {
+		return err
+	}
+	for column, value := range current[s.RowID] {
+		f := s.Fields[column]
+		f.Value = value
+		s.Fields[column] = f
+	}
+	return saveState(a.tx, s)
+}
+
+// decode converts an incoming value for writing, translating references to local row IDs
+func (a *applier) decode(t *tableInfo, c Change, column string, value any) any {
+	return decodeValue(value, func(gid string) any {
+		var id int64
+		err := a.tx.QueryRow("SELECT row_id FROM sync_rows WHERE gid = ? AND deleted = 0", gid).Scan(&id)
+		if err != nil {
+			a.conflict(Conflict{Table: t.name, GID: c.GID, Field: column,
+				Winner: WinnerRemote, Origin: c.Origin, Note: "linked record is missing here; left empty"})
+			return nil
+		}
+		return id
+	})
+}
+
+// conflict records a conflict in the report and the conflicts table
+func (a *applier) conflict(c Conflict) error {
+	local, _ := json.Marshal(c.Local)
+	remote, _ := json.Marshal(c.Remote)
+	var rowID any
+	if c.RowID != 0 {
+		rowID = c.RowID
+	}
+	res, err := a.tx.Exec(`INSERT INTO sync_conflicts (table_name, gid, row_id, field, local_value, remote_value, winner, origin, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, c.Table, c.GID, rowID, c.Field, string(local), string(remote), c.Winner, c.Origin, c.Note)
+	if err != nil {
+		return err
+	}
+	c.ID, _ = res.LastInsertId()
+	a.report.Conflicts = append(a.report.Conflicts, c)
+	return nil
+}
+
+// newer reports whether field state a was written after b (ties broken by device)
+func newer(a, b fieldState) bool {
+	if a.Time != b.Time {
+		return a.Time > b.Time
+	}
+	return a.Version > b.Version
+}
+
+func changedFields(c Change) map[string]any {
+	fields := map[string]any{}
+	for column, f := range c.Fields {
+		fields[column] = f.Value
+	}
+	return fields
+}
+
+func sortedColumns(fields map[string]FieldChange) []string {
+	columns := make([]string, 0, len(fields))
+	for column := range fields {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Conflicts returns recorded conflicts, newest first. Reviewed conflicts are
+// included only when all is set.
+func (r *Replica) Conflicts(all bool) ([]Conflict, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, table_name, gid, COALESCE(row_id, 0), field, COALESCE(local_value, 'null'), COALESCE(remote_value, 'null'),
+		winner, origin, COALESCE(note, ''), reviewed, detected_at FROM sync_conflicts`
+	if !all {
+		query += " WHERE reviewed = 0"
+	}
+	rows, err := tx.Query(query + " ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []Conflict
+	for rows.Next() {
+		var c Conflict
+		var local, remote string
+		if err := rows.Scan(&c.ID, &c.Table, &c.GID, &c.RowID, &c.Field, &local, &remote, &c.Winner, &c.Origin, &c.Note, &c.Reviewed, &c.DetectedAt); err != nil {
+			return nil, err
+		}
+		decodeJSON([]byte(local), &c.Local)
+		decodeJSON([]byte(remote), &c.Remote)
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// Resolve marks a conflict reviewed, writing the losing value back first if
+// take names the side that lost. The change is synced like any local edit.
+func (r *Replica) Resolve(id int64, take string) error {
+	if take != WinnerLocal && take != WinnerRemote {
+		return fmt.Errorf("take must be %q or %q", WinnerLocal, WinnerRemote)
+	}
+	conflicts, err := r.Conflicts(true)
+	if err != nil {
+		return err
+	}
+	var c *Conflict
+	for i := range conflicts {
+		if conflicts[i].ID == id {
+			c = &conflicts[i]
+		}
+	}
+	if c == nil {
+		return fmt.Errorf("conflict %d not found", id)
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if take != c.Winner {
+		if c.Field == RowField {
+			return fmt.Errorf("conflict %d is about the whole row; re-create or delete it by hand", id)
+		}
+		value := c.Remote
+		if take == WinnerLocal {
+			value = c.Local
+		}
+		arg := decodeValue(value, func(gid string) any {
+			var rowID int64
+			tx.QueryRow("SELECT row_id FROM sync_rows WHERE gid = ? AND deleted = 0", gid).Scan(&rowID)
+			if rowID == 0 {
+				return nil
+			}
+			return rowID
+		})
+		res, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", quoteIdent(c.Table), quoteIdent(c.Field)), arg, c.RowID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("the %s row of conflict %d no longer exists", c.Table, id)
+		}
+	}
+	if _, err := tx.Exec("UPDATE sync_conflicts SET reviewed = 1 WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkReviewed marks all open conflicts reviewed and returns how many there were
+func (r *Replica) MarkReviewed() (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("UPDATE sync_conflicts SET reviewed = 1 WHERE reviewed = 0")
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), tx.Commit()
+}
@@ -0,0 +1,345 @@
+package replica
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/internal/db"
+)
+
+// A shared folder holds the change log of every device that syncs through it:
+//
+//	folder.json                           format version and whether the folder is encrypted
+//	key                                   folder key, encrypted with the database password and key (encrypted folders only)
+//	<device>/<first>-<last>.json          changes <first>..<last> made on <device>
+//
+// Each push only adds files, and each device only reads others' files, so
+// devices can sync through Dropbox, iCloud Drive or a network share without
+// a server or locking.
+
+const folderVersion = 1
+
+// ErrNoFolder is returned when pulling from a directory no device has pushed to
+var ErrNoFolder = errors.New("no sync folder")
+
+type folderConfig struct {
+	Version   int  `json:"version"`
+	Encrypted bool `json:"encrypted"`
+}
+
+// Folder is an opened shared sync folder
+type Folder struct {
+	dir string
+	key []byte // nil for unencrypted folders
+}
+
+// FolderExists reports whether dir is a sync folder
+func FolderExists(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "folder.json"))
+	return err == nil
+}
+
+// InitFolder creates a sync folder in dir. An empty password creates an unencrypted folder.
+func InitFolder(dir, password string) (*Folder, error) {
+	if FolderExists(dir) {
+		return nil, fmt.Errorf("sync folder already exists in %s", dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create sync folder: %w", err)
+	}
+
+	f := &Folder{dir: dir}
+	if password != "" {
+		f.key = make([]byte, 32)
+		if _, err := rand.Read(f.key); err != nil {
+			return nil, fmt.Errorf("failed to generate folder key: %w", err)
+		}
+		sealed, err := db.SealLinked(f.key, password)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.WriteFileAtomic(filepath.Join(dir, "key"), sealed, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write folder key: %w", err)
+		}
+	}
+
+	cfg, _ := json.MarshalIndent(folderConfig{Version: folderVersion, Encrypted: password != ""}, "", "  ")
+	if err := db.WriteFileAtomic(filepath.Join(dir, "folder.json"), cfg, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write folder config: %w", err)
+	}
+	return f, nil
+}
+
+// OpenFolder opens the sync folder in dir, asking for the password only if it is encrypted
+func OpenFolder(dir string, password func() (string, error)) (*Folder, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "folder.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w in %s", ErrNoFolder, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder config: %w", err)
+	}
+	var cfg folderConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid folder config: %w", err)
+	}
+	if cfg.Version > folderVersion {
+		return nil, fmt.Errorf("sync folder version %d is newer than this ung supports (upgrade ung)", cfg.Version)
+	}
+
+	f := &Folder{dir: dir}
+	if cfg.Encrypted {
+		sealed, err := os.ReadFile(filepath.Join(dir, "key"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read folder key: %w", err)
+		}
+		if f.key, err = db.OpenLinked(sealed, password); err != nil {
+			return nil, fmt.Errorf("failed to unlock sync folder (all devices must use the same password): %w", err)
+		}
+	}
+	return f, nil
+}
+
+// RewrapFolderKey replaces the sealed folder key in dir with the result of rewrap,
+// used when the database password changes. Other devices must then use the new
+// password as well. Unencrypted folders are left alone.
+func RewrapFolderKey(dir string, rewrap func([]byte) ([]byte, error)) error {
+	path := filepath.Join(dir, "key")
+	sealed, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read folder key: %w", err)
+	}
+	output, err := rewrap(sealed)
+	if err != nil {
+		return err
+	}
+	return db.WriteFileAtomic(path, output, 0600)
+}
+
+// Encrypted reports whether the folder encrypts its contents
+func (f *Folder) Encrypted() bool {
+	return f.key != nil
+}
+
+// segment is one change file in the folder
+type segment struct {
+	origin      string
+	first, last int64
+	path        string
+}
+
+// segments lists the change files in the folder, per device in order
+func (f *Folder) segments() ([]segment, error) {
+	devices, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []segment
+	for _, device := range devices {
+		if !device.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(f.dir, device.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			name := strings.TrimSuffix(file.Name(), ".json")
+			first, last, ok := strings.Cut(name, "-")
+			if file.IsDir() || name == file.Name() || !ok {
+				continue
+			}
+			s := segment{origin: device.Name(), path: filepath.Join(f.dir, device.Name(), file.Name())}
+			var err1, err2 error
+			s.first, err1 = strconv.ParseInt(first, 10, 64)
+			s.last, err2 = strconv.ParseInt(last, 10, 64)
+			if err1 == nil && err2 == nil {
+				segments = append(segments, s)
+			}
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		if segments[i].origin != segments[j].origin {
+			return segments[i].origin < segments[j].origin
+		}
+		return segments[i].first < segments[j].first
+	})
+	return segments, nil
+}
+
+// Push writes the replica's changes the folder doesn't have yet, including
+// changes relayed from devices that don't use this folder, and returns how
+// many were written
+func (f *Folder) Push(r *Replica) (int, error) {
+	segments, err := f.segments()
+	if err != nil {
+		return 0, err
+	}
+	have := map[string]int64{}
+	for _, s := range segments {
+		if s.last > have[s.origin] {
+			have[s.origin] = s.last
+		}
+	}
+
+	changes, err := r.ChangesSince(have)
+	if err != nil {
+		return 0, err
+	}
+	byOrigin := map[string][]Change{}
+	for _, c := range changes {
+		byOrigin[c.Origin] = append(byOrigin[c.Origin], c)
+	}
+
+	for origin, changes := range byOrigin {
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return 0, err
+		}
+		name := fmt.Sprintf("%012d-%012d.json", changes[0].Seq, changes[len(changes)-1].Seq)
+		if data, err = f.seal(data, origin+"/"+name); err != nil {
+			return 0, err
+		}
+		if err := os.MkdirAll(filepath.Join(f.dir, origin), 0700); err != nil {
+			return 0, err
+		}
+		if err := db.WriteFileAtomic(filepath.Join(f.dir, origin, name), data, 0600); err != nil {
+			return 0, fmt.Errorf("failed to write changes: %w", err)
+		}
+	}
+	return len(changes), nil
+}
+
+// Pull applies the changes in the folder the replica hasn't seen yet
+func (f *Folder) Pull(r *Replica) (*Report, error) {
+	seen, err := r.Vector()
+	if err != nil {
+		return nil, err
+	}
+	segments, err := f.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	byOrigin := map[string][]Change{}
+	for _, s := range segments {
+		if s.last <= seen[s.origin] {
+			continue
+		}
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			return nil, err
+		}
+		rel := s.origin + "/" + filepath.Base(s.path)
+		if data, err = f.open(data, rel); err != nil {
+			return nil, fmt.Errorf("%s is corrupted: %w", rel, err)
+		}
+		var changes []Change
+		if err := decodeJSON(data, &changes); err != nil {
+			return nil, fmt.Errorf("%s is corrupted: %w", rel, err)
+		}
+		for _, c := range changes {
+			// Overlapping files (pushed by two relaying devices) repeat changes
+			if c.Origin == s.origin && c.Seq > seen[s.origin] {
+				byOrigin[s.origin] = append(byOrigin[s.origin], c)
+				seen[s.origin] = c.Seq
+			}
+		}
+	}
+	return r.Apply(interleave(byOrigin))
+}
+
+// interleave merges each device's changes by time while keeping every
+// device's own order
+func interleave(byOrigin map[string][]Change) []Change {
+	origins := make([]string, 0, len(byOrigin))
+	total := 0
+	for origin, changes := range byOrigin {
+		origins = append(origins, origin)
+		total += len(changes)
+	}
+	sort.Strings(origins)
+
+	merged := make([]Change, 0, total)
+	next := map[string]int{}
+	for len(merged) < total {
+		pick := ""
+		for _, origin := range origins {
+			i := next[origin]
+			if i >= len(byOrigin[origin]) {
+				continue
+			}
+			if pick == "" || byOrigin[origin][i].Time < byOrigin[pick][next[pick]].Time {
+				pick = origin
+			}
+		}
+		merged = append(merged, byOrigin[pick][next[pick]])
+		next[pick]++
+	}
+	return merged
+}
+
+// seal encrypts a change file bound to its name (a no-op for unencrypted folders)
+func (f *Folder) seal(data []byte, name string) ([]byte, error) {
+	if f.key == nil {
+		return data, nil
+	}
+	gcm, err := f.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, data, []byte(name)), nil
+}
+
+// open reverses seal
+func (f *Folder) open(data []byte, name string) ([]byte, error) {
+	if f.key == nil {
+		return data, nil
+	}
+	gcm, err := f.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(name))
+}
+
+func (f *Folder) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(f.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Exchange applies the changes src has that dst hasn't seen to dst. Used to
+// sync directly with another database file.
+func Exchange(src, dst *Replica) (*Report, error) {
+	seen, err := dst.Vector()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := src.ChangesSince(seen)
+	if err != nil {
+		return nil, err
+	}
+	return dst.Apply(changes)
+}
@@ -0,0 +1,728 @@
+// Package replica merges copies of a UNG database that are edited on more
+// than one device.
+//
+// Every row gets a stable global ID, so the same client or invoice can be
+// matched across databases whose integer IDs differ. Capture compares each
+// row against the state recorded at the last sync and appends the changed
+// fields to a change log. Each change carries the version of every field it
+// replaced, so applying it on another device can tell a fast-forward from a
+// concurrent edit. Concurrent edits of the same field are resolved by last
+// writer wins and recorded as conflicts for review.
+//
+// Bookkeeping tables (created on demand in every database that syncs):
+//
+//	sync_meta       device ID of this database
+//	sync_rows       global ID, local row ID and per-field state of every row
+//	sync_log        change log (local and applied remote changes)
+//	sync_conflicts  concurrent edits and how they were resolved
+package replica
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Change operations
+const (
+	OpUpsert = "upsert"
+	OpDelete = "delete"
+)
+
+// Change is one row-level change in the log
+type Change struct {
+	Origin string                 `json:"origin"` // Device that made the change
+	Seq    int64                  `json:"seq"`    // Origin's sequence number
+	Time   int64                  `json:"time"`   // Unix milliseconds
+	Table  string                 `json:"table"`
+	GID    string                 `json:"gid"`
+	Op     string                 `json:"op"`
+	Fields map[string]FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange is the new value of a field and the version it replaced
+type FieldChange struct {
+	Value any    `json:"v"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// version names the change that last wrote a field
+func (c Change) version() string {
+	return c.Origin + ":" + strconv.FormatInt(c.Seq, 10)
+}
+
+// fieldState is the last synced value of a field and the change that wrote it
+type fieldState struct {
+	Value   any    `json:"v"`
+	Version string `json:"ver,omitempty"`
+	Time    int64  `json:"t,omitempty"`
+}
+
+// rowState is the sync state of one row
+type rowState struct {
+	GID     string
+	Table   string
+	RowID   int64 // 0 once deleted
+	Deleted bool
+	Fields  map[string]fieldState
+}
+
+const schema = `
+	CREATE TABLE IF NOT EXISTS sync_meta (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS sync_rows (
+		gid TEXT PRIMARY KEY,
+		table_name TEXT NOT NULL,
+		row_id INTEGER,
+		deleted INTEGER NOT NULL DEFAULT 0,
+		fields TEXT NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_sync_rows_row ON sync_rows(table_name, row_id);
+	CREATE TABLE IF NOT EXISTS sync_log (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		origin TEXT NOT NULL,
+		origin_seq INTEGER NOT NULL,
+		time INTEGER NOT NULL,
+		table_name TEXT NOT NULL,
+		gid TEXT NOT NULL,
+		op TEXT NOT NULL,
+		fields TEXT NOT NULL,
+		UNIQUE (origin, origin_seq)
+	);
+	CREATE TABLE IF NOT EXISTS sync_conflicts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		table_name TEXT NOT NULL,
+		gid TEXT NOT NULL,
+		row_id INTEGER,
+		field TEXT NOT NULL,
+		local_value TEXT,
+		remote_value TEXT,
+		winner TEXT NOT NULL,
+		origin TEXT NOT NULL,
+		note TEXT,
+		reviewed INTEGER NOT NULL DEFAULT 0,
+		detected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// RowField is the pseudo-field of conflicts about a whole row (delete vs edit)
+const RowField = "(row)"
+
+// Replica is a database taking part in sync
+type Replica struct {
+	conn     *sql.DB
+	identity string
+}
+
+// New returns the replica for a database. identity names where the database
+// lives (host and path); a copy of the file found under a different identity
+// gets a new device ID so the two copies don't mistake each other's changes
+// for their own.
+func New(conn *sql.DB, identity string) *Replica {
+	return &Replica{conn: conn, identity: identity}
+}
+
+// Device returns the device ID of the replica
+func (r *Replica) Device() (string, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	id, err := r.device(tx)
+	if err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+func (r *Replica) begin() (*sql.Tx, error) {
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(schema); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to create sync tables: %w", err)
+	}
+	return tx, nil
+}
+
+// device returns the device ID, creating one on first use or when the
+// database moved to another identity
+func (r *Replica) device(tx *sql.Tx) (string, error) {
+	var id, identity string
+	tx.QueryRow("SELECT value FROM sync_meta WHERE key = 'device_id'").Scan(&id)
+	tx.QueryRow("SELECT value FROM sync_meta WHERE key = 'identity'").Scan(&identity)
+	if id != "" && identity == r.identity {
+		return id, nil
+	}
+
+	id = randomHex(8)
+	for key, value := range map[string]string{"device_id": id, "identity": r.identity} {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO sync_meta (key, value) VALUES (?, ?)", key, value); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// Capture records the rows changed since the last sync in the change log and
+// returns the number of changes recorded
+func (r *Replica) Capture() (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	me, err := r.device(tx)
+	if err != nil {
+		return 0, err
+	}
+	tables, err := syncedTables(tx)
+	if err != nil {
+		return 0, err
+	}
+	states, err := loadStates(tx)
+	if err != nil {
+		return 0, err
+	}
+	var tracked int
+	tx.QueryRow("SELECT count(*) FROM sync_rows").Scan(&tracked)
+
+	// Give every row a global ID first so references can be translated
+	live := map[string]map[int64]*rowState{}
+	for _, s := range states {
+		if !s.Deleted {
+			if live[s.Table] == nil {
+				live[s.Table] = map[int64]*rowState{}
+			}
+			live[s.Table][s.RowID] = s
+		}
+	}
+	gids := map[string]map[int64]string{}
+	for _, t := range tables {
+		ids, err := rowIDs(tx, t)
+		if err != nil {
+			return 0, err
+		}
+		gids[t.name] = map[int64]string{}
+		for id, createdAt := range ids {
+			if s := live[t.name][id]; s != nil {
+				gids[t.name][id] = s.GID
+			} else if tracked == 0 {
+				// Copies of a database that never synced agree on these IDs
+				gids[t.name][id] = stableGID(t.name, id, createdAt)
+			} else {
+				gids[t.name][id] = randomHex(16)
+			}
+		}
+	}
+
+	seq, err := nextSeq(tx, me)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UnixMilli()
+	count := 0
+	record := func(c Change, s *rowState) error {
+		if err := appendLog(tx, c); err != nil {
+			return err
+		}
+		seq++
+		count++
+		return saveState(tx, s)
+	}
+
+	for _, t := range tables {
+		rows, err := readRows(tx, t, gids, 0)
+		if err != nil {
+			return 0, err
+		}
+		for id, fields := range rows {
+			gid := gids[t.name][id]
+			s := states[gid]
+			if s == nil || s.Deleted {
+				s = &rowState{GID: gid, Table: t.name, Fields: map[string]fieldState{}}
+				states[gid] = s
+			}
+			s.RowID = id
+			delete(live[t.name], id)
+
+			change := Change{Origin: me, Seq: seq, Time: now, Table: t.name, GID: gid, Op: OpUpsert, Fields: map[string]FieldChange{}}
+			for column, value := range fields {
+				old, ok := s.Fields[column]
+				if ok && sameValue(old.Value, value) {
+					continue
+				}
+				change.Fields[column] = FieldChange{Value: value, Prev: old.Version}
+				s.Fields[column] = fieldState{Value: value, Version: change.version(), Time: now}
+			}
+			if len(change.Fields) == 0 {
+				continue
+			}
+			if err := record(change, s); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// Rows that are gone were deleted locally
+	for _, t := range tables {
+		for _, s := range sortedStates(live[t.name]) {
+			change := Change{Origin: me, Seq: seq, Time: now, Table: t.name, GID: s.GID, Op: OpDelete, Fields: map[string]FieldChange{}}
+			for column, f := range s.Fields {
+				change.Fields[column] = FieldChange{Prev: f.Version}
+			}
+			s.Deleted, s.RowID = true, 0
+			if err := record(change, s); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Vector returns the highest sequence number applied from each device
+func (r *Replica) Vector() (map[string]int64, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return vector(tx)
+}
+
+func vector(tx *sql.Tx) (map[string]int64, error) {
+	rows, err := tx.Query("SELECT origin, MAX(origin_seq) FROM sync_log GROUP BY origin")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	v := map[string]int64{}
+	for rows.Next() {
+		var origin string
+		var seq int64
+		if err := rows.Scan(&origin, &seq); err != nil {
+			return nil, err
+		}
+		v[origin] = seq
+	}
+	return v, rows.Err()
+}
+
+// ChangesSince returns the logged changes the given vector hasn't seen, in log order
+func (r *Replica) ChangesSince(seen map[string]int64) ([]Change, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT origin, origin_seq, time, table_name, gid, op, fields FROM sync_log ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var changes []Change
+	for rows.Next() {
+		var c Change
+		var fields string
+		if err := rows.Scan(&c.Origin, &c.Seq, &c.Time, &c.Table, &c.GID, &c.Op, &fields); err != nil {
+			return nil, err
+		}
+		if c.Seq <= seen[c.Origin] {
+			continue
+		}
+		if err := decodeJSON([]byte(fields), &c.Fields); err != nil {
+			return nil, fmt.Errorf("corrupted change log entry %s: %w", c.version(), err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func appendLog(tx *sql.Tx, c Change) error {
+	fields, err := json.Marshal(c.Fields)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO sync_log (origin, origin_seq, time, table_name, gid, op, fields)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, c.Origin, c.Seq, c.Time, c.Table, c.GID, c.Op, string(fields))
+	return err
+}
+
+func nextSeq(tx *sql.Tx, origin string) (int64, error) {
+	var seq int64
+	err := tx.QueryRow("SELECT COALESCE(MAX(origin_seq), 0) + 1 FROM sync_log WHERE origin = ?", origin).Scan(&seq)
+	return seq, err
+}
+
+// loadStates reads the sync state of every row, keyed by global ID
+func loadStates(tx *sql.Tx) (map[string]*rowState, error) {
+	rows, err := tx.Query("SELECT gid, table_name, COALESCE(row_id, 0), deleted, fields FROM sync_rows")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	states := map[string]*rowState{}
+	for rows.Next() {
+		s := &rowState{}
+		var fields string
+		if err := rows.Scan(&s.GID, &s.Table, &s.RowID, &s.Deleted, &fields); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON([]byte(fields), &s.Fields); err != nil {
+			return nil, fmt.Errorf("corrupted sync state for %s: %w", s.GID, err)
+		}
+		if s.Fields == nil {
+			s.Fields = map[string]fieldState{}
+		}
+		states[s.GID] = s
+	}
+	return states, rows.Err()
+}
+
+func loadState(tx *sql.Tx, gid string) (*rowState, error) {
+	s := &rowState{GID: gid}
+	var fields string
+	err := tx.QueryRow("SELECT table_name, COALESCE(row_id, 0), deleted, fields FROM sync_rows WHERE gid = ?", gid).
+		Scan(&s.Table, &s.RowID, &s.Deleted, &fields)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeJSON([]byte(fields), &s.Fields); err != nil {
+		return nil, fmt.Errorf("corrupted sync state for %s: %w", gid, err)
+	}
+	if s.Fields == nil {
+		s.Fields = map[string]fieldState{}
+	}
+	return s, nil
+}
+
+func saveState(tx *sql.Tx, s *rowState) error {
+	fields, err := json.Marshal(s.Fields)
+	if err != nil {
+		return err
+	}
+	var rowID any
+	if !s.Deleted {
+		rowID = s.RowID
+	}
+	_, err = tx.Exec("INSERT OR REPLACE INTO sync_rows (gid, table_name, row_id, deleted, fields) VALUES (?, ?, ?, ?, ?)",
+		s.GID, s.Table, rowID, s.Deleted, string(fields))
+	return err
+}
+
+func sortedStates(m map[int64]*rowState) []*rowState {
+	states := make([]*rowState, 0, len(m))
+	for _, s := range m {
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].RowID < states[j].RowID })
+	return states
+}
+
+// tableInfo describes a synced table
+type tableInfo struct {
+	name    string
+	columns map[string]bool
+	refs    map[string]string // Column -> referenced table
+}
+
+// syncedTables returns the tables with an integer id primary key, parents
+// before children. Each device keeps its own audit log, and its own email
+// outbox so a queued email is only sent by the device that queued it.
+func syncedTables(tx *sql.Tx) ([]*tableInfo, error) {
+	rows, err := tx.Query(`SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE 'sync_%' AND name NOT LIKE 'audit_%'
+			AND name NOT IN ('schema_migrations', 'email_outbox')
+		ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	byName := map[string]*tableInfo{}
+	var tables []*tableInfo
+	for _, name := range names {
+		t := &tableInfo{name: name, columns: map[string]bool{}, refs: map[string]string{}}
+		cols, err := tx.Query("SELECT name, type, pk FROM pragma_table_info(?)", name)
+		if err != nil {
+			return nil, err
+		}
+		hasID := false
+		for cols.Next() {
+			var column, typ string
+			var pk int
+			if err := cols.Scan(&column, &typ, &pk); err != nil {
+				cols.Close()
+				return nil, err
+			}
+			t.columns[column] = true
+			if column == "id" && pk == 1 && strings.Contains(strings.ToUpper(typ), "INT") {
+				hasID = true
+			}
+		}
+		cols.Close()
+		if !hasID {
+			continue
+		}
+
+		fks, err := tx.Query(`SELECT "from", "table" FROM pragma_foreign_key_list(?)`, name)
+		if err != nil {
+			return nil, err
+		}
+		for fks.Next() {
+			var from, target string
+			if err := fks.Scan(&from, &target); err != nil {
+				fks.Close()
+				return nil, err
+			}
+			t.refs[from] = target
+		}
+		fks.Close()
+		byName[name] = t
+		tables = append(tables, t)
+	}
+
+	// Tables created without declared foreign keys follow the <name>_id convention
+	for _, t := range tables {
+		for column := range t.columns {
+			target := strings.TrimSuffix(column, "_id") + "s"
+			if _, ok := t.refs[column]; !ok && strings.HasSuffix(column, "_id") && byName[target] != nil {
+				t.refs[column] = target
+			}
+		}
+		for column, target := range t.refs {
+			if byName[target] == nil {
+				delete(t.refs, column)
+			}
+		}
+	}
+	return parentsFirst(tables), nil
+}
+
+// parentsFirst orders tables so referenced tables come before the tables referencing them
+func parentsFirst(tables []*tableInfo) []*tableInfo {
+	done := map[string]bool{}
+	var ordered []*tableInfo
+	for len(ordered) < len(tables) {
+		progressed := false
+		for _, t := range tables {
+			if done[t.name] {
+				continue
+			}
+			ready := true
+			for _, target := range t.refs {
+				if target != t.name && !done[target] {
+					ready = false
+				}
+			}
+			if ready {
+				done[t.name] = true
+				ordered = append(ordered, t)
+				progressed = true
+			}
+		}
+		if !progressed {
+			// Reference cycle: take the rest in name order
+			for _, t := range tables {
+				if !done[t.name] {
+					done[t.name] = true
+					ordered = append(ordered, t)
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// rowIDs returns the IDs of a table's rows with their created_at value (if any)
+func rowIDs(tx *sql.Tx, t *tableInfo) (map[int64]string, error) {
+	createdAt := "''"
+	if t.columns["created_at"] {
+		createdAt = "COALESCE(CAST(created_at AS TEXT), '')"
+	}
+	rows, err := tx.Query(fmt.Sprintf("SELECT id, %s FROM %s", createdAt, quoteIdent(t.name)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var created string
+		if err := rows.Scan(&id, &created); err != nil {
+			return nil, err
+		}
+		ids[id] = created
+	}
+	return ids, rows.Err()
+}
+
+// readRows reads a table (or one row of it when id is set) as encoded field
+// values, with references replaced by global IDs
+func readRows(tx *sql.Tx, t *tableInfo, gids map[string]map[int64]string, id int64) (map[int64]map[string]any, error) {
+	query := "SELECT * FROM " + quoteIdent(t.name)
+	var args []any
+	if id != 0 {
+		query += " WHERE id = ?"
+		args = append(args, id)
+	}
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[int64]map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		fields := map[string]any{}
+		var rowID int64
+		for i, column := range columns {
+			if column == "id" {
+				rowID, _ = values[i].(int64)
+				continue
+			}
+			value := encodeValue(values[i])
+			if target, ok := t.refs[column]; ok && value != nil {
+				ref, _ := value.(int64)
+				if gid, ok := gids[target][ref]; ok {
+					value = map[string]string{"ref": gid}
+				} else {
+					value = nil // Dangling reference
+				}
+			}
+			fields[column] = value
+		}
+		result[rowID] = fields
+	}
+	return result, rows.Err()
+}
+
+// sqliteTimeFormat is the format go-sqlite3 stores time values in
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// encodeValue converts a scanned value into its JSON form
+func encodeValue(v any) any {
+	switch val := v.(type) {
+	case []byte:
+		return map[string]string{"base64": base64.StdEncoding.EncodeToString(val)}
+	case time.Time:
+		return val.Format(sqliteTimeFormat)
+	}
+	return v
+}
+
+// decodeValue converts an encoded value (decoded with UseNumber) back for
+// writing; references are resolved with ref
+func decodeValue(v any, ref func(gid string) any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		if s, ok := val["base64"].(string); ok {
+			b, _ := base64.StdEncoding.DecodeString(s)
+			return b
+		}
+		if gid, ok := val["ref"].(string); ok {
+			return ref(gid)
+		}
+	case map[string]string:
+		if gid, ok := val["ref"]; ok {
+			return ref(gid)
+		}
+	}
+	return v
+}
+
+// sameValue compares encoded values by their JSON form, so 120 and 120.0 match
+func sameValue(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// FormatValue renders an encoded value for display
+func FormatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "(empty)"
+	case string:
+		return val
+	case map[string]any:
+		if s, ok := val["base64"].(string); ok {
+			return fmt.Sprintf("(%d bytes)", base64.StdEncoding.DecodedLen(len(s)))
+		}
+		if _, ok := val["ref"]; ok {
+			return "(linked record)"
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func stableGID(table string, id int64, createdAt string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s", table, id, createdAt)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
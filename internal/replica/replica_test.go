@@ -0,0 +1,249 @@
+package replica
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testSchema = `
+	CREATE TABLE clients (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, email TEXT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+	CREATE TABLE invoices (id INTEGER PRIMARY KEY AUTOINCREMENT, invoice_num TEXT UNIQUE, client_id INTEGER,
+		amount REAL, FOREIGN KEY (client_id) REFERENCES clients(id));
+	CREATE TABLE invoice_line_items (id INTEGER PRIMARY KEY AUTOINCREMENT, invoice_id INTEGER, description TEXT);
+`
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// copiedDatabases returns two copies of the same database, as when a file is
+// copied from a laptop to a desktop
+func copiedDatabases(t *testing.T) (*sql.DB, *Replica, *sql.DB, *Replica) {
+	t.Helper()
+	dir := t.TempDir()
+	laptopPath := filepath.Join(dir, "laptop.db")
+	laptop := openTestDB(t, laptopPath)
+	if _, err := laptop.Exec(testSchema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	laptop.Exec("INSERT INTO clients (name, email) VALUES ('Acme', 'billing@acme.test')")
+	laptop.Exec("INSERT INTO invoices (invoice_num, client_id, amount) VALUES ('INV-001', 1, 100)")
+
+	data, _ := os.ReadFile(laptopPath)
+	desktopPath := filepath.Join(dir, "desktop.db")
+	os.WriteFile(desktopPath, data, 0600)
+	desktop := openTestDB(t, desktopPath)
+	return laptop, New(laptop, "laptop"), desktop, New(desktop, "desktop")
+}
+
+// sync captures both sides and exchanges changes both ways
+func sync(t *testing.T, a, b *Replica) (*Report, *Report) {
+	t.Helper()
+	for _, r := range []*Replica{a, b} {
+		if _, err := r.Capture(); err != nil {
+			t.Fatalf("Capture() error: %v", err)
+		}
+	}
+	toB, err := Exchange(a, b)
+	if err != nil {
+		t.Fatalf("Exchange() error: %v", err)
+	}
+	toA, err := Exchange(b, a)
+	if err != nil {
+		t.Fatalf("Exchange() error: %v", err)
+	}
+	return toA, toB
+}
+
+func queryString(t *testing.T, conn *sql.DB, query string, args ...any) string {
+	t.Helper()
+	var s sql.NullString
+	if err := conn.QueryRow(query, args...).Scan(&s); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return s.String
+}
+
+func TestSyncMergesEditsAndTranslatesReferences(t *testing.T) {
+	laptop, a, desktop, b := copiedDatabases(t)
+
+	// The copies agree on existing rows, so the first sync changes nothing
+	toA, toB := sync(t, a, b)
+	if len(toA.Conflicts)+len(toB.Conflicts) != 0 {
+		t.Fatalf("expected no conflicts for identical copies, got %+v %+v", toA.Conflicts, toB.Conflicts)
+	}
+	if n := queryString(t, desktop, "SELECT count(*) FROM clients"); n != "1" {
+		t.Fatalf("expected no duplicate clients, got %s", n)
+	}
+
+	// Both add a client with the same local ID; different fields of the invoice change
+	laptop.Exec("INSERT INTO clients (name) VALUES ('Laptop Client')")
+	laptop.Exec("UPDATE invoices SET amount = 250")
+	desktop.Exec("INSERT INTO clients (name) VALUES ('Desktop Client')")
+	desktop.Exec("INSERT INTO invoices (invoice_num, client_id, amount) VALUES ('INV-002', 2, 80)")
+	desktop.Exec("INSERT INTO invoice_line_items (invoice_id, description) VALUES (2, 'Design')")
+	desktop.Exec("UPDATE clients SET email = 'ap@acme.test' WHERE name = 'Acme'")
+
+	toA, toB = sync(t, a, b)
+	if len(toA.Conflicts)+len(toB.Conflicts) != 0 {
+		t.Fatalf("expected edits of different fields to merge, got %+v %+v", toA.Conflicts, toB.Conflicts)
+	}
+
+	for name, conn := range map[string]*sql.DB{"laptop": laptop, "desktop": desktop} {
+		if n := queryString(t, conn, "SELECT count(*) FROM clients"); n != "3" {
+			t.Errorf("%s: expected 3 clients, got %s", name, n)
+		}
+		if email := queryString(t, conn, "SELECT email FROM clients WHERE name = 'Acme'"); email != "ap@acme.test" {
+			t.Errorf("%s: expected merged email, got %s", name, email)
+		}
+		if amount := queryString(t, conn, "SELECT amount FROM invoices WHERE invoice_num = 'INV-001'"); amount != "250" && amount != "250.0" {
+			t.Errorf("%s: expected merged amount, got %s", name, amount)
+		}
+		// References point at the right rows even though local IDs differ
+		client := queryString(t, conn, "SELECT c.name FROM invoices i JOIN clients c ON c.id = i.client_id WHERE i.invoice_num = 'INV-002'")
+		if client != "Desktop Client" {
+			t.Errorf("%s: expected INV-002 to belong to Desktop Client, got %q", name, client)
+		}
+		item := queryString(t, conn, "SELECT i.invoice_num FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id")
+		if item != "INV-002" {
+			t.Errorf("%s: expected line item on INV-002, got %q", name, item)
+		}
+	}
+
+	// Applied changes aren't echoed back as local changes
+	for _, r := range []*Replica{a, b} {
+		if n, _ := r.Capture(); n != 0 {
+			t.Errorf("expected nothing to capture after sync, got %d changes", n)
+		}
+	}
+}
+
+func TestSyncResolvesConflictsAndRecordsThem(t *testing.T) {
+	laptop, a, desktop, b := copiedDatabases(t)
+	sync(t, a, b)
+
+	laptop.Exec("UPDATE clients SET name = 'Acme Laptop'")
+	if _, err := a.Capture(); err != nil {
+		t.Fatal(err)
+	}
+	desktop.Exec("UPDATE clients SET name = 'Acme Desktop'")
+	if _, err := b.Capture(); err != nil {
+		t.Fatal(err)
+	}
+	sync(t, a, b)
+
+	// Last writer (desktop) wins on both devices
+	for name, conn := range map[string]*sql.DB{"laptop": laptop, "desktop": desktop} {
+		if got := queryString(t, conn, "SELECT name FROM clients"); got != "Acme Desktop" {
+			t.Errorf("%s: expected the newer edit to win, got %q", name, got)
+		}
+	}
+	conflicts, err := a.Conflicts(false)
+	if err != nil {
+		t.Fatalf("Conflicts() error: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Field != "name" || conflicts[0].Winner != WinnerRemote ||
+		conflicts[0].Local != "Acme Laptop" || conflicts[0].Remote != "Acme Desktop" {
+		t.Fatalf("unexpected conflicts: %+v", conflicts)
+	}
+
+	// Taking the losing side writes it back and syncs it like an edit
+	if err := a.Resolve(conflicts[0].ID, WinnerLocal); err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	sync(t, a, b)
+	if got := queryString(t, desktop, "SELECT name FROM clients"); got != "Acme Laptop" {
+		t.Errorf("expected resolved value to sync, got %q", got)
+	}
+	if open, _ := a.Conflicts(false); len(open) != 0 {
+		t.Errorf("expected resolved conflict to be reviewed, got %+v", open)
+	}
+}
+
+func TestSyncDeletesWinOverEdits(t *testing.T) {
+	laptop, a, desktop, b := copiedDatabases(t)
+	sync(t, a, b)
+
+	laptop.Exec("DELETE FROM invoices")
+	a.Capture()
+	desktop.Exec("UPDATE invoices SET amount = 999")
+	sync(t, a, b)
+
+	for name, conn := range map[string]*sql.DB{"laptop": laptop, "desktop": desktop} {
+		if n := queryString(t, conn, "SELECT count(*) FROM invoices"); n != "0" {
+			t.Errorf("%s: expected invoice to stay deleted, got %s rows", name, n)
+		}
+	}
+	conflicts, _ := b.Conflicts(false)
+	if len(conflicts) != 1 || conflicts[0].Field != RowField {
+		t.Errorf("expected a row conflict for the discarded edit, got %+v", conflicts)
+	}
+}
+
+func TestFolderPushPull(t *testing.T) {
+	laptop, a, desktop, b := copiedDatabases(t)
+	dir := filepath.Join(t.TempDir(), "shared")
+	password := func() (string, error) { return "secret", nil }
+
+	if _, err := OpenFolder(dir, password); err == nil {
+		t.Fatal("expected error for a folder nobody pushed to")
+	}
+	folder, err := InitFolder(dir, "secret")
+	if err != nil {
+		t.Fatalf("InitFolder() error: %v", err)
+	}
+
+	// Initial sync so both devices share a base
+	for _, r := range []*Replica{a, b, a} {
+		r.Capture()
+		if _, err := folder.Pull(r); err != nil {
+			t.Fatalf("Pull() error: %v", err)
+		}
+		folder.Push(r)
+	}
+
+	laptop.Exec("INSERT INTO clients (name) VALUES ('From Laptop')")
+	a.Capture()
+	if n, err := folder.Push(a); err != nil || n == 0 {
+		t.Fatalf("Push() = %d, %v", n, err)
+	}
+	if n, _ := folder.Push(a); n != 0 {
+		t.Errorf("expected second push to write nothing, wrote %d", n)
+	}
+
+	desktop.Exec("UPDATE clients SET email = 'x@acme.test' WHERE id = 1")
+	b.Capture()
+	reopened, err := OpenFolder(dir, password)
+	if err != nil {
+		t.Fatalf("OpenFolder() error: %v", err)
+	}
+	report, err := reopened.Pull(b)
+	if err != nil {
+		t.Fatalf("Pull() error: %v", err)
+	}
+	if report.Applied == 0 || len(report.Conflicts) != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if n := queryString(t, desktop, "SELECT count(*) FROM clients WHERE name = 'From Laptop'"); n != "1" {
+		t.Errorf("expected laptop's client on desktop, got %s", n)
+	}
+	reopened.Push(b)
+	folder.Pull(a)
+	if got := queryString(t, laptop, "SELECT email FROM clients WHERE id = 1"); got != "x@acme.test" {
+		t.Errorf("expected desktop's edit on laptop, got %q", got)
+	}
+
+	if _, err := OpenFolder(dir, func() (string, error) { return "wrong", nil }); err == nil {
+		t.Error("expected wrong password to fail")
+	}
+}
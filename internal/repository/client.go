@@ -2,7 +2,7 @@ package repository
 
 import (
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"gorm.io/gorm"
 )
 
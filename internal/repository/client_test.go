@@ -3,7 +3,7 @@ package repository
 import (
 	"testing"
 
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 func TestClientRepository_Create(t *testing.T) {
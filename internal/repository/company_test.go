@@ -4,7 +4,7 @@ import (
 	"testing"
 
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -23,6 +23,8 @@ func setupTestDB(t *testing.T) {
 	err = db.GormDB.AutoMigrate(
 		&models.Company{},
 		&models.Client{},
+		&models.ClientContact{},
+		&models.ClientAddress{},
 		&models.Contract{},
 		&models.Invoice{},
 		&models.InvoiceLineItem{},
@@ -2,7 +2,7 @@ package repository
 
 import (
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 	"gorm.io/gorm"
 )
 
@@ -20,7 +20,7 @@ func (r *ContractRepository) Create(contract *models.Contract) error {
 
 func (r *ContractRepository) GetByID(id uint) (*models.Contract, error) {
 	var contract models.Contract
-	err := r.db.Preload("Client").First(&contract, id).Error
+	err := r.db.Preload("Client").Preload("Client.Contacts").Preload("Client.Addresses").First(&contract, id).Error
 	if err != nil {
 		return nil, err
 	}
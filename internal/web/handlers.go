@@ -0,0 +1,129 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/dashboard"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Summary is the dashboard's headline numbers and whether the page may
+// offer changes
+type Summary struct {
+	dashboard.Summary
+	ReadOnly bool `json:"read_only"`
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	sum, err := dashboard.Summarize(s.db, time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, Summary{Summary: *sum, ReadOnly: s.opts.ReadOnly})
+}
+
+func (s *Server) handleRevenue(w http.ResponseWriter, r *http.Request) {
+	months := 12
+	if v := r.URL.Query().Get("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 120 {
+			writeError(w, http.StatusBadRequest, "months must be between 1 and 120")
+			return
+		}
+		months = n
+	}
+
+	result, err := dashboard.Revenue(s.db, time.Now(), months)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleUnbilled(w http.ResponseWriter, r *http.Request) {
+	unbilled, err := dashboard.UnbilledTime(s.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, unbilled)
+}
+
+func (s *Server) handleInvoices(w http.ResponseWriter, r *http.Request) {
+	invoices, err := dashboard.Invoices(s.db, r.URL.Query().Get("status"), time.Now())
+	if errors.Is(err, dashboard.ErrUnknownFilter) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, invoices)
+}
+
+// invoice loads the invoice named by the {id} path segment, writing the
+// error response when there's none
+func (s *Server) invoice(w http.ResponseWriter, r *http.Request) (*models.Invoice, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid invoice ID")
+		return nil, false
+	}
+	var inv models.Invoice
+	if err := s.db.First(&inv, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "invoice not found")
+		} else {
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return nil, false
+	}
+	return &inv, true
+}
+
+func (s *Server) handleInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	inv, ok := s.invoice(w, r)
+	if !ok {
+		return
+	}
+	// Only the file recorded for this invoice is served, never a path from the request
+	if !dashboard.FileExists(inv.PDFPath) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("invoice %s has no PDF yet, create it with 'ung invoice --id %d --pdf'", inv.InvoiceNum, inv.ID))
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", inv.InvoiceNum+".pdf"))
+	http.ServeFile(w, r, inv.PDFPath)
+}
+
+func (s *Server) handleInvoicePaid(w http.ResponseWriter, r *http.Request) {
+	inv, ok := s.invoice(w, r)
+	if !ok {
+		return
+	}
+	if inv.Status != models.StatusPaid {
+		err := s.db.Model(inv).Updates(map[string]any{
+			"status":    models.StatusPaid,
+			"paid_date": time.Now(),
+		}).Error
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if s.opts.AfterWrite != nil {
+			if err := s.opts.AfterWrite(); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"id": inv.ID, "status": models.StatusPaid})
+}
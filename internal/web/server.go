@@ -0,0 +1,182 @@
+// Package web serves a small dashboard over the local ung database: revenue
+// by month, unbilled time, open invoices and their PDFs. It is meant for
+// localhost, with an access token, and needs none of the API server's
+// accounts.
+//
+// The page and its scripts are embedded in the binary and read everything
+// through a JSON API under /api. Marking an invoice paid is the only write,
+// and read-only mode turns it off. Writes must be JSON requests from the
+// dashboard's own origin, and requests for host names other than localhost
+// are refused, so other web pages can't use the API through the browser or
+// DNS rebinding.
+package web
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+//go:embed static
+var static embed.FS
+
+// tokenCookie remembers the token after it was given once in the URL
+const tokenCookie = "ung_token"
+
+// Options configures a Server
+type Options struct {
+	// Token, when set, is required in an "Authorization: Bearer" header, a
+	// ?token= query parameter or the cookie the query parameter sets
+	Token string
+	// ReadOnly rejects every request that would change the database
+	ReadOnly bool
+	// AnyHost serves requests for any host name, for servers listening on
+	// the network. By default only localhost and loopback addresses are.
+	AnyHost bool
+	// AfterWrite runs after a change was made, e.g. to flush an encrypted
+	// database to disk
+	AfterWrite func() error
+	// Acquire and Release, when set, bracket every API request, e.g. to hold
+	// the lock of an encrypted database only while a request is answered so
+	// other ung commands can use it in between
+	Acquire func() error
+	Release func() error
+}
+
+// Server is the dashboard's HTTP handler
+type Server struct {
+	db   *gorm.DB
+	opts Options
+	mux  *http.ServeMux
+}
+
+// New returns a server reading from db
+func New(db *gorm.DB, opts Options) *Server {
+	s := &Server{db: db, opts: opts, mux: http.NewServeMux()}
+
+	assets, _ := fs.Sub(static, "static")
+	s.mux.Handle("GET /", http.FileServerFS(assets))
+	s.mux.HandleFunc("GET /api/summary", s.handleSummary)
+	s.mux.HandleFunc("GET /api/revenue", s.handleRevenue)
+	s.mux.HandleFunc("GET /api/unbilled", s.handleUnbilled)
+	s.mux.HandleFunc("GET /api/invoices", s.handleInvoices)
+	s.mux.HandleFunc("GET /api/invoices/{id}/pdf", s.handleInvoicePDF)
+	s.mux.HandleFunc("POST /api/invoices/{id}/paid", s.handleInvoicePaid)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	if !s.opts.AnyHost && !IsLoopbackHost(r.Host) {
+		writeError(w, http.StatusMisdirectedRequest, "the dashboard is only served on localhost")
+		return
+	}
+
+	if s.opts.Token != "" {
+		if q := r.URL.Query().Get("token"); q != "" && s.validToken(q) {
+			// Swap the token in the URL for a cookie so it doesn't stay in the address bar
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    q,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") {
+				u := *r.URL
+				query := u.Query()
+				query.Del("token")
+				u.RawQuery = query.Encode()
+				http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+				return
+			}
+		} else if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "a valid token is required, open the URL printed by 'ung serve'")
+			return
+		}
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if s.opts.ReadOnly {
+			writeError(w, http.StatusForbidden, "the dashboard is read-only")
+			return
+		}
+		// Browsers can't send JSON cross-origin without a preflight, which is
+		// never answered, so other web pages can't make changes
+		if mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+			writeError(w, http.StatusForbidden, "cross-origin requests are not allowed")
+			return
+		}
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		if s.opts.Acquire != nil {
+			if err := s.opts.Acquire(); err != nil {
+				writeError(w, http.StatusServiceUnavailable, err.Error())
+				return
+			}
+		}
+		if s.opts.Release != nil {
+			// A failed write-back keeps the lock and is retried by the next request
+			defer s.opts.Release()
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// IsLoopbackHost reports whether a Host header names this machine
+func IsLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// sameOrigin reports whether an Origin header is the dashboard's own
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && strings.EqualFold(u.Host, host)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return s.validToken(token)
+	}
+	if c, err := r.Cookie(tokenCookie); err == nil {
+		return s.validToken(c.Value)
+	}
+	return false
+}
+
+func (s *Server) validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
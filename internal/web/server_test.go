@@ -0,0 +1,349 @@
+package web
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/dashboard"
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) (*sql.DB, *gorm.DB) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ung.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := schema.Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	gdb, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, gdb
+}
+
+// seed creates a client with an hourly contract, three invoices (paid this
+// month, overdue, pending) and unbilled and already invoiced time
+func seed(t *testing.T, conn *sql.DB, pdfPath string) {
+	t.Helper()
+	now := time.Now()
+	for _, stmt := range []struct {
+		query string
+		args  []any
+	}{
+		{"INSERT INTO companies (id, name, email) VALUES (1, 'Me LLC', 'me@example.com')", nil},
+		{"INSERT INTO clients (id, name, email) VALUES (1, 'Acme', 'ap@acme.test')", nil},
+		{"INSERT INTO contracts (id, contract_num, client_id, name, contract_type, hourly_rate, currency, active, start_date) VALUES (1, 'C-1', 1, 'Website', 'hourly', 100, 'EUR', 1, ?)", []any{now}},
+		{"INSERT INTO invoices (id, invoice_num, company_id, amount, currency, status, issued_date, due_date, paid_date, pdf_path) VALUES (1, 'INV-1', 1, 1000, 'EUR', 'paid', ?, ?, ?, ?)",
+			[]any{now, now.AddDate(0, 0, 30), now, pdfPath}},
+		{"INSERT INTO invoices (id, invoice_num, company_id, amount, currency, status, issued_date, due_date) VALUES (2, 'INV-2', 1, 400, 'EUR', 'sent', ?, ?)",
+			[]any{now.AddDate(0, -2, 0), now.AddDate(0, -1, 0)}},
+		{"INSERT INTO invoices (id, invoice_num, company_id, amount, currency, status, issued_date, due_date) VALUES (3, 'INV-3', 1, 250, 'EUR', 'pending', ?, ?)",
+			[]any{now, now.AddDate(0, 0, 30)}},
+		{"INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (1, 1), (2, 1), (3, 1)", nil},
+		{"INSERT INTO tracking_sessions (client_id, contract_id, start_time, end_time, duration, hours, billable) VALUES (1, 1, ?, ?, 9000, 2.5, 1)",
+			[]any{now, now.Add(150 * time.Minute)}},
+		{"INSERT INTO tracking_sessions (client_id, contract_id, start_time, end_time, duration, billable) VALUES (1, 1, ?, ?, 1800, 1)",
+			[]any{now, now.Add(30 * time.Minute)}},
+		{"INSERT INTO tracking_sessions (client_id, contract_id, start_time, end_time, duration, hours, billable, notes) VALUES (1, 1, ?, ?, 3600, 1, 1, '[Invoiced: INV-1]')",
+			[]any{now, now.Add(time.Hour)}},
+		{"INSERT INTO tracking_sessions (client_id, project_name, start_time) VALUES (1, 'Redesign', ?)", []any{now}},
+	} {
+		if _, err := conn.Exec(stmt.query, stmt.args...); err != nil {
+			t.Fatalf("%s: %v", stmt.query, err)
+		}
+	}
+}
+
+// newRequest returns a request to the dashboard on localhost
+func newRequest(method, path string) *http.Request {
+	return httptest.NewRequest(method, "http://localhost:8080"+path, nil)
+}
+
+// postJSON returns a write request as the dashboard page makes it
+func postJSON(path string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8080"+path, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://localhost:8080")
+	return req
+}
+
+func get(t *testing.T, h http.Handler, path string, v any) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(http.MethodGet, path))
+	if v != nil {
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, rec.Code, rec.Body)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+	return rec
+}
+
+func TestSummary(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	seed(t, conn, "")
+	s := New(gdb, Options{ReadOnly: true})
+
+	var sum Summary
+	get(t, s, "/api/summary", &sum)
+	if sum.Paid != 1000 || sum.RevenueThisMonth != 1000 {
+		t.Errorf("expected 1000 paid this month, got %+v", sum)
+	}
+	if sum.Overdue != 400 || sum.OverdueInvoices != 1 || sum.Pending != 250 || sum.OpenInvoices != 2 {
+		t.Errorf("expected 400 overdue and 250 pending in 2 open invoices, got %+v", sum)
+	}
+	// 2.5h plus 30 minutes without hours; the invoiced hour doesn't count
+	if sum.UnbilledHours != 3 || sum.UnbilledAmount != 300 {
+		t.Errorf("expected 3 unbilled hours worth 300, got %.2fh worth %.2f", sum.UnbilledHours, sum.UnbilledAmount)
+	}
+	if sum.ActiveTimer == nil || sum.ActiveTimer.Client != "Acme" || sum.ActiveTimer.Project != "Redesign" {
+		t.Errorf("expected the running Redesign timer, got %+v", sum.ActiveTimer)
+	}
+	if !sum.ReadOnly {
+		t.Error("expected read_only to be reported")
+	}
+}
+
+func TestRevenue(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	seed(t, conn, "")
+	conn.Exec("INSERT INTO expenses (description, amount, category, date) VALUES ('Laptop', 150, 'equipment', ?)", time.Now())
+	s := New(gdb, Options{})
+
+	var months []dashboard.Month
+	get(t, s, "/api/revenue?months=6", &months)
+	if len(months) != 6 {
+		t.Fatalf("expected 6 months, got %d", len(months))
+	}
+	current := months[5]
+	if current.Month != time.Now().Format("2006-01") || current.Revenue != 1000 || current.Expenses != 150 {
+		t.Errorf("expected 1000 revenue and 150 expenses this month, got %+v", current)
+	}
+	for _, m := range months[:5] {
+		if m.Revenue != 0 {
+			t.Errorf("expected no revenue in %s, got %.2f", m.Month, m.Revenue)
+		}
+	}
+
+	if rec := get(t, s, "/api/revenue?months=0", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for months=0, got %d", rec.Code)
+	}
+}
+
+func TestInvoices(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	seed(t, conn, "")
+	s := New(gdb, Options{})
+
+	for status, want := range map[string][]string{
+		"open":    {"INV-3", "INV-2"},
+		"overdue": {"INV-2"},
+		"paid":    {"INV-1"},
+		"all":     {"INV-3", "INV-1", "INV-2"},
+	} {
+		var invoices []dashboard.Invoice
+		get(t, s, "/api/invoices?status="+status, &invoices)
+		var got []string
+		for _, inv := range invoices {
+			got = append(got, inv.InvoiceNum)
+			if inv.Client != "Acme" {
+				t.Errorf("%s: expected client Acme, got %q", inv.InvoiceNum, inv.Client)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("status=%s: expected %v, got %v", status, want, got)
+		}
+	}
+
+	if rec := get(t, s, "/api/invoices?status=bogus", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown status, got %d", rec.Code)
+	}
+}
+
+func TestInvoicePDF(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	pdf := filepath.Join(t.TempDir(), "INV-1.pdf")
+	os.WriteFile(pdf, []byte("%PDF-1.4 test"), 0600)
+	seed(t, conn, pdf)
+	s := New(gdb, Options{})
+
+	rec := get(t, s, "/api/invoices/1/pdf", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(rec.Body.String(), "%PDF") {
+		t.Errorf("expected the PDF, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec := get(t, s, "/api/invoices/2/pdf", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an invoice without a PDF, got %d", rec.Code)
+	}
+	if rec := get(t, s, "/api/invoices/99/pdf", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing invoice, got %d", rec.Code)
+	}
+}
+
+func TestMarkPaid(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	seed(t, conn, "")
+
+	writes := 0
+	s := New(gdb, Options{AfterWrite: func() error { writes++; return nil }})
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, postJSON("/api/invoices/2/paid"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body)
+	}
+	var status string
+	var paid sql.NullTime
+	conn.QueryRow("SELECT status, paid_date FROM invoices WHERE id = 2").Scan(&status, &paid)
+	if status != "paid" || !paid.Valid {
+		t.Errorf("expected the invoice to be paid with a date, got %s %v", status, paid)
+	}
+	if writes != 1 {
+		t.Errorf("expected AfterWrite to run once, ran %d times", writes)
+	}
+
+	readOnly := New(gdb, Options{ReadOnly: true})
+	rec = httptest.NewRecorder()
+	readOnly.ServeHTTP(rec, postJSON("/api/invoices/3/paid"))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 in read-only mode, got %d", rec.Code)
+	}
+	conn.QueryRow("SELECT status FROM invoices WHERE id = 3").Scan(&status)
+	if status != "pending" {
+		t.Errorf("read-only mode changed the invoice to %s", status)
+	}
+}
+
+func TestToken(t *testing.T) {
+	_, gdb := openTestDB(t)
+	s := New(gdb, Options{Token: "s3cret"})
+
+	if rec := get(t, s, "/api/summary", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := get(t, s, "/", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for the page without a token, got %d", rec.Code)
+	}
+	if rec := get(t, s, "/api/summary?token=wrong", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a wrong token, got %d", rec.Code)
+	}
+
+	req := newRequest(http.MethodGet, "/api/summary")
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with a bearer token, got %d", rec.Code)
+	}
+
+	// The token in the URL becomes a cookie and is dropped from the address
+	rec = get(t, s, "/?token=s3cret", nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Fatalf("expected a redirect to /, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie || !cookies[0].HttpOnly {
+		t.Fatalf("expected an HttpOnly token cookie, got %v", cookies)
+	}
+	req = newRequest(http.MethodGet, "/")
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "UNG Dashboard") {
+		t.Errorf("expected the page with the cookie, got %d", rec.Code)
+	}
+}
+
+func TestWritesRequireJSONFromTheDashboard(t *testing.T) {
+	conn, gdb := openTestDB(t)
+	seed(t, conn, "")
+	s := New(gdb, Options{})
+
+	// A form posted by another site
+	form := newRequest(http.MethodPost, "/api/invoices/2/paid")
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, form)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for a form post, got %d", rec.Code)
+	}
+
+	crossOrigin := postJSON("/api/invoices/2/paid")
+	crossOrigin.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, crossOrigin)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another origin, got %d", rec.Code)
+	}
+
+	var status string
+	conn.QueryRow("SELECT status FROM invoices WHERE id = 2").Scan(&status)
+	if status == "paid" {
+		t.Error("a rejected request marked the invoice paid")
+	}
+}
+
+func TestOnlyLocalhostIsServed(t *testing.T) {
+	_, gdb := openTestDB(t)
+
+	// A page on a rebound DNS name reaches the server with its own Host
+	req := httptest.NewRequest(http.MethodGet, "http://rebind.example:8080/api/summary", nil)
+	rec := httptest.NewRecorder()
+	New(gdb, Options{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusMisdirectedRequest {
+		t.Errorf("expected 421 for another host name, got %d", rec.Code)
+	}
+
+	for _, host := range []string{"localhost:8080", "127.0.0.1:8080", "[::1]:8080"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/summary", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		New(gdb, Options{}).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected 200 for %s, got %d", host, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	New(gdb, Options{AnyHost: true}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://192.168.1.5:8080/api/summary", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a network address with AnyHost, got %d", rec.Code)
+	}
+}
+
+func TestAPIRequestsAcquireTheDatabase(t *testing.T) {
+	_, gdb := openTestDB(t)
+	held, acquired := 0, 0
+	s := New(gdb, Options{
+		Acquire: func() error { held++; acquired++; return nil },
+		Release: func() error { held--; return nil },
+	})
+
+	get(t, s, "/api/summary", nil)
+	get(t, s, "/", nil)
+	if acquired != 1 || held != 0 {
+		t.Errorf("expected one API request to acquire and release the database, acquired %d, still held %d", acquired, held)
+	}
+
+	locked := New(gdb, Options{Acquire: func() error { return errors.New("database is in use by another ung process") }})
+	if rec := get(t, locked, "/api/summary", nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while the database is locked, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,183 @@
+"use strict";
+
+const $ = (id) => document.getElementById(id);
+
+let readOnly = true;
+
+function money(amount, currency) {
+  try {
+    return new Intl.NumberFormat(undefined, { style: "currency", currency: currency || "USD" }).format(amount);
+  } catch {
+    return (currency || "") + " " + amount.toFixed(2);
+  }
+}
+
+function day(value) {
+  if (!value || value.startsWith("0001-")) return "–";
+  return new Date(value).toLocaleDateString();
+}
+
+async function api(path, options) {
+  const res = await fetch("api/" + path, { credentials: "same-origin", ...options });
+  const body = await res.json().catch(() => ({}));
+  if (!res.ok) throw new Error(body.error || res.statusText);
+  return body;
+}
+
+function cell(text, className) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  if (className) td.className = className;
+  return td;
+}
+
+function emptyRow(tbody, columns, text) {
+  const tr = document.createElement("tr");
+  const td = cell(text, "empty");
+  td.colSpan = columns;
+  tr.append(td);
+  tbody.append(tr);
+}
+
+function renderSummary(s) {
+  readOnly = s.read_only;
+  $("mode").textContent = s.read_only ? "read-only" : "read-write";
+  $("paid").textContent = money(s.paid);
+  $("pending").textContent = money(s.pending);
+  $("overdue").textContent = money(s.overdue);
+  $("overdue-count").textContent = `${s.overdue_invoices} of ${s.open_invoices} open invoices`;
+  $("unbilled").textContent = money(s.unbilled_amount);
+  $("unbilled-hours").textContent = `${s.unbilled_hours.toFixed(1)} hours`;
+  $("month-revenue").textContent = money(s.revenue_this_month);
+  $("month-details").textContent =
+    `${money(s.expenses_this_month)} expenses · ${s.hours_this_month.toFixed(1)} hours`;
+
+  const timer = $("timer");
+  if (s.active_timer) {
+    const t = s.active_timer;
+    const what = [t.client, t.project].filter(Boolean).join(" · ") || "untitled";
+    timer.textContent = `⏱️ Tracking ${what} since ${new Date(t.started).toLocaleTimeString()}`;
+    timer.hidden = false;
+  } else {
+    timer.hidden = true;
+  }
+}
+
+function renderChart(months) {
+  const width = 800, height = 220, top = 10, bottom = 24;
+  const max = Math.max(1, ...months.flatMap((m) => [m.revenue, m.expenses]));
+  const slot = width / months.length;
+  const bar = Math.max(2, slot / 3);
+  const scale = (v) => (v / max) * (height - top - bottom);
+
+  const svg = [`<svg viewBox="0 0 ${width} ${height}" preserveAspectRatio="none" role="img" aria-label="Revenue by month">`];
+  months.forEach((m, i) => {
+    const x = i * slot + slot / 2 - bar;
+    const label = new Date(m.month + "-01T00:00:00").toLocaleDateString(undefined, { month: "short" });
+    svg.push(`<rect class="revenue" x="${x}" y="${height - bottom - scale(m.revenue)}" width="${bar}" height="${scale(m.revenue)}"><title>${m.month}: ${money(m.revenue)} revenue</title></rect>`);
+    svg.push(`<rect class="expenses" x="${x + bar}" y="${height - bottom - scale(m.expenses)}" width="${bar}" height="${scale(m.expenses)}"><title>${m.month}: ${money(m.expenses)} expenses</title></rect>`);
+    svg.push(`<text x="${i * slot + slot / 2}" y="${height - 6}" text-anchor="middle">${label}</text>`);
+  });
+  svg.push("</svg>");
+  $("chart").innerHTML = svg.join("");
+}
+
+function renderUnbilled(rows) {
+  const tbody = $("unbilled-rows");
+  tbody.replaceChildren();
+  if (rows.length === 0) {
+    emptyRow(tbody, 6, "No unbilled time");
+    return;
+  }
+  for (const u of rows) {
+    const tr = document.createElement("tr");
+    tr.append(
+      cell(u.client),
+      cell(u.contract || "–"),
+      cell(u.sessions, "num"),
+      cell(u.hours.toFixed(2), "num"),
+      cell(u.rate == null ? "–" : money(u.rate, u.currency) + "/h", "num"),
+      cell(u.rate == null ? "–" : money(u.amount, u.currency), "num"),
+    );
+    tbody.append(tr);
+  }
+}
+
+function renderInvoices(rows) {
+  const tbody = $("invoice-rows");
+  tbody.replaceChildren();
+  if (rows.length === 0) {
+    emptyRow(tbody, 7, "No invoices");
+    return;
+  }
+  for (const inv of rows) {
+    const tr = document.createElement("tr");
+    if (inv.overdue) tr.className = "overdue";
+    const actions = document.createElement("td");
+    if (inv.has_pdf) {
+      const a = document.createElement("a");
+      a.href = `api/invoices/${inv.id}/pdf`;
+      a.target = "_blank";
+      a.textContent = "PDF";
+      actions.append(a);
+    }
+    if (!readOnly && inv.status !== "paid") {
+      const button = document.createElement("button");
+      button.type = "button";
+      button.textContent = "Mark paid";
+      button.onclick = () => markPaid(inv);
+      actions.append(" ", button);
+    }
+    tr.append(
+      cell(inv.invoice_num),
+      cell(inv.client || "–"),
+      cell(day(inv.issued_date)),
+      cell(day(inv.due_date)),
+      cell(inv.overdue && inv.status !== "overdue" ? `${inv.status} (overdue)` : inv.status),
+      cell(money(inv.amount, inv.currency), "num"),
+      actions,
+    );
+    tbody.append(tr);
+  }
+}
+
+async function markPaid(inv) {
+  if (!confirm(`Mark ${inv.invoice_num} as paid?`)) return;
+  try {
+    await api(`invoices/${inv.id}/paid`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: "{}",
+    });
+    await load();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+function showError(err) {
+  $("error").textContent = "❌ " + err.message;
+  $("error").hidden = false;
+}
+
+async function load() {
+  $("error").hidden = true;
+  try {
+    const [summary, revenue, unbilled, invoices] = await Promise.all([
+      api("summary"),
+      api("revenue"),
+      api("unbilled"),
+      api("invoices?status=" + encodeURIComponent($("status").value)),
+    ]);
+    renderSummary(summary);
+    renderChart(revenue);
+    renderUnbilled(unbilled);
+    renderInvoices(invoices);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+$("refresh").onclick = load;
+$("status").onchange = load;
+load();
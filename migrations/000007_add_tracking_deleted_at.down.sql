@@ -1,3 +1,2 @@
--- SQLite doesn't support DROP COLUMN directly
--- To rollback, you'd need to recreate the table without the column
--- This is a no-op for safety
+-- Remove soft delete support from tracking_sessions
+ALTER TABLE tracking_sessions DROP COLUMN deleted_at;
@@ -9,5 +9,4 @@ UPDATE gigs SET status = 'complete' WHERE status = 'done';
 -- Drop project index
 DROP INDEX IF EXISTS idx_gigs_project;
 
--- Note: SQLite doesn't support DROP COLUMN directly
--- The project column will remain but be unused after downgrade
+ALTER TABLE gigs DROP COLUMN project;
@@ -0,0 +1,3 @@
+-- Remove external_id from tracking_sessions
+DROP INDEX IF EXISTS idx_tracking_sessions_external_id;
+ALTER TABLE tracking_sessions DROP COLUMN external_id;
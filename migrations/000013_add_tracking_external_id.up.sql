@@ -0,0 +1,4 @@
+-- Add external_id to tracking_sessions so imported entries (calendar events,
+-- time tracker exports) can be deduplicated on re-import
+ALTER TABLE tracking_sessions ADD COLUMN external_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_tracking_sessions_external_id ON tracking_sessions(external_id);
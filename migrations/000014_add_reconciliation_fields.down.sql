@@ -0,0 +1,4 @@
+-- Remove paid_date from invoices and external_id from expenses
+DROP INDEX IF EXISTS idx_expenses_external_id;
+ALTER TABLE expenses DROP COLUMN external_id;
+ALTER TABLE invoices DROP COLUMN paid_date;
@@ -0,0 +1,5 @@
+-- Add paid_date to invoices so reconciled payments keep the actual payment date,
+-- and external_id to expenses so bank debits aren't imported twice
+ALTER TABLE invoices ADD COLUMN paid_date TIMESTAMP;
+ALTER TABLE expenses ADD COLUMN external_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_expenses_external_id ON expenses(external_id);
@@ -0,0 +1,3 @@
+-- Remove receipt_hash from expenses
+DROP INDEX IF EXISTS idx_expenses_receipt_hash;
+ALTER TABLE expenses DROP COLUMN receipt_hash;
@@ -0,0 +1,3 @@
+-- Add receipt_hash to expenses so duplicate receipts can be detected
+ALTER TABLE expenses ADD COLUMN receipt_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_expenses_receipt_hash ON expenses(receipt_hash);
@@ -0,0 +1,21 @@
+-- Generated triggers on the audited tables
+DROP TRIGGER IF EXISTS audit_invoices_insert;
+DROP TRIGGER IF EXISTS audit_invoices_update;
+DROP TRIGGER IF EXISTS audit_invoices_delete;
+DROP TRIGGER IF EXISTS audit_invoice_line_items_insert;
+DROP TRIGGER IF EXISTS audit_invoice_line_items_update;
+DROP TRIGGER IF EXISTS audit_invoice_line_items_delete;
+DROP TRIGGER IF EXISTS audit_tracking_sessions_insert;
+DROP TRIGGER IF EXISTS audit_tracking_sessions_update;
+DROP TRIGGER IF EXISTS audit_tracking_sessions_delete;
+DROP TRIGGER IF EXISTS audit_contracts_insert;
+DROP TRIGGER IF EXISTS audit_contracts_update;
+DROP TRIGGER IF EXISTS audit_contracts_delete;
+DROP TRIGGER IF EXISTS audit_expenses_insert;
+DROP TRIGGER IF EXISTS audit_expenses_update;
+DROP TRIGGER IF EXISTS audit_expenses_delete;
+DROP TRIGGER IF EXISTS audit_log_no_update;
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+DROP INDEX IF EXISTS idx_audit_log_entity;
+DROP TABLE IF EXISTS audit_context;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,22 @@
+-- Append-only audit log of changes to invoices, line items, sessions, contracts and expenses.
+-- The per-table triggers that fill it are generated from the current columns when ung opens the database.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurred_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    entity TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    old_values TEXT,
+    new_values TEXT,
+    source TEXT NOT NULL DEFAULT 'cli',
+    prev_hash TEXT,
+    hash TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+
+-- Which program (cli, api, telegram, sync, restore) is making changes
+CREATE TABLE IF NOT EXISTS audit_context (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    source TEXT NOT NULL
+);
+INSERT OR IGNORE INTO audit_context (id, source) VALUES (1, 'cli');
@@ -0,0 +1,26 @@
+-- The audit triggers list every column, they are recreated when ung opens the database
+DROP TRIGGER IF EXISTS audit_invoice_line_items_insert;
+DROP TRIGGER IF EXISTS audit_invoice_line_items_update;
+DROP TRIGGER IF EXISTS audit_invoice_line_items_delete;
+
+ALTER TABLE invoice_line_items DROP COLUMN tax_amount;
+ALTER TABLE invoice_line_items DROP COLUMN tax_rate;
+ALTER TABLE invoice_line_items DROP COLUMN discount_pct;
+ALTER TABLE invoice_line_items DROP COLUMN discount;
+
+DROP TABLE IF EXISTS invoice_templates;
+
+DROP INDEX IF EXISTS idx_pomodoro_sessions_deleted_at;
+DROP INDEX IF EXISTS idx_pomodoro_sessions_client;
+DROP INDEX IF EXISTS idx_pomodoro_sessions_contract;
+DROP TABLE IF EXISTS pomodoro_sessions;
+
+DROP TABLE IF EXISTS rate_settings;
+
+DROP INDEX IF EXISTS idx_user_settings_key;
+DROP TABLE IF EXISTS user_settings;
+
+DROP INDEX IF EXISTS idx_recurring_invoices_next_date;
+DROP INDEX IF EXISTS idx_recurring_invoices_active;
+DROP INDEX IF EXISTS idx_recurring_invoices_client;
+DROP TABLE IF EXISTS recurring_invoices;
@@ -0,0 +1,103 @@
+-- Tables that the CLI used to create outside migrations (inline schema, GORM
+-- auto-migration) or that only the API knew about, so every program that opens
+-- a user database agrees on its shape.
+
+-- Recurring invoice templates, generated by `ung recurring` and the API
+CREATE TABLE IF NOT EXISTS recurring_invoices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id INTEGER NOT NULL,
+    contract_id INTEGER,
+    company_id INTEGER,                   -- issuing company, defaults to the first one
+    amount REAL NOT NULL,
+    currency TEXT DEFAULT 'USD',
+    description TEXT,
+    frequency TEXT NOT NULL,              -- weekly, biweekly, monthly, quarterly, yearly
+    day_of_month INTEGER DEFAULT 1,
+    day_of_week INTEGER DEFAULT 1,
+    next_generation_date TIMESTAMP NOT NULL,
+    last_generated_date TIMESTAMP,
+    last_invoice_id INTEGER,
+    active BOOLEAN DEFAULT 1,
+    auto_send BOOLEAN DEFAULT 0,
+    auto_pdf BOOLEAN DEFAULT 1,
+    email_app TEXT,
+    invoice_prefix TEXT DEFAULT 'REC',    -- used by the API when numbering invoices
+    generated_count INTEGER DEFAULT 0,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (client_id) REFERENCES clients(id),
+    FOREIGN KEY (contract_id) REFERENCES contracts(id),
+    FOREIGN KEY (company_id) REFERENCES companies(id),
+    FOREIGN KEY (last_invoice_id) REFERENCES invoices(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_invoices_client ON recurring_invoices(client_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_invoices_active ON recurring_invoices(active);
+CREATE INDEX IF NOT EXISTS idx_recurring_invoices_next_date ON recurring_invoices(next_generation_date);
+
+-- Key/value preferences (`ung settings`)
+CREATE TABLE IF NOT EXISTS user_settings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_settings_key ON user_settings(key);
+
+-- Inputs of the hourly rate calculator
+CREATE TABLE IF NOT EXISTS rate_settings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    hours_per_week REAL DEFAULT 40,       -- billable hours per week
+    weeks_per_year INTEGER DEFAULT 48,
+    default_tax_percent REAL DEFAULT 25,
+    default_margin REAL DEFAULT 20,
+    annual_expenses REAL DEFAULT 0,
+    default_currency TEXT DEFAULT 'USD',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Pomodoro timer sessions
+CREATE TABLE IF NOT EXISTS pomodoro_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    contract_id INTEGER,
+    client_id INTEGER,
+    project_name TEXT,
+    duration INTEGER DEFAULT 25,          -- minutes
+    break_time INTEGER DEFAULT 5,         -- minutes
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP,
+    completed BOOLEAN DEFAULT 0,
+    notes TEXT,
+    session_type TEXT DEFAULT 'work',     -- work, short_break, long_break
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (contract_id) REFERENCES contracts(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pomodoro_sessions_contract ON pomodoro_sessions(contract_id);
+CREATE INDEX IF NOT EXISTS idx_pomodoro_sessions_client ON pomodoro_sessions(client_id);
+CREATE INDEX IF NOT EXISTS idx_pomodoro_sessions_deleted_at ON pomodoro_sessions(deleted_at);
+
+-- Reusable invoice templates
+CREATE TABLE IF NOT EXISTS invoice_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    description TEXT,
+    content TEXT,                         -- HTML/Markdown template content
+    is_default BOOLEAN DEFAULT 0,
+    variables TEXT,                       -- JSON list of template variables
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-line discounts and taxes
+ALTER TABLE invoice_line_items ADD COLUMN discount REAL DEFAULT 0;
+ALTER TABLE invoice_line_items ADD COLUMN discount_pct REAL DEFAULT 0;
+ALTER TABLE invoice_line_items ADD COLUMN tax_rate REAL DEFAULT 0;
+ALTER TABLE invoice_line_items ADD COLUMN tax_amount REAL DEFAULT 0;
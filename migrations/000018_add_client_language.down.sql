@@ -0,0 +1,2 @@
+-- Remove language from clients
+ALTER TABLE clients DROP COLUMN language;
@@ -0,0 +1,3 @@
+-- Add language to clients so documents and emails for a client can use its
+-- language instead of the configured one (empty means config.Language)
+ALTER TABLE clients ADD COLUMN language TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+-- The audit triggers list every column, they are recreated when ung opens the database
+DROP TRIGGER IF EXISTS audit_invoices_insert;
+DROP TRIGGER IF EXISTS audit_invoices_update;
+DROP TRIGGER IF EXISTS audit_invoices_delete;
+DROP TRIGGER IF EXISTS audit_contracts_insert;
+DROP TRIGGER IF EXISTS audit_contracts_update;
+DROP TRIGGER IF EXISTS audit_contracts_delete;
+
+ALTER TABLE invoices DROP COLUMN tax_note;
+ALTER TABLE invoices DROP COLUMN tax_type;
+ALTER TABLE invoices DROP COLUMN tax_profile;
+ALTER TABLE invoices DROP COLUMN tax_amount;
+ALTER TABLE invoices DROP COLUMN net_amount;
+ALTER TABLE contracts DROP COLUMN tax_profile;
+ALTER TABLE clients DROP COLUMN tax_profile;
@@ -0,0 +1,11 @@
+-- Tax profiles (see the tax section of the config) assigned to clients and
+-- contracts, and the tax totals of invoices. amount stays the gross total;
+-- existing invoices were untaxed, so their net is their amount.
+ALTER TABLE clients ADD COLUMN tax_profile TEXT NOT NULL DEFAULT '';
+ALTER TABLE contracts ADD COLUMN tax_profile TEXT NOT NULL DEFAULT '';
+ALTER TABLE invoices ADD COLUMN net_amount REAL NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN tax_amount REAL NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN tax_profile TEXT NOT NULL DEFAULT '';
+ALTER TABLE invoices ADD COLUMN tax_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE invoices ADD COLUMN tax_note TEXT NOT NULL DEFAULT '';
+UPDATE invoices SET net_amount = amount;
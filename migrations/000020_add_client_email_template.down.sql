@@ -0,0 +1,2 @@
+-- Remove the email template set from clients
+ALTER TABLE clients DROP COLUMN email_template;
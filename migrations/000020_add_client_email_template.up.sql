@@ -0,0 +1,3 @@
+-- Add the email template set of clients: a directory next to the email
+-- templates whose templates replace them for the client (empty for none)
+ALTER TABLE clients ADD COLUMN email_template TEXT NOT NULL DEFAULT '';
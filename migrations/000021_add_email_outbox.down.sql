@@ -0,0 +1,11 @@
+-- The audit triggers list every column, they are recreated when ung opens the database
+DROP TRIGGER IF EXISTS audit_invoices_insert;
+DROP TRIGGER IF EXISTS audit_invoices_update;
+DROP TRIGGER IF EXISTS audit_invoices_delete;
+
+ALTER TABLE clients DROP COLUMN email_bcc;
+ALTER TABLE clients DROP COLUMN email_cc;
+ALTER TABLE invoices DROP COLUMN sent_at;
+DROP INDEX IF EXISTS idx_email_outbox_invoice;
+DROP INDEX IF EXISTS idx_email_outbox_status;
+DROP TABLE IF EXISTS email_outbox;
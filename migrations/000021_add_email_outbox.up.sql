@@ -0,0 +1,33 @@
+-- Emails sent over SMTP: queued, retried with backoff until sent or failed,
+-- and kept as the send log with the Message-ID of each message
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL DEFAULT '',            -- invoice, contract, reminder or test
+    invoice_id INTEGER,
+    contract_id INTEGER,
+    message_id TEXT NOT NULL,
+    recipients TEXT NOT NULL,                 -- To, comma separated
+    cc TEXT NOT NULL DEFAULT '',
+    bcc TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    html_body TEXT NOT NULL DEFAULT '',
+    attachments TEXT NOT NULL DEFAULT '',     -- File paths, one per line
+    status TEXT NOT NULL DEFAULT 'queued',    -- queued, sent or failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE SET NULL,
+    FOREIGN KEY (contract_id) REFERENCES contracts(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_status ON email_outbox(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_invoice ON email_outbox(invoice_id);
+
+-- When an invoice was last emailed, and the addresses clients want copied
+ALTER TABLE invoices ADD COLUMN sent_at TIMESTAMP;
+ALTER TABLE clients ADD COLUMN email_cc TEXT NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN email_bcc TEXT NOT NULL DEFAULT '';
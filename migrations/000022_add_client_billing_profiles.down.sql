@@ -0,0 +1,12 @@
+-- The audit triggers list every column, they are recreated when ung opens the database
+DROP TRIGGER IF EXISTS audit_invoices_insert;
+DROP TRIGGER IF EXISTS audit_invoices_update;
+DROP TRIGGER IF EXISTS audit_invoices_delete;
+
+ALTER TABLE invoices DROP COLUMN po_number;
+ALTER TABLE clients DROP COLUMN po_required;
+ALTER TABLE clients DROP COLUMN payment_terms_days;
+ALTER TABLE clients DROP COLUMN currency;
+DROP TABLE IF EXISTS client_addresses;
+DROP INDEX IF EXISTS idx_client_contacts_client;
+DROP TABLE IF EXISTS client_contacts;
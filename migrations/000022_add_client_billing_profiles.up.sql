@@ -0,0 +1,36 @@
+-- Contacts of a client by role. Billing contacts receive its invoices,
+-- legal contacts its contracts, and contacts with cc_invoices are copied.
+CREATE TABLE IF NOT EXISTS client_contacts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id INTEGER NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    role TEXT NOT NULL DEFAULT 'billing',   -- billing, technical or legal
+    cc_invoices BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_contacts_client ON client_contacts(client_id);
+
+-- Addresses of a client, one of each kind. Invoices are addressed to the
+-- billing address, otherwise to the client's address.
+CREATE TABLE IF NOT EXISTS client_addresses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,                      -- billing, legal or postal
+    address TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE,
+    UNIQUE (client_id, kind)
+);
+
+-- Billing terms of new invoices for a client: currency and payment terms
+-- (empty and 0 for the defaults), and whether they need a PO number
+ALTER TABLE clients ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN payment_terms_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN po_required BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN po_number TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+DROP TRIGGER IF EXISTS audit_log_no_update;
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+WHEN OLD.hash IS NOT NULL
+    OR NEW.id IS NOT OLD.id
+    OR NEW.occurred_at IS NOT OLD.occurred_at
+    OR NEW.entity IS NOT OLD.entity
+    OR NEW.entity_id IS NOT OLD.entity_id
+    OR NEW.action IS NOT OLD.action
+    OR NEW.old_values IS NOT OLD.old_values
+    OR NEW.new_values IS NOT OLD.new_values
+    OR NEW.source IS NOT OLD.source
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+
+CREATE TABLE IF NOT EXISTS audit_context (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    source TEXT NOT NULL
+);
+INSERT OR IGNORE INTO audit_context (id, source) VALUES (1, 'cli');
@@ -0,0 +1,20 @@
+-- The source of each audit entry now comes from the connection that made the
+-- change instead of one row shared by every program using the database.
+-- Entries are written as 'unknown' and filled in by a trigger that only
+-- exists on connections opened by ung, before they are sealed.
+DROP TABLE IF EXISTS audit_context;
+
+DROP TRIGGER IF EXISTS audit_log_no_update;
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+WHEN OLD.hash IS NOT NULL
+    OR NEW.id IS NOT OLD.id
+    OR NEW.occurred_at IS NOT OLD.occurred_at
+    OR NEW.entity IS NOT OLD.entity
+    OR NEW.entity_id IS NOT OLD.entity_id
+    OR NEW.action IS NOT OLD.action
+    OR NEW.old_values IS NOT OLD.old_values
+    OR NEW.new_values IS NOT OLD.new_values
+    OR (NEW.source IS NOT OLD.source AND OLD.source IS NOT 'unknown')
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
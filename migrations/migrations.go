@@ -0,0 +1,13 @@
+// Package migrations embeds the versioned SQL schema of the ung database.
+//
+// Each change is a pair of NNNNNN_name.up.sql and NNNNNN_name.down.sql files.
+// The files are run by pkg/schema for the CLI and the API, bundled as-is by the
+// macOS app and read by sqlc, so they stay plain SQL in this directory.
+package migrations
+
+import "embed"
+
+// Files holds every .up.sql and .down.sql migration
+//
+//go:embed *.sql
+var Files embed.FS
@@ -0,0 +1,419 @@
+// Package audit keeps an append-only log of every change to financial
+// records (invoices, line items, time sessions, contracts and expenses).
+//
+// Entries are written by SQLite triggers, so changes are recorded whichever
+// program makes them: the CLI, the API server or the Telegram bot (through
+// the API). Each entry holds the row before and after the change and the
+// source of the connection that made it (see DriverName); changes made
+// without it, e.g. in the sqlite3 shell, are recorded as unknown.
+//
+// Entries are chained: each one's hash covers its contents and the previous
+// entry's hash. Seal fills in hashes for new entries and Verify recomputes the
+// chain, so editing or deleting an entry after it was sealed is detected.
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sources of changes
+const (
+	SourceCLI      = "cli"
+	SourceAPI      = "api"
+	SourceTelegram = "telegram"
+	SourceSync     = "sync"
+	SourceRestore  = "restore"
+	SourceUnknown  = "unknown"
+)
+
+// Actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Entities lists the audited tables and the entity names their entries use
+var Entities = []struct {
+	Table  string
+	Entity string
+}{
+	{"invoices", "invoice"},
+	{"invoice_line_items", "line_item"},
+	{"tracking_sessions", "session"},
+	{"contracts", "contract"},
+	{"expenses", "expense"},
+}
+
+// Schema creates the audit table. Entries can't be deleted, and once sealed
+// they can't be changed; only an unknown source may be filled in before.
+const Schema = `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		occurred_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+		entity TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+		old_values TEXT,
+		new_values TEXT,
+		source TEXT NOT NULL DEFAULT 'cli',
+		prev_hash TEXT,
+		hash TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	WHEN OLD.hash IS NOT NULL
+		OR NEW.id IS NOT OLD.id
+		OR NEW.occurred_at IS NOT OLD.occurred_at
+		OR NEW.entity IS NOT OLD.entity
+		OR NEW.entity_id IS NOT OLD.entity_id
+		OR NEW.action IS NOT OLD.action
+		OR NEW.old_values IS NOT OLD.old_values
+		OR NEW.new_values IS NOT OLD.new_values
+		OR (NEW.source IS NOT OLD.source AND OLD.source IS NOT 'unknown')
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;
+`
+
+// Install creates the audit table and (re)creates the triggers of each
+// audited table for its current columns. Connections opened before the audit
+// table existed don't tag changes with their source, so databases are set up
+// through a connection of their own before being opened with DriverName.
+func Install(conn *sql.DB) error {
+	if _, err := conn.Exec(Schema); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	for _, e := range Entities {
+		columns, err := tableColumns(conn, e.Table)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		for name, stmt := range triggers(e.Table, e.Entity, columns) {
+			if err := replaceTrigger(conn, name, stmt); err != nil {
+				return fmt.Errorf("failed to install audit trigger %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+type column struct {
+	name string
+	blob bool
+}
+
+func tableColumns(conn *sql.DB, table string) ([]column, error) {
+	rows, err := conn.Query("SELECT name, type FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+	var columns []column
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column{name: name, blob: strings.Contains(strings.ToUpper(typ), "BLOB")})
+	}
+	return columns, rows.Err()
+}
+
+// triggers returns the insert, update and delete triggers of a table
+func triggers(table, entity string, columns []column) map[string]string {
+	values := func(row string) string {
+		var pairs []string
+		for _, c := range columns {
+			ref := row + "." + quoteIdent(c.name)
+			if c.blob {
+				ref = "hex(" + ref + ")" // JSON can't hold blobs
+			}
+			pairs = append(pairs, fmt.Sprintf("'%s', %s", c.name, ref))
+		}
+		return "json_object(" + strings.Join(pairs, ", ") + ")"
+	}
+	var changed []string
+	for _, c := range columns {
+		if c.name != "updated_at" {
+			changed = append(changed, fmt.Sprintf("OLD.%[1]s IS NOT NEW.%[1]s", quoteIdent(c.name)))
+		}
+	}
+	source := "'" + SourceUnknown + "'"
+	insert := "INSERT INTO audit_log (entity, entity_id, action, old_values, new_values, source)"
+
+	return map[string]string{
+		"audit_" + table + "_insert": fmt.Sprintf(`CREATE TRIGGER %s AFTER INSERT ON %s
+BEGIN
+	%s VALUES ('%s', NEW.id, 'create', NULL, %s, %s);
+END`, "audit_"+table+"_insert", quoteIdent(table), insert, entity, values("NEW"), source),
+		"audit_" + table + "_update": fmt.Sprintf(`CREATE TRIGGER %s AFTER UPDATE ON %s
+WHEN %s
+BEGIN
+	%s VALUES ('%s', NEW.id, 'update', %s, %s, %s);
+END`, "audit_"+table+"_update", quoteIdent(table), strings.Join(changed, " OR "), insert, entity, values("OLD"), values("NEW"), source),
+		"audit_" + table + "_delete": fmt.Sprintf(`CREATE TRIGGER %s AFTER DELETE ON %s
+BEGIN
+	%s VALUES ('%s', OLD.id, 'delete', %s, NULL, %s);
+END`, "audit_"+table+"_delete", quoteIdent(table), insert, entity, values("OLD"), source),
+	}
+}
+
+// replaceTrigger creates a trigger, replacing an existing one only if it differs
+func replaceTrigger(conn *sql.DB, name, stmt string) error {
+	var existing string
+	conn.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'trigger' AND name = ?", name).Scan(&existing)
+	if existing == stmt {
+		return nil
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DROP TRIGGER IF EXISTS " + quoteIdent(name)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(stmt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Entry is one audit log entry
+type Entry struct {
+	ID         int64  `json:"id"`
+	OccurredAt string `json:"occurred_at"` // UTC, RFC 3339 with milliseconds
+	Entity     string `json:"entity"`
+	EntityID   int64  `json:"entity_id"`
+	Action     string `json:"action"`
+	OldValues  string `json:"old_values,omitempty"` // JSON object, empty for creates
+	NewValues  string `json:"new_values,omitempty"` // JSON object, empty for deletes
+	Source     string `json:"source"`
+	PrevHash   string `json:"prev_hash"`
+	Hash       string `json:"hash"` // Empty until sealed
+}
+
+// Time returns when the change happened
+func (e Entry) Time() time.Time {
+	t, _ := time.Parse("2006-01-02T15:04:05.000Z", e.OccurredAt)
+	return t
+}
+
+// FieldChange is a field whose value an entry changed
+type FieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// Changes returns the fields that differ between the old and new values.
+// For creates and deletes every field is returned.
+func (e Entry) Changes() []FieldChange {
+	var oldValues, newValues map[string]any
+	json.Unmarshal([]byte(e.OldValues), &oldValues)
+	json.Unmarshal([]byte(e.NewValues), &newValues)
+
+	fields := map[string]bool{}
+	for field := range oldValues {
+		fields[field] = true
+	}
+	for field := range newValues {
+		fields[field] = true
+	}
+	var changes []FieldChange
+	for field := range fields {
+		o, n := oldValues[field], newValues[field]
+		if e.Action == ActionUpdate && fmt.Sprint(o) == fmt.Sprint(n) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Old: o, New: n})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// computeHash returns the chain hash of an entry following prev
+func computeHash(prev string, e Entry) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		prev,
+		fmt.Sprint(e.ID),
+		e.OccurredAt,
+		e.Entity,
+		fmt.Sprint(e.EntityID),
+		e.Action,
+		e.OldValues,
+		e.NewValues,
+		e.Source,
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+const entryColumns = `id, occurred_at, entity, entity_id, action, COALESCE(old_values, ''), COALESCE(new_values, ''),
+	source, COALESCE(prev_hash, ''), COALESCE(hash, '')`
+
+func scanEntries(rows *sql.Rows) ([]Entry, error) {
+	defer rows.Close()
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.Entity, &e.EntityID, &e.Action, &e.OldValues, &e.NewValues,
+			&e.Source, &e.PrevHash, &e.Hash); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Seal chains the entries added since the last seal and returns how many it sealed
+func Seal(conn *sql.DB) (int, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var prev string
+	tx.QueryRow("SELECT hash FROM audit_log WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1").Scan(&prev)
+	rows, err := tx.Query("SELECT " + entryColumns + " FROM audit_log WHERE hash IS NULL ORDER BY id")
+	if err != nil {
+		return 0, err
+	}
+	pending, err := scanEntries(rows)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range pending {
+		hash := computeHash(prev, e)
+		if _, err := tx.Exec("UPDATE audit_log SET prev_hash = ?, hash = ? WHERE id = ?", prev, hash, e.ID); err != nil {
+			return 0, err
+		}
+		prev = hash
+	}
+	return len(pending), tx.Commit()
+}
+
+// Verification is the result of checking the hash chain
+type Verification struct {
+	Entries int    // Sealed entries checked
+	Pending int    // Entries not sealed yet
+	Head    string // Hash of the last sealed entry
+}
+
+// Contains reports whether a sealed entry has the given hash, to check that a
+// previously noted head is still part of the chain
+func Contains(conn *sql.DB, hash string) (bool, error) {
+	var n int
+	err := conn.QueryRow("SELECT count(*) FROM audit_log WHERE hash = ?", hash).Scan(&n)
+	return n > 0, err
+}
+
+// Verify recomputes the hash chain and returns an error naming the first
+// entry that was changed, or the entry after one that was removed
+func Verify(conn *sql.DB) (*Verification, error) {
+	rows, err := conn.Query("SELECT " + entryColumns + " FROM audit_log ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Verification{}
+	prev := ""
+	for _, e := range entries {
+		if e.Hash == "" {
+			v.Pending++
+			continue
+		}
+		if v.Pending > 0 {
+			return v, fmt.Errorf("entry %d is sealed after unsealed entries: the chain was altered", e.ID)
+		}
+		if e.PrevHash != prev {
+			return v, fmt.Errorf("entry %d doesn't follow the previous entry: entries before it were removed or changed", e.ID)
+		}
+		if computeHash(prev, e) != e.Hash {
+			return v, fmt.Errorf("entry %d was modified after it was recorded", e.ID)
+		}
+		prev = e.Hash
+		v.Entries++
+		v.Head = e.Hash
+	}
+	return v, nil
+}
+
+// Filter selects audit entries
+type Filter struct {
+	Entity   string
+	EntityID int64
+	Since    time.Time
+	Limit    int
+}
+
+// Query returns matching entries, newest first
+func Query(conn *sql.DB, f Filter) ([]Entry, error) {
+	var where []string
+	var args []any
+	if f.Entity != "" {
+		where = append(where, "entity = ?")
+		args = append(args, f.Entity)
+	}
+	if f.EntityID != 0 {
+		where = append(where, "entity_id = ?")
+		args = append(args, f.EntityID)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "occurred_at >= ?")
+		args = append(args, f.Since.UTC().Format("2006-01-02T15:04:05.000Z"))
+	}
+	query := "SELECT " + entryColumns + " FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+	rows, err := conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanEntries(rows)
+}
+
+// EntityName resolves a user-supplied entity or table name ("invoices", "time")
+func EntityName(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	aliases := map[string]string{"time": "session", "tracking": "session", "tracking_session": "session", "item": "line_item", "invoice_line_item": "line_item"}
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	for _, e := range Entities {
+		if name == e.Entity || name == e.Table || name == e.Entity+"s" {
+			return e.Entity, true
+		}
+	}
+	return "", false
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
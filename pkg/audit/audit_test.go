@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// createTestDB creates a database with audited tables and returns its path
+func createTestDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ung.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(`
+		CREATE TABLE invoices (id INTEGER PRIMARY KEY AUTOINCREMENT, invoice_num TEXT, amount REAL,
+			status TEXT, pdf BLOB, updated_at DATETIME);
+		CREATE TABLE expenses (id INTEGER PRIMARY KEY AUTOINCREMENT, description TEXT, amount REAL);
+	`); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	if err := Install(conn); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	return path
+}
+
+// openTestDB opens path for changes from source
+func openTestDB(t *testing.T, path, source string) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open(DriverName, DSN(path, source))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestTriggersRecordChanges(t *testing.T) {
+	path := createTestDB(t)
+	conn := openTestDB(t, path, SourceCLI)
+
+	conn.Exec("INSERT INTO invoices (invoice_num, amount, status, pdf) VALUES ('INV-001', 100, 'pending', x'00ff')")
+	conn.Exec("UPDATE invoices SET updated_at = CURRENT_TIMESTAMP") // Bookkeeping only
+	openTestDB(t, path, SourceAPI).Exec("UPDATE invoices SET amount = 150, updated_at = CURRENT_TIMESTAMP")
+	conn.Exec("DELETE FROM invoices")
+	conn.Exec("INSERT INTO expenses (description, amount) VALUES ('Laptop', 1200)")
+
+	entries, err := Query(conn, Filter{Entity: "invoice", EntityID: 1})
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected create, update and delete, got %+v", entries)
+	}
+	del, upd, create := entries[0], entries[1], entries[2]
+	if create.Action != ActionCreate || create.Source != SourceCLI || !strings.Contains(create.NewValues, `"pdf":"00FF"`) {
+		t.Errorf("unexpected create entry: %+v", create)
+	}
+	if upd.Action != ActionUpdate || upd.Source != SourceAPI {
+		t.Errorf("unexpected update entry: %+v", upd)
+	}
+	changes := upd.Changes()
+	var fields []string
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	if len(fields) == 0 || fields[0] != "amount" {
+		t.Errorf("expected amount to change, got %v", fields)
+	}
+	if del.Action != ActionDelete || del.NewValues != "" || !strings.Contains(del.OldValues, `"amount":150`) {
+		t.Errorf("unexpected delete entry: %+v", del)
+	}
+
+	if entity, ok := EntityName("expenses"); !ok || entity != "expense" {
+		t.Errorf("EntityName(expenses) = %q, %v", entity, ok)
+	}
+	if all, _ := Query(conn, Filter{Entity: "expense"}); len(all) != 1 {
+		t.Errorf("expected one expense entry, got %d", len(all))
+	}
+}
+
+func TestSealAndVerifyDetectTampering(t *testing.T) {
+	conn := openTestDB(t, createTestDB(t), SourceCLI)
+	for _, amount := range []int{100, 200, 300} {
+		conn.Exec("INSERT INTO expenses (description, amount) VALUES ('Rent', ?)", amount)
+	}
+
+	n, err := Seal(conn)
+	if err != nil || n != 3 {
+		t.Fatalf("Seal() = %d, %v", n, err)
+	}
+	if n, _ := Seal(conn); n != 0 {
+		t.Errorf("expected second seal to do nothing, sealed %d", n)
+	}
+	v, err := Verify(conn)
+	if err != nil || v.Entries != 3 || v.Head == "" {
+		t.Fatalf("Verify() = %+v, %v", v, err)
+	}
+	if ok, _ := Contains(conn, v.Head); !ok {
+		t.Error("expected the head to be in the log")
+	}
+
+	// The log is append-only
+	if _, err := conn.Exec("DELETE FROM audit_log WHERE id = 2"); err == nil {
+		t.Error("expected deleting an entry to fail")
+	}
+	if _, err := conn.Exec("UPDATE audit_log SET new_values = '{}' WHERE id = 2"); err == nil {
+		t.Error("expected changing a sealed entry to fail")
+	}
+
+	// Bypassing the guards is still detected
+	conn.Exec("DROP TRIGGER audit_log_no_update")
+	conn.Exec(`UPDATE audit_log SET new_values = replace(new_values, '200', '20') WHERE id = 2`)
+	if _, err := Verify(conn); err == nil || !strings.Contains(err.Error(), "entry 2") {
+		t.Errorf("expected modified entry 2 to be reported, got %v", err)
+	}
+
+	conn.Exec("DROP TRIGGER audit_log_no_delete")
+	conn.Exec("DELETE FROM audit_log WHERE id = 2")
+	if _, err := Verify(conn); err == nil || !strings.Contains(err.Error(), "entry 3") {
+		t.Errorf("expected a gap before entry 3 to be reported, got %v", err)
+	}
+}
+
+func TestInstallUpdatesTriggersForNewColumns(t *testing.T) {
+	conn := openTestDB(t, createTestDB(t), SourceCLI)
+	conn.Exec("ALTER TABLE expenses ADD COLUMN category TEXT")
+	if err := Install(conn); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	conn.Exec("INSERT INTO expenses (description, amount, category) VALUES ('Train', 40, 'travel')")
+
+	entries, _ := Query(conn, Filter{Entity: "expense", Limit: 1})
+	if len(entries) != 1 || !strings.Contains(entries[0].NewValues, `"category":"travel"`) {
+		t.Errorf("expected new column in entry, got %+v", entries)
+	}
+}
+
+func TestSourceIsPerConnection(t *testing.T) {
+	path := createTestDB(t)
+	api := openTestDB(t, path, SourceAPI)
+	telegram := openTestDB(t, path, SourceTelegram)
+	cli := openTestDB(t, path, SourceCLI)
+	plain, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer plain.Close()
+
+	api.Exec("INSERT INTO expenses (description, amount) VALUES ('Hosting', 20)")
+	telegram.Exec("INSERT INTO expenses (description, amount) VALUES ('Taxi', 15)")
+	restore := Relabel(SourceCLI, SourceSync)
+	cli.Exec("INSERT INTO expenses (description, amount) VALUES ('Laptop', 1200)")
+	restore()
+	cli.Exec("INSERT INTO expenses (description, amount) VALUES ('Desk', 300)")
+	plain.Exec("INSERT INTO expenses (description, amount) VALUES ('Chair', 150)")
+
+	entries, err := Query(cli, Filter{Entity: "expense"})
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	var sources []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		sources = append(sources, entries[i].Source)
+	}
+	want := []string{SourceAPI, SourceTelegram, SourceSync, SourceCLI, SourceUnknown}
+	if strings.Join(sources, ",") != strings.Join(want, ",") {
+		t.Errorf("sources = %v, want %v", sources, want)
+	}
+
+	// Sealing covers the filled-in sources
+	if _, err := Seal(plain); err != nil {
+		t.Fatalf("Seal() error: %v", err)
+	}
+	if _, err := Verify(plain); err != nil {
+		t.Errorf("Verify() error: %v", err)
+	}
+	if _, err := plain.Exec("UPDATE audit_log SET source = 'cli' WHERE source = 'unknown'"); err == nil {
+		t.Error("expected changing the source of a sealed entry to fail")
+	}
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// DriverName is the database/sql driver that records the source of the
+// changes made through its connections. It is the sqlite3 driver with the
+// source given in the DSN (see DSN):
+//
+//	sql.Open(audit.DriverName, audit.DSN(path, audit.SourceAPI))
+//
+// The source belongs to the connection, so programs writing to the same
+// database at the same time each record their own.
+const DriverName = "sqlite3_audit"
+
+// dsnParam carries the source in a DSN; it is removed before the DSN reaches SQLite
+const dsnParam = "_audit_source"
+
+// sourceTrigger fills in the source of entries the audited tables' triggers
+// add. It is a TEMP trigger, so it only exists on connections opened with
+// DriverName; ung_audit_source is registered on each of them.
+const sourceTrigger = `CREATE TEMP TRIGGER IF NOT EXISTS audit_log_source AFTER INSERT ON main.audit_log
+WHEN NEW.source = 'unknown'
+BEGIN
+	UPDATE audit_log SET source = ung_audit_source() WHERE id = NEW.id;
+END`
+
+func init() {
+	sql.Register(DriverName, sourceDriver{})
+}
+
+// DSN adds the source of changes to a sqlite3 DSN
+func DSN(dsn, source string) string {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + dsnParam + "=" + url.QueryEscape(source)
+}
+
+// splitDSN removes the source from a DSN built by DSN
+func splitDSN(dsn string) (string, string) {
+	base, query, ok := strings.Cut(dsn, "?")
+	if !ok {
+		return dsn, SourceUnknown
+	}
+	source := SourceUnknown
+	var kept []string
+	for _, param := range strings.Split(query, "&") {
+		if value, found := strings.CutPrefix(param, dsnParam+"="); found {
+			source, _ = url.QueryUnescape(value)
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if len(kept) == 0 {
+		return base, source
+	}
+	return base + "?" + strings.Join(kept, "&"), source
+}
+
+type sourceDriver struct{}
+
+func (sourceDriver) Open(dsn string) (driver.Conn, error) {
+	dsn, source := splitDSN(dsn)
+	d := &sqlite3.SQLiteDriver{ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+		if err := conn.RegisterFunc("ung_audit_source", func() string { return label(source) }, false); err != nil {
+			return err
+		}
+		// Databases without an audit log yet are set up through another connection
+		if _, err := conn.Exec(sourceTrigger, nil); err != nil && !strings.Contains(err.Error(), "no such table") {
+			return err
+		}
+		return nil
+	}}
+	return d.Open(dsn)
+}
+
+var (
+	relabelMu sync.Mutex
+	relabels  = map[string]string{}
+)
+
+// Relabel records the changes made through connections opened for source as
+// coming from label instead, e.g. while the CLI merges changes from another
+// device. Calling restore puts back what they were recorded as before.
+func Relabel(source, label string) (restore func()) {
+	relabelMu.Lock()
+	defer relabelMu.Unlock()
+	prev, had := relabels[source]
+	relabels[source] = label
+	return func() {
+		relabelMu.Lock()
+		defer relabelMu.Unlock()
+		if had {
+			relabels[source] = prev
+		} else {
+			delete(relabels, source)
+		}
+	}
+}
+
+// label returns what changes made through connections opened for source are recorded as
+func label(source string) string {
+	relabelMu.Lock()
+	defer relabelMu.Unlock()
+	if l, ok := relabels[source]; ok {
+		return l
+	}
+	return source
+}
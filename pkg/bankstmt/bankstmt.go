@@ -0,0 +1,184 @@
+package bankstmt
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the file format of a bank statement export
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatOFX  Format = "ofx" // OFX and QFX (Quicken's OFX variant)
+	FormatCAMT Format = "camt"
+)
+
+// Transaction is a single booked statement line
+type Transaction struct {
+	ID           string // Bank transaction ID, or a content hash when the export has none
+	Date         time.Time
+	Amount       float64 // Positive for incoming credits, negative for outgoing debits
+	Currency     string
+	Counterparty string // Payer for credits, payee for debits
+	Reference    string // Remittance information / memo
+}
+
+// IsCredit reports whether money came in
+func (t Transaction) IsCredit() bool {
+	return t.Amount > 0
+}
+
+// Text returns counterparty and reference joined, for matching
+func (t Transaction) Text() string {
+	return strings.TrimSpace(t.Counterparty + " " + t.Reference)
+}
+
+// Parse reads a statement in the given format
+func Parse(format Format, r io.Reader) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+
+	var txs []Transaction
+	switch format {
+	case FormatCSV:
+		txs, err = parseCSV(data)
+	case FormatOFX:
+		txs, err = parseOFX(data)
+	case FormatCAMT:
+		txs, err = parseCAMT(data)
+	default:
+		return nil, fmt.Errorf("unsupported statement format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Identical transactions on the same day (two coffees) are told apart by
+	// how often they occurred before, so overlapping statements agree on IDs
+	seen := make(map[string]int)
+	for i := range txs {
+		if txs[i].ID == "" {
+			key := hashKey(txs[i])
+			txs[i].ID = hashID(key, seen[key])
+			seen[key]++
+		}
+	}
+	return txs, nil
+}
+
+// DetectFormat guesses the statement format from the file name and contents
+func DetectFormat(fileName string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".csv", ".txt":
+		return FormatCSV
+	}
+
+	head := data
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	switch {
+	case bytes.Contains(head, []byte("OFXHEADER")) || bytes.Contains(head, []byte("<OFX>")):
+		return FormatOFX
+	case bytes.Contains(head, []byte("camt.053")) || bytes.Contains(head, []byte("<BkToCstmrStmt>")):
+		return FormatCAMT
+	}
+	return FormatCSV
+}
+
+// hashKey returns the fields that identify a transaction without an ID
+func hashKey(t Transaction) string {
+	return strings.Join([]string{
+		t.Date.Format("2006-01-02"),
+		strconv.FormatFloat(t.Amount, 'f', 2, 64),
+		t.Currency,
+		t.Counterparty,
+		t.Reference,
+	}, "|")
+}
+
+// hashID builds a stable ID for exports without transaction IDs from the
+// n-th occurrence of key; the first one keeps the plain hash so earlier
+// imports still match
+func hashID(key string, n int) string {
+	if n > 0 {
+		key += "|" + strconv.Itoa(n)
+	}
+	sum := sha1.Sum([]byte(key))
+	return "h" + hex.EncodeToString(sum[:])[:16]
+}
+
+// parseAmount parses amounts like "1,234.56", "1.234,56", "-50" or "(50.00)"
+func parseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.Trim(s, "()")
+	}
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "'", "", "$", "", "€", "", "£", "").Replace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+
+	// Whichever separator comes last is the decimal separator
+	lastComma := strings.LastIndex(s, ",")
+	lastDot := strings.LastIndex(s, ".")
+	switch {
+	case lastComma > lastDot:
+		// A single comma followed by exactly three digits is a thousands separator
+		if lastDot == -1 && strings.Count(s, ",") == 1 && len(s)-lastComma-1 == 3 {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	default:
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %s", s)
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// parseDate parses the date layouts used by bank exports
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	layouts := []string{
+		"2006-01-02",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04:05Z07:00",
+		"02.01.2006",
+		"01/02/2006",
+		"1/2/2006",
+		"02/01/2006",
+		"2006/01/02",
+		"20060102",
+		"02-Jan-2006",
+		"Jan 2, 2006",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse date: %s", s)
+}
@@ -0,0 +1,215 @@
+package bankstmt
+
+import (
+	"strings"
+	"testing"
+)
+
+const csvExport = "Date,Description,Counterparty,Amount,Currency\n" +
+	"2024-02-01,Payment inv.acme.2024-01-15,Acme Corp,\"1,500.00\",USD\n" +
+	"2024-02-03,GitHub subscription,GitHub,-21.00,USD\n"
+
+const semicolonStatement = "Buchungstag;Verwendungszweck;Auftraggeber/Empfänger;Betrag;Währung\n" +
+	"01.02.2024;Rechnung 42;Muster GmbH;1.234,56;EUR\n"
+
+const creditDebitStatement = "Posted Date,Reference,Payee,Paid In,Paid Out\n" +
+	"02/05/2024,INV-7,Globex,250.00,\n" +
+	"02/06/2024,Coffee,Cafe,,4.50\n"
+
+const ofxStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240201120000[0:GMT]
+<TRNAMT>1500.00
+<FITID>2024020101
+<NAME>Acme Corp
+<MEMO>inv.acme.2024-01-15
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240203
+<TRNAMT>-21.00
+<FITID>2024020302
+<NAME>GitHub &amp; Co
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+const camtXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt>
+<Stmt>
+<Ntry>
+  <Amt Ccy="EUR">1500.00</Amt>
+  <CdtDbtInd>CRDT</CdtDbtInd>
+  <Sts>BOOK</Sts>
+  <BookgDt><Dt>2024-02-01</Dt></BookgDt>
+  <AcctSvcrRef>REF-1</AcctSvcrRef>
+  <NtryDtls><TxDtls>
+    <RltdPties><Dbtr><Nm>Acme Corp</Nm></Dbtr></RltdPties>
+    <RmtInf><Ustrd>Invoice inv.acme.2024-01-15</Ustrd></RmtInf>
+  </TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">30.00</Amt>
+  <CdtDbtInd>DBIT</CdtDbtInd>
+  <Sts><Cd>BOOK</Cd></Sts>
+  <BookgDt><DtTm>2024-02-02T10:00:00</DtTm></BookgDt>
+  <NtryDtls><TxDtls>
+    <RltdPties><Cdtr><Pty><Nm>Hosting Ltd</Nm></Pty></Cdtr></RltdPties>
+  </TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">99.00</Amt>
+  <CdtDbtInd>CRDT</CdtDbtInd>
+  <Sts>PDNG</Sts>
+  <BookgDt><Dt>2024-02-03</Dt></BookgDt>
+</Ntry>
+</Stmt>
+</BkToCstmrStmt>
+</Document>`
+
+func TestParseCSV(t *testing.T) {
+	txs, err := Parse(FormatCSV, strings.NewReader(csvExport))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txs))
+	}
+	if txs[0].Amount != 1500 || !txs[0].IsCredit() || txs[0].Counterparty != "Acme Corp" {
+		t.Errorf("unexpected credit: %+v", txs[0])
+	}
+	if txs[1].Amount != -21 || txs[1].IsCredit() {
+		t.Errorf("unexpected debit: %+v", txs[1])
+	}
+	if txs[0].ID == "" || txs[0].ID == txs[1].ID {
+		t.Errorf("expected distinct generated IDs, got %q and %q", txs[0].ID, txs[1].ID)
+	}
+
+	txs, err = Parse(FormatCSV, strings.NewReader(semicolonStatement))
+	if err != nil {
+		t.Fatalf("Parse() semicolon error: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Amount != 1234.56 || txs[0].Currency != "EUR" || txs[0].Date.Day() != 1 {
+		t.Errorf("unexpected semicolon transaction: %+v", txs)
+	}
+
+	txs, err = Parse(FormatCSV, strings.NewReader(creditDebitStatement))
+	if err != nil {
+		t.Fatalf("Parse() credit/debit error: %v", err)
+	}
+	if len(txs) != 2 || txs[0].Amount != 250 || txs[1].Amount != -4.5 {
+		t.Errorf("unexpected credit/debit transactions: %+v", txs)
+	}
+}
+
+func TestParseIdenticalTransactions(t *testing.T) {
+	header := "Date,Description,Counterparty,Amount,Currency\n"
+	coffee := "2024-02-06,Coffee,Cafe,-4.50,EUR\n"
+	first, err := Parse(FormatCSV, strings.NewReader(header+"2024-02-05,Lunch,Diner,-12.00,EUR\n"+coffee+coffee))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if first[1].ID == first[2].ID {
+		t.Errorf("identical transactions share ID %s", first[1].ID)
+	}
+
+	// A later, overlapping statement gives them the same IDs
+	second, err := Parse(FormatCSV, strings.NewReader(header+coffee+coffee+"2024-02-07,Books,Shop,-30.00,EUR\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if first[1].ID != second[0].ID || first[2].ID != second[1].ID {
+		t.Errorf("expected IDs %s and %s in both statements, got %s and %s", first[1].ID, first[2].ID, second[0].ID, second[1].ID)
+	}
+}
+
+func TestParseOFX(t *testing.T) {
+	txs, err := Parse(FormatOFX, strings.NewReader(ofxStatement))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txs))
+	}
+	if txs[0].ID != "2024020101" || txs[0].Amount != 1500 || txs[0].Currency != "EUR" {
+		t.Errorf("unexpected first transaction: %+v", txs[0])
+	}
+	if txs[0].Reference != "inv.acme.2024-01-15" || txs[0].Date.Day() != 1 {
+		t.Errorf("unexpected reference/date: %+v", txs[0])
+	}
+	if txs[1].Counterparty != "GitHub & Co" || txs[1].Amount != -21 {
+		t.Errorf("unexpected second transaction: %+v", txs[1])
+	}
+}
+
+func TestParseCAMT(t *testing.T) {
+	txs, err := Parse(FormatCAMT, strings.NewReader(camtXML))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected pending entry to be skipped, got %d transactions", len(txs))
+	}
+	if txs[0].ID != "REF-1" || txs[0].Amount != 1500 || txs[0].Counterparty != "Acme Corp" {
+		t.Errorf("unexpected credit: %+v", txs[0])
+	}
+	if txs[0].Reference != "Invoice inv.acme.2024-01-15" {
+		t.Errorf("unexpected reference: %q", txs[0].Reference)
+	}
+	if txs[1].Amount != -30 || txs[1].Counterparty != "Hosting Ltd" || txs[1].Date.Day() != 2 {
+		t.Errorf("unexpected debit: %+v", txs[1])
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Format
+	}{
+		{"statement.qfx", "", FormatOFX},
+		{"statement.csv", "", FormatCSV},
+		{"statement.xml", camtXML, FormatCAMT},
+		{"download", ofxStatement, FormatOFX},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.name, []byte(tt.data)); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %s; want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]float64{
+		"1,500.00":  1500,
+		"1.234,56":  1234.56,
+		"-21.00":    -21,
+		"(50.00)":   -50,
+		"1,000":     1000,
+		"12,5":      12.5,
+		"1 234,56":  1234.56,
+		"CHF 10.00": 0,
+	}
+	for input, want := range tests {
+		got, err := parseAmount(input)
+		if want == 0 {
+			if err == nil {
+				t.Errorf("parseAmount(%q) expected error", input)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("parseAmount(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+}
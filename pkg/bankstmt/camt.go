@@ -0,0 +1,153 @@
+package bankstmt
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// CAMT.053 (ISO 20022 bank-to-customer statement) elements. Only the fields
+// needed for reconciliation are mapped; element names match any namespace
+// version of the schema.
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"` // camt.053.001.08+ nests the name in Pty
+}
+
+func (p camtParty) name() string {
+	return cmp.Or(p.Name, p.PartyName)
+}
+
+// camtStatus holds the entry status, either as text or (camt.053.001.08+) in Cd
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtTxDetails struct {
+	Amount         *camtAmount `xml:"Amt"`
+	TxID           string      `xml:"Refs>TxId"`
+	Unstructured   []string    `xml:"RmtInf>Ustrd"`
+	StructuredRefs []string    `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	Debtor         camtParty   `xml:"RltdPties>Dbtr"`
+	Creditor       camtParty   `xml:"RltdPties>Cdtr"`
+	AdditionalInfo string      `xml:"AddtlTxInf"`
+}
+
+type camtEntry struct {
+	Ref            string          `xml:"NtryRef"`
+	Amount         camtAmount      `xml:"Amt"`
+	CreditDebit    string          `xml:"CdtDbtInd"`
+	Status         camtStatus      `xml:"Sts"`
+	BookingDate    camtDate        `xml:"BookgDt"`
+	ValueDate      camtDate        `xml:"ValDt"`
+	ServicerRef    string          `xml:"AcctSvcrRef"`
+	Details        []camtTxDetails `xml:"NtryDtls>TxDtls"`
+	AdditionalInfo string          `xml:"AddtlNtryInf"`
+}
+
+// parseCAMT reads a CAMT.053 XML statement. Pending entries are skipped.
+// Batch entries with per-transaction amounts are split into one transaction
+// per detail record.
+func parseCAMT(data []byte) ([]Transaction, error) {
+	var doc camtDocument
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CAMT.053 XML: %w", err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, fmt.Errorf("no statements found in CAMT.053 file")
+	}
+
+	var txs []Transaction
+	for _, stmt := range doc.Statements {
+		for n, e := range stmt.Entries {
+			status := strings.ToUpper(strings.TrimSpace(cmp.Or(e.Status.Code, e.Status.Text)))
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			dateStr := strings.TrimSpace(cmp.Or(e.BookingDate.Date, e.BookingDate.DateTime, e.ValueDate.Date, e.ValueDate.DateTime))
+			if len(dateStr) > 10 {
+				dateStr = dateStr[:10]
+			}
+			date, err := parseDate(dateStr)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", n+1, err)
+			}
+
+			credit := strings.ToUpper(strings.TrimSpace(e.CreditDebit)) == "CRDT"
+			entryID := strings.TrimSpace(cmp.Or(e.ServicerRef, e.Ref))
+
+			details := e.Details
+			split := len(details) > 1
+			for _, d := range details {
+				if d.Amount == nil {
+					split = false
+				}
+			}
+			if !split {
+				var d camtTxDetails
+				if len(details) > 0 {
+					d = details[0]
+				}
+				d.Amount = &e.Amount
+				details = []camtTxDetails{d}
+			}
+
+			for i, d := range details {
+				amount, err := parseAmount(d.Amount.Value)
+				if err != nil {
+					return nil, fmt.Errorf("entry %d: %w", n+1, err)
+				}
+				amount = abs(amount)
+				counterparty := d.Creditor.name()
+				if credit {
+					counterparty = d.Debtor.name()
+				} else {
+					amount = -amount
+				}
+
+				references := append(append([]string{}, d.Unstructured...), d.StructuredRefs...)
+				reference := strings.Join(references, " ")
+				if reference == "" {
+					reference = cmp.Or(d.AdditionalInfo, e.AdditionalInfo)
+				}
+
+				id := cmp.Or(strings.TrimSpace(d.TxID), entryID)
+				if split && id != "" && id == entryID {
+					id = fmt.Sprintf("%s-%d", entryID, i+1)
+				}
+				txs = append(txs, Transaction{
+					ID:           id,
+					Date:         date,
+					Amount:       amount,
+					Currency:     strings.ToUpper(strings.TrimSpace(cmp.Or(d.Amount.Currency, e.Amount.Currency))),
+					Counterparty: strings.TrimSpace(counterparty),
+					Reference:    strings.TrimSpace(reference),
+				})
+			}
+		}
+	}
+	return txs, nil
+}
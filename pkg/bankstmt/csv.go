@@ -0,0 +1,142 @@
+package bankstmt
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// Header names used by common bank CSV exports, matched case-insensitively
+var (
+	csvDateColumns         = []string{"booking date", "transaction date", "posted date", "posting date", "date", "buchungstag", "value date", "valuta"}
+	csvAmountColumns       = []string{"amount", "transaction amount", "betrag", "umsatz"}
+	csvCreditColumns       = []string{"credit", "paid in", "money in", "deposit", "haben"}
+	csvDebitColumns        = []string{"debit", "paid out", "money out", "withdrawal", "soll"}
+	csvCurrencyColumns     = []string{"currency", "ccy", "währung", "waehrung"}
+	csvCounterpartyColumns = []string{"counterparty", "payee", "payer", "name", "beneficiary", "auftraggeber/empfänger", "beguenstigter/zahlungspflichtiger"}
+	csvReferenceColumns    = []string{"reference", "remittance information", "description", "memo", "details", "purpose", "verwendungszweck"}
+	csvIDColumns           = []string{"transaction id", "fitid", "id"}
+)
+
+// parseCSV reads a bank CSV export with a header row. Both comma and
+// semicolon separated files are supported, as are single signed amount
+// columns and separate credit/debit columns.
+func parseCSV(data []byte) ([]Transaction, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	find := func(names []string) int {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+
+	dateCol := find(csvDateColumns)
+	amountCol := find(csvAmountColumns)
+	creditCol := find(csvCreditColumns)
+	debitCol := find(csvDebitColumns)
+	if dateCol < 0 {
+		return nil, fmt.Errorf("CSV has no date column")
+	}
+	if amountCol < 0 && creditCol < 0 && debitCol < 0 {
+		return nil, fmt.Errorf("CSV has no amount or credit/debit columns")
+	}
+	currencyCol := find(csvCurrencyColumns)
+	counterpartyCol := find(csvCounterpartyColumns)
+	referenceCol := find(csvReferenceColumns)
+	idCol := find(csvIDColumns)
+
+	get := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var txs []Transaction
+	for n, row := range records[1:] {
+		dateStr := get(row, dateCol)
+		if dateStr == "" {
+			continue
+		}
+		date, err := parseDate(dateStr)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", n+2, err)
+		}
+
+		var amount float64
+		if amountCol >= 0 {
+			amount, err = parseAmount(get(row, amountCol))
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", n+2, err)
+			}
+		} else {
+			if credit := get(row, creditCol); credit != "" {
+				v, err := parseAmount(credit)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %w", n+2, err)
+				}
+				amount += abs(v)
+			}
+			if debit := get(row, debitCol); debit != "" {
+				v, err := parseAmount(debit)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %w", n+2, err)
+				}
+				amount -= abs(v)
+			}
+		}
+		if amount == 0 {
+			continue
+		}
+
+		txs = append(txs, Transaction{
+			ID:           get(row, idCol),
+			Date:         date,
+			Amount:       amount,
+			Currency:     strings.ToUpper(get(row, currencyCol)),
+			Counterparty: get(row, counterpartyCol),
+			Reference:    get(row, referenceCol),
+		})
+	}
+	return txs, nil
+}
+
+// detectDelimiter picks ';' when the header line has more semicolons than commas
+func detectDelimiter(data []byte) rune {
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
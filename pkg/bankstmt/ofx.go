@@ -0,0 +1,82 @@
+package bankstmt
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// parseOFX reads OFX/QFX statements. Both the SGML flavour (OFX 1.x, where
+// leaf elements have no closing tags) and the XML flavour (OFX 2.x) are
+// handled by reading each leaf value up to the next tag.
+func parseOFX(data []byte) ([]Transaction, error) {
+	content := string(data)
+	upper := strings.ToUpper(content)
+	if !strings.Contains(upper, "<OFX>") {
+		return nil, fmt.Errorf("not an OFX file")
+	}
+
+	currency := ofxValue(content, upper, "CURDEF")
+
+	var txs []Transaction
+	for {
+		start := strings.Index(upper, "<STMTTRN>")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(upper[start:], "</STMTTRN>")
+		if end < 0 {
+			end = len(upper) - start
+		}
+		block, blockUpper := content[start:start+end], upper[start:start+end]
+		content, upper = content[start+end:], upper[start+end:]
+		if strings.HasPrefix(upper, "</STMTTRN>") {
+			content, upper = content[len("</STMTTRN>"):], upper[len("</STMTTRN>"):]
+		}
+
+		posted := ofxValue(block, blockUpper, "DTPOSTED")
+		if len(posted) < 8 {
+			return nil, fmt.Errorf("transaction without posting date")
+		}
+		date, err := time.Parse("20060102", posted[:8])
+		if err != nil {
+			return nil, fmt.Errorf("invalid posting date: %s", posted)
+		}
+		amount, err := parseAmount(ofxValue(block, blockUpper, "TRNAMT"))
+		if err != nil {
+			return nil, err
+		}
+
+		tx := Transaction{
+			ID:           ofxValue(block, blockUpper, "FITID"),
+			Date:         date,
+			Amount:       amount,
+			Currency:     strings.ToUpper(currency),
+			Counterparty: ofxValue(block, blockUpper, "NAME"),
+			Reference:    ofxValue(block, blockUpper, "MEMO"),
+		}
+		if cur := ofxValue(block, blockUpper, "CURSYM"); cur != "" {
+			tx.Currency = strings.ToUpper(cur)
+		}
+		if tx.Counterparty == "" {
+			tx.Counterparty = ofxValue(block, blockUpper, "PAYEEID")
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// ofxValue returns the text of the first <TAG> element in s
+func ofxValue(s, upper, tag string) string {
+	open := "<" + tag + ">"
+	i := strings.Index(upper, open)
+	if i < 0 {
+		return ""
+	}
+	rest := s[i+len(open):]
+	if j := strings.Index(rest, "<"); j >= 0 {
+		rest = rest[:j]
+	}
+	return strings.TrimSpace(html.UnescapeString(rest))
+}
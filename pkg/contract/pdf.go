@@ -8,17 +8,34 @@ import (
 
 	"github.com/Andriiklymiuk/ung/internal/config"
 	"github.com/Andriiklymiuk/ung/internal/db"
-	"github.com/Andriiklymiuk/ung/internal/models"
-	"github.com/Andriiklymiuk/ung/pkg/invoice"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/pdffont"
+	"github.com/Andriiklymiuk/ung/pkg/template"
 	"github.com/jung-kurt/gofpdf"
 )
 
 // GeneratePDF creates a professional PDF for a contract as a clean one-page document
 func GeneratePDF(contract models.Contract, company models.Company, client models.Client) (string, error) {
 	cfg, _ := config.Load()
+	if template.HasCustomTemplate(cfg, "contract") {
+		pdfPath := contractPath(contract, client)
+		data := template.ContractData{Contract: contract, Company: company, Client: client, Config: cfg}
+		if err := template.RenderContractWithTemplate(cfg.Templates.ContractHTML, data, pdfPath); err != nil {
+			return "", err
+		}
+		return pdfPath, nil
+	}
+
+	loc, cfg := locale.For(cfg, client.Language)
+	text := loc.Contract
 	pdfCfg := cfg.PDF
 
 	pdf := gofpdf.New("P", "mm", "A4", "")
+	font, err := pdffont.Setup(pdf, pdffont.FromConfig(pdfCfg), contract, company, client, pdfCfg, loc.Money(0, contract.Currency))
+	if err != nil {
+		return "", err
+	}
 	pdf.AddPage()
 
 	// Set margins
@@ -28,28 +45,27 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 	contentWidth := pageWidth - leftMargin - rightMargin
 
 	// Document Title - centered at top
-	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetFont(font, "B", 20)
 	pdf.SetTextColor(40, 40, 40)
 	pdf.SetXY(leftMargin, 20)
-	pdf.CellFormat(contentWidth, 10, "SERVICE AGREEMENT", "", 1, "C", false, 0, "")
+	pdf.CellFormat(contentWidth, 10, text.Title, "", 1, "C", false, 0, "")
 
 	// Contract reference line
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(100, 100, 100)
 	pdf.SetXY(leftMargin, 32)
-	pdf.CellFormat(contentWidth, 6, fmt.Sprintf("Contract Reference: %s", contract.ContractNum), "", 1, "C", false, 0, "")
+	pdf.CellFormat(contentWidth, 6, locale.Fill(text.Reference, "{number}", contract.ContractNum), "", 1, "C", false, 0, "")
 
 	// Horizontal line
 	pdf.SetDrawColor(200, 200, 200)
 	pdf.Line(leftMargin, 42, pageWidth-rightMargin, 42)
 
 	// Introduction paragraph
-	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetFont(font, "", 11)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetXY(leftMargin, 50)
 
-	introText := fmt.Sprintf("This Service Agreement is entered into as of %s between the following parties:",
-		contract.StartDate.Format("January 2, 2006"))
+	introText := locale.Fill(text.Intro, "{date}", loc.LongDate(contract.StartDate))
 	pdf.MultiCell(contentWidth, 6, introText, "", "L", false)
 
 	pdf.Ln(8)
@@ -58,12 +74,12 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 	currentY := pdf.GetY()
 
 	// Provider (Company)
-	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetFont(font, "B", 11)
 	pdf.SetTextColor(40, 40, 40)
 	pdf.SetXY(leftMargin, currentY)
-	pdf.Cell(contentWidth/2-5, 6, "Service Provider:")
+	pdf.Cell(contentWidth/2-5, 6, text.Provider)
 
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	providerY := currentY + 7
 	pdf.SetXY(leftMargin, providerY)
@@ -76,30 +92,30 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 	}
 	if company.TaxID != "" {
 		pdf.SetXY(leftMargin, providerY)
-		pdf.Cell(contentWidth/2-5, 5, fmt.Sprintf("Tax ID: %s", company.TaxID))
+		pdf.Cell(contentWidth/2-5, 5, fmt.Sprintf("%s: %s", loc.Text.TaxID, company.TaxID))
 		providerY += 5
 	}
 
 	// Client
-	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetFont(font, "B", 11)
 	pdf.SetTextColor(40, 40, 40)
 	pdf.SetXY(pageWidth/2+5, currentY)
-	pdf.Cell(contentWidth/2-5, 6, "Client:")
+	pdf.Cell(contentWidth/2-5, 6, text.Client)
 
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	clientY := currentY + 7
 	pdf.SetXY(pageWidth/2+5, clientY)
 	pdf.Cell(contentWidth/2-5, 5, client.Name)
 	clientY += 5
-	if client.Address != "" {
+	if address := client.AddressFor(models.AddressLegal); address != "" {
 		pdf.SetXY(pageWidth/2+5, clientY)
-		pdf.MultiCell(contentWidth/2-10, 5, client.Address, "", "L", false)
+		pdf.MultiCell(contentWidth/2-10, 5, address, "", "L", false)
 		clientY = pdf.GetY()
 	}
 	if client.TaxID != "" {
 		pdf.SetXY(pageWidth/2+5, clientY)
-		pdf.Cell(contentWidth/2-5, 5, fmt.Sprintf("Tax ID: %s", client.TaxID))
+		pdf.Cell(contentWidth/2-5, 5, fmt.Sprintf("%s: %s", loc.Text.TaxID, client.TaxID))
 		clientY += 5
 	}
 
@@ -111,13 +127,13 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 	pdf.SetY(maxY + 10)
 
 	// Terms of Agreement section
-	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetFont(font, "B", 12)
 	pdf.SetTextColor(40, 40, 40)
 	pdf.SetX(leftMargin)
-	pdf.Cell(contentWidth, 8, "Terms of Agreement")
+	pdf.Cell(contentWidth, 8, text.Terms)
 	pdf.Ln(10)
 
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 
 	// Build terms text
@@ -125,21 +141,18 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 
 	// Contract type and rate
 	if contract.HourlyRate != nil {
-		termsItems = append(termsItems, fmt.Sprintf("The Service Provider agrees to provide services at an hourly rate of %s.",
-			invoice.FormatCurrency(*contract.HourlyRate, contract.Currency)))
+		termsItems = append(termsItems, locale.Fill(text.HourlyRate, "{rate}", loc.Money(*contract.HourlyRate, contract.Currency)))
 	}
 	if contract.FixedPrice != nil {
-		termsItems = append(termsItems, fmt.Sprintf("The total fixed price for services is %s.",
-			invoice.FormatCurrency(*contract.FixedPrice, contract.Currency)))
+		termsItems = append(termsItems, locale.Fill(text.FixedPrice, "{price}", loc.Money(*contract.FixedPrice, contract.Currency)))
 	}
 
 	// Duration
 	if contract.EndDate != nil {
-		termsItems = append(termsItems, fmt.Sprintf("This agreement is effective from %s until %s.",
-			contract.StartDate.Format("January 2, 2006"), contract.EndDate.Format("January 2, 2006")))
+		termsItems = append(termsItems, locale.Fill(text.Period,
+			"{start}", loc.LongDate(contract.StartDate), "{end}", loc.LongDate(*contract.EndDate)))
 	} else {
-		termsItems = append(termsItems, fmt.Sprintf("This agreement is effective from %s and continues until terminated by either party.",
-			contract.StartDate.Format("January 2, 2006")))
+		termsItems = append(termsItems, locale.Fill(text.OpenEnded, "{start}", loc.LongDate(contract.StartDate)))
 	}
 
 	// Render terms as numbered list
@@ -152,12 +165,12 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 	// Notes section (if any)
 	if contract.Notes != "" {
 		pdf.Ln(5)
-		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetFont(font, "B", 12)
 		pdf.SetTextColor(40, 40, 40)
 		pdf.SetX(leftMargin)
-		pdf.Cell(contentWidth, 8, "Additional Terms")
+		pdf.Cell(contentWidth, 8, text.AdditionalTerms)
 		pdf.Ln(8)
-		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetFont(font, "", 10)
 		pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 		pdf.SetX(leftMargin)
 		pdf.MultiCell(contentWidth, 5, contract.Notes, "", "L", false)
@@ -166,20 +179,20 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 	// Payment Information
 	if company.BankAccount != "" {
 		pdf.Ln(8)
-		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetFont(font, "B", 12)
 		pdf.SetTextColor(40, 40, 40)
 		pdf.SetX(leftMargin)
-		pdf.Cell(contentWidth, 8, "Payment Information")
+		pdf.Cell(contentWidth, 8, text.PaymentInfo)
 		pdf.Ln(8)
-		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetFont(font, "", 10)
 		pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 		pdf.SetX(leftMargin)
 
 		bankInfo := ""
 		if company.BankName != "" {
-			bankInfo += fmt.Sprintf("Bank: %s\n", company.BankName)
+			bankInfo += fmt.Sprintf("%s: %s\n", loc.Text.Bank, company.BankName)
 		}
-		bankInfo += fmt.Sprintf("Account: %s", company.BankAccount)
+		bankInfo += fmt.Sprintf("%s: %s", loc.Text.Account, company.BankAccount)
 		if company.BankSWIFT != "" {
 			bankInfo += fmt.Sprintf("\nSWIFT: %s", company.BankSWIFT)
 		}
@@ -189,12 +202,12 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 	// General Terms
 	if cfg.Invoice.Terms != "" {
 		pdf.Ln(8)
-		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetFont(font, "B", 12)
 		pdf.SetTextColor(40, 40, 40)
 		pdf.SetX(leftMargin)
-		pdf.Cell(contentWidth, 8, "General Conditions")
+		pdf.Cell(contentWidth, 8, text.GeneralConditions)
 		pdf.Ln(8)
-		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetFont(font, "", 10)
 		pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 		pdf.SetX(leftMargin)
 		pdf.MultiCell(contentWidth, 5, cfg.Invoice.Terms, "", "L", false)
@@ -202,21 +215,17 @@ func GeneratePDF(contract models.Contract, company models.Company, client models
 
 	// Signature section
 	pdf.Ln(15)
-	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetX(leftMargin)
-	pdf.MultiCell(contentWidth, 5, "By signing below, both parties agree to the terms and conditions set forth in this agreement.", "", "L", false)
+	pdf.MultiCell(contentWidth, 5, text.Agreement, "", "L", false)
 
 	pdf.Ln(10)
-	drawSignatureLines(pdf, company.Name, client.Name, leftMargin, contentWidth, pdfCfg)
+	drawSignatureLines(pdf, font, text.Signature, company.Name, client.Name, leftMargin, contentWidth, pdfCfg)
 
 	// Save PDF
-	contractsDir := getContractsDir()
-	filename := fmt.Sprintf("%s_%s.pdf", client.Name, contract.Name)
-	filename = sanitizeFilename(filename)
-	pdfPath := filepath.Join(contractsDir, filename)
-
-	err := pdf.OutputFileAndClose(pdfPath)
+	pdfPath := contractPath(contract, client)
+	err = pdf.OutputFileAndClose(pdfPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to save PDF: %w", err)
 	}
@@ -271,7 +280,7 @@ func drawLogo(pdf *gofpdf.Fpdf, logoPath string, x, y float64) float64 {
 }
 
 // drawContractWatermark draws a diagonal watermark based on contract status
-func drawContractWatermark(pdf *gofpdf.Fpdf, active bool, cfg config.PDFConfig) {
+func drawContractWatermark(pdf *gofpdf.Fpdf, font string, active bool, cfg config.PDFConfig) {
 	var text string
 	var r, g, b int
 
@@ -289,7 +298,7 @@ func drawContractWatermark(pdf *gofpdf.Fpdf, active bool, cfg config.PDFConfig)
 
 	// Save current state
 	pdf.SetAlpha(0.08, "Normal")
-	pdf.SetFont("Helvetica", "B", 70)
+	pdf.SetFont(font, "B", 70)
 	pdf.SetTextColor(r, g, b)
 
 	// Calculate center position and draw rotated text
@@ -307,7 +316,7 @@ func drawContractWatermark(pdf *gofpdf.Fpdf, active bool, cfg config.PDFConfig)
 }
 
 // drawStatusBadge draws a colored status badge for contracts
-func drawStatusBadge(pdf *gofpdf.Fpdf, active bool, cfg config.PDFConfig) {
+func drawStatusBadge(pdf *gofpdf.Fpdf, font string, active bool, cfg config.PDFConfig) {
 	var text string
 	var r, g, b int
 
@@ -321,14 +330,14 @@ func drawStatusBadge(pdf *gofpdf.Fpdf, active bool, cfg config.PDFConfig) {
 
 	pdf.SetFillColor(r, g, b)
 	pdf.SetTextColor(255, 255, 255)
-	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFont(font, "B", 8)
 
 	width := pdf.GetStringWidth(text) + 8
 	pdf.CellFormat(width, 6, text, "", 0, "C", true, 0, "")
 }
 
 // drawSignatureLines draws signature lines for both parties
-func drawSignatureLines(pdf *gofpdf.Fpdf, companyName, clientName string, leftMargin, contentWidth float64, cfg config.PDFConfig) {
+func drawSignatureLines(pdf *gofpdf.Fpdf, font, signature, companyName, clientName string, leftMargin, contentWidth float64, cfg config.PDFConfig) {
 	lineWidth := contentWidth/2 - 10
 
 	// Company signature (left)
@@ -337,14 +346,14 @@ func drawSignatureLines(pdf *gofpdf.Fpdf, companyName, clientName string, leftMa
 	pdf.Line(leftMargin, pdf.GetY(), leftMargin+lineWidth, pdf.GetY())
 	pdf.Ln(2)
 	pdf.SetX(leftMargin)
-	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetFont(font, "", 9)
 	pdf.SetTextColor(cfg.TextColor.R, cfg.TextColor.G, cfg.TextColor.B)
 	pdf.Cell(lineWidth, 5, companyName)
 	pdf.Ln(4)
 	pdf.SetX(leftMargin)
-	pdf.SetFont("Helvetica", "I", 8)
+	pdf.SetFont(font, "I", 8)
 	pdf.SetTextColor(128, 128, 128)
-	pdf.Cell(lineWidth, 5, "Signature & Date")
+	pdf.Cell(lineWidth, 5, signature)
 
 	// Client signature (right)
 	clientStartX := leftMargin + contentWidth/2 + 10
@@ -352,14 +361,14 @@ func drawSignatureLines(pdf *gofpdf.Fpdf, companyName, clientName string, leftMa
 	pdf.Line(clientStartX, pdf.GetY(), clientStartX+lineWidth, pdf.GetY())
 	pdf.Ln(2)
 	pdf.SetX(clientStartX)
-	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetFont(font, "", 9)
 	pdf.SetTextColor(cfg.TextColor.R, cfg.TextColor.G, cfg.TextColor.B)
 	pdf.Cell(lineWidth, 5, clientName)
 	pdf.Ln(4)
 	pdf.SetX(clientStartX)
-	pdf.SetFont("Helvetica", "I", 8)
+	pdf.SetFont(font, "I", 8)
 	pdf.SetTextColor(128, 128, 128)
-	pdf.Cell(lineWidth, 5, "Signature & Date")
+	pdf.Cell(lineWidth, 5, signature)
 }
 
 // formatContractType returns a human-readable contract type
@@ -376,12 +385,19 @@ func formatContractType(ct models.ContractType) string {
 	}
 }
 
-// truncateString truncates a string to a maximum length
+// truncateString truncates a string to a maximum number of characters
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	return string(runes[:maxLen-3]) + "..."
+}
+
+// contractPath returns the PDF path of a contract
+func contractPath(contract models.Contract, client models.Client) string {
+	filename := sanitizeFilename(fmt.Sprintf("%s_%s.pdf", client.Name, contract.Name))
+	return filepath.Join(getContractsDir(), filename)
 }
 
 // getContractsDir returns the contracts directory path
@@ -5,8 +5,8 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/config"
-	"github.com/Andriiklymiuk/ung/internal/models"
 	"github.com/Andriiklymiuk/ung/pkg/invoice"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 // TestFormatContractType tests the contract type formatting
@@ -0,0 +1,319 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// GuidelineEN16931 identifies the EN 16931 profile of CII and Factur-X
+const GuidelineEN16931 = "urn:cen.eu:en16931:2017"
+
+type ciiInvoice struct {
+	XMLName     xml.Name       `xml:"rsm:CrossIndustryInvoice"`
+	Rsm         string         `xml:"xmlns:rsm,attr"`
+	Ram         string         `xml:"xmlns:ram,attr"`
+	Udt         string         `xml:"xmlns:udt,attr"`
+	Qdt         string         `xml:"xmlns:qdt,attr"`
+	Context     ciiContext     `xml:"rsm:ExchangedDocumentContext"`
+	Document    ciiDocument    `xml:"rsm:ExchangedDocument"`
+	Transaction ciiTransaction `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type ciiContext struct {
+	GuidelineID string `xml:"ram:GuidelineSpecifiedDocumentContextParameter>ram:ID"`
+}
+
+type ciiDocument struct {
+	ID        string   `xml:"ram:ID"`
+	TypeCode  string   `xml:"ram:TypeCode"`
+	IssueDate ciiDate  `xml:"ram:IssueDateTime"`
+	Notes     []string `xml:"ram:IncludedNote>ram:Content"`
+}
+
+type ciiDate struct {
+	Value ciiDateString `xml:"udt:DateTimeString"`
+}
+
+type ciiDateString struct {
+	Format string `xml:"format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiTransaction struct {
+	Lines      []ciiLine     `xml:"ram:IncludedSupplyChainTradeLineItem"`
+	Agreement  ciiAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   struct{}      `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement ciiSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+}
+
+type ciiLine struct {
+	LineID     string            `xml:"ram:AssociatedDocumentLineDocument>ram:LineID"`
+	Product    ciiProduct        `xml:"ram:SpecifiedTradeProduct"`
+	NetPrice   string            `xml:"ram:SpecifiedLineTradeAgreement>ram:NetPriceProductTradePrice>ram:ChargeAmount"`
+	Quantity   ciiQuantity       `xml:"ram:SpecifiedLineTradeDelivery>ram:BilledQuantity"`
+	Settlement ciiLineSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type ciiProduct struct {
+	Name        string `xml:"ram:Name"`
+	Description string `xml:"ram:Description,omitempty"`
+}
+
+type ciiQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiLineSettlement struct {
+	Tax       ciiTax        `xml:"ram:ApplicableTradeTax"`
+	Allowance *ciiAllowance `xml:"ram:SpecifiedTradeAllowanceCharge"`
+	LineTotal string        `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation>ram:LineTotalAmount"`
+}
+
+type ciiTax struct {
+	CalculatedAmount string  `xml:"ram:CalculatedAmount,omitempty"`
+	TypeCode         string  `xml:"ram:TypeCode"`
+	ExemptionReason  string  `xml:"ram:ExemptionReason,omitempty"`
+	BasisAmount      string  `xml:"ram:BasisAmount,omitempty"`
+	CategoryCode     string  `xml:"ram:CategoryCode"`
+	ExemptionCode    string  `xml:"ram:ExemptionReasonCode,omitempty"`
+	Percent          *string `xml:"ram:RateApplicablePercent"`
+}
+
+type ciiAllowance struct {
+	ChargeIndicator bool   `xml:"ram:ChargeIndicator>udt:Indicator"`
+	Percent         string `xml:"ram:CalculationPercent,omitempty"`
+	BasisAmount     string `xml:"ram:BasisAmount,omitempty"`
+	ActualAmount    string `xml:"ram:ActualAmount"`
+	ReasonCode      string `xml:"ram:ReasonCode"`
+	Reason          string `xml:"ram:Reason"`
+}
+
+type ciiAgreement struct {
+	BuyerReference string         `xml:"ram:BuyerReference,omitempty"`
+	Seller         ciiParty       `xml:"ram:SellerTradeParty"`
+	Buyer          ciiParty       `xml:"ram:BuyerTradeParty"`
+	BuyerOrder     *ciiReferenced `xml:"ram:BuyerOrderReferencedDocument"`
+}
+
+// ciiReferenced references another document, e.g. the buyer's order
+type ciiReferenced struct {
+	ID string `xml:"ram:IssuerAssignedID"`
+}
+
+type ciiParty struct {
+	Name              string               `xml:"ram:Name"`
+	LegalOrganization *ciiLegal            `xml:"ram:SpecifiedLegalOrganization"`
+	Contact           *ciiContact          `xml:"ram:DefinedTradeContact"`
+	Address           ciiAddress           `xml:"ram:PostalTradeAddress"`
+	URI               *ciiSchemeID         `xml:"ram:URIUniversalCommunication>ram:URIID"`
+	TaxRegistrations  []ciiTaxRegistration `xml:"ram:SpecifiedTaxRegistration"`
+}
+
+type ciiLegal struct {
+	ID string `xml:"ram:ID"`
+}
+
+type ciiContact struct {
+	Phone *ciiPhone `xml:"ram:TelephoneUniversalCommunication"`
+	Email *ciiEmail `xml:"ram:EmailURIUniversalCommunication"`
+}
+
+type ciiPhone struct {
+	Number string `xml:"ram:CompleteNumber"`
+}
+
+type ciiEmail struct {
+	URI string `xml:"ram:URIID"`
+}
+
+type ciiAddress struct {
+	PostcodeCode string `xml:"ram:PostcodeCode,omitempty"`
+	LineOne      string `xml:"ram:LineOne,omitempty"`
+	LineTwo      string `xml:"ram:LineTwo,omitempty"`
+	CityName     string `xml:"ram:CityName,omitempty"`
+	CountryID    string `xml:"ram:CountryID"`
+}
+
+type ciiSchemeID struct {
+	SchemeID string `xml:"schemeID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiTaxRegistration struct {
+	ID ciiSchemeID `xml:"ram:ID"`
+}
+
+type ciiSettlement struct {
+	PaymentReference string           `xml:"ram:PaymentReference,omitempty"`
+	Currency         string           `xml:"ram:InvoiceCurrencyCode"`
+	PaymentMeans     *ciiPaymentMeans `xml:"ram:SpecifiedTradeSettlementPaymentMeans"`
+	Taxes            []ciiTax         `xml:"ram:ApplicableTradeTax"`
+	PaymentTerms     *ciiPaymentTerms `xml:"ram:SpecifiedTradePaymentTerms"`
+	Summation        ciiSummation     `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+}
+
+type ciiPaymentMeans struct {
+	TypeCode    string     `xml:"ram:TypeCode"`
+	Account     ciiAccount `xml:"ram:PayeePartyCreditorFinancialAccount"`
+	Institution *ciiBIC    `xml:"ram:PayeeSpecifiedCreditorFinancialInstitution"`
+}
+
+type ciiAccount struct {
+	IBAN          string `xml:"ram:IBANID,omitempty"`
+	AccountName   string `xml:"ram:AccountName,omitempty"`
+	ProprietaryID string `xml:"ram:ProprietaryID,omitempty"`
+}
+
+type ciiBIC struct {
+	BIC string `xml:"ram:BICID"`
+}
+
+type ciiPaymentTerms struct {
+	DueDate ciiDate `xml:"ram:DueDateDateTime"`
+}
+
+type ciiSummation struct {
+	LineTotal     string      `xml:"ram:LineTotalAmount"`
+	TaxBasisTotal string      `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotal      ciiCurrency `xml:"ram:TaxTotalAmount"`
+	GrandTotal    string      `xml:"ram:GrandTotalAmount"`
+	DuePayable    string      `xml:"ram:DuePayableAmount"`
+}
+
+type ciiCurrency struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// CII returns the document as a UN/CEFACT Cross Industry Invoice in the
+// EN 16931 profile, which is also the XML inside a Factur-X PDF
+func CII(doc *Document) ([]byte, error) {
+	inv := ciiInvoice{
+		Rsm:     "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100",
+		Ram:     "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100",
+		Udt:     "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100",
+		Qdt:     "urn:un:unece:uncefact:data:standard:QualifiedDataType:100",
+		Context: ciiContext{GuidelineID: GuidelineEN16931},
+		Document: ciiDocument{
+			ID:        doc.Number,
+			TypeCode:  "380",
+			IssueDate: ciiDateOf(doc.IssueDate),
+		},
+	}
+	if doc.Note != "" {
+		inv.Document.Notes = []string{doc.Note}
+	}
+
+	for _, l := range doc.Lines {
+		line := ciiLine{
+			LineID:   l.ID,
+			Product:  ciiProduct{Name: l.Name, Description: l.Description},
+			NetPrice: amount(l.Price),
+			Quantity: ciiQuantity{UnitCode: UnitCode, Value: decimal(l.Quantity)},
+			Settlement: ciiLineSettlement{
+				Tax:       ciiTax{TypeCode: "VAT", CategoryCode: l.Category, Percent: ublPercent(l.Category, l.Percent)},
+				LineTotal: amount(l.Net),
+			},
+		}
+		if l.Discount != 0 {
+			line.Settlement.Allowance = &ciiAllowance{ActualAmount: amount(l.Discount), ReasonCode: "95", Reason: "Discount"}
+			if l.DiscountPct > 0 {
+				line.Settlement.Allowance.Percent = decimal(l.DiscountPct)
+				line.Settlement.Allowance.BasisAmount = amount(l.Gross)
+			}
+		}
+		inv.Transaction.Lines = append(inv.Transaction.Lines, line)
+	}
+
+	inv.Transaction.Agreement = ciiAgreement{
+		BuyerReference: doc.BuyerReference,
+		Seller:         ciiPartyOf(doc.Seller, true),
+		Buyer:          ciiPartyOf(doc.Buyer, false),
+	}
+	if doc.OrderReference != "" {
+		inv.Transaction.Agreement.BuyerOrder = &ciiReferenced{ID: doc.OrderReference}
+	}
+
+	settlement := &inv.Transaction.Settlement
+	settlement.PaymentReference = doc.Payment.Reference
+	settlement.Currency = doc.Currency
+	if p := doc.Payment; p.MeansCode != "" {
+		settlement.PaymentMeans = &ciiPaymentMeans{TypeCode: p.MeansCode, Account: ciiAccount{AccountName: p.BankName}}
+		if p.MeansCode == "58" {
+			settlement.PaymentMeans.Account.IBAN = p.Account
+		} else {
+			settlement.PaymentMeans.Account.ProprietaryID = p.Account
+		}
+		if p.BIC != "" {
+			settlement.PaymentMeans.Institution = &ciiBIC{BIC: p.BIC}
+		}
+	}
+	for _, t := range doc.Taxes {
+		settlement.Taxes = append(settlement.Taxes, ciiTax{
+			CalculatedAmount: amount(t.Tax),
+			TypeCode:         "VAT",
+			ExemptionReason:  t.ExemptionReason,
+			BasisAmount:      amount(t.Taxable),
+			CategoryCode:     t.Category,
+			ExemptionCode:    t.ExemptionCode,
+			Percent:          ublPercent(t.Category, t.Percent),
+		})
+	}
+	if !doc.DueDate.IsZero() {
+		settlement.PaymentTerms = &ciiPaymentTerms{DueDate: ciiDateOf(doc.DueDate)}
+	}
+	settlement.Summation = ciiSummation{
+		LineTotal:     amount(doc.LineTotal),
+		TaxBasisTotal: amount(doc.TaxExclusive),
+		TaxTotal:      ciiCurrency{Currency: doc.Currency, Value: amount(doc.TaxTotal)},
+		GrandTotal:    amount(doc.TaxInclusive),
+		DuePayable:    amount(doc.Payable),
+	}
+
+	out, err := xml.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write CII: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func ciiDateOf(t time.Time) ciiDate {
+	return ciiDate{Value: ciiDateString{Format: "102", Value: date(t, "20060102")}}
+}
+
+func ciiPartyOf(p Party, seller bool) ciiParty {
+	party := ciiParty{
+		Name: p.Name,
+		Address: ciiAddress{
+			PostcodeCode: p.PostalCode,
+			LineOne:      p.Street,
+			LineTwo:      p.Street2,
+			CityName:     p.City,
+			CountryID:    p.Country,
+		},
+	}
+	if p.Phone != "" || p.Email != "" {
+		party.Contact = &ciiContact{}
+		if p.Phone != "" {
+			party.Contact.Phone = &ciiPhone{Number: p.Phone}
+		}
+		if p.Email != "" {
+			party.Contact.Email = &ciiEmail{URI: p.Email}
+		}
+	}
+	if id, scheme := p.endpoint(); id != "" {
+		party.URI = &ciiSchemeID{SchemeID: scheme, Value: id}
+	}
+	if p.TaxID != "" && !seller {
+		party.LegalOrganization = &ciiLegal{ID: p.TaxID}
+	}
+	if p.VATID != "" {
+		party.TaxRegistrations = append(party.TaxRegistrations, ciiTaxRegistration{ID: ciiSchemeID{SchemeID: "VA", Value: p.VATID}})
+	}
+	if p.TaxID != "" && seller {
+		party.TaxRegistrations = append(party.TaxRegistrations, ciiTaxRegistration{ID: ciiSchemeID{SchemeID: "FC", Value: p.TaxID}})
+	}
+	return party
+}
@@ -0,0 +1,411 @@
+// Package einvoice writes invoices as structured e-invoices following the
+// European standard EN 16931:
+//
+//   - ubl: UBL 2.1 in the Peppol BIS Billing 3.0 flavour
+//   - cii: UN/CEFACT Cross Industry Invoice (D16B), EN 16931 profile
+//   - facturx: Factur-X / ZUGFeRD 2, the invoice PDF as PDF/A-3 with the CII
+//     XML embedded as factur-x.xml
+//
+// New maps an invoice, its line items, company and client to a Document,
+// the syntax-neutral model that Validate checks and UBL and CII serialize.
+package einvoice
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+)
+
+// Formats
+const (
+	FormatUBL     = "ubl"
+	FormatCII     = "cii"
+	FormatFacturX = "facturx"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatUBL, FormatCII, FormatFacturX}
+
+// VAT category codes (UNTDID 5305) used by ung
+const (
+	CategoryStandard      = "S"  // Taxed line, TaxRate > 0
+	CategoryReverseCharge = "AE" // Untaxed line between VAT registered parties in different countries
+	CategoryExempt        = "E"  // Untaxed line of a VAT registered seller
+	CategoryNotSubject    = "O"  // Untaxed line of a seller without VAT ID
+)
+
+// UnitCode is the unit of every line quantity, "one" (UN/ECE Rec 20)
+const UnitCode = "C62"
+
+// Document is an invoice in the terms of the EN 16931 semantic model. Amounts
+// are rounded to cents.
+type Document struct {
+	Number         string
+	IssueDate      time.Time
+	DueDate        time.Time
+	Currency       string
+	Note           string
+	BuyerReference string // Peppol needs one, the PO number or the invoice number
+	OrderReference string // The buyer's purchase order number
+	Seller         Party
+	Buyer          Party
+	Payment        Payment
+	Lines          []Line
+	Taxes          []TaxSubtotal
+	LineTotal      float64 // Sum of line net amounts
+	TaxExclusive   float64
+	TaxTotal       float64
+	TaxInclusive   float64
+	Payable        float64
+
+	taxType string // Type of the invoice's tax profile, see category
+}
+
+// Party is the seller or the buyer
+type Party struct {
+	Name       string
+	Email      string
+	Phone      string
+	Street     string
+	Street2    string
+	City       string
+	PostalCode string
+	Country    string // ISO 3166-1 alpha-2
+	VATID      string // Tax ID when it starts with a country prefix
+	TaxID      string // Any other tax or company registration number
+}
+
+// Payment is how the buyer pays
+type Payment struct {
+	MeansCode string // UNTDID 4461: 58 SEPA credit transfer, 30 credit transfer; empty without account
+	Account   string // IBAN for 58
+	BIC       string
+	BankName  string
+	Reference string // Remittance information, the invoice number
+}
+
+// Line is an invoice line
+type Line struct {
+	ID          string
+	Name        string
+	Description string
+	Quantity    float64
+	Price       float64 // Net price of one unit, before the line discount
+	Gross       float64 // Quantity × Price
+	Discount    float64 // Line allowance amount
+	DiscountPct float64 // 0 when Discount was given as an amount
+	Net         float64 // Gross − Discount
+	Category    string
+	Percent     float64 // VAT rate in percent
+}
+
+// TaxSubtotal is the VAT of all lines with the same category and rate
+type TaxSubtotal struct {
+	Category        string
+	Percent         float64
+	Taxable         float64
+	Tax             float64
+	ExemptionCode   string // VATEX code for untaxed categories
+	ExemptionReason string
+}
+
+// exemptions are the VATEX codes and reasons of untaxed categories
+var exemptions = map[string][2]string{
+	CategoryReverseCharge: {"VATEX-EU-AE", "Reverse charge"},
+	CategoryExempt:        {"VATEX-EU-132", "Exempt from VAT"},
+	CategoryNotSubject:    {"VATEX-EU-O", "Not subject to VAT"},
+}
+
+// New builds the document for an invoice. Without line items the invoice
+// amount becomes a single line.
+func New(invoice models.Invoice, company models.Company, client models.Client, items []models.InvoiceLineItem) *Document {
+	doc := &Document{
+		Number:         invoice.InvoiceNum,
+		IssueDate:      invoice.IssuedDate,
+		DueDate:        invoice.DueDate,
+		Currency:       strings.ToUpper(invoice.Currency),
+		Note:           invoice.Description,
+		BuyerReference: invoice.InvoiceNum,
+		OrderReference: invoice.PONumber,
+		Seller:         newParty(company.Name, company.Email, company.Phone, company.Address, company.TaxID),
+		Buyer:          newParty(client.Name, buyerEmail(client), "", client.AddressFor(models.AddressBilling), client.TaxID),
+		Payment:        newPayment(company, invoice.InvoiceNum),
+		taxType:        invoice.TaxType,
+	}
+	if invoice.PONumber != "" {
+		doc.BuyerReference = invoice.PONumber
+	}
+	if doc.Seller.Street == "" && doc.Seller.City == "" && company.RegistrationAddress != "" {
+		reg := newParty("", "", "", company.RegistrationAddress, company.TaxID)
+		doc.Seller.Street, doc.Seller.Street2, doc.Seller.City, doc.Seller.PostalCode, doc.Seller.Country =
+			reg.Street, reg.Street2, reg.City, reg.PostalCode, reg.Country
+	}
+
+	if len(items) == 0 {
+		items = []models.InvoiceLineItem{{ItemName: invoice.Description, Quantity: 1, Rate: invoice.Net(), Amount: invoice.Net()}}
+	}
+	for i, item := range items {
+		doc.Lines = append(doc.Lines, doc.newLine(i+1, item))
+	}
+	doc.calculate()
+	return doc
+}
+
+// newLine maps a line item; its discount works like on the PDF, a
+// percentage wins over an amount
+func (d *Document) newLine(n int, item models.InvoiceLineItem) Line {
+	line := Line{
+		ID:          strconv.Itoa(n),
+		Name:        item.ItemName,
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		Price:       round(item.Rate),
+		Percent:     round(item.TaxRate * 100),
+	}
+	if line.Name == "" {
+		line.Name = item.Description
+	}
+	if line.Quantity == 0 {
+		line.Quantity = 1
+	}
+	line.Gross = round(line.Quantity * line.Price)
+	line.Discount = round(item.Discount)
+	if item.DiscountPct > 0 {
+		line.DiscountPct = item.DiscountPct
+		line.Discount = round(line.Gross * item.DiscountPct / 100)
+	}
+	line.Net = round(line.Gross - line.Discount)
+	line.Category = d.category(line.Percent)
+	return line
+}
+
+// category picks the VAT category of a line with rate percent. Untaxed
+// lines follow the invoice's tax profile, or the parties without one.
+func (d *Document) category(percent float64) string {
+	switch {
+	case percent > 0:
+		return CategoryStandard
+	case d.taxType == tax.TypeReverseCharge:
+		return CategoryReverseCharge
+	case d.taxType == tax.TypeExempt:
+		return CategoryExempt
+	case d.taxType == tax.TypeNone:
+		return CategoryNotSubject
+	case d.Seller.VATID == "":
+		return CategoryNotSubject
+	case d.Buyer.VATID != "" && d.Seller.Country != "" && d.Buyer.Country != "" && d.Seller.Country != d.Buyer.Country:
+		return CategoryReverseCharge
+	default:
+		return CategoryExempt
+	}
+}
+
+// calculate fills the VAT breakdown and the document totals
+func (d *Document) calculate() {
+	index := map[string]int{}
+	for _, line := range d.Lines {
+		key := fmt.Sprintf("%s/%g", line.Category, line.Percent)
+		i, ok := index[key]
+		if !ok {
+			i = len(d.Taxes)
+			index[key] = i
+			sub := TaxSubtotal{Category: line.Category, Percent: line.Percent}
+			if ex, ok := exemptions[line.Category]; ok {
+				sub.ExemptionCode, sub.ExemptionReason = ex[0], ex[1]
+			}
+			d.Taxes = append(d.Taxes, sub)
+		}
+		d.Taxes[i].Taxable = round(d.Taxes[i].Taxable + line.Net)
+		d.LineTotal = round(d.LineTotal + line.Net)
+	}
+	for i := range d.Taxes {
+		d.Taxes[i].Tax = round(d.Taxes[i].Taxable * d.Taxes[i].Percent / 100)
+		d.TaxTotal = round(d.TaxTotal + d.Taxes[i].Tax)
+	}
+	d.TaxExclusive = d.LineTotal
+	d.TaxInclusive = round(d.TaxExclusive + d.TaxTotal)
+	d.Payable = d.TaxInclusive
+}
+
+var (
+	ibanPattern    = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	vatPattern     = regexp.MustCompile(`^[A-Z]{2}[0-9A-Z+*]{2,13}$`)
+	postcodeBefore = regexp.MustCompile(`^(?:[A-Z]{1,2}-)?(\d{4}\s?[A-Z]{2}|\d{2}-\d{3}|\d{3}\s\d{2}|\d{4,5})\s+(.+)$`)
+	postcodeAfter  = regexp.MustCompile(`^(.+?)\s+([A-Z]{1,2}\d[A-Z\d]?\s?\d[A-Z]{2}|\d{5}(?:-\d{4})?)$`)
+)
+
+func newPayment(company models.Company, reference string) Payment {
+	p := Payment{BIC: strings.ToUpper(strings.ReplaceAll(company.BankSWIFT, " ", "")), BankName: company.BankName, Reference: reference}
+	account := strings.TrimSpace(company.BankAccount)
+	if iban := strings.ToUpper(strings.ReplaceAll(account, " ", "")); ibanPattern.MatchString(iban) {
+		p.MeansCode, p.Account = "58", iban
+	} else if account != "" {
+		p.MeansCode, p.Account = "30", account
+	}
+	return p
+}
+
+// buyerEmail returns the email of the client's billing contact, otherwise
+// the client's
+func buyerEmail(client models.Client) string {
+	for _, contact := range client.ContactsFor(models.ContactBilling) {
+		if contact.Email != "" {
+			return contact.Email
+		}
+	}
+	return client.Email
+}
+
+// newParty splits a free-form address such as "Hauptstr. 1, 10115 Berlin,
+// Germany" into its parts. The country comes from the last address line, or
+// from the prefix of a VAT ID.
+func newParty(name, email, phone, address, taxID string) Party {
+	p := Party{Name: strings.TrimSpace(name), Email: strings.TrimSpace(email), Phone: strings.TrimSpace(phone)}
+
+	id := strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(taxID))
+	if country := vatCountry(id); country != "" && vatPattern.MatchString(id) {
+		p.VATID, p.Country = id, country
+	} else {
+		p.TaxID = strings.TrimSpace(taxID)
+	}
+
+	var parts []string
+	for _, part := range strings.FieldsFunc(address, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if n := len(parts); n > 0 {
+		if country := countryCode(parts[n-1]); country != "" {
+			p.Country = country
+			parts = parts[:n-1]
+		}
+	}
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		if m := postcodeBefore.FindStringSubmatch(parts[i]); m != nil {
+			p.PostalCode, p.City = m[1], m[2]
+		} else if m := postcodeAfter.FindStringSubmatch(parts[i]); m != nil && i > 0 {
+			p.City, p.PostalCode = m[1], m[2]
+		} else {
+			continue
+		}
+		parts = append(parts[:i], parts[i+1:]...)
+		break
+	}
+	if p.City == "" && len(parts) > 1 {
+		p.City = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 0 {
+		p.Street = parts[0]
+	}
+	if len(parts) > 1 {
+		p.Street2 = strings.Join(parts[1:], ", ")
+	}
+	return p
+}
+
+// vatCountry returns the country of a VAT ID's prefix, or "" when it has
+// none. Greece uses EL and Northern Ireland XI.
+func vatCountry(id string) string {
+	if len(id) < 2 {
+		return ""
+	}
+	switch prefix := id[:2]; prefix {
+	case "EL":
+		return "GR"
+	case "XI":
+		return "GB"
+	default:
+		if _, ok := countries[prefix]; ok {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// countryCode recognises an ISO code or a country name
+func countryCode(s string) string {
+	if upper := strings.ToUpper(s); len(upper) == 2 {
+		if _, ok := countries[upper]; ok {
+			return upper
+		}
+	}
+	return countryNames[strings.ToLower(s)]
+}
+
+// countries are the ISO codes ung knows, with their Peppol EAS scheme for
+// VAT numbers where there is one
+var countries = map[string]string{
+	"AT": "9914", "BE": "9925", "BG": "9926", "CH": "9927", "CY": "9928",
+	"CZ": "9929", "DE": "9930", "EE": "9931", "GB": "9932", "GR": "9933",
+	"HR": "9934", "IE": "9935", "LT": "9937", "LU": "9938", "LV": "9939",
+	"MT": "9943", "NL": "9944", "PL": "9945", "PT": "9946", "RO": "9947",
+	"SI": "9949", "SK": "9950", "ES": "9920", "HU": "9910", "FR": "9957",
+	"IT": "0211", "DK": "", "FI": "", "SE": "", "NO": "", "IS": "", "LI": "",
+	"UA": "", "US": "", "CA": "", "AU": "", "NZ": "", "JP": "", "IN": "",
+	"IL": "", "TR": "", "RS": "", "MD": "", "GE": "", "AE": "", "SG": "",
+	"BR": "", "MX": "", "ZA": "", "CN": "", "KR": "", "HK": "", "TH": "",
+}
+
+var countryNames = map[string]string{
+	"austria": "AT", "österreich": "AT", "belgium": "BE", "belgië": "BE", "belgique": "BE",
+	"bulgaria": "BG", "switzerland": "CH", "schweiz": "CH", "suisse": "CH", "svizzera": "CH",
+	"cyprus": "CY", "czech republic": "CZ", "czechia": "CZ", "česko": "CZ",
+	"germany": "DE", "deutschland": "DE", "denmark": "DK", "danmark": "DK",
+	"estonia": "EE", "eesti": "EE", "spain": "ES", "españa": "ES", "finland": "FI", "suomi": "FI",
+	"france": "FR", "united kingdom": "GB", "uk": "GB", "great britain": "GB", "england": "GB",
+	"scotland": "GB", "wales": "GB", "greece": "GR", "croatia": "HR", "hrvatska": "HR",
+	"hungary": "HU", "magyarország": "HU", "ireland": "IE", "italy": "IT", "italia": "IT",
+	"liechtenstein": "LI", "lithuania": "LT", "lietuva": "LT", "luxembourg": "LU", "latvia": "LV",
+	"latvija": "LV", "malta": "MT", "netherlands": "NL", "the netherlands": "NL", "nederland": "NL",
+	"norway": "NO", "norge": "NO", "poland": "PL", "polska": "PL", "portugal": "PT",
+	"romania": "RO", "românia": "RO", "sweden": "SE", "sverige": "SE", "slovenia": "SI",
+	"slovenija": "SI", "slovakia": "SK", "slovensko": "SK", "ukraine": "UA", "україна": "UA",
+	"united states": "US", "united states of america": "US", "usa": "US", "canada": "CA",
+	"australia": "AU", "new zealand": "NZ", "japan": "JP", "india": "IN", "israel": "IL",
+	"turkey": "TR", "türkiye": "TR", "serbia": "RS", "moldova": "MD", "georgia": "GE",
+	"united arab emirates": "AE", "singapore": "SG", "iceland": "IS",
+}
+
+// endpoint returns the electronic address of a party for Peppol and its
+// EAS scheme: the VAT ID where the country has a VAT scheme, the email
+// otherwise
+func (p Party) endpoint() (id, scheme string) {
+	if p.VATID != "" {
+		if scheme := countries[vatCountry(p.VATID)]; scheme != "" {
+			return p.VATID, scheme
+		}
+	}
+	if p.Email != "" {
+		return p.Email, "EM"
+	}
+	return "", ""
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func decimal(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10000)/10000, 'f', -1, 64)
+}
+
+// date formats t, or returns "" for the zero time
+func date(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,298 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// sample is a German freelancer invoicing a French client, with a taxed
+// line, a line with a percentage discount and one with an amount discount
+func sample() (models.Invoice, models.Company, models.Client, []models.InvoiceLineItem) {
+	inv := models.Invoice{
+		InvoiceNum:  "INV-2024-007",
+		Amount:      2150,
+		Currency:    "eur",
+		Description: "Development in March",
+		IssuedDate:  time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		DueDate:     time.Date(2024, 4, 14, 0, 0, 0, 0, time.UTC),
+	}
+	company := models.Company{
+		Name:        "Müller Software",
+		Email:       "billing@mueller.example",
+		Phone:       "+49 30 1234567",
+		Address:     "Hauptstr. 1\n10115 Berlin\nGermany",
+		TaxID:       "DE 123 456 789",
+		BankName:    "Berliner Bank",
+		BankAccount: "DE89 3704 0044 0532 0130 00",
+		BankSWIFT:   "COBADEFFXXX",
+	}
+	client := models.Client{
+		Name:    "Acme SAS",
+		Email:   "ap@acme.example",
+		Address: "1 Rue de Rivoli, 75001 Paris, France",
+		TaxID:   "FR40303265045",
+	}
+	items := []models.InvoiceLineItem{
+		{ItemName: "Development", Description: "Backend work", Quantity: 10, Rate: 150, Amount: 1500, TaxRate: 0.19},
+		{ItemName: "Code review", Quantity: 4, Rate: 100, Amount: 400, DiscountPct: 10},
+		{ItemName: "Hosting", Quantity: 1, Rate: 250, Amount: 250, Discount: 50},
+	}
+	return inv, company, client, items
+}
+
+func TestNew(t *testing.T) {
+	doc := New(sample())
+
+	if doc.Currency != "EUR" {
+		t.Errorf("expected the currency in upper case, got %s", doc.Currency)
+	}
+	if doc.Seller.VATID != "DE123456789" || doc.Seller.Country != "DE" || doc.Seller.City != "Berlin" || doc.Seller.PostalCode != "10115" || doc.Seller.Street != "Hauptstr. 1" {
+		t.Errorf("unexpected seller %+v", doc.Seller)
+	}
+	if doc.Buyer.Country != "FR" || doc.Buyer.City != "Paris" {
+		t.Errorf("unexpected buyer %+v", doc.Buyer)
+	}
+	if doc.Payment.MeansCode != "58" || doc.Payment.Account != "DE89370400440532013000" {
+		t.Errorf("expected a SEPA transfer to the IBAN, got %+v", doc.Payment)
+	}
+
+	wantLines := []struct {
+		net      float64
+		discount float64
+		category string
+	}{
+		{1500, 0, CategoryStandard},
+		{360, 40, CategoryReverseCharge},
+		{200, 50, CategoryReverseCharge},
+	}
+	for i, want := range wantLines {
+		l := doc.Lines[i]
+		if l.Net != want.net || l.Discount != want.discount || l.Category != want.category {
+			t.Errorf("line %d: expected net %.2f, discount %.2f, category %s, got %.2f, %.2f, %s", i+1, want.net, want.discount, want.category, l.Net, l.Discount, l.Category)
+		}
+	}
+
+	if len(doc.Taxes) != 2 || doc.Taxes[0].Tax != 285 || doc.Taxes[1].Taxable != 560 || doc.Taxes[1].ExemptionCode != "VATEX-EU-AE" {
+		t.Errorf("unexpected VAT breakdown %+v", doc.Taxes)
+	}
+	if doc.LineTotal != 2060 || doc.TaxTotal != 285 || doc.TaxInclusive != 2345 || doc.Payable != 2345 {
+		t.Errorf("unexpected totals: lines %.2f, VAT %.2f, total %.2f, payable %.2f", doc.LineTotal, doc.TaxTotal, doc.TaxInclusive, doc.Payable)
+	}
+}
+
+func TestNewWithoutLines(t *testing.T) {
+	inv, company, client, _ := sample()
+	company.TaxID = ""
+	doc := New(inv, company, client, nil)
+
+	if len(doc.Lines) != 1 || doc.Lines[0].Name != inv.Description || doc.Lines[0].Net != inv.Amount {
+		t.Errorf("expected the invoice amount as the only line, got %+v", doc.Lines)
+	}
+	if doc.Lines[0].Category != CategoryNotSubject {
+		t.Errorf("expected a seller without VAT ID to be not subject to VAT, got %s", doc.Lines[0].Category)
+	}
+}
+
+func TestNewWithBillingProfile(t *testing.T) {
+	inv, company, client, items := sample()
+	inv.PONumber = "PO-4711"
+	client.Contacts = []models.ClientContact{{Email: "lead@acme.example", Role: models.ContactTechnical}, {Email: "invoices@acme.example", Role: models.ContactBilling}}
+	client.Addresses = []models.ClientAddress{{Kind: models.AddressBilling, Address: "BP 12, 69001 Lyon, France"}}
+	doc := New(inv, company, client, items)
+
+	if doc.BuyerReference != "PO-4711" || doc.OrderReference != "PO-4711" {
+		t.Errorf("expected the PO number as buyer and order reference, got %q / %q", doc.BuyerReference, doc.OrderReference)
+	}
+	if doc.Buyer.Email != "invoices@acme.example" || doc.Buyer.City != "Lyon" {
+		t.Errorf("expected the billing contact and address, got %+v", doc.Buyer)
+	}
+
+	ubl, err := UBL(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(ubl, []byte("<cac:OrderReference>")) || !bytes.Contains(ubl, []byte("<cbc:ID>PO-4711</cbc:ID>")) {
+		t.Errorf("expected an order reference in UBL:\n%s", ubl)
+	}
+	cii, err := CII(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wellFormed(t, cii)
+	if !bytes.Contains(cii, []byte("<ram:IssuerAssignedID>PO-4711</ram:IssuerAssignedID>")) {
+		t.Errorf("expected a buyer order reference in CII:\n%s", cii)
+	}
+}
+
+func TestParty(t *testing.T) {
+	tests := []struct {
+		address, taxID string
+		want           Party
+	}{
+		{"Keizersgracht 1, 1015 CJ Amsterdam, Nederland", "", Party{Street: "Keizersgracht 1", PostalCode: "1015 CJ", City: "Amsterdam", Country: "NL"}},
+		{"ul. Marszałkowska 1\n00-950 Warszawa", "PL1234567890", Party{Street: "ul. Marszałkowska 1", PostalCode: "00-950", City: "Warszawa", Country: "PL", VATID: "PL1234567890"}},
+		{"10 Downing Street, London SW1A 2AA, UK", "", Party{Street: "10 Downing Street", City: "London", PostalCode: "SW1A 2AA", Country: "GB"}},
+		{"Bahnhofstrasse 1, CH-8001 Zürich", "EL094259216", Party{Street: "Bahnhofstrasse 1", PostalCode: "8001", City: "Zürich", Country: "GR", VATID: "EL094259216"}},
+		{"вул. Хрещатик 1, Київ, Україна", "1234567890", Party{Street: "вул. Хрещатик 1", City: "Київ", Country: "UA", TaxID: "1234567890"}},
+		{"", "", Party{}},
+	}
+	for _, tt := range tests {
+		if got := newParty("", "", "", tt.address, tt.taxID); got != tt.want {
+			t.Errorf("%q: expected %+v, got %+v", tt.address, tt.want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(New(sample()), FormatUBL); err != nil {
+		t.Fatalf("expected the sample to be valid, got %v", err)
+	}
+
+	inv, company, client, items := sample()
+	company.Address = "Hauptstr. 1, 10115 Berlin"
+	company.TaxID = "12/345/67890"
+	client.Address = "Somewhere"
+	client.TaxID = ""
+	client.Email = ""
+	inv.Currency = "euro"
+	inv.DueDate = time.Time{}
+	items[1].ItemName = ""
+
+	err := Validate(New(inv, company, client, items), FormatUBL)
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	var rules []string
+	for _, v := range verr {
+		rules = append(rules, v.Rule)
+	}
+	got := strings.Join(rules, " ")
+	for _, rule := range []string{"BR-05", "BR-09", "BR-11", "BR-25", "BR-CO-25", "PEPPOL-EN16931-R010"} {
+		if !strings.Contains(got, rule) {
+			t.Errorf("expected %s to be broken, got %s", rule, got)
+		}
+	}
+	if strings.Contains(got, "BR-S-02") {
+		t.Error("expected a tax number to be enough for taxed lines")
+	}
+
+	// The Peppol rules don't apply to CII
+	if err := Validate(New(inv, company, client, items), FormatCII); strings.Contains(err.Error(), "PEPPOL") {
+		t.Errorf("expected no Peppol rules for CII, got %v", err)
+	}
+}
+
+func TestValidateWith(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands need a Unix shell")
+	}
+	path := filepath.Join("testdata", "invoice.cii.xml")
+
+	if err := ValidateWith("grep -q CrossIndustryInvoice", path); err != nil {
+		t.Errorf("expected the validator to accept the file, got %v", err)
+	}
+	err := ValidateWith("echo 'BR-CO-10 failed' && false", path)
+	var xerr *ExternalError
+	if !errors.As(err, &xerr) || xerr.Output != "BR-CO-10 failed" {
+		t.Errorf("expected the validator's report, got %v", err)
+	}
+}
+
+func TestUBLAndCII(t *testing.T) {
+	doc := New(sample())
+	for _, tt := range []struct {
+		golden string
+		write  func(*Document) ([]byte, error)
+	}{
+		{"invoice.ubl.xml", UBL},
+		{"invoice.cii.xml", CII},
+	} {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := tt.write(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wellFormed(t, got)
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s (run with -update after an intended change):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestNotSubjectHasNoRate(t *testing.T) {
+	inv, company, client, items := sample()
+	company.TaxID = ""
+	items[0].TaxRate = 0
+	doc := New(inv, company, client, items)
+
+	ubl, _ := UBL(doc)
+	cii, _ := CII(doc)
+	if bytes.Contains(ubl, []byte("<cbc:Percent>")) || bytes.Contains(cii, []byte("RateApplicablePercent")) {
+		t.Error("expected category O without a VAT rate (BR-O-05)")
+	}
+	if !bytes.Contains(ubl, []byte(`<cbc:EndpointID schemeID="EM">billing@mueller.example</cbc:EndpointID>`)) {
+		t.Error("expected the email as electronic address of a seller without VAT ID")
+	}
+}
+
+func TestCategoryFollowsTaxProfile(t *testing.T) {
+	tests := map[string]string{
+		tax.TypeReverseCharge: CategoryReverseCharge,
+		tax.TypeExempt:        CategoryExempt,
+		tax.TypeNone:          CategoryNotSubject,
+	}
+	for taxType, want := range tests {
+		inv, company, client, items := sample()
+		inv.TaxType = taxType
+		// A buyer in the same country would be exempt without a profile
+		client.Address = "Friedrichstr. 10, 10117 Berlin, Germany"
+		client.TaxID = "DE 987 654 321"
+		doc := New(inv, company, client, items)
+		if got := doc.Lines[1].Category; got != want {
+			t.Errorf("%s: expected category %s for an untaxed line, got %s", taxType, want, got)
+		}
+		if got := doc.Lines[0].Category; got != CategoryStandard {
+			t.Errorf("%s: expected a taxed line to stay %s, got %s", taxType, CategoryStandard, got)
+		}
+	}
+}
+
+// wellFormed fails when data isn't well-formed XML
+func wellFormed(t *testing.T, data []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("not well-formed XML: %v", err)
+		}
+	}
+}
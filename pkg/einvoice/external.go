@@ -0,0 +1,45 @@
+package einvoice
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ExternalError is returned when an external validator rejects a file
+type ExternalError struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func (e *ExternalError) Error() string {
+	return fmt.Sprintf("e-invoice validator %q rejected the file: %v", e.Command, e.Err)
+}
+
+func (e *ExternalError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateWith runs an external validator on an exported file, for checks
+// the built-in rules don't cover: the official EN 16931 XSD and Schematron
+// files (e.g. the KoSIT validator) or PDF/A conformance of Factur-X files
+// (e.g. veraPDF). command runs through the shell with the file's path as its
+// last argument; a non-zero exit status means the file is invalid.
+func ValidateWith(command, path string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command+` "`+path+`"`)
+	} else {
+		cmd = exec.Command("sh", "-c", command+` "$1"`, "ung", path)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return &ExternalError{Command: command, Output: strings.TrimSpace(out.String()), Err: err}
+	}
+	return nil
+}
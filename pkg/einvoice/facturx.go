@@ -0,0 +1,304 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf16"
+)
+
+// FacturXFilename is the name the CII XML must have inside a Factur-X PDF
+const FacturXFilename = "factur-x.xml"
+
+// PDFInfo is the document information of a Factur-X PDF
+type PDFInfo struct {
+	Title   string
+	Author  string
+	Created time.Time
+}
+
+var (
+	startxrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	xrefEntryPattern = regexp.MustCompile(`(\d{10}) (\d{5}) ([nf])`)
+	rootPattern      = regexp.MustCompile(`/Root (\d+) 0 R`)
+	sizePattern      = regexp.MustCompile(`/Size (\d+)`)
+	pagesPattern     = regexp.MustCompile(`/Pages (\d+) 0 R`)
+)
+
+// FacturX turns an invoice PDF written by gofpdf into a Factur-X PDF/A-3b
+// with data, the CII XML, attached as factur-x.xml. All fonts of the PDF
+// must be embedded.
+//
+// The objects of the original are kept; a new catalog, document info, XMP
+// metadata, sRGB output intent and the attachment are added and the cross
+// reference table is rewritten.
+func FacturX(pdf, data []byte, info PDFInfo) ([]byte, error) {
+	headerEnd := bytes.IndexByte(pdf, '\n') + 1
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.")) || headerEnd == 0 {
+		return nil, fmt.Errorf("not a PDF")
+	}
+	m := startxrefPattern.FindSubmatch(pdf)
+	if m == nil {
+		return nil, fmt.Errorf("PDF has no cross reference table")
+	}
+	xrefStart, _ := strconv.Atoi(string(m[1]))
+	if xrefStart <= headerEnd || xrefStart >= len(pdf) || !bytes.HasPrefix(pdf[xrefStart:], []byte("xref")) {
+		return nil, fmt.Errorf("PDF cross reference table not found at %d", xrefStart)
+	}
+	trailerStart := bytes.Index(pdf[xrefStart:], []byte("trailer"))
+	if trailerStart < 0 {
+		return nil, fmt.Errorf("PDF has no trailer")
+	}
+	trailer := pdf[xrefStart+trailerStart:]
+	if bytes.Contains(trailer, []byte("/Encrypt")) {
+		return nil, fmt.Errorf("encrypted PDFs can't be PDF/A")
+	}
+	root, size := rootPattern.FindSubmatch(trailer), sizePattern.FindSubmatch(trailer)
+	if root == nil || size == nil {
+		return nil, fmt.Errorf("PDF trailer has no /Root or /Size")
+	}
+	rootNum, _ := strconv.Atoi(string(root[1]))
+	next, _ := strconv.Atoi(string(size[1]))
+
+	// Offsets of the original objects, by object number
+	offsets := make([]int, next)
+	for i, e := range xrefEntryPattern.FindAllSubmatch(pdf[xrefStart:xrefStart+trailerStart], -1) {
+		if i > 0 && i < next && string(e[3]) == "n" {
+			offsets[i], _ = strconv.Atoi(string(e[1]))
+		}
+	}
+	if rootNum <= 0 || rootNum >= next || offsets[rootNum] == 0 {
+		return nil, fmt.Errorf("PDF catalog not found")
+	}
+	pages := pagesPattern.FindSubmatch(pdf[offsets[rootNum]:xrefStart])
+	if pages == nil {
+		return nil, fmt.Errorf("PDF catalog has no pages")
+	}
+
+	// PDF/A wants binary bytes in a comment right after the header, moving
+	// every object by the same distance
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	shift := out.Len() - headerEnd
+	out.Write(pdf[headerEnd:xrefStart])
+	for i := range offsets {
+		if offsets[i] > 0 {
+			offsets[i] += shift
+		}
+	}
+
+	object := func(body string, stream []byte) int {
+		offsets = append(offsets, out.Len())
+		n := len(offsets) - 1
+		fmt.Fprintf(&out, "%d 0 obj\n%s\n", n, body)
+		if stream != nil {
+			out.WriteString("stream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream\n")
+		}
+		out.WriteString("endobj\n")
+		return n
+	}
+
+	created := info.Created.UTC()
+	if created.IsZero() {
+		created = time.Now().UTC()
+	}
+	pdfDate := "D:" + created.Format("20060102150405") + "Z"
+	sum := md5.Sum(data)
+
+	file := object(fmt.Sprintf("<< /Type /EmbeddedFile /Subtype /text#2Fxml /Length %d /Params << /Size %d /ModDate (%s) /CheckSum <%s> >> >>",
+		len(data), len(data), pdfDate, hex.EncodeToString(sum[:])), data)
+	spec := object(fmt.Sprintf("<< /Type /Filespec /F (%s) /UF %s /Desc (Factur-X invoice) /AFRelationship /Data /EF << /F %d 0 R /UF %d 0 R >> >>",
+		FacturXFilename, pdfText(FacturXFilename), file, file), nil)
+	xmp := xmpMetadata(info, created)
+	metadata := object(fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>", len(xmp)), xmp)
+	icc := srgbProfile()
+	profile := object(fmt.Sprintf("<< /N 3 /Length %d >>", len(icc)), icc)
+	intent := object(fmt.Sprintf("<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier (sRGB IEC61966-2.1) /Info (sRGB IEC61966-2.1) /DestOutputProfile %d 0 R >>", profile), nil)
+	infoObj := object(fmt.Sprintf("<< /Title %s /Author %s /Creator (ung) /Producer (ung) /CreationDate (%s) /ModDate (%s) >>",
+		pdfText(info.Title), pdfText(info.Author), pdfDate, pdfDate), nil)
+	catalog := object(fmt.Sprintf("<< /Type /Catalog /Pages %s 0 R /Metadata %d 0 R /OutputIntents [%d 0 R] /AF [%d 0 R] /Names << /EmbeddedFiles << /Names [(%s) %d 0 R] >> >> >>",
+		pages[1], metadata, intent, spec, FacturXFilename, spec), nil)
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		if offset == 0 {
+			out.WriteString("0000000000 65535 f \n")
+		} else {
+			fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+		}
+	}
+	id := md5.Sum(append(append([]byte{}, pdf...), data...))
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R /ID [<%x> <%x>] >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets), catalog, infoObj, id, id, xrefOffset)
+	return out.Bytes(), nil
+}
+
+// pdfText encodes s as a UTF-16 hex string
+func pdfText(s string) string {
+	var b bytes.Buffer
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// xmpMetadata returns the PDF/A-3b identification, the document info and
+// the Factur-X extension schema
+func xmpMetadata(info PDFInfo, created time.Time) []byte {
+	esc := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	date := created.Format("2006-01-02T15:04:05Z")
+	return []byte(`<?xpacket begin="` + "\ufeff" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">
+   <pdfaid:part>3</pdfaid:part>
+   <pdfaid:conformance>B</pdfaid:conformance>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">` + esc(info.Title) + `</rdf:li></rdf:Alt></dc:title>
+   <dc:creator><rdf:Seq><rdf:li>` + esc(info.Author) + `</rdf:li></rdf:Seq></dc:creator>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/">
+   <pdf:Producer>ung</pdf:Producer>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/">
+   <xmp:CreatorTool>ung</xmp:CreatorTool>
+   <xmp:CreateDate>` + date + `</xmp:CreateDate>
+   <xmp:ModifyDate>` + date + `</xmp:ModifyDate>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:fx="urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#">
+   <fx:DocumentType>INVOICE</fx:DocumentType>
+   <fx:DocumentFileName>` + FacturXFilename + `</fx:DocumentFileName>
+   <fx:Version>1.0</fx:Version>
+   <fx:ConformanceLevel>EN 16931</fx:ConformanceLevel>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/" xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#" xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">
+   <pdfaExtension:schemas>
+    <rdf:Bag>
+     <rdf:li rdf:parseType="Resource">
+      <pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>
+      <pdfaSchema:namespaceURI>urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#</pdfaSchema:namespaceURI>
+      <pdfaSchema:prefix>fx</pdfaSchema:prefix>
+      <pdfaSchema:property>
+       <rdf:Seq>` + xmpProperty("DocumentFileName", "Name of the embedded XML invoice file") +
+		xmpProperty("DocumentType", "INVOICE") +
+		xmpProperty("Version", "Version of the Factur-X standard") +
+		xmpProperty("ConformanceLevel", "Factur-X profile of the embedded XML") + `
+       </rdf:Seq>
+      </pdfaSchema:property>
+     </rdf:li>
+    </rdf:Bag>
+   </pdfaExtension:schemas>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`)
+}
+
+func xmpProperty(name, description string) string {
+	return `
+        <rdf:li rdf:parseType="Resource">
+         <pdfaProperty:name>` + name + `</pdfaProperty:name>
+         <pdfaProperty:valueType>Text</pdfaProperty:valueType>
+         <pdfaProperty:category>external</pdfaProperty:category>
+         <pdfaProperty:description>` + description + `</pdfaProperty:description>
+        </rdf:li>`
+}
+
+// srgbProfile builds an ICC v2 display profile for sRGB: D50 adapted
+// primaries and the sRGB tone curve, for the PDF/A output intent
+func srgbProfile() []byte {
+	xyz := func(x, y, z float64) []byte {
+		b := []byte("XYZ \x00\x00\x00\x00")
+		for _, v := range []float64{x, y, z} {
+			b = binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(v*65536))))
+		}
+		return b
+	}
+	text := func(sig, s string) []byte {
+		b := append([]byte(sig+"\x00\x00\x00\x00"), s...)
+		return append(b, 0)
+	}
+	desc := []byte("desc\x00\x00\x00\x00")
+	desc = binary.BigEndian.AppendUint32(desc, uint32(len("sRGB IEC61966-2.1")+1))
+	desc = append(append(desc, "sRGB IEC61966-2.1"...), 0)
+	desc = append(desc, make([]byte, 4+4+2+1+67)...) // No Unicode or ScriptCode description
+
+	curve := []byte("curv\x00\x00\x00\x00")
+	curve = binary.BigEndian.AppendUint32(curve, 1024)
+	for i := 0; i < 1024; i++ {
+		v := float64(i) / 1023
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		curve = binary.BigEndian.AppendUint16(curve, uint16(math.Round(v*65535)))
+	}
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", desc},
+		{"cprt", text("text", "No copyright, use freely")},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", xyz(0.4360747, 0.2225045, 0.0139322)},
+		{"gXYZ", xyz(0.3850649, 0.7168786, 0.0971045)},
+		{"bXYZ", xyz(0.1430804, 0.0606169, 0.7141733)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	// Tag data follows the header and the tag table, 4-byte aligned; the
+	// three tone curves share their data
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	offset := 128 + 4 + 12*len(tags)
+	shared := map[string]int{}
+	for _, tag := range tags {
+		at, ok := shared[string(tag.data)]
+		if !ok {
+			at = offset + len(data)
+			shared[string(tag.data)] = at
+			data = append(data, tag.data...)
+			for len(data)%4 != 0 {
+				data = append(data, 0)
+			}
+		}
+		table = append(table, tag.sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(at))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(tag.data)))
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(128+len(table)+len(data)))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000) // Version 2.1
+	copy(header[12:], "mntrRGB XYZ ")
+	for i, v := range []uint16{2024, 1, 1, 0, 0, 0} {
+		binary.BigEndian.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	binary.BigEndian.PutUint32(header[68:], 0x0000F6D6) // D50 illuminant
+	binary.BigEndian.PutUint32(header[72:], 0x00010000)
+	binary.BigEndian.PutUint32(header[76:], 0x0000D32D)
+
+	return append(append(header, table...), data...)
+}
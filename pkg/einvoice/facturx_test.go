@@ -0,0 +1,130 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// sampleFacturX returns the sample invoice as Factur-X and its CII XML
+func sampleFacturX(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	data, err := CII(New(sample()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.Cell(40, 10, "Invoice INV-2024-007")
+	pdf.AddPage()
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := FacturX(buf.Bytes(), data, PDFInfo{Title: "Invoice INV-2024-007", Author: "Müller Software", Created: time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, data
+}
+
+func TestFacturX(t *testing.T) {
+	out, data := sampleFacturX(t)
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")) {
+		t.Errorf("expected a PDF 1.7 header with a binary comment, got %q", out[:16])
+	}
+
+	// Every object of the cross reference table is where it says
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("no startxref at the end")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d doesn't point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) < 10 {
+		t.Fatalf("expected the original and the new objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("object %d not found at %d", i+1, offset)
+		}
+	}
+
+	for _, want := range []string{
+		"/AFRelationship /Data",
+		"/Subtype /text#2Fxml",
+		"/F (factur-x.xml)",
+		"/OutputIntents [",
+		"/S /GTS_PDFA1",
+		"<pdfaid:part>3</pdfaid:part>",
+		"<fx:ConformanceLevel>EN 16931</fx:ConformanceLevel>",
+		"<dc:creator><rdf:Seq><rdf:li>Müller Software</rdf:li></rdf:Seq></dc:creator>",
+		"<xmp:CreateDate>2024-03-31T12:00:00Z</xmp:CreateDate>",
+		"/CreationDate (D:20240331120000Z)",
+		"/ID [<",
+	} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("expected %q in the PDF", want)
+		}
+	}
+	if !bytes.Contains(out, data) {
+		t.Error("expected the CII XML to be embedded as is")
+	}
+
+	if _, err := FacturX([]byte("not a pdf"), data, PDFInfo{}); err == nil {
+		t.Error("expected an error for something that isn't a PDF")
+	}
+}
+
+// TestFacturXIsPDFA3 checks the output with veraPDF, the reference PDF/A
+// validator, when it's installed
+func TestFacturXIsPDFA3(t *testing.T) {
+	verapdf, err := exec.LookPath("verapdf")
+	if err != nil {
+		t.Skip("verapdf is not installed")
+	}
+	out, _ := sampleFacturX(t)
+	path := filepath.Join(t.TempDir(), "INV-2024-007.facturx.pdf")
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, _ := exec.Command(verapdf, "--flavour", "3b", "--format", "text", path).CombinedOutput()
+	if !bytes.HasPrefix(bytes.TrimSpace(report), []byte("PASS")) {
+		t.Errorf("expected the Factur-X PDF to pass PDF/A-3b validation:\n%s", report)
+	}
+}
+
+func TestSRGBProfile(t *testing.T) {
+	icc := srgbProfile()
+	if size := binary.BigEndian.Uint32(icc); int(size) != len(icc) {
+		t.Errorf("profile size %d doesn't match its length %d", size, len(icc))
+	}
+	if string(icc[36:40]) != "acsp" || string(icc[12:24]) != "mntrRGB XYZ " {
+		t.Error("unexpected profile header")
+	}
+	count := int(binary.BigEndian.Uint32(icc[128:]))
+	for i := 0; i < count; i++ {
+		entry := icc[132+12*i:]
+		offset, size := binary.BigEndian.Uint32(entry[4:]), binary.BigEndian.Uint32(entry[8:])
+		if offset%4 != 0 || int(offset+size) > len(icc) {
+			t.Errorf("tag %s at %d+%d is misplaced", entry[:4], offset, size)
+		}
+	}
+}
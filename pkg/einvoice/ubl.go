@@ -0,0 +1,309 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Peppol BIS Billing 3.0 identifiers
+const (
+	PeppolCustomizationID = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+	PeppolProfileID       = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+)
+
+// The UBL elements are named with their prefixes, declared on the root
+type ublInvoice struct {
+	XMLName                 xml.Name         `xml:"Invoice"`
+	Xmlns                   string           `xml:"xmlns,attr"`
+	Cac                     string           `xml:"xmlns:cac,attr"`
+	Cbc                     string           `xml:"xmlns:cbc,attr"`
+	CustomizationID         string           `xml:"cbc:CustomizationID"`
+	ProfileID               string           `xml:"cbc:ProfileID"`
+	ID                      string           `xml:"cbc:ID"`
+	IssueDate               string           `xml:"cbc:IssueDate"`
+	DueDate                 string           `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode         string           `xml:"cbc:InvoiceTypeCode"`
+	Note                    string           `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode    string           `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference          string           `xml:"cbc:BuyerReference,omitempty"`
+	OrderReference          *ublOrderRef     `xml:"cac:OrderReference"`
+	AccountingSupplierParty ublPartyWrapper  `xml:"cac:AccountingSupplierParty"`
+	AccountingCustomerParty ublPartyWrapper  `xml:"cac:AccountingCustomerParty"`
+	PaymentMeans            *ublPaymentMeans `xml:"cac:PaymentMeans"`
+	TaxTotal                ublTaxTotal      `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal      ublMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines            []ublLine        `xml:"cac:InvoiceLine"`
+}
+
+type ublOrderRef struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublID struct {
+	SchemeID string `xml:"schemeID,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type ublPartyWrapper struct {
+	Party ublParty `xml:"cac:Party"`
+}
+
+type ublParty struct {
+	EndpointID       *ublID         `xml:"cbc:EndpointID"`
+	PartyName        ublName        `xml:"cac:PartyName"`
+	PostalAddress    ublAddress     `xml:"cac:PostalAddress"`
+	PartyTaxSchemes  []ublPartyTax  `xml:"cac:PartyTaxScheme"`
+	PartyLegalEntity ublLegalEntity `xml:"cac:PartyLegalEntity"`
+	Contact          *ublContact    `xml:"cac:Contact"`
+}
+
+type ublName struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type ublAddress struct {
+	StreetName           string     `xml:"cbc:StreetName,omitempty"`
+	AdditionalStreetName string     `xml:"cbc:AdditionalStreetName,omitempty"`
+	CityName             string     `xml:"cbc:CityName,omitempty"`
+	PostalZone           string     `xml:"cbc:PostalZone,omitempty"`
+	Country              ublCountry `xml:"cac:Country"`
+}
+
+type ublCountry struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+type ublPartyTax struct {
+	CompanyID string       `xml:"cbc:CompanyID"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublTaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublLegalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+	CompanyID        string `xml:"cbc:CompanyID,omitempty"`
+}
+
+type ublContact struct {
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type ublPaymentMeans struct {
+	PaymentMeansCode      string              `xml:"cbc:PaymentMeansCode"`
+	PaymentID             string              `xml:"cbc:PaymentID,omitempty"`
+	PayeeFinancialAccount ublFinancialAccount `xml:"cac:PayeeFinancialAccount"`
+}
+
+type ublFinancialAccount struct {
+	ID     string     `xml:"cbc:ID"`
+	Name   string     `xml:"cbc:Name,omitempty"`
+	Branch *ublBranch `xml:"cac:FinancialInstitutionBranch"`
+}
+
+type ublBranch struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount    ublAmount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxCategory struct {
+	ID                     string       `xml:"cbc:ID"`
+	Percent                *string      `xml:"cbc:Percent"`
+	TaxExemptionReasonCode string       `xml:"cbc:TaxExemptionReasonCode,omitempty"`
+	TaxExemptionReason     string       `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme              ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount ublAmount `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount  ublAmount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount  ublAmount `xml:"cbc:TaxInclusiveAmount"`
+	PayableAmount       ublAmount `xml:"cbc:PayableAmount"`
+}
+
+type ublLine struct {
+	ID                  string        `xml:"cbc:ID"`
+	InvoicedQuantity    ublQuantity   `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount ublAmount     `xml:"cbc:LineExtensionAmount"`
+	AllowanceCharge     *ublAllowance `xml:"cac:AllowanceCharge"`
+	Item                ublItem       `xml:"cac:Item"`
+	Price               ublPrice      `xml:"cac:Price"`
+}
+
+type ublQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublAllowance struct {
+	ChargeIndicator           bool       `xml:"cbc:ChargeIndicator"`
+	AllowanceChargeReasonCode string     `xml:"cbc:AllowanceChargeReasonCode"`
+	AllowanceChargeReason     string     `xml:"cbc:AllowanceChargeReason"`
+	MultiplierFactorNumeric   string     `xml:"cbc:MultiplierFactorNumeric,omitempty"`
+	Amount                    ublAmount  `xml:"cbc:Amount"`
+	BaseAmount                *ublAmount `xml:"cbc:BaseAmount"`
+}
+
+type ublItem struct {
+	Description           string         `xml:"cbc:Description,omitempty"`
+	Name                  string         `xml:"cbc:Name"`
+	ClassifiedTaxCategory ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type ublPrice struct {
+	PriceAmount ublAmount `xml:"cbc:PriceAmount"`
+}
+
+// UBL returns the document as a UBL 2.1 invoice following Peppol BIS
+// Billing 3.0
+func UBL(doc *Document) ([]byte, error) {
+	money := func(v float64) ublAmount { return ublAmount{Currency: doc.Currency, Value: amount(v)} }
+
+	inv := ublInvoice{
+		Xmlns:                   "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+		Cac:                     "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+		Cbc:                     "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+		CustomizationID:         PeppolCustomizationID,
+		ProfileID:               PeppolProfileID,
+		ID:                      doc.Number,
+		IssueDate:               date(doc.IssueDate, "2006-01-02"),
+		DueDate:                 date(doc.DueDate, "2006-01-02"),
+		InvoiceTypeCode:         "380",
+		Note:                    doc.Note,
+		DocumentCurrencyCode:    doc.Currency,
+		BuyerReference:          doc.BuyerReference,
+		AccountingSupplierParty: ublPartyWrapper{ublPartyOf(doc.Seller, true)},
+		AccountingCustomerParty: ublPartyWrapper{ublPartyOf(doc.Buyer, false)},
+		TaxTotal:                ublTaxTotal{TaxAmount: money(doc.TaxTotal)},
+		LegalMonetaryTotal: ublMonetaryTotal{
+			LineExtensionAmount: money(doc.LineTotal),
+			TaxExclusiveAmount:  money(doc.TaxExclusive),
+			TaxInclusiveAmount:  money(doc.TaxInclusive),
+			PayableAmount:       money(doc.Payable),
+		},
+	}
+
+	if doc.OrderReference != "" {
+		inv.OrderReference = &ublOrderRef{ID: doc.OrderReference}
+	}
+	if p := doc.Payment; p.MeansCode != "" {
+		inv.PaymentMeans = &ublPaymentMeans{
+			PaymentMeansCode:      p.MeansCode,
+			PaymentID:             p.Reference,
+			PayeeFinancialAccount: ublFinancialAccount{ID: p.Account, Name: p.BankName},
+		}
+		if p.BIC != "" {
+			inv.PaymentMeans.PayeeFinancialAccount.Branch = &ublBranch{ID: p.BIC}
+		}
+	}
+
+	for _, t := range doc.Taxes {
+		inv.TaxTotal.TaxSubtotals = append(inv.TaxTotal.TaxSubtotals, ublTaxSubtotal{
+			TaxableAmount: money(t.Taxable),
+			TaxAmount:     money(t.Tax),
+			TaxCategory: ublTaxCategory{
+				ID:                     t.Category,
+				Percent:                ublPercent(t.Category, t.Percent),
+				TaxExemptionReasonCode: t.ExemptionCode,
+				TaxExemptionReason:     t.ExemptionReason,
+				TaxScheme:              ublTaxScheme{ID: "VAT"},
+			},
+		})
+	}
+
+	for _, l := range doc.Lines {
+		line := ublLine{
+			ID:                  l.ID,
+			InvoicedQuantity:    ublQuantity{UnitCode: UnitCode, Value: decimal(l.Quantity)},
+			LineExtensionAmount: money(l.Net),
+			Item: ublItem{
+				Description: l.Description,
+				Name:        l.Name,
+				ClassifiedTaxCategory: ublTaxCategory{
+					ID:        l.Category,
+					Percent:   ublPercent(l.Category, l.Percent),
+					TaxScheme: ublTaxScheme{ID: "VAT"},
+				},
+			},
+			Price: ublPrice{PriceAmount: money(l.Price)},
+		}
+		if l.Discount != 0 {
+			line.AllowanceCharge = &ublAllowance{
+				AllowanceChargeReasonCode: "95",
+				AllowanceChargeReason:     "Discount",
+				Amount:                    money(l.Discount),
+			}
+			if l.DiscountPct > 0 {
+				base := money(l.Gross)
+				line.AllowanceCharge.MultiplierFactorNumeric = decimal(l.DiscountPct)
+				line.AllowanceCharge.BaseAmount = &base
+			}
+		}
+		inv.InvoiceLines = append(inv.InvoiceLines, line)
+	}
+
+	out, err := xml.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write UBL: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func ublPartyOf(p Party, seller bool) ublParty {
+	party := ublParty{
+		PartyName: ublName{Name: p.Name},
+		PostalAddress: ublAddress{
+			StreetName:           p.Street,
+			AdditionalStreetName: p.Street2,
+			CityName:             p.City,
+			PostalZone:           p.PostalCode,
+			Country:              ublCountry{IdentificationCode: p.Country},
+		},
+		PartyLegalEntity: ublLegalEntity{RegistrationName: p.Name},
+	}
+	if id, scheme := p.endpoint(); id != "" {
+		party.EndpointID = &ublID{SchemeID: scheme, Value: id}
+	}
+	if p.VATID != "" {
+		party.PartyTaxSchemes = append(party.PartyTaxSchemes, ublPartyTax{CompanyID: p.VATID, TaxScheme: ublTaxScheme{ID: "VAT"}})
+	}
+	if p.TaxID != "" {
+		// A seller's tax number (BT-32), a buyer's registration number (BT-47)
+		if seller {
+			party.PartyTaxSchemes = append(party.PartyTaxSchemes, ublPartyTax{CompanyID: p.TaxID, TaxScheme: ublTaxScheme{ID: "TAX"}})
+		} else {
+			party.PartyLegalEntity.CompanyID = p.TaxID
+		}
+	}
+	if p.Phone != "" || p.Email != "" {
+		party.Contact = &ublContact{Telephone: p.Phone, ElectronicMail: p.Email}
+	}
+	return party
+}
+
+// ublPercent leaves out the rate of category O, which has none (BR-O-05)
+func ublPercent(category string, percent float64) *string {
+	if category == CategoryNotSubject {
+		return nil
+	}
+	s := decimal(percent)
+	return &s
+}
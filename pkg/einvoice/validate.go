@@ -0,0 +1,138 @@
+package einvoice
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Violation is a broken business rule, named like in EN 16931 and the
+// Peppol BIS rules so it can be looked up
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("[%s] %s", v.Rule, v.Message)
+}
+
+// ValidationError lists the rules a document breaks
+type ValidationError []Violation
+
+func (e ValidationError) Error() string {
+	rules := make([]string, len(e))
+	for i, v := range e {
+		rules[i] = v.Rule
+	}
+	return fmt.Sprintf("e-invoice breaks %d rule(s): %s", len(e), strings.Join(rules, ", "))
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Validate checks a document against the EN 16931 business rules that an
+// invoice from ung can break, and for UBL also the Peppol BIS Billing 3.0
+// rules. The checks are built in, so no schema files or network access are
+// needed, but they are a subset: ValidateWith runs the official ones. It
+// returns nil for a valid document and a ValidationError otherwise.
+func Validate(doc *Document, format string) error {
+	var errs ValidationError
+	add := func(rule, format string, args ...interface{}) {
+		errs = append(errs, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if doc.Number == "" {
+		add("BR-02", "the invoice has no number")
+	}
+	if doc.IssueDate.IsZero() {
+		add("BR-03", "the invoice has no issue date")
+	}
+	if !currencyPattern.MatchString(doc.Currency) {
+		add("BR-05", "currency %q is not an ISO 4217 code", doc.Currency)
+	}
+	if doc.Seller.Name == "" {
+		add("BR-06", "the company has no name")
+	}
+	if doc.Buyer.Name == "" {
+		add("BR-07", "the client has no name")
+	}
+	if doc.Seller.Country == "" {
+		add("BR-09", "the company's country is unknown; end its address with the country, e.g. \"Hauptstr. 1, 10115 Berlin, Germany\", or use a VAT ID")
+	}
+	if doc.Buyer.Country == "" {
+		add("BR-11", "the client's country is unknown; end its address with the country, e.g. \"1 Rue de Rivoli, 75001 Paris, France\", or use a VAT ID")
+	}
+	if len(doc.Lines) == 0 {
+		add("BR-16", "the invoice has no lines")
+	}
+
+	var lineTotal float64
+	categories := map[string]bool{}
+	for _, l := range doc.Lines {
+		if l.Name == "" {
+			add("BR-25", "line %s has no item name", l.ID)
+		}
+		if l.Price < 0 {
+			add("BR-27", "line %s has a negative price", l.ID)
+		}
+		if l.Net != round(l.Gross-l.Discount) {
+			add("PEPPOL-EN16931-R120", "line %s: net amount %s is not quantity × price − discount", l.ID, amount(l.Net))
+		}
+		lineTotal = round(lineTotal + l.Net)
+		categories[l.Category] = true
+	}
+
+	if lineTotal != doc.LineTotal {
+		add("BR-CO-10", "the line total %s is not the sum of the lines, %s", amount(doc.LineTotal), amount(lineTotal))
+	}
+	var taxTotal float64
+	for _, t := range doc.Taxes {
+		taxTotal = round(taxTotal + t.Tax)
+		if t.Category == CategoryStandard && t.Tax != round(t.Taxable*t.Percent/100) {
+			add("BR-S-09", "VAT at %s%% of %s is not %s", decimal(t.Percent), amount(t.Taxable), amount(t.Tax))
+		}
+		if t.Category != CategoryStandard && t.ExemptionReason == "" && t.ExemptionCode == "" {
+			add("BR-"+t.Category+"-10", "VAT category %s needs an exemption reason", t.Category)
+		}
+	}
+	if taxTotal != doc.TaxTotal {
+		add("BR-CO-14", "the VAT total %s is not the sum of the VAT breakdown, %s", amount(doc.TaxTotal), amount(taxTotal))
+	}
+	if doc.TaxInclusive != round(doc.TaxExclusive+doc.TaxTotal) {
+		add("BR-CO-15", "the total with VAT is not the total without VAT plus VAT")
+	}
+	if doc.Payable > 0 && doc.DueDate.IsZero() {
+		add("BR-CO-25", "an invoice with an amount due needs a due date")
+	}
+
+	if categories[CategoryStandard] && doc.Seller.VATID == "" && doc.Seller.TaxID == "" {
+		add("BR-S-02", "taxed lines need the company's VAT ID or tax number; set it with 'ung company edit'")
+	}
+	if categories[CategoryReverseCharge] && (doc.Seller.VATID == "" || doc.Buyer.VATID == "") {
+		add("BR-AE-02", "reverse charge needs the VAT IDs of the company and the client")
+	}
+	if categories[CategoryNotSubject] && len(categories) > 1 {
+		add("BR-O-11", "lines not subject to VAT can't be mixed with other VAT categories")
+	}
+
+	if p := doc.Payment; p.MeansCode != "" && p.Account == "" {
+		add("BR-61", "credit transfers need the company's bank account")
+	}
+
+	if format == FormatUBL {
+		if doc.BuyerReference == "" {
+			add("PEPPOL-EN16931-R003", "the invoice needs a buyer reference")
+		}
+		if id, _ := doc.Seller.endpoint(); id == "" {
+			add("PEPPOL-EN16931-R020", "the company needs an electronic address, a VAT ID or an email")
+		}
+		if id, _ := doc.Buyer.endpoint(); id == "" {
+			add("PEPPOL-EN16931-R010", "the client needs an electronic address, a VAT ID or an email")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,110 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os/exec"
+	"strings"
+)
+
+// auth returns the login of the configured method, nil without a username
+func (cfg *Config) auth() (smtp.Auth, error) {
+	if cfg.Username == "" {
+		return nil, nil
+	}
+	switch strings.ToLower(cfg.Auth) {
+	case "", AuthPlain:
+		return smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost), nil
+	case AuthLogin:
+		return &loginAuth{username: cfg.Username, password: cfg.Password}, nil
+	case AuthXOAuth2:
+		token, err := cfg.oauth2Token()
+		if err != nil {
+			return nil, err
+		}
+		return &xoauth2Auth{username: cfg.Username, token: token}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth %q (use plain, login or xoauth2)", cfg.Auth)
+	}
+}
+
+// oauth2Token returns the output of OAuth2TokenCmd, as access tokens
+// expire within hours, or else OAuth2Token
+func (cfg *Config) oauth2Token() (string, error) {
+	if cfg.OAuth2TokenCmd != "" {
+		out, err := exec.Command("sh", "-c", cfg.OAuth2TokenCmd).Output()
+		if err != nil {
+			return "", fmt.Errorf("oauth2_token_cmd failed: %w", err)
+		}
+		token := strings.TrimSpace(string(out))
+		if token == "" {
+			return "", fmt.Errorf("oauth2_token_cmd printed no token")
+		}
+		return token, nil
+	}
+	if cfg.OAuth2Token == "" {
+		return "", fmt.Errorf("oauth2_token or oauth2_token_cmd is required for xoauth2")
+	}
+	return cfg.OAuth2Token, nil
+}
+
+// requireTLS refuses to send credentials in the clear, except to this
+// machine, as smtp.PlainAuth does
+func requireTLS(server *smtp.ServerInfo) error {
+	if server.TLS {
+		return nil
+	}
+	switch server.Name {
+	case "localhost", "127.0.0.1", "::1":
+		return nil
+	}
+	return errors.New("unencrypted connection")
+}
+
+// loginAuth implements the LOGIN mechanism of servers without PLAIN
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := requireTLS(server); err != nil {
+		return "", nil, err
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN prompt %q", fromServer)
+	}
+}
+
+// xoauth2Auth implements the XOAUTH2 mechanism of Gmail and Microsoft 365
+type xoauth2Auth struct {
+	username, token string
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := requireTLS(server); err != nil {
+		return "", nil, err
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		// The server sent the error details, an empty reply gets its final error
+		return []byte{}, nil
+	}
+	return nil, nil
+}
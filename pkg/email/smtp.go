@@ -2,27 +2,62 @@ package email
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
 	"net/smtp"
 	"net/textproto"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+)
+
+// Connection security of Config.Security
+const (
+	SecurityTLS      = "tls"      // Implicit TLS, usually port 465
+	SecurityStartTLS = "starttls" // Upgrade with STARTTLS, usually port 587
+	SecurityNone     = "none"     // No encryption, for local relays
+)
+
+// Authentication methods of Config.Auth
+const (
+	AuthPlain   = "plain"   // Username and password, the default
+	AuthLogin   = "login"   // Username and password for servers without PLAIN
+	AuthXOAuth2 = "xoauth2" // OAuth2 access token, e.g. for Gmail and Microsoft 365
+)
+
+// dialTimeout and sendTimeout bound connecting and a whole delivery
+const (
+	dialTimeout = 30 * time.Second
+	sendTimeout = 5 * time.Minute
 )
 
 // Config holds SMTP email configuration
 type Config struct {
-	SMTPHost  string `yaml:"smtp_host"`
-	SMTPPort  int    `yaml:"smtp_port"`
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	FromEmail string `yaml:"from_email"`
-	FromName  string `yaml:"from_name"`
-	UseTLS    bool   `yaml:"use_tls"`
+	SMTPHost       string `yaml:"smtp_host"`
+	SMTPPort       int    `yaml:"smtp_port"`
+	Username       string `yaml:"username"`
+	Password       string `yaml:"password"`
+	FromEmail      string `yaml:"from_email"`
+	FromName       string `yaml:"from_name"`
+	UseTLS         bool   `yaml:"use_tls"`
+	Security       string `yaml:"security,omitempty"`         // tls, starttls or none, see security
+	Auth           string `yaml:"auth,omitempty"`             // plain, login or xoauth2
+	OAuth2Token    string `yaml:"oauth2_token,omitempty"`     // Access token for xoauth2
+	OAuth2TokenCmd string `yaml:"oauth2_token_cmd,omitempty"` // Command printing a fresh access token for xoauth2
+
+	tlsConfig *tls.Config // Replaces the system roots, for tests
 }
 
 // Email represents an email message
@@ -35,9 +70,11 @@ type Email struct {
 	HTMLBody    string
 	Attachments []string
 	ReplyTo     string
+	MessageID   string // Set by Send when empty, e.g. <id@example.com>
 }
 
-// Send sends an email using SMTP
+// Send sends an email using SMTP. It sets email.MessageID when it's empty,
+// so the message can be tracked.
 func Send(cfg *Config, email *Email) error {
 	if cfg.SMTPHost == "" {
 		return fmt.Errorf("SMTP host not configured")
@@ -47,68 +84,34 @@ func Send(cfg *Config, email *Email) error {
 		return fmt.Errorf("no recipients specified")
 	}
 
+	if email.MessageID == "" {
+		email.MessageID = NewMessageID(cfg.FromEmail)
+	}
+
 	// Build the email message
 	message, err := buildMessage(cfg, email)
 	if err != nil {
 		return fmt.Errorf("failed to build message: %w", err)
 	}
 
-	// Connect to SMTP server
-	auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
-	addr := fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort)
-
 	// Collect all recipients
 	recipients := append([]string{}, email.To...)
 	recipients = append(recipients, email.Cc...)
 	recipients = append(recipients, email.Bcc...)
 
-	// Send with TLS if enabled
-	if cfg.UseTLS {
-		return sendWithTLS(addr, auth, cfg.FromEmail, recipients, message)
-	}
-
-	// Send without TLS
-	return smtp.SendMail(addr, auth, cfg.FromEmail, recipients, message)
-}
-
-// sendWithTLS sends email with TLS encryption
-func sendWithTLS(addr string, auth smtp.Auth, from string, to []string, message []byte) error {
-	// Connect to server
-	host := strings.Split(addr, ":")[0]
-
-	// TLS config
-	tlsConfig := &tls.Config{
-		ServerName: host,
-	}
-
-	// Connect with TLS
-	conn, err := tls.Dial("tcp", addr, tlsConfig)
-	if err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
-	}
-	defer conn.Close()
-
-	// Create SMTP client
-	client, err := smtp.NewClient(conn, host)
+	client, err := connect(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create SMTP client: %w", err)
-	}
-	defer client.Quit()
-
-	// Authenticate
-	if auth != nil {
-		if err := client.Auth(auth); err != nil {
-			return fmt.Errorf("authentication failed: %w", err)
-		}
+		return err
 	}
+	defer client.Close()
 
 	// Set sender
-	if err := client.Mail(from); err != nil {
+	if err := client.Mail(cfg.FromEmail); err != nil {
 		return fmt.Errorf("failed to set sender: %w", err)
 	}
 
 	// Set recipients
-	for _, recipient := range to {
+	for _, recipient := range recipients {
 		if err := client.Rcpt(recipient); err != nil {
 			return fmt.Errorf("failed to add recipient %s: %w", recipient, err)
 		}
@@ -128,10 +131,114 @@ func sendWithTLS(addr string, auth smtp.Auth, from string, to []string, message
 
 	err = writer.Close()
 	if err != nil {
-		return fmt.Errorf("failed to close writer: %w", err)
+		return fmt.Errorf("failed to send message: %w", err)
 	}
 
-	return nil
+	return client.Quit()
+}
+
+// security returns the connection security: Security when set, otherwise
+// implicit TLS for UseTLS, otherwise "" for STARTTLS when the server
+// offers it
+func (cfg *Config) security() string {
+	if cfg.Security != "" {
+		return strings.ToLower(cfg.Security)
+	}
+	if cfg.UseTLS {
+		return SecurityTLS
+	}
+	return ""
+}
+
+// connect opens a connection with the configured security and logs in
+func connect(cfg *Config) (*smtp.Client, error) {
+	addr := net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort))
+	tlsConfig := &tls.Config{ServerName: cfg.SMTPHost}
+	if cfg.tlsConfig != nil {
+		tlsConfig = cfg.tlsConfig
+	}
+	security := cfg.security()
+
+	// Connect to server
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	var err error
+	if security == SecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(sendTimeout))
+
+	// Create SMTP client
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create SMTP client: %w", err)
+	}
+
+	// Upgrade to TLS
+	if security != SecurityTLS && security != SecurityNone {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		} else if security == SecurityStartTLS {
+			client.Close()
+			return nil, fmt.Errorf("server doesn't support STARTTLS, set security: tls or none")
+		}
+	}
+
+	// Authenticate
+	auth, err := cfg.auth()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	if ok, _ := client.Extension("AUTH"); ok && auth != nil {
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// NewMessageID returns a unique Message-ID in the domain of the sender
+func NewMessageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		domain = strings.Trim(from[i+1:], "<> ")
+	}
+	random := make([]byte, 12)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
+
+// Permanent reports whether sending failed for a reason that retrying
+// won't fix, i.e. the server rejected it with a 5xx reply
+func Permanent(err error) bool {
+	var reply *textproto.Error
+	return errors.As(err, &reply) && reply.Code >= 500
+}
+
+// RetryDelay returns how long to wait before trying again after a number
+// of failed attempts: a minute, then four times longer each time, up to
+// six hours
+func RetryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < 6*time.Hour; i++ {
+		delay *= 4
+	}
+	if delay > 6*time.Hour {
+		delay = 6 * time.Hour
+	}
+	return delay
 }
 
 // buildMessage constructs the email message with headers and body
@@ -143,7 +250,7 @@ func buildMessage(cfg *Config, email *Email) ([]byte, error) {
 	boundary := writer.Boundary()
 
 	// Write headers
-	buf.WriteString(fmt.Sprintf("From: %s <%s>\r\n", cfg.FromName, cfg.FromEmail))
+	buf.WriteString(fmt.Sprintf("From: %s <%s>\r\n", mime.QEncoding.Encode("utf-8", cfg.FromName), cfg.FromEmail))
 	buf.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(email.To, ", ")))
 
 	if len(email.Cc) > 0 {
@@ -154,7 +261,12 @@ func buildMessage(cfg *Config, email *Email) ([]byte, error) {
 		buf.WriteString(fmt.Sprintf("Reply-To: %s\r\n", email.ReplyTo))
 	}
 
-	buf.WriteString(fmt.Sprintf("Subject: %s\r\n", email.Subject))
+	// Localized subjects need encoding, ASCII ones are left as they are
+	buf.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject)))
+	buf.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	if email.MessageID != "" {
+		buf.WriteString(fmt.Sprintf("Message-ID: %s\r\n", email.MessageID))
+	}
 	buf.WriteString("MIME-Version: 1.0\r\n")
 	buf.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%s\r\n", boundary))
 	buf.WriteString("\r\n")
@@ -181,7 +293,9 @@ func buildMessage(cfg *Config, email *Email) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		textPart.Write([]byte(email.Body))
+		if err := writeQuotedPrintable(textPart, email.Body); err != nil {
+			return nil, err
+		}
 
 		// HTML part
 		htmlHeader := make(textproto.MIMEHeader)
@@ -192,7 +306,9 @@ func buildMessage(cfg *Config, email *Email) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		htmlPart.Write([]byte(email.HTMLBody))
+		if err := writeQuotedPrintable(htmlPart, email.HTMLBody); err != nil {
+			return nil, err
+		}
 
 		altWriter.Close()
 	} else {
@@ -205,7 +321,9 @@ func buildMessage(cfg *Config, email *Email) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		part.Write([]byte(email.Body))
+		if err := writeQuotedPrintable(part, email.Body); err != nil {
+			return nil, err
+		}
 	}
 
 	// Add attachments
@@ -220,6 +338,16 @@ func buildMessage(cfg *Config, email *Email) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// writeQuotedPrintable writes a body part in the quoted-printable
+// encoding its header declares
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
 // addAttachment adds a file attachment to the email
 func addAttachment(writer *multipart.Writer, filePath string) error {
 	file, err := os.Open(filePath)
@@ -266,7 +394,8 @@ func addAttachment(writer *multipart.Writer, filePath string) error {
 	return nil
 }
 
-// ValidateConfig checks if email configuration is valid
+// ValidateConfig checks if email configuration is valid. Without a
+// username the server is used without logging in, e.g. a local relay.
 func ValidateConfig(cfg *Config) error {
 	if cfg.SMTPHost == "" {
 		return fmt.Errorf("SMTP host is required")
@@ -274,54 +403,66 @@ func ValidateConfig(cfg *Config) error {
 	if cfg.SMTPPort == 0 {
 		return fmt.Errorf("SMTP port is required")
 	}
-	if cfg.Username == "" {
-		return fmt.Errorf("username is required")
-	}
-	if cfg.Password == "" {
-		return fmt.Errorf("password is required")
-	}
 	if cfg.FromEmail == "" {
 		return fmt.Errorf("from email is required")
 	}
+	switch cfg.security() {
+	case "", SecurityTLS, SecurityStartTLS, SecurityNone:
+	default:
+		return fmt.Errorf("unknown security %q (use tls, starttls or none)", cfg.Security)
+	}
+	switch strings.ToLower(cfg.Auth) {
+	case "", AuthPlain, AuthLogin:
+		if cfg.Username != "" && cfg.Password == "" {
+			return fmt.Errorf("password is required")
+		}
+	case AuthXOAuth2:
+		if cfg.Username == "" {
+			return fmt.Errorf("username is required for xoauth2")
+		}
+		if cfg.OAuth2Token == "" && cfg.OAuth2TokenCmd == "" {
+			return fmt.Errorf("oauth2_token or oauth2_token_cmd is required for xoauth2")
+		}
+	default:
+		return fmt.Errorf("unknown auth %q (use plain, login or xoauth2)", cfg.Auth)
+	}
 	return nil
 }
 
-// TestConnection tests the SMTP connection
+// TestConnection tests the SMTP connection and login
 func TestConnection(cfg *Config) error {
-	addr := fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort)
-	auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
-
-	// Try to connect and authenticate
-	if cfg.UseTLS {
-		host := strings.Split(addr, ":")[0]
-		tlsConfig := &tls.Config{ServerName: host}
-
-		conn, err := tls.Dial("tcp", addr, tlsConfig)
-		if err != nil {
-			return fmt.Errorf("connection failed: %w", err)
-		}
-		defer conn.Close()
-
-		client, err := smtp.NewClient(conn, host)
-		if err != nil {
-			return fmt.Errorf("failed to create client: %w", err)
-		}
-		defer client.Quit()
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Quit()
+}
 
-		if err := client.Auth(auth); err != nil {
-			return fmt.Errorf("authentication failed: %w", err)
-		}
-	} else {
-		client, err := smtp.Dial(addr)
-		if err != nil {
-			return fmt.Errorf("connection failed: %w", err)
-		}
-		defer client.Quit()
+// ParseAddresses parses a comma separated list of addresses, e.g. from a
+// flag, and returns the bare addresses
+func ParseAddresses(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	parsed, err := mail.ParseAddressList(list)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email addresses %q: %w", list, err)
+	}
+	addresses := make([]string, len(parsed))
+	for i, addr := range parsed {
+		addresses[i] = addr.Address
+	}
+	return addresses, nil
+}
 
-		if err := client.Auth(auth); err != nil {
-			return fmt.Errorf("authentication failed: %w", err)
+// SplitAddresses splits a comma separated list of stored addresses
+func SplitAddresses(list string) []string {
+	var addresses []string
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addresses = append(addresses, addr)
 		}
 	}
-
-	return nil
+	return addresses
 }
@@ -0,0 +1,241 @@
+package email
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/email/smtptest"
+)
+
+// sinkConfig returns a config for sending to a sink
+func sinkConfig(sink *smtptest.Sink) *Config {
+	return &Config{
+		SMTPHost:  sink.Host,
+		SMTPPort:  sink.Port,
+		FromEmail: "billing@example.com",
+		FromName:  "Acme Billing",
+		tlsConfig: sink.ClientTLS(),
+	}
+}
+
+func TestSend(t *testing.T) {
+	sink := smtptest.NewSink()
+	defer sink.Close()
+
+	attachment := filepath.Join(t.TempDir(), "INV-1.pdf")
+	os.WriteFile(attachment, []byte("%PDF-1.4 invoice"), 0644)
+
+	msg := &Email{
+		To:          []string{"client@example.com"},
+		Cc:          []string{"accounts@example.com"},
+		Bcc:         []string{"archive@example.com"},
+		Subject:     "Rechnung für März",
+		Body:        "Total: 1.190,00 € = paid?",
+		HTMLBody:    `<p style="color: red">Total</p>`,
+		Attachments: []string{attachment},
+	}
+	if err := Send(sinkConfig(sink), msg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(msg.MessageID, "@example.com>") {
+		t.Errorf("expected a Message-ID in the sender's domain, got %q", msg.MessageID)
+	}
+
+	messages := sink.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	got := messages[0]
+	if got.From != "billing@example.com" || strings.Join(got.To, ",") != "client@example.com,accounts@example.com,archive@example.com" {
+		t.Errorf("unexpected envelope %s -> %v", got.From, got.To)
+	}
+	if got.Auth != "" || got.TLS {
+		t.Errorf("expected no login or TLS, got %q %v", got.Auth, got.TLS)
+	}
+
+	parsed, err := got.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header.Get("Message-ID") != msg.MessageID || parsed.Header.Get("Date") == "" {
+		t.Errorf("expected Message-ID and Date headers, got %v", parsed.Header)
+	}
+	if parsed.Header.Get("Bcc") != "" || parsed.Header.Get("Cc") != "accounts@example.com" {
+		t.Errorf("expected Cc without Bcc, got %v", parsed.Header)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != msg.Subject {
+		t.Errorf("unexpected subject %q", subject)
+	}
+
+	// The bodies decode to what was sent
+	_, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	alternative, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, _ = mime.ParseMediaType(alternative.Header.Get("Content-Type"))
+	bodies := multipart.NewReader(alternative, params["boundary"])
+	for _, want := range []string{msg.Body, msg.HTMLBody} {
+		part, err := bodies.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part) // Decodes quoted-printable
+		if string(body) != want {
+			t.Errorf("expected body %q, got %q", want, body)
+		}
+	}
+	if file, err := parts.NextPart(); err != nil || file.FileName() != "INV-1.pdf" {
+		t.Errorf("expected the attachment, got %v", err)
+	}
+}
+
+func TestSendSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		sink     func() *smtptest.Sink
+		security string
+		useTLS   bool
+		wantTLS  bool
+		wantErr  bool
+	}{
+		{"implicit TLS", smtptest.NewTLSSink, SecurityTLS, false, true, false},
+		{"use_tls is implicit TLS", smtptest.NewTLSSink, "", true, true, false},
+		{"STARTTLS", smtptest.NewStartTLSSink, SecurityStartTLS, false, true, false},
+		{"STARTTLS when offered", smtptest.NewStartTLSSink, "", false, true, false},
+		{"no STARTTLS offered", smtptest.NewSink, "", false, false, false},
+		{"STARTTLS required", smtptest.NewSink, SecurityStartTLS, false, false, true},
+		{"no encryption", smtptest.NewStartTLSSink, SecurityNone, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := tt.sink()
+			defer sink.Close()
+
+			cfg := sinkConfig(sink)
+			cfg.Security, cfg.UseTLS = tt.security, tt.useTLS
+			err := Send(cfg, &Email{To: []string{"client@example.com"}, Subject: "Hi", Body: "Hi"})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sink.Messages()[0].TLS; got != tt.wantTLS {
+				t.Errorf("expected TLS %v, got %v", tt.wantTLS, got)
+			}
+		})
+	}
+}
+
+func TestSendAuth(t *testing.T) {
+	for _, auth := range []string{AuthPlain, AuthLogin, AuthXOAuth2} {
+		t.Run(auth, func(t *testing.T) {
+			sink := smtptest.NewStartTLSSink()
+			defer sink.Close()
+			sink.Username, sink.Password = "billing@example.com", "secret"
+
+			cfg := sinkConfig(sink)
+			cfg.Username, cfg.Auth = "billing@example.com", auth
+			if auth == AuthXOAuth2 {
+				cfg.OAuth2TokenCmd = "echo secret"
+			} else {
+				cfg.Password = "secret"
+			}
+			msg := &Email{To: []string{"client@example.com"}, Subject: "Hi", Body: "Hi"}
+			if err := Send(cfg, msg); err != nil {
+				t.Fatal(err)
+			}
+			if got := sink.Messages()[0].Auth; got != strings.ToUpper(auth) {
+				t.Errorf("expected %s login, got %q", auth, got)
+			}
+
+			cfg.Password, cfg.OAuth2TokenCmd, cfg.OAuth2Token = "wrong", "", "wrong"
+			err := Send(cfg, msg)
+			if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+				t.Errorf("expected an authentication error, got %v", err)
+			}
+			if !Permanent(err) {
+				t.Errorf("expected a rejected login to be permanent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSendFailures(t *testing.T) {
+	sink := smtptest.NewSink()
+	defer sink.Close()
+	sink.Fail(451, 550)
+
+	msg := &Email{To: []string{"client@example.com"}, Subject: "Hi", Body: "Hi"}
+	err := Send(sinkConfig(sink), msg)
+	if err == nil || Permanent(err) {
+		t.Errorf("expected a temporary failure, got %v", err)
+	}
+	if err := Send(sinkConfig(sink), msg); !Permanent(err) {
+		t.Errorf("expected a permanent failure, got %v", err)
+	}
+	if err := Send(sinkConfig(sink), msg); err != nil {
+		t.Errorf("expected the third attempt to be sent, got %v", err)
+	}
+
+	// A connection that fails is worth retrying
+	sink.Close()
+	if err := Send(sinkConfig(sink), msg); err == nil || Permanent(err) {
+		t.Errorf("expected a temporary connection error, got %v", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	want := []time.Duration{time.Minute, 4 * time.Minute, 16 * time.Minute, 64 * time.Minute, 256 * time.Minute, 6 * time.Hour, 6 * time.Hour}
+	for i, d := range want {
+		if got := RetryDelay(i + 1); got != d {
+			t.Errorf("RetryDelay(%d) = %s; want %s", i+1, got, d)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := Config{SMTPHost: "smtp.example.com", SMTPPort: 587, FromEmail: "me@example.com"}
+	if err := ValidateConfig(&valid); err != nil {
+		t.Errorf("expected a relay without login to be valid, got %v", err)
+	}
+
+	for _, cfg := range []Config{
+		{SMTPPort: 587, FromEmail: "me@example.com"},
+		{SMTPHost: "smtp.example.com", SMTPPort: 587, FromEmail: "me@example.com", Username: "me"},
+		{SMTPHost: "smtp.example.com", SMTPPort: 587, FromEmail: "me@example.com", Security: "ssl"},
+		{SMTPHost: "smtp.example.com", SMTPPort: 587, FromEmail: "me@example.com", Username: "me", Auth: AuthXOAuth2},
+		{SMTPHost: "smtp.example.com", SMTPPort: 587, FromEmail: "me@example.com", Auth: "cram-md5"},
+	} {
+		if err := ValidateConfig(&cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestParseAddresses(t *testing.T) {
+	got, err := ParseAddresses("Accounts <accounts@example.com>, cfo@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "accounts@example.com,cfo@example.com" {
+		t.Errorf("unexpected addresses %v", got)
+	}
+	if got, err := ParseAddresses(" "); err != nil || got != nil {
+		t.Errorf("expected no addresses, got %v %v", got, err)
+	}
+	if _, err := ParseAddresses("accounts@"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
@@ -0,0 +1,328 @@
+// Package smtptest provides an SMTP server that keeps the messages it
+// receives, to test sending email without a mail server, like httptest
+// does for HTTP.
+package smtptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a message received by a Sink
+type Message struct {
+	From string   // Envelope sender
+	To   []string // Envelope recipients, including Cc and Bcc
+	Data []byte   // The message with its headers
+	Auth string   // Mechanism the client logged in with, "" without login
+	TLS  bool     // Whether the connection was encrypted
+}
+
+// Parse parses the headers and body of the message
+func (m Message) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(strings.NewReader(string(m.Data)))
+}
+
+// Sink is an SMTP server on a local port. It accepts every message, or
+// only logins with Username and Password (the token for XOAUTH2) when
+// Username is set.
+type Sink struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+
+	listener  net.Listener
+	tlsConfig *tls.Config
+	implicit  bool // TLS from the start, otherwise offered with STARTTLS
+	startTLS  bool
+
+	mu       sync.Mutex
+	messages []Message
+	failures []int // Reply codes of the next MAIL commands
+	wg       sync.WaitGroup
+}
+
+// NewSink starts a sink without encryption. Close it when done.
+func NewSink() *Sink {
+	return start(false, false)
+}
+
+// NewStartTLSSink starts a sink that offers STARTTLS
+func NewStartTLSSink() *Sink {
+	return start(false, true)
+}
+
+// NewTLSSink starts a sink with implicit TLS
+func NewTLSSink() *Sink {
+	return start(true, false)
+}
+
+func start(implicit, startTLS bool) *Sink {
+	cert, err := certificate()
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: failed to create certificate: %v", err))
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: failed to listen: %v", err))
+	}
+
+	s := &Sink{
+		Host:      "127.0.0.1",
+		Port:      listener.Addr().(*net.TCPAddr).Port,
+		listener:  listener,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		implicit:  implicit,
+		startTLS:  startTLS,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close stops the sink
+func (s *Sink) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// ClientTLS returns a TLS config that trusts the sink's certificate
+func (s *Sink) ClientTLS() *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.tlsConfig.Certificates[0].Leaf)
+	return &tls.Config{RootCAs: pool, ServerName: s.Host}
+}
+
+// Messages returns the messages received so far
+func (s *Sink) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Fail makes the next MAIL commands fail with the given reply codes, e.g.
+// 451 for a temporary and 550 for a permanent failure
+func (s *Sink) Fail(codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, codes...)
+}
+
+func (s *Sink) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(time.Minute))
+			s.handle(conn)
+		}()
+	}
+}
+
+// session is the state of one connection
+type session struct {
+	text   *textproto.Conn
+	tls    bool
+	auth   string
+	from   string
+	to     []string
+	authed bool
+}
+
+func (s *Sink) handle(conn net.Conn) {
+	sess := &session{}
+	if s.implicit {
+		conn = tls.Server(conn, s.tlsConfig)
+		sess.tls = true
+	}
+	sess.text = textproto.NewConn(conn)
+	sess.text.PrintfLine("220 smtptest ESMTP")
+
+	for {
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"smtptest", "8BITMIME", "AUTH PLAIN LOGIN XOAUTH2"}
+			if s.startTLS && !sess.tls {
+				lines = append(lines, "STARTTLS")
+			}
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				sess.text.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			if !s.startTLS || sess.tls {
+				sess.text.PrintfLine("502 5.5.1 STARTTLS not available")
+				continue
+			}
+			sess.text.PrintfLine("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			sess = &session{text: textproto.NewConn(conn), tls: true}
+		case "AUTH":
+			s.login(sess, arg)
+		case "MAIL":
+			if s.Username != "" && !sess.authed {
+				sess.text.PrintfLine("530 5.7.0 Authentication required")
+				continue
+			}
+			if code := s.nextFailure(); code != 0 {
+				sess.text.PrintfLine("%d Rejected by smtptest", code)
+				continue
+			}
+			sess.from, sess.to = address(arg), nil
+			sess.text.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			sess.to = append(sess.to, address(arg))
+			sess.text.PrintfLine("250 2.1.5 OK")
+		case "DATA":
+			sess.text.PrintfLine("354 Go ahead")
+			data, err := sess.text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, Message{From: sess.from, To: sess.to, Data: data, Auth: sess.auth, TLS: sess.tls})
+			s.mu.Unlock()
+			sess.text.PrintfLine("250 2.0.0 OK queued")
+		case "RSET", "NOOP":
+			sess.text.PrintfLine("250 2.0.0 OK")
+		case "QUIT":
+			sess.text.PrintfLine("221 2.0.0 Bye")
+			return
+		default:
+			sess.text.PrintfLine("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+// login checks an AUTH command
+func (s *Sink) login(sess *session, arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	mechanism = strings.ToUpper(mechanism)
+
+	var username, password string
+	switch mechanism {
+	case "PLAIN":
+		parts := strings.Split(decode(initial), "\x00")
+		if len(parts) == 3 {
+			username, password = parts[1], parts[2]
+		}
+	case "LOGIN":
+		username = s.challenge(sess, "Username:")
+		password = s.challenge(sess, "Password:")
+	case "XOAUTH2":
+		for _, field := range strings.Split(decode(initial), "\x01") {
+			if v, ok := strings.CutPrefix(field, "user="); ok {
+				username = v
+			}
+			if v, ok := strings.CutPrefix(field, "auth=Bearer "); ok {
+				password = v
+			}
+		}
+	default:
+		sess.text.PrintfLine("504 5.5.4 Unrecognized authentication type")
+		return
+	}
+
+	if username != s.Username || password != s.Password {
+		if mechanism == "XOAUTH2" {
+			// The error details, which the client answers with an empty line
+			sess.text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(`{"status":"401"}`)))
+			sess.text.ReadLine()
+		}
+		sess.text.PrintfLine("535 5.7.8 Authentication failed")
+		return
+	}
+	sess.authed, sess.auth = true, mechanism
+	sess.text.PrintfLine("235 2.7.0 Authentication successful")
+}
+
+// challenge asks for a LOGIN value and returns the answer
+func (s *Sink) challenge(sess *session, prompt string) string {
+	sess.text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+	line, _ := sess.text.ReadLine()
+	return decode(line)
+}
+
+func (s *Sink) nextFailure() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return 0
+	}
+	code := s.failures[0]
+	s.failures = s.failures[1:]
+	return code
+}
+
+// address returns the address of a "FROM:<a@b.c>" or "TO:<a@b.c>" argument
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
+
+func decode(s string) string {
+	data, _ := base64.StdEncoding.DecodeString(s)
+	return string(data)
+}
+
+// certificate creates a self-signed certificate for 127.0.0.1
+func certificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtptest"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
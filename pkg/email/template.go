@@ -0,0 +1,276 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/payqr"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of templated emails
+const (
+	KindInvoice  = "invoice"
+	KindContract = "contract"
+	KindReminder = "reminder"
+)
+
+// Kinds lists the kinds of templated emails
+var Kinds = []string{KindInvoice, KindContract, KindReminder}
+
+// Template is an email with {name} placeholders, see InvoiceVars and
+// ContractVars. Without HTML the text is sent as HTML too, with a payment
+// button when there is a payment link.
+type Template struct {
+	Subject string `yaml:"subject"`
+	Text    string `yaml:"text"`
+	HTML    string `yaml:"html,omitempty"`
+}
+
+// DefaultTemplate returns the built-in template of a kind in the language
+// of loc
+func DefaultTemplate(kind string, loc *locale.Pack) (Template, error) {
+	text := loc.Email
+	switch kind {
+	case KindInvoice:
+		return Template{Subject: text.InvoiceSubject, Text: text.InvoiceBody}, nil
+	case KindContract:
+		return Template{Subject: text.ContractSubject, Text: text.ContractBody}, nil
+	case KindReminder:
+		return Template{Subject: text.ReminderSubject, Text: text.ReminderBody}, nil
+	default:
+		return Template{}, fmt.Errorf("unknown email template %q (use %s)", kind, strings.Join(Kinds, ", "))
+	}
+}
+
+// TemplateFiles returns the files that can override the built-in template
+// of a kind, least specific first: <kind>.yaml in dir, then the one for the
+// language, e.g. invoice.de.yaml, then both in the client's set directory.
+func TemplateFiles(dir, set, kind, language string) []string {
+	dirs := []string{dir}
+	if set != "" {
+		dirs = append(dirs, filepath.Join(dir, set))
+	}
+	var files []string
+	for _, d := range dirs {
+		files = append(files, filepath.Join(d, kind+".yaml"))
+		if language != "" {
+			files = append(files, filepath.Join(d, kind+"."+language+".yaml"))
+		}
+	}
+	return files
+}
+
+// ValidateSet checks that a template set is a plain directory name, or
+// empty for none
+func ValidateSet(set string) error {
+	if set == "" {
+		return nil
+	}
+	if set != filepath.Base(set) || set == "." || set == ".." || strings.ContainsAny(set, `/\`) {
+		return fmt.Errorf("invalid email template set %q: use a plain name like acme", set)
+	}
+	return nil
+}
+
+// LoadTemplate returns the template of a kind for a client: the built-in
+// one, with the fields set in each of its TemplateFiles replacing it
+func LoadTemplate(dir, set, kind string, loc *locale.Pack) (Template, error) {
+	tmpl, err := DefaultTemplate(kind, loc)
+	if err != nil {
+		return tmpl, err
+	}
+
+	for _, path := range TemplateFiles(dir, set, kind, loc.Code) {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return tmpl, fmt.Errorf("failed to read email template: %w", err)
+		}
+		var override Template
+		if err := yaml.Unmarshal(data, &override); err != nil {
+			return tmpl, fmt.Errorf("failed to parse email template %s: %w", path, err)
+		}
+		if override.Subject != "" {
+			tmpl.Subject = override.Subject
+		}
+		if override.Text != "" {
+			tmpl.Text = override.Text
+			tmpl.HTML = "" // Built from the new text unless it's set too
+		}
+		if override.HTML != "" {
+			tmpl.HTML = override.HTML
+		}
+	}
+	return tmpl, nil
+}
+
+// SaveTemplate writes a template for editing
+func SaveTemplate(tmpl Template, path string) error {
+	data, err := yaml.Marshal(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to marshal email template: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Render fills in the placeholders and returns the email without its
+// recipients. Values are escaped in the HTML body.
+func (t Template) Render(vars map[string]string, loc *locale.Pack) *Email {
+	text := locale.Fill(t.Text, placeholders(vars, false)...)
+	body := locale.Fill(t.HTML, placeholders(vars, true)...)
+	if body == "" {
+		body = textToHTML(text, vars["payment_link"], loc.Email.PayNow)
+	}
+	return &Email{
+		Subject:  strings.TrimSpace(locale.Fill(t.Subject, placeholders(vars, false)...)),
+		Body:     text,
+		HTMLBody: body,
+	}
+}
+
+// RenderInvoice renders an invoice or reminder email in the language of loc
+// from the client's template set in dir, see LoadTemplate and InvoiceVars
+func RenderInvoice(dir, kind string, inv models.Invoice, company models.Company, client models.Client, loc *locale.Pack, paymentURL string) (*Email, error) {
+	tmpl, err := LoadTemplate(dir, client.EmailTemplate, kind, loc)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Render(InvoiceVars(inv, company, client, loc, paymentURL), loc), nil
+}
+
+// AddressTo addresses an email to the client's contacts with a role, or
+// the client's email without any, copying the client's addresses and the
+// contacts that want a copy
+func (e *Email) AddressTo(client models.Client, role models.ContactRole) {
+	e.To, e.Cc = nil, nil
+	seen := map[string]bool{}
+	add := func(list []string, address string) []string {
+		key := strings.ToLower(address)
+		if address == "" || seen[key] {
+			return list
+		}
+		seen[key] = true
+		return append(list, address)
+	}
+
+	for _, contact := range client.ContactsFor(role) {
+		e.To = add(e.To, contact.Email)
+	}
+	if len(e.To) == 0 {
+		e.To = add(e.To, client.Email)
+	}
+	for _, address := range SplitAddresses(client.EmailCc) {
+		e.Cc = add(e.Cc, address)
+	}
+	for _, contact := range client.Contacts {
+		if contact.CcInvoices {
+			e.Cc = add(e.Cc, contact.Email)
+		}
+	}
+	e.Bcc = SplitAddresses(client.EmailBcc)
+}
+
+// placeholders returns vars as {name} and value pairs for locale.Fill
+func placeholders(vars map[string]string, escape bool) []string {
+	oldnew := make([]string, 0, 2*len(vars))
+	for name, value := range vars {
+		if escape {
+			value = html.EscapeString(value)
+		}
+		oldnew = append(oldnew, "{"+name+"}", value)
+	}
+	return oldnew
+}
+
+// textToHTML lays out a text email as HTML: a paragraph per block of
+// lines, and a button for the payment link
+func textToHTML(text, paymentLink, payNow string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<body style=\"font-family: Arial, sans-serif; color: #3c3c3c; line-height: 1.5;\">\n")
+	b.WriteString("<div style=\"max-width: 600px; margin: 0 auto; padding: 20px;\">\n")
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		lines := strings.Split(strings.TrimSpace(paragraph), "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		fmt.Fprintf(&b, "<p>%s</p>\n", strings.Join(lines, "<br>\n"))
+	}
+	if paymentLink != "" {
+		fmt.Fprintf(&b, "<p><a href=\"%s\" style=\"background-color: #e87722; color: #ffffff; padding: 10px 20px; text-decoration: none; border-radius: 5px; display: inline-block;\">%s</a></p>\n",
+			html.EscapeString(paymentLink), html.EscapeString(payNow))
+	}
+	b.WriteString("</div>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// InvoiceVars returns the placeholders of invoice and reminder emails:
+// {invoice}, {company}, {client}, {client_email}, {amount} (formatted),
+// {currency}, {description}, {po_number}, {invoice_date}, {due_date},
+// {days_overdue}, {month}, {month_number}, {year} and {payment_link}. paymentURL is the
+// payment link pattern of the PDF config, see payqr.URL; without one
+// {payment_link} is empty.
+func InvoiceVars(inv models.Invoice, company models.Company, client models.Client, loc *locale.Pack, paymentURL string) map[string]string {
+	vars := partyVars(company, client)
+	vars["invoice"] = inv.InvoiceNum
+	vars["amount"] = loc.Money(inv.Amount, inv.Currency)
+	vars["currency"] = inv.Currency
+	vars["description"] = inv.Description
+	vars["po_number"] = inv.PONumber
+	vars["invoice_date"] = loc.Date(inv.IssuedDate)
+	vars["due_date"] = loc.Date(inv.DueDate)
+	vars["days_overdue"] = "0"
+	if days := int(time.Since(inv.DueDate).Hours() / 24); !inv.DueDate.IsZero() && days > 0 {
+		vars["days_overdue"] = strconv.Itoa(days)
+	}
+	vars["month"] = loc.Month(inv.IssuedDate)
+	vars["month_number"] = inv.IssuedDate.Format("01")
+	vars["year"] = inv.IssuedDate.Format("2006")
+	vars["payment_link"] = ""
+	if paymentURL != "" {
+		if link, err := payqr.URL(paymentURL, payqr.FromInvoice(inv, company, inv.Amount)); err == nil {
+			vars["payment_link"] = link
+		}
+	}
+	return vars
+}
+
+// ContractVars returns the placeholders of contract emails: {contract},
+// {contract_number}, {company}, {client} and {client_email}
+func ContractVars(contract models.Contract, company models.Company, client models.Client) map[string]string {
+	vars := partyVars(company, client)
+	vars["contract"] = contract.Name
+	vars["contract_number"] = contract.ContractNum
+	return vars
+}
+
+func partyVars(company models.Company, client models.Client) map[string]string {
+	return map[string]string{
+		"company":      company.Name,
+		"client":       client.Name,
+		"client_email": client.Email,
+	}
+}
+
+// VarNames returns the names of vars in order, to list them
+func VarNames(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
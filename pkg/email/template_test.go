@@ -0,0 +1,128 @@
+package email
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+func writeTemplate(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	en, de := locale.Get("en"), locale.Get("de")
+
+	tmpl, err := LoadTemplate(dir, "", KindInvoice, en)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Subject != en.Email.InvoiceSubject || tmpl.HTML != "" {
+		t.Errorf("expected the built-in template, got %+v", tmpl)
+	}
+
+	writeTemplate(t, filepath.Join(dir, "invoice.yaml"), "subject: Invoice {invoice}\nhtml: <p>{client}</p>\n")
+	writeTemplate(t, filepath.Join(dir, "invoice.de.yaml"), "subject: Rechnung {invoice}\n")
+	writeTemplate(t, filepath.Join(dir, "acme", "invoice.yaml"), "text: Hi {client}\n")
+
+	tmpl, _ = LoadTemplate(dir, "", KindInvoice, en)
+	if tmpl.Subject != "Invoice {invoice}" || tmpl.Text != en.Email.InvoiceBody || tmpl.HTML != "<p>{client}</p>" {
+		t.Errorf("unexpected common template %+v", tmpl)
+	}
+
+	tmpl, _ = LoadTemplate(dir, "", KindInvoice, de)
+	if tmpl.Subject != "Rechnung {invoice}" || tmpl.Text != de.Email.InvoiceBody {
+		t.Errorf("unexpected German template %+v", tmpl)
+	}
+
+	// A client's text replaces the common HTML, which was written for other text
+	tmpl, _ = LoadTemplate(dir, "acme", KindInvoice, de)
+	if tmpl.Subject != "Rechnung {invoice}" || tmpl.Text != "Hi {client}" || tmpl.HTML != "" {
+		t.Errorf("unexpected client template %+v", tmpl)
+	}
+
+	if _, err := LoadTemplate(dir, "", "receipt", en); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+	writeTemplate(t, filepath.Join(dir, "reminder.yaml"), "subject: [\n")
+	if _, err := LoadTemplate(dir, "", KindReminder, en); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
+
+func TestValidateSet(t *testing.T) {
+	for _, set := range []string{"", "acme", "acme-gmbh"} {
+		if err := ValidateSet(set); err != nil {
+			t.Errorf("%q: %v", set, err)
+		}
+	}
+	for _, set := range []string{"..", "acme/de", "../acme", `acme\de`} {
+		if err := ValidateSet(set); err == nil {
+			t.Errorf("expected an error for %q", set)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	loc := locale.Get("en")
+	vars := map[string]string{"client": "Smith & Sons <Ltd>", "payment_link": "https://pay.example.com/INV-1"}
+
+	msg := Template{Subject: "Hello {client}\n", Text: "Dear {client},\n\nPlease pay."}.Render(vars, loc)
+	if msg.Subject != "Hello Smith & Sons <Ltd>" || !strings.HasPrefix(msg.Body, "Dear Smith & Sons <Ltd>,") {
+		t.Errorf("unexpected text %q / %q", msg.Subject, msg.Body)
+	}
+	if !strings.Contains(msg.HTMLBody, "<p>Dear Smith &amp; Sons &lt;Ltd&gt;,</p>") {
+		t.Errorf("expected escaped paragraphs, got %s", msg.HTMLBody)
+	}
+	if !strings.Contains(msg.HTMLBody, `href="https://pay.example.com/INV-1"`) || !strings.Contains(msg.HTMLBody, loc.Email.PayNow) {
+		t.Errorf("expected a payment button, got %s", msg.HTMLBody)
+	}
+
+	msg = Template{Text: "Hi", HTML: "<b>{client}</b>"}.Render(vars, loc)
+	if msg.HTMLBody != "<b>Smith &amp; Sons &lt;Ltd&gt;</b>" {
+		t.Errorf("unexpected HTML %q", msg.HTMLBody)
+	}
+}
+
+func TestInvoiceVars(t *testing.T) {
+	inv := models.Invoice{
+		InvoiceNum: "INV-7",
+		Amount:     1190,
+		Currency:   "EUR",
+		IssuedDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:    time.Now().AddDate(0, 0, -10),
+	}
+	company := models.Company{Name: "Acme"}
+	client := models.Client{Name: "Globex", Email: "billing@globex.com"}
+
+	vars := InvoiceVars(inv, company, client, locale.Get("de"), "https://pay.example.com/{invoice}")
+	if vars["invoice"] != "INV-7" || vars["client"] != "Globex" || vars["company"] != "Acme" || vars["client_email"] != "billing@globex.com" {
+		t.Errorf("unexpected vars %v", vars)
+	}
+	if vars["month"] != "März" || vars["month_number"] != "03" || vars["year"] != "2025" {
+		t.Errorf("unexpected dates %v", vars)
+	}
+	if vars["days_overdue"] != "10" && vars["days_overdue"] != "9" {
+		t.Errorf("expected 10 days overdue, got %s", vars["days_overdue"])
+	}
+	if vars["payment_link"] != "https://pay.example.com/INV-7" {
+		t.Errorf("unexpected payment link %q", vars["payment_link"])
+	}
+
+	vars = InvoiceVars(inv, company, client, locale.Get("en"), "")
+	if vars["payment_link"] != "" {
+		t.Errorf("expected no payment link, got %q", vars["payment_link"])
+	}
+}
@@ -0,0 +1,177 @@
+package gitlog
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Commit represents a single commit read from a local repository
+type Commit struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Time        time.Time
+	Subject     string
+}
+
+// ShortHash returns the abbreviated commit hash
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 12 {
+		return c.Hash[:12]
+	}
+	return c.Hash
+}
+
+// Block is a cluster of commits that were made close together in time
+type Block struct {
+	Start   time.Time // Estimated start of work (first commit minus lead time)
+	End     time.Time // Time of the last commit
+	Commits []Commit  // Commits in chronological order
+}
+
+// Duration returns the estimated working time of the block
+func (b Block) Duration() time.Duration {
+	return b.End.Sub(b.Start)
+}
+
+// Subjects returns the commit subjects in chronological order
+func (b Block) Subjects() []string {
+	subjects := make([]string, len(b.Commits))
+	for i, c := range b.Commits {
+		subjects[i] = c.Subject
+	}
+	return subjects
+}
+
+// Options filters the commits read from a repository
+type Options struct {
+	Author string // Author name or email pattern, passed to git log --author
+	Since  time.Time
+	Until  time.Time
+}
+
+// Field and record separators used in the git log format
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+)
+
+// logFormat is the --format string producing records parsed by ParseLog
+const logFormat = "%H" + fieldSep + "%an" + fieldSep + "%ae" + fieldSep + "%aI" + fieldSep + "%s" + recordSep
+
+// RepoName returns the directory name of a repository, used as a project name
+func RepoName(repoPath string) string {
+	abs, err := filepath.Abs(repoPath)
+	if err != nil {
+		return filepath.Base(repoPath)
+	}
+	return filepath.Base(abs)
+}
+
+// RepoRoot returns the top-level directory of the repository containing path.
+// Only local repositories are supported; nothing is fetched.
+func RepoRoot(path string) (string, error) {
+	out, err := exec.Command("git", "-C", path, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git repository", path)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ConfiguredEmail returns user.email from the repository's git config
+func ConfiguredEmail(repoPath string) string {
+	out, err := exec.Command("git", "-C", repoPath, "config", "user.email").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ReadCommits returns non-merge commits from a local repository in chronological order
+func ReadCommits(repoPath string, opts Options) ([]Commit, error) {
+	args := []string{"-C", repoPath, "log", "--all", "--no-merges", "--format=" + logFormat}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since="+opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		args = append(args, "--until="+opts.Until.Format(time.RFC3339))
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed in %s: %s", repoPath, strings.TrimSpace(stderr.String()))
+	}
+
+	return ParseLog(string(out))
+}
+
+// ParseLog parses output produced with logFormat and sorts commits chronologically
+func ParseLog(output string) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(output, recordSep) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		fields := strings.Split(record, fieldSep)
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("unexpected git log record: %q", record)
+		}
+
+		t, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit date %q: %w", fields[3], err)
+		}
+
+		commits = append(commits, Commit{
+			Hash:        fields[0],
+			AuthorName:  fields[1],
+			AuthorEmail: fields[2],
+			Time:        t,
+			Subject:     fields[4],
+		})
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Time.Before(commits[j].Time)
+	})
+	return commits, nil
+}
+
+// Cluster groups commits into work blocks. A new block starts when the time since the
+// previous commit exceeds gap. Each block starts lead before its first commit to account
+// for the work that went into it.
+func Cluster(commits []Commit, gap, lead time.Duration) []Block {
+	sorted := make([]Commit, len(commits))
+	copy(sorted, commits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	var blocks []Block
+	for _, c := range sorted {
+		if n := len(blocks); n > 0 && c.Time.Sub(blocks[n-1].End) <= gap {
+			blocks[n-1].End = c.Time
+			blocks[n-1].Commits = append(blocks[n-1].Commits, c)
+			continue
+		}
+		blocks = append(blocks, Block{
+			Start:   c.Time.Add(-lead),
+			End:     c.Time,
+			Commits: []Commit{c},
+		})
+	}
+	return blocks
+}
@@ -0,0 +1,75 @@
+package gitlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLog(t *testing.T) {
+	output := strings.Join([]string{
+		"bbb" + fieldSep + "Jane" + fieldSep + "jane@example.com" + fieldSep + "2024-01-15T11:00:00+01:00" + fieldSep + "Fix login" + recordSep,
+		"\naaa" + fieldSep + "Jane" + fieldSep + "jane@example.com" + fieldSep + "2024-01-15T09:30:00+01:00" + fieldSep + "Add login form" + recordSep,
+		"\n",
+	}, "")
+
+	commits, err := ParseLog(output)
+	if err != nil {
+		t.Fatalf("ParseLog() error: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].Hash != "aaa" || commits[1].Hash != "bbb" {
+		t.Errorf("expected commits in chronological order, got %s, %s", commits[0].Hash, commits[1].Hash)
+	}
+	if commits[0].Subject != "Add login form" {
+		t.Errorf("unexpected subject: %s", commits[0].Subject)
+	}
+	if commits[0].AuthorEmail != "jane@example.com" {
+		t.Errorf("unexpected email: %s", commits[0].AuthorEmail)
+	}
+}
+
+func TestParseLogInvalid(t *testing.T) {
+	if _, err := ParseLog("garbage" + recordSep); err == nil {
+		t.Error("expected error for malformed record")
+	}
+}
+
+func TestCluster(t *testing.T) {
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	commits := []Commit{
+		{Hash: "3", Time: base.Add(90 * time.Minute), Subject: "third"},
+		{Hash: "1", Time: base, Subject: "first"},
+		{Hash: "2", Time: base.Add(45 * time.Minute), Subject: "second"},
+		{Hash: "4", Time: base.Add(6 * time.Hour), Subject: "after lunch"},
+	}
+
+	blocks := Cluster(commits, 2*time.Hour, 30*time.Minute)
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+
+	first := blocks[0]
+	if len(first.Commits) != 3 {
+		t.Errorf("expected 3 commits in first block, got %d", len(first.Commits))
+	}
+	if first.Duration() != 2*time.Hour {
+		t.Errorf("expected 2h (90m + 30m lead), got %v", first.Duration())
+	}
+	if got := strings.Join(first.Subjects(), ","); got != "first,second,third" {
+		t.Errorf("unexpected subjects: %s", got)
+	}
+
+	if blocks[1].Duration() != 30*time.Minute {
+		t.Errorf("single commit block should last the lead time, got %v", blocks[1].Duration())
+	}
+}
+
+func TestShortHash(t *testing.T) {
+	c := Commit{Hash: "0123456789abcdef0123"}
+	if c.ShortHash() != "0123456789ab" {
+		t.Errorf("unexpected short hash: %s", c.ShortHash())
+	}
+}
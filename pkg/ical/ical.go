@@ -0,0 +1,319 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event represents a single VEVENT from an iCalendar file
+type Event struct {
+	UID          string
+	RecurrenceID string // Set for modified instances and expanded occurrences of a recurring event
+	Summary      string
+	Description  string
+	Location     string
+	Organizer    string   // Email address of the organizer
+	Attendees    []string // Email addresses of attendees
+	Categories   []string
+	Status       string // TENTATIVE, CONFIRMED, CANCELLED
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Recurring    bool        // Event has an RRULE; use Expand to get its occurrences
+	RRule        string      // Raw RRULE value
+	ExDates      []time.Time // Occurrences excluded through EXDATE
+
+	recurrenceStart time.Time     // Parsed RECURRENCE-ID of a modified instance
+	duration        time.Duration // DURATION, resolved against DTSTART once the event is complete
+}
+
+// Calendar represents a parsed iCalendar file
+type Calendar struct {
+	Name   string // X-WR-CALNAME, if present
+	Events []Event
+}
+
+// Key returns a stable identifier for the event, suitable for deduplication.
+// Modified instances of recurring events share a UID, so the RECURRENCE-ID is appended.
+func (e Event) Key() string {
+	if e.RecurrenceID != "" {
+		return e.UID + "@" + e.RecurrenceID
+	}
+	return e.UID
+}
+
+// Duration returns the length of the event
+func (e Event) Duration() time.Duration {
+	if e.End.IsZero() {
+		return 0
+	}
+	return e.End.Sub(e.Start)
+}
+
+// Domains returns the unique, lowercased email domains of the organizer and attendees
+func (e Event) Domains() []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, addr := range append([]string{e.Organizer}, e.Attendees...) {
+		at := strings.LastIndex(addr, "@")
+		if at < 0 {
+			continue
+		}
+		domain := strings.ToLower(addr[at+1:])
+		if domain != "" && !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// property is a single content line split into name, parameters and value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads an iCalendar stream and returns its events.
+// Times with a TZID are resolved through the system timezone database,
+// floating times are interpreted in the local timezone.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &Calendar{}
+	var current *Event
+	depth := 0 // nesting inside VEVENT (e.g. VALARM)
+
+	for _, line := range lines {
+		prop, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			current = &Event{}
+			depth = 0
+			continue
+		case prop.name == "BEGIN" && current != nil:
+			depth++
+			continue
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if current != nil {
+				// DURATION may appear before DTSTART, so it is only applied here
+				if current.End.IsZero() && !current.Start.IsZero() {
+					if current.duration != 0 {
+						current.End = current.Start.Add(current.duration)
+					} else if current.AllDay {
+						current.End = current.Start.AddDate(0, 0, 1)
+					}
+				}
+				cal.Events = append(cal.Events, *current)
+			}
+			current = nil
+			continue
+		case prop.name == "END" && current != nil:
+			depth--
+			continue
+		}
+
+		if current == nil {
+			if prop.name == "X-WR-CALNAME" {
+				cal.Name = unescapeText(prop.value)
+			}
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			current.UID = prop.value
+		case "RECURRENCE-ID":
+			current.RecurrenceID = prop.value
+			if t, _, err := parseDateTime(prop); err == nil {
+				current.recurrenceStart = t
+			}
+		case "SUMMARY":
+			current.Summary = unescapeText(prop.value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(prop.value)
+		case "LOCATION":
+			current.Location = unescapeText(prop.value)
+		case "STATUS":
+			current.Status = strings.ToUpper(prop.value)
+		case "RRULE":
+			current.Recurring = true
+			current.RRule = prop.value
+		case "EXDATE":
+			for _, v := range strings.Split(prop.value, ",") {
+				t, _, err := parseDateTime(property{name: prop.name, params: prop.params, value: v})
+				if err != nil {
+					return nil, fmt.Errorf("event %s: invalid EXDATE: %w", current.UID, err)
+				}
+				current.ExDates = append(current.ExDates, t)
+			}
+		case "CATEGORIES":
+			for _, c := range strings.Split(prop.value, ",") {
+				if c = strings.TrimSpace(unescapeText(c)); c != "" {
+					current.Categories = append(current.Categories, c)
+				}
+			}
+		case "ORGANIZER":
+			current.Organizer = mailAddress(prop.value)
+		case "ATTENDEE":
+			if addr := mailAddress(prop.value); addr != "" {
+				current.Attendees = append(current.Attendees, addr)
+			}
+		case "DTSTART":
+			t, allDay, err := parseDateTime(prop)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: invalid DTSTART: %w", current.UID, err)
+			}
+			current.Start = t
+			current.AllDay = allDay
+		case "DTEND":
+			t, _, err := parseDateTime(prop)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: invalid DTEND: %w", current.UID, err)
+			}
+			current.End = t
+		case "DURATION":
+			d, err := ParseDuration(prop.value)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: invalid DURATION: %w", current.UID, err)
+			}
+			current.duration = d
+		}
+	}
+
+	return cal, nil
+}
+
+// unfold reads content lines and joins continuation lines (RFC 5545 §3.1)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseLine splits "NAME;PARAM=VAL:value" into its parts, honoring quoted parameter values
+func parseLine(line string) (property, bool) {
+	inQuotes := false
+	colon := -1
+	for i, ch := range line {
+		if ch == '"' {
+			inQuotes = !inQuotes
+		} else if ch == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+
+	head := line[:colon]
+	prop := property{value: line[colon+1:], params: make(map[string]string)}
+
+	parts := strings.Split(head, ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) == 2 {
+			prop.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return prop, true
+}
+
+// parseDateTime parses DATE and DATE-TIME values, returning whether the value was a date only
+func parseDateTime(prop property) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+
+	if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration parses an RFC 5545 duration such as "PT1H30M" or "P1D"
+func ParseDuration(s string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("unsupported duration: %s", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+2])
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// mailAddress extracts the address from a "mailto:" calendar user value
+func mailAddress(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 7 && strings.EqualFold(value[:7], "mailto:") {
+		value = value[7:]
+	}
+	if !strings.Contains(value, "@") {
+		return ""
+	}
+	return strings.ToLower(value)
+}
+
+// unescapeText reverses TEXT value escaping (RFC 5545 §3.3.11)
+func unescapeText(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(s)
+}
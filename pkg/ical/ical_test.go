@@ -0,0 +1,297 @@
+package ical
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"X-WR-CALNAME:Acme Work\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:abc-123@example.com\r\n" +
+	"DTSTART:20240115T090000Z\r\n" +
+	"DTEND:20240115T103000Z\r\n" +
+	"SUMMARY:Sprint planning\\, Q1\r\n" +
+	"DESCRIPTION:Agenda:\\nbacklog review\r\n" +
+	"ORGANIZER;CN=Jane:mailto:jane@acme.com\r\n" +
+	"ATTENDEE;CN=\"Doe, John\";ROLE=REQ-PARTICIPANT:mailto:John@Acme.com\r\n" +
+	"ATTENDEE:mailto:me@example.org\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:def-456\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20240116T140000\r\n" +
+	"DURATION:PT45M\r\n" +
+	"SUMMARY:A very long meeting title that has been folded by the \r\n" +
+	" calendar app\r\n" +
+	"RRULE:FREQ=WEEKLY\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"DTSTART;VALUE=DATE:20240117\r\n" +
+	"SUMMARY:Day off\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	cal, err := Parse(strings.NewReader(sampleCalendar))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if cal.Name != "Acme Work" {
+		t.Errorf("expected calendar name 'Acme Work', got %q", cal.Name)
+	}
+	if len(cal.Events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(cal.Events))
+	}
+
+	first := cal.Events[0]
+	if first.UID != "abc-123@example.com" {
+		t.Errorf("unexpected UID: %s", first.UID)
+	}
+	if first.Summary != "Sprint planning, Q1" {
+		t.Errorf("unexpected summary: %q", first.Summary)
+	}
+	if first.Description != "Agenda:\nbacklog review" {
+		t.Errorf("VALARM description leaked or text not unescaped: %q", first.Description)
+	}
+	if first.Duration() != 90*time.Minute {
+		t.Errorf("expected 90m duration, got %v", first.Duration())
+	}
+	if first.Organizer != "jane@acme.com" {
+		t.Errorf("unexpected organizer: %s", first.Organizer)
+	}
+	if len(first.Attendees) != 2 || first.Attendees[0] != "john@acme.com" {
+		t.Errorf("unexpected attendees: %v", first.Attendees)
+	}
+	domains := first.Domains()
+	if len(domains) != 2 || domains[0] != "acme.com" || domains[1] != "example.org" {
+		t.Errorf("unexpected domains: %v", domains)
+	}
+
+	second := cal.Events[1]
+	if second.Summary != "A very long meeting title that has been folded by the calendar app" {
+		t.Errorf("folded line not unfolded: %q", second.Summary)
+	}
+	if !second.Recurring {
+		t.Error("expected event with RRULE to be marked recurring")
+	}
+	if second.Duration() != 45*time.Minute {
+		t.Errorf("expected 45m duration, got %v", second.Duration())
+	}
+	if loc, err := time.LoadLocation("Europe/Berlin"); err == nil {
+		want := time.Date(2024, 1, 16, 14, 0, 0, 0, loc)
+		if !second.Start.Equal(want) {
+			t.Errorf("expected start %v, got %v", want, second.Start)
+		}
+	}
+
+	third := cal.Events[2]
+	if !third.AllDay {
+		t.Error("expected all-day event")
+	}
+	if third.Status != "CANCELLED" {
+		t.Errorf("expected CANCELLED status, got %s", third.Status)
+	}
+	if third.Duration() != 24*time.Hour {
+		t.Errorf("expected all-day event to last 24h, got %v", third.Duration())
+	}
+}
+
+func TestEventKey(t *testing.T) {
+	e := Event{UID: "abc"}
+	if e.Key() != "abc" {
+		t.Errorf("expected key 'abc', got %s", e.Key())
+	}
+	e.RecurrenceID = "20240115T090000Z"
+	if e.Key() != "abc@20240115T090000Z" {
+		t.Errorf("unexpected key for recurrence instance: %s", e.Key())
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		hasError bool
+	}{
+		{"PT1H30M", 90 * time.Minute, false},
+		{"P1D", 24 * time.Hour, false},
+		{"P1W", 7 * 24 * time.Hour, false},
+		{"PT15S", 15 * time.Second, false},
+		{"-PT5M", -5 * time.Minute, false},
+		{"1 hour", 0, true},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDuration(tt.input)
+		if tt.hasError {
+			if err == nil {
+				t.Errorf("expected error for %q", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", tt.input, err)
+			continue
+		}
+		if d != tt.expected {
+			t.Errorf("ParseDuration(%q) = %v; want %v", tt.input, d, tt.expected)
+		}
+	}
+}
+
+func TestParseDurationBeforeStart(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:dur-first\r\n" +
+		"DURATION:PT30M\r\n" +
+		"DTSTART:20240115T090000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(cal.Events) != 1 || cal.Events[0].Duration() != 30*time.Minute {
+		t.Fatalf("expected one 30m event, got %+v", cal.Events)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup\r\n" +
+		"DTSTART:20240101T090000Z\r\n" +
+		"DTEND:20240101T091500Z\r\n" +
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6\r\n" +
+		"EXDATE:20240103T090000Z\r\n" +
+		"SUMMARY:Standup\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup\r\n" +
+		"RECURRENCE-ID:20240108T090000Z\r\n" +
+		"DTSTART:20240108T100000Z\r\n" +
+		"DTEND:20240108T103000Z\r\n" +
+		"SUMMARY:Standup (moved)\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:single\r\n" +
+		"DTSTART:20240102T120000Z\r\n" +
+		"DTEND:20240102T130000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	events, err := Expand(cal.Events, time.Time{}, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expand() error: %v", err)
+	}
+
+	// COUNT=6: Jan 1, 3, 8, 10, 15, 17; Jan 3 is excluded and Jan 8 is overridden
+	var starts []string
+	keys := make(map[string]bool)
+	for _, e := range events {
+		if e.UID != "standup" {
+			continue
+		}
+		starts = append(starts, e.Start.UTC().Format("01-02 15:04"))
+		if keys[e.Key()] {
+			t.Errorf("duplicate key %s", e.Key())
+		}
+		keys[e.Key()] = true
+		if e.Duration() != 15*time.Minute && e.Summary == "Standup" {
+			t.Errorf("occurrence lost its duration: %v", e.Duration())
+		}
+	}
+	want := "01-01 09:00,01-08 10:00,01-10 09:00,01-15 09:00,01-17 09:00"
+	sort.Strings(starts)
+	if got := strings.Join(starts, ","); got != want {
+		t.Errorf("occurrences = %s; want %s", got, want)
+	}
+	if len(events) != 6 {
+		t.Errorf("expected 6 events including the single one, got %d", len(events))
+	}
+
+	// A range in the middle of the series only yields occurrences inside it
+	events, err = Expand(cal.Events[:1], time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expand() error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("expected 2 occurrences in range, got %d", len(events))
+	}
+}
+
+func TestExpandMonthly(t *testing.T) {
+	base := Event{
+		UID:       "review",
+		Start:     time.Date(2024, 1, 31, 15, 0, 0, 0, time.UTC),
+		End:       time.Date(2024, 1, 31, 16, 0, 0, 0, time.UTC),
+		Recurring: true,
+	}
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rrule string
+		want  []int // days of the occurrences
+	}{
+		{"FREQ=MONTHLY", []int{31, 31, 31}}, // Jan, Mar, May; months without a 31st are skipped
+		{"FREQ=MONTHLY;BYDAY=-1FR", []int{26, 23, 29, 26, 31}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20240401", []int{31, 29, 31}},
+	}
+
+	for _, tt := range tests {
+		e := base
+		e.RRule = tt.rrule
+		if tt.rrule != "FREQ=MONTHLY" {
+			e.Start = time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+			e.End = e.Start.Add(time.Hour)
+		}
+		events, err := Expand([]Event{e}, time.Time{}, to)
+		if err != nil {
+			t.Fatalf("%s: Expand() error: %v", tt.rrule, err)
+		}
+		var days []int
+		for _, occ := range events {
+			days = append(days, occ.Start.Day())
+		}
+		if len(days) != len(tt.want) {
+			t.Errorf("%s: got days %v; want %v", tt.rrule, days, tt.want)
+			continue
+		}
+		for i := range days {
+			if days[i] != tt.want[i] {
+				t.Errorf("%s: got days %v; want %v", tt.rrule, days, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestExpandRejectsUnsupportedRule(t *testing.T) {
+	e := Event{
+		UID:       "odd",
+		Start:     time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		End:       time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		Recurring: true,
+		RRule:     "FREQ=MONTHLY;BYSETPOS=-1;BYDAY=MO,TU,WE,TH,FR",
+	}
+	if _, err := Expand([]Event{e}, time.Time{}, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected an error for an RRULE that cannot be expanded")
+	}
+}
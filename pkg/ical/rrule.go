@@ -0,0 +1,304 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds the expansion of a single recurring event
+const maxOccurrences = 10000
+
+// rule is the supported subset of an RRULE (RFC 5545 §3.3.10)
+type rule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+}
+
+// weekdayNum is a BYDAY entry such as "MO" or "-1FR"
+type weekdayNum struct {
+	n       int // 0 means every such weekday in the period
+	weekday time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRule parses an RRULE value, rejecting parts that cannot be expanded
+func parseRule(value string, loc *time.Location) (*rule, error) {
+	r := &rule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			switch val {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				r.freq = val
+			default:
+				return nil, fmt.Errorf("unsupported FREQ=%s", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL=%s", val)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT=%s", val)
+			}
+			r.count = n
+		case "UNTIL":
+			t, _, err := parseDateTime(property{value: val, params: map[string]string{}})
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL=%s", val)
+			}
+			if len(val) == 8 {
+				// A date-only UNTIL includes occurrences on that day
+				t = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, loc)
+			}
+			r.until = t
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				wd, ok := weekdays[d[max(0, len(d)-2):]]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY=%s", val)
+				}
+				n := 0
+				if prefix := d[:len(d)-2]; prefix != "" {
+					var err error
+					if n, err = strconv.Atoi(prefix); err != nil || n == 0 {
+						return nil, fmt.Errorf("invalid BYDAY=%s", val)
+					}
+				}
+				r.byDay = append(r.byDay, weekdayNum{n: n, weekday: wd})
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY=%s", val)
+				}
+				r.byMonthDay = append(r.byMonthDay, n)
+			}
+		case "WKST":
+			// Only affects weekly rules with INTERVAL>1 and BYDAY; weeks start on Monday
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %s", key)
+		}
+	}
+
+	if r.freq == "" {
+		return nil, fmt.Errorf("RRULE without FREQ")
+	}
+	for _, d := range r.byDay {
+		if d.n != 0 && r.freq != "MONTHLY" {
+			return nil, fmt.Errorf("BYDAY with ordinal is only supported for FREQ=MONTHLY")
+		}
+	}
+	if len(r.byMonthDay) > 0 && r.freq != "MONTHLY" {
+		return nil, fmt.Errorf("BYMONTHDAY is only supported for FREQ=MONTHLY")
+	}
+	if len(r.byDay) > 0 && r.freq == "YEARLY" {
+		return nil, fmt.Errorf("BYDAY is not supported for FREQ=YEARLY")
+	}
+	return r, nil
+}
+
+// Expand replaces recurring events with their occurrences starting in [from, to).
+// Modified instances (RECURRENCE-ID) replace the occurrence they override and
+// EXDATE occurrences are dropped. A zero from means no lower bound; to is required
+// so that open-ended rules terminate. Recurring events whose RRULE uses parts that
+// cannot be expanded are rejected rather than imported as a single entry.
+func Expand(events []Event, from, to time.Time) ([]Event, error) {
+	overridden := make(map[string]bool)
+	for _, e := range events {
+		if e.RecurrenceID != "" && !e.recurrenceStart.IsZero() {
+			overridden[e.UID+"@"+e.recurrenceStart.UTC().Format(time.RFC3339)] = true
+		}
+	}
+
+	var out []Event
+	for _, e := range events {
+		if !e.Recurring || e.RecurrenceID != "" {
+			out = append(out, e)
+			continue
+		}
+		if e.Start.IsZero() {
+			return nil, fmt.Errorf("event %s: recurring event without DTSTART", e.UID)
+		}
+
+		r, err := parseRule(e.RRule, e.Start.Location())
+		if err != nil {
+			return nil, fmt.Errorf("event %s (%s): %w", e.UID, e.Summary, err)
+		}
+
+		excluded := make(map[int64]bool)
+		for _, ex := range e.ExDates {
+			excluded[ex.Unix()] = true
+		}
+
+		length := e.Duration()
+		for _, start := range r.occurrences(e.Start, to) {
+			if (!from.IsZero() && start.Before(from)) || excluded[start.Unix()] {
+				continue
+			}
+			if overridden[e.UID+"@"+start.UTC().Format(time.RFC3339)] {
+				continue
+			}
+			occ := e
+			occ.Start = start
+			occ.End = start.Add(length)
+			occ.RecurrenceID = formatRecurrenceID(start, e.AllDay)
+			occ.ExDates = nil
+			out = append(out, occ)
+		}
+	}
+	return out, nil
+}
+
+// formatRecurrenceID renders an occurrence start the way RECURRENCE-ID values are written
+func formatRecurrenceID(t time.Time, allDay bool) string {
+	if allDay {
+		return t.Format("20060102")
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// occurrences returns the starts of the rule's occurrences before to, beginning at dtstart.
+// COUNT and UNTIL are applied to the full series so that a range in the middle
+// of a series yields the same occurrences as expanding it from the start.
+func (r *rule) occurrences(dtstart, to time.Time) []time.Time {
+	var out []time.Time
+	seen := 0
+	for period := 0; seen < maxOccurrences; period++ {
+		candidates, periodStart := r.period(dtstart, period)
+		if !periodStart.Before(to) || (!r.until.IsZero() && periodStart.After(r.until)) {
+			break
+		}
+		for _, c := range candidates {
+			if c.Before(dtstart) {
+				continue
+			}
+			if !c.Before(to) || (!r.until.IsZero() && c.After(r.until)) {
+				return out
+			}
+			seen++
+			if r.count > 0 && seen > r.count {
+				return out
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// period returns the sorted candidate starts of the n-th period after dtstart,
+// along with the beginning of that period
+func (r *rule) period(dtstart time.Time, n int) ([]time.Time, time.Time) {
+	loc := dtstart.Location()
+	h, m, s := dtstart.Clock()
+	at := func(y int, mo time.Month, d int) time.Time {
+		return time.Date(y, mo, d, h, m, s, 0, loc)
+	}
+	step := n * r.interval
+
+	var candidates []time.Time
+	var periodStart time.Time
+
+	switch r.freq {
+	case "DAILY":
+		day := at(dtstart.Year(), dtstart.Month(), dtstart.Day()+step)
+		periodStart = day
+		if len(r.byDay) == 0 || r.hasWeekday(day.Weekday()) {
+			candidates = append(candidates, day)
+		}
+
+	case "WEEKLY":
+		offset := (int(dtstart.Weekday()) + 6) % 7 // days since Monday
+		monday := at(dtstart.Year(), dtstart.Month(), dtstart.Day()-offset+7*step)
+		periodStart = monday
+		days := r.byDay
+		if len(days) == 0 {
+			days = []weekdayNum{{weekday: dtstart.Weekday()}}
+		}
+		for _, d := range days {
+			candidates = append(candidates, monday.AddDate(0, 0, (int(d.weekday)+6)%7))
+		}
+
+	case "MONTHLY":
+		first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1, 0, 0, 0, 0, loc)
+		periodStart = first
+		y, mo := first.Year(), first.Month()
+		last := time.Date(y, mo+1, 0, 0, 0, 0, 0, loc).Day()
+
+		switch {
+		case len(r.byMonthDay) > 0:
+			for _, d := range r.byMonthDay {
+				if d < 0 {
+					d = last + d + 1
+				}
+				if d >= 1 && d <= last {
+					candidates = append(candidates, at(y, mo, d))
+				}
+			}
+		case len(r.byDay) > 0:
+			for _, wd := range r.byDay {
+				var days []int
+				for d := 1; d <= last; d++ {
+					if at(y, mo, d).Weekday() == wd.weekday {
+						days = append(days, d)
+					}
+				}
+				switch {
+				case wd.n == 0:
+					for _, d := range days {
+						candidates = append(candidates, at(y, mo, d))
+					}
+				case wd.n > 0 && wd.n <= len(days):
+					candidates = append(candidates, at(y, mo, days[wd.n-1]))
+				case wd.n < 0 && -wd.n <= len(days):
+					candidates = append(candidates, at(y, mo, days[len(days)+wd.n]))
+				}
+			}
+		default:
+			// Months without the start day (e.g. the 31st) are skipped
+			if dtstart.Day() <= last {
+				candidates = append(candidates, at(y, mo, dtstart.Day()))
+			}
+		}
+
+	case "YEARLY":
+		y := dtstart.Year() + step
+		periodStart = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		if c := at(y, dtstart.Month(), dtstart.Day()); c.Day() == dtstart.Day() {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	return candidates, periodStart
+}
+
+func (r *rule) hasWeekday(wd time.Weekday) bool {
+	for _, d := range r.byDay {
+		if d.weekday == wd {
+			return true
+		}
+	}
+	return false
+}
@@ -2,70 +2,108 @@ package invoice
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/Andriiklymiuk/ung/internal/config"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/payqr"
+	"github.com/Andriiklymiuk/ung/pkg/pdffont"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+	"github.com/Andriiklymiuk/ung/pkg/template"
 	"github.com/jung-kurt/gofpdf"
 )
 
 // CurrencySymbols maps currency codes to their symbols
-var CurrencySymbols = map[string]string{
-	"USD": "$",
-	"EUR": "€",
-	"GBP": "£",
-	"JPY": "¥",
-	"CNY": "¥",
-	"CHF": "CHF ",
-	"CAD": "C$",
-	"AUD": "A$",
-	"NZD": "NZ$",
-	"INR": "₹",
-	"KRW": "₩",
-	"BRL": "R$",
-	"MXN": "MX$",
-	"RUB": "₽",
-	"TRY": "₺",
-	"PLN": "zł",
-	"SEK": "kr",
-	"NOK": "kr",
-	"DKK": "kr",
-	"CZK": "Kč",
-	"HUF": "Ft",
-	"UAH": "₴",
-	"ILS": "₪",
-	"SGD": "S$",
-	"HKD": "HK$",
-	"THB": "฿",
-	"ZAR": "R",
-}
+var CurrencySymbols = locale.CurrencySymbols
 
 // FormatCurrency formats an amount with the appropriate currency symbol
 func FormatCurrency(amount float64, currency string) string {
-	symbol, ok := CurrencySymbols[strings.ToUpper(currency)]
-	if !ok {
-		symbol = currency + " "
-	}
-	return fmt.Sprintf("%s%.2f", symbol, amount)
+	return fmt.Sprintf("%s%.2f", locale.Symbol(currency), amount)
 }
 
 // GeneratePDF creates a professional PDF invoice with enhanced features
 func GeneratePDF(invoice models.Invoice, company models.Company, client models.Client, lineItems []models.InvoiceLineItem) (string, error) {
+	invoicesDir := config.GetInvoicesDir()
+	if err := os.MkdirAll(invoicesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create invoices directory: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s.pdf", invoice.InvoiceNum)
+	pdfPath := filepath.Join(invoicesDir, filename)
+
+	// The active template from `ung template use` replaces the built-in layout
+	if cfg, _ := config.Load(); template.HasCustomTemplate(cfg, "invoice") {
+		data := template.InvoiceData{Invoice: invoice, Company: company, Client: client, LineItems: lineItems, Config: cfg}
+		if err := template.RenderWithTemplate(cfg.Templates.InvoiceHTML, data, pdfPath); err != nil {
+			return "", err
+		}
+		return pdfPath, nil
+	}
+
+	pdf, err := render(invoice, company, client, lineItems, false)
+	if err != nil {
+		return "", err
+	}
+
+	// Save PDF
+	err = pdf.OutputFileAndClose(pdfPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to save PDF: %w", err)
+	}
+
+	return pdfPath, nil
+}
+
+// WriteEmbeddedPDF writes the invoice PDF to w with all fonts embedded, as
+// PDF/A needs: a configured core font is replaced with the bundled one
+func WriteEmbeddedPDF(w io.Writer, invoice models.Invoice, company models.Company, client models.Client, lineItems []models.InvoiceLineItem) error {
+	pdf, err := render(invoice, company, client, lineItems, true)
+	if err != nil {
+		return err
+	}
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
+
+// render draws the invoice
+func render(invoice models.Invoice, company models.Company, client models.Client, lineItems []models.InvoiceLineItem, embedFonts bool) (*gofpdf.Fpdf, error) {
 	pdf := gofpdf.New("P", "mm", "A4", "")
 
 	// Load configuration
 	cfg, _ := config.Load()
+	loc, cfg := locale.For(cfg, client.Language)
 	pdfCfg := cfg.PDF
 
+	// The tax rows are labelled by the invoice's tax profile, e.g. "Sales Tax"
+	taxLabel := pdfCfg.TaxLabel
+	if invoice.TaxProfile != "" {
+		if p, err := tax.Lookup(cfg, invoice.TaxProfile); err == nil && p.Label != "" {
+			taxLabel = p.Label
+		}
+	}
+
+	fontCfg := pdffont.FromConfig(pdfCfg)
+	if embedFonts && fontCfg.Regular == "" {
+		fontCfg.Family = pdffont.Bundled
+	}
+	font, err := pdffont.Setup(pdf, fontCfg, invoice, company, client, lineItems, cfg.Invoice, pdfCfg, taxLabel, loc.Money(0, invoice.Currency))
+	if err != nil {
+		return nil, err
+	}
+
 	// Set up page footer with page numbers
 	if pdfCfg.ShowPageNumber {
 		pdf.SetFooterFunc(func() {
 			pdf.SetY(-15)
-			pdf.SetFont("Arial", "I", 8)
+			pdf.SetFont(font, "I", 8)
 			pdf.SetTextColor(128, 128, 128)
-			pdf.CellFormat(0, 10, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+			pdf.CellFormat(0, 10, fmt.Sprintf("%s %d/{nb}", loc.Text.Page, pdf.PageNo()), "", 0, "C", false, 0, "")
 		})
 		pdf.AliasNbPages("")
 	}
@@ -74,7 +112,7 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 
 	// Draw watermark if enabled
 	if pdfCfg.ShowWatermark {
-		drawWatermark(pdf, invoice.Status, pdfCfg)
+		drawWatermark(pdf, font, invoice.Status, pdfCfg)
 	}
 
 	// Set margins
@@ -93,7 +131,7 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 	}
 
 	// Company name (after logo or at start)
-	pdf.SetFont("Arial", "B", 18)
+	pdf.SetFont(font, "B", 18)
 	pdf.SetTextColor(40, 40, 40)
 	// Only add offset if there's actually a logo
 	nameX := leftMargin
@@ -104,7 +142,7 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 	pdf.Cell(contentWidth/2-nameX+leftMargin, 10, company.Name)
 
 	// INVOICE title on right with custom color
-	pdf.SetFont("Arial", "B", 28)
+	pdf.SetFont(font, "B", 28)
 	pdf.SetTextColor(pdfCfg.PrimaryColor.R, pdfCfg.PrimaryColor.G, pdfCfg.PrimaryColor.B)
 	pdf.SetXY(pageWidth-rightMargin-60, headerY)
 	pdf.Cell(60, 10, cfg.Invoice.InvoiceLabel)
@@ -113,19 +151,19 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 
 	// Company details below name
-	pdf.SetFont("Arial", "", 9)
+	pdf.SetFont(font, "", 9)
 	pdf.SetXY(leftMargin, 27)
 
 	// Registration number and Tax ID
 	if company.TaxID != "" {
-		pdf.Cell(contentWidth/2, 4, fmt.Sprintf("Tax ID: %s", company.TaxID))
+		pdf.Cell(contentWidth/2, 4, fmt.Sprintf("%s: %s", loc.Text.TaxID, company.TaxID))
 		pdf.Ln(4)
 	}
 
 	// Bank Details
 	if company.BankAccount != "" {
 		pdf.SetX(leftMargin)
-		bankInfo := "Bank: " + company.BankAccount
+		bankInfo := loc.Text.Bank + ": " + company.BankAccount
 		if company.BankName != "" {
 			bankInfo = company.BankName + " | " + company.BankAccount
 		}
@@ -145,30 +183,37 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 	// Bill To section
 	billToY := pdf.GetY() + 8
 	pdf.SetXY(leftMargin, billToY)
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(font, "B", 10)
 	pdf.SetTextColor(pdfCfg.PrimaryColor.R, pdfCfg.PrimaryColor.G, pdfCfg.PrimaryColor.B)
 	pdf.Cell(40, 5, cfg.Invoice.BillToLabel)
 
-	pdf.SetFont("Arial", "B", 11)
+	pdf.SetFont(font, "B", 11)
 	pdf.SetTextColor(40, 40, 40)
 	pdf.SetXY(leftMargin, billToY+7)
 	pdf.Cell(contentWidth/2, 5, client.Name)
 
-	pdf.SetFont("Arial", "", 9)
+	pdf.SetFont(font, "", 9)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	currentY := billToY + 13
 
+	// Billing contact
+	if contacts := client.ContactsFor(models.ContactBilling); len(contacts) > 0 && contacts[0].Name != "" {
+		pdf.SetXY(leftMargin, currentY)
+		pdf.Cell(contentWidth/2, 4, fmt.Sprintf("%s %s", loc.Text.Attention, contacts[0].Name))
+		currentY += 4
+	}
+
 	// Client tax ID
 	if client.TaxID != "" {
 		pdf.SetXY(leftMargin, currentY)
-		pdf.Cell(contentWidth/2, 4, fmt.Sprintf("Tax ID: %s", client.TaxID))
+		pdf.Cell(contentWidth/2, 4, fmt.Sprintf("%s: %s", loc.Text.TaxID, client.TaxID))
 		currentY += 4
 	}
 
-	// Client address
-	if client.Address != "" {
+	// Client billing address
+	if address := client.AddressFor(models.AddressBilling); address != "" {
 		pdf.SetXY(leftMargin, currentY)
-		pdf.MultiCell(contentWidth/2, 4, client.Address, "", "L", false)
+		pdf.MultiCell(contentWidth/2, 4, address, "", "L", false)
 	}
 
 	// Invoice metadata on the right side
@@ -176,40 +221,54 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 	metaLabelX := pageWidth - rightMargin - 80
 	metaValueX := pageWidth - rightMargin - 40
 
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(font, "B", 10)
 	pdf.SetTextColor(pdfCfg.SecondaryColor.R, pdfCfg.SecondaryColor.G, pdfCfg.SecondaryColor.B)
 
 	// Invoice#
 	pdf.SetXY(metaLabelX, metaStartY)
-	pdf.Cell(40, 5, "Invoice#")
-	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(40, 5, loc.Text.InvoiceNumber)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetXY(metaValueX, metaStartY)
 	pdf.Cell(40, 5, invoice.InvoiceNum)
 
 	// Invoice Date
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(font, "B", 10)
 	pdf.SetTextColor(pdfCfg.SecondaryColor.R, pdfCfg.SecondaryColor.G, pdfCfg.SecondaryColor.B)
 	pdf.SetXY(metaLabelX, metaStartY+6)
-	pdf.Cell(40, 5, "Invoice Date")
-	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(40, 5, loc.Text.InvoiceDate)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetXY(metaValueX, metaStartY+6)
-	pdf.Cell(40, 5, invoice.IssuedDate.Format("02 Jan 2006"))
+	pdf.Cell(40, 5, loc.Date(invoice.IssuedDate))
 
 	// Due Date
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(font, "B", 10)
 	pdf.SetTextColor(pdfCfg.SecondaryColor.R, pdfCfg.SecondaryColor.G, pdfCfg.SecondaryColor.B)
 	pdf.SetXY(metaLabelX, metaStartY+12)
-	pdf.Cell(40, 5, "Due Date")
-	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(40, 5, loc.Text.DueDate)
+	pdf.SetFont(font, "", 10)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetXY(metaValueX, metaStartY+12)
-	pdf.Cell(40, 5, invoice.DueDate.Format("02 Jan 2006"))
+	pdf.Cell(40, 5, loc.Date(invoice.DueDate))
+
+	// The client's purchase order number
+	badgeY := metaStartY + 20
+	if invoice.PONumber != "" {
+		pdf.SetFont(font, "B", 10)
+		pdf.SetTextColor(pdfCfg.SecondaryColor.R, pdfCfg.SecondaryColor.G, pdfCfg.SecondaryColor.B)
+		pdf.SetXY(metaLabelX, metaStartY+18)
+		pdf.Cell(40, 5, loc.Text.PONumber)
+		pdf.SetFont(font, "", 10)
+		pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
+		pdf.SetXY(metaValueX, metaStartY+18)
+		pdf.Cell(40, 5, invoice.PONumber)
+		badgeY += 6
+	}
 
 	// Status badge
-	pdf.SetXY(metaLabelX, metaStartY+20)
-	drawStatusBadge(pdf, invoice.Status, pdfCfg)
+	pdf.SetXY(metaLabelX, badgeY)
+	drawStatusBadge(pdf, font, invoice.Status, pdfCfg)
 
 	// Line Items Table
 	tableY := pdf.GetY() + 15
@@ -221,30 +280,39 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 	// Ensure line items have proper names
 	for i := range lineItems {
 		if lineItems[i].ItemName == "" {
-			lineItems[i].ItemName = fmt.Sprintf("Software services in %s", invoice.IssuedDate.Format("January"))
+			lineItems[i].ItemName = locale.Fill(loc.Text.Services, "{month}", loc.Month(invoice.IssuedDate))
 		}
 	}
 
-	totals := drawLineItemsTable(pdf, lineItems, invoice.Currency, cfg.Invoice, pdfCfg, leftMargin, contentWidth)
+	totals := drawLineItemsTable(pdf, font, loc, lineItems, invoice.Currency, cfg.Invoice, pdfCfg, leftMargin, contentWidth)
 
-	// Draw tax breakdown if enabled
-	if pdfCfg.ShowTaxBreakdown && pdfCfg.TaxRate > 0 {
-		drawTaxBreakdown(pdf, totals, invoice.Currency, pdfCfg, leftMargin, contentWidth)
+	// Tax rows of taxed invoices, or of every invoice with the breakdown enabled
+	if totals.TaxAmount > 0 || pdfCfg.ShowTaxBreakdown {
+		drawTaxBreakdown(pdf, font, loc, totals, invoice.Currency, taxLabel, pdfCfg, leftMargin, contentWidth)
 	}
 
 	// Balance Due section with highlighting
-	drawBalanceDue(pdf, totals.GrandTotal, invoice.Currency, invoice.Status, pdfCfg, leftMargin, contentWidth)
+	drawBalanceDue(pdf, font, loc, totals.GrandTotal, invoice.Currency, invoice.Status, cfg.Invoice.TotalLabel, pdfCfg, leftMargin, contentWidth)
+
+	// Tax note, e.g. the mandatory reverse charge note
+	if invoice.TaxNote != "" {
+		pdf.Ln(3)
+		pdf.SetX(leftMargin)
+		pdf.SetFont(font, "I", 9)
+		pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
+		pdf.MultiCell(contentWidth, 4, invoice.TaxNote, "", "R", false)
+	}
 
 	// Notes section
 	notesY := pdf.GetY() + 15
 	pdf.SetXY(leftMargin, notesY)
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(font, "B", 10)
 	pdf.SetTextColor(pdfCfg.PrimaryColor.R, pdfCfg.PrimaryColor.G, pdfCfg.PrimaryColor.B)
 	pdf.Cell(40, 5, cfg.Invoice.NotesLabel)
-	pdf.SetFont("Arial", "", 9)
+	pdf.SetFont(font, "", 9)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetXY(leftMargin, notesY+6)
-	notesText := "Thank you for your business!"
+	notesText := loc.Text.ThankYou
 	if invoice.Description != "" {
 		notesText = invoice.Description
 	}
@@ -253,29 +321,22 @@ func GeneratePDF(invoice models.Invoice, company models.Company, client models.C
 	// Terms & Conditions
 	termsY := pdf.GetY() + 10
 	pdf.SetXY(leftMargin, termsY)
-	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFont(font, "B", 10)
 	pdf.SetTextColor(pdfCfg.PrimaryColor.R, pdfCfg.PrimaryColor.G, pdfCfg.PrimaryColor.B)
 	pdf.Cell(40, 5, cfg.Invoice.TermsLabel)
-	pdf.SetFont("Arial", "", 9)
+	pdf.SetFont(font, "", 9)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetXY(leftMargin, termsY+6)
 	pdf.MultiCell(contentWidth, 4, cfg.Invoice.Terms, "", "L", false)
 
-	// Save PDF
-	invoicesDir := config.GetInvoicesDir()
-	if err := os.MkdirAll(invoicesDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create invoices directory: %w", err)
-	}
-
-	filename := fmt.Sprintf("%s.pdf", invoice.InvoiceNum)
-	pdfPath := filepath.Join(invoicesDir, filename)
-
-	err := pdf.OutputFileAndClose(pdfPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to save PDF: %w", err)
+	// Payment QR code, pointless once the invoice is paid
+	if pdfCfg.ShowQRCode && invoice.Status != models.StatusPaid {
+		if err := drawPaymentQR(pdf, font, invoice, company, totals.GrandTotal, pdfCfg, leftMargin); err != nil {
+			return nil, err
+		}
 	}
 
-	return pdfPath, nil
+	return pdf, nil
 }
 
 // InvoiceTotals holds calculated invoice totals
@@ -285,6 +346,7 @@ type InvoiceTotals struct {
 	TaxableAmount float64
 	TaxAmount   float64
 	GrandTotal  float64
+	Rates       []tax.Rate // Net and tax by rate, highest first
 }
 
 // drawLogo draws the company logo and returns the X position after the logo
@@ -334,7 +396,7 @@ func drawLogo(pdf *gofpdf.Fpdf, logoPath string, x, y float64) float64 {
 }
 
 // drawWatermark draws a diagonal watermark based on invoice status
-func drawWatermark(pdf *gofpdf.Fpdf, status models.InvoiceStatus, cfg config.PDFConfig) {
+func drawWatermark(pdf *gofpdf.Fpdf, font string, status models.InvoiceStatus, cfg config.PDFConfig) {
 	var text string
 	var r, g, b int
 
@@ -366,7 +428,7 @@ func drawWatermark(pdf *gofpdf.Fpdf, status models.InvoiceStatus, cfg config.PDF
 
 	// Save current state
 	pdf.SetAlpha(0.1, "Normal")
-	pdf.SetFont("Arial", "B", 80)
+	pdf.SetFont(font, "B", 80)
 	pdf.SetTextColor(r, g, b)
 
 	// Calculate center position and draw rotated text
@@ -384,7 +446,7 @@ func drawWatermark(pdf *gofpdf.Fpdf, status models.InvoiceStatus, cfg config.PDF
 }
 
 // drawStatusBadge draws a colored status badge (only for paid and overdue)
-func drawStatusBadge(pdf *gofpdf.Fpdf, status models.InvoiceStatus, cfg config.PDFConfig) {
+func drawStatusBadge(pdf *gofpdf.Fpdf, font string, status models.InvoiceStatus, cfg config.PDFConfig) {
 	var text string
 	var r, g, b int
 
@@ -402,18 +464,18 @@ func drawStatusBadge(pdf *gofpdf.Fpdf, status models.InvoiceStatus, cfg config.P
 
 	pdf.SetFillColor(r, g, b)
 	pdf.SetTextColor(255, 255, 255)
-	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFont(font, "B", 8)
 
 	width := pdf.GetStringWidth(text) + 8
 	pdf.CellFormat(width, 6, text, "", 0, "C", true, 0, "")
 }
 
 // drawLineItemsTable creates the line items table and returns totals
-func drawLineItemsTable(pdf *gofpdf.Fpdf, items []models.InvoiceLineItem, currency string, labels config.InvoiceConfig, pdfCfg config.PDFConfig, leftMargin, contentWidth float64) InvoiceTotals {
+func drawLineItemsTable(pdf *gofpdf.Fpdf, font string, loc *locale.Pack, items []models.InvoiceLineItem, currency string, labels config.InvoiceConfig, pdfCfg config.PDFConfig, leftMargin, contentWidth float64) InvoiceTotals {
 	// Table header with primary color
 	pdf.SetFillColor(pdfCfg.PrimaryColor.R, pdfCfg.PrimaryColor.G, pdfCfg.PrimaryColor.B)
 	pdf.SetTextColor(255, 255, 255)
-	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFont(font, "B", 9)
 
 	// Check if any items have discounts
 	hasDiscounts := false
@@ -451,7 +513,7 @@ func drawLineItemsTable(pdf *gofpdf.Fpdf, items []models.InvoiceLineItem, curren
 	pdf.Ln(-1)
 
 	// Table rows
-	pdf.SetFont("Arial", "", 9)
+	pdf.SetFont(font, "", 9)
 	pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 	pdf.SetFillColor(255, 255, 255)
 
@@ -472,20 +534,16 @@ func drawLineItemsTable(pdf *gofpdf.Fpdf, items []models.InvoiceLineItem, curren
 		pdf.CellFormat(itemWidth, 8, itemText, "", 0, "L", false, 0, "")
 
 		// Quantity - show decimal only if needed
-		qtyStr := fmt.Sprintf("%.0f", item.Quantity)
-		if item.Quantity != float64(int(item.Quantity)) {
-			qtyStr = fmt.Sprintf("%.2f", item.Quantity)
-		}
-		pdf.CellFormat(qtyWidth, 8, qtyStr, "", 0, "C", false, 0, "")
+		pdf.CellFormat(qtyWidth, 8, loc.Quantity(item.Quantity), "", 0, "C", false, 0, "")
 
 		// Rate
-		pdf.CellFormat(rateWidth, 8, FormatCurrency(item.Rate, currency), "", 0, "R", false, 0, "")
+		pdf.CellFormat(rateWidth, 8, loc.Money(item.Rate, currency), "", 0, "R", false, 0, "")
 
 		// Discount
 		if hasDiscounts {
 			if discount > 0 {
 				pdf.SetTextColor(220, 20, 60) // Red for discount
-				pdf.CellFormat(discountWidth, 8, "-"+FormatCurrency(discount, currency), "", 0, "R", false, 0, "")
+				pdf.CellFormat(discountWidth, 8, "-"+loc.Money(discount, currency), "", 0, "R", false, 0, "")
 				pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 			} else {
 				pdf.CellFormat(discountWidth, 8, "-", "", 0, "R", false, 0, "")
@@ -493,7 +551,7 @@ func drawLineItemsTable(pdf *gofpdf.Fpdf, items []models.InvoiceLineItem, curren
 		}
 
 		// Amount
-		pdf.CellFormat(amountWidth, 8, FormatCurrency(lineAmount, currency), "", 0, "R", false, 0, "")
+		pdf.CellFormat(amountWidth, 8, loc.Money(lineAmount, currency), "", 0, "R", false, 0, "")
 		pdf.Ln(-1)
 
 		// Draw separator line
@@ -502,35 +560,37 @@ func drawLineItemsTable(pdf *gofpdf.Fpdf, items []models.InvoiceLineItem, curren
 
 		totals.Subtotal += item.Amount
 		totals.Discount += discount
-		totals.TaxAmount += item.TaxAmount
 	}
 
-	totals.TaxableAmount = totals.Subtotal - totals.Discount
-	totals.GrandTotal = totals.TaxableAmount + totals.TaxAmount
+	taxTotals := tax.Calculate(items)
+	totals.TaxableAmount = taxTotals.Net
+	totals.TaxAmount = taxTotals.Tax
+	totals.GrandTotal = taxTotals.Gross
+	totals.Rates = taxTotals.Rates
 
 	// Subtotal row
 	pdf.Ln(3)
 	pdf.SetX(leftMargin)
-	pdf.SetFont("Arial", "", 9)
+	pdf.SetFont(font, "", 9)
 	summaryLabelWidth := itemWidth + qtyWidth
 	if hasDiscounts {
 		summaryLabelWidth += discountWidth
 	}
 	pdf.CellFormat(summaryLabelWidth, 7, "", "", 0, "R", false, 0, "")
 	pdf.CellFormat(rateWidth, 7, pdfCfg.SubtotalLabel, "", 0, "R", false, 0, "")
-	pdf.SetFont("Arial", "B", 9)
-	pdf.CellFormat(amountWidth, 7, FormatCurrency(totals.Subtotal, currency), "", 0, "R", false, 0, "")
+	pdf.SetFont(font, "B", 9)
+	pdf.CellFormat(amountWidth, 7, loc.Money(totals.Subtotal, currency), "", 0, "R", false, 0, "")
 	pdf.Ln(-1)
 
 	// Discount row (if any)
 	if totals.Discount > 0 {
 		pdf.SetX(leftMargin)
-		pdf.SetFont("Arial", "", 9)
+		pdf.SetFont(font, "", 9)
 		pdf.CellFormat(summaryLabelWidth, 7, "", "", 0, "R", false, 0, "")
 		pdf.SetTextColor(220, 20, 60)
 		pdf.CellFormat(rateWidth, 7, pdfCfg.DiscountLabel, "", 0, "R", false, 0, "")
-		pdf.SetFont("Arial", "B", 9)
-		pdf.CellFormat(amountWidth, 7, "-"+FormatCurrency(totals.Discount, currency), "", 0, "R", false, 0, "")
+		pdf.SetFont(font, "B", 9)
+		pdf.CellFormat(amountWidth, 7, "-"+loc.Money(totals.Discount, currency), "", 0, "R", false, 0, "")
 		pdf.SetTextColor(pdfCfg.TextColor.R, pdfCfg.TextColor.G, pdfCfg.TextColor.B)
 		pdf.Ln(-1)
 	}
@@ -538,27 +598,40 @@ func drawLineItemsTable(pdf *gofpdf.Fpdf, items []models.InvoiceLineItem, curren
 	return totals
 }
 
-// drawTaxBreakdown draws the tax/VAT breakdown section
-func drawTaxBreakdown(pdf *gofpdf.Fpdf, totals InvoiceTotals, currency string, cfg config.PDFConfig, leftMargin, contentWidth float64) {
-	taxAmount := totals.TaxableAmount * cfg.TaxRate
-
-	pdf.SetX(leftMargin)
-	pdf.SetFont("Arial", "", 9)
-
-	// Tax row
+// drawTaxBreakdown draws a tax row per rate. Untaxed parts only get a 0%
+// row with the breakdown enabled.
+func drawTaxBreakdown(pdf *gofpdf.Fpdf, font string, loc *locale.Pack, totals InvoiceTotals, currency, label string, cfg config.PDFConfig, leftMargin, contentWidth float64) {
 	rateWidth := contentWidth * 0.20
 	amountWidth := contentWidth * 0.20
 	labelWidth := contentWidth - rateWidth - amountWidth
 
-	pdf.CellFormat(labelWidth, 7, "", "", 0, "R", false, 0, "")
-	pdf.CellFormat(rateWidth, 7, fmt.Sprintf("%s (%.0f%%)", cfg.TaxLabel, cfg.TaxRate*100), "", 0, "R", false, 0, "")
-	pdf.SetFont("Arial", "B", 9)
-	pdf.CellFormat(amountWidth, 7, FormatCurrency(taxAmount, currency), "", 0, "R", false, 0, "")
-	pdf.Ln(-1)
+	for _, r := range totals.Rates {
+		if r.Rate == 0 && !cfg.ShowTaxBreakdown {
+			continue
+		}
+		pdf.SetX(leftMargin)
+		pdf.SetFont(font, "", 9)
+		pdf.CellFormat(labelWidth, 7, "", "", 0, "R", false, 0, "")
+		pdf.CellFormat(rateWidth, 7, fmt.Sprintf("%s (%s%%)", label, percent(loc, r.Rate)), "", 0, "R", false, 0, "")
+		pdf.SetFont(font, "B", 9)
+		pdf.CellFormat(amountWidth, 7, loc.Money(r.Tax, currency), "", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+}
+
+// percent formats a tax rate as a percentage without trailing zeros, e.g.
+// 19 or 7,25
+func percent(loc *locale.Pack, rate float64) string {
+	s := loc.Number(rate*100, 3)
+	if loc.DecimalSep == "" {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, loc.DecimalSep)
 }
 
 // drawBalanceDue draws the total section without colored background
-func drawBalanceDue(pdf *gofpdf.Fpdf, total float64, currency string, status models.InvoiceStatus, cfg config.PDFConfig, leftMargin, contentWidth float64) {
+func drawBalanceDue(pdf *gofpdf.Fpdf, font string, loc *locale.Pack, total float64, currency string, status models.InvoiceStatus, label string, cfg config.PDFConfig, leftMargin, contentWidth float64) {
 	pdf.Ln(5)
 	pdf.SetX(leftMargin)
 
@@ -574,18 +647,47 @@ func drawBalanceDue(pdf *gofpdf.Fpdf, total float64, currency string, status mod
 
 	// Use dark text on white background for cleaner look
 	pdf.SetTextColor(cfg.TextColor.R, cfg.TextColor.G, cfg.TextColor.B)
-	pdf.SetFont("Arial", "B", 12)
-
-	// Always use "Total" label
-	label := "Total"
+	pdf.SetFont(font, "B", 12)
 
 	pdf.CellFormat(labelWidth, 10, "", "", 0, "R", false, 0, "")
 	pdf.CellFormat(rateWidth, 10, label, "", 0, "R", false, 0, "")
-	pdf.SetFont("Arial", "B", 14)
-	pdf.CellFormat(amountWidth, 10, FormatCurrency(total, currency), "", 0, "R", false, 0, "")
+	pdf.SetFont(font, "B", 14)
+	pdf.CellFormat(amountWidth, 10, loc.Money(total, currency), "", 0, "R", false, 0, "")
 	pdf.Ln(-1)
 }
 
+// drawPaymentQR draws the payment QR code with its caption below the terms,
+// on a new page when it doesn't fit
+func drawPaymentQR(pdf *gofpdf.Fpdf, font string, invoice models.Invoice, company models.Company, total float64, cfg config.PDFConfig, leftMargin float64) error {
+	payload, err := payqr.Payload(cfg.QRFormat, cfg.QRPaymentURL, payqr.FromInvoice(invoice, company, total))
+	if err != nil {
+		return fmt.Errorf("failed to create payment QR code: %w (or set pdf.show_qr_code to false)", err)
+	}
+
+	size := payqr.DefaultSize(cfg.QRFormat)
+	y := pdf.GetY() + 10
+	_, pageHeight := pdf.GetPageSize()
+	if y+6+size > pageHeight-20 {
+		pdf.AddPage()
+		y = 20
+	}
+
+	label := cfg.QRLabel
+	if label == "" {
+		label = "Scan to pay"
+	}
+	pdf.SetXY(leftMargin, y)
+	pdf.SetFont(font, "B", 10)
+	pdf.SetTextColor(cfg.PrimaryColor.R, cfg.PrimaryColor.G, cfg.PrimaryColor.B)
+	pdf.Cell(40, 5, label)
+
+	if err := payqr.Draw(pdf, cfg.QRFormat, payload, leftMargin, y+6, size); err != nil {
+		return fmt.Errorf("failed to draw payment QR code: %w", err)
+	}
+	pdf.SetY(y + 6 + size)
+	return nil
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -1,13 +1,21 @@
 package invoice
 
 import (
+	"bytes"
+	"compress/zlib"
+	"flag"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf16"
 
 	"github.com/Andriiklymiuk/ung/internal/config"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
 )
 
 // TestFormatCurrency tests the currency formatting function
@@ -571,3 +579,200 @@ func BenchmarkCalculateTotals(b *testing.B) {
 		_ = subtotal - totalDiscount
 	}
 }
+
+// TestGeneratePDFWithPaymentQR tests that a payment QR code is drawn when enabled
+func TestGeneratePDFWithPaymentQR(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config.SetForceGlobal(true)
+	defer config.SetForceGlobal(false)
+	cfg, _ := config.Load()
+	cfg.PDF.ShowQRCode = true
+	cfg.PDF.QRFormat = "epc"
+
+	now := time.Now()
+	company := models.Company{Name: "Test Company", BankAccount: "DE89 3704 0044 0532 0130 00", BankSWIFT: "COBADEFFXXX"}
+	client := models.Client{Name: "Test Client"}
+	invoice := models.Invoice{InvoiceNum: "QR-001", Currency: "EUR", Status: models.StatusPending, IssuedDate: now, DueDate: now}
+	lineItems := []models.InvoiceLineItem{{ItemName: "Test Service", Quantity: 1, Rate: 100, Amount: 100}}
+
+	pdfPath, err := GeneratePDF(invoice, company, client, lineItems)
+	if err != nil {
+		t.Fatalf("GeneratePDF failed: %v", err)
+	}
+	if _, err := os.Stat(pdfPath); err != nil {
+		t.Errorf("expected the PDF to be written: %v", err)
+	}
+
+	// EPC codes are for EUR only
+	invoice.Currency = "USD"
+	if _, err := GeneratePDF(invoice, company, client, lineItems); err == nil {
+		t.Error("expected an EPC code for a USD invoice to fail")
+	}
+
+	// Paid invoices don't need one
+	invoice.Status = models.StatusPaid
+	if _, err := GeneratePDF(invoice, company, client, lineItems); err != nil {
+		t.Errorf("expected no QR code on a paid invoice, got %v", err)
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGeneratePDFMultilingual renders Ukrainian and German invoices, and one
+// with a client's billing profile, with the bundled font and compares their
+// text with testdata/*.golden. Run with -update to rewrite the golden files
+// after an intended layout change.
+func TestGeneratePDFMultilingual(t *testing.T) {
+	issued := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		golden    string
+		labels    func(*config.InvoiceConfig)
+		company   models.Company
+		client    models.Client
+		invoice   models.Invoice
+		lineItems []models.InvoiceLineItem
+	}{
+		{
+			golden: "invoice_uk.golden",
+			labels: func(l *config.InvoiceConfig) {
+				l.InvoiceLabel, l.BillToLabel, l.ItemLabel, l.AmountLabel = "РАХУНОК", "Платник:", "Послуга", "Сума"
+			},
+			company:   models.Company{Name: "ФОП Шевченко Тарас", Address: "вул. Хрещатик, 1\nКиїв 01001", TaxID: "3456789012"},
+			client:    models.Client{Name: "ТОВ «Ґрунт і Ї»", Address: "Львів, пл. Ринок 5"},
+			invoice:   models.Invoice{InvoiceNum: "UK-001", Currency: "UAH", Status: models.StatusPending, IssuedDate: issued, DueDate: issued.AddDate(0, 0, 14)},
+			lineItems: []models.InvoiceLineItem{{ItemName: "Розробка програмного забезпечення", Description: "Березень", Quantity: 10, Rate: 1500, Amount: 15000}},
+		},
+		{
+			golden: "invoice_de.golden",
+			labels: func(l *config.InvoiceConfig) {
+				l.InvoiceLabel, l.BillToLabel, l.ItemLabel, l.AmountLabel = "RECHNUNG", "Rechnung an:", "Leistung", "Betrag"
+			},
+			company:   models.Company{Name: "Müller & Söhne GmbH", Address: "Königstraße 12\n70173 Stuttgart"},
+			client:    models.Client{Name: "Bäckerei Großmann", Address: "Straße des 17. Juni 3, Berlin"},
+			invoice:   models.Invoice{InvoiceNum: "DE-001", Currency: "EUR", Status: models.StatusPending, IssuedDate: issued, DueDate: issued.AddDate(0, 0, 30)},
+			lineItems: []models.InvoiceLineItem{{ItemName: "Beratung für Übersetzungen", Quantity: 2.5, Rate: 120, Amount: 300}},
+		},
+		{
+			golden: "invoice_de_tax.golden",
+			labels: func(l *config.InvoiceConfig) {
+				l.InvoiceLabel, l.BillToLabel, l.ItemLabel, l.AmountLabel = "RECHNUNG", "Rechnung an:", "Leistung", "Betrag"
+			},
+			company: models.Company{Name: "Müller & Söhne GmbH", Address: "Königstraße 12\n70173 Stuttgart", TaxID: "DE123456789"},
+			client:  models.Client{Name: "Bäckerei Großmann", Address: "Straße des 17. Juni 3, Berlin"},
+			invoice: models.Invoice{
+				InvoiceNum: "DE-002", Currency: "EUR", Status: models.StatusPending, IssuedDate: issued, DueDate: issued.AddDate(0, 0, 30),
+				Amount: 391.5, NetAmount: 350, TaxAmount: 41.5, TaxProfile: "vat", TaxType: "vat", TaxNote: "Leistungsdatum entspricht dem Rechnungsdatum.",
+			},
+			lineItems: []models.InvoiceLineItem{
+				{ItemName: "Beratung", Quantity: 2, Rate: 100, Amount: 200, TaxRate: 0.19, TaxAmount: 38},
+				{ItemName: "Fachbuch", Quantity: 1, Rate: 50, Amount: 50, TaxRate: 0.07, TaxAmount: 3.5},
+				{ItemName: "Porto", Quantity: 1, Rate: 100, Amount: 100},
+			},
+		},
+		{
+			golden:  "invoice_profile.golden",
+			labels:  func(l *config.InvoiceConfig) {},
+			company: models.Company{Name: "Acme Consulting", Address: "1 Main St\nSpringfield"},
+			client: models.Client{
+				Name:      "Initech",
+				Address:   "4120 Freidrich Ln, Austin",
+				Contacts:  []models.ClientContact{{Name: "Bill Lumbergh", Role: models.ContactTechnical}, {Name: "Accounts Payable", Role: models.ContactBilling}},
+				Addresses: []models.ClientAddress{{Kind: models.AddressBilling, Address: "PO Box 2046\nAustin, TX 78768"}},
+			},
+			invoice:   models.Invoice{InvoiceNum: "PO-001", Currency: "USD", Status: models.StatusPending, IssuedDate: issued, DueDate: issued.AddDate(0, 0, 45), PONumber: "4500012345"},
+			lineItems: []models.InvoiceLineItem{{ItemName: "TPS report automation", Quantity: 1, Rate: 900, Amount: 900}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			config.SetForceGlobal(true)
+			defer config.SetForceGlobal(false)
+			cfg, _ := config.Load()
+			tt.labels(&cfg.Invoice)
+
+			pdfPath, err := GeneratePDF(tt.invoice, tt.company, tt.client, tt.lineItems)
+			if err != nil {
+				t.Fatalf("GeneratePDF failed: %v", err)
+			}
+			data, err := os.ReadFile(pdfPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(data, []byte("/FontFile2")) {
+				t.Error("expected the bundled font to be embedded")
+			}
+
+			got := strings.Join(pdfText(t, data), "\n") + "\n"
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("text doesn't match %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+var (
+	streamStart = regexp.MustCompile(`/Length (\d+)>>\nstream\n`)
+	textShowing = regexp.MustCompile(`(?s)Td \((.*?[^\\])\)Tj`)
+)
+
+// pdfText returns the strings drawn in a PDF's content streams. It reads
+// the UTF-16 strings gofpdf writes for UTF-8 fonts.
+func pdfText(t *testing.T, data []byte) []string {
+	t.Helper()
+	var lines []string
+	for _, m := range streamStart.FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		if m[1]+length > len(data) {
+			continue
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data[m[1] : m[1]+length]))
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			continue
+		}
+		for _, m := range textShowing.FindAllSubmatch(content, -1) {
+			s := unescape(m[1])
+			units := make([]uint16, len(s)/2)
+			for i := range units {
+				units[i] = uint16(s[2*i])<<8 | uint16(s[2*i+1])
+			}
+			lines = append(lines, string(utf16.Decode(units)))
+		}
+	}
+	return lines
+}
+
+// unescape undoes the backslash escapes of a PDF string
+func unescape(s []byte) []byte {
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'r':
+				out = append(out, '\r')
+				continue
+			case 'n':
+				out = append(out, '\n')
+				continue
+			}
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
@@ -0,0 +1,305 @@
+// Package locale holds the built-in language packs used for documents and
+// emails: invoice and contract labels, month names, number, date and money
+// formats, and email subjects and bodies.
+//
+// The pack for a document is the client's language when set, otherwise
+// config.Language. Labels set in the config file win over the pack; a label
+// left at its English default is taken from the pack, so `language: de`
+// alone is enough for German invoices.
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+)
+
+// Pack is everything that changes with the document language
+type Pack struct {
+	Code string // ISO 639-1, e.g. "uk"
+	Name string // Name of the language in that language
+
+	Invoice  config.InvoiceConfig // Labels and default texts, see Apply
+	PDF      PDFLabels            // Labels kept in config.PDFConfig, see Apply
+	Text     Texts
+	Contract ContractTexts
+	Email    EmailTexts
+
+	Months     [12]string // Standalone month names, "березень"
+	MonthsDate [12]string // Month names inside a date, "2 березня"; empty uses Months
+
+	DateLayout     string // Go layout of short dates
+	LongDateLayout string // Go layout of written out dates, "January" is replaced with MonthsDate
+
+	DecimalSep   string
+	ThousandsSep string
+	MoneyFormat  string // "{amount}" and "{symbol}", e.g. "{amount} {symbol}"
+}
+
+// PDFLabels are the labels that live in config.PDFConfig
+type PDFLabels struct {
+	TaxLabel        string
+	SubtotalLabel   string
+	DiscountLabel   string
+	TaxAmountLabel  string
+	BalanceDueLabel string
+	PaidLabel       string
+	DraftLabel      string
+	OverdueLabel    string
+	QRLabel         string
+}
+
+// Texts are invoice texts that can't be set in the config file
+type Texts struct {
+	InvoiceNumber  string
+	InvoiceDate    string
+	DueDate        string
+	PONumber       string // The client's purchase order number
+	Attention      string // Before the contact an invoice is addressed to
+	TaxID          string
+	Bank           string
+	Account        string
+	Page           string // "Page", followed by "1/2"
+	Continued      string // Under a table that goes on on the next page
+	CarriedForward string // Subtotal at the bottom of a page a table goes on from
+	BroughtForward string // The same subtotal at the top of the next page
+	ThankYou       string // Notes of an invoice without a description
+	Services       string // Name of a line item without one, "{month}" is the month of the invoice
+	ReverseCharge  string // Mandatory note of EU reverse charge invoices
+	TaxExempt      string // Note of invoices exempt from VAT
+}
+
+// ContractTexts are the texts of a contract PDF. Placeholders are noted next
+// to each field.
+type ContractTexts struct {
+	Title             string
+	Reference         string // {number}
+	Intro             string // {date}
+	Provider          string
+	Client            string
+	Terms             string
+	HourlyRate        string // {rate}
+	FixedPrice        string // {price}
+	Period            string // {start} {end}
+	OpenEnded         string // {start}
+	AdditionalTerms   string
+	PaymentInfo       string
+	GeneralConditions string
+	Agreement         string
+	Signature         string
+}
+
+// EmailTexts are the built-in subjects and bodies of the emails ung
+// prepares, see email.InvoiceVars and email.ContractVars for their
+// placeholders. Reminders are invoice emails.
+type EmailTexts struct {
+	InvoiceSubject  string
+	InvoiceBody     string
+	ContractSubject string
+	ContractBody    string
+	ReminderSubject string
+	ReminderBody    string
+	PayNow          string // Button of HTML emails with a payment link
+}
+
+// Default is the language used when neither the client nor the config has one
+const Default = "en"
+
+var packs = map[string]*Pack{}
+
+func register(p *Pack) {
+	packs[p.Code] = p
+}
+
+// Get returns the pack for a language code such as "de" or "de-AT", or nil
+// when there is none
+func Get(code string) *Pack {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		code = code[:i]
+	}
+	return packs[code]
+}
+
+// Codes returns the codes of all packs, sorted
+func Codes() []string {
+	codes := make([]string, 0, len(packs))
+	for code := range packs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Validate returns an error when there is no pack for code. An empty code
+// is valid and means the configured language.
+func Validate(code string) error {
+	if code == "" || Get(code) != nil {
+		return nil
+	}
+	return fmt.Errorf("unsupported language %q (supported: %s)", code, strings.Join(Codes(), ", "))
+}
+
+// For returns the pack for a document: the client's language when set,
+// otherwise cfg.Language, otherwise English. The returned config has the
+// pack's labels where cfg keeps the English defaults.
+func For(cfg *config.Config, clientLanguage string) (*Pack, *config.Config) {
+	p := Get(clientLanguage)
+	if p == nil {
+		p = Get(cfg.Language)
+	}
+	if p == nil {
+		p = packs[Default]
+	}
+	return p, p.Apply(cfg)
+}
+
+// Apply returns a copy of cfg whose labels are taken from the pack, except
+// those changed from their English default in the config file
+func (p *Pack) Apply(cfg *config.Config) *config.Config {
+	en := packs[Default]
+	out := *cfg
+
+	inv, def, loc := &out.Invoice, en.Invoice, p.Invoice
+	inv.Terms = pick(inv.Terms, def.Terms, loc.Terms)
+	inv.PaymentNote = pick(inv.PaymentNote, def.PaymentNote, loc.PaymentNote)
+	inv.NotesLabel = pick(inv.NotesLabel, def.NotesLabel, loc.NotesLabel)
+	inv.TermsLabel = pick(inv.TermsLabel, def.TermsLabel, loc.TermsLabel)
+	inv.InvoiceLabel = pick(inv.InvoiceLabel, def.InvoiceLabel, loc.InvoiceLabel)
+	inv.FromLabel = pick(inv.FromLabel, def.FromLabel, loc.FromLabel)
+	inv.BillToLabel = pick(inv.BillToLabel, def.BillToLabel, loc.BillToLabel)
+	inv.DescriptionLabel = pick(inv.DescriptionLabel, def.DescriptionLabel, loc.DescriptionLabel)
+	inv.TotalLabel = pick(inv.TotalLabel, def.TotalLabel, loc.TotalLabel)
+	inv.ItemLabel = pick(inv.ItemLabel, def.ItemLabel, loc.ItemLabel)
+	inv.QuantityLabel = pick(inv.QuantityLabel, def.QuantityLabel, loc.QuantityLabel)
+	inv.RateLabel = pick(inv.RateLabel, def.RateLabel, loc.RateLabel)
+	inv.AmountLabel = pick(inv.AmountLabel, def.AmountLabel, loc.AmountLabel)
+
+	pdf, pdfDef, pdfLoc := &out.PDF, en.PDF, p.PDF
+	pdf.TaxLabel = pick(pdf.TaxLabel, pdfDef.TaxLabel, pdfLoc.TaxLabel)
+	pdf.SubtotalLabel = pick(pdf.SubtotalLabel, pdfDef.SubtotalLabel, pdfLoc.SubtotalLabel)
+	pdf.DiscountLabel = pick(pdf.DiscountLabel, pdfDef.DiscountLabel, pdfLoc.DiscountLabel)
+	pdf.TaxAmountLabel = pick(pdf.TaxAmountLabel, pdfDef.TaxAmountLabel, pdfLoc.TaxAmountLabel)
+	pdf.BalanceDueLabel = pick(pdf.BalanceDueLabel, pdfDef.BalanceDueLabel, pdfLoc.BalanceDueLabel)
+	pdf.PaidLabel = pick(pdf.PaidLabel, pdfDef.PaidLabel, pdfLoc.PaidLabel)
+	pdf.DraftLabel = pick(pdf.DraftLabel, pdfDef.DraftLabel, pdfLoc.DraftLabel)
+	pdf.OverdueLabel = pick(pdf.OverdueLabel, pdfDef.OverdueLabel, pdfLoc.OverdueLabel)
+	pdf.QRLabel = pick(pdf.QRLabel, pdfDef.QRLabel, pdfLoc.QRLabel)
+
+	return &out
+}
+
+// pick keeps a configured label unless it's empty or the English default
+func pick(configured, english, localized string) string {
+	if configured == "" || configured == english {
+		return localized
+	}
+	return configured
+}
+
+// Month returns the standalone name of t's month
+func (p *Pack) Month(t time.Time) string {
+	return p.Months[t.Month()-1]
+}
+
+// Date formats t as a short date, e.g. 01.03.2024
+func (p *Pack) Date(t time.Time) string {
+	return t.Format(p.DateLayout)
+}
+
+// LongDate formats t with the month written out, e.g. 1. März 2024
+func (p *Pack) LongDate(t time.Time) string {
+	s := t.Format(p.LongDateLayout)
+	name := p.MonthsDate[t.Month()-1]
+	if name == "" {
+		name = p.Month(t)
+	}
+	return strings.Replace(s, t.Month().String(), name, 1)
+}
+
+// Number formats v with the pack's separators
+func (p *Pack) Number(v float64, decimals int) string {
+	s := fmt.Sprintf("%.*f", decimals, v)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+
+	if p.ThousandsSep != "" {
+		var b strings.Builder
+		for i, d := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				b.WriteString(p.ThousandsSep)
+			}
+			b.WriteRune(d)
+		}
+		whole = b.String()
+	}
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + p.DecimalSep + frac
+}
+
+// Quantity formats a line item quantity: whole numbers without decimals
+func (p *Pack) Quantity(q float64) string {
+	if q == float64(int64(q)) {
+		return p.Number(q, 0)
+	}
+	return p.Number(q, 2)
+}
+
+// Money formats an amount with the symbol of currency, e.g. 1.500,00 €
+func (p *Pack) Money(amount float64, currency string) string {
+	s := strings.NewReplacer("{symbol}", Symbol(currency), "{amount}", p.Number(amount, 2)).Replace(p.MoneyFormat)
+	return strings.TrimSpace(strings.ReplaceAll(s, "  ", " "))
+}
+
+// Fill replaces placeholders in a pack text, e.g.
+// Fill(p.Contract.Reference, "{number}", "C-1")
+func Fill(text string, oldnew ...string) string {
+	return strings.NewReplacer(oldnew...).Replace(text)
+}
+
+// CurrencySymbols maps currency codes to their symbols
+var CurrencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"CHF": "CHF ",
+	"CAD": "C$",
+	"AUD": "A$",
+	"NZD": "NZ$",
+	"INR": "₹",
+	"KRW": "₩",
+	"BRL": "R$",
+	"MXN": "MX$",
+	"RUB": "₽",
+	"TRY": "₺",
+	"PLN": "zł",
+	"SEK": "kr",
+	"NOK": "kr",
+	"DKK": "kr",
+	"CZK": "Kč",
+	"HUF": "Ft",
+	"UAH": "₴",
+	"ILS": "₪",
+	"SGD": "S$",
+	"HKD": "HK$",
+	"THB": "฿",
+	"ZAR": "R",
+}
+
+// Symbol returns the symbol of a currency, or its code followed by a space
+func Symbol(currency string) string {
+	if symbol, ok := CurrencySymbols[strings.ToUpper(currency)]; ok {
+		return symbol
+	}
+	return currency + " "
+}
@@ -0,0 +1,161 @@
+package locale
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+)
+
+func TestEnglishMatchesConfigDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config.SetForceGlobal(true)
+	defer config.SetForceGlobal(false)
+
+	cfg, _ := config.Load()
+	if en := Get("en"); en.Invoice != cfg.Invoice {
+		t.Errorf("English invoice labels differ from the config defaults:\n%+v\n%+v", en.Invoice, cfg.Invoice)
+	}
+
+	def := config.GetDefaultPDFConfig()
+	labels := PDFLabels{def.TaxLabel, def.SubtotalLabel, def.DiscountLabel, def.TaxAmountLabel,
+		def.BalanceDueLabel, def.PaidLabel, def.DraftLabel, def.OverdueLabel, def.QRLabel}
+	if en := Get("en"); en.PDF != labels {
+		t.Errorf("English PDF labels differ from the config defaults:\n%+v\n%+v", en.PDF, labels)
+	}
+}
+
+func TestPacksComplete(t *testing.T) {
+	for _, code := range Codes() {
+		p := Get(code)
+		for i, name := range p.Months {
+			if name == "" {
+				t.Errorf("%s: month %d has no name", code, i+1)
+			}
+		}
+		if p.Invoice.InvoiceLabel == "" || p.PDF.BalanceDueLabel == "" || p.Text.InvoiceNumber == "" ||
+			p.Contract.Title == "" || p.Email.InvoiceSubject == "" || p.Email.ContractBody == "" ||
+			p.Email.ReminderBody == "" || p.Email.PayNow == "" {
+			t.Errorf("%s: missing texts", code)
+		}
+		if p.DateLayout == "" || p.LongDateLayout == "" || p.DecimalSep == "" || !strings.Contains(p.MoneyFormat, "{amount}") {
+			t.Errorf("%s: missing formats", code)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	if p := Get("de-AT"); p == nil || p.Code != "de" {
+		t.Errorf("expected de-AT to use the German pack, got %v", p)
+	}
+	if p := Get(" UK "); p == nil || p.Code != "uk" {
+		t.Errorf("expected UK to use the Ukrainian pack, got %v", p)
+	}
+	if Get("xx") != nil {
+		t.Error("expected no pack for xx")
+	}
+
+	if err := Validate(""); err != nil {
+		t.Errorf("expected an empty language to be valid, got %v", err)
+	}
+	if err := Validate("fr"); err != nil {
+		t.Errorf("expected fr to be valid, got %v", err)
+	}
+	if err := Validate("xx"); err == nil || !strings.Contains(err.Error(), "de, en, fr, nl, uk") {
+		t.Errorf("expected the supported languages in the error, got %v", err)
+	}
+}
+
+func TestFor(t *testing.T) {
+	cfg := &config.Config{Language: "de", Invoice: Get("en").Invoice}
+	cfg.Invoice.FromLabel = "Absender"
+	cfg.PDF.BalanceDueLabel = "Balance Due"
+
+	p, out := For(cfg, "")
+	if p.Code != "de" {
+		t.Errorf("expected the configured language, got %s", p.Code)
+	}
+	if out.Invoice.InvoiceLabel != "RECHNUNG" {
+		t.Errorf("expected English defaults to be translated, got %q", out.Invoice.InvoiceLabel)
+	}
+	if out.Invoice.FromLabel != "Absender" {
+		t.Errorf("expected a changed label to be kept, got %q", out.Invoice.FromLabel)
+	}
+	if out.PDF.BalanceDueLabel != "Offener Betrag" || out.PDF.PaidLabel != Get("de").PDF.PaidLabel {
+		t.Errorf("expected PDF labels from the pack, got %q and %q", out.PDF.BalanceDueLabel, out.PDF.PaidLabel)
+	}
+	if cfg.Invoice.InvoiceLabel != "INVOICE" {
+		t.Error("expected the config to be left alone")
+	}
+
+	if p, out := For(cfg, "uk"); p.Code != "uk" || out.Invoice.BillToLabel != "Платник" {
+		t.Errorf("expected the client language to win, got %s", p.Code)
+	}
+	if p, _ := For(&config.Config{Language: "xx"}, "yy"); p.Code != Default {
+		t.Errorf("expected English for unknown languages, got %s", p.Code)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	date := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		code, date, long, money, quantity, month string
+	}{
+		{"en", "01 Mar 2024", "March 1, 2024", "€1234567.50", "1.50", "March"},
+		{"de", "01.03.2024", "1. März 2024", "1.234.567,50 €", "1,50", "März"},
+		{"uk", "01.03.2024", "1 березня 2024 р.", "1 234 567,50 ₴", "1,50", "березень"},
+		{"nl", "01-03-2024", "1 maart 2024", "€ 1.234.567,50", "1,50", "maart"},
+		{"fr", "01/03/2024", "1 mars 2024", "1 234 567,50 €", "1,50", "mars"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			p := Get(tt.code)
+			currency := "EUR"
+			if tt.code == "uk" {
+				currency = "UAH"
+			}
+			if got := p.Date(date); got != tt.date {
+				t.Errorf("Date: expected %q, got %q", tt.date, got)
+			}
+			if got := p.LongDate(date); got != tt.long {
+				t.Errorf("LongDate: expected %q, got %q", tt.long, got)
+			}
+			if got := p.Money(1234567.5, currency); got != tt.money {
+				t.Errorf("Money: expected %q, got %q", tt.money, got)
+			}
+			if got := p.Quantity(1.5); got != tt.quantity {
+				t.Errorf("Quantity: expected %q, got %q", tt.quantity, got)
+			}
+			if got := p.Month(date); got != tt.month {
+				t.Errorf("Month: expected %q, got %q", tt.month, got)
+			}
+		})
+	}
+}
+
+func TestNumber(t *testing.T) {
+	de := Get("de")
+	tests := []struct {
+		v        float64
+		decimals int
+		want     string
+	}{
+		{0, 2, "0,00"},
+		{999, 0, "999"},
+		{1000, 0, "1.000"},
+		{-1234.5, 2, "-1.234,50"},
+		{123456, 2, "123.456,00"},
+	}
+	for _, tt := range tests {
+		if got := de.Number(tt.v, tt.decimals); got != tt.want {
+			t.Errorf("Number(%v, %d): expected %q, got %q", tt.v, tt.decimals, tt.want, got)
+		}
+	}
+	if got := Get("en").Money(10, "XYZ"); got != "XYZ 10.00" {
+		t.Errorf("expected unknown currencies to use their code, got %q", got)
+	}
+	if got := Get("de").Money(10, "CHF"); got != "10,00 CHF" {
+		t.Errorf("expected no double spaces, got %q", got)
+	}
+}
@@ -0,0 +1,435 @@
+package locale
+
+import "github.com/Andriiklymiuk/ung/internal/config"
+
+func init() {
+	register(english)
+	register(ukrainian)
+	register(german)
+	register(dutch)
+	register(french)
+}
+
+// english matches the defaults written by `ung config init`
+var english = &Pack{
+	Code: "en",
+	Name: "English",
+	Invoice: config.InvoiceConfig{
+		Terms:            "Please make the payment by the due date.",
+		PaymentNote:      "Payment is due within the specified term.",
+		NotesLabel:       "Notes",
+		TermsLabel:       "Terms & Conditions",
+		InvoiceLabel:     "INVOICE",
+		FromLabel:        "From",
+		BillToLabel:      "Bill To",
+		DescriptionLabel: "Description",
+		TotalLabel:       "Total",
+		ItemLabel:        "Item",
+		QuantityLabel:    "Quantity",
+		RateLabel:        "Rate",
+		AmountLabel:      "Amount",
+	},
+	PDF: PDFLabels{
+		TaxLabel:        "VAT",
+		SubtotalLabel:   "Subtotal",
+		DiscountLabel:   "Discount",
+		TaxAmountLabel:  "VAT",
+		BalanceDueLabel: "Balance Due",
+		PaidLabel:       "PAID",
+		DraftLabel:      "DRAFT",
+		OverdueLabel:    "OVERDUE",
+		QRLabel:         "Scan to pay",
+	},
+	Text: Texts{
+		InvoiceNumber:  "Invoice#",
+		InvoiceDate:    "Invoice Date",
+		DueDate:        "Due Date",
+		PONumber:       "PO Number",
+		Attention:      "Attn.",
+		TaxID:          "Tax ID",
+		Bank:           "Bank",
+		Account:        "Account",
+		Page:           "Page",
+		Continued:      "Continued on next page",
+		CarriedForward: "Carried forward",
+		BroughtForward: "Brought forward",
+		ThankYou:       "Thank you for your business!",
+		Services:       "Software services in {month}",
+		ReverseCharge:  "Reverse charge: VAT to be accounted for by the recipient (Article 196 of Council Directive 2006/112/EC).",
+		TaxExempt:      "Exempt from VAT.",
+	},
+	Contract: ContractTexts{
+		Title:             "SERVICE AGREEMENT",
+		Reference:         "Contract Reference: {number}",
+		Intro:             "This Service Agreement is entered into as of {date} between the following parties:",
+		Provider:          "Service Provider:",
+		Client:            "Client:",
+		Terms:             "Terms of Agreement",
+		HourlyRate:        "The Service Provider agrees to provide services at an hourly rate of {rate}.",
+		FixedPrice:        "The total fixed price for services is {price}.",
+		Period:            "This agreement is effective from {start} until {end}.",
+		OpenEnded:         "This agreement is effective from {start} and continues until terminated by either party.",
+		AdditionalTerms:   "Additional Terms",
+		PaymentInfo:       "Payment Information",
+		GeneralConditions: "General Conditions",
+		Agreement:         "By signing below, both parties agree to the terms and conditions set forth in this agreement.",
+		Signature:         "Signature & Date",
+	},
+	Email: EmailTexts{
+		InvoiceSubject:  "{month_number}.{year} {company}",
+		InvoiceBody:     "Hi,\n\nHere is the invoice for {month} {year}.\n\nBest regards,\n{company}",
+		ContractSubject: "Contract: {company} - {contract}",
+		ContractBody:    "Hi,\n\nPlease find attached the contract for {contract}.\n\nBest regards,\n{company}",
+		ReminderSubject: "Reminder: invoice {invoice} from {company}",
+		ReminderBody:    "Hi,\n\nThis is a friendly reminder about invoice {invoice} over {amount}, due on {due_date}.\n\nBest regards,\n{company}",
+		PayNow:          "Pay now",
+	},
+	Months: [12]string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	},
+	DateLayout:     "02 Jan 2006",
+	LongDateLayout: "January 2, 2006",
+	DecimalSep:     ".",
+	MoneyFormat:    "{symbol}{amount}",
+}
+
+var ukrainian = &Pack{
+	Code: "uk",
+	Name: "Українська",
+	Invoice: config.InvoiceConfig{
+		Terms:            "Будь ласка, сплатіть рахунок до зазначеної дати.",
+		PaymentNote:      "Оплата здійснюється протягом зазначеного терміну.",
+		NotesLabel:       "Примітки",
+		TermsLabel:       "Умови",
+		InvoiceLabel:     "РАХУНОК",
+		FromLabel:        "Від",
+		BillToLabel:      "Платник",
+		DescriptionLabel: "Опис",
+		TotalLabel:       "Разом",
+		ItemLabel:        "Найменування",
+		QuantityLabel:    "Кількість",
+		RateLabel:        "Ціна",
+		AmountLabel:      "Сума",
+	},
+	PDF: PDFLabels{
+		TaxLabel:        "ПДВ",
+		SubtotalLabel:   "Проміжний підсумок",
+		DiscountLabel:   "Знижка",
+		TaxAmountLabel:  "ПДВ",
+		BalanceDueLabel: "До сплати",
+		PaidLabel:       "СПЛАЧЕНО",
+		DraftLabel:      "ЧЕРНЕТКА",
+		OverdueLabel:    "ПРОСТРОЧЕНО",
+		QRLabel:         "Скануйте для оплати",
+	},
+	Text: Texts{
+		InvoiceNumber:  "Рахунок №",
+		InvoiceDate:    "Дата рахунку",
+		DueDate:        "Сплатити до",
+		PONumber:       "Номер замовлення",
+		Attention:      "До уваги",
+		TaxID:          "Податковий номер",
+		Bank:           "Банк",
+		Account:        "Рахунок",
+		Page:           "Сторінка",
+		Continued:      "Продовження на наступній сторінці",
+		CarriedForward: "Перенесено далі",
+		BroughtForward: "Перенесено з попередньої сторінки",
+		ThankYou:       "Дякуємо за співпрацю!",
+		Services:       "Послуги з розробки програмного забезпечення за {month}",
+		ReverseCharge:  "Зворотне оподаткування: ПДВ сплачує отримувач (стаття 196 Директиви Ради 2006/112/ЄС).",
+		TaxExempt:      "Звільнено від ПДВ.",
+	},
+	Contract: ContractTexts{
+		Title:             "ДОГОВІР ПРО НАДАННЯ ПОСЛУГ",
+		Reference:         "Номер договору: {number}",
+		Intro:             "Цей договір про надання послуг укладено {date} між такими сторонами:",
+		Provider:          "Виконавець:",
+		Client:            "Замовник:",
+		Terms:             "Умови договору",
+		HourlyRate:        "Виконавець надає послуги за погодинною ставкою {rate}.",
+		FixedPrice:        "Загальна фіксована вартість послуг становить {price}.",
+		Period:            "Договір діє з {start} до {end}.",
+		OpenEnded:         "Договір діє з {start} до його розірвання будь-якою зі сторін.",
+		AdditionalTerms:   "Додаткові умови",
+		PaymentInfo:       "Платіжні реквізити",
+		GeneralConditions: "Загальні положення",
+		Agreement:         "Підписуючи цей договір, сторони погоджуються з усіма його умовами.",
+		Signature:         "Підпис і дата",
+	},
+	Email: EmailTexts{
+		InvoiceSubject:  "{month_number}.{year} {company}",
+		InvoiceBody:     "Добрий день!\n\nНадсилаю рахунок за {month} {year}.\n\nЗ повагою,\n{company}",
+		ContractSubject: "Договір: {company} - {contract}",
+		ContractBody:    "Добрий день!\n\nУ вкладенні договір «{contract}».\n\nЗ повагою,\n{company}",
+		ReminderSubject: "Нагадування: рахунок {invoice} від {company}",
+		ReminderBody:    "Добрий день!\n\nНагадуємо про рахунок {invoice} на суму {amount} з терміном оплати {due_date}.\n\nЗ повагою,\n{company}",
+		PayNow:          "Сплатити",
+	},
+	Months: [12]string{
+		"січень", "лютий", "березень", "квітень", "травень", "червень",
+		"липень", "серпень", "вересень", "жовтень", "листопад", "грудень",
+	},
+	MonthsDate: [12]string{
+		"січня", "лютого", "березня", "квітня", "травня", "червня",
+		"липня", "серпня", "вересня", "жовтня", "листопада", "грудня",
+	},
+	DateLayout:     "02.01.2006",
+	LongDateLayout: "2 January 2006 р.",
+	DecimalSep:     ",",
+	ThousandsSep:   " ",
+	MoneyFormat:    "{amount} {symbol}",
+}
+
+var german = &Pack{
+	Code: "de",
+	Name: "Deutsch",
+	Invoice: config.InvoiceConfig{
+		Terms:            "Bitte begleichen Sie den Betrag bis zum Fälligkeitsdatum.",
+		PaymentNote:      "Zahlbar innerhalb der angegebenen Frist.",
+		NotesLabel:       "Anmerkungen",
+		TermsLabel:       "Bedingungen",
+		InvoiceLabel:     "RECHNUNG",
+		FromLabel:        "Von",
+		BillToLabel:      "Rechnung an",
+		DescriptionLabel: "Beschreibung",
+		TotalLabel:       "Gesamt",
+		ItemLabel:        "Leistung",
+		QuantityLabel:    "Menge",
+		RateLabel:        "Preis",
+		AmountLabel:      "Betrag",
+	},
+	PDF: PDFLabels{
+		TaxLabel:        "MwSt.",
+		SubtotalLabel:   "Zwischensumme",
+		DiscountLabel:   "Rabatt",
+		TaxAmountLabel:  "MwSt.",
+		BalanceDueLabel: "Offener Betrag",
+		PaidLabel:       "BEZAHLT",
+		DraftLabel:      "ENTWURF",
+		OverdueLabel:    "ÜBERFÄLLIG",
+		QRLabel:         "Zum Bezahlen scannen",
+	},
+	Text: Texts{
+		InvoiceNumber:  "Rechnungsnr.",
+		InvoiceDate:    "Rechnungsdatum",
+		DueDate:        "Fällig am",
+		PONumber:       "Bestellnummer",
+		Attention:      "z. Hd.",
+		TaxID:          "USt-IdNr.",
+		Bank:           "Bank",
+		Account:        "Konto",
+		Page:           "Seite",
+		Continued:      "Fortsetzung auf der nächsten Seite",
+		CarriedForward: "Übertrag",
+		BroughtForward: "Übertrag",
+		ThankYou:       "Vielen Dank für Ihren Auftrag!",
+		Services:       "Softwareentwicklung im {month}",
+		ReverseCharge:  "Steuerschuldnerschaft des Leistungsempfängers (Reverse Charge, Art. 196 der Richtlinie 2006/112/EG).",
+		TaxExempt:      "Steuerfreie Leistung.",
+	},
+	Contract: ContractTexts{
+		Title:             "DIENSTLEISTUNGSVERTRAG",
+		Reference:         "Vertragsnummer: {number}",
+		Intro:             "Dieser Dienstleistungsvertrag wird am {date} zwischen den folgenden Parteien geschlossen:",
+		Provider:          "Auftragnehmer:",
+		Client:            "Auftraggeber:",
+		Terms:             "Vertragsbedingungen",
+		HourlyRate:        "Der Auftragnehmer erbringt die Leistungen zu einem Stundensatz von {rate}.",
+		FixedPrice:        "Der Festpreis für die Leistungen beträgt {price}.",
+		Period:            "Dieser Vertrag gilt vom {start} bis zum {end}.",
+		OpenEnded:         "Dieser Vertrag gilt ab dem {start} und läuft, bis er von einer der Parteien gekündigt wird.",
+		AdditionalTerms:   "Zusätzliche Bedingungen",
+		PaymentInfo:       "Zahlungsinformationen",
+		GeneralConditions: "Allgemeine Bedingungen",
+		Agreement:         "Mit ihrer Unterschrift erkennen beide Parteien die Bedingungen dieses Vertrags an.",
+		Signature:         "Unterschrift & Datum",
+	},
+	Email: EmailTexts{
+		InvoiceSubject:  "{month_number}.{year} {company}",
+		InvoiceBody:     "Hallo,\n\nanbei die Rechnung für {month} {year}.\n\nMit freundlichen Grüßen\n{company}",
+		ContractSubject: "Vertrag: {company} - {contract}",
+		ContractBody:    "Hallo,\n\nanbei der Vertrag für {contract}.\n\nMit freundlichen Grüßen\n{company}",
+		ReminderSubject: "Erinnerung: Rechnung {invoice} von {company}",
+		ReminderBody:    "Hallo,\n\nwir möchten Sie freundlich an die Rechnung {invoice} über {amount}, fällig am {due_date}, erinnern.\n\nMit freundlichen Grüßen\n{company}",
+		PayNow:          "Jetzt bezahlen",
+	},
+	Months: [12]string{
+		"Januar", "Februar", "März", "April", "Mai", "Juni",
+		"Juli", "August", "September", "Oktober", "November", "Dezember",
+	},
+	DateLayout:     "02.01.2006",
+	LongDateLayout: "2. January 2006",
+	DecimalSep:     ",",
+	ThousandsSep:   ".",
+	MoneyFormat:    "{amount} {symbol}",
+}
+
+var dutch = &Pack{
+	Code: "nl",
+	Name: "Nederlands",
+	Invoice: config.InvoiceConfig{
+		Terms:            "Gelieve het bedrag vóór de vervaldatum te betalen.",
+		PaymentNote:      "Betaling binnen de aangegeven termijn.",
+		NotesLabel:       "Opmerkingen",
+		TermsLabel:       "Voorwaarden",
+		InvoiceLabel:     "FACTUUR",
+		FromLabel:        "Van",
+		BillToLabel:      "Factuur aan",
+		DescriptionLabel: "Omschrijving",
+		TotalLabel:       "Totaal",
+		ItemLabel:        "Dienst",
+		QuantityLabel:    "Aantal",
+		RateLabel:        "Tarief",
+		AmountLabel:      "Bedrag",
+	},
+	PDF: PDFLabels{
+		TaxLabel:        "btw",
+		SubtotalLabel:   "Subtotaal",
+		DiscountLabel:   "Korting",
+		TaxAmountLabel:  "btw",
+		BalanceDueLabel: "Te betalen",
+		PaidLabel:       "BETAALD",
+		DraftLabel:      "CONCEPT",
+		OverdueLabel:    "VERVALLEN",
+		QRLabel:         "Scan om te betalen",
+	},
+	Text: Texts{
+		InvoiceNumber:  "Factuurnr.",
+		InvoiceDate:    "Factuurdatum",
+		DueDate:        "Vervaldatum",
+		PONumber:       "Inkoopordernummer",
+		Attention:      "T.a.v.",
+		TaxID:          "Btw-nummer",
+		Bank:           "Bank",
+		Account:        "Rekening",
+		Page:           "Pagina",
+		Continued:      "Vervolg op de volgende pagina",
+		CarriedForward: "Transport",
+		BroughtForward: "Transport",
+		ThankYou:       "Bedankt voor uw opdracht!",
+		Services:       "Softwareontwikkeling in {month}",
+		ReverseCharge:  "Btw verlegd (artikel 196 van Richtlijn 2006/112/EG).",
+		TaxExempt:      "Vrijgesteld van btw.",
+	},
+	Contract: ContractTexts{
+		Title:             "DIENSTVERLENINGSOVEREENKOMST",
+		Reference:         "Contractnummer: {number}",
+		Intro:             "Deze dienstverleningsovereenkomst is aangegaan op {date} tussen de volgende partijen:",
+		Provider:          "Dienstverlener:",
+		Client:            "Opdrachtgever:",
+		Terms:             "Voorwaarden van de overeenkomst",
+		HourlyRate:        "De dienstverlener levert de diensten tegen een uurtarief van {rate}.",
+		FixedPrice:        "De vaste prijs voor de diensten bedraagt {price}.",
+		Period:            "Deze overeenkomst geldt van {start} tot {end}.",
+		OpenEnded:         "Deze overeenkomst geldt vanaf {start} en loopt door tot opzegging door een van de partijen.",
+		AdditionalTerms:   "Aanvullende voorwaarden",
+		PaymentInfo:       "Betalingsgegevens",
+		GeneralConditions: "Algemene voorwaarden",
+		Agreement:         "Door hieronder te tekenen gaan beide partijen akkoord met de voorwaarden van deze overeenkomst.",
+		Signature:         "Handtekening & datum",
+	},
+	Email: EmailTexts{
+		InvoiceSubject:  "{month_number}.{year} {company}",
+		InvoiceBody:     "Hallo,\n\nHierbij de factuur voor {month} {year}.\n\nMet vriendelijke groet,\n{company}",
+		ContractSubject: "Contract: {company} - {contract}",
+		ContractBody:    "Hallo,\n\nIn de bijlage vindt u het contract voor {contract}.\n\nMet vriendelijke groet,\n{company}",
+		ReminderSubject: "Herinnering: factuur {invoice} van {company}",
+		ReminderBody:    "Hallo,\n\nGraag herinneren wij u aan factuur {invoice} van {amount}, te betalen op {due_date}.\n\nMet vriendelijke groet,\n{company}",
+		PayNow:          "Nu betalen",
+	},
+	Months: [12]string{
+		"januari", "februari", "maart", "april", "mei", "juni",
+		"juli", "augustus", "september", "oktober", "november", "december",
+	},
+	DateLayout:     "02-01-2006",
+	LongDateLayout: "2 January 2006",
+	DecimalSep:     ",",
+	ThousandsSep:   ".",
+	MoneyFormat:    "{symbol} {amount}",
+}
+
+var french = &Pack{
+	Code: "fr",
+	Name: "Français",
+	Invoice: config.InvoiceConfig{
+		Terms:            "Merci de régler le montant avant la date d'échéance.",
+		PaymentNote:      "Paiement à effectuer dans le délai indiqué.",
+		NotesLabel:       "Remarques",
+		TermsLabel:       "Conditions générales",
+		InvoiceLabel:     "FACTURE",
+		FromLabel:        "De",
+		BillToLabel:      "Facturé à",
+		DescriptionLabel: "Description",
+		TotalLabel:       "Total",
+		ItemLabel:        "Prestation",
+		QuantityLabel:    "Quantité",
+		RateLabel:        "Prix unitaire",
+		AmountLabel:      "Montant",
+	},
+	PDF: PDFLabels{
+		TaxLabel:        "TVA",
+		SubtotalLabel:   "Sous-total",
+		DiscountLabel:   "Remise",
+		TaxAmountLabel:  "TVA",
+		BalanceDueLabel: "Solde dû",
+		PaidLabel:       "PAYÉE",
+		DraftLabel:      "BROUILLON",
+		OverdueLabel:    "EN RETARD",
+		QRLabel:         "Scanner pour payer",
+	},
+	Text: Texts{
+		InvoiceNumber:  "Facture n°",
+		InvoiceDate:    "Date de facture",
+		DueDate:        "Échéance",
+		PONumber:       "Bon de commande",
+		Attention:      "À l'attention de",
+		TaxID:          "N° TVA",
+		Bank:           "Banque",
+		Account:        "Compte",
+		Page:           "Page",
+		Continued:      "Suite page suivante",
+		CarriedForward: "À reporter",
+		BroughtForward: "Report",
+		ThankYou:       "Merci de votre confiance !",
+		Services:       "Développement logiciel – {month}",
+		ReverseCharge:  "Autoliquidation : TVA due par le preneur (article 196 de la directive 2006/112/CE).",
+		TaxExempt:      "Exonéré de TVA.",
+	},
+	Contract: ContractTexts{
+		Title:             "CONTRAT DE PRESTATION DE SERVICES",
+		Reference:         "Référence du contrat : {number}",
+		Intro:             "Le présent contrat de prestation de services est conclu le {date} entre les parties suivantes :",
+		Provider:          "Prestataire :",
+		Client:            "Client :",
+		Terms:             "Conditions du contrat",
+		HourlyRate:        "Le prestataire fournit les services au taux horaire de {rate}.",
+		FixedPrice:        "Le prix forfaitaire des services est de {price}.",
+		Period:            "Le présent contrat est en vigueur du {start} au {end}.",
+		OpenEnded:         "Le présent contrat prend effet le {start} et se poursuit jusqu'à sa résiliation par l'une des parties.",
+		AdditionalTerms:   "Conditions supplémentaires",
+		PaymentInfo:       "Informations de paiement",
+		GeneralConditions: "Conditions générales",
+		Agreement:         "En signant ci-dessous, les deux parties acceptent les conditions du présent contrat.",
+		Signature:         "Signature et date",
+	},
+	Email: EmailTexts{
+		InvoiceSubject:  "{month_number}.{year} {company}",
+		InvoiceBody:     "Bonjour,\n\nVeuillez trouver ci-joint la facture de {month} {year}.\n\nCordialement,\n{company}",
+		ContractSubject: "Contrat : {company} - {contract}",
+		ContractBody:    "Bonjour,\n\nVeuillez trouver ci-joint le contrat {contract}.\n\nCordialement,\n{company}",
+		ReminderSubject: "Rappel : facture {invoice} de {company}",
+		ReminderBody:    "Bonjour,\n\nNous vous rappelons que la facture {invoice} d'un montant de {amount} est à régler le {due_date}.\n\nCordialement,\n{company}",
+		PayNow:          "Payer maintenant",
+	},
+	Months: [12]string{
+		"janvier", "février", "mars", "avril", "mai", "juin",
+		"juillet", "août", "septembre", "octobre", "novembre", "décembre",
+	},
+	DateLayout:     "02/01/2006",
+	LongDateLayout: "2 January 2006",
+	DecimalSep:     ",",
+	ThousandsSep:   " ",
+	MoneyFormat:    "{amount} {symbol}",
+}
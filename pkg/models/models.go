@@ -0,0 +1,841 @@
+// Package models holds the GORM models of a user database, shared by the CLI
+// and the API server. The tables themselves are created by pkg/schema.
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Company represents a business entity (the user's company)
+type Company struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	Name                string    `gorm:"not null" json:"name"`
+	Email               string    `gorm:"not null" json:"email"`
+	Phone               string    `json:"phone"`
+	Address             string    `json:"address"`
+	RegistrationAddress string    `gorm:"column:registration_address" json:"registration_address"`
+	TaxID               string    `gorm:"column:tax_id" json:"tax_id"`
+	BankName            string    `gorm:"column:bank_name" json:"bank_name"`
+	BankAccount         string    `gorm:"column:bank_account" json:"bank_account"`
+	BankSWIFT           string    `gorm:"column:bank_swift" json:"bank_swift"`
+	LogoPath            string    `gorm:"column:logo_path" json:"logo_path"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// Client represents a customer/client
+type Client struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"not null" json:"name"`
+	Email         string    `gorm:"not null" json:"email"`
+	Address       string    `json:"address"`
+	TaxID         string    `gorm:"column:tax_id" json:"tax_id"`
+	Language      string    `json:"language"`                                            // Document language, empty for config.Language
+	TaxProfile    string    `gorm:"column:tax_profile" json:"tax_profile"`               // Tax profile of its invoices, empty for tax.default
+	EmailTemplate string    `gorm:"column:email_template" json:"email_template"`         // Email template set, empty for the common templates
+	EmailCc       string    `gorm:"column:email_cc" json:"email_cc"`                     // Addresses copied on its emails, comma separated
+	EmailBcc      string    `gorm:"column:email_bcc" json:"email_bcc"`                   // Addresses blind copied on its emails, comma separated
+	Currency      string    `json:"currency"`                                            // Currency of new invoices, empty for USD
+	PaymentTerms  int       `gorm:"column:payment_terms_days" json:"payment_terms_days"` // Days new invoices are due after issue, 0 for 30
+	PORequired    bool      `gorm:"column:po_required" json:"po_required"`               // New invoices need a PO number
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// The billing profile, only when loaded with Preload
+	Contacts  []ClientContact `gorm:"foreignKey:ClientID" json:"contacts,omitempty"`
+	Addresses []ClientAddress `gorm:"foreignKey:ClientID" json:"addresses,omitempty"`
+}
+
+// AddressFor returns the client's address of a kind, otherwise its address
+func (c Client) AddressFor(kind AddressKind) string {
+	for _, a := range c.Addresses {
+		if a.Kind == kind && a.Address != "" {
+			return a.Address
+		}
+	}
+	return c.Address
+}
+
+// ContactsFor returns the client's contacts with a role
+func (c Client) ContactsFor(role ContactRole) []ClientContact {
+	var contacts []ClientContact
+	for _, contact := range c.Contacts {
+		if contact.Role == role {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts
+}
+
+// DefaultPaymentTerms is how many days after issue an invoice is due
+// unless its client has other payment terms
+const DefaultPaymentTerms = 30
+
+// DueDate returns when an invoice issued on a date is due under the
+// client's payment terms
+func (c Client) DueDate(issued time.Time) time.Time {
+	days := c.PaymentTerms
+	if days <= 0 {
+		days = DefaultPaymentTerms
+	}
+	return issued.AddDate(0, 0, days)
+}
+
+// ContactRole is what a client contact is contacted about
+type ContactRole string
+
+const (
+	ContactBilling   ContactRole = "billing"   // Receives invoices, e.g. accounts payable
+	ContactTechnical ContactRole = "technical" // The project lead
+	ContactLegal     ContactRole = "legal"     // Receives contracts
+)
+
+// ContactRoles lists the roles of client contacts
+var ContactRoles = []ContactRole{ContactBilling, ContactTechnical, ContactLegal}
+
+// ClientContact is a person at a client
+type ClientContact struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	ClientID   uint        `gorm:"not null;index" json:"client_id"`
+	Name       string      `json:"name"`
+	Email      string      `json:"email"`
+	Phone      string      `json:"phone"`
+	Role       ContactRole `gorm:"default:billing" json:"role"`
+	CcInvoices bool        `gorm:"column:cc_invoices" json:"cc_invoices"` // Copied on invoice and contract emails
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// AddressKind is what a client address is used for
+type AddressKind string
+
+const (
+	AddressBilling AddressKind = "billing" // Printed on invoices
+	AddressLegal   AddressKind = "legal"   // Registered address, printed on contracts
+	AddressPostal  AddressKind = "postal"  // For letters
+)
+
+// AddressKinds lists the kinds of client addresses
+var AddressKinds = []AddressKind{AddressBilling, AddressLegal, AddressPostal}
+
+// ClientAddress is an address of a client, one of each kind
+type ClientAddress struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	ClientID  uint        `gorm:"not null;uniqueIndex:idx_client_address_kind" json:"client_id"`
+	Kind      AddressKind `gorm:"not null;uniqueIndex:idx_client_address_kind" json:"kind"`
+	Address   string      `gorm:"not null" json:"address"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// ContractType represents the type of contract
+type ContractType string
+
+const (
+	ContractTypeHourly     ContractType = "hourly"
+	ContractTypeFixedPrice ContractType = "fixed_price"
+	ContractTypeRetainer   ContractType = "retainer"
+)
+
+// Contract represents a work contract with a client
+type Contract struct {
+	ID           uint         `gorm:"primaryKey" json:"id"`
+	ContractNum  string       `gorm:"uniqueIndex;not null" json:"contract_num"` // e.g., "contract.acme.jan.2025"
+	ClientID     uint         `gorm:"not null;index" json:"client_id"`
+	Client       Client       `gorm:"foreignKey:ClientID" json:"client,omitempty"`
+	Name         string       `gorm:"not null" json:"name"` // e.g., "Website Development Q1 2025"
+	ContractType ContractType `gorm:"not null" json:"contract_type"`
+	HourlyRate   *float64     `json:"hourly_rate"` // For hourly contracts
+	FixedPrice   *float64     `json:"fixed_price"` // For fixed price contracts
+	Currency     string       `gorm:"default:USD" json:"currency"`
+	StartDate    time.Time    `json:"start_date"`
+	EndDate      *time.Time   `json:"end_date"`
+	Active       bool         `gorm:"default:true" json:"active"`
+	Notes        string       `json:"notes"`
+	PDFPath      string       `gorm:"column:pdf_path" json:"pdf_path"`
+	TaxProfile   string       `gorm:"column:tax_profile" json:"tax_profile"` // Tax profile of its invoices, empty for the client's
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// InvoiceStatus represents the status of an invoice
+type InvoiceStatus string
+
+const (
+	StatusPending InvoiceStatus = "pending"
+	StatusSent    InvoiceStatus = "sent"
+	StatusPaid    InvoiceStatus = "paid"
+	StatusOverdue InvoiceStatus = "overdue"
+)
+
+// Invoice represents an invoice
+type Invoice struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	InvoiceNum  string        `gorm:"uniqueIndex;not null" json:"invoice_num"`
+	CompanyID   uint          `gorm:"not null;index" json:"company_id"`
+	Company     Company       `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Amount      float64       `gorm:"not null" json:"amount"`                // Gross total the client pays
+	NetAmount   float64       `gorm:"column:net_amount" json:"net_amount"`   // Total before tax
+	TaxAmount   float64       `gorm:"column:tax_amount" json:"tax_amount"`   // Total tax
+	TaxProfile  string        `gorm:"column:tax_profile" json:"tax_profile"` // Tax profile the totals were calculated with
+	TaxType     string        `gorm:"column:tax_type" json:"tax_type"`       // Type of that profile, kept for tax reports
+	TaxNote     string        `gorm:"column:tax_note" json:"tax_note"`       // Note printed on the invoice, e.g. for reverse charge
+	Currency    string        `gorm:"default:USD" json:"currency"`
+	Description string        `json:"description"`
+	Status      InvoiceStatus `gorm:"default:pending" json:"status"`
+	IssuedDate  time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"issued_date"`
+	DueDate     time.Time     `json:"due_date"`
+	PaidDate    *time.Time    `json:"paid_date"` // Date the payment was received
+	PDFPath     string        `gorm:"column:pdf_path" json:"pdf_path"`
+	SentAt      *time.Time    `gorm:"column:sent_at" json:"sent_at"`     // When it was last emailed over SMTP
+	PONumber    string        `gorm:"column:po_number" json:"po_number"` // The client's purchase order number
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Net returns the total before tax. Invoices created without a tax
+// calculation, e.g. through the API, are all net.
+func (i Invoice) Net() float64 {
+	if i.NetAmount == 0 && i.TaxAmount == 0 {
+		return i.Amount
+	}
+	return i.NetAmount
+}
+
+// InvoiceRecipient links invoices to clients
+type InvoiceRecipient struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	InvoiceID uint `gorm:"not null;index" json:"invoice_id"`
+	ClientID  uint `gorm:"not null;index" json:"client_id"`
+}
+
+// InvoiceLineItem represents an item/service on an invoice
+type InvoiceLineItem struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	InvoiceID   uint      `gorm:"not null;index" json:"invoice_id"`
+	Invoice     Invoice   `gorm:"foreignKey:InvoiceID" json:"-"`
+	ItemName    string    `gorm:"column:item_name;not null" json:"item_name"`
+	Description string    `json:"description"`
+	Quantity    float64   `gorm:"not null;default:1" json:"quantity"`
+	Rate        float64   `gorm:"not null" json:"rate"`
+	Amount      float64   `gorm:"not null" json:"amount"`
+	Discount    float64   `gorm:"default:0" json:"discount"`     // Discount amount for this line item
+	DiscountPct float64   `gorm:"default:0" json:"discount_pct"` // Discount percentage (0-100)
+	TaxRate     float64   `gorm:"default:0" json:"tax_rate"`     // Tax rate for this item (0-1)
+	TaxAmount   float64   `gorm:"default:0" json:"tax_amount"`   // Calculated tax amount
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TrackingSession represents a time tracking session
+type TrackingSession struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	ClientID    *uint          `gorm:"index" json:"client_id"`
+	Client      *Client        `gorm:"foreignKey:ClientID" json:"client,omitempty"`
+	ContractID  *uint          `gorm:"index" json:"contract_id"`
+	Contract    *Contract      `gorm:"foreignKey:ContractID" json:"contract,omitempty"`
+	ProjectName string         `json:"project_name"`
+	StartTime   time.Time      `gorm:"not null" json:"start_time"`
+	EndTime     *time.Time     `json:"end_time"`
+	Duration    *int           `json:"duration"` // in seconds
+	Hours       *float64       `json:"hours"`    // calculated hours for easier display
+	Billable    bool           `gorm:"default:true" json:"billable"`
+	Notes       string         `json:"notes"`
+	ExternalID  string         `gorm:"column:external_id;index" json:"external_id"` // Source ID for imported entries, e.g. "ics:<uid>"
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ExpenseCategory represents the category of an expense
+type ExpenseCategory string
+
+const (
+	ExpenseCategorySoftware       ExpenseCategory = "software"
+	ExpenseCategoryHardware       ExpenseCategory = "hardware"
+	ExpenseCategoryTravel         ExpenseCategory = "travel"
+	ExpenseCategoryMeals          ExpenseCategory = "meals"
+	ExpenseCategoryOfficeSupplies ExpenseCategory = "office_supplies"
+	ExpenseCategoryUtilities      ExpenseCategory = "utilities"
+	ExpenseCategoryMarketing      ExpenseCategory = "marketing"
+	ExpenseCategoryOther          ExpenseCategory = "other"
+)
+
+// Expense represents a business expense
+type Expense struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Description string          `gorm:"not null" json:"description"`
+	Amount      float64         `gorm:"not null" json:"amount"`
+	Currency    string          `gorm:"default:USD" json:"currency"`
+	Category    ExpenseCategory `gorm:"not null" json:"category"`
+	Date        time.Time       `gorm:"not null" json:"date"`
+	Vendor      string          `json:"vendor"`
+	ReceiptPath string          `gorm:"column:receipt_path" json:"receipt_path"`
+	ReceiptHash string          `gorm:"column:receipt_hash;index" json:"receipt_hash"` // SHA-256 of the receipt contents
+	Notes       string          `json:"notes"`
+	ExternalID  string          `gorm:"column:external_id;index" json:"external_id"` // Source ID for imported expenses, e.g. "bank:<id>"
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// RecurringFrequency represents how often a recurring invoice is generated
+type RecurringFrequency string
+
+const (
+	FrequencyWeekly    RecurringFrequency = "weekly"
+	FrequencyBiweekly  RecurringFrequency = "biweekly"
+	FrequencyMonthly   RecurringFrequency = "monthly"
+	FrequencyQuarterly RecurringFrequency = "quarterly"
+	FrequencyYearly    RecurringFrequency = "yearly"
+)
+
+// RecurringInvoice represents a template for automatically generating invoices
+type RecurringInvoice struct {
+	ID                 uint               `gorm:"primaryKey" json:"id"`
+	ClientID           uint               `gorm:"not null;index" json:"client_id"`
+	Client             Client             `gorm:"foreignKey:ClientID" json:"client,omitempty"`
+	ContractID         *uint              `gorm:"index" json:"contract_id"`
+	Contract           *Contract          `gorm:"foreignKey:ContractID" json:"contract,omitempty"`
+	CompanyID          *uint              `json:"company_id"` // Issuing company, the first one when not set
+	Company            *Company           `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Amount             float64            `gorm:"not null" json:"amount"`
+	Currency           string             `gorm:"default:USD" json:"currency"`
+	Description        string             `json:"description"`
+	Frequency          RecurringFrequency `gorm:"not null" json:"frequency"`
+	DayOfMonth         int                `gorm:"default:1" json:"day_of_month"` // 1-28 for monthly
+	DayOfWeek          int                `gorm:"default:1" json:"day_of_week"`  // 1-7 for weekly (1=Monday)
+	NextGenerationDate time.Time          `json:"next_generation_date"`
+	LastGeneratedDate  *time.Time         `json:"last_generated_date"`
+	LastInvoiceID      *uint              `json:"last_invoice_id"`
+	Active             bool               `gorm:"default:true" json:"active"`
+	AutoSend           bool               `gorm:"default:false" json:"auto_send"`    // Auto-send email when generated
+	AutoPDF            bool               `gorm:"default:true" json:"auto_pdf"`      // Auto-generate PDF
+	EmailApp           string             `gorm:"column:email_app" json:"email_app"` // apple, outlook, gmail
+	InvoicePrefix      string             `gorm:"default:REC" json:"invoice_prefix"` // Invoice number prefix used by the API
+	GeneratedCount     int                `gorm:"default:0" json:"generated_count"`  // How many invoices generated
+	Notes              string             `json:"notes"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+}
+
+// OutboxStatus represents the delivery status of an outbox email
+type OutboxStatus string
+
+const (
+	OutboxQueued OutboxStatus = "queued" // Waiting for its next attempt
+	OutboxSent   OutboxStatus = "sent"
+	OutboxFailed OutboxStatus = "failed" // Rejected, or out of attempts
+)
+
+// OutboxEmail is an email sent over SMTP, queued until it's delivered and
+// then kept as the send log
+type OutboxEmail struct {
+	ID            uint         `gorm:"primaryKey" json:"id"`
+	Kind          string       `json:"kind"` // invoice, contract or reminder
+	InvoiceID     *uint        `gorm:"index" json:"invoice_id"`
+	ContractID    *uint        `json:"contract_id"`
+	MessageID     string       `gorm:"column:message_id;not null" json:"message_id"`
+	Recipients    string       `gorm:"not null" json:"recipients"` // To, comma separated
+	Cc            string       `json:"cc"`
+	Bcc           string       `json:"bcc"`
+	Subject       string       `gorm:"not null" json:"subject"`
+	Body          string       `json:"body"`
+	HTMLBody      string       `gorm:"column:html_body" json:"html_body"`
+	Attachments   string       `json:"attachments"` // File paths, one per line
+	Status        OutboxStatus `gorm:"default:queued" json:"status"`
+	Attempts      int          `json:"attempts"`
+	LastError     string       `json:"last_error"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	SentAt        *time.Time   `json:"sent_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// TableName keeps the table name of the migration
+func (OutboxEmail) TableName() string {
+	return "email_outbox"
+}
+
+// UserSettings stores user preferences and dashboard settings
+type UserSettings struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Key       string    `gorm:"uniqueIndex;not null" json:"key"`
+	Value     string    `gorm:"not null" json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Settings keys
+const (
+	SettingWeeklyHoursTarget = "weekly_hours_target"
+)
+
+// RateSettings holds the inputs of the hourly rate calculator
+type RateSettings struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	HoursPerWeek      float64   `gorm:"default:40" json:"hours_per_week"`      // Billable hours per week
+	WeeksPerYear      int       `gorm:"default:48" json:"weeks_per_year"`      // Working weeks per year
+	DefaultTaxPercent float64   `gorm:"default:25" json:"default_tax_percent"` // Default tax rate
+	DefaultMargin     float64   `gorm:"default:20" json:"default_margin"`      // Default profit margin
+	AnnualExpenses    float64   `gorm:"default:0" json:"annual_expenses"`      // Annual business expenses
+	DefaultCurrency   string    `gorm:"default:USD" json:"default_currency"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// PomodoroSession represents a pomodoro timer session
+type PomodoroSession struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	ContractID  *uint          `gorm:"index" json:"contract_id"`
+	Contract    *Contract      `gorm:"foreignKey:ContractID" json:"contract,omitempty"`
+	ClientID    *uint          `gorm:"index" json:"client_id"`
+	Client      *Client        `gorm:"foreignKey:ClientID" json:"client,omitempty"`
+	ProjectName string         `json:"project_name"`
+	Duration    int            `gorm:"default:25" json:"duration"` // Duration in minutes
+	BreakTime   int            `gorm:"default:5" json:"break_time"`
+	StartTime   time.Time      `gorm:"not null" json:"start_time"`
+	EndTime     *time.Time     `json:"end_time"`
+	Completed   bool           `gorm:"default:false" json:"completed"`
+	Notes       string         `json:"notes"`
+	SessionType string         `gorm:"default:work" json:"session_type"` // work, short_break, long_break
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// InvoiceTemplate represents a reusable invoice template
+type InvoiceTemplate struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `json:"description"`
+	Content     string    `gorm:"type:text" json:"content"` // HTML/Markdown template content
+	IsDefault   bool      `gorm:"default:false" json:"is_default"`
+	Variables   string    `gorm:"type:text" json:"variables"` // JSON list of template variables
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// =====================================
+// Job Hunter Models
+// =====================================
+
+// Profile represents the user's professional profile extracted from CV/resume
+type Profile struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Name       string    `json:"name"`
+	Title      string    `json:"title"`      // e.g., "Senior Go Developer"
+	Bio        string    `json:"bio"`        // Professional summary
+	Skills     string    `json:"skills"`     // JSON array of skills
+	Experience int       `json:"experience"` // Years of experience
+	Rate       float64   `json:"rate"`       // Desired hourly rate
+	Currency   string    `gorm:"default:USD" json:"currency"`
+	Location   string    `json:"location"`
+	Remote     bool      `gorm:"default:true" json:"remote"`
+	Languages  string    `json:"languages"`                       // JSON array of languages
+	Education  string    `json:"education"`                       // JSON array of education
+	Projects   string    `json:"projects"`                        // JSON array of notable projects
+	Links      string    `json:"links"`                           // JSON: github, linkedin, portfolio
+	PDFPath    string    `gorm:"column:pdf_path" json:"pdf_path"` // Original CV path
+	PDFContent string    `json:"pdf_content"`                     // Extracted text from PDF
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// JobSource represents where jobs are scraped from
+type JobSource string
+
+const (
+	JobSourceHN             JobSource = "hackernews"
+	JobSourceRemoteOK       JobSource = "remoteok"
+	JobSourceWeWorkRemotely JobSource = "weworkremotely"
+	JobSourceJobicy         JobSource = "jobicy"
+	JobSourceArbeitnow      JobSource = "arbeitnow"
+	JobSourceDjinni         JobSource = "djinni"      // Ukrainian IT jobs
+	JobSourceDOU            JobSource = "dou"         // Ukrainian IT community
+	JobSourceNetherlands    JobSource = "netherlands" // Dutch job boards
+	JobSourceEuroJobs       JobSource = "eurojobs"    // European job boards
+	JobSourceUpwork         JobSource = "upwork"
+	JobSourceLinkedIn       JobSource = "linkedin"
+	JobSourceManual         JobSource = "manual"
+)
+
+// Job represents a scraped job posting
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Source      JobSource  `gorm:"not null" json:"source"`
+	SourceID    string     `json:"source_id"`  // External ID from source
+	SourceURL   string     `json:"source_url"` // Link to original posting
+	Title       string     `gorm:"not null" json:"title"`
+	Company     string     `json:"company"`
+	Description string     `json:"description"`
+	Skills      string     `json:"skills"`    // JSON array of required skills
+	RateMin     float64    `json:"rate_min"`  // Minimum rate/salary
+	RateMax     float64    `json:"rate_max"`  // Maximum rate/salary
+	RateType    string     `json:"rate_type"` // hourly, monthly, yearly
+	Currency    string     `gorm:"default:USD" json:"currency"`
+	Remote      bool       `gorm:"default:true" json:"remote"`
+	Location    string     `json:"location"`
+	JobType     string     `json:"job_type"`    // contract, fulltime, parttime
+	MatchScore  float64    `json:"match_score"` // 0-100 match with profile
+	PostedAt    time.Time  `json:"posted_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ApplicationStatus represents the status of a job application
+type ApplicationStatus string
+
+const (
+	AppStatusDraft     ApplicationStatus = "draft"
+	AppStatusApplied   ApplicationStatus = "applied"
+	AppStatusViewed    ApplicationStatus = "viewed"
+	AppStatusResponse  ApplicationStatus = "response"
+	AppStatusInterview ApplicationStatus = "interview"
+	AppStatusOffer     ApplicationStatus = "offer"
+	AppStatusRejected  ApplicationStatus = "rejected"
+	AppStatusWithdrawn ApplicationStatus = "withdrawn"
+)
+
+// Application represents a job application
+type Application struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	JobID       uint              `gorm:"not null;index" json:"job_id"`
+	Job         Job               `gorm:"foreignKey:JobID" json:"job,omitempty"`
+	ProfileID   uint              `gorm:"index" json:"profile_id"`
+	Profile     Profile           `gorm:"foreignKey:ProfileID" json:"-"`
+	Proposal    string            `json:"proposal"`     // Generated proposal text
+	ProposalPDF string            `json:"proposal_pdf"` // Path to PDF proposal
+	CoverLetter string            `json:"cover_letter"`
+	Status      ApplicationStatus `gorm:"default:draft" json:"status"`
+	Notes       string            `json:"notes"`
+	AppliedAt   *time.Time        `json:"applied_at"`
+	ResponseAt  *time.Time        `json:"response_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// =====================================
+// Gig Models - Central Work Unit
+// =====================================
+
+// GigStatus represents the workflow status of a gig
+// Simplified flow: todo → in_progress → sent → done
+type GigStatus string
+
+const (
+	GigStatusTodo       GigStatus = "todo"        // Queued, not started
+	GigStatusInProgress GigStatus = "in_progress" // Actively working
+	GigStatusSent       GigStatus = "sent"        // Delivered, awaiting payment
+	GigStatusDone       GigStatus = "done"        // Completed & paid
+	GigStatusOnHold     GigStatus = "on_hold"     // Paused
+	GigStatusCancelled  GigStatus = "cancelled"   // Cancelled
+)
+
+// GigType represents the type of work arrangement
+type GigType string
+
+const (
+	GigTypeHourly   GigType = "hourly"
+	GigTypeFixed    GigType = "fixed"
+	GigTypeRetainer GigType = "retainer"
+)
+
+// GigPriority represents urgency level
+type GigPriority int
+
+const (
+	GigPriorityNormal GigPriority = 0
+	GigPriorityHigh   GigPriority = 1
+	GigPriorityUrgent GigPriority = 2
+)
+
+// Gig represents a work project/engagement - the central unit connecting hunter → work → invoice
+type Gig struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"not null" json:"name"`
+	ClientID      *uint     `gorm:"index" json:"client_id"`
+	Client        *Client   `gorm:"foreignKey:ClientID" json:"client,omitempty"`
+	ContractID    *uint     `gorm:"index" json:"contract_id"`
+	Contract      *Contract `gorm:"foreignKey:ContractID" json:"contract,omitempty"`
+	ApplicationID *uint     `gorm:"index" json:"application_id"` // From hunter
+
+	Status   GigStatus   `gorm:"not null;default:todo" json:"status"`
+	GigType  GigType     `gorm:"default:hourly" json:"gig_type"`
+	Priority GigPriority `gorm:"default:0" json:"priority"`
+	Project  string      `gorm:"index" json:"project"` // Optional project grouping
+
+	// Financial
+	EstimatedHours  *float64 `json:"estimated_hours"`
+	EstimatedAmount *float64 `json:"estimated_amount"`
+	HourlyRate      *float64 `json:"hourly_rate"`
+	Currency        string   `gorm:"default:USD" json:"currency"`
+
+	// Tracking aggregation
+	TotalHoursTracked float64    `gorm:"default:0" json:"total_hours_tracked"`
+	LastTrackedAt     *time.Time `json:"last_tracked_at"`
+
+	// Billing
+	TotalInvoiced  float64    `gorm:"default:0" json:"total_invoiced"`
+	LastInvoicedAt *time.Time `json:"last_invoiced_at"`
+
+	// Dates
+	StartDate   *time.Time `json:"start_date"`
+	DueDate     *time.Time `json:"due_date"`
+	CompletedAt *time.Time `json:"completed_at"`
+
+	// Metadata
+	Description string `json:"description"`
+	Notes       string `json:"notes"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WorkLogType represents the type of work log entry
+type WorkLogType string
+
+const (
+	WorkLogTypeNote      WorkLogType = "note"
+	WorkLogTypeDecision  WorkLogType = "decision"
+	WorkLogTypeMeeting   WorkLogType = "meeting"
+	WorkLogTypeBlocker   WorkLogType = "blocker"
+	WorkLogTypeMilestone WorkLogType = "milestone"
+)
+
+// WorkLog represents a note/log entry attached to work
+type WorkLog struct {
+	ID                uint             `gorm:"primaryKey" json:"id"`
+	GigID             *uint            `gorm:"index" json:"gig_id"`
+	Gig               *Gig             `gorm:"foreignKey:GigID" json:"gig,omitempty"`
+	ClientID          *uint            `gorm:"index" json:"client_id"`
+	Client            *Client          `gorm:"foreignKey:ClientID" json:"client,omitempty"`
+	TrackingSessionID *uint            `gorm:"index" json:"tracking_session_id"`
+	TrackingSession   *TrackingSession `gorm:"foreignKey:TrackingSessionID" json:"tracking_session,omitempty"`
+
+	Content string      `gorm:"not null" json:"content"`
+	LogType WorkLogType `gorm:"default:note" json:"log_type"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GigTask represents an actionable task within a gig
+type GigTask struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	GigID       uint       `gorm:"not null;index" json:"gig_id"`
+	Gig         *Gig       `gorm:"foreignKey:GigID" json:"gig,omitempty"`
+	Title       string     `gorm:"not null" json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `gorm:"default:false" json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	DueDate     *time.Time `json:"due_date"`
+	SortOrder   int        `gorm:"default:0" json:"sort_order"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// =====================================
+// Enhanced Goals
+// =====================================
+
+// GoalType represents different types of goals
+type GoalType string
+
+const (
+	GoalTypeIncome  GoalType = "income"
+	GoalTypeHours   GoalType = "hours"
+	GoalTypeClients GoalType = "clients"
+	GoalTypeSavings GoalType = "savings"
+)
+
+// IncomeGoal represents an enhanced income/hours/client goal
+type IncomeGoal struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	Amount      float64  `gorm:"not null" json:"amount"`
+	Period      string   `gorm:"not null" json:"period"` // monthly, quarterly, yearly
+	Year        int      `gorm:"not null" json:"year"`
+	Month       int      `json:"month"`   // for monthly goals
+	Quarter     int      `json:"quarter"` // for quarterly goals
+	Description string   `json:"description"`
+	GoalType    GoalType `gorm:"default:income" json:"goal_type"`
+
+	// For different goal types
+	TargetHours    *float64 `json:"target_hours"`    // hours goal
+	TargetClients  *int     `json:"target_clients"`  // clients goal
+	SavingsPercent *float64 `json:"savings_percent"` // savings goal (e.g., 30%)
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// =====================================
+// Dig Models - Idea Analysis & Incubation
+// =====================================
+
+// DigSessionStatus represents the status of a dig session
+type DigSessionStatus string
+
+const (
+	DigStatusPending   DigSessionStatus = "pending"
+	DigStatusAnalyzing DigSessionStatus = "analyzing"
+	DigStatusCompleted DigSessionStatus = "completed"
+	DigStatusFailed    DigSessionStatus = "failed"
+)
+
+// DigRecommendation represents the final recommendation for an idea
+type DigRecommendation string
+
+const (
+	DigRecommendProceed DigRecommendation = "proceed"
+	DigRecommendPivot   DigRecommendation = "pivot"
+	DigRecommendRefine  DigRecommendation = "refine"
+	DigRecommendAbandon DigRecommendation = "abandon"
+)
+
+// DigPerspective represents analysis perspectives
+type DigPerspective string
+
+const (
+	// Core perspectives (always run)
+	DigPerspectiveFirstPrinciples DigPerspective = "first_principles"
+	DigPerspectiveDesigner        DigPerspective = "designer"
+	DigPerspectiveMarketing       DigPerspective = "marketing"
+	DigPerspectiveTechnical       DigPerspective = "technical"
+	DigPerspectiveFinancial       DigPerspective = "financial"
+
+	// Harsh/Critical perspectives (run for deeper analysis)
+	DigPerspectiveDevilsAdvocate DigPerspective = "devils_advocate"
+	DigPerspectiveCopycat        DigPerspective = "copycat"
+	DigPerspectiveUserPsychology DigPerspective = "user_psychology"
+	DigPerspectiveScalability    DigPerspective = "scalability"
+	DigPerspectiveWorstCase      DigPerspective = "worst_case"
+)
+
+// DigSession represents an idea analysis session
+type DigSession struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	Title           string            `json:"title"`
+	RawIdea         string            `gorm:"not null" json:"raw_idea"`
+	RefinedIdea     string            `json:"refined_idea"`
+	Status          DigSessionStatus  `gorm:"default:pending" json:"status"`
+	OverallScore    *float64          `json:"overall_score"`
+	Recommendation  DigRecommendation `json:"recommendation"`
+	CurrentStage    string            `gorm:"default:first_principles" json:"current_stage"`
+	StagesCompleted string            `json:"stages_completed"` // JSON array
+	StartedAt       *time.Time        `json:"started_at"`
+	CompletedAt     *time.Time        `json:"completed_at"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+
+	// Agentic early-exit fields
+	EarlyExit       bool   `gorm:"default:false" json:"early_exit"`  // True if analysis stopped early
+	EarlyExitReason string `json:"early_exit_reason"`                // Why analysis stopped
+	ViabilityCheck  string `json:"viability_check"`                  // JSON of viability assessment
+	PivotFocus      bool   `gorm:"default:false" json:"pivot_focus"` // True if focusing on pivots
+	FlawType        string `json:"flaw_type"`                        // Type of fundamental flaw if any
+
+	// Relationships
+	Analyses          []DigAnalysis         `gorm:"foreignKey:SessionID" json:"analyses,omitempty"`
+	ExecutionPlan     *DigExecutionPlan     `gorm:"foreignKey:SessionID" json:"execution_plan,omitempty"`
+	Marketing         *DigMarketing         `gorm:"foreignKey:SessionID" json:"marketing,omitempty"`
+	RevenueProjection *DigRevenueProjection `gorm:"foreignKey:SessionID" json:"revenue_projection,omitempty"`
+	Alternatives      []DigAlternative      `gorm:"foreignKey:SessionID" json:"alternatives,omitempty"`
+}
+
+// DigAnalysis represents analysis from one perspective
+type DigAnalysis struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	SessionID        uint           `gorm:"not null;index" json:"session_id"`
+	Perspective      DigPerspective `gorm:"not null" json:"perspective"`
+	Summary          string         `json:"summary"`
+	Strengths        string         `json:"strengths"`       // JSON array
+	Weaknesses       string         `json:"weaknesses"`      // JSON array
+	Opportunities    string         `json:"opportunities"`   // JSON array
+	Threats          string         `json:"threats"`         // JSON array
+	Recommendations  string         `json:"recommendations"` // JSON array
+	Score            *float64       `json:"score"`
+	DetailedAnalysis string         `json:"detailed_analysis"` // JSON
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// DigExecutionPlan represents the implementation roadmap
+type DigExecutionPlan struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	SessionID        uint      `gorm:"not null;index" json:"session_id"`
+	Summary          string    `json:"summary"`
+	MVPScope         string    `json:"mvp_scope"`
+	FullScope        string    `json:"full_scope"`
+	Architecture     string    `json:"architecture"`      // JSON
+	TechStack        string    `json:"tech_stack"`        // JSON
+	Integrations     string    `json:"integrations"`      // JSON
+	Phases           string    `json:"phases"`            // JSON array
+	Milestones       string    `json:"milestones"`        // JSON array
+	TeamRequirements string    `json:"team_requirements"` // JSON
+	EstimatedCost    string    `json:"estimated_cost"`    // JSON
+	LLMPrompt        string    `json:"llm_prompt"`        // Ready-to-use prompt
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// DigMarketing represents generated marketing materials
+type DigMarketing struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	SessionID            uint      `gorm:"not null;index" json:"session_id"`
+	ValueProposition     string    `json:"value_proposition"`
+	TargetAudience       string    `json:"target_audience"` // JSON
+	PositioningStatement string    `json:"positioning_statement"`
+	Taglines             string    `json:"taglines"` // JSON array
+	ElevatorPitch        string    `json:"elevator_pitch"`
+	Headlines            string    `json:"headlines"`         // JSON array
+	Descriptions         string    `json:"descriptions"`      // JSON array
+	ColorSuggestions     string    `json:"color_suggestions"` // JSON
+	ImageryPrompts       string    `json:"imagery_prompts"`   // JSON array
+	GeneratedImages      string    `json:"generated_images"`  // JSON array
+	ChannelStrategy      string    `json:"channel_strategy"`  // JSON
+	LaunchStrategy       string    `json:"launch_strategy"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// TableName keeps GORM from pluralizing the table name
+func (DigMarketing) TableName() string {
+	return "dig_marketing"
+}
+
+// DigRevenueProjection represents financial projections
+type DigRevenueProjection struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	SessionID         uint      `gorm:"not null;index" json:"session_id"`
+	MarketSize        string    `json:"market_size"` // JSON: TAM, SAM, SOM
+	MarketGrowth      string    `json:"market_growth"`
+	Competitors       string    `json:"competitors"`    // JSON array
+	PricingModels     string    `json:"pricing_models"` // JSON array
+	RecommendedPrice  string    `json:"recommended_price"`
+	PricingRationale  string    `json:"pricing_rationale"`
+	Year1Revenue      string    `json:"year1_revenue"` // JSON monthly
+	Year2Revenue      string    `json:"year2_revenue"` // JSON monthly
+	Year3Revenue      string    `json:"year3_revenue"` // JSON yearly
+	KeyMetrics        string    `json:"key_metrics"`   // JSON
+	BreakEvenAnalysis string    `json:"break_even_analysis"`
+	Assumptions       string    `json:"assumptions"` // JSON
+	Risks             string    `json:"risks"`       // JSON
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// DigAlternative represents a suggested pivot or alternative idea
+type DigAlternative struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	SessionID       uint      `gorm:"not null;index" json:"session_id"`
+	AlternativeIdea string    `gorm:"not null" json:"alternative_idea"`
+	Rationale       string    `json:"rationale"`
+	Comparison      string    `json:"comparison"`
+	ViabilityScore  *float64  `json:"viability_score"`
+	EffortLevel     string    `json:"effort_level"` // low, medium, high
+	Potential       string    `json:"potential"`    // low, medium, high, very_high
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -1,8 +1,12 @@
 package models
 
 import (
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/schema"
+	gormschema "gorm.io/gorm/schema"
 )
 
 func TestContractType_Constants(t *testing.T) {
@@ -109,6 +113,36 @@ func TestClient_Initialization(t *testing.T) {
 	}
 }
 
+func TestClient_BillingProfile(t *testing.T) {
+	client := Client{
+		Address: "456 Client Ave",
+		Contacts: []ClientContact{
+			{Name: "Lead", Role: ContactTechnical},
+			{Name: "AP", Role: ContactBilling},
+		},
+		Addresses: []ClientAddress{{Kind: AddressBilling, Address: "PO Box 12"}},
+	}
+
+	if got := client.AddressFor(AddressBilling); got != "PO Box 12" {
+		t.Errorf("Expected the billing address, got %s", got)
+	}
+	if got := client.AddressFor(AddressLegal); got != "456 Client Ave" {
+		t.Errorf("Expected the client address without a legal one, got %s", got)
+	}
+	if got := client.ContactsFor(ContactBilling); len(got) != 1 || got[0].Name != "AP" {
+		t.Errorf("Expected the billing contact, got %v", got)
+	}
+
+	issued := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	if got := client.DueDate(issued); !got.Equal(issued.AddDate(0, 0, DefaultPaymentTerms)) {
+		t.Errorf("Expected the default payment terms, got %s", got)
+	}
+	client.PaymentTerms = 14
+	if got := client.DueDate(issued); !got.Equal(time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 14 days, got %s", got)
+	}
+}
+
 func TestContract_HourlyRate(t *testing.T) {
 	rate := 150.0
 	contract := Contract{
@@ -295,3 +329,39 @@ func TestInvoiceRecipient_LinkingInvoiceToClient(t *testing.T) {
 		t.Errorf("Expected ClientID 2, got %d", recipient.ClientID)
 	}
 }
+
+// Every field GORM reads or writes must be a column of the shared schema
+func TestModelsMatchSchema(t *testing.T) {
+	ref, err := schema.Reference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	tables, err := schema.Tables(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, model := range []interface{}{
+		&Company{}, &Client{}, &Contract{}, &Invoice{}, &InvoiceRecipient{}, &InvoiceLineItem{},
+		&TrackingSession{}, &Expense{}, &RecurringInvoice{}, &UserSettings{}, &RateSettings{},
+		&PomodoroSession{}, &InvoiceTemplate{}, &Profile{}, &Job{}, &Application{},
+		&Gig{}, &WorkLog{}, &GigTask{}, &IncomeGoal{}, &OutboxEmail{}, &ClientContact{}, &ClientAddress{},
+		&DigSession{}, &DigAnalysis{}, &DigExecutionPlan{}, &DigMarketing{}, &DigRevenueProjection{}, &DigAlternative{},
+	} {
+		s, err := gormschema.Parse(model, &sync.Map{}, gormschema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		table, ok := tables[s.Table]
+		if !ok {
+			t.Errorf("%s: table %s is not in the schema", s.Name, s.Table)
+			continue
+		}
+		for _, field := range s.Fields {
+			if field.DBName != "" && !table.Has(field.DBName) {
+				t.Errorf("%s.%s: column %s.%s is not in the schema", s.Name, field.Name, s.Table, field.DBName)
+			}
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Package payqr builds payment QR codes for invoices and draws them into
+// PDFs.
+//
+// Three formats are supported:
+//
+//   - epc: the EPC069-12 SEPA credit transfer code read by European
+//     banking apps ("GiroCode"), for EUR invoices
+//   - swiss: the Swiss QR-bill payload (version 2.0, structured address),
+//     for CHF and EUR invoices
+//   - url: a payment link built from a pattern, e.g. a Stripe or PayPal page
+package payqr
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/qrcode"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Formats
+const (
+	FormatEPC   = "epc"
+	FormatSwiss = "swiss"
+	FormatURL   = "url"
+)
+
+// Details is what a payment code tells the payer's bank
+type Details struct {
+	Name      string  // Beneficiary
+	Address   string  // Beneficiary address, parts separated by lines or commas
+	IBAN      string  // Beneficiary account
+	BIC       string  // Beneficiary bank, optional
+	Amount    float64 // 0 lets the payer enter the amount
+	Currency  string  // ISO 4217 code
+	Reference string  // Invoice number, shown to the beneficiary
+}
+
+// FromInvoice returns the payment details for an invoice of company
+func FromInvoice(invoice models.Invoice, company models.Company, amount float64) Details {
+	return Details{
+		Name:      company.Name,
+		Address:   company.Address,
+		IBAN:      company.BankAccount,
+		BIC:       company.BankSWIFT,
+		Amount:    amount,
+		Currency:  invoice.Currency,
+		Reference: invoice.InvoiceNum,
+	}
+}
+
+// Payload returns the QR code content in the given format. pattern is the
+// payment link for FormatURL, see URL.
+func Payload(format, pattern string, d Details) (string, error) {
+	switch strings.ToLower(format) {
+	case "", FormatEPC:
+		return EPC(d)
+	case FormatSwiss:
+		return Swiss(d)
+	case FormatURL:
+		return URL(pattern, d)
+	default:
+		return "", fmt.Errorf("unknown QR code format %q (valid: epc, swiss, url)", format)
+	}
+}
+
+// EPC returns an EPC069-12 (version 002) SEPA credit transfer payload
+func EPC(d Details) (string, error) {
+	if !strings.EqualFold(d.Currency, "EUR") {
+		return "", fmt.Errorf("EPC QR codes are for EUR payments, the invoice is in %s", d.Currency)
+	}
+	iban, err := normalizeIBAN(d.IBAN)
+	if err != nil {
+		return "", err
+	}
+	amount, err := formatAmount(d.Amount)
+	if err != nil {
+		return "", err
+	}
+	if amount != "" {
+		amount = "EUR" + amount
+	}
+
+	lines := []string{
+		"BCD",
+		"002",
+		"1", // UTF-8
+		"SCT",
+		strings.ToUpper(strings.ReplaceAll(d.BIC, " ", "")),
+		truncate(d.Name, 70),
+		iban,
+		amount,
+		"", // purpose
+		"", // structured reference
+		truncate(remittance(d.Reference), 140),
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Swiss returns a Swiss QR-bill payload. A QR-IBAN gets a QR reference made
+// from the digits of the invoice number; other IBANs carry the invoice
+// number as the message.
+func Swiss(d Details) (string, error) {
+	currency := strings.ToUpper(d.Currency)
+	if currency != "CHF" && currency != "EUR" {
+		return "", fmt.Errorf("Swiss QR-bills are for CHF or EUR payments, the invoice is in %s", d.Currency)
+	}
+	iban, err := normalizeIBAN(d.IBAN)
+	if err != nil {
+		return "", err
+	}
+	country := iban[:2]
+	if country != "CH" && country != "LI" {
+		return "", fmt.Errorf("Swiss QR-bills need a Swiss or Liechtenstein IBAN, got %s", country)
+	}
+	addr, err := parseAddress(d.Address)
+	if err != nil {
+		return "", err
+	}
+	amount, err := formatAmount(d.Amount)
+	if err != nil {
+		return "", err
+	}
+
+	refType, ref := "NON", ""
+	if isQRIBAN(iban) {
+		refType, ref = "QRR", qrReference(d.Reference)
+	}
+
+	lines := []string{
+		"SPC",
+		"0200",
+		"1",
+		iban,
+		// Creditor, structured address
+		"S",
+		truncate(d.Name, 70),
+		truncate(addr.street, 70),
+		truncate(addr.building, 16),
+		truncate(addr.postcode, 16),
+		truncate(addr.town, 35),
+		country,
+		// Ultimate creditor, reserved
+		"", "", "", "", "", "", "",
+		amount,
+		currency,
+		// Ultimate debtor, left for the payer to fill in
+		"", "", "", "", "", "", "",
+		refType,
+		ref,
+		truncate(remittance(d.Reference), 140),
+		"EPD",
+	}
+	return strings.Join(lines, "\r\n"), nil
+}
+
+// URL fills a payment link pattern. {invoice}, {amount}, {currency},
+// {iban}, {bic} and {name} are replaced with escaped values.
+func URL(pattern string, d Details) (string, error) {
+	if pattern == "" {
+		return "", fmt.Errorf("no payment link configured, set pdf.qr_payment_url, e.g. https://pay.example.com/{invoice}?amount={amount}")
+	}
+	replacer := strings.NewReplacer(
+		"{invoice}", url.QueryEscape(d.Reference),
+		"{amount}", fmt.Sprintf("%.2f", d.Amount),
+		"{currency}", url.QueryEscape(strings.ToUpper(d.Currency)),
+		"{iban}", url.QueryEscape(strings.ReplaceAll(d.IBAN, " ", "")),
+		"{bic}", url.QueryEscape(d.BIC),
+		"{name}", url.QueryEscape(d.Name),
+	)
+	return replacer.Replace(pattern), nil
+}
+
+// DefaultSize returns the printed size of a code in mm, without quiet zone.
+// Swiss QR-bills must be 46 mm.
+func DefaultSize(format string) float64 {
+	if strings.EqualFold(format, FormatSwiss) {
+		return 46
+	}
+	return 35
+}
+
+// Draw draws a QR code for payload with its top left corner at x, y. Swiss
+// QR-bill codes get the Swiss cross in the middle.
+func Draw(pdf *gofpdf.Fpdf, format, payload string, x, y, size float64) error {
+	code, err := qrcode.Encode([]byte(payload), qrcode.M)
+	if err != nil {
+		return err
+	}
+
+	module := size / float64(code.Size)
+	pdf.SetFillColor(0, 0, 0)
+	for row := 0; row < code.Size; row++ {
+		// One rectangle per run of dark modules keeps the PDF small
+		for col := 0; col < code.Size; {
+			if !code.Black(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < code.Size && code.Black(col, row) {
+				col++
+			}
+			pdf.Rect(x+float64(start)*module, y+float64(row)*module, float64(col-start)*module, module, "F")
+		}
+	}
+
+	if strings.EqualFold(format, FormatSwiss) {
+		drawSwissCross(pdf, x+size/2, y+size/2, size*7/46)
+	}
+	return nil
+}
+
+// drawSwissCross draws the Swiss cross centered on cx, cy
+func drawSwissCross(pdf *gofpdf.Fpdf, cx, cy, size float64) {
+	pdf.SetFillColor(255, 255, 255)
+	pdf.Rect(cx-size/2, cy-size/2, size, size, "F")
+	inner := size * 0.86
+	pdf.SetFillColor(0, 0, 0)
+	pdf.Rect(cx-inner/2, cy-inner/2, inner, inner, "F")
+
+	arm, length := inner*0.19, inner*0.62
+	pdf.SetFillColor(255, 255, 255)
+	pdf.Rect(cx-arm/2, cy-length/2, arm, length, "F")
+	pdf.Rect(cx-length/2, cy-arm/2, length, arm, "F")
+}
+
+// normalizeIBAN removes spaces and checks the IBAN's check digits
+func normalizeIBAN(iban string) (string, error) {
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if iban == "" {
+		return "", fmt.Errorf("the company has no bank account, add its IBAN with 'ung company edit'")
+	}
+	if !ibanPattern.MatchString(iban) || mod97(iban[4:]+iban[:4]) != 1 {
+		return "", fmt.Errorf("bank account %q is not a valid IBAN", iban)
+	}
+	return iban, nil
+}
+
+// mod97 returns s mod 97 with letters counted as 10 to 35 (ISO 7064)
+func mod97(s string) int {
+	var digits strings.Builder
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			fmt.Fprintf(&digits, "%d", r-'A'+10)
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	return int(new(big.Int).Mod(n, big.NewInt(97)).Int64())
+}
+
+// isQRIBAN reports whether a Swiss IBAN is a QR-IBAN, whose bank ID is
+// between 30000 and 31999
+func isQRIBAN(iban string) bool {
+	iid := iban[4:9]
+	return iid >= "30000" && iid <= "31999"
+}
+
+// qrReference returns a 27-digit QR reference made of the digits in s and
+// a check digit (modulo 10, recursive)
+func qrReference(s string) string {
+	var digits strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	ref := digits.String()
+	if len(ref) > 26 {
+		ref = ref[len(ref)-26:]
+	}
+	ref = strings.Repeat("0", 26-len(ref)) + ref
+
+	table := [10]int{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+	carry := 0
+	for _, r := range ref {
+		carry = table[(carry+int(r-'0'))%10]
+	}
+	return ref + fmt.Sprint((10-carry)%10)
+}
+
+// address is a structured postal address
+type address struct {
+	street, building, postcode, town string
+}
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	townLine    = regexp.MustCompile(`^(?:[A-Z]{2}-)?(\d{4,5})\s+(.+)$`)
+	streetLine  = regexp.MustCompile(`^(.*\S)\s+(\d+\w*)$`)
+)
+
+// parseAddress splits "Bahnhofstrasse 1, 8001 Zürich" into its parts
+func parseAddress(s string) (address, error) {
+	var parts []string
+	for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		m := townLine.FindStringSubmatch(parts[i])
+		if m == nil {
+			continue
+		}
+		addr := address{postcode: m[1], town: m[2]}
+		if i > 0 {
+			addr.street = parts[0]
+			if m := streetLine.FindStringSubmatch(parts[0]); m != nil {
+				addr.street, addr.building = m[1], m[2]
+			}
+		}
+		return addr, nil
+	}
+	return address{}, fmt.Errorf("the company address needs a postal code and town for a Swiss QR-bill, e.g. \"Bahnhofstrasse 1, 8001 Zürich\"")
+}
+
+// formatAmount formats an amount with two decimals, or returns an empty
+// string for 0
+func formatAmount(amount float64) (string, error) {
+	if amount == 0 {
+		return "", nil
+	}
+	if amount < 0.01 || amount > 999999999.99 {
+		return "", fmt.Errorf("amount %.2f can't be paid with a QR code", amount)
+	}
+	return fmt.Sprintf("%.2f", amount), nil
+}
+
+func remittance(reference string) string {
+	if reference == "" {
+		return ""
+	}
+	return "Invoice " + reference
+}
+
+// truncate shortens s to n characters
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\n", " "))
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
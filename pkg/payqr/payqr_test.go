@@ -0,0 +1,123 @@
+package payqr
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestEPC(t *testing.T) {
+	d := Details{
+		Name:      "Acme GmbH",
+		IBAN:      "DE89 3704 0044 0532 0130 00",
+		BIC:       "COBADEFFXXX",
+		Amount:    1250.5,
+		Currency:  "eur",
+		Reference: "INV-2024-001",
+	}
+	got, err := EPC(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "BCD\n002\n1\nSCT\nCOBADEFFXXX\nAcme GmbH\nDE89370400440532013000\nEUR1250.50\n\n\nInvoice INV-2024-001"
+	if got != want {
+		t.Errorf("expected\n%q\ngot\n%q", want, got)
+	}
+
+	d.Currency = "USD"
+	if _, err := EPC(d); err == nil {
+		t.Error("expected USD to be refused")
+	}
+	d.Currency, d.IBAN = "EUR", "DE89370400440532013001"
+	if _, err := EPC(d); err == nil || !strings.Contains(err.Error(), "not a valid IBAN") {
+		t.Errorf("expected a check digit error, got %v", err)
+	}
+	d.IBAN = ""
+	if _, err := EPC(d); err == nil || !strings.Contains(err.Error(), "ung company edit") {
+		t.Errorf("expected a hint to add the IBAN, got %v", err)
+	}
+}
+
+func TestSwiss(t *testing.T) {
+	d := Details{
+		Name:      "Muster AG",
+		Address:   "Bahnhofstrasse 12a\n8001 Zürich\nSwitzerland",
+		IBAN:      "CH93 0076 2011 6238 5295 7",
+		Amount:    99,
+		Currency:  "CHF",
+		Reference: "2024-17",
+	}
+	got, err := Swiss(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(got, "\r\n")
+	if len(lines) != 31 {
+		t.Fatalf("expected 31 fields, got %d: %q", len(lines), got)
+	}
+	for i, want := range map[int]string{
+		0: "SPC", 3: "CH9300762011623852957", 4: "S", 5: "Muster AG", 6: "Bahnhofstrasse", 7: "12a",
+		8: "8001", 9: "Zürich", 10: "CH", 18: "99.00", 19: "CHF", 27: "NON", 28: "", 29: "Invoice 2024-17", 30: "EPD",
+	} {
+		if lines[i] != want {
+			t.Errorf("field %d: expected %q, got %q", i, want, lines[i])
+		}
+	}
+
+	// A QR-IBAN needs a QR reference
+	d.IBAN = "CH44 3199 9123 0008 8901 2"
+	got, _ = Swiss(d)
+	lines = strings.Split(got, "\r\n")
+	if lines[27] != "QRR" || len(lines[28]) != 27 || !strings.HasPrefix(lines[28], "00000000000000000000202417") {
+		t.Errorf("expected a QR reference from the invoice number, got %s %s", lines[27], lines[28])
+	}
+
+	d.Address = "Somewhere"
+	if _, err := Swiss(d); err == nil {
+		t.Error("expected an address without postal code to be refused")
+	}
+	d.Address, d.IBAN = "Bahnhofstrasse 1, 8001 Zürich", "DE89370400440532013000"
+	if _, err := Swiss(d); err == nil {
+		t.Error("expected a German IBAN to be refused")
+	}
+}
+
+// The example reference from the Swiss payment standards
+func TestQRReference(t *testing.T) {
+	if got := qrReference("21000000000313947143000901"); got != "210000000003139471430009017" {
+		t.Errorf("unexpected reference %s", got)
+	}
+	if got := qrReference("INV-42"); len(got) != 27 || !strings.HasPrefix(got, "00000000000000000000000042") {
+		t.Errorf("expected the invoice digits padded to 26, got %s", got)
+	}
+}
+
+func TestURL(t *testing.T) {
+	d := Details{Name: "Acme & Co", Amount: 10, Currency: "usd", Reference: "INV 1"}
+	got, err := URL("https://pay.example.com/{invoice}?amount={amount}&currency={currency}&to={name}", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://pay.example.com/INV+1?amount=10.00&currency=USD&to=Acme+%26+Co"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if _, err := URL("", d); err == nil {
+		t.Error("expected a missing pattern to be reported")
+	}
+	if _, err := Payload("bitcoin", "", d); err == nil {
+		t.Error("expected an unknown format to be refused")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	if err := Draw(pdf, FormatSwiss, "SPC\r\n0200\r\n1", 20, 20, DefaultSize(FormatSwiss)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pdf.Output(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+}
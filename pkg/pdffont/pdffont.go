@@ -0,0 +1,171 @@
+// Package pdffont sets up the fonts used for invoice, contract and template
+// PDFs.
+//
+// gofpdf's core fonts (Arial, Helvetica, Times, Courier) only cover ASCII
+// reliably, so names like "ТОВ Приклад" or "Müller & Söhne" come out
+// mangled. By default documents use the bundled DejaVu Sans Condensed, a
+// TTF font with Latin, Cyrillic and Greek glyphs that is embedded (subset)
+// in each PDF. TTF files can be configured instead, and a core font can
+// still be chosen: it is kept for documents that are plain ASCII and
+// replaced with the bundled font for documents that aren't.
+package pdffont
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Bundled is the family name of the bundled font
+const Bundled = "DejaVu"
+
+//go:embed fonts/*.ttf
+var bundledFonts embed.FS
+
+// Font describes the font of a document. Empty Regular and Family select
+// the bundled font.
+type Font struct {
+	Family     string // Core font name, or the name to register the TTF files under
+	Regular    string // TTF file paths
+	Bold       string
+	Italic     string
+	BoldItalic string
+}
+
+// coreFamilies are the gofpdf core fonts, keyed by lowercase name
+var coreFamilies = map[string]string{
+	"arial":     "Arial",
+	"helvetica": "Helvetica",
+	"times":     "Times",
+	"courier":   "Courier",
+}
+
+// FromConfig returns the font configured under pdf: in the config file
+func FromConfig(cfg config.PDFConfig) Font {
+	return Font{
+		Family:     cfg.FontFamily,
+		Regular:    cfg.FontRegular,
+		Bold:       cfg.FontBold,
+		Italic:     cfg.FontItalic,
+		BoldItalic: cfg.FontBoldItalic,
+	}
+}
+
+// IsCore reports whether family is one of the gofpdf core fonts
+func IsCore(family string) bool {
+	_, ok := coreFamilies[strings.ToLower(family)]
+	return ok
+}
+
+// Setup registers font with pdf and returns the family to pass to SetFont.
+// content is the text that goes into the document (structs are fine, they
+// are printed with fmt.Sprint): a core font is only used when all of it is
+// ASCII, otherwise the bundled font is used for this document.
+func Setup(pdf *gofpdf.Fpdf, font Font, content ...interface{}) (string, error) {
+	if font.Regular != "" {
+		return addFiles(pdf, font)
+	}
+
+	if core, ok := coreFamilies[strings.ToLower(font.Family)]; ok {
+		if isASCII(fmt.Sprint(content...)) {
+			return core, nil
+		}
+		return addBundled(pdf)
+	}
+
+	if font.Family != "" && !strings.EqualFold(font.Family, Bundled) && !strings.EqualFold(font.Family, "DejaVu Sans") {
+		return "", fmt.Errorf("unknown font family %q: use Arial, Helvetica, Times, Courier or DejaVu, or set the font file paths", font.Family)
+	}
+	return addBundled(pdf)
+}
+
+// addBundled registers the bundled DejaVu Sans Condensed. There is no bold
+// oblique variant in the bundle; bold italic text is set in bold.
+func addBundled(pdf *gofpdf.Fpdf) (string, error) {
+	styles := map[string]string{
+		"":   "DejaVuSansCondensed.ttf",
+		"B":  "DejaVuSansCondensed-Bold.ttf",
+		"I":  "DejaVuSansCondensed-Oblique.ttf",
+		"BI": "DejaVuSansCondensed-Bold.ttf",
+	}
+	for style, name := range styles {
+		data, err := bundledFonts.ReadFile("fonts/" + name)
+		if err != nil {
+			return "", err
+		}
+		pdf.AddUTF8FontFromBytes(Bundled, style, data)
+	}
+	if err := pdf.Error(); err != nil {
+		return "", fmt.Errorf("failed to load the bundled font: %w", err)
+	}
+	return Bundled, nil
+}
+
+// addFiles registers the configured TTF files. Missing styles fall back to
+// the closest one given: bold italic to bold, then italic, then regular.
+func addFiles(pdf *gofpdf.Fpdf, font Font) (string, error) {
+	family := font.Family
+	if family == "" || IsCore(family) {
+		family = strings.TrimSuffix(filepath.Base(font.Regular), filepath.Ext(font.Regular))
+	}
+
+	bold := firstOf(font.Bold, font.Regular)
+	italic := firstOf(font.Italic, font.Regular)
+	styles := map[string]string{
+		"":   font.Regular,
+		"B":  bold,
+		"I":  italic,
+		"BI": firstOf(font.BoldItalic, font.Bold, font.Italic, font.Regular),
+	}
+
+	loaded := map[string][]byte{}
+	for style, path := range styles {
+		data, ok := loaded[path]
+		if !ok {
+			var err error
+			if data, err = os.ReadFile(expandHome(path)); err != nil {
+				return "", fmt.Errorf("failed to load font %s: %w", path, err)
+			}
+			loaded[path] = data
+		}
+		pdf.AddUTF8FontFromBytes(family, style, data)
+	}
+	if err := pdf.Error(); err != nil {
+		return "", fmt.Errorf("failed to load font %s: %w", font.Regular, err)
+	}
+	return family, nil
+}
+
+func firstOf(paths ...string) string {
+	for _, p := range paths {
+		if p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
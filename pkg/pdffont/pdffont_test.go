@@ -0,0 +1,103 @@
+package pdffont
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestSetup(t *testing.T) {
+	type company struct{ Name, Address string }
+	tests := []struct {
+		name    string
+		font    Font
+		content []interface{}
+		want    string
+	}{
+		{"bundled by default", Font{}, []interface{}{"Acme"}, Bundled},
+		{"bundled by name", Font{Family: "dejavu"}, nil, Bundled},
+		{"core font for ASCII", Font{Family: "helvetica"}, []interface{}{company{"Acme Inc", "1 Main St"}}, "Helvetica"},
+		{"fallback for Cyrillic", Font{Family: "Arial"}, []interface{}{company{"ТОВ Приклад", "Київ"}}, Bundled},
+		{"fallback for umlauts", Font{Family: "Times"}, []interface{}{"Acme", company{Name: "Müller GmbH"}}, Bundled},
+		{"fallback for currency symbols", Font{Family: "Courier"}, []interface{}{"€0.00"}, Bundled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdf := gofpdf.New("P", "mm", "A4", "")
+			got, err := Setup(pdf, tt.font, tt.content...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			render(t, pdf, got, "Acme, Київ, Müller")
+		})
+	}
+}
+
+func TestSetupFiles(t *testing.T) {
+	dir := t.TempDir()
+	regular, _ := bundledFonts.ReadFile("fonts/DejaVuSansCondensed.ttf")
+	path := filepath.Join(dir, "MySans-Regular.ttf")
+	if err := os.WriteFile(path, regular, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing styles use the regular file, and a core font name doesn't
+	// hide the files
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	family, err := Setup(pdf, Font{Family: "Arial", Regular: path}, "Київ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if family != "MySans-Regular" {
+		t.Errorf("expected the family to be named after the file, got %s", family)
+	}
+	out := render(t, pdf, family, "Київ")
+	if !bytes.Contains(out, []byte("/FontFile2")) {
+		t.Error("expected the font to be embedded")
+	}
+
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	if family, _ := Setup(pdf, Font{Family: "Brand", Regular: path, Bold: path}); family != "Brand" {
+		t.Errorf("expected the configured family name, got %s", family)
+	}
+
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	if _, err := Setup(pdf, Font{Regular: filepath.Join(dir, "missing.ttf")}); err == nil || !strings.Contains(err.Error(), "failed to load font") {
+		t.Errorf("expected a missing file to be reported, got %v", err)
+	}
+
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	if _, err := Setup(pdf, Font{Family: "Comic Sans"}); err == nil {
+		t.Error("expected an unknown family without files to be refused")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	font := FromConfig(config.PDFConfig{FontFamily: "Brand", FontRegular: "a.ttf", FontBold: "b.ttf", FontItalic: "i.ttf", FontBoldItalic: "bi.ttf"})
+	if font != (Font{Family: "Brand", Regular: "a.ttf", Bold: "b.ttf", Italic: "i.ttf", BoldItalic: "bi.ttf"}) {
+		t.Errorf("unexpected font %+v", font)
+	}
+}
+
+// render writes text in every style and returns the PDF
+func render(t *testing.T, pdf *gofpdf.Fpdf, family, text string) []byte {
+	t.Helper()
+	pdf.AddPage()
+	for _, style := range []string{"", "B", "I", "BI"} {
+		pdf.SetFont(family, style, 12)
+		pdf.Cell(40, 10, text)
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
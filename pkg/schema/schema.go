@@ -0,0 +1,317 @@
+// Package schema creates and upgrades ung databases. It is the single source
+// of truth for the shape of a user database: the CLI, the API server and the
+// Telegram bot (through the API) all open databases migrated by this package,
+// and the GORM models in pkg/models map onto the tables it creates.
+//
+// The schema is the ordered list of SQL migrations in the migrations
+// directory. Applied migrations are recorded in schema_migrations, so a
+// database can be moved to any version, forward or backward.
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/migrations"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Migration is one step of the schema
+type Migration struct {
+	Version string // file name without the .up.sql suffix, e.g. 000001_init_schema
+	Up      string
+	Down    string
+}
+
+var all []Migration
+
+func init() {
+	var err error
+	if all, err = load(migrations.Files); err != nil {
+		panic(err)
+	}
+}
+
+// load reads the up and down scripts of every migration in version order
+func load(files fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	list := make([]Migration, 0, len(names))
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".up.sql")
+		up, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		down, err := fs.ReadFile(files, version+".down.sql")
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no down script: %w", version, err)
+		}
+		list = append(list, Migration{Version: version, Up: string(up), Down: string(down)})
+	}
+	return list, nil
+}
+
+// Migrations returns every migration in the order they are applied
+func Migrations() []Migration {
+	return append([]Migration(nil), all...)
+}
+
+// Latest returns the version a database is at after Migrate
+func Latest() string {
+	return all[len(all)-1].Version
+}
+
+// Known reports whether version is one of the migrations of this build
+func Known(version string) bool {
+	return indexOf(version) >= 0
+}
+
+func indexOf(version string) int {
+	for i, m := range all {
+		if m.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// Migrate brings a database up to the latest version. Databases created
+// before migrations were recorded are adopted first, see MigrateTo.
+func Migrate(conn *sql.DB) error {
+	return MigrateTo(conn, Latest())
+}
+
+// MigrateTo applies or reverts migrations until the database is at version.
+// An empty version reverts every migration.
+//
+// A database that has tables but no recorded migrations was created by an
+// older ung from its inline schema, or by the API through GORM. Its missing
+// tables, columns and indexes are added and it is recorded as being at the
+// latest version, so it can then be moved like any other database.
+func MigrateTo(conn *sql.DB, version string) error {
+	target := -1
+	if version != "" {
+		if target = indexOf(version); target < 0 {
+			return fmt.Errorf("unknown schema version %s", version)
+		}
+	}
+
+	if _, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	applied, err := Applied(conn)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		legacy, err := hasTables(conn)
+		if err != nil {
+			return err
+		}
+		if legacy {
+			if err := adopt(conn); err != nil {
+				return fmt.Errorf("failed to upgrade database: %w", err)
+			}
+			if applied, err = Applied(conn); err != nil {
+				return err
+			}
+		}
+	}
+	done := map[string]bool{}
+	for _, v := range applied {
+		done[v] = true
+	}
+
+	for i := len(all) - 1; i > target; i-- {
+		if done[all[i].Version] {
+			if err := run(conn, all[i].Version, all[i].Down, "DELETE FROM schema_migrations WHERE version = ?"); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 0; i <= target; i++ {
+		if !done[all[i].Version] {
+			if err := run(conn, all[i].Version, all[i].Up, "INSERT INTO schema_migrations (version) VALUES (?)"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run executes one migration script and records it in the same transaction
+func run(conn *sql.DB, version, script, record string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("failed to run migration %s: %w", version, err)
+	}
+	if _, err := tx.Exec(record, version); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", version, err)
+	}
+	return tx.Commit()
+}
+
+// Applied returns the recorded migrations in version order. It includes
+// versions unknown to this build when a newer ung migrated the database.
+func Applied(conn *sql.DB) ([]string, error) {
+	var exists int
+	if err := conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, nil
+	}
+
+	rows, err := conn.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var versions []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// Version returns the latest migration recorded in the database, or an empty
+// string for a database that was never migrated
+func Version(conn *sql.DB) (string, error) {
+	applied, err := Applied(conn)
+	if err != nil || len(applied) == 0 {
+		return "", err
+	}
+	return applied[len(applied)-1], nil
+}
+
+// Reference returns an in-memory database at the latest version, to compare
+// other databases against. The caller closes it.
+func Reference() (*sql.DB, error) {
+	ref, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Every connection to :memory: is a separate database
+	ref.SetMaxOpenConns(1)
+	if err := Migrate(ref); err != nil {
+		ref.Close()
+		return nil, fmt.Errorf("failed to build reference schema: %w", err)
+	}
+	return ref, nil
+}
+
+// hasTables reports whether the database has any tables besides schema_migrations
+func hasTables(conn *sql.DB) (bool, error) {
+	var n int
+	err := conn.QueryRow(`SELECT count(*) FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'`).Scan(&n)
+	return n > 0, err
+}
+
+// adopt adds whatever the latest schema has and the database lacks, then
+// records every migration as applied. Existing tables and data are kept.
+func adopt(conn *sql.DB) error {
+	ref, err := Reference()
+	if err != nil {
+		return err
+	}
+	defer ref.Close()
+
+	expected, err := Tables(ref)
+	if err != nil {
+		return err
+	}
+	actual, err := Tables(conn)
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, name := range sortedKeys(expected) {
+		want := expected[name]
+		have, ok := actual[name]
+		if !ok {
+			if _, err := tx.Exec(want.SQL); err != nil {
+				return fmt.Errorf("failed to create table %s: %w", name, err)
+			}
+			continue
+		}
+		for _, col := range want.Columns {
+			if have.Has(col.Name) {
+				continue
+			}
+			if !col.Addable() {
+				return fmt.Errorf("column %s.%s is missing and can't be added to existing rows", name, col.Name)
+			}
+			if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", name, col.Definition())); err != nil {
+				return fmt.Errorf("failed to add column %s.%s: %w", name, col.Name, err)
+			}
+		}
+	}
+
+	// Indexes and triggers, skipping any whose name is already taken
+	rows, err := ref.Query("SELECT name, sql FROM sqlite_master WHERE type IN ('index', 'trigger') AND sql IS NOT NULL ORDER BY type, name")
+	if err != nil {
+		return err
+	}
+	var objects [][2]string
+	for rows.Next() {
+		var name, stmt string
+		if err := rows.Scan(&name, &stmt); err != nil {
+			rows.Close()
+			return err
+		}
+		objects = append(objects, [2]string{name, stmt})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, o := range objects {
+		var exists int
+		if err := tx.QueryRow("SELECT count(*) FROM sqlite_master WHERE name = ?", o[0]).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		if _, err := tx.Exec(o[1]); err != nil {
+			return fmt.Errorf("failed to create %s: %w", o[0], err)
+		}
+	}
+
+	for _, m := range all {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO schema_migrations (version) VALUES (?)", m.Version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,272 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Andriiklymiuk/ung/pkg/audit"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ung.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// shape describes every table as its column definitions, ignoring column
+// order, which differs between fresh and upgraded databases
+func shape(t *testing.T, conn *sql.DB) map[string][]string {
+	t.Helper()
+	tables, err := Tables(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string][]string{}
+	for name, table := range tables {
+		var cols []string
+		for _, c := range table.Columns {
+			cols = append(cols, strings.ToLower(c.Definition()))
+		}
+		out[name] = sortedKeys(setOf(cols))
+	}
+	return out
+}
+
+func setOf(items []string) map[string]bool {
+	m := map[string]bool{}
+	for _, item := range items {
+		m[item] = true
+	}
+	return m
+}
+
+// freshShape is the shape of a new database migrated straight to version
+func freshShape(t *testing.T, version string) map[string][]string {
+	t.Helper()
+	conn := openDB(t)
+	if err := MigrateTo(conn, version); err != nil {
+		t.Fatalf("migrating a new database to %s: %v", version, err)
+	}
+	return shape(t, conn)
+}
+
+func count(t *testing.T, conn *sql.DB, query string) int {
+	t.Helper()
+	var n int
+	if err := conn.QueryRow(query).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	conn := openDB(t)
+	if err := Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	// Running again is a no-op
+	if err := Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := Version(conn); v != Latest() {
+		t.Errorf("expected version %s, got %s", Latest(), v)
+	}
+	if n := count(t, conn, "SELECT count(*) FROM schema_migrations"); n != len(Migrations()) {
+		t.Errorf("expected %d recorded migrations, got %d", len(Migrations()), n)
+	}
+	tables := shape(t, conn)
+	for _, name := range []string{"companies", "invoices", "gigs", "dig_sessions", "audit_log",
+		"recurring_invoices", "user_settings", "rate_settings", "pomodoro_sessions", "invoice_templates"} {
+		if _, ok := tables[name]; !ok {
+			t.Errorf("table %s is missing", name)
+		}
+	}
+}
+
+// Databases created by every earlier version upgrade to the same schema as a
+// new database, keeping their rows
+func TestMigrateForwardFromEveryVersion(t *testing.T) {
+	latest := freshShape(t, Latest())
+
+	for _, m := range Migrations() {
+		t.Run(m.Version, func(t *testing.T) {
+			conn := openDB(t)
+			if err := MigrateTo(conn, m.Version); err != nil {
+				t.Fatal(err)
+			}
+			seed(t, conn)
+
+			if err := Migrate(conn); err != nil {
+				t.Fatalf("upgrading from %s: %v", m.Version, err)
+			}
+			if got := shape(t, conn); !reflect.DeepEqual(got, latest) {
+				t.Errorf("upgraded schema differs from a new database:\n%s", diffShapes(got, latest))
+			}
+			checkSeed(t, conn)
+		})
+	}
+}
+
+// Every migration can be reverted, leaving the schema that version had, and
+// applied again
+func TestMigrateBackwardToEveryVersion(t *testing.T) {
+	conn := openDB(t)
+	if err := Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	// The CLI adds triggers that name every audited column
+	if err := audit.Install(conn); err != nil {
+		t.Fatal(err)
+	}
+	seed(t, conn)
+
+	list := Migrations()
+	for i := len(list) - 2; i >= 0; i-- {
+		version := list[i].Version
+		if err := MigrateTo(conn, version); err != nil {
+			t.Fatalf("downgrading to %s: %v", version, err)
+		}
+		if v, _ := Version(conn); v != version {
+			t.Fatalf("expected version %s, got %s", version, v)
+		}
+		if got, want := shape(t, conn), freshShape(t, version); !reflect.DeepEqual(got, want) {
+			t.Errorf("schema after downgrading to %s differs from that version:\n%s", version, diffShapes(got, want))
+		}
+	}
+
+	if err := Migrate(conn); err != nil {
+		t.Fatalf("upgrading again: %v", err)
+	}
+	if got, want := shape(t, conn), freshShape(t, Latest()); !reflect.DeepEqual(got, want) {
+		t.Errorf("schema after a full round trip differs:\n%s", diffShapes(got, want))
+	}
+	checkSeed(t, conn)
+
+	if err := MigrateTo(conn, "999999_from_the_future"); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
+
+// A database created by the old inline schema, without schema_migrations,
+// is adopted: its data is kept and it ends up with everything a new
+// database has
+func TestMigrateAdoptsInlineSchemaDatabase(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "inline_schema.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := openDB(t)
+	if _, err := conn.Exec(string(fixture)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := Version(conn); v != Latest() {
+		t.Errorf("expected version %s, got %s", Latest(), v)
+	}
+
+	// Columns the old schema declared differently keep their definition
+	got, err := Tables(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := Reference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	want, err := Tables(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, table := range want {
+		for _, col := range table.Columns {
+			if !got[name].Has(col.Name) {
+				t.Errorf("column %s.%s is missing", name, col.Name)
+			}
+		}
+	}
+
+	for query, want := range map[string]int{
+		"SELECT count(*) FROM invoices WHERE invoice_num = 'INV-2024-001' AND paid_date IS NULL": 1,
+		"SELECT count(*) FROM invoice_line_items WHERE amount = 250 AND tax_amount = 0":          1,
+		"SELECT count(*) FROM tracking_sessions WHERE duration = 9000 AND external_id IS NULL":   1,
+		"SELECT count(*) FROM recurring_invoices WHERE invoice_prefix = 'REC'":                   1,
+		"SELECT count(*) FROM income_goals WHERE goal_type = 'income'":                           1,
+		"SELECT count(*) FROM user_settings WHERE value = '32'":                                  1,
+		"SELECT count(*) FROM companies WHERE bank_name IS NULL":                                 1,
+	} {
+		if n := count(t, conn, query); n != want {
+			t.Errorf("%s: expected %d, got %d", query, want, n)
+		}
+	}
+
+	// From here on it moves between versions like any other database
+	previous := Migrations()[len(Migrations())-2].Version
+	if err := MigrateTo(conn, previous); err != nil {
+		t.Fatalf("downgrading the adopted database: %v", err)
+	}
+	if err := Migrate(conn); err != nil {
+		t.Fatalf("upgrading the adopted database again: %v", err)
+	}
+}
+
+// seed inserts rows into the tables every version has
+func seed(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	for _, stmt := range []string{
+		"INSERT INTO companies (id, name, email) VALUES (1, 'Me LLC', 'me@example.com')",
+		"INSERT INTO clients (id, name, email) VALUES (1, 'Acme', 'ap@acme.test')",
+		"INSERT INTO invoices (id, invoice_num, company_id, amount) VALUES (1, 'INV-1', 1, 250)",
+		"INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (1, 1)",
+		"INSERT INTO tracking_sessions (client_id, start_time, duration) VALUES (1, '2024-01-10 09:00:00', 9000)",
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+func checkSeed(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	for _, query := range []string{
+		"SELECT count(*) FROM companies WHERE name = 'Me LLC'",
+		"SELECT count(*) FROM clients WHERE name = 'Acme'",
+		"SELECT count(*) FROM invoices WHERE invoice_num = 'INV-1' AND amount = 250",
+		"SELECT count(*) FROM invoice_recipients WHERE invoice_id = 1 AND client_id = 1",
+		"SELECT count(*) FROM tracking_sessions WHERE duration = 9000",
+	} {
+		if n := count(t, conn, query); n != 1 {
+			t.Errorf("%s: expected 1 row, got %d", query, n)
+		}
+	}
+}
+
+func diffShapes(got, want map[string][]string) string {
+	var b strings.Builder
+	for _, table := range sortedKeys(want) {
+		if _, ok := got[table]; !ok {
+			fmt.Fprintf(&b, "  missing table %s\n", table)
+		} else if !reflect.DeepEqual(got[table], want[table]) {
+			fmt.Fprintf(&b, "  %s: got %v, want %v\n", table, got[table], want[table])
+		}
+	}
+	for _, table := range sortedKeys(got) {
+		if _, ok := want[table]; !ok {
+			fmt.Fprintf(&b, "  unexpected table %s\n", table)
+		}
+	}
+	return b.String()
+}
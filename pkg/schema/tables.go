@@ -0,0 +1,101 @@
+package schema
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// Column is a column of a table as SQLite reports it
+type Column struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default sql.NullString
+}
+
+// Definition returns the column as it's written in ALTER TABLE ADD COLUMN
+func (c Column) Definition() string {
+	def := c.Name
+	if c.Type != "" {
+		def += " " + c.Type
+	}
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	if c.Default.Valid {
+		def += " DEFAULT " + c.Default.String
+	}
+	return def
+}
+
+// Addable reports whether SQLite can add the column to a table that has
+// rows: NOT NULL columns need a default
+func (c Column) Addable() bool {
+	return !c.NotNull || c.Default.Valid
+}
+
+// Table is a table with the statement that created it
+type Table struct {
+	SQL     string
+	Columns []Column
+}
+
+// Has reports whether the table has a column
+func (t Table) Has(column string) bool {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tables returns the tables of a database by name, without schema_migrations
+func Tables(conn *sql.DB) (map[string]Table, error) {
+	rows, err := conn.Query(`SELECT name, sql FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'`)
+	if err != nil {
+		return nil, err
+	}
+	tables := map[string]Table{}
+	for rows.Next() {
+		var name, stmt string
+		if err := rows.Scan(&name, &stmt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables[name] = Table{SQL: stmt}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for name, table := range tables {
+		cols, err := conn.Query(`SELECT name, type, "notnull", dflt_value FROM pragma_table_info(?) ORDER BY cid`, name)
+		if err != nil {
+			return nil, err
+		}
+		for cols.Next() {
+			var c Column
+			if err := cols.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default); err != nil {
+				cols.Close()
+				return nil, err
+			}
+			table.Columns = append(table.Columns, c)
+		}
+		cols.Close()
+		tables[name] = table
+	}
+	return tables, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,173 @@
+-- A database created by ung before migrations were recorded: the CLI's inline
+-- schema, run when no migrations directory was found, plus the tables
+-- `ung goal` and `ung settings` created through GORM. Used to test that such
+-- databases are adopted without losing data.
+
+CREATE TABLE IF NOT EXISTS companies (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	address TEXT,
+	tax_id TEXT,
+	phone TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS clients (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	address TEXT,
+	tax_id TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS contracts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	contract_num TEXT UNIQUE NOT NULL,
+	client_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	contract_type TEXT NOT NULL,
+	hourly_rate REAL,
+	fixed_price REAL,
+	currency TEXT DEFAULT 'USD',
+	start_date TIMESTAMP NOT NULL,
+	end_date TIMESTAMP,
+	active BOOLEAN DEFAULT 1,
+	notes TEXT,
+	pdf_path TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients(id)
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	invoice_num TEXT UNIQUE NOT NULL,
+	company_id INTEGER NOT NULL,
+	amount REAL NOT NULL,
+	currency TEXT DEFAULT 'USD',
+	description TEXT,
+	status TEXT DEFAULT 'pending',
+	issued_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	due_date TIMESTAMP,
+	pdf_path TEXT,
+	notes TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (company_id) REFERENCES companies(id)
+);
+
+CREATE TABLE IF NOT EXISTS invoice_line_items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	invoice_id INTEGER NOT NULL,
+	item_name TEXT NOT NULL,
+	description TEXT,
+	quantity REAL NOT NULL,
+	rate REAL NOT NULL,
+	amount REAL NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (invoice_id) REFERENCES invoices(id)
+);
+
+CREATE TABLE IF NOT EXISTS invoice_recipients (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	invoice_id INTEGER NOT NULL,
+	client_id INTEGER NOT NULL,
+	FOREIGN KEY (invoice_id) REFERENCES invoices(id),
+	FOREIGN KEY (client_id) REFERENCES clients(id)
+);
+
+CREATE TABLE IF NOT EXISTS tracking_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id INTEGER,
+	contract_id INTEGER,
+	project_name TEXT,
+	start_time TIMESTAMP NOT NULL,
+	end_time TIMESTAMP,
+	duration INTEGER,
+	hours REAL,
+	billable BOOLEAN DEFAULT 1,
+	notes TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients(id),
+	FOREIGN KEY (contract_id) REFERENCES contracts(id)
+);
+
+CREATE TABLE IF NOT EXISTS expenses (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	description TEXT NOT NULL,
+	amount REAL NOT NULL,
+	currency TEXT DEFAULT 'USD',
+	category TEXT NOT NULL,
+	date TIMESTAMP NOT NULL,
+	vendor TEXT,
+	notes TEXT,
+	receipt_path TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS recurring_invoices (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id INTEGER NOT NULL,
+	contract_id INTEGER,
+	amount REAL NOT NULL,
+	currency TEXT DEFAULT 'USD',
+	description TEXT,
+	frequency TEXT NOT NULL,
+	day_of_month INTEGER DEFAULT 1,
+	day_of_week INTEGER DEFAULT 1,
+	next_generation_date TIMESTAMP NOT NULL,
+	last_generated_date TIMESTAMP,
+	last_invoice_id INTEGER,
+	active BOOLEAN DEFAULT 1,
+	auto_send BOOLEAN DEFAULT 0,
+	auto_pdf BOOLEAN DEFAULT 1,
+	email_app TEXT,
+	generated_count INTEGER DEFAULT 0,
+	notes TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients(id),
+	FOREIGN KEY (contract_id) REFERENCES contracts(id),
+	FOREIGN KEY (last_invoice_id) REFERENCES invoices(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_company ON invoices(company_id);
+CREATE INDEX IF NOT EXISTS idx_invoice_recipients_invoice ON invoice_recipients(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_invoice_recipients_client ON invoice_recipients(client_id);
+CREATE INDEX IF NOT EXISTS idx_tracking_sessions_client ON tracking_sessions(client_id);
+CREATE INDEX IF NOT EXISTS idx_tracking_sessions_contract ON tracking_sessions(contract_id);
+CREATE INDEX IF NOT EXISTS idx_contracts_client ON contracts(client_id);
+CREATE INDEX IF NOT EXISTS idx_contracts_active ON contracts(active);
+CREATE INDEX IF NOT EXISTS idx_invoice_line_items_invoice ON invoice_line_items(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_invoices_client ON recurring_invoices(client_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_invoices_active ON recurring_invoices(active);
+CREATE INDEX IF NOT EXISTS idx_recurring_invoices_next_date ON recurring_invoices(next_generation_date);
+
+CREATE TABLE `income_goals` (`id` integer PRIMARY KEY AUTOINCREMENT,`amount` real NOT NULL,`period` text NOT NULL,`year` integer NOT NULL,`month` integer,`quarter` integer,`description` text,`created_at` datetime,`updated_at` datetime);
+CREATE TABLE `user_settings` (`id` integer PRIMARY KEY AUTOINCREMENT,`key` text NOT NULL,`value` text NOT NULL,`created_at` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX `idx_user_settings_key` ON `user_settings`(`key`);
+
+INSERT INTO companies (id, name, email) VALUES (1, 'Me LLC', 'me@example.com');
+INSERT INTO clients (id, name, email, tax_id) VALUES (1, 'Acme', 'ap@acme.test', 'US123');
+INSERT INTO contracts (id, contract_num, client_id, name, contract_type, hourly_rate, start_date)
+    VALUES (1, 'contract.acme.jan.2024', 1, 'Website', 'hourly', 100, '2024-01-01 00:00:00');
+INSERT INTO invoices (id, invoice_num, company_id, amount, status, due_date)
+    VALUES (1, 'INV-2024-001', 1, 250, 'sent', '2024-02-15 00:00:00');
+INSERT INTO invoice_line_items (invoice_id, item_name, quantity, rate, amount) VALUES (1, 'Development', 2.5, 100, 250);
+INSERT INTO invoice_recipients (invoice_id, client_id) VALUES (1, 1);
+INSERT INTO tracking_sessions (client_id, contract_id, project_name, start_time, end_time, duration, hours)
+    VALUES (1, 1, 'Website', '2024-01-10 09:00:00', '2024-01-10 11:30:00', 9000, 2.5);
+INSERT INTO expenses (description, amount, category, date) VALUES ('Laptop', 1999, 'hardware', '2024-01-05 00:00:00');
+INSERT INTO recurring_invoices (client_id, contract_id, amount, frequency, next_generation_date)
+    VALUES (1, 1, 1000, 'monthly', '2024-02-01 00:00:00');
+INSERT INTO income_goals (amount, period, year, month) VALUES (5000, 'monthly', 2024, 1);
+INSERT INTO user_settings (key, value) VALUES ('weekly_hours_target', '32');
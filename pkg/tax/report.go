@@ -0,0 +1,118 @@
+package tax
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+// Return are the figures of a VAT return for invoices in one currency
+type Return struct {
+	Currency      string
+	Invoices      int
+	Rates         []TaxedSales        // Taxed sales by kind of tax and rate, VAT first, highest rate first
+	ReverseCharge []ReverseChargeSale // Sales to EU businesses, for the EC sales list
+	Exempt        float64             // Net of invoices exempt from VAT
+	NotTaxed      float64             // Net of invoices without tax, e.g. to clients abroad
+	Net           float64             // Net of all invoices
+	Tax           float64             // Output tax, the VAT to pay
+	SalesTax      float64             // US sales tax collected, paid to the states
+}
+
+// TaxedSales are the sales taxed at one rate with one kind of tax
+type TaxedSales struct {
+	Type string // TypeVAT or TypeSalesTax
+	Rate
+}
+
+// ReverseChargeSale is the net invoiced to one client with reverse charge
+type ReverseChargeSale struct {
+	Client string
+	TaxID  string
+	Net    float64
+}
+
+// Add adds an invoice with its line items to the return. Invoices without
+// line items count with their stored totals.
+func (r *Return) Add(inv models.Invoice, client models.Client, items []models.InvoiceLineItem) {
+	r.Invoices++
+	net := inv.Net()
+	r.Net = Round(r.Net + net)
+
+	switch inv.TaxType {
+	case TypeReverseCharge:
+		r.addReverseCharge(client, net)
+		return
+	case TypeExempt:
+		r.Exempt = Round(r.Exempt + net)
+		return
+	}
+
+	var rates []Rate
+	if len(items) > 0 {
+		rates = Calculate(items).Rates
+	} else if inv.TaxAmount != 0 && net != 0 {
+		// The rate of stored totals, rounded to e.g. 0.19
+		rate := math.Round(inv.TaxAmount/net*10000) / 10000
+		rates = []Rate{{Rate: rate, Net: net, Tax: inv.TaxAmount}}
+	} else {
+		rates = []Rate{{Net: net}}
+	}
+
+	kind := TypeVAT
+	if inv.TaxType == TypeSalesTax {
+		kind = TypeSalesTax
+	}
+	for _, rate := range rates {
+		// Zero-rated lines of a VAT invoice are reported as such, other
+		// untaxed sales separately
+		if rate.Rate == 0 && inv.TaxType != TypeVAT && inv.TaxType != TypeSalesTax {
+			r.NotTaxed = Round(r.NotTaxed + rate.Net)
+			continue
+		}
+		r.addRate(TaxedSales{Type: kind, Rate: rate})
+	}
+}
+
+func (r *Return) addRate(sales TaxedSales) {
+	if sales.Type == TypeSalesTax {
+		r.SalesTax = Round(r.SalesTax + sales.Tax)
+	} else {
+		r.Tax = Round(r.Tax + sales.Tax)
+	}
+	for i := range r.Rates {
+		if r.Rates[i].Type == sales.Type && r.Rates[i].Rate.Rate == sales.Rate.Rate {
+			r.Rates[i].Net = Round(r.Rates[i].Net + sales.Net)
+			r.Rates[i].Tax = Round(r.Rates[i].Tax + sales.Tax)
+			return
+		}
+	}
+	r.Rates = append(r.Rates, sales)
+	sort.Slice(r.Rates, func(i, j int) bool {
+		if r.Rates[i].Type != r.Rates[j].Type {
+			return r.Rates[i].Type == TypeVAT
+		}
+		return r.Rates[i].Rate.Rate > r.Rates[j].Rate.Rate
+	})
+}
+
+func (r *Return) addReverseCharge(client models.Client, net float64) {
+	for i := range r.ReverseCharge {
+		if r.ReverseCharge[i].TaxID == client.TaxID {
+			r.ReverseCharge[i].Net = Round(r.ReverseCharge[i].Net + net)
+			return
+		}
+	}
+	r.ReverseCharge = append(r.ReverseCharge, ReverseChargeSale{Client: client.Name, TaxID: client.TaxID, Net: net})
+	sort.Slice(r.ReverseCharge, func(i, j int) bool { return r.ReverseCharge[i].TaxID < r.ReverseCharge[j].TaxID })
+}
+
+// ReverseChargeNet returns the net of all reverse charge sales
+func (r *Return) ReverseChargeNet() float64 {
+	total := 0.0
+	for _, s := range r.ReverseCharge {
+		total += s.Net
+	}
+	return Round(total)
+}
@@ -0,0 +1,42 @@
+package tax
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StateRates are the statewide base sales tax rates of US states as of
+// 2024. County and city rates come on top and vary, set the rates of a
+// sales_tax profile to the combined rate of where you deliver.
+var StateRates = map[string]float64{
+	"AL": 0.04, "AK": 0, "AZ": 0.056, "AR": 0.065, "CA": 0.0725,
+	"CO": 0.029, "CT": 0.0635, "DE": 0, "DC": 0.06, "FL": 0.06,
+	"GA": 0.04, "HI": 0.04, "ID": 0.06, "IL": 0.0625, "IN": 0.07,
+	"IA": 0.06, "KS": 0.065, "KY": 0.06, "LA": 0.0445, "ME": 0.055,
+	"MD": 0.06, "MA": 0.0625, "MI": 0.06, "MN": 0.06875, "MS": 0.07,
+	"MO": 0.04225, "MT": 0, "NE": 0.055, "NV": 0.0685, "NH": 0,
+	"NJ": 0.06625, "NM": 0.04875, "NY": 0.04, "NC": 0.0475, "ND": 0.05,
+	"OH": 0.0575, "OK": 0.045, "OR": 0, "PA": 0.06, "RI": 0.07,
+	"SC": 0.06, "SD": 0.042, "TN": 0.07, "TX": 0.0625, "UT": 0.061,
+	"VT": 0.06, "VA": 0.053, "WA": 0.065, "WV": 0.06, "WI": 0.05,
+	"WY": 0.04,
+}
+
+// stateZIP matches the "CA 94103" part of a US address
+var stateZIP = regexp.MustCompile(`(?:^|[\s,])([A-Z]{2})\s+\d{5}(?:-\d{4})?\b`)
+
+// StateOf returns the state code of a US address, e.g. "CA" for
+// "1 Market St, San Francisco, CA 94103", or "" when it has none
+func StateOf(address string) string {
+	for _, m := range stateZIP.FindAllStringSubmatch(address, -1) {
+		if _, ok := StateRates[m[1]]; ok {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// normalizeState upper-cases a state code from the config
+func normalizeState(state string) string {
+	return strings.ToUpper(strings.TrimSpace(state))
+}
@@ -0,0 +1,256 @@
+// Package tax calculates the tax of invoices from tax profiles: domestic VAT,
+// EU reverse charge, VAT exemption and US sales tax by state.
+//
+// Profiles are configured in the tax section of the config and assigned to
+// clients and contracts by name. An invoice uses the profile of its contract,
+// otherwise the one of its client, otherwise tax.default. The totals are
+// calculated when the invoice is created and stored on it and its line items,
+// so changing a profile later doesn't change issued invoices.
+package tax
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+// Profile types
+const (
+	TypeNone          = "none"           // No tax
+	TypeVAT           = "vat"            // Domestic VAT at the profile's rate
+	TypeReverseCharge = "reverse_charge" // EU B2B supply, the client accounts for the VAT
+	TypeExempt        = "exempt"         // Exempt from VAT
+	TypeSalesTax      = "sales_tax"      // US sales tax at the rate of the client's state
+)
+
+// Types lists the profile types that can be configured
+var Types = []string{TypeVAT, TypeReverseCharge, TypeExempt, TypeSalesTax, TypeNone}
+
+// Built-in profiles, a configured profile with the same name replaces them
+const (
+	ProfileNone          = "none"
+	ProfileVAT           = "vat" // At pdf.tax_rate
+	ProfileReverseCharge = "reverse-charge"
+	ProfileExempt        = "exempt"
+	ProfileSalesTax      = "us-sales-tax"
+)
+
+// Profile is a named tax profile
+type Profile struct {
+	Name string
+	config.TaxProfile
+}
+
+// builtin returns the profiles that exist without configuration
+func builtin(cfg *config.Config) map[string]config.TaxProfile {
+	return map[string]config.TaxProfile{
+		ProfileNone:          {Type: TypeNone},
+		ProfileVAT:           {Type: TypeVAT, Rate: cfg.PDF.TaxRate},
+		ProfileReverseCharge: {Type: TypeReverseCharge},
+		ProfileExempt:        {Type: TypeExempt},
+		ProfileSalesTax:      {Type: TypeSalesTax, Label: "Sales Tax"},
+	}
+}
+
+// Names returns the names of the built-in and configured profiles, sorted
+func Names(cfg *config.Config) []string {
+	set := builtin(cfg)
+	for name, p := range cfg.Tax.Profiles {
+		set[name] = p
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns a configured or built-in profile by name
+func Lookup(cfg *config.Config, name string) (Profile, error) {
+	p, ok := cfg.Tax.Profiles[name]
+	if !ok {
+		p, ok = builtin(cfg)[name]
+	}
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown tax profile %q (available: %s)", name, strings.Join(Names(cfg), ", "))
+	}
+	if !validType(p.Type) {
+		return Profile{}, fmt.Errorf("tax profile %q has type %q (valid: %s)", name, p.Type, strings.Join(Types, ", "))
+	}
+	return Profile{Name: name, TaxProfile: p}, nil
+}
+
+// Validate returns an error when there is no profile called name. An empty
+// name is valid and means the default.
+func Validate(cfg *config.Config, name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := Lookup(cfg, name)
+	return err
+}
+
+// Resolve returns the profile of the first non-empty name, e.g. of the
+// contract and then the client, falling back to tax.default. Without any,
+// the zero Profile is returned, which calculates no tax.
+func Resolve(cfg *config.Config, names ...string) (Profile, error) {
+	for _, name := range append(names, cfg.Tax.Default) {
+		if name != "" {
+			return Lookup(cfg, name)
+		}
+	}
+	return Profile{}, nil
+}
+
+// ResolveBuiltin is Resolve for callers without a config, such as the API.
+// Only the built-in profiles exist and the vat profile has no rate, so lines
+// keep the rates they were given.
+func ResolveBuiltin(names ...string) (Profile, error) {
+	return Resolve(&config.Config{}, names...)
+}
+
+func validType(t string) bool {
+	for _, v := range Types {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Result is the tax of an invoice as it's stored on it
+type Result struct {
+	Totals
+	Profile string // Name of the profile
+	Type    string // Type of the profile
+	Note    string // Note printed on the invoice
+}
+
+// Apply sets the tax rate and amount of each line item for a client and
+// returns the invoice totals. With VAT, sales tax or no profile, lines that
+// already have a rate keep it, so an invoice can mix rates. Reverse charge,
+// exempt and none set every rate to 0. loc gives the note of reverse charge
+// and exempt invoices in the client's language.
+func (p Profile) Apply(client models.Client, items []models.InvoiceLineItem, loc *locale.Pack) (Result, error) {
+	rate := 0.0
+	keep := false
+	switch p.Type {
+	case "":
+		keep = true
+	case TypeVAT:
+		rate, keep = p.Rate, true
+	case TypeSalesTax:
+		r, err := p.salesTaxRate(client.AddressFor(models.AddressBilling))
+		if err != nil {
+			return Result{}, err
+		}
+		rate, keep = r, true
+	case TypeReverseCharge:
+		if strings.TrimSpace(client.TaxID) == "" {
+			return Result{}, fmt.Errorf("reverse charge needs the VAT ID of %s (set it with 'ung client edit')", client.Name)
+		}
+	}
+
+	for i := range items {
+		if !keep || items[i].TaxRate == 0 {
+			items[i].TaxRate = rate
+		}
+		items[i].TaxAmount = Round(LineNet(items[i]) * items[i].TaxRate)
+	}
+
+	result := Result{Totals: Calculate(items), Profile: p.Name, Type: p.Type, Note: p.Note}
+	if result.Note == "" {
+		switch p.Type {
+		case TypeReverseCharge:
+			result.Note = loc.Text.ReverseCharge
+		case TypeExempt:
+			result.Note = loc.Text.TaxExempt
+		}
+	}
+	return result, nil
+}
+
+// salesTaxRate returns the rate of the state in a US address, or of the
+// profile's state when the address has none
+func (p Profile) salesTaxRate(address string) (float64, error) {
+	state := StateOf(address)
+	if state == "" {
+		state = normalizeState(p.State)
+	}
+	if state == "" {
+		if p.Rate > 0 {
+			return p.Rate, nil
+		}
+		return 0, fmt.Errorf("no US state in the client address for sales tax (add \"CA 94103\" to it, or set state on tax profile %q)", p.Name)
+	}
+	for s, r := range p.Rates {
+		if normalizeState(s) == state {
+			return r, nil
+		}
+	}
+	if r, ok := StateRates[state]; ok {
+		return r, nil
+	}
+	if p.Rate > 0 {
+		return p.Rate, nil
+	}
+	return 0, fmt.Errorf("unknown US state %q in tax profile %q", state, p.Name)
+}
+
+// Totals are the net, tax and gross amounts of an invoice
+type Totals struct {
+	Net   float64
+	Tax   float64
+	Gross float64
+	Rates []Rate // One per tax rate, highest first
+}
+
+// Rate is the part of an invoice taxed at one rate
+type Rate struct {
+	Rate float64 // e.g. 0.19
+	Net  float64
+	Tax  float64
+}
+
+// Calculate totals line items by their tax rate. The tax of each rate is
+// rounded once, as VAT returns and EN 16931 do, so it can differ by a cent
+// from the sum of the line amounts.
+func Calculate(items []models.InvoiceLineItem) Totals {
+	byRate := map[float64]float64{}
+	for _, item := range items {
+		byRate[item.TaxRate] += LineNet(item)
+	}
+
+	var t Totals
+	for rate, net := range byRate {
+		r := Rate{Rate: rate, Net: Round(net), Tax: Round(net * rate)}
+		t.Rates = append(t.Rates, r)
+		t.Net += r.Net
+		t.Tax += r.Tax
+	}
+	sort.Slice(t.Rates, func(i, j int) bool { return t.Rates[i].Rate > t.Rates[j].Rate })
+	t.Net, t.Tax = Round(t.Net), Round(t.Tax)
+	t.Gross = Round(t.Net + t.Tax)
+	return t
+}
+
+// LineNet returns the amount of a line item after its discount. A
+// percentage discount wins over an amount.
+func LineNet(item models.InvoiceLineItem) float64 {
+	discount := item.Discount
+	if item.DiscountPct > 0 {
+		discount = item.Amount * item.DiscountPct / 100
+	}
+	return item.Amount - discount
+}
+
+// Round rounds an amount to cents
+func Round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,229 @@
+package tax
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.PDF.TaxRate = 0.19
+	cfg.Tax.Profiles = map[string]config.TaxProfile{
+		"de-reduced": {Type: TypeVAT, Rate: 0.07},
+		"us":         {Type: TypeSalesTax, Rates: map[string]float64{"ny": 0.08875}},
+		"broken":     {Type: "gst"},
+	}
+	return cfg
+}
+
+func lines(amounts ...float64) []models.InvoiceLineItem {
+	items := make([]models.InvoiceLineItem, len(amounts))
+	for i, a := range amounts {
+		items[i] = models.InvoiceLineItem{Quantity: 1, Rate: a, Amount: a}
+	}
+	return items
+}
+
+func TestApply(t *testing.T) {
+	cfg := testConfig()
+	en := locale.Get("en")
+	de := locale.Get("de")
+
+	tests := []struct {
+		name     string
+		profile  string
+		client   models.Client
+		items    []models.InvoiceLineItem
+		loc      *locale.Pack
+		net, tax float64
+		note     string
+		err      string
+	}{
+		{name: "vat", profile: ProfileVAT, items: lines(100, 50.5), loc: en, net: 150.5, tax: 28.6},
+		{name: "configured vat", profile: "de-reduced", items: lines(100), loc: en, net: 100, tax: 7},
+		{
+			name: "reverse charge", profile: ProfileReverseCharge,
+			client: models.Client{Name: "Acme BV", TaxID: "NL123456789B01"},
+			items:  lines(1000), loc: de, net: 1000, tax: 0, note: de.Text.ReverseCharge,
+		},
+		{
+			name: "reverse charge without VAT ID", profile: ProfileReverseCharge,
+			client: models.Client{Name: "Acme BV"}, items: lines(1000), loc: en, err: "VAT ID of Acme BV",
+		},
+		{name: "exempt", profile: ProfileExempt, items: lines(200), loc: en, net: 200, note: en.Text.TaxExempt},
+		{name: "none", profile: ProfileNone, items: lines(200), loc: en, net: 200},
+		{
+			name: "sales tax from address", profile: ProfileSalesTax,
+			client: models.Client{Address: "1 Market St\nSan Francisco, CA 94103"},
+			items:  lines(100), loc: en, net: 100, tax: 7.25,
+		},
+		{
+			name: "configured state rate", profile: "us",
+			client: models.Client{Address: "350 5th Ave, New York, NY 10118-0110"},
+			items:  lines(100), loc: en, net: 100, tax: 8.88,
+		},
+		{
+			name: "sales tax without state", profile: ProfileSalesTax,
+			client: models.Client{Address: "Kyiv, Ukraine"}, items: lines(100), loc: en, err: "no US state",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Lookup(cfg, tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := p.Apply(tt.client, tt.items, tt.loc)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Net != tt.net || r.Tax != tt.tax || r.Gross != Round(tt.net+tt.tax) {
+				t.Errorf("got net %.2f tax %.2f gross %.2f, want %.2f %.2f", r.Net, r.Tax, r.Gross, tt.net, tt.tax)
+			}
+			if r.Note != tt.note {
+				t.Errorf("got note %q, want %q", r.Note, tt.note)
+			}
+			if r.Profile != tt.profile {
+				t.Errorf("got profile %q, want %q", r.Profile, tt.profile)
+			}
+		})
+	}
+}
+
+func TestApplyKeepsLineRates(t *testing.T) {
+	p, _ := Lookup(testConfig(), ProfileVAT)
+	items := lines(100, 100)
+	items[1].TaxRate = 0.07
+	r, err := p.Apply(models.Client{}, items, locale.Get("en"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Rates) != 2 || r.Rates[0].Rate != 0.19 || r.Rates[1].Rate != 0.07 {
+		t.Fatalf("expected 19%% and 7%% rates, got %+v", r.Rates)
+	}
+	if r.Tax != 26 || items[0].TaxAmount != 19 || items[1].TaxAmount != 7 {
+		t.Errorf("unexpected tax %.2f, lines %.2f %.2f", r.Tax, items[0].TaxAmount, items[1].TaxAmount)
+	}
+
+	// Reverse charge zeroes every rate
+	rc, _ := Lookup(testConfig(), ProfileReverseCharge)
+	r, _ = rc.Apply(models.Client{TaxID: "FR123"}, items, locale.Get("en"))
+	if r.Tax != 0 || items[1].TaxRate != 0 {
+		t.Errorf("expected no tax with reverse charge, got %.2f", r.Tax)
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	// Three lines of 0.35 at 19% are 0.0665 tax each: rounded once per rate
+	// that's 0.20, not 3 × 0.07
+	items := lines(0.35, 0.35, 0.35)
+	for i := range items {
+		items[i].TaxRate = 0.19
+	}
+	items = append(items, models.InvoiceLineItem{Amount: 200, DiscountPct: 10, Discount: 50})
+
+	totals := Calculate(items)
+	if totals.Net != 181.05 || totals.Tax != 0.2 || totals.Gross != 181.25 {
+		t.Errorf("got %+v", totals)
+	}
+	if len(totals.Rates) != 2 || totals.Rates[1].Rate != 0 || totals.Rates[1].Net != 180 {
+		t.Errorf("unexpected rates %+v", totals.Rates)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cfg := testConfig()
+
+	p, err := Resolve(cfg, "", "")
+	if err != nil || p.Type != "" {
+		t.Errorf("expected no profile without names or default, got %+v, %v", p, err)
+	}
+
+	cfg.Tax.Default = ProfileVAT
+	if p, _ := Resolve(cfg, "", ""); p.Name != ProfileVAT || p.Rate != 0.19 {
+		t.Errorf("expected the default, got %+v", p)
+	}
+	if p, _ := Resolve(cfg, "", "de-reduced", ProfileExempt); p.Name != "de-reduced" {
+		t.Errorf("expected the first name, got %+v", p)
+	}
+
+	if _, err := Resolve(cfg, "missing"); err == nil || !strings.Contains(err.Error(), "available:") {
+		t.Errorf("expected unknown profile error, got %v", err)
+	}
+	if err := Validate(cfg, "broken"); err == nil {
+		t.Error("expected an error for an invalid profile type")
+	}
+	if err := Validate(cfg, ""); err != nil {
+		t.Errorf("expected empty to be valid, got %v", err)
+	}
+}
+
+func TestStateOf(t *testing.T) {
+	tests := map[string]string{
+		"1 Market St, San Francisco, CA 94103": "CA",
+		"Austin TX 73301-0001":                 "TX",
+		"Hauptstr. 1, 10115 Berlin":            "",
+		"ZZ 12345":                             "",
+		"PO Box 12, Portland, OR 97201, USA":   "OR",
+		"Suite 2, DE 19901\nDover":             "DE",
+	}
+	for address, want := range tests {
+		if got := StateOf(address); got != want {
+			t.Errorf("StateOf(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
+
+func TestReturn(t *testing.T) {
+	var r Return
+	vat := lines(1000)
+	vat[0].TaxRate = 0.19
+	r.Add(models.Invoice{Amount: 1190, NetAmount: 1000, TaxAmount: 190, TaxType: TypeVAT}, models.Client{}, vat)
+
+	mixed := lines(100, 50)
+	mixed[0].TaxRate = 0.19
+	mixed[1].TaxRate = 0.07
+	r.Add(models.Invoice{Amount: 172.5, NetAmount: 150, TaxAmount: 22.5, TaxType: TypeVAT}, models.Client{}, mixed)
+
+	acme := models.Client{Name: "Acme BV", TaxID: "NL123"}
+	r.Add(models.Invoice{Amount: 500, NetAmount: 500, TaxType: TypeReverseCharge}, acme, lines(500))
+	r.Add(models.Invoice{Amount: 300, NetAmount: 300, TaxType: TypeReverseCharge}, acme, lines(300))
+	r.Add(models.Invoice{Amount: 80, NetAmount: 80, TaxType: TypeExempt}, models.Client{}, nil)
+
+	// Created without tax, e.g. through the API
+	r.Add(models.Invoice{Amount: 40}, models.Client{}, nil)
+
+	// Sales tax at the same rate as VAT is reported on its own
+	sales := lines(100)
+	sales[0].TaxRate = 0.07
+	r.Add(models.Invoice{Amount: 107, NetAmount: 100, TaxAmount: 7, TaxType: TypeSalesTax}, models.Client{}, sales)
+
+	if r.Invoices != 7 || r.Net != 2170 || r.Tax != 212.5 || r.SalesTax != 7 {
+		t.Errorf("got %d invoices, net %.2f, tax %.2f, sales tax %.2f", r.Invoices, r.Net, r.Tax, r.SalesTax)
+	}
+	want := []TaxedSales{
+		{Type: TypeVAT, Rate: Rate{Rate: 0.19, Net: 1100, Tax: 209}},
+		{Type: TypeVAT, Rate: Rate{Rate: 0.07, Net: 50, Tax: 3.5}},
+		{Type: TypeSalesTax, Rate: Rate{Rate: 0.07, Net: 100, Tax: 7}},
+	}
+	if !slices.Equal(r.Rates, want) {
+		t.Errorf("expected rates %+v, got %+v", want, r.Rates)
+	}
+	if len(r.ReverseCharge) != 1 || r.ReverseCharge[0].Net != 800 || r.ReverseChargeNet() != 800 {
+		t.Errorf("unexpected reverse charge %+v", r.ReverseCharge)
+	}
+	if r.Exempt != 80 || r.NotTaxed != 40 {
+		t.Errorf("got exempt %.2f, not taxed %.2f", r.Exempt, r.NotTaxed)
+	}
+}
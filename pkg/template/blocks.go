@@ -0,0 +1,383 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Andriiklymiuk/ung/pkg/payqr"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// renderText renders the "text" option, with {name} placeholders filled in
+func (r *Renderer) renderText(block TemplateBlock) error {
+	text := Interpolate(stringOption(block, "text", ""), r.doc.vars)
+	if text == "" {
+		return nil
+	}
+
+	width := r.blockWidth(block, r.contentWidth())
+	size := r.fontSize(block, r.template.Fonts.SizeBody)
+	lineWidth := r.pdf.GetLineWidth()
+	border, fill := r.applyStyle(block)
+	r.pdf.SetFont(r.font, block.Style.FontStyle, size)
+	r.pdf.SetXY(r.blockX(block, width), r.blockY(block))
+	r.pdf.MultiCell(width, size*0.45, text, border, alignment(block), fill)
+	r.pdf.SetLineWidth(lineWidth)
+
+	r.advance(block, r.pdf.GetY()+2)
+	return nil
+}
+
+// renderFields renders custom label and value pairs from the "fields"
+// option: a list of {"label": ..., "value": ...} objects, or an object of
+// labels to values, drawn in label order. Values can have {name}
+// placeholders; fields with an empty value are left out.
+func (r *Renderer) renderFields(block TemplateBlock) error {
+	var pairs [][2]string
+	switch fields := block.Options["fields"].(type) {
+	case []interface{}:
+		for _, f := range fields {
+			field, ok := f.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("fields must be objects with a label and a value")
+			}
+			label, _ := field["label"].(string)
+			value := fmt.Sprint(field["value"])
+			if field["value"] == nil {
+				value = ""
+			}
+			pairs = append(pairs, [2]string{label, value})
+		}
+	case map[string]interface{}:
+		for label, value := range fields {
+			pairs = append(pairs, [2]string{label, fmt.Sprint(value)})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("fields must be a list or an object")
+	}
+
+	var filled [][2]string
+	for _, p := range pairs {
+		if value := Interpolate(p[1], r.doc.vars); value != "" {
+			filled = append(filled, [2]string{Interpolate(p[0], r.doc.vars), value})
+		}
+	}
+
+	width := r.blockWidth(block, r.contentWidth()/2)
+	bottom := r.drawFields(block, r.blockX(block, width), r.blockY(block), width, filled)
+	r.advance(block, bottom+2)
+	return nil
+}
+
+// drawFields draws label and value pairs in two columns and returns the Y
+// below them. The "label_width" option sets the width of the labels.
+func (r *Renderer) drawFields(block TemplateBlock, x, y, width float64, pairs [][2]string) float64 {
+	size := r.fontSize(block, r.template.Fonts.SizeSmall)
+	lineHeight := size * 0.45
+	labelWidth := floatOption(block, "label_width", width*0.35)
+
+	for _, p := range pairs {
+		r.pdf.SetFont(r.font, "B", size)
+		r.setTextColor(r.template.Colors.Secondary)
+		r.pdf.SetXY(x, y)
+		r.pdf.CellFormat(labelWidth, lineHeight, p[0], "", 0, "L", false, 0, "")
+
+		r.pdf.SetFont(r.font, "", size)
+		r.setTextColor(r.textColor(block))
+		r.pdf.SetXY(x+labelWidth, y)
+		r.pdf.MultiCell(width-labelWidth, lineHeight, p[1], "", "L", false)
+		y = r.pdf.GetY() + 1
+	}
+	return y
+}
+
+// renderImage renders the image at the "path" option, e.g. a stamp or a
+// product photo. Position.Width sets its width, 40 mm by default; the
+// height follows from the image unless Position.Height is set.
+func (r *Renderer) renderImage(block TemplateBlock) error {
+	path := Interpolate(stringOption(block, "path", ""), r.doc.vars)
+	if path == "" {
+		return fmt.Errorf("image blocks need a path option")
+	}
+
+	width := r.blockWidth(block, 40)
+	height, err := r.drawImage(expandPath(path), r.blockX(block, width), r.blockY(block), width, block.Position.Height)
+	if err != nil {
+		return err
+	}
+	r.advance(block, r.blockY(block)+height+3)
+	return nil
+}
+
+// drawImage draws an image file and returns its height. A height of 0
+// keeps the aspect ratio of the image.
+func (r *Renderer) drawImage(path string, x, y, width, height float64) (float64, error) {
+	options := gofpdf.ImageOptions{ReadDpi: true}
+	info := r.pdf.RegisterImageOptions(path, options)
+	if info == nil || r.pdf.Err() {
+		return 0, fmt.Errorf("failed to load image %s: %w", path, r.pdf.Error())
+	}
+	if height == 0 {
+		height = width * info.Height() / info.Width()
+	}
+	r.pdf.ImageOptions(path, x, y, width, height, false, options, 0, "")
+	return height, nil
+}
+
+// renderSignature renders signature lines for the "parties" option,
+// "company" and/or "client" side by side, company by default. The "image"
+// option is a scanned signature drawn above the company's line, "label" the
+// caption under the names.
+func (r *Renderer) renderSignature(block TemplateBlock) error {
+	parties := listOption(block, "parties", []string{"company"})
+	label := stringOption(block, "label", r.loc.Contract.Signature)
+	contentWidth := r.contentWidth()
+	lineWidth := r.blockWidth(block, contentWidth/2-10)
+
+	y := r.blockY(block)
+	image := Interpolate(stringOption(block, "image", ""), r.doc.vars)
+	signatureHeight := 0.0
+	if image != "" {
+		signatureHeight = block.Position.Height
+		if signatureHeight == 0 {
+			signatureHeight = 15
+		}
+	}
+	lineY := y + signatureHeight
+
+	for i, party := range parties {
+		x := r.template.Margins.Left + float64(i)*(contentWidth/2+10)
+		if len(parties) == 1 {
+			x = r.blockX(block, lineWidth)
+		}
+
+		var name string
+		switch party {
+		case "company":
+			name = r.doc.company.Name
+			if image != "" {
+				if _, err := r.drawImage(expandPath(image), x, y, 0, signatureHeight); err != nil {
+					return err
+				}
+			}
+		case "client":
+			name = r.doc.client.Name
+		default:
+			return fmt.Errorf("signature party %q must be company or client", party)
+		}
+
+		r.pdf.SetDrawColor(100, 100, 100)
+		r.pdf.Line(x, lineY, x+lineWidth, lineY)
+		r.pdf.SetFont(r.font, "", 9)
+		r.setTextColor(r.template.Colors.Text)
+		r.pdf.SetXY(x, lineY+2)
+		r.pdf.Cell(lineWidth, 5, name)
+		r.pdf.SetFont(r.font, "I", 8)
+		r.setTextColor(r.template.Colors.Muted)
+		r.pdf.SetXY(x, lineY+6)
+		r.pdf.Cell(lineWidth, 5, label)
+	}
+
+	r.advance(block, lineY+13)
+	return nil
+}
+
+// renderPaymentDetails renders the company's bank details and, on
+// invoices, the reference, due date and amount to pay. It's left out when
+// the company has no bank account.
+func (r *Renderer) renderPaymentDetails(block TemplateBlock) error {
+	company := r.doc.company
+	if company.BankAccount == "" {
+		return nil
+	}
+
+	var pairs [][2]string
+	if company.BankName != "" {
+		pairs = append(pairs, [2]string{r.loc.Text.Bank, company.BankName})
+	}
+	pairs = append(pairs, [2]string{r.loc.Text.Account, company.BankAccount})
+	if company.BankSWIFT != "" {
+		pairs = append(pairs, [2]string{"SWIFT", company.BankSWIFT})
+	}
+	if _, ok := r.data.(InvoiceData); ok {
+		pairs = append(pairs,
+			[2]string{r.loc.Text.InvoiceNumber, r.doc.vars["invoice.number"]},
+			[2]string{r.loc.Text.DueDate, r.doc.vars["invoice.due_date"]},
+			[2]string{r.cfg.PDF.BalanceDueLabel, r.doc.vars["total_money"]},
+		)
+	}
+
+	width := r.blockWidth(block, r.contentWidth()/2)
+	x := r.blockX(block, width)
+	y := r.blockY(block)
+	if title := stringOption(block, "title", r.loc.Contract.PaymentInfo); title != "" {
+		r.pdf.SetFont(r.font, "B", 10)
+		r.setTextColor(r.template.Colors.Primary)
+		r.pdf.SetXY(x, y)
+		r.pdf.Cell(width, 5, title)
+		y += 7
+	}
+	bottom := r.drawFields(block, x, y, width, pairs)
+	r.advance(block, bottom+3)
+	return nil
+}
+
+// renderQRCode renders a QR code of the "content" option, e.g. a link to
+// the invoice online, with {name} placeholders filled in. Position.Width
+// sets its size, 30 mm by default; "label" is a caption above it.
+func (r *Renderer) renderQRCode(block TemplateBlock) error {
+	content := Interpolate(stringOption(block, "content", ""), r.doc.vars)
+	if content == "" {
+		return fmt.Errorf("qr_code blocks need a content option")
+	}
+
+	size := r.blockWidth(block, 30)
+	x := r.blockX(block, size)
+	y := r.blockY(block)
+	if label := Interpolate(stringOption(block, "label", ""), r.doc.vars); label != "" {
+		r.pdf.SetFont(r.font, "B", 9)
+		r.setTextColor(r.template.Colors.Primary)
+		r.pdf.SetXY(x, y)
+		r.pdf.Cell(size, 5, label)
+		y += 6
+	}
+	if err := payqr.Draw(r.pdf, "", content, x, y, size); err != nil {
+		return err
+	}
+	r.advance(block, y+size+5)
+	return nil
+}
+
+// pageWidth returns the width of the page in mm
+func (r *Renderer) pageWidth() float64 {
+	width, _ := r.pdf.GetPageSize()
+	return width
+}
+
+// contentWidth returns the width between the margins
+func (r *Renderer) contentWidth() float64 {
+	return r.pageWidth() - r.template.Margins.Left - r.template.Margins.Right
+}
+
+// blockWidth returns the width of a block, or def when it has none
+func (r *Renderer) blockWidth(block TemplateBlock, def float64) float64 {
+	if block.Position.Width > 0 {
+		return block.Position.Width
+	}
+	return def
+}
+
+// blockX returns the left edge of a block of width: X from the left
+// margin, otherwise aligned left, center or right
+func (r *Renderer) blockX(block TemplateBlock, width float64) float64 {
+	if block.Position.X != 0 {
+		return r.template.Margins.Left + block.Position.X
+	}
+	switch block.Position.Align {
+	case "right":
+		return r.pageWidth() - r.template.Margins.Right - width
+	case "center":
+		return (r.pageWidth() - width) / 2
+	}
+	return r.template.Margins.Left
+}
+
+// blockY returns the top of a block: its fixed Y, or below the previous
+// block
+func (r *Renderer) blockY(block TemplateBlock) float64 {
+	if block.Position.Y > 0 {
+		return block.Position.Y
+	}
+	return r.currentY
+}
+
+// advance moves the following blocks below y. Blocks placed at a fixed Y
+// don't push them down.
+func (r *Renderer) advance(block TemplateBlock, y float64) {
+	if block.Position.Y == 0 {
+		r.currentY = y
+	}
+}
+
+// fontSize returns the font size of a block, or def when it has none
+func (r *Renderer) fontSize(block TemplateBlock, def float64) float64 {
+	if block.Style.FontSize > 0 {
+		return block.Style.FontSize
+	}
+	if def > 0 {
+		return def
+	}
+	return 10
+}
+
+// textColor returns the text color of a block, or the template's
+func (r *Renderer) textColor(block TemplateBlock) HexColor {
+	if block.Style.TextColor != "" {
+		return block.Style.TextColor
+	}
+	return r.template.Colors.Text
+}
+
+// applyStyle sets the colors of a block and returns the border and fill
+// arguments of its cells
+func (r *Renderer) applyStyle(block TemplateBlock) (string, bool) {
+	r.setTextColor(r.textColor(block))
+	border := ""
+	if block.Style.BorderWidth > 0 {
+		red, green, blue := block.Style.BorderColor.ToRGB()
+		r.pdf.SetDrawColor(red, green, blue)
+		r.pdf.SetLineWidth(block.Style.BorderWidth)
+		border = "1"
+	}
+	fill := block.Style.BackgroundColor != ""
+	if fill {
+		red, green, blue := block.Style.BackgroundColor.ToRGB()
+		r.pdf.SetFillColor(red, green, blue)
+	}
+	return border, fill
+}
+
+// alignment returns the gofpdf alignment of a block's Position.Align
+func alignment(block TemplateBlock) string {
+	switch block.Position.Align {
+	case "right":
+		return "R"
+	case "center":
+		return "C"
+	}
+	return "L"
+}
+
+// floatOption returns a block's number option, or def when it's not set
+func floatOption(block TemplateBlock, name string, def float64) float64 {
+	if v, ok := block.Options[name].(float64); ok && v > 0 {
+		return v
+	}
+	return def
+}
+
+// boolOption returns a block's true or false option, or def when it's not
+// set
+func boolOption(block TemplateBlock, name string, def bool) bool {
+	if v, ok := block.Options[name].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// listOption returns a block's list of strings option, or def when it's
+// not set
+func listOption(block TemplateBlock, name string, def []string) []string {
+	values, ok := block.Options[name].([]interface{})
+	if !ok || len(values) == 0 {
+		return def
+	}
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		list = append(list, fmt.Sprint(v))
+	}
+	return list
+}
@@ -0,0 +1,122 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// comparisons are the operators of a condition, longest first so ">=" isn't
+// read as ">"
+var comparisons = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// Eval evaluates a show_if condition against a document's variables, e.g.
+// "status==paid", "currency!=EUR && total>1000" or "client.tax_id". A bare
+// variable is true when it's set and not "false" or "0", "!" negates it.
+// "&&" binds tighter than "||". Values compare as numbers when both sides
+// are numbers, otherwise as text ignoring case.
+func Eval(condition string, vars map[string]string) (bool, error) {
+	for _, or := range strings.Split(condition, "||") {
+		all := true
+		for _, and := range strings.Split(or, "&&") {
+			ok, err := evalComparison(strings.TrimSpace(and), vars)
+			if err != nil {
+				return false, fmt.Errorf("show_if %q: %w", condition, err)
+			}
+			if !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func evalComparison(expr string, vars map[string]string) (bool, error) {
+	if expr == "" {
+		return false, fmt.Errorf("empty comparison")
+	}
+	for _, op := range comparisons {
+		i := strings.Index(expr, op)
+		if i < 0 {
+			continue
+		}
+		name := strings.TrimSpace(expr[:i])
+		want := strings.Trim(strings.TrimSpace(expr[i+len(op):]), `"'`)
+		got, ok := vars[name]
+		if !ok {
+			return false, fmt.Errorf("unknown variable %q", name)
+		}
+		return compare(got, op, want)
+	}
+
+	negate := strings.HasPrefix(expr, "!")
+	name := strings.TrimSpace(strings.TrimPrefix(expr, "!"))
+	value, ok := vars[name]
+	if !ok {
+		return false, fmt.Errorf("unknown variable %q", name)
+	}
+	set := value != "" && value != "false" && value != "0"
+	return set != negate, nil
+}
+
+func compare(got, op, want string) (bool, error) {
+	a, errA := strconv.ParseFloat(got, 64)
+	b, errB := strconv.ParseFloat(want, 64)
+	if errA == nil && errB == nil {
+		switch op {
+		case "==":
+			return a == b, nil
+		case "!=":
+			return a != b, nil
+		case ">=":
+			return a >= b, nil
+		case "<=":
+			return a <= b, nil
+		case ">":
+			return a > b, nil
+		default:
+			return a < b, nil
+		}
+	}
+
+	switch op {
+	case "==":
+		return strings.EqualFold(got, want), nil
+	case "!=":
+		return !strings.EqualFold(got, want), nil
+	}
+	return false, fmt.Errorf("%q needs numbers, got %q and %q", op, got, want)
+}
+
+// Interpolate replaces the {name} placeholders of text with variables.
+// Unknown placeholders are kept as they are.
+func Interpolate(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(text, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(text[start:], "}")
+		if end < 0 {
+			break
+		}
+		name := text[start+1 : start+end]
+		b.WriteString(text[:start])
+		if value, ok := vars[name]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(text[start : start+end+1])
+		}
+		text = text[start+end+1:]
+	}
+	b.WriteString(text)
+	return b.String()
+}
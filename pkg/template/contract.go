@@ -0,0 +1,173 @@
+package template
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+// GetDefaultContractTemplate returns the default contract template
+// definition
+func GetDefaultContractTemplate() *TemplateDefinition {
+	tmpl := GetDefaultInvoiceTemplate()
+	tmpl.Description = "Default professional contract template"
+	tmpl.Type = "contract"
+	tmpl.Blocks = []TemplateBlock{
+		{
+			Type:     "header",
+			Position: BlockPosition{Align: "left"},
+			Style:    BlockStyle{FontSize: 28, FontStyle: "B"},
+		},
+		{
+			Type:     "text",
+			Position: BlockPosition{Align: "left"},
+			Style:    BlockStyle{FontSize: 10},
+			Options:  map[string]interface{}{"text": "{contract.name} ({contract.number})"},
+		},
+		{
+			Type:     "spacer",
+			Position: BlockPosition{Height: 5},
+		},
+		{
+			Type:     "company_info",
+			Position: BlockPosition{Width: 50, Align: "left"},
+			Style:    BlockStyle{FontSize: 9},
+		},
+		{
+			Type:     "spacer",
+			Position: BlockPosition{Height: 10},
+		},
+		{
+			Type:     "client_info",
+			Position: BlockPosition{Width: 50, Align: "left"},
+			Style:    BlockStyle{FontSize: 9},
+		},
+		{
+			Type:     "spacer",
+			Position: BlockPosition{Height: 10},
+		},
+		{
+			Type:  "contract_terms",
+			Style: BlockStyle{FontSize: 10},
+		},
+		{
+			Type:     "notes",
+			Position: BlockPosition{Width: 100},
+			Style:    BlockStyle{FontSize: 9},
+		},
+		{
+			Type: "payment_details",
+		},
+		{
+			Type:     "terms",
+			Position: BlockPosition{Width: 100},
+			Style:    BlockStyle{FontSize: 9},
+		},
+		{
+			Type:     "spacer",
+			Position: BlockPosition{Height: 15},
+		},
+		{
+			Type:    "signature",
+			Options: map[string]interface{}{"parties": []interface{}{"company", "client"}},
+		},
+	}
+	tmpl.Pages.FollowBlocks = followHeader("{company.name} | {contract.number}")
+	return tmpl
+}
+
+// RenderContract renders a contract using a template
+func (r *Renderer) RenderContract(data ContractData, outputPath string) error {
+	r.data = data
+	r.loc, r.cfg = locale.For(data.Config, data.Client.Language)
+	r.doc = document{
+		company:    data.Company,
+		client:     data.Client,
+		address:    data.Client.AddressFor(models.AddressLegal),
+		contact:    contactName(data.Client, models.ContactLegal),
+		currency:   data.Contract.Currency,
+		title:      r.loc.Contract.Title,
+		notes:      data.Contract.Notes,
+		notesLabel: r.loc.Contract.AdditionalTerms,
+		termsLabel: r.loc.Contract.GeneralConditions,
+		vars:       ContractVars(data, r.loc),
+	}
+	return r.render(outputPath, data.Contract, r.loc.Contract, r.cfg.Invoice.Terms)
+}
+
+// renderContractTerms renders the rate and period of a contract as a
+// numbered list
+func (r *Renderer) renderContractTerms(block TemplateBlock, data ContractData) error {
+	c := data.Contract
+	text := r.loc.Contract
+
+	var terms []string
+	if c.HourlyRate != nil {
+		terms = append(terms, locale.Fill(text.HourlyRate, "{rate}", r.loc.Money(*c.HourlyRate, c.Currency)))
+	}
+	if c.FixedPrice != nil {
+		terms = append(terms, locale.Fill(text.FixedPrice, "{price}", r.loc.Money(*c.FixedPrice, c.Currency)))
+	}
+	if c.EndDate != nil {
+		terms = append(terms, locale.Fill(text.Period, "{start}", r.loc.LongDate(c.StartDate), "{end}", r.loc.LongDate(*c.EndDate)))
+	} else {
+		terms = append(terms, locale.Fill(text.OpenEnded, "{start}", r.loc.LongDate(c.StartDate)))
+	}
+
+	width := r.blockWidth(block, r.contentWidth())
+	x := r.blockX(block, width)
+	y := r.blockY(block)
+	size := r.fontSize(block, r.template.Fonts.SizeBody)
+
+	r.pdf.SetFont(r.font, "B", 10)
+	r.setTextColor(r.template.Colors.Primary)
+	r.pdf.SetXY(x, y)
+	r.pdf.Cell(width, 5, text.Terms)
+
+	r.pdf.SetFont(r.font, "", size)
+	r.setTextColor(r.textColor(block))
+	r.pdf.SetXY(x, y+7)
+	for i, term := range terms {
+		r.pdf.SetX(x)
+		r.pdf.MultiCell(width, size*0.5, fmt.Sprintf("%d. %s", i+1, term), "", "L", false)
+		r.pdf.Ln(1)
+	}
+
+	r.advance(block, r.pdf.GetY()+5)
+	return nil
+}
+
+// GetSampleContractData returns sample data for contract template preview
+func GetSampleContractData(cfg *config.Config) ContractData {
+	invoice := GetSampleInvoiceData(cfg)
+	rate := 100.0
+	return ContractData{
+		Contract: models.Contract{
+			ContractNum:  "contract.client.jan.2024",
+			Name:         "Web Development",
+			ContractType: models.ContractTypeHourly,
+			HourlyRate:   &rate,
+			Currency:     "USD",
+			StartDate:    time.Now(),
+			Active:       true,
+			Notes:        "Sample contract for template preview",
+		},
+		Company: invoice.Company,
+		Client:  invoice.Client,
+		Config:  cfg,
+	}
+}
+
+// RenderContractWithTemplate renders a contract using a custom template
+func RenderContractWithTemplate(templatePath string, data ContractData, outputPath string) error {
+	tmpl, err := LoadTemplate(templatePath)
+	if err != nil {
+		return err
+	}
+
+	renderer := NewRenderer(tmpl)
+	return renderer.RenderContract(data, outputPath)
+}
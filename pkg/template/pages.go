@@ -0,0 +1,215 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// tableRowHeight is the height of a line items row in mm
+const tableRowHeight = 8.0
+
+// footerHeight is the least room kept below the content for the footer
+const footerHeight = 12.0
+
+// lineItemsTable is a line items table being drawn: its header repeats on
+// follow pages and its subtotal is carried over to them
+type lineItemsTable struct {
+	block    TemplateBlock
+	currency string
+	widths   [4]float64 // Item, quantity, rate and amount columns
+	subtotal float64    // Amount of the rows drawn so far
+}
+
+// startPage lays out the top of a follow page: the follow blocks and the
+// table being drawn. gofpdf calls it for every new page, also when text
+// flows over the bottom of the last one.
+func (r *Renderer) startPage() {
+	if r.pdf.PageNo() == 1 {
+		return
+	}
+
+	r.currentY = r.followTop()
+	for _, block := range r.template.Pages.FollowBlocks {
+		switch block.Type {
+		case "page_break", "line_items":
+			r.pdf.SetError(fmt.Errorf("%s blocks can't be on follow pages", block.Type))
+			return
+		}
+		if err := r.renderBlock(block); err != nil {
+			r.pdf.SetError(fmt.Errorf("failed to render follow page block %s: %w", block.Type, err))
+			return
+		}
+	}
+
+	if t := r.table; t != nil {
+		if boolOption(t.block, "repeat_header", true) {
+			r.drawTableHeader()
+		}
+		if boolOption(t.block, "carry_forward", true) {
+			r.drawSubtotalRow("", r.loc.Text.BroughtForward)
+		}
+	}
+	r.pdf.SetY(r.currentY)
+}
+
+// followTop returns the top margin of follow pages
+func (r *Renderer) followTop() float64 {
+	if top := r.template.Pages.FollowMarginTop; top > 0 {
+		return top
+	}
+	return r.template.Margins.Top
+}
+
+// footer returns the footer of the pages, "" when there is none
+func (r *Renderer) footer() string {
+	switch footer := r.template.Pages.Footer; footer {
+	case "none":
+		return ""
+	case "":
+		return r.loc.Text.Page + " {page}/{pages}"
+	default:
+		return footer
+	}
+}
+
+// renderFooter draws the footer centered in the bottom margin of a page
+func (r *Renderer) renderFooter() {
+	footer := r.footer()
+	if footer == "" {
+		return
+	}
+
+	vars := make(map[string]string, len(r.doc.vars)+2)
+	for name, value := range r.doc.vars {
+		vars[name] = value
+	}
+	vars["page"] = strconv.Itoa(r.pdf.PageNo())
+	vars["pages"] = "{nb}" // Replaced with the page count by gofpdf
+
+	_, height := r.pdf.GetPageSize()
+	bottom := r.bottomMargin()
+	r.pdf.SetFont(r.font, "", r.fontSize(TemplateBlock{}, r.template.Fonts.SizeSmall))
+	r.setTextColor(r.template.Colors.Muted)
+	r.pdf.SetXY(r.template.Margins.Left, height-bottom+(bottom-5)/2)
+	r.pdf.CellFormat(r.contentWidth(), 5, Interpolate(footer, vars), "", 0, "C", false, 0, "")
+}
+
+// bottomMargin returns the room below the content: the bottom margin, and
+// at least enough for the footer
+func (r *Renderer) bottomMargin() float64 {
+	if r.footer() != "" && r.template.Margins.Bottom < footerHeight {
+		return footerHeight
+	}
+	return r.template.Margins.Bottom
+}
+
+// pageBottom returns the lowest Y content can reach
+func (r *Renderer) pageBottom() float64 {
+	_, height := r.pdf.GetPageSize()
+	return height - r.bottomMargin()
+}
+
+// drawTableHeader draws the header row of the line items table
+func (r *Renderer) drawTableHeader() {
+	t := r.table
+	labels := r.cfg.Invoice
+	pr, pg, pb := r.template.Colors.Primary.ToRGB()
+	r.pdf.SetFillColor(pr, pg, pb)
+	r.pdf.SetTextColor(255, 255, 255)
+	r.pdf.SetFont(r.font, "B", 9)
+	r.pdf.SetXY(r.template.Margins.Left, r.currentY)
+
+	r.pdf.CellFormat(t.widths[0], tableRowHeight, labels.ItemLabel, "", 0, "L", true, 0, "")
+	r.pdf.CellFormat(t.widths[1], tableRowHeight, labels.QuantityLabel, "", 0, "C", true, 0, "")
+	r.pdf.CellFormat(t.widths[2], tableRowHeight, labels.RateLabel, "", 0, "R", true, 0, "")
+	r.pdf.CellFormat(t.widths[3], tableRowHeight, labels.AmountLabel, "", 0, "R", true, 0, "")
+	r.currentY += tableRowHeight
+}
+
+// drawCarriedForward ends a page the table goes on from with a
+// "continued" note and the subtotal so far
+func (r *Renderer) drawCarriedForward() {
+	label := ""
+	if boolOption(r.table.block, "carry_forward", true) {
+		label = r.loc.Text.CarriedForward
+	}
+	r.drawSubtotalRow(r.loc.Text.Continued, label)
+}
+
+// drawSubtotalRow draws a table row with a note under the item and
+// quantity columns and the subtotal with its label under the others
+func (r *Renderer) drawSubtotalRow(note, label string) {
+	t := r.table
+	r.pdf.SetXY(r.template.Margins.Left, r.currentY)
+	r.pdf.SetFont(r.font, "I", 8)
+	r.setTextColor(r.template.Colors.Muted)
+	r.pdf.CellFormat(t.widths[0]+t.widths[1], tableRowHeight, note, "", 0, "L", false, 0, "")
+	if label != "" {
+		r.pdf.SetFont(r.font, "B", 9)
+		r.setTextColor(r.template.Colors.Text)
+		r.pdf.CellFormat(t.widths[2], tableRowHeight, label, "", 0, "R", false, 0, "")
+		r.pdf.CellFormat(t.widths[3], tableRowHeight, r.loc.Money(t.subtotal, t.currency), "", 0, "R", false, 0, "")
+	}
+	r.currentY += tableRowHeight
+}
+
+// keepsTogether reports whether a block moves to the next page as a whole
+func keepsTogether(block TemplateBlock) bool {
+	return block.KeepTogether || block.Type == "totals" || block.Type == "terms"
+}
+
+// keepTogether starts a new page when a block that keeps together doesn't
+// fit on the rest of this one, unless it's taller than a page anyway
+func (r *Renderer) keepTogether(block TemplateBlock) {
+	if !keepsTogether(block) || block.Position.Y > 0 {
+		return
+	}
+	if show, err := r.shows(block); err != nil || !show {
+		return
+	}
+
+	height := r.blockHeight(block)
+	if height == 0 || r.currentY+height <= r.pageBottom() || height > r.pageBottom()-r.followTop() {
+		return
+	}
+	r.pdf.AddPage()
+}
+
+// blockHeight estimates the height of a block from its text, or returns
+// Position.Height for blocks the renderer can't measure
+func (r *Renderer) blockHeight(block TemplateBlock) float64 {
+	width := r.contentWidth()
+	switch block.Type {
+	case "totals":
+		return 18
+	case "terms":
+		return 6 + r.textHeight(r.cfg.Invoice.Terms, "", 9, width, 4)
+	case "notes":
+		if r.doc.notes == "" {
+			return 0
+		}
+		return 11 + r.textHeight(r.doc.notes, "", 9, width, 4)
+	case "text":
+		size := r.fontSize(block, r.template.Fonts.SizeBody)
+		text := Interpolate(stringOption(block, "text", ""), r.doc.vars)
+		return 2 + r.textHeight(text, block.Style.FontStyle, size, r.blockWidth(block, width), size*0.45)
+	case "signature":
+		if stringOption(block, "image", "") == "" {
+			return 13
+		}
+		if block.Position.Height > 0 {
+			return block.Position.Height + 13
+		}
+		return 28
+	}
+	return block.Position.Height
+}
+
+// textHeight returns the height of text wrapped to width
+func (r *Renderer) textHeight(text, style string, size, width, lineHeight float64) float64 {
+	if text == "" {
+		return 0
+	}
+	r.pdf.SetFont(r.font, style, size)
+	return float64(len(r.pdf.SplitText(text, width))) * lineHeight
+}
@@ -0,0 +1,116 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+)
+
+// longInvoice returns sample data with one line item per session, as
+// hourly invoices have them
+func longInvoice(items int) InvoiceData {
+	data := GetSampleInvoiceData(testConfig())
+	data.LineItems = nil
+	for i := 0; i < items; i++ {
+		data.LineItems = append(data.LineItems, models.InvoiceLineItem{
+			ItemName: fmt.Sprintf("Session %d", i+1), Quantity: 2, Rate: 50, Amount: 100,
+		})
+	}
+	return data
+}
+
+func TestRenderInvoiceOverPages(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "invoice.pdf")
+
+	r := NewRenderer(GetDefaultInvoiceTemplate())
+	if err := r.RenderInvoice(longInvoice(3), out); err != nil {
+		t.Fatal(err)
+	}
+	if pages := r.pdf.PageNo(); pages != 1 {
+		t.Errorf("expected a short invoice on one page, got %d", pages)
+	}
+
+	r = NewRenderer(GetDefaultInvoiceTemplate())
+	if err := r.RenderInvoice(longInvoice(80), out); err != nil {
+		t.Fatal(err)
+	}
+	if pages := r.pdf.PageNo(); pages < 3 {
+		t.Errorf("expected 80 items to take at least 3 pages, got %d", pages)
+	}
+	if r.table != nil {
+		t.Error("expected the table to be done")
+	}
+
+	// Tables can't repeat on follow pages
+	tmpl := GetDefaultInvoiceTemplate()
+	tmpl.Pages.FollowBlocks = []TemplateBlock{{Type: "line_items"}}
+	if err := NewRenderer(tmpl).RenderInvoice(longInvoice(80), out); err == nil {
+		t.Error("expected an error for line_items on follow pages")
+	}
+	if err := NewRenderer(tmpl).RenderInvoice(longInvoice(3), out); err != nil {
+		t.Errorf("expected no follow pages for 3 items, got %v", err)
+	}
+}
+
+func TestKeepTogether(t *testing.T) {
+	r := NewRenderer(GetDefaultInvoiceTemplate())
+	data := GetSampleInvoiceData(testConfig())
+	r.data = data
+	r.loc, r.cfg = locale.For(data.Config, "")
+	r.doc = document{company: data.Company, client: data.Client, currency: "USD", vars: InvoiceVars(data, r.loc)}
+	if err := r.setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.currentY = 100
+	r.keepTogether(TemplateBlock{Type: "totals"})
+	if r.pdf.PageNo() != 1 {
+		t.Error("expected the totals to fit on the first page")
+	}
+
+	// Text that fits may run up to the footer
+	r.currentY = r.pageBottom() - 5
+	r.keepTogether(TemplateBlock{Type: "spacer", Position: BlockPosition{Height: 20}})
+	if r.pdf.PageNo() != 1 {
+		t.Error("expected a block that doesn't keep together to stay")
+	}
+
+	r.keepTogether(TemplateBlock{Type: "totals", ShowIf: "status==paid"})
+	if r.pdf.PageNo() != 1 {
+		t.Error("expected a hidden block not to start a page")
+	}
+
+	r.keepTogether(TemplateBlock{Type: "totals"})
+	if r.pdf.PageNo() != 2 {
+		t.Fatal("expected the totals to move to the next page")
+	}
+	if r.currentY <= r.followTop() || r.currentY > 40 {
+		t.Errorf("expected the totals below the follow page header, at %.1f", r.currentY)
+	}
+
+	r.currentY = r.pageBottom() - 5
+	r.keepTogether(TemplateBlock{Type: "image", KeepTogether: true, Position: BlockPosition{Height: 30}})
+	if r.pdf.PageNo() != 3 {
+		t.Error("expected a block with keep_together to move to the next page")
+	}
+}
+
+func TestFooter(t *testing.T) {
+	r := NewRenderer(GetDefaultInvoiceTemplate())
+	r.loc = locale.Get("de")
+	if got := r.footer(); got != "Seite {page}/{pages}" {
+		t.Errorf("unexpected default footer %q", got)
+	}
+
+	r.template.Margins.Bottom = 5
+	if got := r.bottomMargin(); got != footerHeight {
+		t.Errorf("expected room for the footer, got %.1f", got)
+	}
+	r.template.Pages.Footer = "none"
+	if got := r.bottomMargin(); got != 5 {
+		t.Errorf("expected the bottom margin without a footer, got %.1f", got)
+	}
+}
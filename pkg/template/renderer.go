@@ -9,7 +9,11 @@ import (
 	"time"
 
 	"github.com/Andriiklymiuk/ung/internal/config"
-	"github.com/Andriiklymiuk/ung/internal/models"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/payqr"
+	"github.com/Andriiklymiuk/ung/pkg/pdffont"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
 	"github.com/jung-kurt/gofpdf"
 )
 
@@ -23,6 +27,17 @@ type TemplateDefinition struct {
 	Colors      ColorScheme       `json:"colors" yaml:"colors"`
 	Fonts       FontConfig        `json:"fonts" yaml:"fonts"`
 	Blocks      []TemplateBlock   `json:"blocks" yaml:"blocks"`
+	Pages       PageLayout        `json:"pages" yaml:"pages"`
+}
+
+// PageLayout defines how a document runs over several pages. The first
+// page has the template's blocks; when they don't fit, each follow page
+// starts with FollowBlocks, e.g. a compact header, and line item tables
+// repeat their header there.
+type PageLayout struct {
+	FollowBlocks    []TemplateBlock `json:"follow_blocks,omitempty" yaml:"follow_blocks,omitempty"`
+	FollowMarginTop float64         `json:"follow_margin_top,omitempty" yaml:"follow_margin_top,omitempty"` // Top margin of follow pages (0 = Margins.Top)
+	Footer          string          `json:"footer,omitempty" yaml:"footer,omitempty"`                       // Footer of every page with {page}, {pages} and document placeholders; "none" hides it
 }
 
 // Margins defines page margins in mm
@@ -58,18 +73,31 @@ func (c HexColor) ToRGB() (int, int, int) {
 
 // FontConfig defines fonts used in template
 type FontConfig struct {
-	Family    string  `json:"family" yaml:"family"` // "Arial", "Helvetica", "Times"
-	SizeTitle float64 `json:"size_title" yaml:"size_title"`
-	SizeBody  float64 `json:"size_body" yaml:"size_body"`
-	SizeSmall float64 `json:"size_small" yaml:"size_small"`
-}
-
-// TemplateBlock represents a visual block in the template
+	Family     string  `json:"family" yaml:"family"`                       // "DejaVu" (bundled), "Arial", "Helvetica", "Times", or the name for the files below; empty uses the pdf config font
+	Regular    string  `json:"regular,omitempty" yaml:"regular,omitempty"` // TTF file paths
+	Bold       string  `json:"bold,omitempty" yaml:"bold,omitempty"`
+	Italic     string  `json:"italic,omitempty" yaml:"italic,omitempty"`
+	BoldItalic string  `json:"bold_italic,omitempty" yaml:"bold_italic,omitempty"`
+	SizeTitle  float64 `json:"size_title" yaml:"size_title"`
+	SizeBody   float64 `json:"size_body" yaml:"size_body"`
+	SizeSmall  float64 `json:"size_small" yaml:"size_small"`
+}
+
+// TemplateBlock represents a visual block in the template. Invoices and
+// contracts share "header", "company_info", "client_info", "notes", "terms",
+// "text", "fields", "image", "signature", "payment_details", "qr_code",
+// "page_break" and "spacer"; "invoice_meta", "line_items", "totals" and
+// "payment_qr" are for invoices, "contract_terms" for contracts.
+//
+// A block that doesn't fit on the rest of a page starts on the next one
+// when KeepTogether is set; totals and terms always keep together.
 type TemplateBlock struct {
-	Type     string            `json:"type" yaml:"type"` // "header", "company_info", "client_info", "line_items", "totals", "notes", "terms", "spacer"
+	Type     string            `json:"type" yaml:"type"`
 	Position BlockPosition     `json:"position" yaml:"position"`
 	Style    BlockStyle        `json:"style" yaml:"style"`
 	Options  map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty"`
+	ShowIf   string            `json:"show_if,omitempty" yaml:"show_if,omitempty"` // Condition, e.g. "status==paid", see Eval
+	KeepTogether bool          `json:"keep_together,omitempty" yaml:"keep_together,omitempty"`
 }
 
 // BlockPosition defines where a block appears
@@ -114,8 +142,26 @@ type Renderer struct {
 	template *TemplateDefinition
 	pdf      *gofpdf.Fpdf
 	data     interface{}
+	doc      document
 	cfg      *config.Config
+	loc      *locale.Pack // Language of the document
+	font     string       // Family registered with pdf
 	currentY float64
+	table    *lineItemsTable // Table being drawn, continued on follow pages
+}
+
+// document is what the blocks shared by invoices and contracts draw
+type document struct {
+	company    models.Company
+	client     models.Client
+	address    string // The client's billing or legal address
+	contact    string // The client's billing or legal contact
+	currency   string
+	title      string            // Title of the header block
+	notes      string            // Invoice description or contract notes
+	notesLabel string
+	termsLabel string
+	vars       map[string]string // Variables of show_if and {name} placeholders
 }
 
 // NewRenderer creates a renderer with a template
@@ -179,7 +225,6 @@ func GetDefaultInvoiceTemplate() *TemplateDefinition {
 			Accent:    "#2C3E50",
 		},
 		Fonts: FontConfig{
-			Family:    "Arial",
 			SizeTitle: 28,
 			SizeBody:  10,
 			SizeSmall: 9,
@@ -251,32 +296,65 @@ func GetDefaultInvoiceTemplate() *TemplateDefinition {
 				Style: BlockStyle{FontSize: 9},
 			},
 		},
+		Pages: PageLayout{
+			FollowBlocks: followHeader("{company.name} | {invoice.number}"),
+		},
+	}
+}
+
+// followHeader returns the blocks of a compact header for follow pages
+func followHeader(text string) []TemplateBlock {
+	return []TemplateBlock{
+		{
+			Type: "text",
+			Position: BlockPosition{Align: "right"},
+			Style: BlockStyle{FontSize: 9, TextColor: "#808080"},
+			Options: map[string]interface{}{"text": text},
+		},
+		{
+			Type: "spacer",
+			Position: BlockPosition{Height: 5},
+		},
 	}
 }
 
 // RenderInvoice renders an invoice using a template
 func (r *Renderer) RenderInvoice(data InvoiceData, outputPath string) error {
 	r.data = data
-	r.cfg = data.Config
-
-	// Initialize PDF
-	pageSize := r.template.PageSize
-	if pageSize == "" {
-		pageSize = "A4"
+	r.loc, r.cfg = locale.For(data.Config, data.Client.Language)
+	r.doc = document{
+		company:    data.Company,
+		client:     data.Client,
+		address:    data.Client.AddressFor(models.AddressBilling),
+		contact:    contactName(data.Client, models.ContactBilling),
+		currency:   data.Invoice.Currency,
+		title:      r.cfg.Invoice.InvoiceLabel,
+		notes:      data.Invoice.Description,
+		notesLabel: r.cfg.Invoice.NotesLabel,
+		termsLabel: r.cfg.Invoice.TermsLabel,
+		vars:       InvoiceVars(data, r.loc),
 	}
-	r.pdf = gofpdf.New("P", "mm", pageSize, "")
-	r.pdf.AddPage()
+	return r.render(outputPath, data.Invoice, data.LineItems, r.cfg.Invoice, r.cfg.PDF)
+}
 
-	// Set margins
-	r.pdf.SetMargins(r.template.Margins.Left, r.template.Margins.Top, r.template.Margins.Right)
-	r.currentY = r.template.Margins.Top
+// render draws the blocks of the template for the document set up by
+// RenderInvoice or RenderContract. content is the text the document can
+// contain, to pick a font that has its characters.
+func (r *Renderer) render(outputPath string, content ...interface{}) error {
+	if err := r.setup(content...); err != nil {
+		return err
+	}
 
 	// Render each block
 	for _, block := range r.template.Blocks {
+		r.keepTogether(block)
 		if err := r.renderBlock(block); err != nil {
 			return fmt.Errorf("failed to render block %s: %w", block.Type, err)
 		}
 	}
+	if err := r.pdf.Error(); err != nil {
+		return err
+	}
 
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
@@ -286,83 +364,165 @@ func (r *Renderer) RenderInvoice(data InvoiceData, outputPath string) error {
 	return r.pdf.OutputFileAndClose(outputPath)
 }
 
-// renderBlock renders a single template block
+// setup creates the PDF with its font and first page
+func (r *Renderer) setup(content ...interface{}) error {
+	// Initialize PDF
+	pageSize := r.template.PageSize
+	if pageSize == "" {
+		pageSize = "A4"
+	}
+	r.pdf = gofpdf.New("P", "mm", pageSize, "")
+	content = append(content, r.doc.company, r.doc.client, r.doc.title, r.template.Blocks, r.template.Pages,
+		r.loc.Text, r.loc.Money(0, r.doc.currency))
+	font, err := pdffont.Setup(r.pdf, r.fontConfig(), content...)
+	if err != nil {
+		return err
+	}
+	r.font = font
+
+	// Set margins, page breaks happen above the footer
+	r.pdf.SetMargins(r.template.Margins.Left, r.template.Margins.Top, r.template.Margins.Right)
+	r.pdf.SetAutoPageBreak(true, r.bottomMargin())
+	r.pdf.SetHeaderFunc(r.startPage)
+	r.pdf.SetFooterFunc(r.renderFooter)
+	r.pdf.AliasNbPages("")
+	r.pdf.AddPage()
+	r.currentY = r.template.Margins.Top
+	return nil
+}
+
+// fontConfig returns the template's font, or the pdf config font when the
+// template doesn't set one
+func (r *Renderer) fontConfig() pdffont.Font {
+	fonts := r.template.Fonts
+	if fonts.Family == "" && fonts.Regular == "" {
+		return pdffont.FromConfig(r.cfg.PDF)
+	}
+	return pdffont.Font{
+		Family:     fonts.Family,
+		Regular:    fonts.Regular,
+		Bold:       fonts.Bold,
+		Italic:     fonts.Italic,
+		BoldItalic: fonts.BoldItalic,
+	}
+}
+
+// renderBlock renders a single template block when its show_if condition
+// holds
 func (r *Renderer) renderBlock(block TemplateBlock) error {
-	invoiceData, ok := r.data.(InvoiceData)
-	if !ok {
-		return fmt.Errorf("invalid data type for invoice rendering")
+	if show, err := r.shows(block); err != nil || !show {
+		return err
 	}
 
 	switch block.Type {
 	case "header":
-		return r.renderHeader(block, invoiceData)
+		return r.renderHeader(block)
 	case "company_info":
-		return r.renderCompanyInfo(block, invoiceData)
+		return r.renderCompanyInfo(block)
 	case "client_info":
-		return r.renderClientInfo(block, invoiceData)
-	case "invoice_meta":
-		return r.renderInvoiceMeta(block, invoiceData)
-	case "line_items":
-		return r.renderLineItems(block, invoiceData)
-	case "totals":
-		return r.renderTotals(block, invoiceData)
+		return r.renderClientInfo(block)
 	case "notes":
-		return r.renderNotes(block, invoiceData)
+		return r.renderNotes(block)
 	case "terms":
-		return r.renderTerms(block, invoiceData)
+		return r.renderTerms(block)
+	case "text":
+		return r.renderText(block)
+	case "fields":
+		return r.renderFields(block)
+	case "image":
+		return r.renderImage(block)
+	case "signature":
+		return r.renderSignature(block)
+	case "payment_details":
+		return r.renderPaymentDetails(block)
+	case "qr_code":
+		return r.renderQRCode(block)
+	case "page_break":
+		r.pdf.AddPage()
+		return nil
 	case "spacer":
 		r.currentY += block.Position.Height
 		return nil
-	default:
-		return nil // Unknown block types are ignored
 	}
+
+	switch data := r.data.(type) {
+	case InvoiceData:
+		switch block.Type {
+		case "invoice_meta":
+			return r.renderInvoiceMeta(block, data)
+		case "line_items":
+			return r.renderLineItems(block, data)
+		case "totals":
+			return r.renderTotals(block, data)
+		case "payment_qr":
+			return r.renderPaymentQR(block, data)
+		case "contract_terms":
+			return fmt.Errorf("contract_terms blocks are for contract templates")
+		}
+	case ContractData:
+		switch block.Type {
+		case "contract_terms":
+			return r.renderContractTerms(block, data)
+		case "invoice_meta", "line_items", "totals", "payment_qr":
+			return fmt.Errorf("%s blocks are for invoice templates", block.Type)
+		}
+	}
+	return nil // Unknown block types are ignored
+}
+
+// shows reports whether a block's show_if condition holds
+func (r *Renderer) shows(block TemplateBlock) (bool, error) {
+	if block.ShowIf == "" {
+		return true, nil
+	}
+	return Eval(block.ShowIf, r.doc.vars)
 }
 
 // renderHeader renders the header block
-func (r *Renderer) renderHeader(block TemplateBlock, data InvoiceData) error {
+func (r *Renderer) renderHeader(block TemplateBlock) error {
 	leftMargin := r.template.Margins.Left
 	pageWidth := 210.0 // A4 width
 	contentWidth := pageWidth - leftMargin - r.template.Margins.Right
 
 	// Company name
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 18)
+	r.pdf.SetFont(r.font, "B", 18)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(leftMargin, r.currentY)
-	r.pdf.Cell(contentWidth/2, 10, data.Company.Name)
+	r.pdf.Cell(contentWidth/2, 10, r.doc.company.Name)
 
-	// Invoice title
-	r.pdf.SetFont(r.template.Fonts.Family, "B", r.template.Fonts.SizeTitle)
+	// Invoice or contract title
+	r.pdf.SetFont(r.font, "B", r.template.Fonts.SizeTitle)
 	r.setTextColor(r.template.Colors.Primary)
 	r.pdf.SetXY(pageWidth-r.template.Margins.Right-60, r.currentY)
-	r.pdf.Cell(60, 10, r.cfg.Invoice.InvoiceLabel)
+	r.pdf.Cell(60, 10, r.doc.title)
 
 	r.currentY += 15
 	return nil
 }
 
 // renderCompanyInfo renders company information
-func (r *Renderer) renderCompanyInfo(block TemplateBlock, data InvoiceData) error {
+func (r *Renderer) renderCompanyInfo(block TemplateBlock) error {
 	leftMargin := r.template.Margins.Left
 	fontSize := block.Style.FontSize
 	if fontSize == 0 {
 		fontSize = r.template.Fonts.SizeSmall
 	}
 
-	r.pdf.SetFont(r.template.Fonts.Family, "", fontSize)
+	r.pdf.SetFont(r.font, "", fontSize)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(leftMargin, r.currentY)
 
-	company := data.Company
+	company := r.doc.company
 	lineHeight := fontSize * 0.4
 
 	if company.TaxID != "" {
-		r.pdf.Cell(80, lineHeight, fmt.Sprintf("Tax ID: %s", company.TaxID))
+		r.pdf.Cell(80, lineHeight, fmt.Sprintf("%s: %s", r.loc.Text.TaxID, company.TaxID))
 		r.currentY += lineHeight
 		r.pdf.SetXY(leftMargin, r.currentY)
 	}
 
 	if company.BankAccount != "" {
-		bankInfo := "Bank: " + company.BankAccount
+		bankInfo := r.loc.Text.Bank + ": " + company.BankAccount
 		if company.BankName != "" {
 			bankInfo = company.BankName + " | " + company.BankAccount
 		}
@@ -383,7 +543,7 @@ func (r *Renderer) renderCompanyInfo(block TemplateBlock, data InvoiceData) erro
 }
 
 // renderClientInfo renders client information
-func (r *Renderer) renderClientInfo(block TemplateBlock, data InvoiceData) error {
+func (r *Renderer) renderClientInfo(block TemplateBlock) error {
 	leftMargin := r.template.Margins.Left
 	fontSize := block.Style.FontSize
 	if fontSize == 0 {
@@ -391,33 +551,39 @@ func (r *Renderer) renderClientInfo(block TemplateBlock, data InvoiceData) error
 	}
 
 	// Bill To label
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 10)
+	r.pdf.SetFont(r.font, "B", 10)
 	r.setTextColor(r.template.Colors.Primary)
 	r.pdf.SetXY(leftMargin, r.currentY)
 	r.pdf.Cell(40, 5, r.cfg.Invoice.BillToLabel)
 	r.currentY += 7
 
 	// Client name
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 11)
+	r.pdf.SetFont(r.font, "B", 11)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(leftMargin, r.currentY)
-	r.pdf.Cell(80, 5, data.Client.Name)
+	r.pdf.Cell(80, 5, r.doc.client.Name)
 	r.currentY += 6
 
-	r.pdf.SetFont(r.template.Fonts.Family, "", fontSize)
+	r.pdf.SetFont(r.font, "", fontSize)
 
-	client := data.Client
+	client := r.doc.client
 	lineHeight := fontSize * 0.4
 
+	if r.doc.contact != "" {
+		r.pdf.SetXY(leftMargin, r.currentY)
+		r.pdf.Cell(80, lineHeight, fmt.Sprintf("%s %s", r.loc.Text.Attention, r.doc.contact))
+		r.currentY += lineHeight
+	}
+
 	if client.TaxID != "" {
 		r.pdf.SetXY(leftMargin, r.currentY)
-		r.pdf.Cell(80, lineHeight, fmt.Sprintf("Tax ID: %s", client.TaxID))
+		r.pdf.Cell(80, lineHeight, fmt.Sprintf("%s: %s", r.loc.Text.TaxID, client.TaxID))
 		r.currentY += lineHeight
 	}
 
-	if client.Address != "" {
+	if r.doc.address != "" {
 		r.pdf.SetXY(leftMargin, r.currentY)
-		r.pdf.MultiCell(80, lineHeight, client.Address, "", "L", false)
+		r.pdf.MultiCell(80, lineHeight, r.doc.address, "", "L", false)
 		r.currentY = r.pdf.GetY()
 	}
 
@@ -431,96 +597,104 @@ func (r *Renderer) renderInvoiceMeta(block TemplateBlock, data InvoiceData) erro
 	metaLabelX := pageWidth - rightMargin - 80
 	metaValueX := pageWidth - rightMargin - 40
 
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 10)
+	r.pdf.SetFont(r.font, "B", 10)
 	r.setTextColor(r.template.Colors.Secondary)
 
 	// Invoice#
 	r.pdf.SetXY(metaLabelX, r.currentY)
-	r.pdf.Cell(40, 5, "Invoice#")
-	r.pdf.SetFont(r.template.Fonts.Family, "", 10)
+	r.pdf.Cell(40, 5, r.loc.Text.InvoiceNumber)
+	r.pdf.SetFont(r.font, "", 10)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(metaValueX, r.currentY)
 	r.pdf.Cell(40, 5, data.Invoice.InvoiceNum)
 
 	// Invoice Date
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 10)
+	r.pdf.SetFont(r.font, "B", 10)
 	r.setTextColor(r.template.Colors.Secondary)
 	r.pdf.SetXY(metaLabelX, r.currentY+6)
-	r.pdf.Cell(40, 5, "Invoice Date")
-	r.pdf.SetFont(r.template.Fonts.Family, "", 10)
+	r.pdf.Cell(40, 5, r.loc.Text.InvoiceDate)
+	r.pdf.SetFont(r.font, "", 10)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(metaValueX, r.currentY+6)
-	r.pdf.Cell(40, 5, data.Invoice.IssuedDate.Format("02 Jan 2006"))
+	r.pdf.Cell(40, 5, r.loc.Date(data.Invoice.IssuedDate))
 
 	// Due Date
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 10)
+	r.pdf.SetFont(r.font, "B", 10)
 	r.setTextColor(r.template.Colors.Secondary)
 	r.pdf.SetXY(metaLabelX, r.currentY+12)
-	r.pdf.Cell(40, 5, "Due Date")
-	r.pdf.SetFont(r.template.Fonts.Family, "", 10)
+	r.pdf.Cell(40, 5, r.loc.Text.DueDate)
+	r.pdf.SetFont(r.font, "", 10)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(metaValueX, r.currentY+12)
-	r.pdf.Cell(40, 5, data.Invoice.DueDate.Format("02 Jan 2006"))
+	r.pdf.Cell(40, 5, r.loc.Date(data.Invoice.DueDate))
+
+	// The client's purchase order number
+	if data.Invoice.PONumber != "" {
+		r.pdf.SetFont(r.font, "B", 10)
+		r.setTextColor(r.template.Colors.Secondary)
+		r.pdf.SetXY(metaLabelX, r.currentY+18)
+		r.pdf.Cell(40, 5, r.loc.Text.PONumber)
+		r.pdf.SetFont(r.font, "", 10)
+		r.setTextColor(r.template.Colors.Text)
+		r.pdf.SetXY(metaValueX, r.currentY+18)
+		r.pdf.Cell(40, 5, data.Invoice.PONumber)
+	}
 
 	return nil
 }
 
-// renderLineItems renders the line items table
+// renderLineItems renders the line items table. Rows that don't fit on
+// the page go on the next one, below the repeated table header and the
+// subtotal carried over; the "repeat_header" and "carry_forward" options
+// turn these off.
 func (r *Renderer) renderLineItems(block TemplateBlock, data InvoiceData) error {
 	leftMargin := r.template.Margins.Left
-	pageWidth := 210.0
-	contentWidth := pageWidth - leftMargin - r.template.Margins.Right
+	contentWidth := r.contentWidth()
 
-	// Column widths
-	itemWidth := contentWidth * 0.45
-	qtyWidth := contentWidth * 0.15
-	rateWidth := contentWidth * 0.20
-	amountWidth := contentWidth * 0.20
+	r.table = &lineItemsTable{
+		block:    block,
+		currency: data.Invoice.Currency,
+		widths:   [4]float64{contentWidth * 0.45, contentWidth * 0.15, contentWidth * 0.20, contentWidth * 0.20},
+	}
+	defer func() { r.table = nil }()
 
 	// Table header
-	pr, pg, pb := r.template.Colors.Primary.ToRGB()
-	r.pdf.SetFillColor(pr, pg, pb)
-	r.pdf.SetTextColor(255, 255, 255)
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 9)
-	r.pdf.SetXY(leftMargin, r.currentY)
-
-	r.pdf.CellFormat(itemWidth, 8, r.cfg.Invoice.ItemLabel, "", 0, "L", true, 0, "")
-	r.pdf.CellFormat(qtyWidth, 8, r.cfg.Invoice.QuantityLabel, "", 0, "C", true, 0, "")
-	r.pdf.CellFormat(rateWidth, 8, r.cfg.Invoice.RateLabel, "", 0, "R", true, 0, "")
-	r.pdf.CellFormat(amountWidth, 8, r.cfg.Invoice.AmountLabel, "", 0, "R", true, 0, "")
-	r.pdf.Ln(-1)
-	r.currentY = r.pdf.GetY()
+	r.drawTableHeader()
 
 	// Table rows
-	r.pdf.SetFont(r.template.Fonts.Family, "", 9)
-	r.setTextColor(r.template.Colors.Text)
-
+	widths := r.table.widths
 	for _, item := range data.LineItems {
-		r.pdf.SetX(leftMargin)
+		// Leave room to carry the subtotal to the next page
+		if r.currentY+2*tableRowHeight > r.pageBottom() {
+			r.drawCarriedForward()
+			r.pdf.AddPage()
+		}
+
+		r.pdf.SetFont(r.font, "", 9)
+		r.setTextColor(r.template.Colors.Text)
+		r.pdf.SetXY(leftMargin, r.currentY)
 
 		// Item name
-		r.pdf.CellFormat(itemWidth, 8, item.ItemName, "", 0, "L", false, 0, "")
+		r.pdf.CellFormat(widths[0], tableRowHeight, item.ItemName, "", 0, "L", false, 0, "")
 
 		// Quantity
-		qtyStr := fmt.Sprintf("%.0f", item.Quantity)
-		if item.Quantity != float64(int(item.Quantity)) {
-			qtyStr = fmt.Sprintf("%.2f", item.Quantity)
-		}
-		r.pdf.CellFormat(qtyWidth, 8, qtyStr, "", 0, "C", false, 0, "")
+		r.pdf.CellFormat(widths[1], tableRowHeight, r.loc.Quantity(item.Quantity), "", 0, "C", false, 0, "")
 
 		// Rate
-		r.pdf.CellFormat(rateWidth, 8, formatCurrency(item.Rate, data.Invoice.Currency), "", 0, "R", false, 0, "")
+		r.pdf.CellFormat(widths[2], tableRowHeight, r.loc.Money(item.Rate, data.Invoice.Currency), "", 0, "R", false, 0, "")
 
 		// Amount
-		r.pdf.CellFormat(amountWidth, 8, formatCurrency(item.Amount, data.Invoice.Currency), "", 0, "R", false, 0, "")
+		r.pdf.CellFormat(widths[3], tableRowHeight, r.loc.Money(item.Amount, data.Invoice.Currency), "", 0, "R", false, 0, "")
 		r.pdf.Ln(-1)
 
 		// Draw separator
 		r.pdf.SetDrawColor(220, 220, 220)
 		r.pdf.Line(leftMargin, r.pdf.GetY(), leftMargin+contentWidth, r.pdf.GetY())
+
+		r.table.subtotal += item.Amount
+		r.currentY = r.pdf.GetY()
 	}
 
-	r.currentY = r.pdf.GetY()
 	return nil
 }
 
@@ -534,11 +708,7 @@ func (r *Renderer) renderTotals(block TemplateBlock, data InvoiceData) error {
 	amountWidth := contentWidth * 0.20
 	labelWidth := contentWidth - rateWidth - amountWidth
 
-	// Calculate total
-	var total float64
-	for _, item := range data.LineItems {
-		total += item.Amount
-	}
+	total := invoiceTotal(data)
 
 	r.pdf.Ln(5)
 	r.pdf.SetX(leftMargin)
@@ -550,20 +720,68 @@ func (r *Renderer) renderTotals(block TemplateBlock, data InvoiceData) error {
 	r.pdf.SetX(leftMargin)
 
 	r.setTextColor(r.template.Colors.Text)
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 12)
+	r.pdf.SetFont(r.font, "B", 12)
 
 	r.pdf.CellFormat(labelWidth, 10, "", "", 0, "R", false, 0, "")
 	r.pdf.CellFormat(rateWidth, 10, r.cfg.Invoice.TotalLabel, "", 0, "R", false, 0, "")
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 14)
-	r.pdf.CellFormat(amountWidth, 10, formatCurrency(total, data.Invoice.Currency), "", 0, "R", false, 0, "")
+	r.pdf.SetFont(r.font, "B", 14)
+	r.pdf.CellFormat(amountWidth, 10, r.loc.Money(total, data.Invoice.Currency), "", 0, "R", false, 0, "")
 
 	r.currentY = r.pdf.GetY() + 10
 	return nil
 }
 
-// renderNotes renders the notes section
-func (r *Renderer) renderNotes(block TemplateBlock, data InvoiceData) error {
-	if data.Invoice.Description == "" {
+// renderPaymentQR renders a payment QR code with a caption. The format,
+// payment link and caption default to the PDF config and can be set with
+// the "format", "payment_url" and "label" options; Position.Width sets the
+// size of the code, X and Y place it anywhere on the page.
+func (r *Renderer) renderPaymentQR(block TemplateBlock, data InvoiceData) error {
+	format := stringOption(block, "format", r.cfg.PDF.QRFormat)
+	pattern := stringOption(block, "payment_url", r.cfg.PDF.QRPaymentURL)
+	label := stringOption(block, "label", r.cfg.PDF.QRLabel)
+
+	payload, err := payqr.Payload(format, pattern, payqr.FromInvoice(data.Invoice, data.Company, invoiceTotal(data)))
+	if err != nil {
+		return err
+	}
+
+	size := r.blockWidth(block, payqr.DefaultSize(format))
+	x := r.blockX(block, size)
+	y := r.blockY(block)
+
+	if label != "" {
+		r.pdf.SetFont(r.font, "B", 10)
+		r.setTextColor(r.template.Colors.Primary)
+		r.pdf.SetXY(x, y)
+		r.pdf.Cell(size, 5, label)
+		y += 6
+	}
+	if err := payqr.Draw(r.pdf, format, payload, x, y, size); err != nil {
+		return err
+	}
+
+	r.advance(block, y+size+5)
+	return nil
+}
+
+// stringOption returns a block's string option, or def when it's not set
+func stringOption(block TemplateBlock, name, def string) string {
+	if v, ok := block.Options[name].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// invoiceTotal is the gross total of the line items after discounts and
+// tax, as the totals block shows it
+func invoiceTotal(data InvoiceData) float64 {
+	return tax.Calculate(data.LineItems).Gross
+}
+
+// renderNotes renders the notes section: the invoice description or the
+// contract notes
+func (r *Renderer) renderNotes(block TemplateBlock) error {
+	if r.doc.notes == "" {
 		return nil
 	}
 
@@ -572,29 +790,29 @@ func (r *Renderer) renderNotes(block TemplateBlock, data InvoiceData) error {
 	contentWidth := pageWidth - leftMargin - r.template.Margins.Right
 
 	r.pdf.SetXY(leftMargin, r.currentY)
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 10)
+	r.pdf.SetFont(r.font, "B", 10)
 	r.setTextColor(r.template.Colors.Primary)
-	r.pdf.Cell(40, 5, r.cfg.Invoice.NotesLabel)
-	r.pdf.SetFont(r.template.Fonts.Family, "", 9)
+	r.pdf.Cell(40, 5, r.doc.notesLabel)
+	r.pdf.SetFont(r.font, "", 9)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(leftMargin, r.currentY+6)
-	r.pdf.MultiCell(contentWidth, 4, data.Invoice.Description, "", "L", false)
+	r.pdf.MultiCell(contentWidth, 4, r.doc.notes, "", "L", false)
 
 	r.currentY = r.pdf.GetY() + 5
 	return nil
 }
 
 // renderTerms renders the terms section
-func (r *Renderer) renderTerms(block TemplateBlock, data InvoiceData) error {
+func (r *Renderer) renderTerms(block TemplateBlock) error {
 	leftMargin := r.template.Margins.Left
 	pageWidth := 210.0
 	contentWidth := pageWidth - leftMargin - r.template.Margins.Right
 
 	r.pdf.SetXY(leftMargin, r.currentY)
-	r.pdf.SetFont(r.template.Fonts.Family, "B", 10)
+	r.pdf.SetFont(r.font, "B", 10)
 	r.setTextColor(r.template.Colors.Primary)
-	r.pdf.Cell(40, 5, r.cfg.Invoice.TermsLabel)
-	r.pdf.SetFont(r.template.Fonts.Family, "", 9)
+	r.pdf.Cell(40, 5, r.doc.termsLabel)
+	r.pdf.SetFont(r.font, "", 9)
 	r.setTextColor(r.template.Colors.Text)
 	r.pdf.SetXY(leftMargin, r.currentY+6)
 	r.pdf.MultiCell(contentWidth, 4, r.cfg.Invoice.Terms, "", "L", false)
@@ -609,20 +827,6 @@ func (r *Renderer) setTextColor(color HexColor) {
 	r.pdf.SetTextColor(red, green, blue)
 }
 
-// formatCurrency formats amount with currency symbol
-func formatCurrency(amount float64, currency string) string {
-	symbols := map[string]string{
-		"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥",
-		"CAD": "C$", "AUD": "A$", "CHF": "CHF ", "UAH": "₴",
-		"PLN": "zł", "CZK": "Kč", "SEK": "kr", "NOK": "kr",
-	}
-	symbol, ok := symbols[strings.ToUpper(currency)]
-	if !ok {
-		symbol = currency + " "
-	}
-	return fmt.Sprintf("%s%.2f", symbol, amount)
-}
-
 // expandPath expands ~ to home directory
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~") {
@@ -653,6 +857,12 @@ func ListTemplates(templatesDir string) ([]TemplateInfo, error) {
 			Type:        "invoice",
 			IsBuiltin:   true,
 		},
+		{
+			Name:        "default",
+			Description: "Default professional contract template (built-in)",
+			Type:        "contract",
+			IsBuiltin:   true,
+		},
 	}
 
 	// Look for custom templates
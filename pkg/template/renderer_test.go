@@ -0,0 +1,166 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Andriiklymiuk/ung/internal/config"
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{PDF: config.GetDefaultPDFConfig()}
+}
+
+func TestEval(t *testing.T) {
+	vars := map[string]string{
+		"status":        "paid",
+		"currency":      "USD",
+		"total":         "1190.00",
+		"client.tax_id": "",
+		"paid":          "true",
+	}
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"status==paid", true},
+		{"status == PAID", true},
+		{"status!=paid", false},
+		{"currency!=EUR", true},
+		{"currency != 'EUR'", true},
+		{"total>1000", true},
+		{"total>=1190", true},
+		{"total<1000", false},
+		{"paid", true},
+		{"!paid", false},
+		{"client.tax_id", false},
+		{"!client.tax_id", true},
+		{"status==paid && currency==EUR", false},
+		{"status==paid && currency==EUR || total>1000", true},
+		{"currency==EUR || currency==USD", true},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.condition, vars)
+		if err != nil {
+			t.Errorf("%q: %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q = %v; want %v", tt.condition, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"unknown==1", "status>1", "status==paid &&"} {
+		if _, err := Eval(bad, vars); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{"invoice.number": "INV-1", "client.name": "Acme"}
+	got := Interpolate("Invoice {invoice.number} for {client.name}, {unknown} {", vars)
+	want := "Invoice INV-1 for Acme, {unknown} {"
+	if got != want {
+		t.Errorf("Interpolate = %q; want %q", got, want)
+	}
+}
+
+func TestInvoiceVars(t *testing.T) {
+	data := GetSampleInvoiceData(testConfig())
+	r := NewRenderer(GetDefaultInvoiceTemplate())
+	r.RenderInvoice(data, filepath.Join(t.TempDir(), "invoice.pdf"))
+
+	vars := r.doc.vars
+	if vars["invoice.number"] != "INV-2024-001" || vars["total"] != "5000.00" || vars["status"] != "pending" || vars["paid"] != "false" {
+		t.Errorf("unexpected invoice vars %v", vars)
+	}
+	if vars["client.name"] != "Client Company Inc." || vars["type"] != "invoice" {
+		t.Errorf("unexpected party vars %v", vars)
+	}
+}
+
+func TestRenderInvoiceBlocks(t *testing.T) {
+	tmpl := GetDefaultInvoiceTemplate()
+	tmpl.Blocks = append(tmpl.Blocks,
+		TemplateBlock{Type: "text", Options: map[string]interface{}{"text": "Thanks, {client.name}"}},
+		TemplateBlock{Type: "fields", Options: map[string]interface{}{"fields": []interface{}{
+			map[string]interface{}{"label": "PO", "value": "4711"},
+			map[string]interface{}{"label": "Tax ID", "value": "{client.tax_id}"},
+		}}},
+		TemplateBlock{Type: "payment_details", ShowIf: "status!=paid"},
+		TemplateBlock{Type: "page_break"},
+		TemplateBlock{Type: "qr_code", Options: map[string]interface{}{"content": "{invoice.number}"}},
+		TemplateBlock{Type: "signature", ShowIf: "total>1000"},
+		TemplateBlock{Type: "contract_terms", ShowIf: "status==paid"},
+	)
+
+	out := filepath.Join(t.TempDir(), "invoice.pdf")
+	if err := NewRenderer(tmpl).RenderInvoice(GetSampleInvoiceData(testConfig()), out); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(out); err != nil || info.Size() == 0 {
+		t.Fatalf("expected a PDF at %s: %v", out, err)
+	}
+
+	// A block for the other document type fails once it's shown
+	tmpl.Blocks[len(tmpl.Blocks)-1].ShowIf = ""
+	err := NewRenderer(tmpl).RenderInvoice(GetSampleInvoiceData(testConfig()), out)
+	if err == nil || !strings.Contains(err.Error(), "contract_terms") {
+		t.Errorf("expected a contract_terms error, got %v", err)
+	}
+
+	// So does a condition on an unknown variable
+	tmpl.Blocks[len(tmpl.Blocks)-1] = TemplateBlock{Type: "spacer", ShowIf: "colour==red"}
+	if err := NewRenderer(tmpl).RenderInvoice(GetSampleInvoiceData(testConfig()), out); err == nil {
+		t.Error("expected an error for an unknown variable")
+	}
+}
+
+func TestRenderContract(t *testing.T) {
+	data := GetSampleContractData(testConfig())
+	out := filepath.Join(t.TempDir(), "contract.pdf")
+	if err := NewRenderer(GetDefaultContractTemplate()).RenderContract(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(out); err != nil || info.Size() == 0 {
+		t.Fatalf("expected a PDF at %s: %v", out, err)
+	}
+
+	vars := ContractVars(data, locale.Get("en"))
+	if vars["contract.name"] != "Web Development" || vars["status"] != "active" || vars["rate"] != "100.00" {
+		t.Errorf("unexpected contract vars %v", vars)
+	}
+
+	// Invoice blocks have nothing to render for a contract
+	tmpl := GetDefaultContractTemplate()
+	tmpl.Blocks = append(tmpl.Blocks, TemplateBlock{Type: "line_items"})
+	if err := NewRenderer(tmpl).RenderContract(data, out); err == nil {
+		t.Error("expected an error for line_items in a contract")
+	}
+
+	data.Contract.Active = false
+	tmpl.Blocks[len(tmpl.Blocks)-1].ShowIf = "active"
+	if err := NewRenderer(tmpl).RenderContract(data, out); err != nil {
+		t.Errorf("expected the hidden block to be skipped, got %v", err)
+	}
+}
+
+func TestListTemplatesBuiltins(t *testing.T) {
+	templates, err := ListTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]bool{}
+	for _, tmpl := range templates {
+		if tmpl.IsBuiltin {
+			types[tmpl.Type] = true
+		}
+	}
+	if !types["invoice"] || !types["contract"] {
+		t.Errorf("expected built-in invoice and contract templates, got %+v", templates)
+	}
+}
@@ -0,0 +1,115 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Andriiklymiuk/ung/pkg/locale"
+	"github.com/Andriiklymiuk/ung/pkg/models"
+	"github.com/Andriiklymiuk/ung/pkg/tax"
+)
+
+// InvoiceVars returns the variables of an invoice for show_if conditions
+// and {name} placeholders. Amounts are plain numbers, e.g. "1190.00", the
+// _money variants are formatted in the document language.
+func InvoiceVars(data InvoiceData, loc *locale.Pack) map[string]string {
+	inv := data.Invoice
+	totals := tax.Calculate(data.LineItems)
+	if len(data.LineItems) == 0 {
+		totals = tax.Totals{Net: inv.Net(), Tax: inv.TaxAmount, Gross: inv.Amount}
+	}
+
+	vars := partyVars(data.Company, data.Client, loc)
+	vars["type"] = "invoice"
+	vars["invoice.number"] = inv.InvoiceNum
+	vars["invoice.date"] = loc.Date(inv.IssuedDate)
+	vars["invoice.due_date"] = loc.Date(inv.DueDate)
+	vars["invoice.description"] = inv.Description
+	vars["invoice.po_number"] = inv.PONumber
+	vars["client.address"] = data.Client.AddressFor(models.AddressBilling)
+	vars["client.contact"] = contactName(data.Client, models.ContactBilling)
+	vars["status"] = string(inv.Status)
+	vars["currency"] = inv.Currency
+	vars["net"] = money(totals.Net)
+	vars["tax"] = money(totals.Tax)
+	vars["total"] = money(totals.Gross)
+	vars["net_money"] = loc.Money(totals.Net, inv.Currency)
+	vars["tax_money"] = loc.Money(totals.Tax, inv.Currency)
+	vars["total_money"] = loc.Money(totals.Gross, inv.Currency)
+	vars["tax_profile"] = inv.TaxProfile
+	vars["tax_type"] = inv.TaxType
+	vars["tax_note"] = inv.TaxNote
+	vars["items"] = strconv.Itoa(len(data.LineItems))
+	vars["paid"] = strconv.FormatBool(inv.Status == models.StatusPaid)
+	vars["overdue"] = strconv.FormatBool(inv.Status == models.StatusOverdue ||
+		(inv.Status != models.StatusPaid && !inv.DueDate.IsZero() && inv.DueDate.Before(time.Now())))
+	return vars
+}
+
+// ContractVars returns the variables of a contract, see InvoiceVars
+func ContractVars(data ContractData, loc *locale.Pack) map[string]string {
+	c := data.Contract
+	vars := partyVars(data.Company, data.Client, loc)
+	vars["type"] = "contract"
+	vars["contract.number"] = c.ContractNum
+	vars["contract.name"] = c.Name
+	vars["client.address"] = data.Client.AddressFor(models.AddressLegal)
+	vars["client.contact"] = contactName(data.Client, models.ContactLegal)
+	vars["contract.type"] = string(c.ContractType)
+	vars["contract.start_date"] = loc.Date(c.StartDate)
+	vars["contract.end_date"] = ""
+	if c.EndDate != nil {
+		vars["contract.end_date"] = loc.Date(*c.EndDate)
+	}
+	vars["contract.notes"] = c.Notes
+	vars["currency"] = c.Currency
+	vars["active"] = strconv.FormatBool(c.Active)
+	vars["status"] = "inactive"
+	if c.Active {
+		vars["status"] = "active"
+	}
+	vars["tax_profile"] = c.TaxProfile
+
+	rate := 0.0
+	if c.HourlyRate != nil {
+		rate = *c.HourlyRate
+	} else if c.FixedPrice != nil {
+		rate = *c.FixedPrice
+	}
+	vars["rate"] = money(rate)
+	vars["rate_money"] = loc.Money(rate, c.Currency)
+	return vars
+}
+
+// partyVars returns the variables of the company and the client
+func partyVars(company models.Company, client models.Client, loc *locale.Pack) map[string]string {
+	return map[string]string{
+		"company.name":         company.Name,
+		"company.email":        company.Email,
+		"company.phone":        company.Phone,
+		"company.address":      company.Address,
+		"company.tax_id":       company.TaxID,
+		"company.bank_name":    company.BankName,
+		"company.bank_account": company.BankAccount,
+		"company.bank_swift":   company.BankSWIFT,
+		"client.name":          client.Name,
+		"client.email":         client.Email,
+		"client.address":       client.Address,
+		"client.tax_id":        client.TaxID,
+		"language":             loc.Code,
+		"today":                loc.Date(time.Now()),
+	}
+}
+
+// contactName returns the name of the client's first contact with a role
+func contactName(client models.Client, role models.ContactRole) string {
+	if contacts := client.ContactsFor(role); len(contacts) > 0 {
+		return contacts[0].Name
+	}
+	return ""
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
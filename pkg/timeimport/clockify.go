@@ -0,0 +1,129 @@
+package timeimport
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// clockifyEntry is a time entry from the Clockify API (hydrated) or a detailed report JSON export
+type clockifyEntry struct {
+	ID           string `json:"id"`
+	Description  string `json:"description"`
+	Billable     bool   `json:"billable"`
+	TimeInterval struct {
+		Start    string `json:"start"`
+		End      string `json:"end"`
+		Duration string `json:"duration"` // ISO 8601, e.g. PT1H30M
+	} `json:"timeInterval"`
+	Project *struct {
+		Name       string `json:"name"`
+		ClientName string `json:"clientName"`
+	} `json:"project"`
+	ProjectName string `json:"projectName"`
+	ClientName  string `json:"clientName"`
+	Task        *struct {
+		Name string `json:"name"`
+	} `json:"task"`
+	TaskName string `json:"taskName"`
+	Tags     []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	UserEmail string `json:"userEmail"`
+}
+
+// parseClockifyCSV reads a Clockify "Detailed report" CSV export
+func parseClockifyCSV(r io.Reader) ([]Entry, error) {
+	t, err := readCSVTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if !t.has("start date") || !(t.has("duration (decimal)") || t.has("duration (h)")) {
+		return nil, fmt.Errorf("not a Clockify detailed report: missing 'Start Date' or 'Duration' column")
+	}
+
+	var entries []Entry
+	for i, row := range t.rows {
+		start, err := parseDateTime(t.get(row, "start date"), t.get(row, "start time"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		hours, err := parseClockDuration(t.get(row, "duration (decimal)", "duration (h)"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+
+		e := Entry{
+			Source:      SourceClockify,
+			User:        t.get(row, "email", "user"),
+			Client:      t.get(row, "client"),
+			Project:     t.get(row, "project"),
+			Task:        t.get(row, "task"),
+			Description: t.get(row, "description"),
+			Tags:        splitTags(t.get(row, "tags")),
+			Billable:    parseBool(t.get(row, "billable")),
+			Start:       start,
+			Hours:       hours,
+		}
+		if end, err := parseDateTime(t.get(row, "end date"), t.get(row, "end time")); err == nil {
+			e.End = end
+		}
+		e.ExternalID = externalID(SourceClockify, t.get(row, "id"),
+			e.User, start.Format(time.RFC3339), e.Project, e.Description)
+		finish(&e)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// parseClockifyJSON reads a JSON array of Clockify time entries
+func parseClockifyJSON(r io.Reader) ([]Entry, error) {
+	var raw []clockifyEntry
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse Clockify JSON: %w", err)
+	}
+
+	var entries []Entry
+	for _, ce := range raw {
+		if ce.TimeInterval.End == "" {
+			continue // Timer still running
+		}
+		start, err := time.Parse(time.RFC3339, ce.TimeInterval.Start)
+		if err != nil {
+			return nil, fmt.Errorf("entry %s: invalid start: %w", ce.ID, err)
+		}
+		end, err := time.Parse(time.RFC3339, ce.TimeInterval.End)
+		if err != nil {
+			return nil, fmt.Errorf("entry %s: invalid end: %w", ce.ID, err)
+		}
+
+		e := Entry{
+			Source:      SourceClockify,
+			User:        ce.UserEmail,
+			Client:      ce.ClientName,
+			Project:     ce.ProjectName,
+			Task:        ce.TaskName,
+			Description: ce.Description,
+			Billable:    ce.Billable,
+			Start:       start,
+			End:         end,
+		}
+		if ce.Project != nil {
+			e.Project = cmp.Or(ce.Project.Name, e.Project)
+			e.Client = cmp.Or(ce.Project.ClientName, e.Client)
+		}
+		if ce.Task != nil {
+			e.Task = cmp.Or(ce.Task.Name, e.Task)
+		}
+		for _, tag := range ce.Tags {
+			e.Tags = append(e.Tags, tag.Name)
+		}
+
+		e.ExternalID = externalID(SourceClockify, ce.ID, e.User, ce.TimeInterval.Start, e.Project, e.Description)
+		finish(&e)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
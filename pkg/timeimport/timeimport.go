@@ -0,0 +1,214 @@
+package timeimport
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Source identifies the time tracker an export came from
+type Source string
+
+const (
+	SourceToggl    Source = "toggl"
+	SourceClockify Source = "clockify"
+	SourceHarvest  Source = "harvest"
+)
+
+// Format is the file format of an export
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// Entry is a time entry normalized from any supported tracker export
+type Entry struct {
+	Source      Source
+	ExternalID  string // Stable ID used for idempotent re-imports, e.g. "toggl:123"
+	User        string
+	Client      string
+	Project     string
+	Task        string
+	Description string
+	Tags        []string
+	Billable    bool
+	Start       time.Time
+	End         time.Time
+	Hours       float64
+}
+
+// Parse reads an export from the given tracker in the given format
+func Parse(source Source, format Format, r io.Reader) ([]Entry, error) {
+	switch source {
+	case SourceToggl:
+		if format == FormatJSON {
+			return parseTogglJSON(r)
+		}
+		return parseTogglCSV(r)
+	case SourceClockify:
+		if format == FormatJSON {
+			return parseClockifyJSON(r)
+		}
+		return parseClockifyCSV(r)
+	case SourceHarvest:
+		if format == FormatJSON {
+			return parseHarvestJSON(r)
+		}
+		return parseHarvestCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported source: %s", source)
+	}
+}
+
+// DetectFormat returns the format implied by a file name
+func DetectFormat(fileName string) Format {
+	if strings.HasSuffix(strings.ToLower(fileName), ".json") {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// externalID builds a source-prefixed ID. CSV exports often carry no IDs, so a
+// content hash of the identifying fields is used instead.
+func externalID(source Source, id string, fallback ...string) string {
+	if id != "" {
+		return string(source) + ":" + id
+	}
+	sum := sha1.Sum([]byte(strings.Join(fallback, "|")))
+	return string(source) + ":csv:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// csvTable gives access to CSV rows by (case-insensitive) header name
+type csvTable struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// readCSVTable reads a CSV file with a header row
+func readCSVTable(r io.Reader) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	t := &csvTable{columns: make(map[string]int), rows: records[1:]}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		t.columns[name] = i
+	}
+	return t, nil
+}
+
+// has reports whether the table has a column
+func (t *csvTable) has(name string) bool {
+	_, ok := t.columns[strings.ToLower(name)]
+	return ok
+}
+
+// get returns the value of the first existing column for a row
+func (t *csvTable) get(row []string, names ...string) string {
+	for _, name := range names {
+		if i, ok := t.columns[strings.ToLower(name)]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+	}
+	return ""
+}
+
+// parseDateTime combines separate date and time columns, trying common export layouts
+func parseDateTime(date, clock string) (time.Time, error) {
+	dateLayouts := []string{"2006-01-02", "01/02/2006", "1/2/2006", "02.01.2006", "02/01/2006"}
+	clockLayouts := []string{"15:04:05", "15:04", "03:04:05 PM", "3:04:05 PM", "03:04 PM", "3:04 PM", "3:04PM", "3:04pm"}
+
+	if clock == "" {
+		for _, dl := range dateLayouts {
+			if t, err := time.ParseInLocation(dl, date, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unable to parse date: %s", date)
+	}
+
+	for _, dl := range dateLayouts {
+		for _, cl := range clockLayouts {
+			if t, err := time.ParseInLocation(dl+" "+cl, date+" "+clock, time.Local); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse date/time: %s %s", date, clock)
+}
+
+// parseClockDuration parses "1:30:00", "01:30" or decimal hours like "1.5"
+func parseClockDuration(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if !strings.Contains(s, ":") {
+		var h float64
+		if _, err := fmt.Sscanf(strings.ReplaceAll(s, ",", "."), "%f", &h); err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		return h, nil
+	}
+
+	parts := strings.Split(s, ":")
+	var h, m, sec int
+	var err error
+	switch len(parts) {
+	case 2:
+		_, err = fmt.Sscanf(s, "%d:%d", &h, &m)
+	case 3:
+		_, err = fmt.Sscanf(s, "%d:%d:%d", &h, &m, &sec)
+	default:
+		err = fmt.Errorf("too many parts")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return float64(h) + float64(m)/60 + float64(sec)/3600, nil
+}
+
+// parseBool interprets the yes/no values used by tracker exports
+func parseBool(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "true", "1", "y", "billable":
+		return true
+	}
+	return false
+}
+
+// splitTags splits a comma separated tag list
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// finish fills in whichever of End and Hours is missing
+func finish(e *Entry) {
+	if e.End.IsZero() && e.Hours > 0 {
+		e.End = e.Start.Add(time.Duration(e.Hours * float64(time.Hour)))
+	}
+	if e.Hours == 0 && !e.End.IsZero() {
+		e.Hours = e.End.Sub(e.Start).Hours()
+	}
+}
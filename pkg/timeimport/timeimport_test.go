@@ -0,0 +1,222 @@
+package timeimport
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const togglCSV = "\ufeffUser,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount (USD)\n" +
+	"Jane,jane@example.com,Acme,Website,,Homepage layout,Yes,2024-01-15,09:00:00,2024-01-15,10:30:00,01:30:00,\"design, frontend\",150.00\n" +
+	"Jane,jane@example.com,,Internal,,Admin,No,2024-01-16,14:00:00,2024-01-16,14:15:00,00:15:00,,0.00\n"
+
+const togglJSON = `[
+  {"id": 101, "description": "API work", "start": "2024-01-15T09:00:00Z", "stop": "2024-01-15T11:00:00Z",
+   "duration": 7200, "billable": true, "tags": ["backend"], "project_name": "API", "client_name": "Globex"},
+  {"id": 102, "description": "Running", "start": "2024-01-15T12:00:00Z", "duration": -1705312800}
+]`
+
+const clockifyCSV = "Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),Duration (decimal)\n" +
+	"Website,Acme,Fix nav,Frontend,Jane,,jane@example.com,urgent,Yes,01/15/2024,09:00:00 AM,01/15/2024,11:15:00 AM,02:15:00,2.25\n"
+
+const clockifyJSON = `[
+  {"id": "abc123", "description": "Review", "billable": true,
+   "timeInterval": {"start": "2024-01-15T09:00:00Z", "end": "2024-01-15T09:45:00Z", "duration": "PT45M"},
+   "project": {"name": "Website", "clientName": "Acme"}, "tags": [{"name": "review"}]}
+]`
+
+const harvestCSV = "Date,Client,Project,Project Code,Task,Notes,Hours,Hours Rounded,Billable?,Invoiced?,First Name,Last Name\n" +
+	"2024-01-15,Acme,Website,WEB,Design,Wireframes,3.5,3.5,Yes,No,Jane,Doe\n"
+
+const harvestJSON = `{"time_entries": [
+  {"id": 555, "spent_date": "2024-01-15", "hours": 2.0, "notes": "Sprint planning", "billable": true,
+   "started_time": "9:00am", "ended_time": "11:00am",
+   "user": {"name": "Jane Doe"}, "client": {"name": "Acme"}, "project": {"name": "Website"}, "task": {"name": "Meetings"}}
+]}`
+
+func TestParseToggl(t *testing.T) {
+	entries, err := Parse(SourceToggl, FormatCSV, strings.NewReader(togglCSV))
+	if err != nil {
+		t.Fatalf("CSV parse error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Client != "Acme" || e.Project != "Website" || e.Description != "Homepage layout" {
+		t.Errorf("unexpected entry fields: %+v", e)
+	}
+	if e.Hours != 1.5 || !e.Billable {
+		t.Errorf("expected 1.5 billable hours, got %.2f billable=%v", e.Hours, e.Billable)
+	}
+	if len(e.Tags) != 2 || e.Tags[1] != "frontend" {
+		t.Errorf("unexpected tags: %v", e.Tags)
+	}
+	if !strings.HasPrefix(e.ExternalID, "toggl:csv:") {
+		t.Errorf("expected hashed external ID, got %s", e.ExternalID)
+	}
+	if entries[1].Billable {
+		t.Error("expected second entry to be non-billable")
+	}
+
+	// Hashed IDs must be stable across parses
+	again, _ := Parse(SourceToggl, FormatCSV, strings.NewReader(togglCSV))
+	if again[0].ExternalID != e.ExternalID {
+		t.Error("expected stable external IDs for CSV rows")
+	}
+
+	entries, err = Parse(SourceToggl, FormatJSON, strings.NewReader(togglJSON))
+	if err != nil {
+		t.Fatalf("JSON parse error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected running entry to be skipped, got %d entries", len(entries))
+	}
+	if entries[0].ExternalID != "toggl:101" || entries[0].Hours != 2 || entries[0].Client != "Globex" {
+		t.Errorf("unexpected JSON entry: %+v", entries[0])
+	}
+}
+
+func TestParseClockify(t *testing.T) {
+	entries, err := Parse(SourceClockify, FormatCSV, strings.NewReader(clockifyCSV))
+	if err != nil {
+		t.Fatalf("CSV parse error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Hours != 2.25 || entries[0].Task != "Frontend" {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if entries[0].Start.Hour() != 9 {
+		t.Errorf("expected start at 9, got %v", entries[0].Start)
+	}
+
+	entries, err = Parse(SourceClockify, FormatJSON, strings.NewReader(clockifyJSON))
+	if err != nil {
+		t.Fatalf("JSON parse error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.ExternalID != "clockify:abc123" || e.Client != "Acme" || e.Project != "Website" {
+		t.Errorf("unexpected JSON entry: %+v", e)
+	}
+	if e.Hours != 0.75 {
+		t.Errorf("expected 0.75 hours, got %.2f", e.Hours)
+	}
+	if len(e.Tags) != 1 || e.Tags[0] != "review" {
+		t.Errorf("unexpected tags: %v", e.Tags)
+	}
+}
+
+func TestParseHarvest(t *testing.T) {
+	entries, err := Parse(SourceHarvest, FormatCSV, strings.NewReader(harvestCSV))
+	if err != nil {
+		t.Fatalf("CSV parse error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Hours != 3.5 || e.User != "Jane Doe" || !e.Billable {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.End.Sub(e.Start) != 210*time.Minute {
+		t.Errorf("expected end derived from hours, got %v", e.End.Sub(e.Start))
+	}
+
+	entries, err = Parse(SourceHarvest, FormatJSON, strings.NewReader(harvestJSON))
+	if err != nil {
+		t.Fatalf("JSON parse error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e = entries[0]
+	if e.ExternalID != "harvest:555" || e.Start.Hour() != 9 || e.Hours != 2 {
+		t.Errorf("unexpected JSON entry: %+v", e)
+	}
+}
+
+func TestParseHarvestIdenticalRows(t *testing.T) {
+	row := "2024-01-15,Acme,Website,WEB,Design,Wireframes,1.0,1.0,Yes,No,Jane,Doe\n"
+	csv := strings.SplitAfter(harvestCSV, "\n")[0] + row + row
+
+	entries, err := Parse(SourceHarvest, FormatCSV, strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("CSV parse error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].ExternalID == entries[1].ExternalID {
+		t.Errorf("identical rows share external ID %s", entries[0].ExternalID)
+	}
+
+	// A single row keeps the same ID as before identical rows were told apart
+	single, err := Parse(SourceHarvest, FormatCSV, strings.NewReader(strings.SplitAfter(harvestCSV, "\n")[0]+row))
+	if err != nil {
+		t.Fatalf("CSV parse error: %v", err)
+	}
+	if single[0].ExternalID != entries[0].ExternalID {
+		t.Errorf("expected first row ID %s to be stable, got %s", entries[0].ExternalID, single[0].ExternalID)
+	}
+}
+
+func TestParseHarvestOverlappingExports(t *testing.T) {
+	header := strings.SplitAfter(harvestCSV, "\n")[0]
+	before := "2024-01-14,Acme,Website,WEB,Design,Sitemap,2.0,2.0,Yes,No,Jane,Doe\n"
+	row := "2024-01-15,Acme,Website,WEB,Design,Wireframes,1.0,1.0,Yes,No,Jane,Doe\n"
+	after := "2024-01-16,Acme,Website,WEB,Design,Mockups,3.0,3.0,Yes,No,Jane,Doe\n"
+
+	// The same identical rows at different offsets keep their IDs
+	first, err := Parse(SourceHarvest, FormatCSV, strings.NewReader(header+before+row+row))
+	if err != nil {
+		t.Fatalf("CSV parse error: %v", err)
+	}
+	second, err := Parse(SourceHarvest, FormatCSV, strings.NewReader(header+row+row+after))
+	if err != nil {
+		t.Fatalf("CSV parse error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if first[i+1].ExternalID != second[i].ExternalID {
+			t.Errorf("row %d: expected ID %s in both exports, got %s", i+1, first[i+1].ExternalID, second[i].ExternalID)
+		}
+	}
+}
+
+func TestParseWrongFormat(t *testing.T) {
+	if _, err := Parse(SourceToggl, FormatCSV, strings.NewReader(harvestCSV)); err == nil {
+		t.Error("expected error when parsing a Harvest CSV as Toggl")
+	}
+}
+
+func TestParseClockDuration(t *testing.T) {
+	tests := map[string]float64{
+		"01:30:00": 1.5,
+		"2:15":     2.25,
+		"1.5":      1.5,
+		"0,25":     0.25,
+	}
+	for input, expected := range tests {
+		got, err := parseClockDuration(input)
+		if err != nil {
+			t.Errorf("parseClockDuration(%q) error: %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("parseClockDuration(%q) = %v; want %v", input, got, expected)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	if DetectFormat("export.JSON") != FormatJSON {
+		t.Error("expected JSON format")
+	}
+	if DetectFormat("export.csv") != FormatCSV {
+		t.Error("expected CSV format")
+	}
+}
@@ -0,0 +1,124 @@
+package timeimport
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// togglEntry is a time entry from the Toggl Track API or a detailed report JSON export
+type togglEntry struct {
+	ID          int64    `json:"id"`
+	Description string   `json:"description"`
+	Start       string   `json:"start"`
+	Stop        string   `json:"stop"`
+	End         string   `json:"end"`
+	Duration    int64    `json:"duration"` // seconds; negative while running
+	Dur         int64    `json:"dur"`      // milliseconds (reports API)
+	Billable    bool     `json:"billable"`
+	Tags        []string `json:"tags"`
+	Project     string   `json:"project"`
+	ProjectName string   `json:"project_name"`
+	Client      string   `json:"client"`
+	ClientName  string   `json:"client_name"`
+	Task        string   `json:"task"`
+	User        string   `json:"user"`
+}
+
+// parseTogglCSV reads a Toggl Track "Detailed report" CSV export
+func parseTogglCSV(r io.Reader) ([]Entry, error) {
+	t, err := readCSVTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if !t.has("start date") || !t.has("duration") {
+		return nil, fmt.Errorf("not a Toggl detailed report: missing 'Start date' or 'Duration' column")
+	}
+
+	var entries []Entry
+	for i, row := range t.rows {
+		start, err := parseDateTime(t.get(row, "start date"), t.get(row, "start time"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		hours, err := parseClockDuration(t.get(row, "duration"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+
+		e := Entry{
+			Source:      SourceToggl,
+			User:        t.get(row, "email", "user"),
+			Client:      t.get(row, "client"),
+			Project:     t.get(row, "project"),
+			Task:        t.get(row, "task"),
+			Description: t.get(row, "description"),
+			Tags:        splitTags(t.get(row, "tags")),
+			Billable:    parseBool(t.get(row, "billable")),
+			Start:       start,
+			Hours:       hours,
+		}
+		if end, err := parseDateTime(t.get(row, "end date"), t.get(row, "end time")); err == nil {
+			e.End = end
+		}
+		e.ExternalID = externalID(SourceToggl, t.get(row, "id"),
+			e.User, start.Format(time.RFC3339), e.Project, e.Description)
+		finish(&e)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// parseTogglJSON reads a JSON array of Toggl time entries
+func parseTogglJSON(r io.Reader) ([]Entry, error) {
+	var raw []togglEntry
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse Toggl JSON: %w", err)
+	}
+
+	var entries []Entry
+	for _, te := range raw {
+		if te.Duration < 0 {
+			continue // Timer still running
+		}
+		start, err := time.Parse(time.RFC3339, te.Start)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: invalid start: %w", te.ID, err)
+		}
+
+		e := Entry{
+			Source:      SourceToggl,
+			User:        te.User,
+			Client:      cmp.Or(te.ClientName, te.Client),
+			Project:     cmp.Or(te.ProjectName, te.Project),
+			Task:        te.Task,
+			Description: te.Description,
+			Tags:        te.Tags,
+			Billable:    te.Billable,
+			Start:       start,
+		}
+		if stop := cmp.Or(te.Stop, te.End); stop != "" {
+			if end, err := time.Parse(time.RFC3339, stop); err == nil {
+				e.End = end
+			}
+		}
+		switch {
+		case te.Duration > 0:
+			e.Hours = float64(te.Duration) / 3600
+		case te.Dur > 0:
+			e.Hours = float64(te.Dur) / 3600000
+		}
+
+		id := ""
+		if te.ID != 0 {
+			id = strconv.FormatInt(te.ID, 10)
+		}
+		e.ExternalID = externalID(SourceToggl, id, e.User, te.Start, e.Project, e.Description)
+		finish(&e)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -21,11 +21,24 @@ func NewAPIClient(baseURL string) *APIClient {
 	return &APIClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: sourceTransport{http.DefaultTransport},
 		},
 	}
 }
 
+// sourceTransport marks requests as coming from the bot, so the API records
+// the changes they make as Telegram changes in the audit log
+type sourceTransport struct {
+	next http.RoundTripper
+}
+
+func (t sourceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-UNG-Source", "telegram")
+	return t.next.RoundTrip(req)
+}
+
 // Invoice represents an invoice from the API
 type Invoice struct {
 	ID         uint    `json:"id"`
@@ -830,7 +843,7 @@ func (c *APIClient) GetDashboard(token string) (*RevenueProjection, error) {
 type RecurringInvoice struct {
 	ID             uint    `json:"id"`
 	ClientID       uint    `json:"client_id"`
-	CompanyID      uint    `json:"company_id"`
+	CompanyID      *uint   `json:"company_id"`
 	Amount         float64 `json:"amount"`
 	Currency       string  `json:"currency"`
 	Description    string  `json:"description"`